@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"net/http"
+)
+
+// auditEventKey is the request context key used to store the AuditEvent being recorded for a mutating request
+type auditEventKey struct{}
+
+// WithAuditEvent returns a copy of a http request whose context carries the AuditEvent recorded for it
+// The AuditEvent is shared by every copy of the request made afterwards, so handlers can add to it
+func WithAuditEvent(r *http.Request, e *models.AuditEvent) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), auditEventKey{}, e))
+}
+
+// LoadAuditEvent returns the AuditEvent recorded for a http request, or nil when the request is not audited
+func LoadAuditEvent(r *http.Request) *models.AuditEvent {
+	e, _ := r.Context().Value(auditEventKey{}).(*models.AuditEvent)
+	return e
+}
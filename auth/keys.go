@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SigningKey is an asymmetric key pair used to sign and verify tokens, Private is nil for verification only keys
+type SigningKey struct {
+	Id        string
+	Algorithm string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+}
+
+// KeySet stores the key used to sign new tokens along with every key that is still accepted when verifying tokens
+// When no signing key is set, tokens are signed and verified with HS256 using the secret
+type KeySet struct {
+	secret  []byte
+	signing *SigningKey
+	keys    map[string]*SigningKey
+}
+
+// JSONWebKey is the public JWK representation of a SigningKey
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a JWKS document listing the public keys that tokens can be verified with
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+var (
+	keySetMu sync.RWMutex
+	keySet   *KeySet
+)
+
+// NewKeySet initializes a KeySet that signs with the signing key and also verifies with any retired keys
+func NewKeySet(secret []byte, signing *SigningKey, retired ...*SigningKey) *KeySet {
+	ks := &KeySet{secret: secret, signing: signing, keys: make(map[string]*SigningKey)}
+	if signing != nil {
+		ks.keys[signing.Id] = signing
+	}
+	for _, k := range retired {
+		ks.keys[k.Id] = k
+	}
+	return ks
+}
+
+// SetKeySet replaces the KeySet used to sign and verify tokens
+func SetKeySet(ks *KeySet) {
+	keySetMu.Lock()
+	defer keySetMu.Unlock()
+	keySet = ks
+}
+
+// currentKeySet returns the active KeySet, loading it from the environment the first time it is needed
+func currentKeySet() (*KeySet, error) {
+	keySetMu.RLock()
+	ks := keySet
+	keySetMu.RUnlock()
+	if ks != nil {
+		return ks, nil
+	}
+	return InitializeKeys()
+}
+
+// InitializeKeys loads the KeySet from the TOKEN_* environmental variables and makes it the active KeySet
+// TOKEN_SIGNING_KEY is the path to a PEM private key that is used with TOKEN_ALGORITHM (RS256, ES256 or EdDSA),
+// and TOKEN_VERIFICATION_KEYS is a comma separated list of PEM public key paths that are still accepted
+func InitializeKeys() (*KeySet, error) {
+	secret := []byte(os.Getenv("TOKEN_SECRET"))
+	alg := os.Getenv("TOKEN_ALGORITHM")
+	if alg == "" || alg == "HS256" {
+		ks := NewKeySet(secret, nil)
+		SetKeySet(ks)
+		return ks, nil
+	}
+	signing, err := LoadSigningKey(alg, os.Getenv("TOKEN_SIGNING_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	var retired []*SigningKey
+	for _, path := range strings.Split(os.Getenv("TOKEN_VERIFICATION_KEYS"), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		k, err := LoadVerificationKey(path)
+		if err != nil {
+			return nil, err
+		}
+		retired = append(retired, k)
+	}
+	ks := NewKeySet(secret, signing, retired...)
+	SetKeySet(ks)
+	return ks, nil
+}
+
+// LoadSigningKey reads a PEM encoded private key file to be used with a signing algorithm
+func LoadSigningKey(alg string, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid signing key file: " + path)
+	}
+	var private interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported signing key type: " + path)
+	}
+	return NewSigningKey(alg, signer)
+}
+
+// LoadVerificationKey reads a PEM encoded public key file, the algorithm is determined by the key type
+func LoadVerificationKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid verification key file: " + path)
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	var alg string
+	switch public.(type) {
+	case *rsa.PublicKey:
+		alg = "RS256"
+	case *ecdsa.PublicKey:
+		alg = "ES256"
+	case ed25519.PublicKey:
+		alg = "EdDSA"
+	default:
+		return nil, errors.New("unsupported verification key type: " + path)
+	}
+	return newKey(alg, nil, public)
+}
+
+// NewSigningKey initializes a SigningKey from a private key, ensuring the key type matches the algorithm
+func NewSigningKey(alg string, private crypto.Signer) (*SigningKey, error) {
+	return newKey(alg, private, private.Public())
+}
+
+// newKey initializes a SigningKey with a kid derived from its public key
+func newKey(alg string, private crypto.Signer, public crypto.PublicKey) (*SigningKey, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			return nil, errors.New("rsa keys can only be used with RS256")
+		}
+	case *ecdsa.PublicKey:
+		if alg != "ES256" || pub.Curve != elliptic.P256() {
+			return nil, errors.New("ecdsa keys can only be used with ES256 on the P-256 curve")
+		}
+	case ed25519.PublicKey:
+		if alg != "EdDSA" {
+			return nil, errors.New("ed25519 keys can only be used with EdDSA")
+		}
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+	return &SigningKey{
+		Id:        base64.RawURLEncoding.EncodeToString(sum[:12]),
+		Algorithm: alg,
+		Private:   private,
+		Public:    public,
+	}, nil
+}
+
+// verificationKey returns the key that a token with a given kid and alg header must be verified with
+func (ks *KeySet) verificationKey(kid string, alg string) (interface{}, error) {
+	if ks.signing == nil {
+		if alg != "HS256" {
+			return nil, errors.New("unexpected token signing method")
+		}
+		return ks.secret, nil
+	}
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown token key id")
+	}
+	if k.Algorithm != alg {
+		return nil, errors.New("unexpected token signing method")
+	}
+	return k.Public, nil
+}
+
+// JWKS returns the public keys of the KeySet, symmetric secrets are never published
+func (ks *KeySet) JWKS() *JSONWebKeySet {
+	jwks := &JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, k := range ks.keys {
+		jwks.Keys = append(jwks.Keys, k.JWK())
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
+
+// JWK returns the public JSONWebKey of the SigningKey
+func (k *SigningKey) JWK() JSONWebKey {
+	jwk := JSONWebKey{Kid: k.Id, Use: "sig", Alg: k.Algorithm}
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// JWKS returns the public keys of the active KeySet
+func JWKS() (*JSONWebKeySet, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return nil, err
+	}
+	return ks.JWKS(), nil
+}
@@ -0,0 +1,425 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDCProvider is an OpenID Connect identity provider that users can sign in with using the authorization code flow
+// with PKCE. Users without an account are provisioned into GroupId with Role, or into the group that GroupMap maps one
+// of the values of the GroupClaim claim to. No users are provisioned when neither is set
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	GroupId      string
+	Role         string
+	GroupClaim   string
+	GroupMap     map[string]string
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]crypto.PublicKey
+}
+
+// OIDCIdentity is the verified identity of a user signed in with an OIDCProvider
+type OIDCIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	FirstName     string
+	LastName      string
+	Groups        []string
+}
+
+// oidcDiscovery is the subset of a provider's OpenID Connect discovery document that is used
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcTokenResponse is the response of a provider's token endpoint
+type oidcTokenResponse struct {
+	IdToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+const oidcHTTPTimeout = time.Second * 10
+
+var (
+	oidcMu        sync.RWMutex
+	oidcProviders map[string]*OIDCProvider
+	oidcClient    = &http.Client{Timeout: oidcHTTPTimeout}
+)
+
+// SetOIDCProviders replaces the OIDCProviders that users can sign in with
+func SetOIDCProviders(providers ...*OIDCProvider) {
+	m := make(map[string]*OIDCProvider)
+	for _, p := range providers {
+		m[p.Name] = p
+	}
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	oidcProviders = m
+}
+
+// InitializeOIDCProviders loads the OIDCProviders from the OIDC_PROVIDERS environmental variable, a JSON list of providers
+func InitializeOIDCProviders() error {
+	var providers []*OIDCProvider
+	if conf := strings.TrimSpace(os.Getenv("OIDC_PROVIDERS")); conf != "" && conf != "null" {
+		if err := json.Unmarshal([]byte(conf), &providers); err != nil {
+			return errors.New("invalid oidc providers: " + err.Error())
+		}
+	}
+	for _, p := range providers {
+		if p.Name == "" || p.Issuer == "" || p.ClientId == "" || p.RedirectURL == "" {
+			return errors.New("oidc providers require a Name, Issuer, ClientId and RedirectURL")
+		}
+	}
+	SetOIDCProviders(providers...)
+	return nil
+}
+
+// GetOIDCProvider returns the OIDCProvider with an inputted name
+func GetOIDCProvider(name string) (*OIDCProvider, error) {
+	oidcMu.RLock()
+	p, ok := oidcProviders[name]
+	oidcMu.RUnlock()
+	if !ok {
+		return nil, errors.New("unknown identity provider")
+	}
+	return p, nil
+}
+
+// randomString returns a new random base64url encoded string
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewOIDCState returns a new random state, PKCE code verifier and nonce for an authorization request
+func NewOIDCState() (state string, verifier string, nonce string, err error) {
+	if state, err = randomString(); err != nil {
+		return
+	}
+	if verifier, err = randomString(); err != nil {
+		return
+	}
+	nonce, err = randomString()
+	return
+}
+
+// pkceChallenge returns the S256 PKCE code challenge of a code verifier
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// getJSON fetches a JSON document from an url
+func getJSON(u string, v interface{}) error {
+	resp, err := oidcClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("unexpected status fetching " + u + ": " + resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// discover returns the OIDCProvider's discovery document, it is only fetched once
+func (p *OIDCProvider) discover() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	var d oidcDiscovery
+	err := getJSON(strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", &d)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != strings.TrimSuffix(p.Issuer, "/") {
+		return nil, errors.New("oidc discovery issuer does not match the configured issuer")
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document is missing required endpoints")
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// AuthCodeURL returns the url that a user is redirected to in order to sign in with the OIDCProvider
+func (p *OIDCProvider) AuthCodeURL(state string, verifier string, nonce string) (string, error) {
+	d, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.ClientId)
+	q.Set("redirect_uri", p.RedirectURL)
+	q.Set("scope", strings.Join(scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", pkceChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems an authorization code with its PKCE code verifier and returns the verified identity of its ID token
+func (p *OIDCProvider) Exchange(code string, verifier string, nonce string) (*OIDCIdentity, error) {
+	if code == "" {
+		return nil, errors.New("missing authorization code")
+	}
+	d, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("client_id", p.ClientId)
+	form.Set("code_verifier", verifier)
+	req, err := http.NewRequest("POST", d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientId), url.QueryEscape(p.ClientSecret))
+	}
+	resp, err := oidcClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var tr oidcTokenResponse
+	if err = json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return nil, errors.New("invalid oidc token response")
+	}
+	if tr.Error != "" {
+		return nil, errors.New("oidc token error: " + tr.Error + " " + tr.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || tr.IdToken == "" {
+		return nil, errors.New("oidc token response is missing an id_token")
+	}
+	return p.VerifyIDToken(tr.IdToken, nonce)
+}
+
+// VerifyIDToken verifies the signature, issuer, audience, expiration and nonce of an ID token and returns its identity
+func (p *OIDCProvider) VerifyIDToken(idToken string, nonce string) (*OIDCIdentity, error) {
+	d, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.Alg() {
+		case "RS256", "ES256":
+		default:
+			return nil, errors.New("unexpected id token signing method")
+		}
+		kid, _ := token.Header["kid"].(string)
+		return p.verificationKey(d, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid id token")
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if iss, _ := claims["iss"].(string); iss != d.Issuer {
+		return nil, errors.New("id token was issued by another issuer")
+	}
+	if !claimContains(claims["aud"], p.ClientId) {
+		return nil, errors.New("id token was issued to another client")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("id token is missing an expiration")
+	}
+	if n, _ := claims["nonce"].(string); nonce == "" || n != nonce {
+		return nil, errors.New("id token nonce does not match")
+	}
+	identity := &OIDCIdentity{Provider: p.Name}
+	identity.Subject, _ = claims["sub"].(string)
+	if identity.Subject == "" {
+		return nil, errors.New("id token is missing a subject")
+	}
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+	identity.Username, _ = claims["preferred_username"].(string)
+	identity.FirstName, _ = claims["given_name"].(string)
+	identity.LastName, _ = claims["family_name"].(string)
+	if p.GroupClaim != "" {
+		identity.Groups = claimValues(claims[p.GroupClaim])
+	}
+	return identity, nil
+}
+
+// MapGroup returns the id of the group that users signing in with an OIDCIdentity are provisioned into
+func (p *OIDCProvider) MapGroup(identity *OIDCIdentity) string {
+	for _, g := range identity.Groups {
+		if groupId, ok := p.GroupMap[g]; ok {
+			return groupId
+		}
+	}
+	return p.GroupId
+}
+
+// verificationKey returns the provider's public key with a kid, the JWKS is fetched again when the kid is unknown
+func (p *OIDCProvider) verificationKey(d *oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+	var jwks JSONWebKeySet
+	if err := getJSON(d.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if k, err := jwk.PublicKey(); err == nil {
+			keys[jwk.Kid] = k
+		}
+	}
+	p.keys = keys
+	k, ok := p.keys[kid]
+	if !ok && kid == "" && len(p.keys) == 1 { // a provider with a single key does not have to set a kid
+		for _, only := range p.keys {
+			return only, nil
+		}
+	}
+	if !ok {
+		return nil, errors.New("unknown id token key id")
+	}
+	return k, nil
+}
+
+// PublicKey returns the RSA or P-256 ECDSA public key of a JSONWebKey
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, errors.New("unsupported key curve")
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, errors.New("unsupported key type")
+}
+
+// claimValues returns the values of a claim that is either a string or a list of strings
+func claimValues(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// claimContains determines whether a string or list of strings claim contains a value
+func claimContains(claim interface{}, value string) bool {
+	for _, v := range claimValues(claim) {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateOIDCStateToken is used to create a short-lived token that carries the state, PKCE code verifier and nonce
+// of an authorization request to its callback
+func CreateOIDCStateToken(provider string, state string, verifier string, nonce string, exp int64) (string, error) {
+	return signClaims(jwt.MapClaims{
+		"typ":      "oidc",
+		"provider": provider,
+		"state":    state,
+		"verifier": verifier,
+		"nonce":    nonce,
+		"exp":      exp,
+	})
+}
+
+// DecodeOIDCStateToken is used to decode an OIDC state token, its provider and state must match the callback request
+func DecodeOIDCStateToken(curToken string, provider string, state string) (verifier string, nonce string, err error) {
+	tokenClaims, err := parseClaims(curToken)
+	if err != nil {
+		return "", "", err
+	}
+	typ, _ := tokenClaims["typ"].(string)
+	p, _ := tokenClaims["provider"].(string)
+	s, _ := tokenClaims["state"].(string)
+	if typ != "oidc" || p != provider || state == "" || s != state {
+		return "", "", errors.New("invalid oidc state")
+	}
+	verifier, _ = tokenClaims["verifier"].(string)
+	nonce, _ = tokenClaims["nonce"].(string)
+	return verifier, nonce, nil
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// testIdP is a stub OpenID Connect identity provider that issues an ID token for a single authorization code
+type testIdP struct {
+	server    *httptest.Server
+	key       *SigningKey
+	code      string
+	challenge string
+	claims    jwt.MapClaims
+}
+
+// newTestIdP starts a testIdP serving its discovery document, JWKS and token endpoint
+func newTestIdP(t *testing.T) *testIdP {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	key, err := NewSigningKey("RS256", private)
+	if err != nil {
+		t.Fatalf("NewSigningKey() error = %v", err)
+	}
+	idp := &testIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(NewKeySet(nil, idp.key).JWKS())
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("code") != idp.code || pkceChallenge(r.PostForm.Get("code_verifier")) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(t, idp.claims), "token_type": "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// sign signs a set of ID token claims with the testIdP's key
+func (idp *testIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = idp.key.Id
+	signed, err := token.SignedString(idp.key.Private)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return signed
+}
+
+// validClaims returns the claims of a valid ID token issued by the testIdP
+func (idp *testIdP) validClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            "api",
+		"sub":            "subject-1",
+		"email":          "sso@test.com",
+		"email_verified": true,
+		"groups":         []string{"engineering"},
+		"nonce":          nonce,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func Test_oidcVerifyIDToken(t *testing.T) {
+	idp := newTestIdP(t)
+	provider := &OIDCProvider{
+		Name:        "test",
+		Issuer:      idp.server.URL,
+		ClientId:    "api",
+		RedirectURL: "http://localhost/auth/oidc/test/callback",
+		GroupId:     "000000000000000000000002",
+		GroupClaim:  "groups",
+		GroupMap:    map[string]string{"engineering": "000000000000000000000003"},
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string                            // The name of the test
+		token   func(claims jwt.MapClaims) string // signs the ID token of the test
+		modify  func(claims jwt.MapClaims)        // changes the valid claims of the test
+		wantErr bool                              // whether we want an error
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"success", nil, func(claims jwt.MapClaims) {}, false},
+		{"audience list", nil, func(claims jwt.MapClaims) { claims["aud"] = []string{"other", "api"} }, false},
+		{"wrong audience", nil, func(claims jwt.MapClaims) { claims["aud"] = "other" }, true},
+		{"wrong issuer", nil, func(claims jwt.MapClaims) { claims["iss"] = "https://evil.test" }, true},
+		{"wrong nonce", nil, func(claims jwt.MapClaims) { claims["nonce"] = "replayed" }, true},
+		{"expired", nil, func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }, true},
+		{"missing subject", nil, func(claims jwt.MapClaims) { delete(claims, "sub") }, true},
+		{
+			"unknown key",
+			func(claims jwt.MapClaims) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+				token.Header["kid"] = idp.key.Id
+				signed, _ := token.SignedString(other)
+				return signed
+			},
+			func(claims jwt.MapClaims) {},
+			true,
+		},
+		{
+			"symmetric signature",
+			func(claims jwt.MapClaims) string {
+				signed, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("api"))
+				return signed
+			},
+			func(claims jwt.MapClaims) {},
+			true,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.validClaims("nonce-1")
+			tt.modify(claims)
+			var token string
+			if tt.token != nil {
+				token = tt.token(claims)
+			} else {
+				token = idp.sign(t, claims)
+			}
+			got, err := provider.VerifyIDToken(token, "nonce-1")
+			if (err != nil) != tt.wantErr { // Asserting whether we get the correct wanted value
+				t.Fatalf("OIDCProvider.VerifyIDToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Subject != "subject-1" || got.Email != "sso@test.com" || !got.EmailVerified {
+				t.Errorf("OIDCProvider.VerifyIDToken() = %+v", got)
+			}
+			if groupId := provider.MapGroup(got); groupId != "000000000000000000000003" {
+				t.Errorf("OIDCProvider.MapGroup() = %v, want %v", groupId, "000000000000000000000003")
+			}
+		})
+	}
+}
+
+func Test_oidcExchange(t *testing.T) {
+	idp := newTestIdP(t)
+	provider := &OIDCProvider{Name: "test", Issuer: idp.server.URL, ClientId: "api", ClientSecret: "secret", RedirectURL: "http://localhost/cb"}
+	state, verifier, nonce, err := NewOIDCState()
+	if err != nil {
+		t.Fatalf("NewOIDCState() error = %v", err)
+	}
+	authURL, err := provider.AuthCodeURL(state, verifier, nonce)
+	if err != nil {
+		t.Fatalf("OIDCProvider.AuthCodeURL() error = %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	q := u.Query()
+	if q.Get("state") != state || q.Get("nonce") != nonce || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("OIDCProvider.AuthCodeURL() = %v", authURL)
+	}
+	idp.code = "code-1"
+	idp.challenge = q.Get("code_challenge")
+	idp.claims = idp.validClaims(nonce)
+	// The code can not be redeemed without the code verifier that its challenge was derived from
+	if _, err = provider.Exchange(idp.code, "wrong-verifier", nonce); err == nil {
+		t.Errorf("OIDCProvider.Exchange() accepted a wrong code verifier")
+	}
+	got, err := provider.Exchange(idp.code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("OIDCProvider.Exchange() error = %v", err)
+	}
+	if got.Subject != "subject-1" {
+		t.Errorf("OIDCProvider.Exchange() subject = %v, want %v", got.Subject, "subject-1")
+	}
+}
+
+func Test_oidcStateToken(t *testing.T) {
+	SetKeySet(NewKeySet([]byte("TESTINGSALT"), nil))
+	token, err := CreateOIDCStateToken("test", "state-1", "verifier-1", "nonce-1", time.Now().Add(time.Minute).Unix())
+	if err != nil {
+		t.Fatalf("CreateOIDCStateToken() error = %v", err)
+	}
+	verifier, nonce, err := DecodeOIDCStateToken(token, "test", "state-1")
+	if err != nil || verifier != "verifier-1" || nonce != "nonce-1" {
+		t.Errorf("DecodeOIDCStateToken() = %v, %v, %v", verifier, nonce, err)
+	}
+	if _, _, err = DecodeOIDCStateToken(token, "test", "state-2"); err == nil {
+		t.Errorf("DecodeOIDCStateToken() accepted a mismatched state")
+	}
+	if _, _, err = DecodeOIDCStateToken(token, "other", "state-1"); err == nil {
+		t.Errorf("DecodeOIDCStateToken() accepted another provider")
+	}
+	// State tokens can not be used as a session
+	if _, err = DecodeJWT(token); err == nil {
+		t.Errorf("DecodeJWT() accepted an oidc state token")
+	}
+}
@@ -1,22 +1,28 @@
 package auth
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
 	"github.com/dgrijalva/jwt-go"
 	"net/http"
-	"os"
 )
 
 // TokenData stores the structured data from a session token for use
+// GroupId is the group the session was issued for, which is either the User's own group or a group it is a member of
+// Permissions and Groups are not part of the token, they are resolved from the User's memberships when a request is verified
 type TokenData struct {
-	UserId    string
-	Role      string
-	RootAdmin bool
-	GroupId   string
+	UserId      string
+	Role        string
+	RootAdmin   bool
+	GroupId     string
+	Permissions []string
+	Groups      []string
 }
 
+// tokenDataKey is the request context key used to store TokenData that was already verified by a middleware
+type tokenDataKey struct{}
+
 // InitUserToken inputs a pointer to a user and returns TokenData
 func InitUserToken(u *models.User) (*TokenData, error) {
 	err := u.Validate("auth")
@@ -34,13 +40,32 @@ func InitUserToken(u *models.User) (*TokenData, error) {
 // ToUser creates a new User struct using the TokenData and returns a pointer to it
 func (t *TokenData) ToUser() *models.User {
 	return &models.User{
-		Id:        t.UserId,
-		Role:      t.Role,
-		RootAdmin: t.RootAdmin,
-		GroupId:   t.GroupId,
+		Id:          t.UserId,
+		Role:        t.Role,
+		RootAdmin:   t.RootAdmin,
+		GroupId:     t.GroupId,
+		Permissions: t.Permissions,
 	}
 }
 
+// HasPermission determines whether the TokenData's User is granted a permission
+func (t *TokenData) HasPermission(permission string) bool {
+	return t.ToUser().HasPermission(permission)
+}
+
+// IsMember determines whether the TokenData's User belongs to a group, either as its own group or through a membership
+func (t *TokenData) IsMember(groupId string) bool {
+	if t.RootAdmin || t.GroupId == groupId {
+		return true
+	}
+	for _, g := range t.Groups {
+		if g == groupId {
+			return true
+		}
+	}
+	return false
+}
+
 // GetGroupsScope returns a scoped Group ID filter based on token User role
 func (t *TokenData) GetGroupsScope() *models.Group {
 	g := models.Group{Id: t.GroupId}
@@ -52,11 +77,11 @@ func (t *TokenData) GetGroupsScope() *models.Group {
 
 // GetUsersScope returns a scoped User ID filter based on token User role
 func (t *TokenData) GetUsersScope(scopeType string) *models.User {
-	g := models.User{Id: t.UserId, GroupId: t.GroupId, RootAdmin: t.RootAdmin, Role: t.Role}
+	g := models.User{Id: t.UserId, GroupId: t.GroupId, RootAdmin: t.RootAdmin, Role: t.Role, Permissions: t.Permissions}
 	if t.RootAdmin {
 		g.Id = ""
 		g.GroupId = ""
-	} else if t.Role == "admin" && scopeType == "create" || scopeType == "update" {
+	} else if t.HasPermission(models.PermUsersCreate) && scopeType == "create" || scopeType == "update" {
 		g.Id = ""
 		g.GroupId = t.GroupId
 	} else if scopeType == "find" {
@@ -75,7 +100,7 @@ func (t *TokenData) AdminRouteRoleCheck() string {
 	return groupId
 }
 
-// CreateToken is used to create a new session JWT token
+// CreateToken is used to create a new session JWT token, signed by the signing key of the active KeySet
 func (t *TokenData) CreateToken(exp int64) (string, error) {
 	if t.UserId == "" || t.GroupId == "" || t.Role == "" {
 		return "", errors.New("missing required token claims")
@@ -83,48 +108,116 @@ func (t *TokenData) CreateToken(exp int64) (string, error) {
 	if exp == 0 {
 		return "", errors.New("new token must have a expiration time greater than 0")
 	}
-	var MySigningKey = []byte(os.Getenv("TOKEN_SECRET"))
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["id"] = t.UserId
-	claims["role"] = t.Role
-	claims["root"] = t.RootAdmin
-	claims["group_id"] = t.GroupId
-	claims["exp"] = exp
-	return token.SignedString(MySigningKey)
+	return signClaims(jwt.MapClaims{
+		"id":       t.UserId,
+		"role":     t.Role,
+		"root":     t.RootAdmin,
+		"group_id": t.GroupId,
+		"exp":      exp,
+	})
 }
 
-// DecodeJWT is used to decode a JWT token
-func DecodeJWT(curToken string) (*TokenData, error) {
-	var tokenData TokenData
+// CreateChallengeToken is used to create a short-lived token that a User exchanges for a session by completing 2FA
+// Each challenge token carries a random jti, so invalidating one challenge does not affect the next
+func CreateChallengeToken(userId string, exp int64) (string, error) {
+	if userId == "" {
+		return "", errors.New("missing required token claims")
+	}
+	jti, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	return signClaims(jwt.MapClaims{"id": userId, "typ": "2fa", "jti": jti, "exp": exp})
+}
+
+// signClaims signs a set of claims with the signing key of the active KeySet
+func signClaims(claims jwt.MapClaims) (string, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if ks.signing != nil {
+		token = jwt.NewWithClaims(jwt.GetSigningMethod(ks.signing.Algorithm), claims)
+		token.Header["kid"] = ks.signing.Id
+		return token.SignedString(ks.signing.Private)
+	}
+	return token.SignedString(ks.secret)
+}
+
+// parseClaims verifies a token using the key matching its kid header and returns its claims
+func parseClaims(curToken string) (jwt.MapClaims, error) {
 	if curToken == "" {
-		return &tokenData, errors.New("unauthorized")
+		return nil, errors.New("unauthorized")
+	}
+	ks, err := currentKeySet()
+	if err != nil {
+		return nil, err
 	}
-	var MySigningKey = []byte(os.Getenv("TOKEN_SECRET"))
-	// Decode token
 	token, err := jwt.Parse(curToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("error")
-		}
-		return []byte(MySigningKey), nil
+		kid, _ := token.Header["kid"].(string)
+		return ks.verificationKey(kid, token.Method.Alg())
 	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return token.Claims.(jwt.MapClaims), nil
+}
+
+// DecodeJWT is used to decode a JWT token
+func DecodeJWT(curToken string) (*TokenData, error) {
+	var tokenData TokenData
+	tokenClaims, err := parseClaims(curToken)
 	if err != nil {
 		return &tokenData, err
 	}
+	// Challenge tokens can not be used as a session
+	if _, ok := tokenClaims["typ"]; ok {
+		return &tokenData, errors.New("invalid token")
+	}
 	// Determine user based on token
-	if token.Valid {
-		tokenClaims := token.Claims.(jwt.MapClaims)
-		tokenData.UserId = tokenClaims["id"].(string)
-		tokenData.Role = tokenClaims["role"].(string)
-		tokenData.RootAdmin = tokenClaims["root"].(bool)
-		tokenData.GroupId = tokenClaims["group_id"].(string)
-		return &tokenData, nil
+	tokenData.UserId, _ = tokenClaims["id"].(string)
+	tokenData.Role, _ = tokenClaims["role"].(string)
+	tokenData.RootAdmin, _ = tokenClaims["root"].(bool)
+	tokenData.GroupId, _ = tokenClaims["group_id"].(string)
+	return &tokenData, nil
+}
+
+// DecodeChallengeToken is used to decode a 2FA challenge token and returns the id of the User it was issued to
+func DecodeChallengeToken(curToken string) (string, error) {
+	tokenClaims, err := parseClaims(curToken)
+	if err != nil {
+		return "", err
+	}
+	userId, _ := tokenClaims["id"].(string)
+	if typ, _ := tokenClaims["typ"].(string); typ != "2fa" || userId == "" {
+		return "", errors.New("invalid challenge token")
+	}
+	return userId, nil
+}
+
+// WithTokenData returns a copy of a http request whose context carries verified TokenData
+// The TokenData's User is recorded as the actor of the request's AuditEvent, along with its Group when the event has
+// no group of its own yet
+func WithTokenData(r *http.Request, tokenData *TokenData) *http.Request {
+	if e := LoadAuditEvent(r); e != nil {
+		e.ActorId = tokenData.UserId
+		if e.GroupId == "" {
+			e.GroupId = tokenData.GroupId
+		}
 	}
-	return &tokenData, errors.New("invalid token")
+	return r.WithContext(context.WithValue(r.Context(), tokenDataKey{}, tokenData))
 }
 
 // LoadTokenFromRequest inputs a http request and returns decrypted TokenData or an error
+// TokenData stored in the request context, such as from an API-Key, takes precedence over the Auth-Token header
 func LoadTokenFromRequest(r *http.Request) (*TokenData, error) {
+	if tokenData, ok := r.Context().Value(tokenDataKey{}).(*TokenData); ok {
+		return tokenData, nil
+	}
 	authToken := r.Header.Get("Auth-Token")
 	tokenData, err := DecodeJWT(authToken)
 	if err != nil {
@@ -133,7 +226,8 @@ func LoadTokenFromRequest(r *http.Request) (*TokenData, error) {
 	return tokenData, nil
 }
 
-// VerifyGroupRequestScope inputs a Group http request and returns decrypted TokenData or an error
+// VerifyGroupRequestScope inputs a Group http request and returns the group id when it is the group of the session
+// Groups the User is only a member of must be switched to before they can be managed
 func VerifyGroupRequestScope(r *http.Request, groupId string) (string, error) {
 	tokenData, err := LoadTokenFromRequest(r)
 	if err != nil {
@@ -145,6 +239,18 @@ func VerifyGroupRequestScope(r *http.Request, groupId string) (string, error) {
 	return "", errors.New("unauthorized")
 }
 
+// VerifyGroupMemberScope inputs a Group http request and returns the group id when the User is a member of the group
+func VerifyGroupMemberScope(r *http.Request, groupId string) (string, error) {
+	tokenData, err := LoadTokenFromRequest(r)
+	if err != nil {
+		return "", err
+	}
+	if tokenData.IsMember(groupId) {
+		return groupId, nil
+	}
+	return "", errors.New("unauthorized")
+}
+
 // VerifyUserRequestScope inputs User http request and returns decrypted TokenData or an error
 func VerifyUserRequestScope(r *http.Request, userId string, scopeType string) (*models.User, error) {
 	tokenData, err := LoadTokenFromRequest(r)
@@ -152,7 +258,7 @@ func VerifyUserRequestScope(r *http.Request, userId string, scopeType string) (*
 		return nil, err
 	}
 	userScope := tokenData.GetUsersScope(scopeType)
-	if tokenData.RootAdmin || tokenData.Role == "admin" { // default scope ok if user is a root admin or group admin
+	if tokenData.HasPermission(models.PermUsersUpdateAny) { // default scope ok if user can manage the users of its group
 		userScope.Id = userId
 		return userScope, nil
 	}
@@ -165,6 +271,21 @@ func VerifyUserRequestScope(r *http.Request, userId string, scopeType string) (*
 	return nil, errors.New("unauthorized")
 }
 
+// VerifyUserPermissionScope inputs a User http request and returns the scope of a user management action that
+// requires a permission, such as deleting a User
+func VerifyUserPermissionScope(r *http.Request, userId string, permission string) (*models.User, error) {
+	tokenData, err := LoadTokenFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	if !tokenData.HasPermission(permission) {
+		return nil, errors.New("unauthorized")
+	}
+	userScope := tokenData.GetUsersScope("update")
+	userScope.Id = userId
+	return userScope, nil
+}
+
 // VerifyRequestScope inputs generic http requests and returns decrypted TokenData or an error
 func VerifyRequestScope(r *http.Request, scopeType string) (*models.User, error) {
 	tokenData, err := LoadTokenFromRequest(r)
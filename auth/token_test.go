@@ -1,6 +1,12 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
 	"reflect"
 	"testing"
@@ -134,3 +140,129 @@ func Test_decodeToken(t *testing.T) {
 		})
 	}
 }
+
+// newTestSigningKey generates a new SigningKey for an algorithm
+func newTestSigningKey(t *testing.T, alg string) *SigningKey {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	k, err := NewSigningKey(alg, private)
+	if err != nil {
+		t.Fatalf("NewSigningKey() error = %v", err)
+	}
+	return k
+}
+
+func Test_asymmetricToken(t *testing.T) {
+	defer SetKeySet(nil)
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name string // The name of the test
+		alg  string // The signing algorithm used for this test
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"rsa", "RS256"},
+		{"ecdsa", "ES256"},
+		{"ed25519", "EdDSA"},
+	}
+	want := &TokenData{UserId: "000000000000000000000001", GroupId: "000000000000000000000011", Role: "member", RootAdmin: false}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newTestSigningKey(t, tt.alg)
+			SetKeySet(NewKeySet(nil, k))
+			testToken, err := want.CreateToken(time.Now().Add(time.Hour * 1).Unix())
+			if err != nil {
+				t.Fatalf("TokenData.CreateToken() error = %v", err)
+			}
+			got, err := DecodeJWT(testToken)
+			if err != nil {
+				t.Errorf("DecodeJWT() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, want) { // Asserting whether we get the correct wanted value
+				t.Errorf("DecodeJWT() = %v, want %v", got, want)
+			}
+			jwks, _ := JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != k.Id || jwks.Keys[0].Alg != tt.alg {
+				t.Errorf("JWKS() = %v, want the %v key", jwks, k.Id)
+			}
+		})
+	}
+}
+
+func Test_keyRotation(t *testing.T) {
+	defer SetKeySet(nil)
+	oldKey := newTestSigningKey(t, "RS256")
+	newKey := newTestSigningKey(t, "ES256")
+	tokenData := &TokenData{UserId: "000000000000000000000001", GroupId: "000000000000000000000011", Role: "member", RootAdmin: false}
+	SetKeySet(NewKeySet(nil, oldKey))
+	oldToken, _ := tokenData.CreateToken(time.Now().Add(time.Hour * 1).Unix())
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string  // The name of the test
+		wantErr bool    // whether we want an error.
+		keySet  *KeySet // The active KeySet when decoding a token signed by the old key
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"retired key still verifies",
+			false,
+			NewKeySet(nil, newKey, &SigningKey{Id: oldKey.Id, Algorithm: oldKey.Algorithm, Public: oldKey.Public}),
+		},
+		{
+			"removed key",
+			true,
+			NewKeySet(nil, newKey),
+		},
+		{
+			"symmetric secret",
+			true,
+			NewKeySet([]byte("SECRET"), nil),
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetKeySet(tt.keySet)
+			_, err := DecodeJWT(oldToken)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeJWT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_challengeToken(t *testing.T) {
+	challengeToken, err := CreateChallengeToken("000000000000000000000001", time.Now().Add(time.Minute).Unix())
+	if err != nil {
+		t.Fatalf("CreateChallengeToken() error = %v", err)
+	}
+	userId, err := DecodeChallengeToken(challengeToken)
+	if err != nil || userId != "000000000000000000000001" {
+		t.Errorf("DecodeChallengeToken() = %v, %v, want 000000000000000000000001", userId, err)
+	}
+	// Challenge tokens issued at the same time are still distinct
+	if next, _ := CreateChallengeToken("000000000000000000000001", time.Now().Add(time.Minute).Unix()); next == challengeToken {
+		t.Errorf("CreateChallengeToken() issued the same token twice")
+	}
+	// A challenge token can not be used as a session token and vice versa
+	if _, err = DecodeJWT(challengeToken); err == nil {
+		t.Errorf("DecodeJWT() accepted a challenge token")
+	}
+	sessionToken, _ := (&TokenData{UserId: "000000000000000000000001", GroupId: "000000000000000000000011", Role: "member"}).CreateToken(time.Now().Add(time.Minute).Unix())
+	if _, err = DecodeChallengeToken(sessionToken); err == nil {
+		t.Errorf("DecodeChallengeToken() accepted a session token")
+	}
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func Test_TOTPCode(t *testing.T) {
+	// RFC 6238 SHA1 test vectors, truncated to 6 digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name string    // The name of the test
+		at   time.Time // The time the code is generated at
+		want string    // What out instance we want our function to return.
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"59", time.Unix(59, 0), "287082"},
+		{"1111111109", time.Unix(1111111109, 0), "081804"},
+		{"1234567890", time.Unix(1234567890, 0), "005924"},
+		{"2000000000", time.Unix(2000000000, 0), "279037"},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TOTPCode(secret, tt.at)
+			if err != nil {
+				t.Errorf("TOTPCode() error = %v", err)
+				return
+			}
+			if got != tt.want { // Asserting whether we get the correct wanted value
+				t.Errorf("TOTPCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_VerifyTOTP(t *testing.T) {
+	secret, _ := GenerateTOTPSecret()
+	now := time.Now()
+	code, _ := TOTPCode(secret, now)
+	skewed, _ := TOTPCode(secret, now.Add(-time.Second*totpPeriod))
+	stale, _ := TOTPCode(secret, now.Add(-time.Second*totpPeriod*3))
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string // The name of the test
+		code     string // The code being verified
+		lastStep int64  // The last time step that a code was used at
+		want     bool   // What out instance we want our function to return.
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"current code", code, 0, true},
+		{"previous period", skewed, 0, true},
+		{"stale code", stale, 0, false},
+		{"replayed code", code, totpStep(now), false},
+		{"wrong code", "abcdef", 0, false},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := VerifyTOTP(secret, tt.code, now, tt.lastStep); got != tt.want { // Asserting whether we get the correct wanted value
+				t.Errorf("VerifyTOTP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,20 +2,25 @@ package cmd
 
 import (
 	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
 	"github.com/JECSand/go-rest-api-boilerplate/database"
+	"github.com/JECSand/go-rest-api-boilerplate/mail"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
 	"github.com/JECSand/go-rest-api-boilerplate/server"
 	"github.com/JECSand/go-rest-api-boilerplate/services"
 	"github.com/JECSand/go-rest-api-boilerplate/utilities"
 	"go.mongodb.org/mongo-driver/bson"
+	"log"
 	"os"
 	"time"
 )
 
 // App is the highest level struct of the rest_api application. Stores the server, client, and config settings.
 type App struct {
-	server *server.Server
-	db     database.DBClient
+	server     *server.Server
+	db         database.DBClient
+	scheduler  *services.TaskScheduler
+	dispatcher *services.WebhookDispatcher
 }
 
 // Initialize is a function used to initialize a new instantiation of the API Application
@@ -29,6 +34,14 @@ func (a *App) Initialize() error {
 		}
 		conf.InitializeEnvironmentalVars()
 	}
+	_, err = auth.InitializeKeys()
+	if err != nil {
+		return err
+	}
+	err = auth.InitializeOIDCProviders()
+	if err != nil {
+		return err
+	}
 	// 2) Initialize & Connect DB Client
 	a.db, err = database.InitializeNewClient()
 	if err != nil {
@@ -44,12 +57,50 @@ func (a *App) Initialize() error {
 	blHandler := a.db.NewBlacklistHandler()
 	tHandler := a.db.NewTaskHandler()
 	fHandler := a.db.NewFileHandler()
+	rtHandler := a.db.NewRefreshTokenHandler()
+	kHandler := a.db.NewAPIKeyHandler()
+	utHandler := a.db.NewUserTokenHandler()
+	laHandler := a.db.NewLoginAttemptHandler()
+	roHandler := a.db.NewRoleHandler()
+	mHandler := a.db.NewMembershipHandler()
+	iHandler := a.db.NewInvitationHandler()
+	idHandler := a.db.NewIdentityHandler()
+	thHandler := a.db.NewTaskHistoryHandler()
+	cHandler := a.db.NewCommentHandler()
+	tsHandler := a.db.NewTaskSeriesHandler()
+	ftHandler := a.db.NewFeedTokenHandler()
+	auHandler := a.db.NewAuditEventHandler()
+	whHandler := a.db.NewWebhookHandler()
+	wdHandler := a.db.NewWebhookDeliveryHandler()
 	gService := database.NewGroupService(a.db, gHandler)
 	uService := database.NewUserService(a.db, uHandler, gHandler)
 	bService := database.NewBlacklistService(a.db, blHandler)
-	tService := services.NewTokenService(uService, gService, bService)
-	ttService := database.NewTaskService(a.db, tHandler, uHandler, gHandler)
-	fService := database.NewFileService(a.db, fHandler, uHandler, gHandler)
+	rtService := database.NewRefreshTokenService(a.db, rtHandler)
+	kService := database.NewAPIKeyService(a.db, kHandler)
+	utService := database.NewUserTokenService(a.db, utHandler)
+	laService := database.NewLoginAttemptService(a.db, laHandler)
+	roService := database.NewRoleService(a.db, roHandler)
+	mService := database.NewMembershipService(a.db, mHandler)
+	iService := database.NewInvitationService(a.db, iHandler)
+	idService := database.NewIdentityService(a.db, idHandler)
+	tService := services.NewTokenService(uService, gService, bService, rtService, kService, utService, laService, roService, mService, iService, idService, mail.NewMailer())
+	ttService := database.NewTaskService(a.db, tHandler, uHandler, gHandler, thHandler, mHandler, tsHandler)
+	fService := database.NewFileService(a.db, fHandler, uHandler, gHandler, tHandler)
+	cService := database.NewCommentService(a.db, cHandler, tHandler, uHandler, mHandler)
+	acService := database.NewActivityService(a.db, tHandler, thHandler, cHandler)
+	tsService := database.NewTaskSeriesService(a.db, tsHandler, tHandler, gHandler)
+	ftService := database.NewFeedTokenService(a.db, ftHandler)
+	seService := database.NewSearchService(a.db, tHandler, uHandler, gHandler)
+	auService := database.NewAuditService(a.db, auHandler)
+	whService := database.NewWebhookService(a.db, whHandler, wdHandler)
+	a.db.Subscribe(func(e *models.Event) {
+		if _, err := whService.WebhookEnqueue(context.Background(), e); err != nil {
+			log.Println("webhook error:", err)
+		}
+	})
+	csService := database.NewCascadeService(a.db, gHandler, uHandler, tHandler, fHandler, mHandler, kHandler, rtHandler)
+	broker := services.NewMemoryEventBroker(0)
+	a.db.Subscribe(broker.Publish)
 	// 4) Create RootAdmin user if database is empty
 	var group models.Group
 	var adminUser models.User
@@ -63,7 +114,7 @@ func (a *App) Initialize() error {
 	if docCount == 0 {
 		group.RootAdmin = true
 		group.Id = utilities.GenerateObjectID()
-		adminGroup, err := gService.GroupCreate(&group)
+		adminGroup, err := gService.GroupCreate(ctx, &group)
 		if err != nil {
 			return err
 		}
@@ -73,18 +124,24 @@ func (a *App) Initialize() error {
 		adminUser.FirstName = "root"
 		adminUser.LastName = "admin"
 		adminUser.GroupId = adminGroup.Id
-		_, err = uService.UserCreate(&adminUser)
+		_, err = uService.UserCreate(ctx, &adminUser)
 		if err != nil {
 			return err
 		}
 	}
 	// 5) Initialize Server
-	a.server = server.NewServer(uService, gService, ttService, fService, tService)
+	a.server = server.NewServer(uService, gService, ttService, fService, roService, mService, iService, cService, acService, tsService, ftService, seService, auService, whService, broker, csService, tService)
+	a.scheduler = services.NewTaskScheduler(tsService, services.SystemClock, services.SchedulerInterval())
+	a.dispatcher = services.NewWebhookDispatcher(whService, services.NewWebhookClient(), services.SystemClock, services.WebhookInterval())
 	return nil
 }
 
 // Run is a function used to run a previously initialized API Application
 func (a *App) Run() {
 	defer a.db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.scheduler.Start(ctx)
+	go a.dispatcher.Start(ctx)
 	a.server.Start()
 }
@@ -1,10 +1,23 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/websocket"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 var ta App
@@ -117,6 +130,569 @@ func TestUpdatePassword(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, testResponseOKAuth.Code)
 }
 
+// TestPasswordReset Test
+func TestPasswordReset(t *testing.T) {
+	// Test Setup
+	setup()
+	defer os.Remove(os.Getenv("MAIL_FILE"))
+	createTestGroup(ta, 1)
+	user := createTestUser(ta, 1)
+	// Unknown emails are accepted without sending anything
+	reqUnknown, err := http.NewRequest("POST", "/auth/password/forgot", bytes.NewBuffer([]byte(`{"email":"nobody@test.com"}`)))
+	if err != nil {
+		t.Errorf("TestPasswordReset() error = %v", err)
+	}
+	reqUnknown.Header.Add("Content-Type", "application/json")
+	unknownTestResponse := executeRequest(ta, reqUnknown)
+	checkResponseCode(t, http.StatusAccepted, unknownTestResponse.Code)
+	reqForgot, err := http.NewRequest("POST", "/auth/password/forgot", bytes.NewBuffer([]byte(`{"email":"`+user.Email+`"}`)))
+	if err != nil {
+		t.Errorf("TestPasswordReset() error = %v", err)
+	}
+	reqForgot.Header.Add("Content-Type", "application/json")
+	forgotTestResponse := executeRequest(ta, reqForgot)
+	checkResponseCode(t, http.StatusAccepted, forgotTestResponse.Code)
+	token := lastMailToken(user.Email)
+	if token == "" {
+		t.Fatalf("TestPasswordReset() no password reset email was sent")
+	}
+	resetPassword := func(token string) int {
+		payload := []byte(`{"token":"` + token + `","new_password":"789test124"}`)
+		req, err := http.NewRequest("POST", "/auth/password/reset", bytes.NewBuffer(payload))
+		if err != nil {
+			t.Errorf("TestPasswordReset() error = %v", err)
+		}
+		req.Header.Add("Content-Type", "application/json")
+		return executeRequest(ta, req).Code
+	}
+	checkResponseCode(t, http.StatusUnauthorized, resetPassword("invalid"))
+	checkResponseCode(t, http.StatusAccepted, resetPassword(token))
+	testResponseAuth := signIn(ta, user.Email, "789test124")
+	checkResponseCode(t, http.StatusOK, testResponseAuth.Code)
+	// Clean database and do final status check, reset tokens can only be used once
+	checkResponseCode(t, http.StatusUnauthorized, resetPassword(token))
+}
+
+// TestVerifyEmail Test
+func TestVerifyEmail(t *testing.T) {
+	// Test Setup
+	setup()
+	defer os.Remove(os.Getenv("MAIL_FILE"))
+	payload := []byte(`{"username":"verify","email":"verify@test.com","password":"abc123"}`)
+	reqRegister, err := http.NewRequest("POST", "/auth/register", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Errorf("TestVerifyEmail() error = %v", err)
+	}
+	reqRegister.Header.Add("Content-Type", "application/json")
+	registerTestResponse := executeRequest(ta, reqRegister)
+	checkResponseCode(t, http.StatusCreated, registerTestResponse.Code)
+	token := lastMailToken("verify@test.com")
+	if token == "" {
+		t.Fatalf("TestVerifyEmail() no email verification email was sent")
+	}
+	reqVerify, err := http.NewRequest("POST", "/auth/verify", bytes.NewBuffer([]byte(`{"token":"`+token+`"}`)))
+	if err != nil {
+		t.Errorf("TestVerifyEmail() error = %v", err)
+	}
+	reqVerify.Header.Add("Content-Type", "application/json")
+	verifyTestResponse := executeRequest(ta, reqVerify)
+	checkResponseCode(t, http.StatusOK, verifyTestResponse.Code)
+	var verified map[string]interface{}
+	json.Unmarshal(verifyTestResponse.Body.Bytes(), &verified)
+	if _, ok := verified["email_verified_at"]; !ok {
+		t.Errorf("TestVerifyEmail() = %v, want a verified email", verifyTestResponse.Body.String())
+	}
+	// Clean database and do final status check, a verified email can not be sent another link
+	reqResend, err := http.NewRequest("POST", "/auth/verify/resend", nil)
+	if err != nil {
+		t.Errorf("TestVerifyEmail() error = %v", err)
+	}
+	reqResend.Header.Add("Auth-Token", registerTestResponse.Header().Get("Auth-Token"))
+	resendTestResponse := executeRequest(ta, reqResend)
+	checkResponseCode(t, http.StatusBadRequest, resendTestResponse.Code)
+}
+
+// TestLoginLockout Test
+func TestLoginLockout(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	user := createTestUser(ta, 1)
+	// The test configuration locks an account out after 3 failed sign ins
+	for i := 0; i < 3; i++ {
+		checkResponseCode(t, http.StatusUnauthorized, signIn(ta, user.Email, "wrong").Code)
+	}
+	lockedResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusTooManyRequests, lockedResponse.Code)
+	if lockedResponse.Header().Get("Retry-After") == "" {
+		t.Errorf("TestLoginLockout() missing Retry-After header")
+	}
+	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
+	req, err := http.NewRequest("GET", "/users/"+user.Id, nil)
+	if err != nil {
+		t.Errorf("TestLoginLockout() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", authResponse.Header().Get("Auth-Token"))
+	getTestResponse := executeRequest(ta, req)
+	var locked map[string]interface{}
+	json.Unmarshal(getTestResponse.Body.Bytes(), &locked)
+	if _, ok := locked["locked_until"]; !ok {
+		t.Errorf("TestLoginLockout() = %v, want a locked user", getTestResponse.Body.String())
+	}
+	reqUnlock, err := http.NewRequest("POST", "/users/"+user.Id+"/unlock", nil)
+	if err != nil {
+		t.Errorf("TestLoginLockout() error = %v", err)
+	}
+	reqUnlock.Header.Add("Auth-Token", authResponse.Header().Get("Auth-Token"))
+	unlockTestResponse := executeRequest(ta, reqUnlock)
+	checkResponseCode(t, http.StatusOK, unlockTestResponse.Code)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusOK, signIn(ta, user.Email, "abc123").Code)
+}
+
+// TestCustomRole Test
+func TestCustomRole(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	user := createTestUser(ta, 1)
+	createTestTask(ta, 2)
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, adminResponse.Code)
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	authResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
+	authToken := authResponse.Header().Get("Auth-Token")
+	// A member can not reassign the task of another user
+	reassign := []byte(`{"user_id":"000000000000000000000012"}`)
+	req, err := http.NewRequest("PATCH", "/tasks/000000000000000000000022", bytes.NewBuffer(reassign))
+	if err != nil {
+		t.Errorf("TestCustomRole() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", authToken)
+	checkResponseCode(t, http.StatusNotFound, executeRequest(ta, req).Code)
+	// Create a project lead role and assign it to the user
+	payload := []byte(`{"name":"project lead","group_id":"000000000000000000000002","permissions":["tasks.read.any","tasks.update.any"]}`)
+	req, err = http.NewRequest("POST", "/roles", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Errorf("TestCustomRole() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusCreated, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("PUT", "/users/"+user.Id+"/role", bytes.NewBuffer([]byte(`{"role":"project lead"}`)))
+	if err != nil {
+		t.Errorf("TestCustomRole() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	// The project lead can reassign tasks but can not delete users
+	req, err = http.NewRequest("PATCH", "/tasks/000000000000000000000022", bytes.NewBuffer(reassign))
+	if err != nil {
+		t.Errorf("TestCustomRole() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", authToken)
+	checkResponseCode(t, http.StatusAccepted, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("DELETE", "/users/"+user.Id, nil)
+	if err != nil {
+		t.Errorf("TestCustomRole() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", authToken)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusForbidden, executeRequest(ta, req).Code)
+}
+
+// TestRoleEscalation Test
+func TestRoleEscalation(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	user := createTestUser(ta, 1)
+	rootResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, rootResponse.Code)
+	rootToken := rootResponse.Header().Get("Auth-Token")
+	// Only a root admin can make the user a group admin
+	req, err := http.NewRequest("PUT", "/users/"+user.Id+"/role", bytes.NewBuffer([]byte(`{"role":"admin"}`)))
+	if err != nil {
+		t.Errorf("TestRoleEscalation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", rootToken)
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	adminResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, adminResponse.Code)
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	// A group admin can not create or promote other admins
+	payload := []byte(`{"username":"lead","password":"abc123","firstname":"test","lastname":"lead","email":"lead@email.com","group_id":"000000000000000000000002","role":"admin"}`)
+	req, err = http.NewRequest("POST", "/users", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Errorf("TestRoleEscalation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusForbidden, executeRequest(ta, req).Code)
+	payload = []byte(`{"username":"lead","password":"abc123","firstname":"test","lastname":"lead","email":"lead@email.com","group_id":"000000000000000000000002","role":"member"}`)
+	req, err = http.NewRequest("POST", "/users", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Errorf("TestRoleEscalation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	createResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusCreated, createResponse.Code)
+	var lead map[string]interface{}
+	json.Unmarshal(createResponse.Body.Bytes(), &lead)
+	leadId, _ := lead["id"].(string)
+	req, err = http.NewRequest("PUT", "/users/"+leadId+"/role", bytes.NewBuffer([]byte(`{"role":"admin"}`)))
+	if err != nil {
+		t.Errorf("TestRoleEscalation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusForbidden, executeRequest(ta, req).Code)
+	// A group admin can grant a custom role, whose users can only grant the permissions they have
+	req, err = http.NewRequest("POST", "/roles", bytes.NewBuffer([]byte(`{"name":"lead","permissions":["roles.manage","tasks.read.any"]}`)))
+	if err != nil {
+		t.Errorf("TestRoleEscalation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	roleResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusCreated, roleResponse.Code)
+	var role map[string]interface{}
+	json.Unmarshal(roleResponse.Body.Bytes(), &role)
+	roleId, _ := role["id"].(string)
+	req, err = http.NewRequest("PUT", "/users/"+leadId+"/role", bytes.NewBuffer([]byte(`{"role":"lead"}`)))
+	if err != nil {
+		t.Errorf("TestRoleEscalation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	leadResponse := signIn(ta, "lead@email.com", "abc123")
+	checkResponseCode(t, http.StatusOK, leadResponse.Code)
+	leadToken := leadResponse.Header().Get("Auth-Token")
+	req, err = http.NewRequest("PUT", "/users/"+leadId+"/role", bytes.NewBuffer([]byte(`{"role":"admin"}`)))
+	if err != nil {
+		t.Errorf("TestRoleEscalation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", leadToken)
+	checkResponseCode(t, http.StatusForbidden, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("PATCH", "/roles/"+roleId, bytes.NewBuffer([]byte(`{"permissions":["roles.manage","tasks.read.any","users.delete"]}`)))
+	if err != nil {
+		t.Errorf("TestRoleEscalation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", leadToken)
+	checkResponseCode(t, http.StatusForbidden, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("POST", "/roles", bytes.NewBuffer([]byte(`{"name":"deleter","permissions":["users.delete"]}`)))
+	if err != nil {
+		t.Errorf("TestRoleEscalation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", leadToken)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusForbidden, executeRequest(ta, req).Code)
+}
+
+// TestAddGroupMember Test
+func TestAddGroupMember(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	user := createTestUser(ta, 1)
+	other := createTestUser(ta, 2)
+	rootResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	rootToken := rootResponse.Header().Get("Auth-Token")
+	req, err := http.NewRequest("PUT", "/users/"+user.Id+"/role", bytes.NewBuffer([]byte(`{"role":"admin"}`)))
+	if err != nil {
+		t.Errorf("TestAddGroupMember() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", rootToken)
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	adminResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, adminResponse.Code)
+	payload := []byte(`{"user_id":"` + other.Id + `","role":"member"}`)
+	// A group admin can not pull a user of another group into its group without an invitation
+	req, err = http.NewRequest("POST", "/groups/000000000000000000000002/members", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Errorf("TestAddGroupMember() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminResponse.Header().Get("Auth-Token"))
+	checkResponseCode(t, http.StatusUnauthorized, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("POST", "/groups/000000000000000000000002/members", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Errorf("TestAddGroupMember() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", rootToken)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusCreated, executeRequest(ta, req).Code)
+}
+
+// TestSwitchGroup Test
+func TestSwitchGroup(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	user := createTestUser(ta, 1)
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, adminResponse.Code)
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	authResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
+	authToken := authResponse.Header().Get("Auth-Token")
+	switchPayload := []byte(`{"group_id":"000000000000000000000003"}`)
+	// The user can not switch to a group it is not a member of
+	req, err := http.NewRequest("POST", "/auth/switch-group", bytes.NewBuffer(switchPayload))
+	if err != nil {
+		t.Errorf("TestSwitchGroup() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", authToken)
+	checkResponseCode(t, http.StatusForbidden, executeRequest(ta, req).Code)
+	// Add the user to the second group as an admin
+	req, err = http.NewRequest("POST", "/groups/000000000000000000000003/members", bytes.NewBuffer([]byte(`{"user_id":"`+user.Id+`","role":"admin"}`)))
+	if err != nil {
+		t.Errorf("TestSwitchGroup() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusCreated, executeRequest(ta, req).Code)
+	// Members can read the group without switching to it
+	for _, path := range []string{"/groups/000000000000000000000003/workflow", "/groups/000000000000000000000003/activity"} {
+		req, err = http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Errorf("TestSwitchGroup() error = %v", err)
+		}
+		req.Header.Add("Auth-Token", authToken)
+		checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	}
+	req, err = http.NewRequest("POST", "/auth/switch-group", bytes.NewBuffer(switchPayload))
+	if err != nil {
+		t.Errorf("TestSwitchGroup() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", authToken)
+	switchResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, switchResponse.Code)
+	switchToken := switchResponse.Header().Get("Auth-Token")
+	if switchResponse.Header().Get("Refresh-Token") == "" {
+		t.Errorf("TestSwitchGroup() missing Refresh-Token header")
+	}
+	// The user is an admin of the second group, but only a member of its own group
+	groupPayload := []byte(`{"name":"renamed"}`)
+	req, err = http.NewRequest("PATCH", "/groups/000000000000000000000003", bytes.NewBuffer(groupPayload))
+	if err != nil {
+		t.Errorf("TestSwitchGroup() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", switchToken)
+	checkResponseCode(t, http.StatusAccepted, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("PATCH", "/groups/000000000000000000000002", bytes.NewBuffer(groupPayload))
+	if err != nil {
+		t.Errorf("TestSwitchGroup() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", authToken)
+	checkResponseCode(t, http.StatusForbidden, executeRequest(ta, req).Code)
+	// The user can create tasks in the group it switched to
+	req, err = http.NewRequest("POST", "/tasks", bytes.NewBuffer(getTestTaskPayload("CREATE")))
+	if err != nil {
+		t.Errorf("TestSwitchGroup() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", switchToken)
+	taskResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusCreated, taskResponse.Code)
+	var task models.Task
+	if err = json.NewDecoder(taskResponse.Body).Decode(&task); err != nil || task.GroupId != "000000000000000000000003" || task.UserId != user.Id {
+		t.Errorf("TestSwitchGroup() task = %+v, want a task of the user in the switched group", task)
+	}
+	// Sessions for a group stop working once the user is removed from it
+	req, err = http.NewRequest("DELETE", "/groups/000000000000000000000003/members/"+user.Id, nil)
+	if err != nil {
+		t.Errorf("TestSwitchGroup() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("GET", "/tasks", nil)
+	if err != nil {
+		t.Errorf("TestSwitchGroup() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", switchToken)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusUnauthorized, executeRequest(ta, req).Code)
+}
+
+// TestInvitation Invitation Test
+func TestInvitation(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	user := createTestUser(ta, 1)
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, adminResponse.Code)
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	// A member without the users.invite permission can not invite anyone
+	authResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
+	req, err := http.NewRequest("POST", "/invitations", bytes.NewBuffer([]byte(`{"email":"invitee@test.com"}`)))
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", authResponse.Header().Get("Auth-Token"))
+	checkResponseCode(t, http.StatusForbidden, executeRequest(ta, req).Code)
+	// Invite a new user, accepting the invitation creates the user in the group
+	req, err = http.NewRequest("POST", "/invitations", bytes.NewBuffer([]byte(`{"email":"invitee@test.com","group_id":"000000000000000000000003"}`)))
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusCreated, executeRequest(ta, req).Code)
+	token := lastMailToken("invitee@test.com")
+	if token == "" {
+		t.Fatalf("TestInvitation() no invitation was emailed")
+	}
+	acceptPayload := []byte(`{"token":"` + token + `","username":"invitee","password":"abc123","firstname":"In","lastname":"Vitee"}`)
+	req, err = http.NewRequest("POST", "/auth/invitations/accept", bytes.NewBuffer(acceptPayload))
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	acceptResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusCreated, acceptResponse.Code)
+	if acceptResponse.Header().Get("Auth-Token") == "" {
+		t.Errorf("TestInvitation() missing Auth-Token header")
+	}
+	// Invitations are single use
+	req, err = http.NewRequest("POST", "/auth/invitations/accept", bytes.NewBuffer(acceptPayload))
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	checkResponseCode(t, http.StatusBadRequest, executeRequest(ta, req).Code)
+	checkResponseCode(t, http.StatusOK, signIn(ta, "invitee@test.com", "abc123").Code)
+	// Invite an existing user, accepting the invitation adds it to the group
+	req, err = http.NewRequest("POST", "/invitations", bytes.NewBuffer([]byte(`{"email":"`+user.Email+`","group_id":"000000000000000000000003","role":"admin"}`)))
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusCreated, executeRequest(ta, req).Code)
+	token = lastMailToken(user.Email)
+	req, err = http.NewRequest("POST", "/auth/invitations/accept", bytes.NewBuffer([]byte(`{"token":"`+token+`","password":"wrong"}`)))
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	checkResponseCode(t, http.StatusBadRequest, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("POST", "/auth/invitations/accept", bytes.NewBuffer([]byte(`{"token":"`+token+`","password":"abc123"}`)))
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("POST", "/auth/switch-group", bytes.NewBuffer([]byte(`{"group_id":"000000000000000000000003"}`)))
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", authResponse.Header().Get("Auth-Token"))
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	// Revoked invitations can no longer be accepted
+	req, err = http.NewRequest("POST", "/invitations", bytes.NewBuffer([]byte(`{"email":"revoked@test.com"}`)))
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	createResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusCreated, createResponse.Code)
+	var invitation models.Invitation
+	if err = json.Unmarshal(createResponse.Body.Bytes(), &invitation); err != nil {
+		t.Fatalf("TestInvitation() error = %v", err)
+	}
+	if invitation.Token != "" {
+		t.Errorf("TestInvitation() invitation token was returned to the inviter")
+	}
+	req, err = http.NewRequest("DELETE", "/invitations/"+invitation.Id, nil)
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("POST", "/auth/invitations/accept", bytes.NewBuffer([]byte(`{"token":"`+lastMailToken("revoked@test.com")+`","username":"revoked","password":"abc123"}`)))
+	if err != nil {
+		t.Errorf("TestInvitation() error = %v", err)
+	}
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusBadRequest, executeRequest(ta, req).Code)
+}
+
+// TestInvitationLockout Test
+func TestInvitationLockout(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	user := createTestUser(ta, 1)
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	req, err := http.NewRequest("POST", "/invitations", bytes.NewBuffer([]byte(`{"email":"`+user.Email+`","group_id":"000000000000000000000003"}`)))
+	if err != nil {
+		t.Errorf("TestInvitationLockout() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminResponse.Header().Get("Auth-Token"))
+	checkResponseCode(t, http.StatusCreated, executeRequest(ta, req).Code)
+	token := lastMailToken(user.Email)
+	accept := func(password string) int {
+		req, err := http.NewRequest("POST", "/auth/invitations/accept", bytes.NewBuffer([]byte(`{"token":"`+token+`","password":"`+password+`"}`)))
+		if err != nil {
+			t.Errorf("TestInvitationLockout() error = %v", err)
+		}
+		return executeRequest(ta, req).Code
+	}
+	// Wrong passwords lock the account out of accepting the invitation and of signing in
+	for i := 0; i < 3; i++ {
+		checkResponseCode(t, http.StatusBadRequest, accept("wrong"))
+	}
+	checkResponseCode(t, http.StatusTooManyRequests, accept("abc123"))
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusTooManyRequests, signIn(ta, user.Email, "abc123").Code)
+}
+
+// TestOIDCSignIn Single Sign-On Test
+func TestOIDCSignIn(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	user := createTestUser(ta, 1)
+	idp := newStubIdP(t, "000000000000000000000003")
+	// A new account is provisioned into the mapped group
+	response := idp.oidcSignIn(t, ta, jwt.MapClaims{"sub": "sso-1", "email": "sso@test.com", "email_verified": true, "preferred_username": "sso"})
+	checkResponseCode(t, http.StatusOK, response.Code)
+	if response.Header().Get("Auth-Token") == "" {
+		t.Errorf("TestOIDCSignIn() missing Auth-Token header")
+	}
+	var provisioned models.User
+	if err := json.Unmarshal(response.Body.Bytes(), &provisioned); err != nil {
+		t.Fatalf("TestOIDCSignIn() error = %v", err)
+	}
+	if provisioned.GroupId != "000000000000000000000003" || provisioned.Email != "sso@test.com" {
+		t.Errorf("TestOIDCSignIn() provisioned user = %+v", provisioned)
+	}
+	// The identity stays linked to the account when its email address changes
+	response = idp.oidcSignIn(t, ta, jwt.MapClaims{"sub": "sso-1", "email": "renamed@test.com", "email_verified": true})
+	checkResponseCode(t, http.StatusOK, response.Code)
+	// An existing account is linked by its verified email address
+	response = idp.oidcSignIn(t, ta, jwt.MapClaims{"sub": "sso-2", "email": user.Email, "email_verified": false})
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+	response = idp.oidcSignIn(t, ta, jwt.MapClaims{"sub": "sso-2", "email": user.Email, "email_verified": true})
+	checkResponseCode(t, http.StatusOK, response.Code)
+	var linked models.User
+	if err := json.Unmarshal(response.Body.Bytes(), &linked); err != nil {
+		t.Fatalf("TestOIDCSignIn() error = %v", err)
+	}
+	if linked.Id != user.Id {
+		t.Errorf("TestOIDCSignIn() linked user = %v, want %v", linked.Id, user.Id)
+	}
+	// The callback must be made with the state of the sign in
+	req, err := http.NewRequest("GET", "/auth/oidc/stub/callback?code=forged&state=forged", nil)
+	if err != nil {
+		t.Errorf("TestOIDCSignIn() error = %v", err)
+	}
+	checkResponseCode(t, http.StatusBadRequest, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("GET", "/auth/oidc/unknown/start", nil)
+	if err != nil {
+		t.Errorf("TestOIDCSignIn() error = %v", err)
+	}
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusNotFound, executeRequest(ta, req).Code)
+}
+
 // TestModifyUser User Test
 func TestModifyUser(t *testing.T) {
 	// Test Setup
@@ -201,6 +777,66 @@ func TestDeleteUser(t *testing.T) {
 	testResponse := executeRequest(ta, req)
 	// Clean database and do final status check
 	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	var report struct {
+		Id       string           `json:"id"`
+		Password string           `json:"password"`
+		Removed  map[string]int64 `json:"removed"`
+	}
+	if err = json.Unmarshal(testResponse.Body.Bytes(), &report); err != nil {
+		t.Errorf("TestDeleteUser() error = %v", err)
+	}
+	if report.Id != "000000000000000000000012" || report.Password != "" || report.Removed["users"] != 1 {
+		t.Errorf("TestDeleteUser() report = %+v", report)
+	}
+}
+
+// TestRestoreUser User Test
+func TestRestoreUser(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestUser(ta, 1)
+	createTestTask(ta, 1)
+	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	authToken := authResponse.Header().Get("Auth-Token")
+	// Restoring a user that is not deleted should fail
+	reqErr, err := http.NewRequest("POST", "/users/000000000000000000000012/restore", nil)
+	if err != nil {
+		t.Errorf("TestRestoreUser() error = %v", err)
+	}
+	reqErr.Header.Add("Content-Type", "application/json")
+	reqErr.Header.Add("Auth-Token", authToken)
+	testResponseErr := executeRequest(ta, reqErr)
+	checkResponseCode(t, http.StatusNotFound, testResponseErr.Code)
+	// Delete the user along with its tasks
+	reqDelete, err := http.NewRequest("DELETE", "/users/000000000000000000000012", nil)
+	if err != nil {
+		t.Errorf("TestRestoreUser() error = %v", err)
+	}
+	reqDelete.Header.Add("Content-Type", "application/json")
+	reqDelete.Header.Add("Auth-Token", authToken)
+	deleteTestResponse := executeRequest(ta, reqDelete)
+	checkResponseCode(t, http.StatusOK, deleteTestResponse.Code)
+	checkResponseCode(t, http.StatusUnauthorized, signIn(ta, "test2@email.com", "abc123").Code)
+	// Restore the user and ensure its tasks came back with it
+	req, err := http.NewRequest("POST", "/users/000000000000000000000012/restore", nil)
+	if err != nil {
+		t.Errorf("TestRestoreUser() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	testResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	checkResponseCode(t, http.StatusOK, signIn(ta, "test2@email.com", "abc123").Code)
+	reqTask, err := http.NewRequest("GET", "/tasks/000000000000000000000021", nil)
+	if err != nil {
+		t.Errorf("TestRestoreUser() error = %v", err)
+	}
+	reqTask.Header.Add("Content-Type", "application/json")
+	reqTask.Header.Add("Auth-Token", authToken)
+	taskTestResponse := executeRequest(ta, reqTask)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusOK, taskTestResponse.Code)
 }
 
 // TestTokenRefresh Auth Token Test
@@ -233,47 +869,307 @@ func TestTokenRefresh(t *testing.T) {
 	checkResponseCode(t, http.StatusCreated, testResponse.Code)
 }
 
-// TestGenerateAPIKey Test
-func TestGenerateAPIKey(t *testing.T) {
+// TestRefreshTokenRotation Auth Test
+func TestRefreshTokenRotation(t *testing.T) {
 	// Test Setup
 	setup()
 	createTestGroup(ta, 1)
 	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
-	authToken := authResponse.Header().Get("Auth-Token")
-	// Refresh Auth-Token Test
-	reqAPIKey, err := http.NewRequest("GET", "/auth/api-key", nil)
-	if err != nil {
-		t.Errorf("TestGenerateAPIKey() error = %v", err)
+	refreshToken := authResponse.Header().Get("Refresh-Token")
+	if refreshToken == "" {
+		t.Errorf("TestRefreshTokenRotation() missing Refresh-Token header")
 	}
-	reqAPIKey.Header.Add("Content-Type", "application/json")
-	reqAPIKey.Header.Add("Auth-Token", authToken)
-	apiKeyTestResponse := executeRequest(ta, reqAPIKey)
-	checkResponseCode(t, http.StatusOK, apiKeyTestResponse.Code)
-	apiKey := apiKeyTestResponse.Header().Get("API-Key")
-	// Test new token to ensure it works by creating a new group
-	payload := getTestUserPayload("CREATE")
-	req, err := http.NewRequest("POST", "/users", bytes.NewBuffer(payload))
+	payload := []byte(`{"refresh_token":"` + refreshToken + `"}`)
+	// Exchange the refresh token for a new session and refresh token
+	req, err := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(payload))
 	if err != nil {
-		t.Errorf("TestGenerateAPIKey() error = %v", err)
+		t.Errorf("TestRefreshTokenRotation() error = %v", err)
 	}
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Auth-Token", apiKey)
 	testResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	rotatedToken := testResponse.Header().Get("Refresh-Token")
+	if testResponse.Header().Get("Auth-Token") == "" || rotatedToken == "" || rotatedToken == refreshToken {
+		t.Errorf("TestRefreshTokenRotation() refresh token was not rotated")
+	}
+	// Presenting the old refresh token again is detected as reuse
+	reqReuse, err := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Errorf("TestRefreshTokenRotation() error = %v", err)
+	}
+	reqReuse.Header.Add("Content-Type", "application/json")
+	reuseTestResponse := executeRequest(ta, reqReuse)
+	checkResponseCode(t, http.StatusUnauthorized, reuseTestResponse.Code)
+	// The rotated refresh token was revoked along with the rest of its family
+	reqRevoked, err := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer([]byte(`{"refresh_token":"`+rotatedToken+`"}`)))
+	if err != nil {
+		t.Errorf("TestRefreshTokenRotation() error = %v", err)
+	}
+	reqRevoked.Header.Add("Content-Type", "application/json")
+	revokedTestResponse := executeRequest(ta, reqRevoked)
 	// Clean database and do final status check
-	checkResponseCode(t, http.StatusCreated, testResponse.Code)
+	checkResponseCode(t, http.StatusUnauthorized, revokedTestResponse.Code)
 }
 
-/*
-GROUP TESTS
-*/
-
-// TestCreateGroup Test
-func TestCreateGroup(t *testing.T) {
+// TestAPIKeyGroupSwitch Test
+func TestAPIKeyGroupSwitch(t *testing.T) {
 	// Test Setup
 	setup()
-	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	user := createTestUser(ta, 1)
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	authResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
 	authToken := authResponse.Header().Get("Auth-Token")
-	// Create new group test
+	// Switch the user to the second group and create an api key there
+	req, err := http.NewRequest("POST", "/groups/000000000000000000000003/members", bytes.NewBuffer([]byte(`{"user_id":"`+user.Id+`","role":"member"}`)))
+	if err != nil {
+		t.Errorf("TestAPIKeyGroupSwitch() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusCreated, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("POST", "/auth/switch-group", bytes.NewBuffer([]byte(`{"group_id":"000000000000000000000003"}`)))
+	if err != nil {
+		t.Errorf("TestAPIKeyGroupSwitch() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", authToken)
+	switchResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, switchResponse.Code)
+	req, err = http.NewRequest("POST", "/auth/api-keys", bytes.NewBuffer([]byte(`{"name":"reporting","scopes":["tasks:read"]}`)))
+	if err != nil {
+		t.Errorf("TestAPIKeyGroupSwitch() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", switchResponse.Header().Get("Auth-Token"))
+	createTestResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusCreated, createTestResponse.Code)
+	var apiKey map[string]interface{}
+	json.Unmarshal(createTestResponse.Body.Bytes(), &apiKey)
+	key, _ := apiKey["key"].(string)
+	req, err = http.NewRequest("GET", "/tasks", nil)
+	if err != nil {
+		t.Errorf("TestAPIKeyGroupSwitch() error = %v", err)
+	}
+	req.Header.Add("API-Key", key)
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	// The api key stops working once the user is removed from the group it was issued for
+	req, err = http.NewRequest("DELETE", "/groups/000000000000000000000003/members/"+user.Id, nil)
+	if err != nil {
+		t.Errorf("TestAPIKeyGroupSwitch() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", adminToken)
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, req).Code)
+	req, err = http.NewRequest("GET", "/tasks", nil)
+	if err != nil {
+		t.Errorf("TestAPIKeyGroupSwitch() error = %v", err)
+	}
+	req.Header.Add("API-Key", key)
+	checkResponseCode(t, http.StatusUnauthorized, executeRequest(ta, req).Code)
+}
+
+// TestScopedAPIKey Test
+func TestScopedAPIKey(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	authToken := authResponse.Header().Get("Auth-Token")
+	// Create a read only api key for tasks
+	payload := []byte(`{"name":"reporting","scopes":["tasks:read"]}`)
+	reqCreate, err := http.NewRequest("POST", "/auth/api-keys", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Errorf("TestScopedAPIKey() error = %v", err)
+	}
+	reqCreate.Header.Add("Content-Type", "application/json")
+	reqCreate.Header.Add("Auth-Token", authToken)
+	createTestResponse := executeRequest(ta, reqCreate)
+	checkResponseCode(t, http.StatusCreated, createTestResponse.Code)
+	var apiKey map[string]interface{}
+	json.Unmarshal(createTestResponse.Body.Bytes(), &apiKey)
+	key, _ := apiKey["key"].(string)
+	keyId, _ := apiKey["id"].(string)
+	if key == "" {
+		t.Errorf("TestScopedAPIKey() missing api key in response")
+	}
+	// The api key can read tasks
+	reqRead, err := http.NewRequest("GET", "/tasks", nil)
+	if err != nil {
+		t.Errorf("TestScopedAPIKey() error = %v", err)
+	}
+	reqRead.Header.Add("API-Key", key)
+	readTestResponse := executeRequest(ta, reqRead)
+	checkResponseCode(t, http.StatusOK, readTestResponse.Code)
+	// The api key can not write tasks or manage api keys
+	reqWrite, err := http.NewRequest("POST", "/tasks", bytes.NewBuffer(getTestTaskPayload("CREATE")))
+	if err != nil {
+		t.Errorf("TestScopedAPIKey() error = %v", err)
+	}
+	reqWrite.Header.Add("Content-Type", "application/json")
+	reqWrite.Header.Add("API-Key", key)
+	writeTestResponse := executeRequest(ta, reqWrite)
+	checkResponseCode(t, http.StatusUnauthorized, writeTestResponse.Code)
+	reqKeys, err := http.NewRequest("GET", "/auth/api-keys", nil)
+	if err != nil {
+		t.Errorf("TestScopedAPIKey() error = %v", err)
+	}
+	reqKeys.Header.Add("API-Key", key)
+	keysTestResponse := executeRequest(ta, reqKeys)
+	checkResponseCode(t, http.StatusUnauthorized, keysTestResponse.Code)
+	// Revoke the api key
+	reqDelete, err := http.NewRequest("DELETE", "/auth/api-keys/"+keyId, nil)
+	if err != nil {
+		t.Errorf("TestScopedAPIKey() error = %v", err)
+	}
+	reqDelete.Header.Add("Auth-Token", authToken)
+	deleteTestResponse := executeRequest(ta, reqDelete)
+	checkResponseCode(t, http.StatusOK, deleteTestResponse.Code)
+	reqRevoked, err := http.NewRequest("GET", "/tasks", nil)
+	if err != nil {
+		t.Errorf("TestScopedAPIKey() error = %v", err)
+	}
+	reqRevoked.Header.Add("API-Key", key)
+	revokedTestResponse := executeRequest(ta, reqRevoked)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusUnauthorized, revokedTestResponse.Code)
+}
+
+// TestTwoFactorSignIn Test
+func TestTwoFactorSignIn(t *testing.T) {
+	// Test Setup
+	setup()
+	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	authToken := authResponse.Header().Get("Auth-Token")
+	var root map[string]interface{}
+	json.Unmarshal(authResponse.Body.Bytes(), &root)
+	groupId, _ := root["group_id"].(string)
+	// Require 2FA for the root admin group, blocking the root admin until it is enabled
+	reqRequire, err := http.NewRequest("PUT", "/groups/"+groupId+"/2fa", bytes.NewBuffer([]byte(`{"required":true}`)))
+	if err != nil {
+		t.Errorf("TestTwoFactorSignIn() error = %v", err)
+	}
+	reqRequire.Header.Add("Content-Type", "application/json")
+	reqRequire.Header.Add("Auth-Token", authToken)
+	requireTestResponse := executeRequest(ta, reqRequire)
+	checkResponseCode(t, http.StatusOK, requireTestResponse.Code)
+	reqBlocked, err := http.NewRequest("GET", "/tasks", nil)
+	if err != nil {
+		t.Errorf("TestTwoFactorSignIn() error = %v", err)
+	}
+	reqBlocked.Header.Add("Auth-Token", authToken)
+	blockedTestResponse := executeRequest(ta, reqBlocked)
+	checkResponseCode(t, http.StatusForbidden, blockedTestResponse.Code)
+	// Enroll and enable 2FA
+	reqEnroll, err := http.NewRequest("POST", "/auth/2fa/enroll", nil)
+	if err != nil {
+		t.Errorf("TestTwoFactorSignIn() error = %v", err)
+	}
+	reqEnroll.Header.Add("Auth-Token", authToken)
+	enrollTestResponse := executeRequest(ta, reqEnroll)
+	checkResponseCode(t, http.StatusCreated, enrollTestResponse.Code)
+	var enrollment struct {
+		Secret        string   `json:"secret"`
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	json.Unmarshal(enrollTestResponse.Body.Bytes(), &enrollment)
+	if enrollment.Secret == "" || len(enrollment.RecoveryCodes) == 0 {
+		t.Fatalf("TestTwoFactorSignIn() missing 2FA enrollment in response")
+	}
+	code, _ := auth.TOTPCode(enrollment.Secret, time.Now())
+	reqEnable, err := http.NewRequest("POST", "/auth/2fa/enable", bytes.NewBuffer([]byte(`{"code":"`+code+`"}`)))
+	if err != nil {
+		t.Errorf("TestTwoFactorSignIn() error = %v", err)
+	}
+	reqEnable.Header.Add("Content-Type", "application/json")
+	reqEnable.Header.Add("Auth-Token", authToken)
+	enableTestResponse := executeRequest(ta, reqEnable)
+	checkResponseCode(t, http.StatusOK, enableTestResponse.Code)
+	reqAllowed, err := http.NewRequest("GET", "/tasks", nil)
+	if err != nil {
+		t.Errorf("TestTwoFactorSignIn() error = %v", err)
+	}
+	reqAllowed.Header.Add("Auth-Token", authToken)
+	allowedTestResponse := executeRequest(ta, reqAllowed)
+	checkResponseCode(t, http.StatusOK, allowedTestResponse.Code)
+	// Signing in now returns a challenge instead of a session
+	challengeResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusAccepted, challengeResponse.Code)
+	if challengeResponse.Header().Get("Auth-Token") != "" {
+		t.Errorf("TestTwoFactorSignIn() session token issued before 2FA was completed")
+	}
+	var challenge map[string]interface{}
+	json.Unmarshal(challengeResponse.Body.Bytes(), &challenge)
+	challengeToken, _ := challenge["challenge_token"].(string)
+	completeTwoFactor := func(recoveryCode string) int {
+		payload := []byte(`{"challenge_token":"` + challengeToken + `","recovery_code":"` + recoveryCode + `"}`)
+		req, err := http.NewRequest("POST", "/auth/2fa", bytes.NewBuffer(payload))
+		if err != nil {
+			t.Errorf("TestTwoFactorSignIn() error = %v", err)
+		}
+		req.Header.Add("Content-Type", "application/json")
+		return executeRequest(ta, req).Code
+	}
+	checkResponseCode(t, http.StatusUnauthorized, completeTwoFactor("invalid"))
+	checkResponseCode(t, http.StatusOK, completeTwoFactor(enrollment.RecoveryCodes[0]))
+	// Recovery codes can only be used once
+	checkResponseCode(t, http.StatusUnauthorized, completeTwoFactor(enrollment.RecoveryCodes[0]))
+	newChallenge := func() {
+		challengeResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+		checkResponseCode(t, http.StatusAccepted, challengeResponse.Code)
+		json.Unmarshal(challengeResponse.Body.Bytes(), &challenge)
+		challengeToken, _ = challenge["challenge_token"].(string)
+	}
+	// A challenge token is invalidated after too many wrong codes, even before the account is locked out
+	t.Setenv("LOGIN_MAX_ATTEMPTS", "10")
+	newChallenge()
+	for i := 0; i < 5; i++ {
+		checkResponseCode(t, http.StatusUnauthorized, completeTwoFactor("invalid"))
+	}
+	checkResponseCode(t, http.StatusUnauthorized, completeTwoFactor(enrollment.RecoveryCodes[1]))
+	newChallenge()
+	checkResponseCode(t, http.StatusOK, completeTwoFactor(enrollment.RecoveryCodes[1]))
+	// Wrong codes count against the account lockout, which signing in with the password does not clear
+	t.Setenv("LOGIN_MAX_ATTEMPTS", "3")
+	newChallenge()
+	checkResponseCode(t, http.StatusUnauthorized, completeTwoFactor("invalid"))
+	checkResponseCode(t, http.StatusUnauthorized, completeTwoFactor("invalid"))
+	newChallenge()
+	checkResponseCode(t, http.StatusUnauthorized, completeTwoFactor("invalid"))
+	checkResponseCode(t, http.StatusTooManyRequests, completeTwoFactor(enrollment.RecoveryCodes[2]))
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusTooManyRequests, signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD")).Code)
+}
+
+// TestGetJWKS Test
+func TestGetJWKS(t *testing.T) {
+	// Test Setup
+	setup()
+	req, err := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	if err != nil {
+		t.Errorf("TestGetJWKS() error = %v", err)
+	}
+	testResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	var jwks map[string][]interface{}
+	json.Unmarshal(testResponse.Body.Bytes(), &jwks)
+	// The HS256 secret of the test configuration is never published
+	if keys, ok := jwks["keys"]; !ok || len(keys) != 0 {
+		t.Errorf("TestGetJWKS() = %v, want an empty key set", testResponse.Body.String())
+	}
+}
+
+/*
+GROUP TESTS
+*/
+
+// TestCreateGroup Test
+func TestCreateGroup(t *testing.T) {
+	// Test Setup
+	setup()
+	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	authToken := authResponse.Header().Get("Auth-Token")
+	// Create new group test
 	payload := getTestGroupPayload("CREATE")
 	req, err := http.NewRequest("POST", "/groups", bytes.NewBuffer(payload))
 	if err != nil {
@@ -362,6 +1258,77 @@ func TestDeleteGroup(t *testing.T) {
 	testResponse := executeRequest(ta, req)
 	// Clean database and do final status check
 	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	var report struct {
+		Id       string           `json:"id"`
+		Removed  map[string]int64 `json:"removed"`
+		Strategy string           `json:"strategy"`
+	}
+	if err = json.Unmarshal(testResponse.Body.Bytes(), &report); err != nil {
+		t.Errorf("TestDeleteGroup() error = %v", err)
+	}
+	if report.Id != "000000000000000000000002" || report.Removed["groups"] != 1 || report.Strategy == "" {
+		t.Errorf("TestDeleteGroup() report = %+v", report)
+	}
+}
+
+// TestCancelledRequest Test
+func TestCancelledRequest(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	authToken := authResponse.Header().Get("Auth-Token")
+	// Delete a group with a request whose client has gone away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, "DELETE", "/groups/000000000000000000000002", nil)
+	if err != nil {
+		t.Errorf("TestCancelledRequest() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	if testResponse := executeRequest(ta, req); testResponse.Code == http.StatusOK {
+		t.Errorf("TestCancelledRequest() deleted the group of a cancelled request")
+	}
+	// The group is left as is
+	reqGet, err := http.NewRequest("GET", "/groups/000000000000000000000002", nil)
+	if err != nil {
+		t.Errorf("TestCancelledRequest() error = %v", err)
+	}
+	reqGet.Header.Add("Content-Type", "application/json")
+	reqGet.Header.Add("Auth-Token", authToken)
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, reqGet).Code)
+}
+
+// TestRestoreGroup Test
+func TestRestoreGroup(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestUser(ta, 1)
+	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	authToken := authResponse.Header().Get("Auth-Token")
+	// Delete the group along with its users
+	reqDelete, err := http.NewRequest("DELETE", "/groups/000000000000000000000002", nil)
+	if err != nil {
+		t.Errorf("TestRestoreGroup() error = %v", err)
+	}
+	reqDelete.Header.Add("Content-Type", "application/json")
+	reqDelete.Header.Add("Auth-Token", authToken)
+	deleteTestResponse := executeRequest(ta, reqDelete)
+	checkResponseCode(t, http.StatusOK, deleteTestResponse.Code)
+	checkResponseCode(t, http.StatusUnauthorized, signIn(ta, "test2@email.com", "abc123").Code)
+	// Restore the group and ensure its users can sign in again
+	req, err := http.NewRequest("POST", "/groups/000000000000000000000002/restore", nil)
+	if err != nil {
+		t.Errorf("TestRestoreGroup() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	testResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusOK, signIn(ta, "test2@email.com", "abc123").Code)
 }
 
 /*
@@ -435,6 +1402,943 @@ func TestListTasks(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, testResponse.Code)
 }
 
+// TestListTasksPaginated Test
+func TestListTasksPaginated(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestUser(ta, 1)
+	createTestTask(ta, 1)
+	createTestTask(ta, 2)
+	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
+	authToken := authResponse.Header().Get("Auth-Token")
+	// An unknown sort field is rejected
+	reqErr, err := http.NewRequest("GET", "/tasks?sort=password", nil)
+	if err != nil {
+		t.Errorf("TestListTasksPaginated() error = %v", err)
+	}
+	reqErr.Header.Add("Content-Type", "application/json")
+	reqErr.Header.Add("Auth-Token", authToken)
+	testResponseErr := executeRequest(ta, reqErr)
+	checkResponseCode(t, http.StatusBadRequest, testResponseErr.Code)
+	// List the first page of todos
+	req, err := http.NewRequest("GET", "/tasks?limit=1", nil)
+	if err != nil {
+		t.Errorf("TestListTasksPaginated() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	testResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	var page struct {
+		Total int64  `json:"total"`
+		Next  string `json:"next"`
+	}
+	if err = json.NewDecoder(testResponse.Body).Decode(&page); err != nil {
+		t.Errorf("TestListTasksPaginated() error = %v", err)
+	}
+	// Clean database and do final status check
+	if page.Total != 2 || page.Next != "/tasks?after=000000000000000000000021&limit=1" {
+		t.Errorf("TestListTasksPaginated() page = %+v", page)
+	}
+}
+
+// TestTaskDetails Test
+func TestTaskDetails(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	user := createTestUser(ta, 1)
+	createTestUser(ta, 2)
+	createTestTask(ta, 1)
+	createTestTask(ta, 2)
+	authResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
+	authToken := authResponse.Header().Get("Auth-Token")
+	// Completing a task with a priority, labels and an estimate stamps its completed_at
+	reqStart, err := http.NewRequest("PATCH", "/tasks/000000000000000000000021", bytes.NewBuffer([]byte(`{"status":"IN_PROGRESS"}`)))
+	if err != nil {
+		t.Errorf("TestTaskDetails() error = %v", err)
+	}
+	reqStart.Header.Add("Content-Type", "application/json")
+	reqStart.Header.Add("Auth-Token", authToken)
+	testResponseStart := executeRequest(ta, reqStart)
+	checkResponseCode(t, http.StatusAccepted, testResponseStart.Code)
+	payload := []byte(`{"status":"COMPLETED","priority":"HIGH","labels":["backend"],"estimate":2.5}`)
+	req, err := http.NewRequest("PATCH", "/tasks/000000000000000000000021", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Errorf("TestTaskDetails() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	testResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusAccepted, testResponse.Code)
+	var task models.Task
+	if err = json.NewDecoder(testResponse.Body).Decode(&task); err != nil {
+		t.Errorf("TestTaskDetails() error = %v", err)
+	}
+	if task.CompletedAt.IsZero() || task.Priority != models.HIGH || task.Estimate != 2.5 {
+		t.Errorf("TestTaskDetails() task = %+v", task)
+	}
+	// The task can be found by its priority, label, estimate range and completion time
+	req, err = http.NewRequest("GET", "/tasks?priority=HIGH&labels=backend&estimate_gte=2&completed_at_gte=2020-01-01T00:00:00Z", nil)
+	if err != nil {
+		t.Errorf("TestTaskDetails() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	testResponse = executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	var page struct {
+		Total int64 `json:"total"`
+	}
+	if err = json.NewDecoder(testResponse.Body).Decode(&page); err != nil {
+		t.Errorf("TestTaskDetails() error = %v", err)
+	}
+	if page.Total != 1 {
+		t.Errorf("TestTaskDetails() total = %v, want %v", page.Total, 1)
+	}
+	// An invalid range value is rejected
+	reqErr, err := http.NewRequest("GET", "/tasks?estimate_gte=soon", nil)
+	if err != nil {
+		t.Errorf("TestTaskDetails() error = %v", err)
+	}
+	reqErr.Header.Add("Content-Type", "application/json")
+	reqErr.Header.Add("Auth-Token", authToken)
+	testResponseErr := executeRequest(ta, reqErr)
+	checkResponseCode(t, http.StatusBadRequest, testResponseErr.Code)
+	// A task can not be assigned to a user outside of its group
+	reqAssign, err := http.NewRequest("PATCH", "/tasks/000000000000000000000021", bytes.NewBuffer([]byte(`{"assignee_id":"000000000000000000000013"}`)))
+	if err != nil {
+		t.Errorf("TestTaskDetails() error = %v", err)
+	}
+	reqAssign.Header.Add("Content-Type", "application/json")
+	reqAssign.Header.Add("Auth-Token", authToken)
+	testResponseAssign := executeRequest(ta, reqAssign)
+	checkResponseCode(t, http.StatusServiceUnavailable, testResponseAssign.Code)
+	// Once the user is a member of the group the task can be assigned to it
+	rootResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	req, err = http.NewRequest("POST", "/groups/000000000000000000000002/members", bytes.NewBuffer([]byte(`{"user_id":"000000000000000000000013","role":"member"}`)))
+	if err != nil {
+		t.Errorf("TestTaskDetails() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", rootResponse.Header().Get("Auth-Token"))
+	checkResponseCode(t, http.StatusCreated, executeRequest(ta, req).Code)
+	reqAssign, err = http.NewRequest("PATCH", "/tasks/000000000000000000000021", bytes.NewBuffer([]byte(`{"assignee_id":"000000000000000000000013"}`)))
+	if err != nil {
+		t.Errorf("TestTaskDetails() error = %v", err)
+	}
+	reqAssign.Header.Add("Content-Type", "application/json")
+	reqAssign.Header.Add("Auth-Token", authToken)
+	checkResponseCode(t, http.StatusAccepted, executeRequest(ta, reqAssign).Code)
+	// A member lists its own tasks along with the tasks assigned to it, but not the other tasks of the group
+	memberResponse := signIn(ta, "test3@email.com.com", "abc123")
+	checkResponseCode(t, http.StatusOK, memberResponse.Code)
+	req, err = http.NewRequest("POST", "/auth/switch-group", bytes.NewBuffer([]byte(`{"group_id":"000000000000000000000002"}`)))
+	if err != nil {
+		t.Errorf("TestTaskDetails() error = %v", err)
+	}
+	req.Header.Add("Auth-Token", memberResponse.Header().Get("Auth-Token"))
+	switchResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, switchResponse.Code)
+	for _, tc := range []struct {
+		token string
+		query string
+		want  []string
+	}{
+		{switchResponse.Header().Get("Auth-Token"), "assignee_id=000000000000000000000013", []string{"000000000000000000000021"}},
+		{switchResponse.Header().Get("Auth-Token"), "sort=name", []string{"000000000000000000000021", "000000000000000000000022"}},
+		{authToken, "sort=name", []string{"000000000000000000000021"}},
+	} {
+		req, err = http.NewRequest("GET", "/tasks?"+tc.query, nil)
+		if err != nil {
+			t.Errorf("TestTaskDetails() error = %v", err)
+		}
+		req.Header.Add("Auth-Token", tc.token)
+		testResponse = executeRequest(ta, req)
+		checkResponseCode(t, http.StatusOK, testResponse.Code)
+		var tasks struct {
+			Tasks []*models.Task `json:"tasks"`
+		}
+		if err = json.NewDecoder(testResponse.Body).Decode(&tasks); err != nil {
+			t.Errorf("TestTaskDetails() error = %v", err)
+		}
+		var got []string
+		for _, listed := range tasks.Tasks {
+			got = append(got, listed.Id)
+		}
+		// Clean database and do final status check
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("TestTaskDetails() tasks?%v = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+// TestTaskWorkflow Test
+func TestTaskWorkflow(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	user := createTestUser(ta, 1)
+	createTestTask(ta, 1)
+	authResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
+	authToken := authResponse.Header().Get("Auth-Token")
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	// A task can not skip a status of the default workflow or move to an unknown status
+	for _, payload := range []string{`{"status":"COMPLETED"}`, `{"status":"ARCHIVED"}`} {
+		req, err := http.NewRequest("PATCH", "/tasks/000000000000000000000021", bytes.NewBuffer([]byte(payload)))
+		if err != nil {
+			t.Errorf("TestTaskWorkflow() error = %v", err)
+		}
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("Auth-Token", authToken)
+		testResponse := executeRequest(ta, req)
+		checkResponseCode(t, http.StatusConflict, testResponse.Code)
+	}
+	// Members can not configure the workflow of their group
+	workflow := []byte(`{"statuses":["NOT_STARTED","IN_REVIEW","COMPLETED"],"transitions":[{"from":"NOT_STARTED","to":"IN_REVIEW"},{"from":"IN_REVIEW","to":"COMPLETED"}]}`)
+	reqErr, err := http.NewRequest("PUT", "/groups/000000000000000000000002/workflow", bytes.NewBuffer(workflow))
+	if err != nil {
+		t.Errorf("TestTaskWorkflow() error = %v", err)
+	}
+	reqErr.Header.Add("Content-Type", "application/json")
+	reqErr.Header.Add("Auth-Token", authToken)
+	testResponseErr := executeRequest(ta, reqErr)
+	checkResponseCode(t, http.StatusForbidden, testResponseErr.Code)
+	// Add a custom status to the workflow of the group
+	reqSet, err := http.NewRequest("PUT", "/groups/000000000000000000000002/workflow", bytes.NewBuffer(workflow))
+	if err != nil {
+		t.Errorf("TestTaskWorkflow() error = %v", err)
+	}
+	reqSet.Header.Add("Content-Type", "application/json")
+	reqSet.Header.Add("Auth-Token", adminToken)
+	testResponseSet := executeRequest(ta, reqSet)
+	checkResponseCode(t, http.StatusOK, testResponseSet.Code)
+	// Move the task to the custom status
+	reqMove, err := http.NewRequest("PATCH", "/tasks/000000000000000000000021", bytes.NewBuffer([]byte(`{"status":"IN_REVIEW"}`)))
+	if err != nil {
+		t.Errorf("TestTaskWorkflow() error = %v", err)
+	}
+	reqMove.Header.Add("Content-Type", "application/json")
+	reqMove.Header.Add("Auth-Token", authToken)
+	testResponseMove := executeRequest(ta, reqMove)
+	checkResponseCode(t, http.StatusAccepted, testResponseMove.Code)
+	// The transition is recorded in the history of the task
+	req, err := http.NewRequest("GET", "/tasks/000000000000000000000021/history", nil)
+	if err != nil {
+		t.Errorf("TestTaskWorkflow() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	testResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	var history struct {
+		History []*models.TaskHistory `json:"history"`
+	}
+	if err = json.NewDecoder(testResponse.Body).Decode(&history); err != nil {
+		t.Errorf("TestTaskWorkflow() error = %v", err)
+	}
+	// Clean database and do final status check
+	if len(history.History) != 1 || history.History[0].To != "IN_REVIEW" || history.History[0].ActorId != user.Id {
+		t.Errorf("TestTaskWorkflow() history = %+v", history.History)
+	}
+}
+
+// TestTaskDependencies Test
+func TestTaskDependencies(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestUser(ta, 1)
+	createTestTask(ta, 1)
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, adminResponse.Code)
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	// Create a subtask of the task that is blocked by it
+	subtask := []byte(`{"name":"subTask","due":"2030-01-01T00:00:00Z","user_id":"000000000000000000000012","group_id":"000000000000000000000002","parent_id":"000000000000000000000021","blocked_by":["000000000000000000000021"]}`)
+	reqCreate, err := http.NewRequest("POST", "/tasks", bytes.NewBuffer(subtask))
+	if err != nil {
+		t.Errorf("TestTaskDependencies() error = %v", err)
+	}
+	reqCreate.Header.Add("Content-Type", "application/json")
+	reqCreate.Header.Add("Auth-Token", adminToken)
+	testResponseCreate := executeRequest(ta, reqCreate)
+	checkResponseCode(t, http.StatusCreated, testResponseCreate.Code)
+	var created models.Task
+	if err = json.NewDecoder(testResponseCreate.Body).Decode(&created); err != nil {
+		t.Errorf("TestTaskDependencies() error = %v", err)
+	}
+	// Links that would create a cycle are rejected
+	for _, payload := range []string{`{"blocked_by":["` + created.Id + `"]}`, `{"parent_id":"` + created.Id + `"}`} {
+		req, err := http.NewRequest("PATCH", "/tasks/000000000000000000000021", bytes.NewBuffer([]byte(payload)))
+		if err != nil {
+			t.Errorf("TestTaskDependencies() error = %v", err)
+		}
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("Auth-Token", adminToken)
+		testResponse := executeRequest(ta, req)
+		checkResponseCode(t, http.StatusConflict, testResponse.Code)
+	}
+	// Unknown expansions are rejected
+	reqErr, err := http.NewRequest("GET", "/tasks/000000000000000000000021?expand=owners", nil)
+	if err != nil {
+		t.Errorf("TestTaskDependencies() error = %v", err)
+	}
+	reqErr.Header.Add("Content-Type", "application/json")
+	reqErr.Header.Add("Auth-Token", adminToken)
+	testResponseErr := executeRequest(ta, reqErr)
+	checkResponseCode(t, http.StatusBadRequest, testResponseErr.Code)
+	// Expand the subtasks and dependency graph of the first task
+	req, err := http.NewRequest("GET", "/tasks/000000000000000000000021?expand=subtasks,dependencies", nil)
+	if err != nil {
+		t.Errorf("TestTaskDependencies() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", adminToken)
+	testResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	var detail struct {
+		Id           string             `json:"id"`
+		Subtasks     []*models.TaskTree `json:"subtasks"`
+		Dependencies *models.TaskGraph  `json:"dependencies"`
+	}
+	if err = json.NewDecoder(testResponse.Body).Decode(&detail); err != nil {
+		t.Errorf("TestTaskDependencies() error = %v", err)
+	}
+	// Clean database and do final status check
+	if detail.Id != "000000000000000000000021" || len(detail.Subtasks) != 1 || detail.Subtasks[0].Id != created.Id {
+		t.Errorf("TestTaskDependencies() subtasks = %+v", detail.Subtasks)
+	}
+	if detail.Dependencies == nil || len(detail.Dependencies.Tasks) != 2 || len(detail.Dependencies.Edges) != 1 || detail.Dependencies.Edges[0].BlockedBy != "000000000000000000000021" {
+		t.Errorf("TestTaskDependencies() dependencies = %+v", detail.Dependencies)
+	}
+}
+
+// TestTaskComments Test
+func TestTaskRecurrence(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestUser(ta, 1)
+	createTestTask(ta, 1)
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, adminResponse.Code)
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	recurrenceRequest := func(method string, url string, payload string) *http.Request {
+		req, err := http.NewRequest(method, url, bytes.NewBuffer([]byte(payload)))
+		if err != nil {
+			t.Errorf("TestTaskRecurrence() error = %v", err)
+		}
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("Auth-Token", adminToken)
+		return req
+	}
+	// Invalid rules are rejected
+	testResponseErr := executeRequest(ta, recurrenceRequest("PUT", "/tasks/000000000000000000000021/recurrence", `{"rrule":"FREQ=HOURLY"}`))
+	checkResponseCode(t, http.StatusBadRequest, testResponseErr.Code)
+	// Repeat the task daily on schedule
+	testResponseSet := executeRequest(ta, recurrenceRequest("PUT", "/tasks/000000000000000000000021/recurrence", `{"rrule":"FREQ=DAILY;COUNT=2","generate":"schedule"}`))
+	checkResponseCode(t, http.StatusOK, testResponseSet.Code)
+	var series models.TaskSeries
+	if err := json.NewDecoder(testResponseSet.Body).Decode(&series); err != nil {
+		t.Errorf("TestTaskRecurrence() error = %v", err)
+	}
+	if series.TaskId != "000000000000000000000021" || series.RRule != "FREQ=DAILY;COUNT=2" || !series.NextDue.Equal(series.Due.AddDate(0, 0, 1)) {
+		t.Errorf("TestTaskRecurrence() series = %+v", series)
+	}
+	// The scheduler creates the next instance once the task is due, and only once
+	scheduler := services.NewTaskScheduler(ta.server.TaskSeriesService, testClock{series.Due}, time.Minute)
+	for _, want := range []int{1, 0} {
+		created, err := scheduler.Run(context.Background())
+		if err != nil || created != want {
+			t.Errorf("TaskScheduler.Run() = %v, %v, want %v", created, err, want)
+		}
+	}
+	testResponseGet := executeRequest(ta, recurrenceRequest("GET", "/tasks/000000000000000000000021/recurrence", ""))
+	checkResponseCode(t, http.StatusOK, testResponseGet.Code)
+	var current models.TaskSeries
+	if err := json.NewDecoder(testResponseGet.Body).Decode(&current); err != nil {
+		t.Errorf("TestTaskRecurrence() error = %v", err)
+	}
+	if current.Id != series.Id || current.TaskId == series.TaskId || current.Occurrence != 2 {
+		t.Errorf("TestTaskRecurrence() current series = %+v", current)
+	}
+	// Only the current instance can be skipped, and the last occurrence can not be skipped
+	testResponseSkip := executeRequest(ta, recurrenceRequest("POST", "/tasks/000000000000000000000021/recurrence/skip", ""))
+	checkResponseCode(t, http.StatusConflict, testResponseSkip.Code)
+	testResponseSkip = executeRequest(ta, recurrenceRequest("POST", "/tasks/"+current.TaskId+"/recurrence/skip", ""))
+	checkResponseCode(t, http.StatusConflict, testResponseSkip.Code)
+	// Stop the series
+	testResponseStop := executeRequest(ta, recurrenceRequest("DELETE", "/tasks/"+current.TaskId+"/recurrence", ""))
+	checkResponseCode(t, http.StatusOK, testResponseStop.Code)
+	// Clean database and do final status check
+	testResponse := executeRequest(ta, recurrenceRequest("GET", "/tasks/"+current.TaskId+"/recurrence", ""))
+	checkResponseCode(t, http.StatusNotFound, testResponse.Code)
+}
+
+func TestTaskCalendar(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestUser(ta, 1)
+	createTestTask(ta, 1)
+	userResponse := signIn(ta, "test2@email.com", "abc123")
+	checkResponseCode(t, http.StatusOK, userResponse.Code)
+	userToken := userResponse.Header().Get("Auth-Token")
+	calendarRequest := func(method string, url string, body []byte, contentType string) *http.Request {
+		req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+		if err != nil {
+			t.Errorf("TestTaskCalendar() error = %v", err)
+		}
+		req.Header.Add("Content-Type", contentType)
+		req.Header.Add("Auth-Token", userToken)
+		return req
+	}
+	// Feeds of other users can not be subscribed to
+	testResponseErr := executeRequest(ta, calendarRequest("POST", "/auth/feeds", []byte(`{"name":"phone","feed_type":"user","feed_id":"000000000000000000000013"}`), "application/json"))
+	checkResponseCode(t, http.StatusUnauthorized, testResponseErr.Code)
+	// Import a todo and an event into the group of the session
+	ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:todo-1\r\nSUMMARY:Renew domain\r\nDUE:20300101T090000Z\r\nPRIORITY:1\r\nEND:VTODO\r\n" +
+		"BEGIN:VEVENT\r\nUID:event-1\r\nSUMMARY:Planning\r\nDTSTART:20300102T100000Z\r\nEND:VEVENT\r\nBEGIN:VTODO\r\nUID:todo-2\r\nSUMMARY:Undated\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	testResponseImport := executeRequest(ta, calendarRequest("POST", "/tasks/import", []byte(ics), "text/calendar"))
+	checkResponseCode(t, http.StatusCreated, testResponseImport.Code)
+	var imported struct {
+		Tasks   []*models.Task `json:"tasks"`
+		Skipped []struct {
+			UID string `json:"uid"`
+		} `json:"skipped"`
+	}
+	if err := json.NewDecoder(testResponseImport.Body).Decode(&imported); err != nil {
+		t.Errorf("TestTaskCalendar() error = %v", err)
+	}
+	if len(imported.Tasks) != 2 || imported.Tasks[0].Priority != models.URGENT || imported.Tasks[1].GroupId != "000000000000000000000002" {
+		t.Errorf("TestTaskCalendar() imported = %+v", imported.Tasks)
+	}
+	if len(imported.Skipped) != 1 || imported.Skipped[0].UID != "todo-2" {
+		t.Errorf("TestTaskCalendar() skipped = %+v", imported.Skipped)
+	}
+	// Subscribe to the feed of the user
+	testResponseFeed := executeRequest(ta, calendarRequest("POST", "/auth/feeds", []byte(`{"name":"phone","feed_type":"user"}`), "application/json"))
+	checkResponseCode(t, http.StatusCreated, testResponseFeed.Code)
+	var feed models.FeedToken
+	if err := json.NewDecoder(testResponseFeed.Body).Decode(&feed); err != nil {
+		t.Errorf("TestTaskCalendar() error = %v", err)
+	}
+	if feed.URL != "/users/000000000000000000000012/tasks.ics?token="+feed.Token {
+		t.Errorf("TestTaskCalendar() feed url = %v", feed.URL)
+	}
+	// The feed is read with its token only
+	reqCalendar, _ := http.NewRequest("GET", feed.URL, nil)
+	testResponseCalendar := executeRequest(ta, reqCalendar)
+	checkResponseCode(t, http.StatusOK, testResponseCalendar.Code)
+	calendar := testResponseCalendar.Body.String()
+	if testResponseCalendar.Header().Get("Content-Type") != "text/calendar; charset=UTF-8" || strings.Count(calendar, "BEGIN:VTODO") != 3 || !strings.Contains(calendar, "SUMMARY:Renew domain") {
+		t.Errorf("TestTaskCalendar() calendar = %v", calendar)
+	}
+	reqOther, _ := http.NewRequest("GET", "/users/000000000000000000000013/tasks.ics?token="+feed.Token, nil)
+	checkResponseCode(t, http.StatusUnauthorized, executeRequest(ta, reqOther).Code)
+	// Revoked feed tokens can no longer read the feed
+	testResponseRevoke := executeRequest(ta, calendarRequest("DELETE", "/auth/feeds/"+feed.Id, nil, "application/json"))
+	checkResponseCode(t, http.StatusOK, testResponseRevoke.Code)
+	// Clean database and do final status check
+	reqRevoked, _ := http.NewRequest("GET", feed.URL, nil)
+	checkResponseCode(t, http.StatusUnauthorized, executeRequest(ta, reqRevoked).Code)
+}
+
+func TestSearch(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	createTestUser(ta, 1)
+	createTestUser(ta, 2)
+	createTestTask(ta, 1)
+	createTestTask(ta, 2)
+	userResponse := signIn(ta, "test2@email.com", "abc123")
+	checkResponseCode(t, http.StatusOK, userResponse.Code)
+	userToken := userResponse.Header().Get("Auth-Token")
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, adminResponse.Code)
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	search := func(token string, query string) (int, []*models.SearchHit) {
+		req, err := http.NewRequest("GET", "/search?"+query, nil)
+		if err != nil {
+			t.Errorf("TestSearch() error = %v", err)
+		}
+		req.Header.Add("Auth-Token", token)
+		response := executeRequest(ta, req)
+		var dto struct {
+			Hits []*models.SearchHit `json:"hits"`
+		}
+		if response.Code == http.StatusOK {
+			if err = json.NewDecoder(response.Body).Decode(&dto); err != nil {
+				t.Errorf("TestSearch() error = %v", err)
+			}
+		}
+		return response.Code, dto.Hits
+	}
+	// Task descriptions are searched within the group of the session
+	testCode, hits := search(userToken, "q=task")
+	checkResponseCode(t, http.StatusOK, testCode)
+	if len(hits) != 2 || hits[0].Type != models.SearchTask || hits[0].Task == nil {
+		t.Errorf("TestSearch() task hits = %+v", hits)
+	}
+	// Users are found by name without their passwords
+	testCode, hits = search(userToken, "q=tester")
+	checkResponseCode(t, http.StatusOK, testCode)
+	if len(hits) != 1 || hits[0].Id != "000000000000000000000012" || hits[0].User == nil || hits[0].User.Password != "" {
+		t.Errorf("TestSearch() user hits = %+v", hits)
+	}
+	// Users and groups outside the scope of the session are left out
+	testCode, hits = search(userToken, "q=quality+test3")
+	checkResponseCode(t, http.StatusOK, testCode)
+	if len(hits) != 0 {
+		t.Errorf("TestSearch() out of scope hits = %+v", hits)
+	}
+	testCode, hits = search(adminToken, "q=quality+test3&limit=5")
+	checkResponseCode(t, http.StatusOK, testCode)
+	if len(hits) != 2 || hits[0].Type != models.SearchGroup || hits[1].Id != "000000000000000000000013" {
+		t.Errorf("TestSearch() admin hits = %+v", hits)
+	}
+	// Invalid searches
+	testCode, _ = search(userToken, "q=")
+	checkResponseCode(t, http.StatusBadRequest, testCode)
+	testCode, _ = search(userToken, "q=task&limit=1000")
+	checkResponseCode(t, http.StatusBadRequest, testCode)
+	testCode, _ = search("", "q=task")
+	checkResponseCode(t, http.StatusUnauthorized, testCode)
+}
+
+func TestAuditLog(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestUser(ta, 1)
+	createTestTask(ta, 1)
+	userResponse := signIn(ta, "test2@email.com", "abc123")
+	checkResponseCode(t, http.StatusOK, userResponse.Code)
+	userToken := userResponse.Header().Get("Auth-Token")
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, adminResponse.Code)
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	auditRequest := func(method string, url string, body []byte, token string) *http.Request {
+		req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+		if err != nil {
+			t.Errorf("TestAuditLog() error = %v", err)
+		}
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("Auth-Token", token)
+		return req
+	}
+	auditEvents := func(url string, token string) []*models.AuditEvent {
+		response := executeRequest(ta, auditRequest("GET", url, nil, token))
+		checkResponseCode(t, http.StatusOK, response.Code)
+		var dto struct {
+			AuditEvents []*models.AuditEvent `json:"audit_events"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&dto); err != nil {
+			t.Errorf("TestAuditLog() error = %v", err)
+		}
+		return dto.AuditEvents
+	}
+	// Mutating calls are recorded with the changes they made
+	testResponseTask := executeRequest(ta, auditRequest("PATCH", "/tasks/000000000000000000000021", getTestTaskPayload("UPDATE"), userToken))
+	checkResponseCode(t, http.StatusAccepted, testResponseTask.Code)
+	requestId := testResponseTask.Header().Get("X-Request-Id")
+	events := auditEvents("/audit?request_id="+requestId, adminToken)
+	if len(events) != 1 || events[0].Action != "PATCH /tasks/{taskId}" || events[0].ActorId != "000000000000000000000012" || events[0].GroupId != "000000000000000000000002" || events[0].ResourceId != "000000000000000000000021" || events[0].Status != http.StatusAccepted {
+		t.Errorf("TestAuditLog() task events = %+v", events)
+	} else if change := events[0].Changes["name"]; change == nil || string(change.Before) != `"testTask"` || string(change.After) != `"NewTestTask"` {
+		t.Errorf("TestAuditLog() task changes = %+v", events[0].Changes)
+	}
+	// Password hashes are redacted
+	testResponseUser := executeRequest(ta, auditRequest("PATCH", "/users/000000000000000000000012", []byte(`{"role":"admin","password":"xyz789"}`), adminToken))
+	checkResponseCode(t, http.StatusAccepted, testResponseUser.Code)
+	events = auditEvents("/audit?request_id="+testResponseUser.Header().Get("X-Request-Id"), adminToken)
+	if len(events) != 1 || events[0].ResourceType != "users" || string(events[0].Changes["role"].After) != `"admin"` || string(events[0].Changes["password"].After) != `"`+models.AuditRedacted+`"` {
+		t.Errorf("TestAuditLog() user events = %+v", events)
+	}
+	// Group admins see the events of the records of their own group, including the changes root admins made to them
+	events = auditEvents("/audit", userToken)
+	if len(events) != 2 || events[0].RequestId != requestId || events[1].ResourceId != "000000000000000000000012" || events[1].ActorId == "000000000000000000000012" {
+		t.Errorf("TestAuditLog() group events = %+v", events)
+	}
+	testResponseRole := executeRequest(ta, auditRequest("POST", "/roles", []byte(`{"name":"auditor","permissions":["tasks.read.any"]}`), userToken))
+	checkResponseCode(t, http.StatusCreated, testResponseRole.Code)
+	events = auditEvents("/audit?request_id="+testResponseRole.Header().Get("X-Request-Id"), userToken)
+	if len(events) != 1 || events[0].ResourceType != "roles" || events[0].GroupId != "000000000000000000000002" || string(events[0].Changes["name"].After) != `"auditor"` {
+		t.Errorf("TestAuditLog() role events = %+v", events)
+	}
+	if events = auditEvents("/audit?group_id=000000000000000000000001", userToken); len(events) != 0 {
+		t.Errorf("TestAuditLog() other group events = %+v", events)
+	}
+	// Members can not read the audit log
+	testResponseDemote := executeRequest(ta, auditRequest("PATCH", "/users/000000000000000000000012", []byte(`{"role":"member"}`), adminToken))
+	checkResponseCode(t, http.StatusAccepted, testResponseDemote.Code)
+	checkResponseCode(t, http.StatusUnauthorized, executeRequest(ta, auditRequest("GET", "/audit", nil, userToken)).Code)
+}
+
+func TestWebhooks(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestUser(ta, 1)
+	createTestTask(ta, 1)
+	userResponse := signIn(ta, "test2@email.com", "abc123")
+	checkResponseCode(t, http.StatusOK, userResponse.Code)
+	userToken := userResponse.Header().Get("Auth-Token")
+	adminResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	checkResponseCode(t, http.StatusOK, adminResponse.Code)
+	adminToken := adminResponse.Header().Get("Auth-Token")
+	receiver := newWebhookReceiver(t, http.StatusOK)
+	webhookRequest := func(method string, url string, body []byte, token string) *http.Request {
+		req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+		if err != nil {
+			t.Errorf("TestWebhooks() error = %v", err)
+		}
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("Auth-Token", token)
+		return req
+	}
+	// Members can not register webhooks
+	payload := []byte(`{"url":"` + receiver.URL() + `","events":["task.status_changed","user.deleted"],"group_id":"000000000000000000000002"}`)
+	checkResponseCode(t, http.StatusForbidden, executeRequest(ta, webhookRequest("POST", "/webhooks", payload, userToken)).Code)
+	// Webhooks can not be registered for local addresses
+	for _, local := range []string{receiver.server.URL, "http://localhost:8081", "http://169.254.169.254/latest/meta-data", "http://10.0.0.5"} {
+		localPayload := []byte(`{"url":"` + local + `","events":["task.created"],"group_id":"000000000000000000000002"}`)
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(ta, webhookRequest("POST", "/webhooks", localPayload, adminToken)).Code)
+	}
+	// Deliveries never connect to local addresses, nor follow redirects
+	client := services.NewWebhookClient()
+	if _, err := client.Post(receiver.server.URL, "application/json", nil); !errors.Is(err, models.ErrWebhookAddress) || len(receiver.requests) != 0 {
+		t.Errorf("TestWebhooks() local delivery error = %v, received %v", err, len(receiver.requests))
+	}
+	if err := client.CheckRedirect(httptest.NewRequest("POST", receiver.URL(), nil), nil); err != http.ErrUseLastResponse {
+		t.Errorf("TestWebhooks() redirect = %v, want %v", err, http.ErrUseLastResponse)
+	}
+	// The secret of a webhook is only returned when it is registered
+	testResponseCreate := executeRequest(ta, webhookRequest("POST", "/webhooks", payload, adminToken))
+	checkResponseCode(t, http.StatusCreated, testResponseCreate.Code)
+	var webhook models.Webhook
+	if err := json.NewDecoder(testResponseCreate.Body).Decode(&webhook); err != nil || webhook.Secret == "" || webhook.GroupId != "000000000000000000000002" {
+		t.Fatalf("TestWebhooks() webhook = %+v, %v", webhook, err)
+	}
+	testResponseGet := executeRequest(ta, webhookRequest("GET", "/webhooks/"+webhook.Id, nil, adminToken))
+	checkResponseCode(t, http.StatusOK, testResponseGet.Code)
+	if strings.Contains(testResponseGet.Body.String(), webhook.Secret) {
+		t.Errorf("TestWebhooks() secret exposed = %v", testResponseGet.Body.String())
+	}
+	checkResponseCode(t, http.StatusBadRequest, executeRequest(ta, webhookRequest("PATCH", "/webhooks/"+webhook.Id, []byte(`{"events":["task.archived"]}`), adminToken)).Code)
+	// A status change is delivered to the endpoint, signed with the secret
+	testResponseTask := executeRequest(ta, webhookRequest("PATCH", "/tasks/000000000000000000000021", []byte(`{"status":"IN_PROGRESS"}`), userToken))
+	checkResponseCode(t, http.StatusAccepted, testResponseTask.Code)
+	dispatcher := services.NewWebhookDispatcher(ta.server.WebhookService, receiver.Client(), testClock{time.Now().UTC()}, time.Minute)
+	if attempted, err := dispatcher.Run(context.Background()); attempted != 1 || err != nil {
+		t.Fatalf("WebhookDispatcher.Run() = %v, %v, want 1", attempted, err)
+	}
+	if len(receiver.requests) != 1 {
+		t.Fatalf("TestWebhooks() received %v deliveries, want 1", len(receiver.requests))
+	}
+	req, body := receiver.requests[0], receiver.bodies[0]
+	timestamp, _ := strconv.ParseInt(req.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	if req.Header.Get("X-Webhook-Signature") != "sha256="+models.SignWebhookPayload(webhook.Secret, timestamp, body) || req.Header.Get("X-Webhook-Event") != models.EventTaskStatusChanged {
+		t.Errorf("TestWebhooks() delivery headers = %v", req.Header)
+	}
+	var event models.Event
+	var task models.Task
+	if err := json.Unmarshal(body, &event); err != nil || json.Unmarshal(event.Data, &task) != nil || task.Id != "000000000000000000000021" || task.Status != models.INPROGRESS {
+		t.Errorf("TestWebhooks() delivery = %v", string(body))
+	}
+	// A failed delivery is retried later and logged
+	receiver.status = http.StatusInternalServerError
+	checkResponseCode(t, http.StatusOK, executeRequest(ta, webhookRequest("DELETE", "/users/000000000000000000000012", nil, adminToken)).Code)
+	dispatcher = services.NewWebhookDispatcher(ta.server.WebhookService, receiver.Client(), testClock{time.Now().UTC()}, time.Minute)
+	if attempted, err := dispatcher.Run(context.Background()); attempted != 1 || err != nil {
+		t.Fatalf("WebhookDispatcher.Run() = %v, %v, want 1", attempted, err)
+	}
+	if attempted, _ := dispatcher.Run(context.Background()); attempted != 0 {
+		t.Errorf("WebhookDispatcher.Run() retried before its backoff = %v", attempted)
+	}
+	testResponseLog := executeRequest(ta, webhookRequest("GET", "/webhooks/"+webhook.Id+"/deliveries", nil, adminToken))
+	checkResponseCode(t, http.StatusOK, testResponseLog.Code)
+	var dto struct {
+		Deliveries []*models.WebhookDelivery `json:"deliveries"`
+		Total      int64                     `json:"total"`
+	}
+	if err := json.NewDecoder(testResponseLog.Body).Decode(&dto); err != nil || dto.Total != 2 {
+		t.Fatalf("TestWebhooks() deliveries = %+v, %v", dto, err)
+	}
+	if d := dto.Deliveries[0]; d.Status != models.DeliverySucceeded || d.Attempts != 1 || d.ResponseStatus != http.StatusOK {
+		t.Errorf("TestWebhooks() first delivery = %+v", d)
+	}
+	if d := dto.Deliveries[1]; d.Event != models.EventUserDeleted || d.Status != models.DeliveryPending || d.Attempts != 1 || d.ResponseStatus != http.StatusInternalServerError || d.Error == "" {
+		t.Errorf("TestWebhooks() second delivery = %+v", d)
+	}
+	if strings.Contains(string(dto.Deliveries[1].Payload), "password") {
+		t.Errorf("TestWebhooks() user payload = %v", string(dto.Deliveries[1].Payload))
+	}
+}
+
+func TestEventStreams(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	createTestUser(ta, 1)
+	createTestUser(ta, 2)
+	createTestTask(ta, 1)
+	userResponse := signIn(ta, "test2@email.com", "abc123")
+	checkResponseCode(t, http.StatusOK, userResponse.Code)
+	userToken := userResponse.Header().Get("Auth-Token")
+	otherResponse := signIn(ta, "test3@email.com.com", "abc123")
+	checkResponseCode(t, http.StatusOK, otherResponse.Code)
+	otherToken := otherResponse.Header().Get("Auth-Token")
+	api := httptest.NewServer(ta.server.Router)
+	t.Cleanup(api.Close)
+	streamRequest := func(url string, token string) *http.Request {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Errorf("TestEventStreams() error = %v", err)
+		}
+		if token != "" {
+			req.Header.Add("Auth-Token", token)
+		}
+		return req
+	}
+	// Streams are authenticated and scoped to the group of the session
+	checkResponseCode(t, http.StatusUnauthorized, executeRequest(ta, streamRequest("/events/stream", "")).Code)
+	checkResponseCode(t, http.StatusUnauthorized, executeRequest(ta, streamRequest("/events/stream?group_id=000000000000000000000003", userToken)).Code)
+	checkResponseCode(t, http.StatusBadRequest, executeRequest(ta, streamRequest("/events/stream?types=task.archived", userToken)).Code)
+	client := &http.Client{Timeout: 10 * time.Second}
+	sseResponse, err := client.Do(streamRequest(api.URL+"/events/stream", userToken))
+	if err != nil {
+		t.Fatalf("TestEventStreams() error = %v", err)
+	}
+	defer sseResponse.Body.Close()
+	checkResponseCode(t, http.StatusOK, sseResponse.StatusCode)
+	if contentType := sseResponse.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("TestEventStreams() Content-Type = %v", contentType)
+	}
+	sse := bufio.NewReader(sseResponse.Body)
+	if line, err := sse.ReadString('\n'); err != nil || line != ": connected\n" {
+		t.Fatalf("TestEventStreams() stream = %q, %v", line, err)
+	}
+	wsURL := "ws" + strings.TrimPrefix(api.URL, "http") + "/events/ws"
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL+"?types=task.status_changed", http.Header{"Auth-Token": {userToken}})
+	if err != nil {
+		t.Fatalf("TestEventStreams() websocket error = %v", err)
+	}
+	defer ws.Close()
+	otherWs, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Auth-Token": {otherToken}})
+	if err != nil {
+		t.Fatalf("TestEventStreams() websocket error = %v", err)
+	}
+	defer otherWs.Close()
+	// The handshake is authenticated before it is upgraded, and browsers can only connect from an allowed origin
+	if _, resp, err := websocket.DefaultDialer.Dial(wsURL, nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("TestEventStreams() unauthenticated websocket = %v, %v", resp, err)
+	}
+	if _, resp, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Auth-Token": {userToken}, "Origin": {"https://evil.example.com"}}); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("TestEventStreams() cross origin websocket = %v, %v", resp, err)
+	}
+	browserWs, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Auth-Token": {userToken}, "Origin": {"http://localhost:3000"}})
+	if err != nil {
+		t.Fatalf("TestEventStreams() websocket error = %v", err)
+	}
+	browserWs.Close()
+	for origin, want := range map[string]string{"http://localhost:3000": "http://localhost:3000", "https://evil.example.com": ""} {
+		req, _ := http.NewRequest("GET", "/tasks", nil)
+		req.Header.Add("Auth-Token", userToken)
+		req.Header.Add("Origin", origin)
+		if got := executeRequest(ta, req).Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("TestEventStreams() Access-Control-Allow-Origin for %v = %q, want %q", origin, got, want)
+		}
+	}
+	// A change of a task is pushed to the streams of its group
+	req, _ := http.NewRequest("PATCH", "/tasks/000000000000000000000021", bytes.NewBuffer([]byte(`{"status":"IN_PROGRESS"}`)))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", userToken)
+	checkResponseCode(t, http.StatusAccepted, executeRequest(ta, req).Code)
+	for _, want := range []string{models.EventTaskUpdated, models.EventTaskStatusChanged} {
+		eventType, event, err := readStreamEvent(sse)
+		var task models.Task
+		if err != nil || eventType != want || event.Type != want || json.Unmarshal(event.Data, &task) != nil || task.Id != "000000000000000000000021" {
+			t.Fatalf("TestEventStreams() stream event = %v, %+v, %v, want %v", eventType, event, err, want)
+		}
+	}
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event models.Event
+	if err = ws.ReadJSON(&event); err != nil || event.Type != models.EventTaskStatusChanged || event.GroupId != "000000000000000000000002" {
+		t.Errorf("TestEventStreams() websocket event = %+v, %v", event, err)
+	}
+	// Streams of other groups do not receive it
+	_ = otherWs.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, msg, err := otherWs.ReadMessage(); err == nil {
+		t.Errorf("TestEventStreams() other group received = %v", string(msg))
+	}
+}
+
+func TestTaskComments(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	user := createTestUser(ta, 1)
+	outsider := createTestUser(ta, 2)
+	createTestTask(ta, 1)
+	authResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
+	authToken := authResponse.Header().Get("Auth-Token")
+	outsiderResponse := signIn(ta, outsider.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, outsiderResponse.Code)
+	outsiderToken := outsiderResponse.Header().Get("Auth-Token")
+	// Comment on the task, users outside of the group are not resolved as mentions
+	payload := []byte(`{"body":"**Note** for @test_user and @test_user2"}`)
+	reqCreate, err := http.NewRequest("POST", "/tasks/000000000000000000000021/comments", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Errorf("TestTaskComments() error = %v", err)
+	}
+	reqCreate.Header.Add("Content-Type", "application/json")
+	reqCreate.Header.Add("Auth-Token", authToken)
+	testResponseCreate := executeRequest(ta, reqCreate)
+	checkResponseCode(t, http.StatusCreated, testResponseCreate.Code)
+	var comment models.Comment
+	if err = json.NewDecoder(testResponseCreate.Body).Decode(&comment); err != nil {
+		t.Errorf("TestTaskComments() error = %v", err)
+	}
+	if comment.UserId != user.Id || len(comment.Mentions) != 1 || comment.Mentions[0] != user.Id {
+		t.Errorf("TestTaskComments() comment = %+v", comment)
+	}
+	// Users of other groups can not edit the comment
+	reqErr, err := http.NewRequest("PATCH", "/tasks/000000000000000000000021/comments/"+comment.Id, bytes.NewBuffer([]byte(`{"body":"edited"}`)))
+	if err != nil {
+		t.Errorf("TestTaskComments() error = %v", err)
+	}
+	reqErr.Header.Add("Content-Type", "application/json")
+	reqErr.Header.Add("Auth-Token", outsiderToken)
+	testResponseErr := executeRequest(ta, reqErr)
+	checkResponseCode(t, http.StatusNotFound, testResponseErr.Code)
+	// The author can edit the comment
+	reqEdit, err := http.NewRequest("PATCH", "/tasks/000000000000000000000021/comments/"+comment.Id, bytes.NewBuffer([]byte(`{"body":"edited"}`)))
+	if err != nil {
+		t.Errorf("TestTaskComments() error = %v", err)
+	}
+	reqEdit.Header.Add("Content-Type", "application/json")
+	reqEdit.Header.Add("Auth-Token", authToken)
+	testResponseEdit := executeRequest(ta, reqEdit)
+	checkResponseCode(t, http.StatusAccepted, testResponseEdit.Code)
+	// The comment shows up in the activity feed of the group
+	reqFeed, err := http.NewRequest("GET", "/groups/000000000000000000000002/activity", nil)
+	if err != nil {
+		t.Errorf("TestTaskComments() error = %v", err)
+	}
+	reqFeed.Header.Add("Content-Type", "application/json")
+	reqFeed.Header.Add("Auth-Token", authToken)
+	testResponseFeed := executeRequest(ta, reqFeed)
+	checkResponseCode(t, http.StatusOK, testResponseFeed.Code)
+	var feed struct {
+		Activity []*models.Activity `json:"activity"`
+	}
+	if err = json.NewDecoder(testResponseFeed.Body).Decode(&feed); err != nil {
+		t.Errorf("TestTaskComments() error = %v", err)
+	}
+	if len(feed.Activity) != 2 || feed.Activity[0].Type != models.ActivityCommentCreated || feed.Activity[0].Body != "edited" {
+		t.Errorf("TestTaskComments() activity = %+v", feed.Activity)
+	}
+	// The author can delete the comment
+	reqDelete, err := http.NewRequest("DELETE", "/tasks/000000000000000000000021/comments/"+comment.Id, nil)
+	if err != nil {
+		t.Errorf("TestTaskComments() error = %v", err)
+	}
+	reqDelete.Header.Add("Content-Type", "application/json")
+	reqDelete.Header.Add("Auth-Token", authToken)
+	testResponseDelete := executeRequest(ta, reqDelete)
+	checkResponseCode(t, http.StatusOK, testResponseDelete.Code)
+	req, err := http.NewRequest("GET", "/tasks/000000000000000000000021/comments", nil)
+	if err != nil {
+		t.Errorf("TestTaskComments() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	testResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	var comments struct {
+		Comments []*models.Comment `json:"comments"`
+	}
+	if err = json.NewDecoder(testResponse.Body).Decode(&comments); err != nil {
+		t.Errorf("TestTaskComments() error = %v", err)
+	}
+	// Clean database and do final status check
+	if len(comments.Comments) != 0 {
+		t.Errorf("TestTaskComments() comments = %+v", comments.Comments)
+	}
+}
+
+// TestTaskAttachments Test
+func TestTaskAttachments(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestGroup(ta, 2)
+	user := createTestUser(ta, 1)
+	outsider := createTestUser(ta, 2)
+	createTestTask(ta, 1)
+	authResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
+	authToken := authResponse.Header().Get("Auth-Token")
+	outsiderResponse := signIn(ta, outsider.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, outsiderResponse.Code)
+	outsiderToken := outsiderResponse.Header().Get("Auth-Token")
+	// Users of other groups can not see the attachments of the task
+	reqErr, err := http.NewRequest("GET", "/tasks/000000000000000000000021/attachments", nil)
+	if err != nil {
+		t.Errorf("TestTaskAttachments() error = %v", err)
+	}
+	reqErr.Header.Add("Content-Type", "application/json")
+	reqErr.Header.Add("Auth-Token", outsiderToken)
+	testResponseErr := executeRequest(ta, reqErr)
+	checkResponseCode(t, http.StatusNotFound, testResponseErr.Code)
+	// An upload needs a multipart file
+	reqUpload, err := http.NewRequest("POST", "/tasks/000000000000000000000021/attachments", bytes.NewBuffer([]byte(`{}`)))
+	if err != nil {
+		t.Errorf("TestTaskAttachments() error = %v", err)
+	}
+	reqUpload.Header.Add("Content-Type", "application/json")
+	reqUpload.Header.Add("Auth-Token", authToken)
+	testResponseUpload := executeRequest(ta, reqUpload)
+	checkResponseCode(t, http.StatusBadRequest, testResponseUpload.Code)
+	// Unknown attachments are not found
+	reqGet, err := http.NewRequest("GET", "/tasks/000000000000000000000021/attachments/000000000000000000000051", nil)
+	if err != nil {
+		t.Errorf("TestTaskAttachments() error = %v", err)
+	}
+	reqGet.Header.Add("Auth-Token", authToken)
+	testResponseGet := executeRequest(ta, reqGet)
+	checkResponseCode(t, http.StatusNotFound, testResponseGet.Code)
+	// Deleting the task cascades to its attachments
+	reqDelete, err := http.NewRequest("DELETE", "/tasks/000000000000000000000021", nil)
+	if err != nil {
+		t.Errorf("TestTaskAttachments() error = %v", err)
+	}
+	reqDelete.Header.Add("Content-Type", "application/json")
+	reqDelete.Header.Add("Auth-Token", authToken)
+	testResponseDelete := executeRequest(ta, reqDelete)
+	checkResponseCode(t, http.StatusOK, testResponseDelete.Code)
+	req, err := http.NewRequest("GET", "/tasks/000000000000000000000021/attachments", nil)
+	if err != nil {
+		t.Errorf("TestTaskAttachments() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	testResponse := executeRequest(ta, req)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusNotFound, testResponse.Code)
+}
+
 // TestListTask Test
 func TestListTask(t *testing.T) {
 	// Test Setup
@@ -478,3 +2382,76 @@ func TestDeleteTask(t *testing.T) {
 	// Clean database and do final status check
 	checkResponseCode(t, http.StatusOK, testResponse.Code)
 }
+
+// Restore Todos Test
+func TestRestoreTask(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	user := createTestUser(ta, 1)
+	createTestTask(ta, 1)
+	authResponse := signIn(ta, user.Email, "abc123")
+	checkResponseCode(t, http.StatusOK, authResponse.Code)
+	authToken := authResponse.Header().Get("Auth-Token")
+	// Delete a specific todos doc
+	reqDelete, err := http.NewRequest("DELETE", "/tasks/000000000000000000000021", nil)
+	if err != nil {
+		t.Errorf("TestRestoreTask() error = %v", err)
+	}
+	reqDelete.Header.Add("Content-Type", "application/json")
+	reqDelete.Header.Add("Auth-Token", authToken)
+	deleteTestResponse := executeRequest(ta, reqDelete)
+	checkResponseCode(t, http.StatusOK, deleteTestResponse.Code)
+	// Restore the deleted todos doc
+	req, err := http.NewRequest("POST", "/tasks/000000000000000000000021/restore", nil)
+	if err != nil {
+		t.Errorf("TestRestoreTask() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	testResponse := executeRequest(ta, req)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+}
+
+/*
+ADMIN TESTS
+*/
+
+// TestPurge Test
+func TestPurge(t *testing.T) {
+	// Test Setup
+	setup()
+	createTestGroup(ta, 1)
+	createTestUser(ta, 1)
+	authResponse := signIn(ta, os.Getenv("ROOT_EMAIL"), os.Getenv("ROOT_PASSWORD"))
+	authToken := authResponse.Header().Get("Auth-Token")
+	// Delete a user so there is a record to purge
+	reqDelete, err := http.NewRequest("DELETE", "/users/000000000000000000000012", nil)
+	if err != nil {
+		t.Errorf("TestPurge() error = %v", err)
+	}
+	reqDelete.Header.Add("Content-Type", "application/json")
+	reqDelete.Header.Add("Auth-Token", authToken)
+	deleteTestResponse := executeRequest(ta, reqDelete)
+	checkResponseCode(t, http.StatusOK, deleteTestResponse.Code)
+	// Purge all soft deleted records
+	req, err := http.NewRequest("POST", "/purge", nil)
+	if err != nil {
+		t.Errorf("TestPurge() error = %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Auth-Token", authToken)
+	testResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusOK, testResponse.Code)
+	// A purged user can no longer be restored
+	reqRestore, err := http.NewRequest("POST", "/users/000000000000000000000012/restore", nil)
+	if err != nil {
+		t.Errorf("TestPurge() error = %v", err)
+	}
+	reqRestore.Header.Add("Content-Type", "application/json")
+	reqRestore.Header.Add("Auth-Token", authToken)
+	restoreTestResponse := executeRequest(ta, reqRestore)
+	// Clean database and do final status check
+	checkResponseCode(t, http.StatusNotFound, restoreTestResponse.Code)
+}
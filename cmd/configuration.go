@@ -7,19 +7,42 @@ import (
 
 // configuration is a struct designed to hold the applications variable configuration settings
 type configuration struct {
-	MongoURI     string
-	Database     string
-	TokenSecret  string
-	RootAdmin    string
-	RootPassword string
-	RootEmail    string
-	RootGroup    string
-	Registration string
-	Port         string
-	HTTPS        string
-	Cert         string
-	Key          string
-	ENV          string
+	MongoURI              string
+	Database              string
+	TokenSecret           string
+	TokenAlgorithm        string
+	TokenSigningKey       string
+	TokenVerificationKeys string
+	RootAdmin             string
+	RootPassword          string
+	RootEmail             string
+	RootGroup             string
+	Registration          string
+	PurgeRetention        string
+	RefreshTokenTTL       string
+	TOTPIssuer            string
+	LoginMaxAttempts      string
+	LoginLockout          string
+	SchedulerInterval     string
+	WebhookInterval       string
+	DBReadTimeout         string
+	DBWriteTimeout        string
+	DBBulkTimeout         string
+	OIDCProviders         json.RawMessage
+	AppURL                string
+	CORSOrigins           string
+	Mailer                string
+	MailFrom              string
+	MailFile              string
+	SMTPHost              string
+	SMTPPort              string
+	SMTPUsername          string
+	SMTPPassword          string
+	Port                  string
+	HTTPS                 string
+	Cert                  string
+	Key                   string
+	ENV                   string
 }
 
 // getConfigurations is a function that reads a json configuration file and outputs a Configuration struct
@@ -46,11 +69,34 @@ func (c *configuration) InitializeEnvironmentalVars() {
 	os.Setenv("MONGO_URI", c.MongoURI)
 	os.Setenv("DATABASE", c.Database)
 	os.Setenv("TOKEN_SECRET", c.TokenSecret)
+	os.Setenv("TOKEN_ALGORITHM", c.TokenAlgorithm)
+	os.Setenv("TOKEN_SIGNING_KEY", c.TokenSigningKey)
+	os.Setenv("TOKEN_VERIFICATION_KEYS", c.TokenVerificationKeys)
 	os.Setenv("ROOT_ADMIN", c.RootAdmin)
 	os.Setenv("ROOT_PASSWORD", c.RootPassword)
 	os.Setenv("ROOT_EMAIL", c.RootEmail)
 	os.Setenv("ROOT_GROUP", c.RootGroup)
 	os.Setenv("REGISTRATION", c.Registration)
+	os.Setenv("PURGE_RETENTION", c.PurgeRetention)
+	os.Setenv("REFRESH_TOKEN_TTL", c.RefreshTokenTTL)
+	os.Setenv("TOTP_ISSUER", c.TOTPIssuer)
+	os.Setenv("LOGIN_MAX_ATTEMPTS", c.LoginMaxAttempts)
+	os.Setenv("LOGIN_LOCKOUT", c.LoginLockout)
+	os.Setenv("SCHEDULER_INTERVAL", c.SchedulerInterval)
+	os.Setenv("WEBHOOK_INTERVAL", c.WebhookInterval)
+	os.Setenv("DB_READ_TIMEOUT", c.DBReadTimeout)
+	os.Setenv("DB_WRITE_TIMEOUT", c.DBWriteTimeout)
+	os.Setenv("DB_BULK_TIMEOUT", c.DBBulkTimeout)
+	os.Setenv("OIDC_PROVIDERS", string(c.OIDCProviders))
+	os.Setenv("APP_URL", c.AppURL)
+	os.Setenv("CORS_ORIGINS", c.CORSOrigins)
+	os.Setenv("MAILER", c.Mailer)
+	os.Setenv("MAIL_FROM", c.MailFrom)
+	os.Setenv("MAIL_FILE", c.MailFile)
+	os.Setenv("SMTP_HOST", c.SMTPHost)
+	os.Setenv("SMTP_PORT", c.SMTPPort)
+	os.Setenv("SMTP_USERNAME", c.SMTPUsername)
+	os.Setenv("SMTP_PASSWORD", c.SMTPPassword)
 	os.Setenv("PORT", c.Port)
 	os.Setenv("HTTPS", c.HTTPS)
 	os.Setenv("CERT", c.Cert)
@@ -1,11 +1,25 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/dgrijalva/jwt-go"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 )
@@ -33,6 +47,178 @@ func signIn(ta App, email string, password string) *httptest.ResponseRecorder {
 	return response
 }
 
+// lastMailToken returns the token of the last link that the file mailer sent to an email address
+func lastMailToken(email string) string {
+	f, err := os.Open(os.Getenv("MAIL_FILE"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	var token string
+	re := regexp.MustCompile(`token=(\S+)`)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var m struct {
+			To   string `json:"to"`
+			Body string `json:"body"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &m) != nil || m.To != email {
+			continue
+		}
+		if match := re.FindStringSubmatch(m.Body); match != nil {
+			token, _ = url.QueryUnescape(match[1])
+		}
+	}
+	return token
+}
+
+// stubIdP is a local OpenID Connect identity provider that signs in whoever a test tells it to
+type stubIdP struct {
+	server *httptest.Server
+	key    *auth.SigningKey
+	codes  map[string]url.Values    // the authorization request each issued code was issued for
+	claims map[string]jwt.MapClaims // the ID token claims each issued code is redeemed for
+}
+
+// newStubIdP starts a stubIdP and registers it as the "stub" provider, provisioning new users into a group
+func newStubIdP(t *testing.T, groupId string) *stubIdP {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	key, err := auth.NewSigningKey("RS256", private)
+	if err != nil {
+		t.Fatalf("auth.NewSigningKey() error = %v", err)
+	}
+	idp := &stubIdP{key: key, codes: make(map[string]url.Values), claims: make(map[string]jwt.MapClaims)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(auth.NewKeySet(nil, idp.key).JWKS())
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		code := r.PostForm.Get("code")
+		authReq, ok := idp.codes[code]
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != authReq.Get("code_challenge") {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(idp.codes, code)
+		claims := idp.claims[code]
+		claims["iss"] = idp.server.URL
+		claims["aud"] = authReq.Get("client_id")
+		claims["nonce"] = authReq.Get("nonce")
+		claims["exp"] = time.Now().Add(time.Minute).Unix()
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = idp.key.Id
+		idToken, _ := token.SignedString(idp.key.Private)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "token_type": "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	auth.SetOIDCProviders(&auth.OIDCProvider{
+		Name:        "stub",
+		Issuer:      idp.server.URL,
+		ClientId:    "api",
+		RedirectURL: "http://localhost:8081/auth/oidc/stub/callback",
+		GroupId:     groupId,
+	})
+	t.Cleanup(func() { auth.SetOIDCProviders() })
+	return idp
+}
+
+// oidcSignIn signs in with the stubIdP as the account with the inputted claims and returns the callback response
+func (idp *stubIdP) oidcSignIn(t *testing.T, ta App, claims jwt.MapClaims) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/auth/oidc/stub/start", nil)
+	startResponse := executeRequest(ta, req)
+	checkResponseCode(t, http.StatusFound, startResponse.Code)
+	location, err := url.Parse(startResponse.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("oidcSignIn() error = %v", err)
+	}
+	code := base64.RawURLEncoding.EncodeToString([]byte(location.Query().Get("state")))
+	idp.codes[code] = location.Query()
+	idp.claims[code] = claims
+	callback := url.Values{"code": {code}, "state": {location.Query().Get("state")}}
+	req, _ = http.NewRequest("GET", "/auth/oidc/stub/callback?"+callback.Encode(), nil)
+	for _, c := range startResponse.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	return executeRequest(ta, req)
+}
+
+// webhookReceiver is a local webhook endpoint that records the deliveries it receives
+type webhookReceiver struct {
+	server   *httptest.Server
+	status   int             // the status the endpoint responds with
+	requests []*http.Request // the deliveries it received
+	bodies   [][]byte        // the payloads of the deliveries it received
+}
+
+// newWebhookReceiver starts a webhookReceiver that responds with a status
+func newWebhookReceiver(t *testing.T, status int) *webhookReceiver {
+	wr := &webhookReceiver{status: status}
+	wr.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		wr.requests = append(wr.requests, r)
+		wr.bodies = append(wr.bodies, body)
+		w.WriteHeader(wr.status)
+	}))
+	t.Cleanup(wr.server.Close)
+	return wr
+}
+
+// URL returns the url a webhook delivered to the webhookReceiver is registered with, a public host name since local
+// webhook urls are refused
+func (wr *webhookReceiver) URL() string {
+	return "http://hooks.example.com/events"
+}
+
+// Client returns a http.Client that connects every request to the webhookReceiver, whatever the host of its url
+func (wr *webhookReceiver) Client() *http.Client {
+	addr := wr.server.Listener.Addr().String()
+	dialer := &net.Dialer{}
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network string, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}}
+}
+
+// readStreamEvent reads the next event of a Server-Sent Events stream, skipping its comments, and returns its type
+// along with the decoded Event
+func readStreamEvent(reader *bufio.Reader) (string, *models.Event, error) {
+	var eventType string
+	var data []byte
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = []byte(strings.TrimPrefix(line, "data: "))
+		case line == "" && data != nil:
+			var e models.Event
+			err = json.Unmarshal(data, &e)
+			return eventType, &e, err
+		}
+	}
+}
+
 // CreateTestGroup creates a group doc for test setup
 func createTestGroup(ta App, groupType int) *models.Group {
 	group := models.Group{}
@@ -49,7 +235,7 @@ func createTestGroup(ta App, groupType int) *models.Group {
 		group.LastModified = time.Now().UTC()
 		group.CreatedAt = time.Now().UTC()
 	}
-	_, err := ta.server.GroupService.GroupDocInsert(&group)
+	_, err := ta.server.GroupService.GroupDocInsert(context.Background(), &group)
 	if err != nil {
 		panic(err)
 	}
@@ -84,7 +270,7 @@ func createTestUser(ta App, userType int) *models.User {
 		user.LastModified = time.Now().UTC()
 		user.CreatedAt = time.Now().UTC()
 	}
-	_, err := ta.server.UserService.UserDocInsert(&user)
+	_, err := ta.server.UserService.UserDocInsert(context.Background(), &user)
 	if err != nil {
 		panic(err)
 	}
@@ -116,7 +302,7 @@ func createTestTask(ta App, taskType int) *models.Task {
 		task.LastModified = now.UTC()
 		task.CreatedAt = now.UTC()
 	}
-	_, err := ta.server.TaskService.TaskDocInsert(&task)
+	_, err := ta.server.TaskService.TaskDocInsert(context.Background(), &task)
 	if err != nil {
 		panic(err)
 	}
@@ -172,7 +358,7 @@ func getTestTaskPayload(tCase string) []byte {
 		return b
 	case "UPDATE":
 		tTask.Name = "NewTestTask"
-		tTask.Status = models.COMPLETED
+		tTask.Status = models.INPROGRESS
 		tTask.Description = "Updated Task to complete"
 		tTask.UserId = "000000000000000000000012"
 		tTask.GroupId = "000000000000000000000002"
@@ -181,3 +367,13 @@ func getTestTaskPayload(tCase string) []byte {
 	}
 	return nil
 }
+
+// testClock is a services.Clock that tells a fixed time
+type testClock struct {
+	now time.Time
+}
+
+// Now returns the fixed time of the testClock
+func (c testClock) Now() time.Time {
+	return c.now
+}
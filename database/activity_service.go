@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"time"
+)
+
+// ActivityService is used by the app to build the activity feed of a group from its tasks, task history and comments
+type ActivityService struct {
+	db             DBClient
+	taskHandler    *DBHandler[*taskModel]
+	historyHandler *DBHandler[*taskHistoryModel]
+	commentHandler *DBHandler[*commentModel]
+}
+
+// NewActivityService is an exported function used to initialize a new ActivityService struct
+func NewActivityService(db DBClient, tHandler *DBHandler[*taskModel], hHandler *DBHandler[*taskHistoryModel], cHandler *DBHandler[*commentModel]) *ActivityService {
+	return &ActivityService{db, tHandler, hHandler, cHandler}
+}
+
+// activityListOptions returns the ListOptions used to load the newest limit records created before a given time
+func activityListOptions(before time.Time, limit int64) *models.ListOptions {
+	o := &models.ListOptions{Limit: limit, Sort: []string{"-created_at"}, Ranges: make(map[string]string)}
+	if !before.IsZero() {
+		o.Ranges["created_at_lte"] = before.Add(-time.Millisecond).UTC().Format(time.RFC3339Nano)
+	}
+	return o
+}
+
+// ActivityFind is used to find the newest limit Activities of a group that happened before a given time, newest first
+// A zero before time returns the latest Activities of the group
+func (p *ActivityService) ActivityFind(ctx context.Context, groupId string, before time.Time, limit int64) ([]*models.Activity, error) {
+	var activities []*models.Activity
+	if limit < 1 || limit > models.MaxListLimit {
+		return activities, errors.New("invalid activity limit")
+	}
+	gId, err := primitive.ObjectIDFromHex(groupId)
+	if err != nil {
+		return activities, errors.New("invalid group id")
+	}
+	tms, _, err := p.taskHandler.FindPage(ctx, &taskModel{GroupId: gId}, activityListOptions(before, limit))
+	if err != nil {
+		return activities, err
+	}
+	hms, _, err := p.historyHandler.FindPage(ctx, &taskHistoryModel{GroupId: gId}, activityListOptions(before, limit))
+	if err != nil {
+		return activities, err
+	}
+	cms, _, err := p.commentHandler.FindPage(ctx, &commentModel{GroupId: gId}, activityListOptions(before, limit))
+	if err != nil {
+		return activities, err
+	}
+	taskNames := make(map[string]string)
+	for _, tm := range tms {
+		taskNames[tm.Id.Hex()] = tm.Name
+		activities = append(activities, &models.Activity{
+			Type:      models.ActivityTaskCreated,
+			TaskId:    tm.Id.Hex(),
+			GroupId:   groupId,
+			ActorId:   tm.UserId.Hex(),
+			TaskName:  tm.Name,
+			To:        tm.Status,
+			CreatedAt: tm.CreatedAt,
+		})
+	}
+	for _, hm := range hms {
+		activities = append(activities, &models.Activity{
+			Type:      models.ActivityTaskStatusChanged,
+			TaskId:    hm.TaskId.Hex(),
+			GroupId:   groupId,
+			ActorId:   hm.ActorId.Hex(),
+			From:      hm.From,
+			To:        hm.To,
+			CreatedAt: hm.CreatedAt,
+		})
+	}
+	for _, cm := range cms {
+		activities = append(activities, &models.Activity{
+			Type:      models.ActivityCommentCreated,
+			TaskId:    cm.TaskId.Hex(),
+			GroupId:   groupId,
+			ActorId:   cm.UserId.Hex(),
+			CommentId: cm.Id.Hex(),
+			Body:      cm.Body,
+			CreatedAt: cm.CreatedAt,
+		})
+	}
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activities[i].CreatedAt.After(activities[j].CreatedAt)
+	})
+	if int64(len(activities)) > limit {
+		activities = activities[:limit]
+	}
+	for _, a := range activities {
+		if _, ok := taskNames[a.TaskId]; !ok {
+			taskNames[a.TaskId] = ""
+			if taskId, err := primitive.ObjectIDFromHex(a.TaskId); err == nil {
+				if tm, err := p.taskHandler.FindOne(ctx, &taskModel{Id: taskId}); err == nil {
+					taskNames[a.TaskId] = tm.Name
+				}
+			}
+		}
+		a.TaskName = taskNames[a.TaskId]
+	}
+	return activities, nil
+}
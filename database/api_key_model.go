@@ -0,0 +1,197 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type apiKeyModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	Name         string             `bson:"name,omitempty"`
+	KeyHash      string             `bson:"key_hash,omitempty"`
+	UserId       primitive.ObjectID `bson:"user_id,omitempty"`
+	GroupId      primitive.ObjectID `bson:"group_id,omitempty"`
+	Scopes       []string           `bson:"scopes,omitempty"`
+	ExpiresAt    time.Time          `bson:"expires_at,omitempty"`
+	LastUsedAt   time.Time          `bson:"last_used_at,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newAPIKeyModel initializes a new pointer to an apiKeyModel struct from a pointer to a JSON APIKey struct
+func newAPIKeyModel(k *models.APIKey) (km *apiKeyModel, err error) {
+	km = &apiKeyModel{
+		Name:         k.Name,
+		KeyHash:      k.KeyHash,
+		Scopes:       k.Scopes,
+		ExpiresAt:    k.ExpiresAt,
+		LastUsedAt:   k.LastUsedAt,
+		LastModified: k.LastModified,
+		CreatedAt:    k.CreatedAt,
+		DeletedAt:    k.DeletedAt,
+	}
+	if k.Id != "" && k.Id != "000000000000000000000000" {
+		km.Id, err = primitive.ObjectIDFromHex(k.Id)
+	}
+	if k.UserId != "" && k.UserId != "000000000000000000000000" {
+		km.UserId, err = primitive.ObjectIDFromHex(k.UserId)
+	}
+	if k.GroupId != "" && k.GroupId != "000000000000000000000000" {
+		km.GroupId, err = primitive.ObjectIDFromHex(k.GroupId)
+	}
+	return
+}
+
+// update the apiKeyModel using an overwrite bson doc
+func (a *apiKeyModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	km := apiKeyModel{}
+	err = bson.Unmarshal(data, &km)
+	if len(km.Name) > 0 {
+		a.Name = km.Name
+	}
+	if len(km.KeyHash) > 0 {
+		a.KeyHash = km.KeyHash
+	}
+	if len(km.Scopes) > 0 {
+		a.Scopes = km.Scopes
+	}
+	if !km.ExpiresAt.IsZero() {
+		a.ExpiresAt = km.ExpiresAt
+	}
+	if !km.LastUsedAt.IsZero() {
+		a.LastUsedAt = km.LastUsedAt
+	}
+	if !km.LastModified.IsZero() {
+		a.LastModified = km.LastModified
+	}
+	if !km.DeletedAt.IsZero() {
+		a.DeletedAt = km.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the apiKeyModel
+func (a *apiKeyModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, a)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the apiKeyModel
+func (a *apiKeyModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	km := apiKeyModel{}
+	err = bson.Unmarshal(data, &km)
+	if km.Id.Hex() != "" && km.Id.Hex() != "000000000000000000000000" {
+		return a.Id == km.Id
+	}
+	if km.KeyHash != "" {
+		return a.KeyHash == km.KeyHash
+	}
+	if km.UserId.Hex() != "" && km.UserId.Hex() != "000000000000000000000000" {
+		return a.UserId == km.UserId
+	}
+	if km.GroupId.Hex() != "" && km.GroupId.Hex() != "000000000000000000000000" {
+		return a.GroupId == km.GroupId
+	}
+	return false
+}
+
+// getID returns the unique identifier of the apiKeyModel
+func (a *apiKeyModel) getID() (id interface{}) {
+	return a.Id
+}
+
+// getDeletedAt returns the time the apiKeyModel was revoked at
+func (a *apiKeyModel) getDeletedAt() time.Time {
+	return a.DeletedAt
+}
+
+// addTimeStamps updates an apiKeyModel struct with a timestamp
+func (a *apiKeyModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	a.LastModified = currentTime
+	if newRecord {
+		a.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if an apiKeyModel has a value assigned for Id, if no value a new one is generated and assigned
+func (a *apiKeyModel) addObjectID() {
+	if a.Id.Hex() == "" || a.Id.Hex() == "000000000000000000000000" {
+		a.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates an apiKeyModel struct postProcess to do things such as validating required fields
+func (a *apiKeyModel) postProcess() (err error) {
+	if a.KeyHash == "" {
+		err = errors.New("api key record does not have a KeyHash")
+	}
+	return
+}
+
+// toDoc converts the bson apiKeyModel into a bson.D
+func (a *apiKeyModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(a)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the apiKeyModel data
+func (a *apiKeyModel) bsonFilter() (doc bson.D, err error) {
+	if a.Id.Hex() != "" && a.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", a.Id}}
+	} else if a.KeyHash != "" {
+		doc = bson.D{{"key_hash", a.KeyHash}}
+	} else if a.UserId.Hex() != "" && a.UserId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"user_id", a.UserId}}
+	} else if a.GroupId.Hex() != "" && a.GroupId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"group_id", a.GroupId}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the apiKeyModel data
+func (a *apiKeyModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := a.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to an APIKey JSON struct from a pointer to a BSON apiKeyModel
+func (a *apiKeyModel) toRoot() *models.APIKey {
+	return &models.APIKey{
+		Id:           a.Id.Hex(),
+		Name:         a.Name,
+		KeyHash:      a.KeyHash,
+		UserId:       a.UserId.Hex(),
+		GroupId:      a.GroupId.Hex(),
+		Scopes:       a.Scopes,
+		ExpiresAt:    a.ExpiresAt,
+		LastUsedAt:   a.LastUsedAt,
+		LastModified: a.LastModified,
+		CreatedAt:    a.CreatedAt,
+		DeletedAt:    a.DeletedAt,
+	}
+}
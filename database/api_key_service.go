@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
+)
+
+// APIKeyService is used by the app to manage all api key related controllers and functionality
+type APIKeyService struct {
+	collection DBCollection
+	db         DBClient
+	handler    *DBHandler[*apiKeyModel]
+}
+
+// NewAPIKeyService is an exported function used to initialize a new APIKeyService struct
+func NewAPIKeyService(db DBClient, handler *DBHandler[*apiKeyModel]) *APIKeyService {
+	collection := db.GetCollection("api_keys")
+	return &APIKeyService{collection, db, handler}
+}
+
+// APIKeyCreate is used to issue a new named api key, the returned APIKey is the only one to carry the raw Key
+func (p *APIKeyService) APIKeyCreate(ctx context.Context, k *models.APIKey) (*models.APIKey, error) {
+	err := k.Validate("create")
+	if err != nil {
+		return nil, err
+	}
+	err = k.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	km, err := newAPIKeyModel(k)
+	if err != nil {
+		return nil, err
+	}
+	km, err = p.handler.InsertOne(ctx, km)
+	if err != nil {
+		return nil, err
+	}
+	created := km.toRoot()
+	created.Key = k.Key
+	return created, nil
+}
+
+// APIKeysFind is used to find all of the active api keys matching the input APIKey
+func (p *APIKeyService) APIKeysFind(ctx context.Context, k *models.APIKey) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	km, err := newAPIKeyModel(k)
+	if err != nil {
+		return keys, err
+	}
+	kms, err := p.handler.FindMany(ctx, km)
+	if err != nil {
+		return keys, err
+	}
+	for _, m := range kms {
+		keys = append(keys, m.toRoot())
+	}
+	return keys, nil
+}
+
+// APIKeyDelete is used to revoke an api key, the key's user id is checked when one is specified
+func (p *APIKeyService) APIKeyDelete(ctx context.Context, k *models.APIKey) (*models.APIKey, error) {
+	km, err := newAPIKeyModel(k)
+	if err != nil {
+		return nil, err
+	}
+	found, err := p.handler.FindOne(ctx, &apiKeyModel{Id: km.Id})
+	if err != nil {
+		return nil, errors.New("api key not found")
+	}
+	if k.CheckID("user_id") && found.UserId != km.UserId {
+		return nil, errors.New("api key not found")
+	}
+	km, err = p.handler.DeleteOne(ctx, &apiKeyModel{Id: found.Id})
+	if err != nil {
+		return nil, err
+	}
+	return km.toRoot(), nil
+}
+
+// APIKeyAuthenticate looks up an active api key by its raw key and records that it was used
+func (p *APIKeyService) APIKeyAuthenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	km, err := p.handler.FindOne(ctx, &apiKeyModel{KeyHash: models.HashToken(key)})
+	if err != nil {
+		return nil, errors.New("invalid api key")
+	}
+	if km.toRoot().Expired() {
+		return nil, errors.New("api key has expired")
+	}
+	km.LastUsedAt = time.Now().UTC()
+	km, err = p.handler.UpdateOne(ctx, &apiKeyModel{Id: km.Id}, km)
+	if err != nil {
+		return nil, err
+	}
+	return km.toRoot(), nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+	"time"
+)
+
+func Test_APIKeyCreate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string         // The name of the test
+		wantErr bool           // whether we want an error.
+		key     *models.APIKey // The input of the test
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			false,
+			&models.APIKey{Name: "ci", UserId: "000000000000000000000012", GroupId: "000000000000000000000002", Scopes: []string{"tasks:read"}},
+		},
+		{
+			"missing name",
+			true,
+			&models.APIKey{UserId: "000000000000000000000012", GroupId: "000000000000000000000002", Scopes: []string{"tasks:read"}},
+		},
+		{
+			"invalid scope",
+			true,
+			&models.APIKey{Name: "ci", UserId: "000000000000000000000012", GroupId: "000000000000000000000002", Scopes: []string{"tasks:delete"}},
+		},
+		{
+			"expired",
+			true,
+			&models.APIKey{Name: "ci", UserId: "000000000000000000000012", GroupId: "000000000000000000000002", Scopes: []string{"tasks:read"}, ExpiresAt: time.Now().UTC().Add(-time.Hour)},
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestAPIKeyService()
+			got, err := testService.APIKeyCreate(context.Background(), tt.key)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("APIKeyService.APIKeyCreate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (got.Key == "" || got.KeyHash != models.HashToken(got.Key)) { // Asserting whether we get the correct wanted value
+				t.Errorf("APIKeyService.APIKeyCreate() = %v, want a new hashed key", got)
+			}
+		})
+	}
+}
+
+func Test_APIKeyAuthenticate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string // The name of the test
+		wantErr bool   // whether we want an error.
+		revoked bool   // whether the issued key is revoked before it is presented
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			false,
+			false,
+		},
+		{
+			"revoked",
+			true,
+			true,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestAPIKeyService()
+			issued, err := testService.APIKeyCreate(context.Background(), &models.APIKey{Name: "ci", UserId: "000000000000000000000012", GroupId: "000000000000000000000002", Scopes: []string{"tasks:write"}})
+			if err != nil {
+				t.Fatalf("APIKeyService.APIKeyCreate() error = %v", err)
+			}
+			if tt.revoked {
+				_, err = testService.APIKeyDelete(context.Background(), &models.APIKey{Id: issued.Id, UserId: issued.UserId})
+				if err != nil {
+					t.Fatalf("APIKeyService.APIKeyDelete() error = %v", err)
+				}
+			}
+			got, err := testService.APIKeyAuthenticate(context.Background(), issued.Key)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("APIKeyService.APIKeyAuthenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (got.Id != issued.Id || got.LastUsedAt.IsZero() || !got.HasScope("tasks:read")) { // Asserting whether we get the correct wanted value
+				t.Errorf("APIKeyService.APIKeyAuthenticate() = %v, want %v", got, issued)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type auditEventModel struct {
+	Id           primitive.ObjectID  `bson:"_id,omitempty"`
+	ActorId      primitive.ObjectID  `bson:"actor_id,omitempty"`
+	GroupId      primitive.ObjectID  `bson:"group_id,omitempty"`
+	Action       string              `bson:"action,omitempty"`
+	ResourceType string              `bson:"resource_type,omitempty"`
+	ResourceId   primitive.ObjectID  `bson:"resource_id,omitempty"`
+	Changes      []*auditChangeModel `bson:"changes,omitempty"`
+	Status       int                 `bson:"status,omitempty"`
+	RequestId    primitive.ObjectID  `bson:"request_id,omitempty"`
+	IP           string              `bson:"ip,omitempty"`
+	CreatedAt    time.Time           `bson:"created_at,omitempty"`
+}
+
+// auditChangeModel stores a field of an AuditChange with its json encoded before and after values
+type auditChangeModel struct {
+	Field  string `bson:"field"`
+	Before string `bson:"before,omitempty"`
+	After  string `bson:"after,omitempty"`
+}
+
+// newAuditEventModel initializes a new pointer to an auditEventModel struct from a pointer to a JSON AuditEvent struct
+func newAuditEventModel(e *models.AuditEvent) (em *auditEventModel, err error) {
+	em = &auditEventModel{
+		Action:       e.Action,
+		ResourceType: e.ResourceType,
+		Status:       e.Status,
+		IP:           e.IP,
+		CreatedAt:    e.CreatedAt,
+	}
+	for field, change := range e.Changes {
+		em.Changes = append(em.Changes, &auditChangeModel{Field: field, Before: string(change.Before), After: string(change.After)})
+	}
+	if e.Id != "" && e.Id != "000000000000000000000000" {
+		em.Id, err = primitive.ObjectIDFromHex(e.Id)
+	}
+	if e.ActorId != "" && e.ActorId != "000000000000000000000000" {
+		em.ActorId, err = primitive.ObjectIDFromHex(e.ActorId)
+	}
+	if e.GroupId != "" && e.GroupId != "000000000000000000000000" {
+		em.GroupId, err = primitive.ObjectIDFromHex(e.GroupId)
+	}
+	if e.ResourceId != "" && e.ResourceId != "000000000000000000000000" {
+		em.ResourceId, err = primitive.ObjectIDFromHex(e.ResourceId)
+	}
+	if e.RequestId != "" && e.RequestId != "000000000000000000000000" {
+		em.RequestId, err = primitive.ObjectIDFromHex(e.RequestId)
+	}
+	return
+}
+
+// update is a no-op since audit events are append only
+func (e *auditEventModel) update(doc interface{}) (err error) {
+	return errors.New("audit events can not be updated")
+}
+
+// bsonLoad loads a bson doc into the auditEventModel
+func (e *auditEventModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, e)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the auditEventModel
+func (e *auditEventModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	em := auditEventModel{}
+	err = bson.Unmarshal(data, &em)
+	if em.Id.Hex() != "" && em.Id.Hex() != "000000000000000000000000" {
+		return e.Id == em.Id
+	}
+	if em.GroupId.Hex() != "" && em.GroupId.Hex() != "000000000000000000000000" {
+		return e.GroupId == em.GroupId
+	}
+	return false
+}
+
+// getID returns the unique identifier of the auditEventModel
+func (e *auditEventModel) getID() (id interface{}) {
+	return e.Id
+}
+
+// getDeletedAt returns a zero time since audit events are never deleted
+func (e *auditEventModel) getDeletedAt() time.Time {
+	return time.Time{}
+}
+
+// addTimeStamps updates an auditEventModel struct with a timestamp
+func (e *auditEventModel) addTimeStamps(newRecord bool) {
+	if newRecord && e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+}
+
+// addObjectID checks if an auditEventModel has a value assigned for Id, if no value a new one is generated and assigned
+func (e *auditEventModel) addObjectID() {
+	if e.Id.Hex() == "" || e.Id.Hex() == "000000000000000000000000" {
+		e.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates an auditEventModel struct postProcess to do things such as validating required fields
+func (e *auditEventModel) postProcess() (err error) {
+	if e.Action == "" {
+		err = errors.New("audit event record does not have an Action")
+	}
+	return
+}
+
+// toDoc converts the bson auditEventModel into a bson.D
+func (e *auditEventModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(e)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the auditEventModel data
+func (e *auditEventModel) bsonFilter() (doc bson.D, err error) {
+	if e.Id.Hex() != "" && e.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", e.Id}}
+	} else if e.GroupId.Hex() != "" && e.GroupId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"group_id", e.GroupId}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the auditEventModel data
+func (e *auditEventModel) bsonUpdate() (doc bson.D, err error) {
+	return nil, errors.New("audit events can not be updated")
+}
+
+// toRoot creates and return a new pointer to an AuditEvent JSON struct from a pointer to a BSON auditEventModel
+func (e *auditEventModel) toRoot() *models.AuditEvent {
+	ae := &models.AuditEvent{
+		Id:           e.Id.Hex(),
+		Action:       e.Action,
+		ResourceType: e.ResourceType,
+		Status:       e.Status,
+		IP:           e.IP,
+		CreatedAt:    e.CreatedAt,
+	}
+	if !e.ActorId.IsZero() {
+		ae.ActorId = e.ActorId.Hex()
+	}
+	if !e.GroupId.IsZero() {
+		ae.GroupId = e.GroupId.Hex()
+	}
+	if !e.ResourceId.IsZero() {
+		ae.ResourceId = e.ResourceId.Hex()
+	}
+	if !e.RequestId.IsZero() {
+		ae.RequestId = e.RequestId.Hex()
+	}
+	if len(e.Changes) > 0 {
+		ae.Changes = make(map[string]*models.AuditChange)
+		for _, c := range e.Changes {
+			change := &models.AuditChange{}
+			if c.Before != "" {
+				change.Before = json.RawMessage(c.Before)
+			}
+			if c.After != "" {
+				change.After = json.RawMessage(c.After)
+			}
+			ae.Changes[c.Field] = change
+		}
+	}
+	return ae
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// AuditService is used by the app to record and query the append only audit log of the API's mutating calls
+type AuditService struct {
+	collection DBCollection
+	db         DBClient
+	handler    *DBHandler[*auditEventModel]
+}
+
+// NewAuditService is an exported function used to initialize a new AuditService struct
+func NewAuditService(db DBClient, handler *DBHandler[*auditEventModel]) *AuditService {
+	collection := db.GetCollection("audit_events")
+	return &AuditService{collection, db, handler}
+}
+
+// AuditEventCreate is used to append a new AuditEvent to the audit log
+func (p *AuditService) AuditEventCreate(ctx context.Context, e *models.AuditEvent) (*models.AuditEvent, error) {
+	err := e.Validate("create")
+	if err != nil {
+		return nil, err
+	}
+	em, err := newAuditEventModel(e)
+	if err != nil {
+		return nil, err
+	}
+	em, err = p.handler.InsertOne(ctx, em)
+	if err != nil {
+		return nil, err
+	}
+	return em.toRoot(), nil
+}
+
+// AuditEventsFindPage is used to find a sorted, filtered and paginated page of AuditEvents along with the total number
+// of matches, a GroupId limits the page to the events of that group
+func (p *AuditService) AuditEventsFindPage(ctx context.Context, e *models.AuditEvent, o *models.ListOptions) ([]*models.AuditEvent, int64, error) {
+	var events []*models.AuditEvent
+	em, err := newAuditEventModel(e)
+	if err != nil {
+		return events, 0, err
+	}
+	ems, total, err := p.handler.FindPage(ctx, em, o)
+	if err != nil {
+		return events, 0, err
+	}
+	for _, m := range ems {
+		events = append(events, m.toRoot())
+	}
+	return events, total, nil
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+)
+
+func Test_AuditEventCreate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string             // The name of the test
+		wantErr bool               // whether we want an error.
+		event   *models.AuditEvent // The input of the test
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"task update",
+			false,
+			&models.AuditEvent{
+				ActorId:      "000000000000000000000012",
+				GroupId:      "000000000000000000000002",
+				Action:       "PATCH /tasks/{taskId}",
+				ResourceType: "tasks",
+				ResourceId:   "000000000000000000000022",
+				Changes:      map[string]*models.AuditChange{"name": {Before: json.RawMessage(`"Task1"`), After: json.RawMessage(`"Task3"`)}},
+				Status:       202,
+				RequestId:    "000000000000000000000091",
+				IP:           "127.0.0.1",
+			},
+		},
+		{
+			"failed sign in",
+			false,
+			&models.AuditEvent{Action: "POST /auth", ResourceType: "auth", Status: 401, IP: "127.0.0.1"},
+		},
+		{
+			"missing action",
+			true,
+			&models.AuditEvent{ResourceType: "tasks"},
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestAuditService()
+			got, err := testService.AuditEventCreate(context.Background(), tt.event)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AuditService.AuditEventCreate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.Id == "" || got.CreatedAt.IsZero() || got.ActorId != tt.event.ActorId || got.ResourceId != tt.event.ResourceId || got.Status != tt.event.Status { // Asserting whether we get the correct wanted value
+				t.Errorf("AuditService.AuditEventCreate() = %+v, want %+v", got, tt.event)
+			}
+			if len(got.Changes) != len(tt.event.Changes) {
+				t.Errorf("AuditService.AuditEventCreate() changes = %v, want %v", got.Changes, tt.event.Changes)
+			}
+			for field, change := range tt.event.Changes {
+				if c := got.Changes[field]; c == nil || string(c.Before) != string(change.Before) || string(c.After) != string(change.After) {
+					t.Errorf("AuditService.AuditEventCreate() %v change = %+v, want %+v", field, c, change)
+				}
+			}
+		})
+	}
+}
+
+func Test_AuditEventsFindPage(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string             // The name of the test
+		wantErr bool               // whether we want an error.
+		filter  *models.AuditEvent // The scope of the page
+		filters map[string]string  // The filters of the list request
+		want    int                // The wanted number of events
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"every group", false, &models.AuditEvent{}, map[string]string{}, 3},
+		{"group scope", false, &models.AuditEvent{GroupId: "000000000000000000000002"}, map[string]string{}, 2},
+		{"resource filter", false, &models.AuditEvent{}, map[string]string{"resource_type": "groups"}, 1},
+		{"actor filter in scope", false, &models.AuditEvent{GroupId: "000000000000000000000002"}, map[string]string{"actor_id": "000000000000000000000013"}, 1},
+		{"other group filter", false, &models.AuditEvent{GroupId: "000000000000000000000002"}, map[string]string{"group_id": "000000000000000000000003"}, 0},
+		{"invalid actor filter", true, &models.AuditEvent{}, map[string]string{"actor_id": "jill"}, 0},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestAuditService()
+			for _, e := range []*models.AuditEvent{
+				{ActorId: "000000000000000000000012", GroupId: "000000000000000000000002", Action: "POST /tasks", ResourceType: "tasks", Status: 201},
+				{ActorId: "000000000000000000000013", GroupId: "000000000000000000000002", Action: "DELETE /tasks/{taskId}", ResourceType: "tasks", Status: 200},
+				{ActorId: "000000000000000000000014", GroupId: "000000000000000000000003", Action: "PATCH /groups/{groupId}", ResourceType: "groups", Status: 202},
+			} {
+				if _, err := testService.AuditEventCreate(context.Background(), e); err != nil {
+					t.Fatalf("AuditService.AuditEventCreate() error = %v", err)
+				}
+			}
+			got, total, err := testService.AuditEventsFindPage(context.Background(), tt.filter, &models.ListOptions{Limit: 50, Filters: tt.filters, Ranges: map[string]string{}})
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AuditService.AuditEventsFindPage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (len(got) != tt.want || total != int64(tt.want)) { // Asserting whether we get the correct wanted value
+				t.Errorf("AuditService.AuditEventsFindPage() = %v (total %v), want %v", len(got), total, tt.want)
+			}
+		})
+	}
+}
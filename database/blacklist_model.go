@@ -77,6 +77,11 @@ func (b *blacklistModel) getID() (id interface{}) {
 	return b.Id
 }
 
+// getDeletedAt returns the zero time since blacklist records are never soft deleted
+func (b *blacklistModel) getDeletedAt() time.Time {
+	return time.Time{}
+}
+
 // addTimeStamps updates a blacklistModel struct with a timestamp
 func (b *blacklistModel) addTimeStamps(newRecord bool) {
 	currentTime := time.Now().UTC()
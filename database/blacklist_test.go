@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
 	"testing"
 )
@@ -36,7 +37,7 @@ func Test_BlacklistAuthToken(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := initTestBlacklistService()
 			//fmt.Println("\n\nPRE CREATE: ", tt.group)
-			err := testService.BlacklistAuthToken(tt.authToken)
+			err := testService.BlacklistAuthToken(context.Background(), tt.authToken)
 			//fmt.Println("\nPOST CREATE: ", got)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
@@ -70,7 +71,7 @@ func Test_CheckTokenBlacklist(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestBlacklists()
-			found := testService.CheckTokenBlacklist(tt.authToken)
+			found := testService.CheckTokenBlacklist(context.Background(), tt.authToken)
 			// Checking the error
 			if found != tt.want { // Asserting whether we get the correct wanted value
 				t.Errorf("GroupService.CheckTokenBlacklist() = %v, want %v", found, tt.want)
@@ -0,0 +1,298 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+// cascadeAttempts is how many times a step of a cascade delete saga is attempted before the saga is undone
+const cascadeAttempts = 3
+
+// cascadeBackoff is how long a cascade delete saga waits to retry a failed step, doubling after every attempt
+const cascadeBackoff = 100 * time.Millisecond
+
+// cascadeStep soft deletes the records of a collection as part of a cascade delete, undo restores the records the step
+// deleted
+type cascadeStep struct {
+	collection string
+	remove     func(ctx context.Context, deletedAt time.Time) (int64, error)
+	undo       func(ctx context.Context, deletedAt time.Time) error
+}
+
+// newCascadeStep returns the cascadeStep that soft deletes the records of a handler's collection matching a filter
+func newCascadeStep[T dbModel](collection string, h *DBHandler[T], f bson.D) cascadeStep {
+	return cascadeStep{
+		collection: collection,
+		remove: func(ctx context.Context, deletedAt time.Time) (int64, error) {
+			return h.softDelete(ctx, f, deletedAt)
+		},
+		undo: func(ctx context.Context, deletedAt time.Time) error {
+			return h.undoSoftDelete(ctx, f, deletedAt)
+		},
+	}
+}
+
+// newRevokeStep returns the cascadeStep that revokes the records of a handler's collection matching a filter, for
+// records such as refresh tokens that are revoked rather than soft deleted
+func newRevokeStep[T dbModel](collection string, h *DBHandler[T], f bson.D) cascadeStep {
+	return cascadeStep{
+		collection: collection,
+		remove: func(ctx context.Context, deletedAt time.Time) (int64, error) {
+			return h.revoke(ctx, f, deletedAt)
+		},
+		undo: func(ctx context.Context, deletedAt time.Time) error {
+			return h.undoRevoke(ctx, f, deletedAt)
+		},
+	}
+}
+
+// capturedStep returns the cascadeStep that applies the step of each filter returned by filters, such as the filters of
+// the records owned by users deleted by the same cascade delete. The filters are captured by the first remove, so that
+// undo reverts the records of the same filters whether or not the owners were restored first
+func capturedStep(collection string, filters func(ctx context.Context, deletedAt time.Time) ([]bson.D, error), step func(f bson.D) cascadeStep) cascadeStep {
+	var steps []cascadeStep
+	captured := false
+	return cascadeStep{
+		collection: collection,
+		remove: func(ctx context.Context, deletedAt time.Time) (int64, error) {
+			if !captured {
+				fs, err := filters(ctx, deletedAt)
+				if err != nil {
+					return 0, err
+				}
+				for _, f := range fs {
+					steps = append(steps, step(f))
+				}
+				captured = true
+			}
+			var removed int64
+			for _, s := range steps {
+				n, err := s.remove(ctx, deletedAt)
+				removed += n
+				if err != nil {
+					return removed, err
+				}
+			}
+			return removed, nil
+		},
+		undo: func(ctx context.Context, deletedAt time.Time) error {
+			for _, s := range steps {
+				if err := s.undo(ctx, deletedAt); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// CascadeService is used by the app to delete Groups and Users along with the users, tasks and files that belong to
+// them, as one operation. The memberships and api keys of the deleted users are deleted and their refresh tokens are
+// revoked along with them, so that a restored User has to sign in again
+type CascadeService struct {
+	db        DBClient
+	gHandler  *DBHandler[*groupModel]
+	uHandler  *DBHandler[*userModel]
+	tHandler  *DBHandler[*taskModel]
+	fHandler  *DBHandler[*fileModel]
+	mHandler  *DBHandler[*membershipModel]
+	kHandler  *DBHandler[*apiKeyModel]
+	rtHandler *DBHandler[*refreshTokenModel]
+}
+
+// NewCascadeService is an exported function used to initialize a new CascadeService struct
+func NewCascadeService(db DBClient, gHandler *DBHandler[*groupModel], uHandler *DBHandler[*userModel], tHandler *DBHandler[*taskModel], fHandler *DBHandler[*fileModel], mHandler *DBHandler[*membershipModel], kHandler *DBHandler[*apiKeyModel], rtHandler *DBHandler[*refreshTokenModel]) *CascadeService {
+	return &CascadeService{db, gHandler, uHandler, tHandler, fHandler, mHandler, kHandler, rtHandler}
+}
+
+// deletedOwners returns the ids of the users and tasks matching a filter that were deleted by the same cascade delete,
+// tasks are skipped when their filter is nil
+func (p *CascadeService) deletedOwners(ctx context.Context, users bson.D, tasks bson.D, deletedAt time.Time) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	ums, err := p.uHandler.findMany(ctx, deletedAtFilter(users, deletedAt))
+	if err != nil {
+		return nil, err
+	}
+	for _, um := range ums {
+		ids = append(ids, um.Id)
+	}
+	if tasks == nil {
+		return ids, nil
+	}
+	tms, err := p.tHandler.findMany(ctx, deletedAtFilter(tasks, deletedAt))
+	if err != nil {
+		return nil, err
+	}
+	for _, tm := range tms {
+		ids = append(ids, tm.Id)
+	}
+	return ids, nil
+}
+
+// ownedFilters returns the filters of the records whose key field holds the id of one of the deletedOwners, along with
+// the filters fs
+func (p *CascadeService) ownedFilters(key string, users bson.D, tasks bson.D, fs ...bson.D) func(ctx context.Context, deletedAt time.Time) ([]bson.D, error) {
+	return func(ctx context.Context, deletedAt time.Time) ([]bson.D, error) {
+		ids, err := p.deletedOwners(ctx, users, tasks, deletedAt)
+		if err != nil {
+			return nil, err
+		}
+		filters := append([]bson.D{}, fs...)
+		for _, id := range ids {
+			filters = append(filters, bson.D{{key, id}})
+		}
+		return filters, nil
+	}
+}
+
+// fileStep returns the cascadeStep that soft deletes the files, such as user images and task attachments, of the users
+// and tasks matching a filter that were deleted by the same cascade delete
+func (p *CascadeService) fileStep(users bson.D, tasks bson.D) cascadeStep {
+	return capturedStep("files", p.ownedFilters("owner_id", users, tasks), func(f bson.D) cascadeStep {
+		return newCascadeStep("files", p.fHandler, f)
+	})
+}
+
+// accessSteps returns the cascadeSteps that soft delete the memberships and api keys and revoke the refresh tokens of
+// the users matching a filter that were deleted by the same cascade delete, along with those matching the filters fs
+func (p *CascadeService) accessSteps(users bson.D, fs ...bson.D) []cascadeStep {
+	return []cascadeStep{
+		capturedStep("memberships", p.ownedFilters("user_id", users, nil, fs...), func(f bson.D) cascadeStep {
+			return newCascadeStep("memberships", p.mHandler, f)
+		}),
+		capturedStep("api_keys", p.ownedFilters("user_id", users, nil, fs...), func(f bson.D) cascadeStep {
+			return newCascadeStep("api_keys", p.kHandler, f)
+		}),
+		capturedStep("refresh_tokens", p.ownedFilters("user_id", users, nil, fs...), func(f bson.D) cascadeStep {
+			return newRevokeStep("refresh_tokens", p.rtHandler, f)
+		}),
+	}
+}
+
+// retryStep runs a function of a cascade delete saga until it succeeds, it was attempted cascadeAttempts times or ctx
+// is done
+func retryStep(ctx context.Context, f func(ctx context.Context) error) error {
+	var err error
+	wait := cascadeBackoff
+	for attempt := 1; attempt <= cascadeAttempts; attempt++ {
+		err = f(ctx)
+		if err == nil || attempt == cascadeAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	return err
+}
+
+// run applies the steps of a cascade delete in a transaction when the database supports them. Otherwise they are
+// applied in order by a saga that retries a failed step, and undoes the completed steps when the step keeps failing or
+// ctx is cancelled. The undo is not bound to ctx, so a client that goes away does not leave the cascade half applied
+func (p *CascadeService) run(ctx context.Context, steps []cascadeStep) (*models.DeleteReport, error) {
+	report := models.NewDeleteReport(time.Now().UTC().Truncate(time.Millisecond)) // the precision of a bson date
+	err := p.db.WithTransaction(ctx, func(ctx context.Context) error {
+		for _, s := range steps {
+			n, err := s.remove(ctx, report.DeletedAt)
+			if err != nil {
+				return err
+			}
+			report.Removed[s.collection] = n
+		}
+		return nil
+	})
+	if err == nil {
+		report.Strategy = models.CascadeTransaction
+		return report, nil
+	}
+	if !errors.Is(err, errTransactionsUnsupported) {
+		return nil, err
+	}
+	report.Strategy = models.CascadeSaga
+	for i, s := range steps {
+		err = retryStep(ctx, func(ctx context.Context) error {
+			n, err := s.remove(ctx, report.DeletedAt) // an attempt only removes the records earlier attempts left
+			report.Removed[s.collection] += n
+			return err
+		})
+		if err == nil {
+			continue
+		}
+		for j := i; j >= 0; j-- { // the failed step is undone as well, it may have deleted some of its records
+			undo := steps[j].undo
+			if undoErr := retryStep(context.Background(), func(ctx context.Context) error { return undo(ctx, report.DeletedAt) }); undoErr != nil {
+				log.Println("cascade delete error:", undoErr)
+			}
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+// GroupDeleteCascade is used to delete a Group along with its users, its tasks and their files, the memberships, api
+// keys and refresh tokens of its users and those for the Group. The Group is deleted first so that nothing can be added
+// to it while it is deleted
+func (p *CascadeService) GroupDeleteCascade(ctx context.Context, g *models.Group) (*models.DeleteReport, error) {
+	if !g.CheckID("id") {
+		return nil, errors.New("missing valid query filter")
+	}
+	gm, err := newGroupModel(&models.Group{Id: g.Id})
+	if err != nil {
+		return nil, err
+	}
+	gm, err = p.gHandler.FindOne(ctx, gm)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	members := bson.D{{"group_id", gm.Id}}
+	report, err := p.run(ctx, append([]cascadeStep{
+		newCascadeStep("groups", p.gHandler, bson.D{{"_id", gm.Id}}),
+		newCascadeStep("users", p.uHandler, members),
+		newCascadeStep("tasks", p.tHandler, members),
+		p.fileStep(members, members),
+	}, p.accessSteps(members, members)...))
+	if err != nil {
+		return nil, err
+	}
+	gm.DeletedAt, gm.LastModified = report.DeletedAt, report.DeletedAt
+	report.Group = gm.toRoot()
+	emit(p.db, models.EventGroupDeleted, report.Group.Id, report.Group, nil)
+	return report, nil
+}
+
+// UserDeleteCascade is used to delete a User along with its tasks, its image, the attachments of its tasks, its
+// memberships, api keys and refresh tokens, the User's group id is checked when one is specified
+func (p *CascadeService) UserDeleteCascade(ctx context.Context, u *models.User) (*models.DeleteReport, error) {
+	if !u.CheckID("id") {
+		return nil, errors.New("missing valid query filter")
+	}
+	um, err := newUserModel(&models.User{Id: u.Id, GroupId: u.GroupId})
+	if err != nil {
+		return nil, err
+	}
+	found, err := p.uHandler.FindOne(ctx, &userModel{Id: um.Id})
+	if err != nil || (u.CheckID("group_id") && found.GroupId != um.GroupId) {
+		return nil, errors.New("user not found")
+	}
+	um = found
+	report, err := p.run(ctx, append([]cascadeStep{
+		newCascadeStep("users", p.uHandler, bson.D{{"_id", um.Id}}),
+		newCascadeStep("tasks", p.tHandler, bson.D{{"user_id", um.Id}}),
+		p.fileStep(bson.D{{"_id", um.Id}}, bson.D{{"user_id", um.Id}}),
+	}, p.accessSteps(bson.D{{"_id", um.Id}})...))
+	if err != nil {
+		return nil, err
+	}
+	um.DeletedAt, um.LastModified = report.DeletedAt, report.DeletedAt
+	report.User = um.toRoot()
+	emitUser(p.db, models.EventUserDeleted, report.User, nil)
+	return report, nil
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// activeCount returns how many records of a handler's collection matching a filter are not soft deleted
+func activeCount[T dbModel](h *DBHandler[T], f bson.D) int {
+	ms, _ := h.findMany(context.Background(), activeFilter(f))
+	return len(ms)
+}
+
+// accessCounts returns how many memberships and api keys are not soft deleted and how many refresh tokens are not revoked
+func accessCounts(p *CascadeService) [3]int {
+	return [3]int{activeCount(p.mHandler, bson.D{}), activeCount(p.kHandler, bson.D{}), activeCount(p.rtHandler, bson.D{{"revoked_at", nil}})}
+}
+
+func Test_GroupDeleteCascade(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string           // The name of the test
+		wantErr bool             // whether we want an error.
+		group   *models.Group    // The group to delete
+		want    map[string]int64 // The wanted number of records removed from each collection
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"group", false, &models.Group{Id: "000000000000000000000002"}, map[string]int64{"groups": 1, "users": 2, "tasks": 2, "files": 3, "memberships": 2, "api_keys": 2, "refresh_tokens": 2}},
+		{"empty group", false, &models.Group{Id: "000000000000000000000003"}, map[string]int64{"groups": 1, "users": 0, "tasks": 0, "files": 0, "memberships": 1, "api_keys": 0, "refresh_tokens": 1}},
+		{"missing group", true, &models.Group{Id: "000000000000000000000009"}, nil},
+		{"missing id", true, &models.Group{}, nil},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestCascade()
+			got, err := testService.GroupDeleteCascade(context.Background(), tt.group)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CascadeService.GroupDeleteCascade() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Removed, tt.want) || got.Strategy != models.CascadeSaga || got.Group.Id != tt.group.Id || !got.Group.DeletedAt.Equal(got.DeletedAt) {
+				t.Errorf("CascadeService.GroupDeleteCascade() = %+v, want %v", got, tt.want)
+			}
+			groupId, _ := primitive.ObjectIDFromHex(tt.group.Id)
+			if activeCount(testService.gHandler, bson.D{{"_id", groupId}}) != 0 || activeCount(testService.uHandler, bson.D{{"group_id", groupId}}) != 0 || activeCount(testService.tHandler, bson.D{{"group_id", groupId}}) != 0 {
+				t.Errorf("CascadeService.GroupDeleteCascade() left records of the group active")
+			}
+			// The other groups are left as is
+			if activeCount(testService.gHandler, bson.D{{"name", "test1"}}) != 1 || activeCount(testService.uHandler, bson.D{{"email", "test1@email.com"}}) != 1 {
+				t.Errorf("CascadeService.GroupDeleteCascade() deleted records of another group")
+			}
+			if got, want := accessCounts(testService), [3]int{2 - int(tt.want["memberships"]), 3 - int(tt.want["api_keys"]), 3 - int(tt.want["refresh_tokens"])}; got != want {
+				t.Errorf("CascadeService.GroupDeleteCascade() left %v memberships, api keys and refresh tokens, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_UserDeleteCascade(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string           // The name of the test
+		wantErr bool             // whether we want an error.
+		user    *models.User     // The user to delete, along with its group scope
+		want    map[string]int64 // The wanted number of records removed from each collection
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"user", false, &models.User{Id: "000000000000000000000013"}, map[string]int64{"users": 1, "tasks": 1, "files": 2, "memberships": 0, "api_keys": 1, "refresh_tokens": 0}},
+		{"user in group scope", false, &models.User{Id: "000000000000000000000012", GroupId: "000000000000000000000002"}, map[string]int64{"users": 1, "tasks": 1, "files": 1, "memberships": 1, "api_keys": 0, "refresh_tokens": 2}},
+		{"user out of group scope", true, &models.User{Id: "000000000000000000000012", GroupId: "000000000000000000000003"}, nil},
+		{"missing id", true, &models.User{}, nil},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestCascade()
+			got, err := testService.UserDeleteCascade(context.Background(), tt.user)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CascadeService.UserDeleteCascade() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Removed, tt.want) || got.User.Id != tt.user.Id || !got.User.DeletedAt.Equal(got.DeletedAt) {
+				t.Errorf("CascadeService.UserDeleteCascade() = %+v, want %v", got, tt.want)
+			}
+			// The group and the other users of the group are left as is
+			groupId, _ := primitive.ObjectIDFromHex("000000000000000000000002")
+			if activeCount(testService.gHandler, bson.D{{"_id", groupId}}) != 1 || activeCount(testService.uHandler, bson.D{{"group_id", groupId}}) != 1 {
+				t.Errorf("CascadeService.UserDeleteCascade() deleted other records of the group")
+			}
+			userId, _ := primitive.ObjectIDFromHex(tt.user.Id)
+			if activeCount(testService.mHandler, bson.D{{"user_id", userId}}) != 0 || activeCount(testService.kHandler, bson.D{{"user_id", userId}}) != 0 || activeCount(testService.rtHandler, bson.D{{"user_id", userId}, {"revoked_at", nil}}) != 0 {
+				t.Errorf("CascadeService.UserDeleteCascade() left access records of the user active")
+			}
+			if got, want := accessCounts(testService), [3]int{2 - int(tt.want["memberships"]), 3 - int(tt.want["api_keys"]), 3 - int(tt.want["refresh_tokens"])}; got != want {
+				t.Errorf("CascadeService.UserDeleteCascade() left %v memberships, api keys and refresh tokens, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_CascadeSagaUndo(t *testing.T) {
+	testService := setupTestCascade()
+	// Tasks can not be deleted, so the saga is undone after it deleted the group and its users
+	testService.tHandler = &DBHandler[*taskModel]{db: testService.db, collection: failingTestCollection{testService.tHandler.collection}}
+	if report, err := testService.GroupDeleteCascade(context.Background(), &models.Group{Id: "000000000000000000000002"}); err == nil {
+		t.Fatalf("CascadeService.GroupDeleteCascade() = %+v, want an error", report)
+	}
+	groupId, _ := primitive.ObjectIDFromHex("000000000000000000000002")
+	if activeCount(testService.gHandler, bson.D{{"_id", groupId}}) != 1 || activeCount(testService.uHandler, bson.D{{"group_id", groupId}}) != 2 {
+		t.Errorf("CascadeService.GroupDeleteCascade() did not undo the deleted group and users")
+	}
+	if activeCount(testService.tHandler, bson.D{{"group_id", groupId}}) != 2 || activeCount(testService.fHandler, bson.D{{"bucket_type", "task-attachments"}}) != 3 {
+		t.Errorf("CascadeService.GroupDeleteCascade() deleted tasks or files")
+	}
+	// Records deleted before the saga are not restored by its undo
+	userId, _ := primitive.ObjectIDFromHex("000000000000000000000013")
+	if _, err := testService.uHandler.softDelete(context.Background(), bson.D{{"_id", userId}}, time.Now().UTC().Add(-time.Hour)); err != nil {
+		t.Fatalf("DBHandler.softDelete() error = %v", err)
+	}
+	if _, err := testService.GroupDeleteCascade(context.Background(), &models.Group{Id: "000000000000000000000002"}); err == nil {
+		t.Fatalf("CascadeService.GroupDeleteCascade() want an error")
+	}
+	if activeCount(testService.uHandler, bson.D{{"group_id", groupId}}) != 1 {
+		t.Errorf("CascadeService.GroupDeleteCascade() undo restored a user deleted before it")
+	}
+}
+
+func Test_CascadeSagaPartialStep(t *testing.T) {
+	groupId, _ := primitive.ObjectIDFromHex("000000000000000000000002")
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name      string           // The name of the test
+		failures  int              // How many file soft deletes fail once the first three succeeded
+		wantErr   bool             // whether we want an error.
+		want      map[string]int64 // The wanted number of records removed from each collection
+		wantFiles int              // The wanted number of active task attachments
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"retried step", 1, false, map[string]int64{"groups": 1, "users": 2, "tasks": 2, "files": 3, "memberships": 2, "api_keys": 2, "refresh_tokens": 2}, 0},
+		{"undone step", cascadeAttempts, true, nil, 3},
+	}
+	// Iterating over the previous test slice, the files of the two users and the first task are deleted before the
+	// files of the second task fail to be deleted
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestCascade()
+			testService.fHandler = &DBHandler[*fileModel]{db: testService.db, collection: &flakyTestCollection{testService.fHandler.collection, 3, tt.failures}}
+			got, err := testService.GroupDeleteCascade(context.Background(), &models.Group{Id: "000000000000000000000002"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CascadeService.GroupDeleteCascade() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got.Removed, tt.want) {
+				t.Errorf("CascadeService.GroupDeleteCascade() = %+v, want %v", got.Removed, tt.want)
+			}
+			if got := activeCount(testService.fHandler, bson.D{{"bucket_type", "task-attachments"}}); got != tt.wantFiles {
+				t.Errorf("CascadeService.GroupDeleteCascade() left %v task attachments, want %v", got, tt.wantFiles)
+			}
+			if tt.wantErr && (activeCount(testService.gHandler, bson.D{{"_id", groupId}}) != 1 || activeCount(testService.tHandler, bson.D{{"group_id", groupId}}) != 2) {
+				t.Errorf("CascadeService.GroupDeleteCascade() did not undo the deleted group and tasks")
+			}
+		})
+	}
+}
+
+func Test_CascadeSagaUndoAccess(t *testing.T) {
+	testService := setupTestCascade()
+	// The refresh tokens of the group are revoked before those of its user 12 keep failing to be revoked, so the saga is
+	// undone after it deleted every other record
+	testService.rtHandler = &DBHandler[*refreshTokenModel]{db: testService.db, collection: &flakyTestCollection{testService.rtHandler.collection, 1, cascadeAttempts}}
+	if report, err := testService.GroupDeleteCascade(context.Background(), &models.Group{Id: "000000000000000000000002"}); err == nil {
+		t.Fatalf("CascadeService.GroupDeleteCascade() = %+v, want an error", report)
+	}
+	groupId, _ := primitive.ObjectIDFromHex("000000000000000000000002")
+	if activeCount(testService.gHandler, bson.D{{"_id", groupId}}) != 1 || activeCount(testService.uHandler, bson.D{{"group_id", groupId}}) != 2 || activeCount(testService.fHandler, bson.D{{"bucket_type", "task-attachments"}}) != 3 {
+		t.Errorf("CascadeService.GroupDeleteCascade() did not undo the deleted group, users and files")
+	}
+	if got, want := accessCounts(testService), [3]int{2, 3, 3}; got != want {
+		t.Errorf("CascadeService.GroupDeleteCascade() left %v memberships, api keys and refresh tokens, want %v", got, want)
+	}
+	// The refresh token revoked before the saga is not reinstated by its undo
+	tokenId, _ := primitive.ObjectIDFromHex("000000000000000000000064")
+	if activeCount(testService.rtHandler, bson.D{{"_id", tokenId}, {"revoked_at", nil}}) != 0 {
+		t.Errorf("CascadeService.GroupDeleteCascade() undo reinstated a refresh token revoked before it")
+	}
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// commentModel structures a comment BSON document to save in a comments collection
+type commentModel struct {
+	Id           primitive.ObjectID   `bson:"_id,omitempty"`
+	TaskId       primitive.ObjectID   `bson:"task_id,omitempty"`
+	GroupId      primitive.ObjectID   `bson:"group_id,omitempty"`
+	UserId       primitive.ObjectID   `bson:"user_id,omitempty"`
+	Body         string               `bson:"body,omitempty"`
+	Mentions     []primitive.ObjectID `bson:"mentions,omitempty"`
+	EditedAt     time.Time            `bson:"edited_at,omitempty"`
+	LastModified time.Time            `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time            `bson:"created_at,omitempty"`
+	DeletedAt    time.Time            `bson:"deleted_at,omitempty"`
+}
+
+// newCommentModel initializes a new pointer to a commentModel struct from a pointer to a JSON Comment struct
+func newCommentModel(c *models.Comment) (cm *commentModel, err error) {
+	cm = &commentModel{
+		Body:         c.Body,
+		EditedAt:     c.EditedAt,
+		LastModified: c.LastModified,
+		CreatedAt:    c.CreatedAt,
+		DeletedAt:    c.DeletedAt,
+	}
+	if c.Id != "" && c.Id != "000000000000000000000000" {
+		cm.Id, err = primitive.ObjectIDFromHex(c.Id)
+	}
+	if c.TaskId != "" && c.TaskId != "000000000000000000000000" {
+		cm.TaskId, err = primitive.ObjectIDFromHex(c.TaskId)
+	}
+	if c.GroupId != "" && c.GroupId != "000000000000000000000000" {
+		cm.GroupId, err = primitive.ObjectIDFromHex(c.GroupId)
+	}
+	if c.UserId != "" && c.UserId != "000000000000000000000000" {
+		cm.UserId, err = primitive.ObjectIDFromHex(c.UserId)
+	}
+	for _, mention := range c.Mentions {
+		mentionId, mErr := primitive.ObjectIDFromHex(mention)
+		if mErr != nil {
+			return cm, mErr
+		}
+		cm.Mentions = append(cm.Mentions, mentionId)
+	}
+	return
+}
+
+// update the commentModel using an overwrite bson doc
+func (c *commentModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	cm := commentModel{}
+	err = bson.Unmarshal(data, &cm)
+	if cm.Body != "" {
+		c.Body = cm.Body
+	}
+	if cm.Mentions != nil {
+		c.Mentions = cm.Mentions
+	}
+	if !cm.EditedAt.IsZero() {
+		c.EditedAt = cm.EditedAt
+	}
+	if !cm.LastModified.IsZero() {
+		c.LastModified = cm.LastModified
+	}
+	if !cm.DeletedAt.IsZero() {
+		c.DeletedAt = cm.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the commentModel
+func (c *commentModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, c)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the commentModel
+func (c *commentModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	cm := commentModel{}
+	err = bson.Unmarshal(data, &cm)
+	if cm.Id.Hex() != "" && cm.Id.Hex() != "000000000000000000000000" {
+		return c.Id == cm.Id
+	}
+	if cm.TaskId.Hex() != "" && cm.TaskId.Hex() != "000000000000000000000000" {
+		return c.TaskId == cm.TaskId
+	}
+	if cm.GroupId.Hex() != "" && cm.GroupId.Hex() != "000000000000000000000000" {
+		return c.GroupId == cm.GroupId
+	}
+	return false
+}
+
+// getID returns the unique identifier of the commentModel
+func (c *commentModel) getID() (id interface{}) {
+	return c.Id
+}
+
+// getDeletedAt returns the time the commentModel was soft deleted at
+func (c *commentModel) getDeletedAt() time.Time {
+	return c.DeletedAt
+}
+
+// addTimeStamps updates a commentModel struct with a timestamp
+func (c *commentModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	c.LastModified = currentTime
+	if newRecord {
+		c.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a commentModel has a value assigned for Id, if no value a new one is generated and assigned
+func (c *commentModel) addObjectID() {
+	if c.Id.Hex() == "" || c.Id.Hex() == "000000000000000000000000" {
+		c.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a commentModel struct postProcess to do things such as validating required fields
+func (c *commentModel) postProcess() (err error) {
+	if c.TaskId.IsZero() || c.Body == "" {
+		err = errors.New("comment record does not have a TaskId and Body")
+	}
+	return
+}
+
+// toDoc converts the bson commentModel into a bson.D
+func (c *commentModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the commentModel data
+func (c *commentModel) bsonFilter() (doc bson.D, err error) {
+	if c.Id.Hex() != "" && c.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", c.Id}}
+	} else if c.TaskId.Hex() != "" && c.TaskId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"task_id", c.TaskId}}
+	} else if c.GroupId.Hex() != "" && c.GroupId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"group_id", c.GroupId}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the commentModel data
+func (c *commentModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := c.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a Comment JSON struct from a pointer to a BSON commentModel
+func (c *commentModel) toRoot() *models.Comment {
+	cm := &models.Comment{
+		Id:           c.Id.Hex(),
+		TaskId:       c.TaskId.Hex(),
+		GroupId:      c.GroupId.Hex(),
+		UserId:       c.UserId.Hex(),
+		Body:         c.Body,
+		EditedAt:     c.EditedAt,
+		LastModified: c.LastModified,
+		CreatedAt:    c.CreatedAt,
+		DeletedAt:    c.DeletedAt,
+	}
+	for _, mention := range c.Mentions {
+		cm.Mentions = append(cm.Mentions, mention.Hex())
+	}
+	return cm
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CommentService is used by the app to manage all Comment related controllers and functionality
+type CommentService struct {
+	collection        DBCollection
+	db                DBClient
+	handler           *DBHandler[*commentModel]
+	taskHandler       *DBHandler[*taskModel]
+	userHandler       *DBHandler[*userModel]
+	membershipHandler *DBHandler[*membershipModel]
+}
+
+// NewCommentService is an exported function used to initialize a new CommentService struct
+func NewCommentService(db DBClient, handler *DBHandler[*commentModel], tHandler *DBHandler[*taskModel], uHandler *DBHandler[*userModel], mHandler *DBHandler[*membershipModel]) *CommentService {
+	collection := db.GetCollection("comments")
+	return &CommentService{collection, db, handler, tHandler, uHandler, mHandler}
+}
+
+// groupUsernames returns the ids of the users of a group, both its own users and members, keyed by lowercase username
+func (p *CommentService) groupUsernames(ctx context.Context, groupId primitive.ObjectID) (map[string]primitive.ObjectID, error) {
+	usernames := make(map[string]primitive.ObjectID)
+	ums, err := p.userHandler.FindMany(ctx, &userModel{GroupId: groupId})
+	if err != nil {
+		return usernames, err
+	}
+	mms, err := p.membershipHandler.FindMany(ctx, &membershipModel{GroupId: groupId})
+	if err != nil {
+		return usernames, err
+	}
+	for _, mm := range mms {
+		um, err := p.userHandler.FindOne(ctx, &userModel{Id: mm.UserId})
+		if err != nil {
+			continue
+		}
+		ums = append(ums, um)
+	}
+	for _, um := range ums {
+		if um.Username != "" {
+			usernames[strings.ToLower(um.Username)] = um.Id
+		}
+	}
+	return usernames, nil
+}
+
+// resolveMentions returns the ids of the users of a group @mentioned in a Comment, unknown usernames are ignored
+func (p *CommentService) resolveMentions(ctx context.Context, c *models.Comment, groupId primitive.ObjectID) ([]primitive.ObjectID, error) {
+	var mentions []primitive.ObjectID
+	mentioned := c.MentionedUsernames()
+	if len(mentioned) == 0 {
+		return mentions, nil
+	}
+	usernames, err := p.groupUsernames(ctx, groupId)
+	if err != nil {
+		return mentions, err
+	}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, username := range mentioned {
+		if id, ok := usernames[strings.ToLower(username)]; ok && !seen[id] {
+			seen[id] = true
+			mentions = append(mentions, id)
+		}
+	}
+	return mentions, nil
+}
+
+// CommentCreate is used to create a new Comment on a Task
+func (p *CommentService) CommentCreate(ctx context.Context, c *models.Comment) (*models.Comment, error) {
+	err := c.Validate("create")
+	if err != nil {
+		return nil, err
+	}
+	c.Mentions = nil
+	cm, err := newCommentModel(c)
+	if err != nil {
+		return nil, err
+	}
+	tm, err := p.taskHandler.FindOne(ctx, &taskModel{Id: cm.TaskId})
+	if err != nil || tm.GroupId != cm.GroupId {
+		return nil, errors.New("task not found")
+	}
+	cm.Mentions, err = p.resolveMentions(ctx, c, cm.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	cm.EditedAt = time.Time{}
+	cm, err = p.handler.InsertOne(ctx, cm)
+	if err != nil {
+		return nil, err
+	}
+	comment := cm.toRoot()
+	emit(p.db, models.EventCommentCreated, comment.GroupId, comment, nil)
+	return comment, nil
+}
+
+// CommentsFind is used to find the Comments of a Task, oldest first
+func (p *CommentService) CommentsFind(ctx context.Context, c *models.Comment) ([]*models.Comment, error) {
+	var comments []*models.Comment
+	if !c.CheckID("task_id") {
+		return comments, errors.New("missing valid query filter")
+	}
+	cm, err := newCommentModel(&models.Comment{TaskId: c.TaskId})
+	if err != nil {
+		return comments, err
+	}
+	cms, err := p.handler.FindMany(ctx, cm)
+	if err != nil {
+		return comments, err
+	}
+	sort.SliceStable(cms, func(i, j int) bool {
+		return cms[i].CreatedAt.Before(cms[j].CreatedAt)
+	})
+	for _, r := range cms {
+		comments = append(comments, r.toRoot())
+	}
+	return comments, nil
+}
+
+// CommentFind is used to find a specific Comment, when a task_id is given the Comment must belong to that Task
+func (p *CommentService) CommentFind(ctx context.Context, c *models.Comment) (*models.Comment, error) {
+	if !c.CheckID("id") {
+		return nil, errors.New("missing valid query filter")
+	}
+	cm, err := newCommentModel(&models.Comment{Id: c.Id})
+	if err != nil {
+		return nil, err
+	}
+	cm, err = p.handler.FindOne(ctx, cm)
+	if err != nil {
+		return nil, errors.New("comment not found")
+	}
+	if c.CheckID("task_id") && cm.TaskId.Hex() != c.TaskId {
+		return nil, errors.New("comment not found")
+	}
+	return cm.toRoot(), nil
+}
+
+// CommentUpdate is used to edit the body of an existing Comment, its mentions are resolved again
+func (p *CommentService) CommentUpdate(ctx context.Context, c *models.Comment) (*models.Comment, error) {
+	err := c.Validate("update")
+	if err != nil {
+		return nil, err
+	}
+	cur, err := p.CommentFind(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	f, err := newCommentModel(&models.Comment{Id: cur.Id})
+	if err != nil {
+		return nil, err
+	}
+	cm, err := newCommentModel(&models.Comment{Id: cur.Id, TaskId: cur.TaskId, GroupId: cur.GroupId, UserId: cur.UserId, Body: c.Body, CreatedAt: cur.CreatedAt})
+	if err != nil {
+		return nil, err
+	}
+	cm.Mentions, err = p.resolveMentions(ctx, c, cm.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	cm.EditedAt = time.Now().UTC()
+	cm, err = p.handler.UpdateOne(ctx, f, cm)
+	if err != nil {
+		return nil, err
+	}
+	if len(cm.Mentions) == 0 && len(cur.Mentions) > 0 {
+		err = p.handler.UnsetFields(ctx, f, "mentions")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cm.toRoot(), nil
+}
+
+// CommentDelete is used to delete a Comment
+func (p *CommentService) CommentDelete(ctx context.Context, c *models.Comment) (*models.Comment, error) {
+	cur, err := p.CommentFind(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := newCommentModel(&models.Comment{Id: cur.Id})
+	if err != nil {
+		return nil, err
+	}
+	cm, err = p.handler.DeleteOne(ctx, cm)
+	if err != nil {
+		return nil, err
+	}
+	return cm.toRoot(), nil
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+	"time"
+)
+
+func Test_CommentCreate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string          // The name of the test
+		mentions []string        // The user ids we want the comment to mention
+		wantErr  bool            // whether we want an error.
+		comment  *models.Comment // The input of the test
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			[]string{"000000000000000000000014", "000000000000000000000015"},
+			false,
+			&models.Comment{
+				TaskId:  "000000000000000000000022",
+				GroupId: "000000000000000000000002",
+				UserId:  "000000000000000000000012",
+				Body:    "Thanks @jill, @Bill and @jill! Not `@outsider`, @outsider or @nobody.",
+			},
+		},
+		{
+			"missing body",
+			nil,
+			true,
+			&models.Comment{
+				TaskId:  "000000000000000000000022",
+				GroupId: "000000000000000000000002",
+				UserId:  "000000000000000000000012",
+				Body:    "  ",
+			},
+		},
+		{
+			"task in another group",
+			nil,
+			true,
+			&models.Comment{
+				TaskId:  "000000000000000000000022",
+				GroupId: "000000000000000000000001",
+				UserId:  "000000000000000000000011",
+				Body:    "Hello",
+			},
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestComments()
+			got, err := testService.CommentCreate(context.Background(), tt.comment)
+			fmt.Println("\nPOST CREATE: ", got)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CommentService.CommentCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Id == "" || got.CreatedAt.IsZero() || !got.EditedAt.IsZero() || fmt.Sprint(got.Mentions) != fmt.Sprint(tt.mentions) {
+				t.Errorf("CommentService.CommentCreate() = %+v, want mentions %v", got, tt.mentions)
+			}
+		})
+	}
+}
+
+func Test_CommentUpdate(t *testing.T) {
+	testService := setupTestComments()
+	created, err := testService.CommentCreate(context.Background(), &models.Comment{
+		TaskId:  "000000000000000000000022",
+		GroupId: "000000000000000000000002",
+		UserId:  "000000000000000000000012",
+		Body:    "cc @jill",
+	})
+	if err != nil {
+		t.Fatalf("CommentService.CommentCreate() error = %v", err)
+	}
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string   // The name of the test
+		body     string   // The new body of the comment
+		mentions []string // The user ids we want the comment to mention
+		wantErr  bool     // whether we want an error.
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"new mention", "cc @bill", []string{"000000000000000000000015"}, false},
+		{"no mentions", "never mind", nil, false},
+		{"empty body", "", nil, true},
+	}
+	// Iterating over the previous test slice, each test edits the same comment
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testService.CommentUpdate(context.Background(), &models.Comment{Id: created.Id, TaskId: created.TaskId, Body: tt.body})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CommentService.CommentUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := testService.CommentFind(context.Background(), &models.Comment{Id: created.Id})
+			if err != nil {
+				t.Fatalf("CommentService.CommentFind() error = %v", err)
+			}
+			if got.Body != tt.body || got.EditedAt.IsZero() || got.UserId != created.UserId || fmt.Sprint(got.Mentions) != fmt.Sprint(tt.mentions) {
+				t.Errorf("CommentService.CommentUpdate() = %+v, want mentions %v", got, tt.mentions)
+			}
+		})
+	}
+	if _, err = testService.CommentUpdate(context.Background(), &models.Comment{Id: created.Id, TaskId: "000000000000000000000023", Body: "moved"}); err == nil {
+		t.Errorf("CommentService.CommentUpdate() expected an error for a comment of another task")
+	}
+}
+
+func Test_CommentDelete(t *testing.T) {
+	testService := setupTestComments()
+	for _, body := range []string{"first", "second"} {
+		_, err := testService.CommentCreate(context.Background(), &models.Comment{
+			TaskId:  "000000000000000000000022",
+			GroupId: "000000000000000000000002",
+			UserId:  "000000000000000000000012",
+			Body:    body,
+		})
+		if err != nil {
+			t.Fatalf("CommentService.CommentCreate() error = %v", err)
+		}
+	}
+	comments, err := testService.CommentsFind(context.Background(), &models.Comment{TaskId: "000000000000000000000022"})
+	if err != nil || len(comments) != 2 || comments[0].Body != "first" {
+		t.Fatalf("CommentService.CommentsFind() = %+v, error = %v", comments, err)
+	}
+	if _, err = testService.CommentDelete(context.Background(), &models.Comment{Id: comments[0].Id}); err != nil {
+		t.Fatalf("CommentService.CommentDelete() error = %v", err)
+	}
+	comments, err = testService.CommentsFind(context.Background(), &models.Comment{TaskId: "000000000000000000000022"})
+	if err != nil || len(comments) != 1 || comments[0].Body != "second" {
+		t.Errorf("CommentService.CommentsFind() = %+v, error = %v", comments, err)
+	}
+}
+
+func Test_ActivityFind(t *testing.T) {
+	cs := setupTestComments()
+	gHandler := cs.db.NewGroupHandler()
+	ts := &TaskService{cs.db.GetCollection("tasks"), cs.db, cs.taskHandler, cs.userHandler, gHandler, cs.db.NewTaskHistoryHandler(), cs.membershipHandler, NewTaskSeriesService(cs.db, cs.db.NewTaskSeriesHandler(), cs.taskHandler, gHandler)}
+	time.Sleep(2 * time.Millisecond)
+	if _, err := ts.TaskUpdate(context.Background(), &models.Task{Id: "000000000000000000000022", Status: models.INPROGRESS}, "000000000000000000000012"); err != nil {
+		t.Fatalf("TaskService.TaskUpdate() error = %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	comment, err := cs.CommentCreate(context.Background(), &models.Comment{
+		TaskId:  "000000000000000000000022",
+		GroupId: "000000000000000000000002",
+		UserId:  "000000000000000000000013",
+		Body:    "Started",
+	})
+	if err != nil {
+		t.Fatalf("CommentService.CommentCreate() error = %v", err)
+	}
+	testService := NewActivityService(cs.db, cs.taskHandler, ts.historyHandler, cs.handler)
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string    // The name of the test
+		before  time.Time // The time the activities happened before
+		limit   int64     // The maximum number of activities
+		types   []string  // The types of activity we want, newest first
+		wantErr bool      // whether we want an error.
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"latest", time.Time{}, 10, []string{models.ActivityCommentCreated, models.ActivityTaskStatusChanged, models.ActivityTaskCreated, models.ActivityTaskCreated}, false},
+		{"limited", time.Time{}, 2, []string{models.ActivityCommentCreated, models.ActivityTaskStatusChanged}, false},
+		{"before comment", comment.CreatedAt, 10, []string{models.ActivityTaskStatusChanged, models.ActivityTaskCreated, models.ActivityTaskCreated}, false},
+		{"invalid limit", time.Time{}, 0, nil, true},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testService.ActivityFind(context.Background(), "000000000000000000000002", tt.before, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ActivityService.ActivityFind() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var types []string
+			for _, a := range got {
+				types = append(types, a.Type)
+				if a.TaskName == "" {
+					t.Errorf("ActivityService.ActivityFind() activity without a task name = %+v", a)
+				}
+			}
+			if fmt.Sprint(types) != fmt.Sprint(tt.types) {
+				t.Errorf("ActivityService.ActivityFind() = %v, want %v", types, tt.types)
+			}
+		})
+	}
+}
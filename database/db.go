@@ -2,11 +2,16 @@ package database
 
 import (
 	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/gridfs"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -21,6 +26,7 @@ type dbModel interface {
 	addObjectID()
 	postProcess() (err error)
 	getID() (id interface{})
+	getDeletedAt() time.Time
 	update(doc interface{}) (err error)
 	match(doc interface{}) bool
 }
@@ -37,8 +43,30 @@ type DBClient interface {
 	NewBlacklistHandler() *DBHandler[*blacklistModel]
 	NewTaskHandler() *DBHandler[*taskModel]
 	NewFileHandler() *DBHandler[*fileModel]
+	NewRefreshTokenHandler() *DBHandler[*refreshTokenModel]
+	NewAPIKeyHandler() *DBHandler[*apiKeyModel]
+	NewUserTokenHandler() *DBHandler[*userTokenModel]
+	NewLoginAttemptHandler() *DBHandler[*loginAttemptModel]
+	NewRoleHandler() *DBHandler[*roleModel]
+	NewMembershipHandler() *DBHandler[*membershipModel]
+	NewInvitationHandler() *DBHandler[*invitationModel]
+	NewIdentityHandler() *DBHandler[*identityModel]
+	NewTaskHistoryHandler() *DBHandler[*taskHistoryModel]
+	NewCommentHandler() *DBHandler[*commentModel]
+	NewTaskSeriesHandler() *DBHandler[*taskSeriesModel]
+	NewFeedTokenHandler() *DBHandler[*feedTokenModel]
+	NewAuditEventHandler() *DBHandler[*auditEventModel]
+	NewWebhookHandler() *DBHandler[*webhookModel]
+	NewWebhookDeliveryHandler() *DBHandler[*webhookDeliveryModel]
+	Subscribe(s EventSubscriber)
+	Emit(e *models.Event)
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
+// errTransactionsUnsupported is returned by WithTransaction when the database deployment is a standalone server, which
+// does not support multi-document transactions
+var errTransactionsUnsupported = errors.New("transactions are not supported by the database deployment")
+
 // DBCursor is an abstraction of the dbClient and testDBClient types
 type DBCursor interface {
 	Next(ctx context.Context) bool
@@ -60,7 +88,9 @@ type DBCollection interface {
 	InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
 	DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
 	FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult
+	FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult
 	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
 	UpdateByID(ctx context.Context, id interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
 	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error)
 	FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
@@ -68,10 +98,24 @@ type DBCollection interface {
 	DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
 }
 
+// textIndexes lists the weighted fields of the text index of each collection that can be searched
+var textIndexes = map[string]bson.D{
+	"tasks":  {{"name", 10}, {"description", 2}},
+	"users":  {{"username", 10}, {"firstname", 5}, {"lastname", 5}, {"email", 5}},
+	"groups": {{"name", 10}},
+}
+
+// textSearcher is implemented by the collections that rank a text search in memory instead of with a text index
+type textSearcher interface {
+	textSearch(filter bson.D, query string, limit int64) (docs []dbModel, scores []float64, err error)
+}
+
 // DBClient manages a database connection
 type dbClient struct {
+	eventBus
 	connectionURI string
 	client        *mongo.Client
+	transactions  bool
 }
 
 // InitializeNewClient returns an initialized DBClient based on the ENV
@@ -94,7 +138,59 @@ func initializeNewClient() (*dbClient, error) {
 func (db *dbClient) Connect() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	return db.client.Connect(ctx)
+	err := db.client.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	db.transactions = db.supportsTransactions(ctx)
+	return db.ensureTextIndexes(ctx)
+}
+
+// supportsTransactions determines whether the database deployment is a replica set or a sharded cluster, the
+// deployments that support multi-document transactions
+func (db *dbClient) supportsTransactions(ctx context.Context) bool {
+	var hello bson.M
+	if err := db.client.Database("admin").RunCommand(ctx, bson.D{{"hello", 1}}).Decode(&hello); err != nil {
+		return false
+	}
+	_, replicaSet := hello["setName"]
+	return replicaSet || hello["msg"] == "isdbgrid"
+}
+
+// WithTransaction runs fn in a multi-document transaction, which is committed when fn returns no error and retried
+// on transient errors until ctx is done. The operations of fn must use the ctx passed to it to be part of the transaction
+func (db *dbClient) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !db.transactions {
+		return errTransactionsUnsupported
+	}
+	session, err := db.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// ensureTextIndexes creates the text index of each searchable collection, an index that already exists is left as is
+func (db *dbClient) ensureTextIndexes(ctx context.Context) error {
+	for collectionName, weights := range textIndexes {
+		var keys bson.D
+		for _, w := range weights {
+			keys = append(keys, bson.E{Key: w.Key, Value: "text"})
+		}
+		index := mongo.IndexModel{
+			Keys:    keys,
+			Options: options.Index().SetName("text_search").SetWeights(weights),
+		}
+		_, err := db.client.Database(os.Getenv("DATABASE")).Collection(collectionName).Indexes().CreateOne(ctx, index)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Close closes an open DB connection
@@ -174,22 +270,320 @@ func (db *dbClient) NewFileHandler() *DBHandler[*fileModel] {
 	}
 }
 
+// NewRefreshTokenHandler returns a new DBHandler refresh tokens interface
+func (db *dbClient) NewRefreshTokenHandler() *DBHandler[*refreshTokenModel] {
+	col := db.GetCollection("refresh_tokens")
+	return &DBHandler[*refreshTokenModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewAPIKeyHandler returns a new DBHandler api keys interface
+func (db *dbClient) NewAPIKeyHandler() *DBHandler[*apiKeyModel] {
+	col := db.GetCollection("api_keys")
+	return &DBHandler[*apiKeyModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewUserTokenHandler returns a new DBHandler user tokens interface
+func (db *dbClient) NewUserTokenHandler() *DBHandler[*userTokenModel] {
+	col := db.GetCollection("user_tokens")
+	return &DBHandler[*userTokenModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewLoginAttemptHandler returns a new DBHandler login attempts interface
+func (db *dbClient) NewLoginAttemptHandler() *DBHandler[*loginAttemptModel] {
+	col := db.GetCollection("login_attempts")
+	return &DBHandler[*loginAttemptModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewRoleHandler returns a new DBHandler roles interface
+func (db *dbClient) NewRoleHandler() *DBHandler[*roleModel] {
+	col := db.GetCollection("roles")
+	return &DBHandler[*roleModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewMembershipHandler returns a new DBHandler memberships interface
+func (db *dbClient) NewMembershipHandler() *DBHandler[*membershipModel] {
+	col := db.GetCollection("memberships")
+	return &DBHandler[*membershipModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewInvitationHandler returns a new DBHandler invitations interface
+func (db *dbClient) NewInvitationHandler() *DBHandler[*invitationModel] {
+	col := db.GetCollection("invitations")
+	return &DBHandler[*invitationModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewIdentityHandler returns a new DBHandler identities interface
+func (db *dbClient) NewIdentityHandler() *DBHandler[*identityModel] {
+	col := db.GetCollection("identities")
+	return &DBHandler[*identityModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewTaskHistoryHandler returns a new DBHandler task history interface
+func (db *dbClient) NewTaskHistoryHandler() *DBHandler[*taskHistoryModel] {
+	col := db.GetCollection("task_history")
+	return &DBHandler[*taskHistoryModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewCommentHandler returns a new DBHandler comments interface
+func (db *dbClient) NewCommentHandler() *DBHandler[*commentModel] {
+	col := db.GetCollection("comments")
+	return &DBHandler[*commentModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewTaskSeriesHandler returns a new DBHandler task series interface
+func (db *dbClient) NewTaskSeriesHandler() *DBHandler[*taskSeriesModel] {
+	col := db.GetCollection("task_series")
+	return &DBHandler[*taskSeriesModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewFeedTokenHandler returns a new DBHandler feed tokens interface
+func (db *dbClient) NewFeedTokenHandler() *DBHandler[*feedTokenModel] {
+	col := db.GetCollection("feed_tokens")
+	return &DBHandler[*feedTokenModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewAuditEventHandler returns a new DBHandler audit events interface
+func (db *dbClient) NewAuditEventHandler() *DBHandler[*auditEventModel] {
+	col := db.GetCollection("audit_events")
+	return &DBHandler[*auditEventModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewWebhookHandler returns a new DBHandler webhooks interface
+func (db *dbClient) NewWebhookHandler() *DBHandler[*webhookModel] {
+	col := db.GetCollection("webhooks")
+	return &DBHandler[*webhookModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewWebhookDeliveryHandler returns a new DBHandler webhook deliveries interface
+func (db *dbClient) NewWebhookDeliveryHandler() *DBHandler[*webhookDeliveryModel] {
+	col := db.GetCollection("webhook_deliveries")
+	return &DBHandler[*webhookDeliveryModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
 // DBHandler is a Generic type struct for organizing dbModel methods
 type DBHandler[T dbModel] struct {
 	db         DBClient
 	collection DBCollection
 }
 
+// activeFilter appends a clause to a bson filter that excludes soft deleted documents
+func activeFilter(f bson.D) bson.D {
+	return append(f, bson.E{Key: "deleted_at", Value: bson.D{{"$exists", false}}})
+}
+
+// deletedFilter appends a clause to a bson filter that only matches soft deleted documents
+func deletedFilter(f bson.D) bson.D {
+	return append(f, bson.E{Key: "deleted_at", Value: bson.D{{"$exists", true}}})
+}
+
+// deletedSinceFilter appends a clause to a bson filter that matches documents soft deleted at or after a given time
+func deletedSinceFilter(f bson.D, since time.Time) bson.D {
+	return append(f, bson.E{Key: "deleted_at", Value: bson.D{{"$gte", since}}})
+}
+
+// deletedBeforeFilter appends a clause to a bson filter that matches documents soft deleted at or before a given time
+func deletedBeforeFilter(f bson.D, before time.Time) bson.D {
+	return append(f, bson.E{Key: "deleted_at", Value: bson.D{{"$lte", before}}})
+}
+
+// deletedAtFilter appends a clause to a bson filter that matches documents soft deleted at exactly a given time
+func deletedAtFilter(f bson.D, deletedAt time.Time) bson.D {
+	return append(f, bson.E{Key: "deleted_at", Value: bson.D{{"$gte", deletedAt}, {"$lte", deletedAt}}})
+}
+
+// listFilter appends the equality filters of a list request to a scoped bson filter
+// ok is false when a filter contradicts the scope, in which case nothing can match
+func listFilter(f bson.D, filters map[string]string) (doc bson.D, ok bool, err error) {
+	doc = f
+	for field, v := range filters {
+		var value interface{} = v
+		key := field
+		if field == "id" {
+			key = "_id"
+		}
+		if strings.HasSuffix(key, "_id") {
+			value, err = primitive.ObjectIDFromHex(v)
+			if err != nil {
+				return doc, false, errors.New("invalid " + field + " filter")
+			}
+		}
+		scoped := false
+		for _, e := range f {
+			if e.Key == key {
+				if e.Value != value {
+					return doc, false, nil
+				}
+				scoped = true
+			}
+		}
+		if !scoped {
+			doc = append(doc, bson.E{Key: key, Value: value})
+		}
+	}
+	return doc, true, nil
+}
+
+// listRanges appends the $gte and $lte clauses of the range filters of a list request to a bson filter
+func listRanges(f bson.D, ranges map[string]string) (bson.D, error) {
+	clauses := make(map[string]bson.D)
+	var keys []string
+	for field, v := range ranges {
+		key, op := strings.TrimSuffix(field, "_gte"), "$gte"
+		if strings.HasSuffix(field, "_lte") {
+			key, op = strings.TrimSuffix(field, "_lte"), "$lte"
+		}
+		value, err := models.ParseRangeValue(v)
+		if err != nil {
+			return f, errors.New("invalid " + field + " filter")
+		}
+		if _, ok := clauses[key]; !ok {
+			keys = append(keys, key)
+		}
+		clauses[key] = append(clauses[key], bson.E{Key: op, Value: value})
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f = append(f, bson.E{Key: key, Value: clauses[key]})
+	}
+	return f, nil
+}
+
+// listSort converts the sort fields of a list request into a bson sort, using the _id as a tiebreaker
+func listSort(fields []string) bson.D {
+	sort := bson.D{}
+	for _, field := range fields {
+		order := 1
+		if strings.HasPrefix(field, "-") {
+			order = -1
+			field = strings.TrimPrefix(field, "-")
+		}
+		if field == "id" {
+			field = "_id"
+		}
+		sort = append(sort, bson.E{Key: field, Value: order})
+	}
+	return append(sort, bson.E{Key: "_id", Value: 1})
+}
+
+// softDeleteUpdate returns a bson update that stamps the deleted_at field of a document
+func softDeleteUpdate(deletedAt time.Time) bson.D {
+	return bson.D{{"$set", bson.D{{"deleted_at", deletedAt}, {"last_modified", deletedAt}}}}
+}
+
+// restoreUpdate returns a bson update that clears the deleted_at field of a document
+func restoreUpdate(restoredAt time.Time) bson.D {
+	return bson.D{{"$unset", bson.D{{"deleted_at", ""}}}, {"$set", bson.D{{"last_modified", restoredAt}}}}
+}
+
+// dbOperation is a kind of DBHandler operation, the timeout of each kind can be configured
+type dbOperation int
+
+const (
+	readOperation  dbOperation = iota // finds, counts and searches
+	writeOperation                    // inserts, updates, deletes and restores of a single record
+	bulkOperation                     // updates, deletes, restores and purges of every record matching a filter
+)
+
+// operationTimeouts maps each dbOperation to the env var that configures its timeout and the timeout used otherwise
+var operationTimeouts = map[dbOperation]struct {
+	env      string
+	fallback time.Duration
+}{
+	readOperation:  {"DB_READ_TIMEOUT", 30 * time.Second},
+	writeOperation: {"DB_WRITE_TIMEOUT", 10 * time.Second},
+	bulkOperation:  {"DB_BULK_TIMEOUT", 30 * time.Second},
+}
+
+// operationTimeout returns how long a dbOperation may run
+func operationTimeout(op dbOperation) time.Duration {
+	t := operationTimeouts[op]
+	timeout, err := time.ParseDuration(os.Getenv(t.env))
+	if err != nil || timeout <= 0 {
+		return t.fallback
+	}
+	return timeout
+}
+
+// withTimeout returns a copy of ctx that is cancelled once the timeout of a dbOperation passes. ctx is usually that of
+// an http.Request, so the operation is also cancelled when the client goes away or the request's deadline passes
+func withTimeout(ctx context.Context, op dbOperation) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, operationTimeout(op))
+}
+
 // FindOne is used to get a dbModel from the db with custom filter
-func (h *DBHandler[T]) FindOne(filter T) (T, error) {
+func (h *DBHandler[T]) FindOne(ctx context.Context, filter T) (T, error) {
 	var m T
 	f, err := filter.bsonFilter()
 	if err != nil {
 		return filter, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withTimeout(ctx, readOperation)
 	defer cancel()
-	err = h.collection.FindOne(ctx, f).Decode(&m)
+	err = h.collection.FindOne(ctx, activeFilter(f)).Decode(&m)
+	if err != nil {
+		return filter, err
+	}
+	return m, nil
+}
+
+// FindOneDeleted is used to get a soft deleted dbModel from the db with custom filter
+func (h *DBHandler[T]) FindOneDeleted(ctx context.Context, filter T) (T, error) {
+	var m T
+	f, err := filter.bsonFilter()
+	if err != nil {
+		return filter, err
+	}
+	if len(f) == 0 {
+		return filter, errors.New("filter cannot be empty for deleted lookup")
+	}
+	ctx, cancel := withTimeout(ctx, readOperation)
+	defer cancel()
+	err = h.collection.FindOne(ctx, deletedFilter(f)).Decode(&m)
 	if err != nil {
 		return filter, err
 	}
@@ -197,28 +591,19 @@ func (h *DBHandler[T]) FindOne(filter T) (T, error) {
 }
 
 // FindOneAsync is used to get a dbModel from the db with custom filter
-func (h *DBHandler[T]) FindOneAsync(tCh chan T, eCh chan error, filter T, wg *sync.WaitGroup) {
+func (h *DBHandler[T]) FindOneAsync(ctx context.Context, tCh chan T, eCh chan error, filter T, wg *sync.WaitGroup) {
 	defer wg.Done()
-	t, err := h.FindOne(filter)
+	t, err := h.FindOne(ctx, filter)
 	tCh <- t
 	eCh <- err
 }
 
-// FindMany is used to get a slice of dbModels from the db with custom filter
-func (h *DBHandler[T]) FindMany(filter T) ([]T, error) {
+// findMany decodes every dbModel returned by a bson filter
+func (h *DBHandler[T]) findMany(ctx context.Context, f bson.D, opts ...*options.FindOptions) ([]T, error) {
 	var m []T
-	f, err := filter.bsonFilter()
-	if err != nil {
-		return m, err
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withTimeout(ctx, readOperation)
 	defer cancel()
-	var cur *mongo.Cursor
-	if len(f) > 0 {
-		cur, err = h.collection.Find(ctx, f)
-	} else {
-		cur, err = h.collection.Find(ctx, bson.M{})
-	}
+	cur, err := h.collection.Find(ctx, f, opts...)
 	if err != nil {
 		return m, err
 	}
@@ -236,8 +621,123 @@ func (h *DBHandler[T]) FindMany(filter T) ([]T, error) {
 	return m, nil
 }
 
+// SearchText is used to get the dbModels that match a text search query and a filter, ranked by their text score
+func (h *DBHandler[T]) SearchText(ctx context.Context, f bson.D, query string, limit int64) ([]T, []float64, error) {
+	var m []T
+	var scores []float64
+	f = activeFilter(append(f, bson.E{Key: "$text", Value: bson.D{{"$search", query}}}))
+	if ts, ok := h.collection.(textSearcher); ok {
+		docs, docScores, err := ts.textSearch(f, query, limit)
+		if err != nil {
+			return m, scores, err
+		}
+		for i, doc := range docs {
+			raw, err := bson.Marshal(doc)
+			if err != nil {
+				return m, scores, err
+			}
+			var md T
+			if err = bson.Unmarshal(raw, &md); err != nil {
+				return m, scores, err
+			}
+			if err = md.postProcess(); err != nil {
+				return m, scores, err
+			}
+			m = append(m, md)
+			scores = append(scores, docScores[i])
+		}
+		return m, scores, nil
+	}
+	ctx, cancel := withTimeout(ctx, readOperation)
+	defer cancel()
+	score := bson.D{{"score", bson.D{{"$meta", "textScore"}}}}
+	opts := options.Find().SetProjection(score).SetSort(score).SetLimit(limit)
+	cur, err := h.collection.Find(ctx, f, opts)
+	if err != nil {
+		return m, scores, err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var md T
+		var scored struct {
+			Score float64 `bson:"score"`
+		}
+		if err = cur.Decode(&md); err != nil {
+			return m, scores, err
+		}
+		if err = cur.Decode(&scored); err != nil {
+			return m, scores, err
+		}
+		if err = md.postProcess(); err != nil {
+			return m, scores, err
+		}
+		m = append(m, md)
+		scores = append(scores, scored.Score)
+	}
+	return m, scores, nil
+}
+
+// FindMany is used to get a slice of dbModels from the db with custom filter
+func (h *DBHandler[T]) FindMany(ctx context.Context, filter T) ([]T, error) {
+	f, err := filter.bsonFilter()
+	if err != nil {
+		return nil, err
+	}
+	return h.findMany(ctx, activeFilter(f))
+}
+
+// FindPage is used to get a sorted, filtered and paginated slice of dbModels along with the total number of matches
+func (h *DBHandler[T]) FindPage(ctx context.Context, filter T, o *models.ListOptions) ([]T, int64, error) {
+	f, err := filter.bsonFilter()
+	if err != nil {
+		return nil, 0, err
+	}
+	return h.findPage(ctx, f, o)
+}
+
+// findPage decodes a sorted, filtered and paginated page of the dbModels returned by a bson filter
+func (h *DBHandler[T]) findPage(ctx context.Context, f bson.D, o *models.ListOptions) ([]T, int64, error) {
+	f, ok, err := listFilter(f, o.Filters)
+	if err != nil || !ok {
+		return nil, 0, err
+	}
+	f, err = listRanges(f, o.Ranges)
+	if err != nil {
+		return nil, 0, err
+	}
+	f = activeFilter(f)
+	ctx, cancel := withTimeout(ctx, readOperation)
+	defer cancel()
+	total, err := h.collection.CountDocuments(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOpts := options.Find().SetLimit(o.Limit).SetSort(listSort(o.Sort))
+	if o.Offset > 0 {
+		findOpts.SetSkip(o.Offset)
+	}
+	if o.After != "" {
+		after, err := primitive.ObjectIDFromHex(o.After)
+		if err != nil {
+			return nil, 0, err
+		}
+		f = append(f, bson.E{Key: "_id", Value: bson.D{{"$gt", after}}})
+	}
+	m, err := h.findMany(ctx, f, findOpts)
+	return m, total, err
+}
+
+// FindManyDeleted is used to get a slice of dbModels that were soft deleted at or before a given time
+func (h *DBHandler[T]) FindManyDeleted(ctx context.Context, filter T, before time.Time) ([]T, error) {
+	f, err := filter.bsonFilter()
+	if err != nil {
+		return nil, err
+	}
+	return h.findMany(ctx, deletedBeforeFilter(f, before))
+}
+
 // UpdateOne Function to update a dbModel from datasource with custom filter and update model
-func (h *DBHandler[T]) UpdateOne(filter T, m T) (T, error) {
+func (h *DBHandler[T]) UpdateOne(ctx context.Context, filter T, m T) (T, error) {
 	f, err := filter.bsonFilter()
 	if err != nil {
 		return m, err
@@ -247,9 +747,9 @@ func (h *DBHandler[T]) UpdateOne(filter T, m T) (T, error) {
 	if err != nil {
 		return m, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withTimeout(ctx, writeOperation)
 	defer cancel()
-	_, err = h.collection.UpdateOne(ctx, f, update)
+	_, err = h.collection.UpdateOne(ctx, activeFilter(f), update)
 	if err != nil {
 		return m, err
 	}
@@ -257,11 +757,122 @@ func (h *DBHandler[T]) UpdateOne(filter T, m T) (T, error) {
 	return m, err
 }
 
+// UpdateMany Function to update every dbModel matching a custom filter with an update model
+func (h *DBHandler[T]) UpdateMany(ctx context.Context, filter T, m T) error {
+	f, err := filter.bsonFilter()
+	if err != nil {
+		return err
+	}
+	if len(f) == 0 {
+		return errors.New("filter cannot be empty for mass update")
+	}
+	m.addTimeStamps(false)
+	update, err := m.bsonUpdate()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, bulkOperation)
+	defer cancel()
+	_, err = h.collection.UpdateMany(ctx, activeFilter(f), update)
+	return err
+}
+
+// UpdateIf updates the dbModel record matching a bson filter with an update model and reports whether one matched,
+// filtering on a field the update changes lets concurrent writers use it as a compare and set
+func (h *DBHandler[T]) UpdateIf(ctx context.Context, f bson.D, m T) (bool, error) {
+	if len(f) == 0 {
+		return false, errors.New("filter cannot be empty for conditional update")
+	}
+	m.addTimeStamps(false)
+	update, err := m.bsonUpdate()
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := withTimeout(ctx, writeOperation)
+	defer cancel()
+	res, err := h.collection.UpdateMany(ctx, activeFilter(f), update)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// IncrementOne atomically increments a counter field of the dbModel record matching a bson filter and sets the fields
+// of an update model, the record is inserted when none matches. The record is returned as it is after the update
+func (h *DBHandler[T]) IncrementOne(ctx context.Context, f bson.D, field string, m T) (T, error) {
+	var re T
+	if len(f) == 0 {
+		return re, errors.New("filter cannot be empty for increment")
+	}
+	m.addTimeStamps(true)
+	doc, err := m.toDoc()
+	if err != nil {
+		return re, err
+	}
+	set, setOnInsert := bson.D{}, bson.D{}
+	for _, e := range doc {
+		switch e.Key {
+		case field, "_id":
+		case "created_at":
+			setOnInsert = append(setOnInsert, e)
+		default:
+			set = append(set, e)
+		}
+	}
+	update := bson.D{{"$inc", bson.D{{field, 1}}}, {"$set", set}, {"$setOnInsert", setOnInsert}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	ctx, cancel := withTimeout(ctx, writeOperation)
+	defer cancel()
+	err = h.collection.FindOneAndUpdate(ctx, activeFilter(f), update, opts).Decode(&re)
+	if err != nil {
+		return re, err
+	}
+	err = re.postProcess()
+	return re, err
+}
+
+// UnsetIf removes fields from the dbModel records matching a bson filter and reports whether one matched
+func (h *DBHandler[T]) UnsetIf(ctx context.Context, f bson.D, fields ...string) (bool, error) {
+	if len(f) == 0 {
+		return false, errors.New("filter cannot be empty for conditional unset")
+	}
+	unset := bson.D{}
+	for _, field := range fields {
+		unset = append(unset, bson.E{Key: field, Value: ""})
+	}
+	ctx, cancel := withTimeout(ctx, writeOperation)
+	defer cancel()
+	res, err := h.collection.UpdateMany(ctx, activeFilter(f), bson.D{{"$unset", unset}, {"$set", bson.D{{"last_modified", time.Now().UTC()}}}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// UnsetFields removes fields from the dbModel record matching a custom filter
+func (h *DBHandler[T]) UnsetFields(ctx context.Context, filter T, fields ...string) error {
+	f, err := filter.bsonFilter()
+	if err != nil {
+		return err
+	}
+	if len(f) == 0 {
+		return errors.New("filter cannot be empty for unset")
+	}
+	unset := bson.D{}
+	for _, field := range fields {
+		unset = append(unset, bson.E{Key: field, Value: ""})
+	}
+	ctx, cancel := withTimeout(ctx, writeOperation)
+	defer cancel()
+	_, err = h.collection.UpdateOne(ctx, activeFilter(f), bson.D{{"$unset", unset}, {"$set", bson.D{{"last_modified", time.Now().UTC()}}}})
+	return err
+}
+
 // InsertOne adds a new dbModel record to a collection
-func (h *DBHandler[T]) InsertOne(m T) (T, error) {
+func (h *DBHandler[T]) InsertOne(ctx context.Context, m T) (T, error) {
 	m.addTimeStamps(true)
 	m.addObjectID()
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := withTimeout(ctx, writeOperation)
 	defer cancel()
 	_, err := h.collection.InsertOne(ctx, m)
 	if err != nil {
@@ -271,32 +882,162 @@ func (h *DBHandler[T]) InsertOne(m T) (T, error) {
 	return m, err
 }
 
-// DeleteOne adds a new dbModel record to a collection
-func (h *DBHandler[T]) DeleteOne(filter T) (T, error) { //TODO: to be replaced with "soft delete"
+// DeleteOne soft deletes a dbModel record by stamping its deleted_at field
+func (h *DBHandler[T]) DeleteOne(ctx context.Context, filter T) (T, error) {
 	var m T
 	f, err := filter.bsonFilter()
 	if err != nil {
 		return m, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := withTimeout(ctx, writeOperation)
 	defer cancel()
-	err = h.collection.FindOneAndDelete(ctx, f).Decode(&m)
+	err = h.collection.FindOne(ctx, activeFilter(f)).Decode(&m)
+	if err != nil {
+		return m, err
+	}
+	deletedAt := time.Now().UTC()
+	_, err = h.collection.UpdateOne(ctx, bson.D{{"_id", m.getID()}}, softDeleteUpdate(deletedAt))
+	if err != nil {
+		return m, err
+	}
+	err = m.bsonLoad(bson.D{{"deleted_at", deletedAt}, {"last_modified", deletedAt}})
 	return m, err
 }
 
-// DeleteMany adds a new dbModel record to a collection
-func (h *DBHandler[T]) DeleteMany(filter T) (T, error) { //TODO: to be replaced with "soft delete"
+// DeleteMany soft deletes every dbModel record matching the filter
+func (h *DBHandler[T]) DeleteMany(ctx context.Context, filter T) (T, error) {
 	var m T
 	f, err := filter.bsonFilter()
 	if err != nil {
 		return m, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if len(f) == 0 {
+		return m, errors.New("filter cannot be empty for mass delete")
+	}
+	ctx, cancel := withTimeout(ctx, bulkOperation)
 	defer cancel()
-	_, err = h.collection.DeleteMany(ctx, f)
+	_, err = h.collection.UpdateMany(ctx, activeFilter(f), softDeleteUpdate(time.Now().UTC()))
 	return filter, err
 }
 
+// RestoreOne clears the deleted_at field of a soft deleted dbModel record
+// Like FindOneAndUpdate, the record is returned as it was prior to being restored
+func (h *DBHandler[T]) RestoreOne(ctx context.Context, filter T) (T, error) {
+	m, err := h.FindOneDeleted(ctx, filter)
+	if err != nil {
+		return m, err
+	}
+	ctx, cancel := withTimeout(ctx, writeOperation)
+	defer cancel()
+	_, err = h.collection.UpdateOne(ctx, bson.D{{"_id", m.getID()}}, restoreUpdate(time.Now().UTC()))
+	return m, err
+}
+
+// RestoreMany clears the deleted_at field of every dbModel record matching the filter that was soft deleted at or after since
+func (h *DBHandler[T]) RestoreMany(ctx context.Context, filter T, since time.Time) error {
+	f, err := filter.bsonFilter()
+	if err != nil {
+		return err
+	}
+	if len(f) == 0 {
+		return errors.New("filter cannot be empty for mass restore")
+	}
+	ctx, cancel := withTimeout(ctx, bulkOperation)
+	defer cancel()
+	_, err = h.collection.UpdateMany(ctx, deletedSinceFilter(f, since), restoreUpdate(time.Now().UTC()))
+	return err
+}
+
+// softDelete stamps the deleted_at field of every active dbModel record matching a bson filter within ctx, which can be
+// that of a transaction, and returns how many records it soft deleted. A softDelete that failed part way through
+// returns the records it deleted before it failed when the database reports them, so repeated attempts add up
+func (h *DBHandler[T]) softDelete(ctx context.Context, f bson.D, deletedAt time.Time) (int64, error) {
+	if len(f) == 0 {
+		return 0, errors.New("filter cannot be empty for mass delete")
+	}
+	ctx, cancel := withTimeout(ctx, bulkOperation)
+	defer cancel()
+	res, err := h.collection.UpdateMany(ctx, activeFilter(f), softDeleteUpdate(deletedAt))
+	if res == nil {
+		return 0, err
+	}
+	return res.ModifiedCount, err
+}
+
+// undoSoftDelete clears the deleted_at field of every dbModel record matching a bson filter that was soft deleted at
+// exactly deletedAt, which undoes a softDelete without restoring the records deleted before it
+func (h *DBHandler[T]) undoSoftDelete(ctx context.Context, f bson.D, deletedAt time.Time) error {
+	if len(f) == 0 {
+		return errors.New("filter cannot be empty for mass restore")
+	}
+	ctx, cancel := withTimeout(ctx, bulkOperation)
+	defer cancel()
+	_, err := h.collection.UpdateMany(ctx, deletedAtFilter(f, deletedAt), restoreUpdate(time.Now().UTC()))
+	return err
+}
+
+// revoke stamps the revoked_at field of every dbModel record matching a bson filter that was not revoked yet within ctx,
+// which can be that of a transaction, and returns how many records it revoked. It is the softDelete of records such as
+// refresh tokens that are revoked rather than soft deleted
+func (h *DBHandler[T]) revoke(ctx context.Context, f bson.D, revokedAt time.Time) (int64, error) {
+	if len(f) == 0 {
+		return 0, errors.New("filter cannot be empty for mass revoke")
+	}
+	ctx, cancel := withTimeout(ctx, bulkOperation)
+	defer cancel()
+	f = append(f, bson.E{Key: "revoked_at", Value: nil})
+	res, err := h.collection.UpdateMany(ctx, activeFilter(f), bson.D{{"$set", bson.D{{"revoked_at", revokedAt}, {"last_modified", revokedAt}}}})
+	if res == nil {
+		return 0, err
+	}
+	return res.ModifiedCount, err
+}
+
+// undoRevoke clears the revoked_at field of every dbModel record matching a bson filter that was revoked at exactly
+// revokedAt, which undoes a revoke without reinstating the records revoked before it
+func (h *DBHandler[T]) undoRevoke(ctx context.Context, f bson.D, revokedAt time.Time) error {
+	if len(f) == 0 {
+		return errors.New("filter cannot be empty for mass revoke")
+	}
+	ctx, cancel := withTimeout(ctx, bulkOperation)
+	defer cancel()
+	f = append(f, bson.E{Key: "revoked_at", Value: bson.D{{"$gte", revokedAt}, {"$lte", revokedAt}}})
+	_, err := h.collection.UpdateMany(ctx, f, bson.D{{"$unset", bson.D{{"revoked_at", ""}}}, {"$set", bson.D{{"last_modified", time.Now().UTC()}}}})
+	return err
+}
+
+// Purge permanently removes every dbModel record that was soft deleted at or before a given time
+func (h *DBHandler[T]) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, bulkOperation)
+	defer cancel()
+	res, err := h.collection.DeleteMany(ctx, deletedBeforeFilter(bson.D{}, before))
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// PurgeOne permanently removes a dbModel record whether it is active or soft deleted, such as to roll back a record
+// that was just created
+func (h *DBHandler[T]) PurgeOne(ctx context.Context, filter T) (T, error) {
+	var m T
+	f, err := filter.bsonFilter()
+	if err != nil {
+		return m, err
+	}
+	if len(f) == 0 {
+		return m, errors.New("filter cannot be empty for purge")
+	}
+	ctx, cancel := withTimeout(ctx, writeOperation)
+	defer cancel()
+	err = h.collection.FindOne(ctx, f).Decode(&m)
+	if err != nil {
+		return m, err
+	}
+	_, err = h.collection.DeleteOne(ctx, bson.D{{"_id", m.getID()}})
+	return m, err
+}
+
 // newRoutine returns a new Routine for executing ASYNC DB statements
 func (h *DBHandler[T]) newRoutine() *dbRoutine[T] {
 	return &dbRoutine[T]{handler: h}
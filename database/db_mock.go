@@ -13,25 +13,344 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.mongodb.org/mongo-driver/x/bsonx"
 	"os"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 )
 
 /*
 ================ testDBUtils ==================
 */
 
-// cleanUpdateBSON inputs a bson type and attempts to marshall it into a slice of bytes
-func cleanUpdateBSON(bsonData interface{}) (data interface{}, err error) {
+// cleanUpdateBSON inputs a bson update and splits it into its $set document and the keys of its $unset document
+func cleanUpdateBSON(bsonData interface{}) (data interface{}, unset []string, err error) {
 	switch t := bsonData.(type) {
 	case nil:
-		return nil, errors.New("input bsonData to marshall can not be nil")
+		return nil, nil, errors.New("input bsonData to marshall can not be nil")
 	case bson.D:
-		if len(t) > 0 && t[0].Key == "$set" {
-			return t[0].Value, nil
+		if len(t) > 0 && (t[0].Key == "$set" || t[0].Key == "$unset") {
+			data = bson.D{}
+			for _, op := range t {
+				switch op.Key {
+				case "$set":
+					data = op.Value
+				case "$unset":
+					for _, e := range op.Value.(bson.D) {
+						unset = append(unset, e.Key)
+					}
+				}
+			}
+			return data, unset, nil
+		}
+		return t, nil, nil
+	}
+	return bsonData, nil, nil
+}
+
+// setDocField sets a field of a bson doc, appending the field when the doc does not have it yet
+func setDocField(doc bson.D, key string, value interface{}) bson.D {
+	for i, e := range doc {
+		if e.Key == key {
+			doc[i].Value = value
+			return doc
+		}
+	}
+	return append(doc, bson.E{Key: key, Value: value})
+}
+
+// incrementValue adds the value of an $inc operator to a bson number, a missing field counts as 0
+func incrementValue(v interface{}, by interface{}) int64 {
+	toInt := func(n interface{}) int64 {
+		switch t := n.(type) {
+		case int:
+			return int64(t)
+		case int32:
+			return int64(t)
+		case int64:
+			return t
+		case float64:
+			return int64(t)
+		}
+		return 0
+	}
+	return toInt(v) + toInt(by)
+}
+
+// splitDeletedFilter separates the deleted_at clause from a bson filter so the remainder can be matched by a dbModel
+func splitDeletedFilter(filter interface{}) (interface{}, bson.D) {
+	f, ok := filter.(bson.D)
+	if !ok {
+		return filter, nil
+	}
+	var clause bson.D
+	cleaned := bson.D{}
+	for _, e := range f {
+		if op, isOp := e.Value.(bson.D); isOp && e.Key == "deleted_at" {
+			clause = op
+			continue
+		}
+		cleaned = append(cleaned, e)
+	}
+	return cleaned, clause
+}
+
+// toTime converts a bson time value into a time.Time
+func toTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case primitive.DateTime:
+		return t.Time()
+	}
+	return time.Time{}
+}
+
+// matchDeleted checks whether a dbModel satisfies a deleted_at filter clause
+func matchDeleted(doc dbModel, clause bson.D) bool {
+	deletedAt := doc.getDeletedAt()
+	for _, op := range clause {
+		switch op.Key {
+		case "$exists":
+			if op.Value.(bool) == deletedAt.IsZero() {
+				return false
+			}
+		case "$gte":
+			if deletedAt.IsZero() || deletedAt.Before(toTime(op.Value)) {
+				return false
+			}
+		case "$lte":
+			if deletedAt.IsZero() || deletedAt.After(toTime(op.Value)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// splitCursorFilter separates the _id range clause used by after cursors from a bson filter
+func splitCursorFilter(filter bson.D) (bson.D, bson.D) {
+	var clause bson.D
+	cleaned := bson.D{}
+	for _, e := range filter {
+		if op, isOp := e.Value.(bson.D); isOp && e.Key == "_id" {
+			clause = op
+			continue
+		}
+		cleaned = append(cleaned, e)
+	}
+	return cleaned, clause
+}
+
+// matchCursor checks whether a dbModel satisfies an _id range clause
+func matchCursor(doc dbModel, clause bson.D) bool {
+	docId, err := standardizeID(doc)
+	if err != nil {
+		return false
+	}
+	for _, op := range clause {
+		switch op.Key {
+		case "$gt":
+			after, ok := op.Value.(primitive.ObjectID)
+			if !ok || docId <= after.Hex() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchFields checks whether a dbModel matches every field of a bson filter of equalities and $gte and $lte ranges,
+// an array field matches when it contains the filter value and an $or clause matches when any of its filters match
+func matchFields(doc dbModel, filter bson.D) bool {
+	data, err := doc.toDoc()
+	if err != nil {
+		return false
+	}
+	for _, e := range filter {
+		if clauses, isOr := e.Value.(bson.A); isOr && e.Key == "$or" {
+			if !matchAny(doc, clauses) {
+				return false
+			}
+			continue
+		}
+		v, ok := data.Map()[e.Key]
+		if e.Value == nil { // like mongo, a null filter matches a missing or null field
+			if ok && v != nil {
+				return false
+			}
+			continue
+		}
+		if !ok {
+			return false
+		}
+		if ops, isOp := e.Value.(bson.D); isOp {
+			if !matchRange(v, ops) {
+				return false
+			}
+			continue
+		}
+		if arr, isArr := v.(primitive.A); isArr {
+			if !containsValue(arr, e.Value) {
+				return false
+			}
+			continue
+		}
+		if fmt.Sprint(v) != fmt.Sprint(e.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny checks whether a dbModel matches any of the bson filters of an $or clause
+func matchAny(doc dbModel, clauses bson.A) bool {
+	for _, c := range clauses {
+		if f, ok := c.(bson.D); ok && matchFields(doc, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRange checks whether a bson value satisfies the $gte and $lte operators of a range clause
+func matchRange(v interface{}, ops bson.D) bool {
+	for _, op := range ops {
+		bound := op.Value
+		if t, isTime := bound.(time.Time); isTime {
+			bound = primitive.NewDateTimeFromTime(t)
+		}
+		c := compareValues(v, bound)
+		switch op.Key {
+		case "$gte":
+			if c < 0 {
+				return false
+			}
+		case "$lte":
+			if c > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// containsValue checks whether a bson array contains a value
+func containsValue(arr primitive.A, value interface{}) bool {
+	for _, v := range arr {
+		if fmt.Sprint(v) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareValues orders two bson values of the same field, returning -1, 0 or 1
+func compareValues(a interface{}, b interface{}) int {
+	switch at := a.(type) {
+	case nil:
+		if b == nil {
+			return 0
+		}
+		return -1
+	case primitive.DateTime:
+		bt, _ := b.(primitive.DateTime)
+		switch {
+		case at < bt:
+			return -1
+		case at > bt:
+			return 1
+		}
+		return 0
+	case primitive.ObjectID:
+		bt, _ := b.(primitive.ObjectID)
+		return strings.Compare(at.Hex(), bt.Hex())
+	case float64:
+		bt, _ := b.(float64)
+		switch {
+		case at < bt:
+			return -1
+		case at > bt:
+			return 1
+		}
+		return 0
+	}
+	if b == nil {
+		return 1
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// applyFindOptions sorts, skips and limits the documents matched by a find
+func applyFindOptions(docs []dbModel, opts ...*options.FindOptions) []dbModel {
+	o := options.MergeFindOptions(opts...)
+	if sortDoc, ok := o.Sort.(bson.D); ok && len(sortDoc) > 0 {
+		sort.SliceStable(docs, func(i, j int) bool {
+			a, _ := docs[i].toDoc()
+			b, _ := docs[j].toDoc()
+			for _, e := range sortDoc {
+				c := compareValues(a.Map()[e.Key], b.Map()[e.Key])
+				if c != 0 {
+					return (c < 0) == (fmt.Sprint(e.Value) != "-1")
+				}
+			}
+			return false
+		})
+	}
+	if o.Skip != nil {
+		if *o.Skip >= int64(len(docs)) {
+			return nil
+		}
+		docs = docs[*o.Skip:]
+	}
+	if o.Limit != nil && *o.Limit > 0 && *o.Limit < int64(len(docs)) {
+		docs = docs[:*o.Limit]
+	}
+	return docs
+}
+
+// textTokens splits a text into lowercase words the way a text index does, trailing plural s are stemmed
+func textTokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			words[i] = strings.TrimSuffix(w, "s")
+		}
+	}
+	return words
+}
+
+// textScore approximates the text score of a document, every weighted field that holds a query term adds its weight
+// scaled by the share of the field's words that are query terms
+func textScore(doc dbModel, weights bson.D, terms []string) float64 {
+	data, err := doc.toDoc()
+	if err != nil {
+		return 0
+	}
+	var score float64
+	for _, w := range weights {
+		value, ok := data.Map()[w.Key].(string)
+		if !ok {
+			continue
+		}
+		words := textTokens(value)
+		matches := 0
+		for _, word := range words {
+			for _, term := range terms {
+				if word == term {
+					matches++
+					break
+				}
+			}
+		}
+		if matches > 0 {
+			weight, _ := w.Value.(int)
+			score += float64(weight) * (0.5 + 0.5*float64(matches)/float64(len(words)))
 		}
-		return t, nil
 	}
-	return bsonData, nil
+	return score
 }
 
 // standardizeID ensures that a dbModels unique identified is returned as a string
@@ -115,6 +434,134 @@ func bsonUnmarshall(colName string, bsonData interface{}) (data dbModel, err err
 		tm := taskModel{}
 		err = bson.Unmarshal(bData, &tm)
 		return &tm, nil
+	case "files":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		fm := fileModel{}
+		err = bson.Unmarshal(bData, &fm)
+		return &fm, nil
+	case "refresh_tokens":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		rm := refreshTokenModel{}
+		err = bson.Unmarshal(bData, &rm)
+		return &rm, nil
+	case "api_keys":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		km := apiKeyModel{}
+		err = bson.Unmarshal(bData, &km)
+		return &km, nil
+	case "user_tokens":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		tm := userTokenModel{}
+		err = bson.Unmarshal(bData, &tm)
+		return &tm, nil
+	case "login_attempts":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		am := loginAttemptModel{}
+		err = bson.Unmarshal(bData, &am)
+		return &am, nil
+	case "roles":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		rm := roleModel{}
+		err = bson.Unmarshal(bData, &rm)
+		return &rm, nil
+	case "memberships":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		mm := membershipModel{}
+		err = bson.Unmarshal(bData, &mm)
+		return &mm, nil
+	case "invitations":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		im := invitationModel{}
+		err = bson.Unmarshal(bData, &im)
+		return &im, nil
+	case "identities":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		im := identityModel{}
+		err = bson.Unmarshal(bData, &im)
+		return &im, nil
+	case "task_history":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		hm := taskHistoryModel{}
+		err = bson.Unmarshal(bData, &hm)
+		return &hm, nil
+	case "comments":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		cm := commentModel{}
+		err = bson.Unmarshal(bData, &cm)
+		return &cm, nil
+	case "task_series":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		sm := taskSeriesModel{}
+		err = bson.Unmarshal(bData, &sm)
+		return &sm, nil
+	case "feed_tokens":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		fm := feedTokenModel{}
+		err = bson.Unmarshal(bData, &fm)
+		return &fm, nil
+	case "audit_events":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		em := auditEventModel{}
+		err = bson.Unmarshal(bData, &em)
+		return &em, nil
+	case "webhooks":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		wm := webhookModel{}
+		err = bson.Unmarshal(bData, &wm)
+		return &wm, nil
+	case "webhook_deliveries":
+		bData, err := bsonMarshall(bsonData)
+		if err != nil {
+			return nil, err
+		}
+		dm := webhookDeliveryModel{}
+		err = bson.Unmarshal(bData, &dm)
+		return &dm, nil
 	}
 	return nil, errors.New("invalid test collection type")
 }
@@ -188,19 +635,25 @@ func getTestTasksModels() []*taskModel {
 	var gms []*taskModel
 	var gm *taskModel
 	gm, _ = newTaskModel(&models.Task{
-		Id:      "000000000000000000000022",
-		Name:    "Task1",
-		Due:     time.Now().UTC(),
-		UserId:  "000000000000000000000013",
-		GroupId: "000000000000000000000002",
+		Id:         "000000000000000000000022",
+		Name:       "Task1",
+		Priority:   models.HIGH,
+		Due:        time.Now().UTC(),
+		Labels:     []string{"backend", "api"},
+		Estimate:   3,
+		UserId:     "000000000000000000000013",
+		AssigneeId: "000000000000000000000012",
+		GroupId:    "000000000000000000000002",
 	})
 	gms = append(gms, gm)
 	gm, _ = newTaskModel(&models.Task{
-		Id:      "000000000000000000000023",
-		Name:    "Task2",
-		Due:     time.Now().UTC(),
-		UserId:  "000000000000000000000012",
-		GroupId: "000000000000000000000002",
+		Id:       "000000000000000000000023",
+		Name:     "Task2",
+		Due:      time.Now().UTC(),
+		Labels:   []string{"frontend"},
+		Estimate: 8,
+		UserId:   "000000000000000000000012",
+		GroupId:  "000000000000000000000002",
 	})
 	gms = append(gms, gm)
 	return gms
@@ -231,7 +684,7 @@ func initTestTaskService() *TaskService {
 	}
 	tg := getTestGroupModels(true)
 	for _, d := range tg {
-		_, err := gs.GroupCreate(d.toRoot())
+		_, err := gs.GroupCreate(context.Background(), d.toRoot())
 		if err != nil {
 			panic(err)
 		}
@@ -246,19 +699,23 @@ func initTestTaskService() *TaskService {
 	}
 	tu := getTestUsersModels(true)
 	for _, d := range tu {
-		_, err := us.UserCreate(d.toRoot())
+		_, err := us.UserCreate(context.Background(), d.toRoot())
 		if err != nil {
 			panic(err)
 		}
 	}
 	collection := db.GetCollection("tasks")
 	tHandler := db.NewTaskHandler()
+	thHandler := db.NewTaskHistoryHandler()
 	return &TaskService{
 		collection,
 		db,
 		tHandler,
 		uHandler,
 		gHandler,
+		thHandler,
+		db.NewMembershipHandler(),
+		NewTaskSeriesService(db, db.NewTaskSeriesHandler(), tHandler, gHandler),
 	}
 }
 
@@ -276,7 +733,7 @@ func setupTestTasks() *TaskService {
 	}
 	tg := getTestGroupModels(true)
 	for _, d := range tg {
-		_, err := gs.GroupCreate(d.toRoot())
+		_, err := gs.GroupCreate(context.Background(), d.toRoot())
 		if err != nil {
 			panic(err)
 		}
@@ -291,23 +748,27 @@ func setupTestTasks() *TaskService {
 	}
 	tu := getTestUsersModels(true)
 	for _, d := range tu {
-		_, err := us.UserCreate(d.toRoot())
+		_, err := us.UserCreate(context.Background(), d.toRoot())
 		if err != nil {
 			panic(err)
 		}
 	}
 	collection := db.GetCollection("tasks")
 	tHandler := db.NewTaskHandler()
+	thHandler := db.NewTaskHistoryHandler()
 	ts := &TaskService{
 		collection,
 		db,
 		tHandler,
 		uHandler,
 		gHandler,
+		thHandler,
+		db.NewMembershipHandler(),
+		NewTaskSeriesService(db, db.NewTaskSeriesHandler(), tHandler, gHandler),
 	}
 	td := getTestTasksModels()
 	for _, d := range td {
-		_, err := ts.TaskCreate(d.toRoot())
+		_, err := ts.TaskCreate(context.Background(), d.toRoot())
 		if err != nil {
 			panic(err)
 		}
@@ -315,6 +776,162 @@ func setupTestTasks() *TaskService {
 	return ts
 }
 
+/*
+================ testTaskSeriesUtils ==================
+*/
+
+// setupTestTaskSeries moves the due of Task1 to start, the current instance of every series set by the tests
+func setupTestTaskSeries(start time.Time) *TaskSeriesService {
+	ts := setupTestTasks()
+	taskId, _ := primitive.ObjectIDFromHex("000000000000000000000022")
+	if _, err := ts.taskHandler.UpdateOne(context.Background(), &taskModel{Id: taskId}, &taskModel{Due: start}); err != nil {
+		panic(err)
+	}
+	return ts.seriesService
+}
+
+/*
+================ testSearchUtils ==================
+*/
+
+// setupTestSearch searches the groups, users and tasks of setupTestTasks
+func setupTestSearch() *SearchService {
+	ts := setupTestTasks()
+	return NewSearchService(ts.db, ts.taskHandler, ts.userHandler, ts.groupHandler)
+}
+
+/*
+================ testFilesUtils ==================
+*/
+
+func getTestFilesModels() []*fileModel {
+	var fms []*fileModel
+	for _, f := range []*models.File{
+		{Id: "000000000000000000000051", OwnerId: "000000000000000000000022", OwnerType: "task", Name: "spec.pdf"},
+		{Id: "000000000000000000000052", OwnerId: "000000000000000000000022", OwnerType: "task", Name: "mockup.png"},
+		{Id: "000000000000000000000053", OwnerId: "000000000000000000000023", OwnerType: "task", Name: "notes.txt"},
+	} {
+		f.BucketType = "task-attachments"
+		f.FileType = "application/octet-stream"
+		_ = f.BuildBucketName()
+		fm, _ := newFileModel(f)
+		fms = append(fms, fm)
+	}
+	return fms
+}
+
+func setupTestFiles() *FileService {
+	ts := setupTestTasks()
+	fHandler := ts.db.NewFileHandler()
+	for _, fm := range getTestFilesModels() {
+		if _, err := fHandler.InsertOne(context.Background(), fm); err != nil {
+			panic(err)
+		}
+	}
+	return NewFileService(ts.db, fHandler, ts.userHandler, ts.groupHandler, ts.taskHandler)
+}
+
+/*
+================ testCascadeUtils ==================
+*/
+
+// failingTestCollection is a test collection whose writes fail, such as while the database is unreachable
+type failingTestCollection struct {
+	DBCollection
+}
+
+// InsertOne fails without inserting the document
+func (coll failingTestCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	return nil, errors.New("connection refused")
+}
+
+// UpdateMany fails without updating any document
+func (coll failingTestCollection) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return nil, errors.New("connection refused")
+}
+
+// flakyTestCollection is a test collection whose soft deletes and revokes start failing once succeed of them succeeded,
+// such as when the database becomes unreachable part way through a step, they succeed again once failures of them failed
+type flakyTestCollection struct {
+	DBCollection
+	succeed  int
+	failures int
+}
+
+// UpdateMany fails the soft deletes and revokes that are due to fail without updating any document
+func (coll *flakyTestCollection) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	if u, ok := update.(bson.D); ok && len(u) == 1 && u[0].Key == "$set" {
+		if set, ok := u[0].Value.(bson.D); ok && len(set) > 0 && (set[0].Key == "deleted_at" || set[0].Key == "revoked_at") {
+			if coll.succeed > 0 {
+				coll.succeed--
+			} else if coll.failures > 0 {
+				coll.failures--
+				return nil, errors.New("connection refused")
+			}
+		}
+	}
+	return coll.DBCollection.UpdateMany(ctx, filter, update, opts...)
+}
+
+// getTestCascadeAccessModels returns the memberships, api keys and refresh tokens of the users of setupTestFiles. The
+// root admin 11 is a member of group 2 and user 12 of group 3, the refresh token 64 of user 13 was revoked before
+func getTestCascadeAccessModels() ([]*membershipModel, []*apiKeyModel, []*refreshTokenModel) {
+	var mms []*membershipModel
+	for _, m := range []*models.Membership{
+		{Id: "000000000000000000000041", UserId: "000000000000000000000011", GroupId: "000000000000000000000002", Role: models.MemberRole},
+		{Id: "000000000000000000000042", UserId: "000000000000000000000012", GroupId: "000000000000000000000003", Role: models.MemberRole},
+	} {
+		mm, _ := newMembershipModel(m)
+		mms = append(mms, mm)
+	}
+	var kms []*apiKeyModel
+	for _, k := range []*models.APIKey{
+		{Id: "000000000000000000000044", Name: "ci", UserId: "000000000000000000000013", GroupId: "000000000000000000000002"},
+		{Id: "000000000000000000000045", Name: "member", UserId: "000000000000000000000011", GroupId: "000000000000000000000002"},
+		{Id: "000000000000000000000046", Name: "root", UserId: "000000000000000000000011", GroupId: "000000000000000000000001"},
+	} {
+		k.KeyHash = models.HashToken(k.Id)
+		km, _ := newAPIKeyModel(k)
+		kms = append(kms, km)
+	}
+	var rms []*refreshTokenModel
+	for _, rt := range []*models.RefreshToken{
+		{Id: "000000000000000000000061", UserId: "000000000000000000000012", GroupId: "000000000000000000000002"},
+		{Id: "000000000000000000000062", UserId: "000000000000000000000012", GroupId: "000000000000000000000003"},
+		{Id: "000000000000000000000063", UserId: "000000000000000000000011", GroupId: "000000000000000000000001"},
+		{Id: "000000000000000000000064", UserId: "000000000000000000000013", GroupId: "000000000000000000000002", RevokedAt: time.Now().UTC().Add(-time.Hour)},
+	} {
+		rt.FamilyId, rt.TokenHash, rt.ExpiresAt = rt.Id, models.HashToken(rt.Id), time.Now().UTC().Add(time.Hour)
+		rm, _ := newRefreshTokenModel(rt)
+		rms = append(rms, rm)
+	}
+	return mms, kms, rms
+}
+
+// setupTestCascade deletes the groups, users, tasks and task attachments of setupTestFiles, along with the memberships,
+// api keys and refresh tokens of getTestCascadeAccessModels
+func setupTestCascade() *CascadeService {
+	fs := setupTestFiles()
+	mHandler, kHandler, rtHandler := fs.db.NewMembershipHandler(), fs.db.NewAPIKeyHandler(), fs.db.NewRefreshTokenHandler()
+	mms, kms, rms := getTestCascadeAccessModels()
+	for _, mm := range mms {
+		if _, err := mHandler.InsertOne(context.Background(), mm); err != nil {
+			panic(err)
+		}
+	}
+	for _, km := range kms {
+		if _, err := kHandler.InsertOne(context.Background(), km); err != nil {
+			panic(err)
+		}
+	}
+	for _, rm := range rms {
+		if _, err := rtHandler.InsertOne(context.Background(), rm); err != nil {
+			panic(err)
+		}
+	}
+	return NewCascadeService(fs.db, fs.groupHandler, fs.userHandler, fs.taskHandler, fs.fileHandler, mHandler, kHandler, rtHandler)
+}
+
 /*
 ================ testBlacklistUtils ==================
 */
@@ -333,26 +950,282 @@ func initTestBlacklistService() *BlacklistService {
 	}
 }
 
-func setupTestBlacklists() *BlacklistService {
+func setupTestBlacklists() *BlacklistService {
+	os.Setenv("ENV", "test")
+	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
+	os.Setenv("DATABASE", "test")
+	db, _ := initializeNewTestClient()
+	collection := db.GetCollection("blacklists")
+	gHandler := db.NewBlacklistHandler()
+	gs := &BlacklistService{
+		collection,
+		db,
+		gHandler,
+	}
+	td := getTestTokens()
+	for _, d := range td {
+		err := gs.BlacklistAuthToken(context.Background(), d)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return gs
+}
+
+/*
+================ testRefreshTokensUtils ==================
+*/
+
+func initTestRefreshTokenService() *RefreshTokenService {
+	os.Setenv("ENV", "test")
+	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
+	os.Setenv("DATABASE", "test")
+	db, _ := initializeNewTestClient()
+	collection := db.GetCollection("refresh_tokens")
+	rHandler := db.NewRefreshTokenHandler()
+	return &RefreshTokenService{
+		collection,
+		db,
+		rHandler,
+	}
+}
+
+/*
+================ testAPIKeysUtils ==================
+*/
+
+func initTestAPIKeyService() *APIKeyService {
+	os.Setenv("ENV", "test")
+	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
+	os.Setenv("DATABASE", "test")
+	db, _ := initializeNewTestClient()
+	collection := db.GetCollection("api_keys")
+	kHandler := db.NewAPIKeyHandler()
+	return &APIKeyService{
+		collection,
+		db,
+		kHandler,
+	}
+}
+
+/*
+================ testFeedTokensUtils ==================
+*/
+
+func initTestFeedTokenService() *FeedTokenService {
+	os.Setenv("ENV", "test")
+	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
+	os.Setenv("DATABASE", "test")
+	db, _ := initializeNewTestClient()
+	collection := db.GetCollection("feed_tokens")
+	fHandler := db.NewFeedTokenHandler()
+	return &FeedTokenService{
+		collection,
+		db,
+		fHandler,
+	}
+}
+
+/*
+================ testAuditEventsUtils ==================
+*/
+
+func initTestAuditService() *AuditService {
+	os.Setenv("ENV", "test")
+	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
+	os.Setenv("DATABASE", "test")
+	db, _ := initializeNewTestClient()
+	collection := db.GetCollection("audit_events")
+	eHandler := db.NewAuditEventHandler()
+	return &AuditService{
+		collection,
+		db,
+		eHandler,
+	}
+}
+
+/*
+================ testWebhooksUtils ==================
+*/
+
+// setupTestWebhooks queues the events of the tasks of setupTestTasks for the webhooks of a WebhookService
+func setupTestWebhooks() (*WebhookService, *TaskService) {
+	ts := setupTestTasks()
+	ws := NewWebhookService(ts.db, ts.db.NewWebhookHandler(), ts.db.NewWebhookDeliveryHandler())
+	ts.db.Subscribe(func(e *models.Event) {
+		if _, err := ws.WebhookEnqueue(context.Background(), e); err != nil {
+			panic(err)
+		}
+	})
+	return ws, ts
+}
+
+/*
+================ testUserTokensUtils ==================
+*/
+
+func initTestUserTokenService() *UserTokenService {
+	os.Setenv("ENV", "test")
+	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
+	os.Setenv("DATABASE", "test")
+	db, _ := initializeNewTestClient()
+	collection := db.GetCollection("user_tokens")
+	tHandler := db.NewUserTokenHandler()
+	return &UserTokenService{
+		collection,
+		db,
+		tHandler,
+	}
+}
+
+/*
+================ testLoginAttemptsUtils ==================
+*/
+
+func initTestLoginAttemptService() *LoginAttemptService {
+	os.Setenv("ENV", "test")
+	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
+	os.Setenv("DATABASE", "test")
+	db, _ := initializeNewTestClient()
+	collection := db.GetCollection("login_attempts")
+	aHandler := db.NewLoginAttemptHandler()
+	return &LoginAttemptService{
+		collection,
+		db,
+		aHandler,
+	}
+}
+
+/*
+================ testRolesUtils ==================
+*/
+
+func initTestRoleService() *RoleService {
+	os.Setenv("ENV", "test")
+	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
+	os.Setenv("DATABASE", "test")
+	db, _ := initializeNewTestClient()
+	collection := db.GetCollection("roles")
+	rHandler := db.NewRoleHandler()
+	return &RoleService{
+		collection,
+		db,
+		rHandler,
+	}
+}
+
+/*
+================ testMembershipsUtils ==================
+*/
+
+func initTestMembershipService() *MembershipService {
+	os.Setenv("ENV", "test")
+	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
+	os.Setenv("DATABASE", "test")
+	db, _ := initializeNewTestClient()
+	collection := db.GetCollection("memberships")
+	mHandler := db.NewMembershipHandler()
+	return &MembershipService{
+		collection,
+		db,
+		mHandler,
+	}
+}
+
+/*
+================ testCommentsUtils ==================
+*/
+
+func getTestCommentUsersModels() []*userModel {
+	var ums []*userModel
+	um, _ := newUserModel(&models.User{
+		Id:       "000000000000000000000014",
+		Username: "jill",
+		Email:    "test4@email.com",
+		Password: "abc123",
+		GroupId:  "000000000000000000000002",
+		Role:     "member",
+	})
+	ums = append(ums, um)
+	um, _ = newUserModel(&models.User{
+		Id:       "000000000000000000000015",
+		Username: "bill",
+		Email:    "test5@email.com",
+		Password: "abc123",
+		GroupId:  "000000000000000000000001",
+		Role:     "member",
+	})
+	ums = append(ums, um)
+	um, _ = newUserModel(&models.User{
+		Id:       "000000000000000000000016",
+		Username: "outsider",
+		Email:    "test6@email.com",
+		Password: "abc123",
+		GroupId:  "000000000000000000000001",
+		Role:     "member",
+	})
+	ums = append(ums, um)
+	return ums
+}
+
+func setupTestComments() *CommentService {
+	ts := setupTestTasks()
+	for _, um := range getTestCommentUsersModels() {
+		if _, err := ts.userHandler.InsertOne(context.Background(), um); err != nil {
+			panic(err)
+		}
+	}
+	mHandler := ts.db.NewMembershipHandler()
+	mm, _ := newMembershipModel(&models.Membership{UserId: "000000000000000000000015", GroupId: "000000000000000000000002", Role: "member"})
+	if _, err := mHandler.InsertOne(context.Background(), mm); err != nil {
+		panic(err)
+	}
+	collection := ts.db.GetCollection("comments")
+	cHandler := ts.db.NewCommentHandler()
+	return &CommentService{
+		collection,
+		ts.db,
+		cHandler,
+		ts.taskHandler,
+		ts.userHandler,
+		mHandler,
+	}
+}
+
+/*
+================ testInvitationsUtils ==================
+*/
+
+func initTestInvitationService() *InvitationService {
+	os.Setenv("ENV", "test")
+	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
+	os.Setenv("DATABASE", "test")
+	db, _ := initializeNewTestClient()
+	collection := db.GetCollection("invitations")
+	iHandler := db.NewInvitationHandler()
+	return &InvitationService{
+		collection,
+		db,
+		iHandler,
+	}
+}
+
+/*
+================ testIdentitiesUtils ==================
+*/
+
+func initTestIdentityService() *IdentityService {
 	os.Setenv("ENV", "test")
 	os.Setenv("MONGO_URI", "mongodb+srv://in_mem")
 	os.Setenv("DATABASE", "test")
 	db, _ := initializeNewTestClient()
-	collection := db.GetCollection("blacklists")
-	gHandler := db.NewBlacklistHandler()
-	gs := &BlacklistService{
+	collection := db.GetCollection("identities")
+	iHandler := db.NewIdentityHandler()
+	return &IdentityService{
 		collection,
 		db,
-		gHandler,
-	}
-	td := getTestTokens()
-	for _, d := range td {
-		err := gs.BlacklistAuthToken(d)
-		if err != nil {
-			panic(err)
-		}
+		iHandler,
 	}
-	return gs
 }
 
 /*
@@ -387,7 +1260,7 @@ func setupTestGroups() *GroupService {
 	}
 	td := getTestGroupModels(false)
 	for _, d := range td {
-		_, err := gs.GroupCreate(d.toRoot())
+		_, err := gs.GroupCreate(context.Background(), d.toRoot())
 		if err != nil {
 			panic(err)
 		}
@@ -414,7 +1287,7 @@ func initTestUserService() *UserService {
 	}
 	td := getTestGroupModels(true)
 	for _, d := range td {
-		_, err := gs.GroupCreate(d.toRoot())
+		_, err := gs.GroupCreate(context.Background(), d.toRoot())
 		if err != nil {
 			panic(err)
 		}
@@ -444,7 +1317,7 @@ func setupTestUsers() *UserService {
 	}
 	tg := getTestGroupModels(true)
 	for _, d := range tg {
-		_, err := gs.GroupCreate(d.toRoot())
+		_, err := gs.GroupCreate(context.Background(), d.toRoot())
 		if err != nil {
 			panic(err)
 		}
@@ -459,7 +1332,7 @@ func setupTestUsers() *UserService {
 	}
 	tu := getTestUsersModels(true)
 	for _, d := range tu {
-		_, err := us.UserCreate(d.toRoot())
+		_, err := us.UserCreate(context.Background(), d.toRoot())
 		if err != nil {
 			panic(err)
 		}
@@ -630,8 +1503,29 @@ func (coll *testMongoCollection) deleteById(findId string) (reDoc dbModel, err e
 	return reDoc, nil
 }
 
+// unsetFields removes fields from a document in the test collection
+func (coll *testMongoCollection) unsetFields(doc dbModel, keys []string) (dbModel, error) {
+	bsonData, err := doc.toDoc()
+	if err != nil {
+		return doc, err
+	}
+	cleaned := bson.D{}
+	for _, e := range bsonData {
+		keep := true
+		for _, k := range keys {
+			if e.Key == k {
+				keep = false
+			}
+		}
+		if keep {
+			cleaned = append(cleaned, e)
+		}
+	}
+	return coll.unmarshallBSON(cleaned)
+}
+
 // updateById a document in the test collection
-func (coll *testMongoCollection) updateById(findId string, upDoc dbModel) (reDoc dbModel, err error) {
+func (coll *testMongoCollection) updateById(findId string, upDoc dbModel, unset []string) (reDoc dbModel, err error) {
 	var dbDocs []dbModel
 	up := false
 	for _, doc := range coll.docs {
@@ -652,6 +1546,12 @@ func (coll *testMongoCollection) updateById(findId string, upDoc dbModel) (reDoc
 			if err != nil {
 				return reDoc, err
 			}
+			if len(unset) > 0 {
+				reDoc, err = coll.unsetFields(reDoc, unset)
+				if err != nil {
+					return reDoc, err
+				}
+			}
 			up = true
 			dbDocs = append(dbDocs, reDoc)
 		}
@@ -664,8 +1564,11 @@ func (coll *testMongoCollection) updateById(findId string, upDoc dbModel) (reDoc
 }
 
 // find documents in the test collection
-func (coll *testMongoCollection) find(dbDoc dbModel) (reDocs []dbModel, err error) {
+func (coll *testMongoCollection) find(dbDoc dbModel, deletedClause bson.D) (reDocs []dbModel, err error) {
 	for _, doc := range coll.docs {
+		if !matchDeleted(doc, deletedClause) {
+			continue
+		}
 		if dbDoc == nil {
 			reDocs = append(reDocs, doc)
 		} else {
@@ -687,6 +1590,68 @@ func (coll *testMongoCollection) find(dbDoc dbModel) (reDocs []dbModel, err erro
 	return reDocs, nil
 }
 
+// findMatching returns the documents of the test collection that satisfy every clause of a bson filter
+func (coll *testMongoCollection) findMatching(filter interface{}) (reDocs []dbModel, err error) {
+	filter, deletedClause := splitDeletedFilter(filter)
+	f, ok := filter.(bson.D)
+	if !ok {
+		filterDoc, err := coll.unmarshallBSON(filter)
+		if err != nil {
+			return nil, err
+		}
+		return coll.find(filterDoc, deletedClause)
+	}
+	f, cursorClause := splitCursorFilter(f)
+	for _, doc := range coll.docs {
+		if matchDeleted(doc, deletedClause) && matchCursor(doc, cursorClause) && matchFields(doc, f) {
+			reDocs = append(reDocs, doc)
+		}
+	}
+	return reDocs, nil
+}
+
+// textSearch ranks the documents of the test collection that satisfy a filter by their text score against a query,
+// in place of the text index of a mongo collection
+func (coll *testMongoCollection) textSearch(filter bson.D, query string, limit int64) (docs []dbModel, scores []float64, err error) {
+	weights, ok := textIndexes[coll.name]
+	if !ok {
+		return nil, nil, errors.New("text index required for $text query")
+	}
+	var f bson.D
+	for _, e := range filter {
+		if e.Key != "$text" {
+			f = append(f, e)
+		}
+	}
+	reDocs, err := coll.findMatching(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	terms := textTokens(query)
+	for _, doc := range reDocs {
+		if score := textScore(doc, weights, terms); score > 0 {
+			docs = append(docs, doc)
+			scores = append(scores, score)
+		}
+	}
+	order := make([]int, len(docs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	sortedDocs := make([]dbModel, len(docs))
+	sortedScores := make([]float64, len(docs))
+	for i, o := range order {
+		sortedDocs[i], sortedScores[i] = docs[o], scores[o]
+	}
+	if limit > 0 && limit < int64(len(sortedDocs)) {
+		sortedDocs, sortedScores = sortedDocs[:limit], sortedScores[:limit]
+	}
+	return sortedDocs, sortedScores, nil
+}
+
 // insert documents into test collection
 func (coll *testMongoCollection) insert(dbDocs []dbModel) (err error) {
 	var valDocs []dbModel
@@ -725,6 +1690,9 @@ func (coll *testMongoCollection) delete(dbDocs []dbModel) (reDocs []dbModel, err
 // InsertOne into test collection
 func (coll *testMongoCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
 	coll.ctx = ctx
+	if err := ctx.Err(); err != nil { // like the driver, operations fail once their context is done
+		return nil, err
+	}
 	fmt.Println("\n--->INSERT ONE: ", document, opts)
 	doc := document.(dbModel)
 	err := coll.insert([]dbModel{doc})
@@ -734,6 +1702,9 @@ func (coll *testMongoCollection) InsertOne(ctx context.Context, document interfa
 // InsertMany into test collection
 func (coll *testMongoCollection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
 	coll.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if len(documents) == 0 {
 		return nil, mongo.ErrEmptySlice
 	}
@@ -758,12 +1729,20 @@ func (coll *testMongoCollection) InsertMany(ctx context.Context, documents []int
 func (coll *testMongoCollection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
 	var delCount int64
 	coll.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	fmt.Println("\n--->DELETE MANY: ", filter, opts)
+	filter, deletedClause := splitDeletedFilter(filter)
 	filterDoc, err := coll.unmarshallBSON(filter)
 	if err != nil {
 		return nil, err
 	}
-	delDocs, err := coll.delete([]dbModel{filterDoc})
+	matchDocs, err := coll.find(filterDoc, deletedClause)
+	if err != nil {
+		return nil, err
+	}
+	delDocs, err := coll.delete(matchDocs)
 	delCount = int64(len(delDocs))
 	return &mongo.DeleteResult{DeletedCount: delCount}, nil
 }
@@ -772,6 +1751,9 @@ func (coll *testMongoCollection) DeleteMany(ctx context.Context, filter interfac
 func (coll *testMongoCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
 	var delCount int64
 	coll.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	fmt.Println("\n--->DELETE ONE: ", filter, opts)
 	filterDoc, err := coll.unmarshallBSON(filter)
 	if err != nil {
@@ -786,6 +1768,9 @@ func (coll *testMongoCollection) DeleteOne(ctx context.Context, filter interface
 func (coll *testMongoCollection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
 	var rawResult []byte
 	coll.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
 	fmt.Println("\n--->FIND ONE AND DELETE: ", filter, opts)
 	filterDoc, err := coll.unmarshallBSON(filter)
 	if err == nil {
@@ -802,10 +1787,103 @@ func (coll *testMongoCollection) FindOneAndDelete(ctx context.Context, filter in
 	return res
 }
 
+// FindOneAndUpdate applies the $inc, $set and $setOnInsert operators of an update to the first document of the test
+// collection matching a filter, when none matches and the upsert option is set a document is built from the filter
+func (coll *testMongoCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	coll.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	fmt.Println("\n--->FIND ONE AND UPDATE: ", filter, update, opts)
+	upsert, after := false, false
+	for _, o := range opts {
+		if o.Upsert != nil {
+			upsert = *o.Upsert
+		}
+		if o.ReturnDocument != nil {
+			after = *o.ReturnDocument == options.After
+		}
+	}
+	matchDocs, err := coll.findMatching(filter)
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	var before bson.D
+	doc := bson.D{}
+	if len(matchDocs) > 0 {
+		if before, err = matchDocs[0].toDoc(); err != nil {
+			return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+		}
+		doc = append(doc, before...)
+	} else if upsert {
+		f, _ := splitDeletedFilter(filter)
+		for _, e := range f.(bson.D) {
+			if _, isOp := e.Value.(bson.D); !isOp {
+				doc = append(doc, e)
+			}
+		}
+		doc = setDocField(doc, "_id", primitive.NewObjectID())
+	} else {
+		return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+	}
+	for _, op := range update.(bson.D) {
+		for _, e := range op.Value.(bson.D) {
+			switch op.Key {
+			case "$inc":
+				doc = setDocField(doc, e.Key, incrementValue(doc.Map()[e.Key], e.Value))
+			case "$set":
+				doc = setDocField(doc, e.Key, e.Value)
+			case "$setOnInsert":
+				if before == nil {
+					doc = setDocField(doc, e.Key, e.Value)
+				}
+			}
+		}
+	}
+	reDoc, err := coll.unmarshallBSON(doc)
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	if before == nil {
+		err = coll.insert([]dbModel{reDoc})
+	} else {
+		err = coll.replace(reDoc)
+	}
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	if !after {
+		if before == nil {
+			return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+		}
+		return mongo.NewSingleResultFromDocument(before, nil, nil)
+	}
+	return mongo.NewSingleResultFromDocument(doc, nil, nil)
+}
+
+// replace a document of the test collection with a new version of it that has the same ID
+func (coll *testMongoCollection) replace(dbDoc dbModel) error {
+	docId, err := standardizeID(dbDoc)
+	if err != nil {
+		return err
+	}
+	for i, doc := range coll.docs {
+		if curId, _ := standardizeID(doc); curId == docId {
+			coll.docs[i] = dbDoc
+			return nil
+		}
+	}
+	return errors.New("document not found in test collection: " + docId)
+}
+
 // UpdateOne a document in the test collection
 func (coll *testMongoCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
 	coll.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	fmt.Println("\n--->UPDATE ONE: ", filter, update, opts)
+	filter, deletedClause := splitDeletedFilter(filter)
 	filterDoc, err := coll.unmarshallBSON(filter)
 	if err != nil {
 		return nil, err
@@ -814,7 +1892,14 @@ func (coll *testMongoCollection) UpdateOne(ctx context.Context, filter interface
 	if err != nil {
 		return nil, err
 	}
-	update, err = cleanUpdateBSON(update)
+	curDoc, err := coll.findById(docId)
+	if err != nil {
+		return nil, err
+	}
+	if !matchDeleted(curDoc, deletedClause) {
+		return nil, errors.New("document not found in test collection: " + docId)
+	}
+	update, unset, err := cleanUpdateBSON(update)
 	if err != nil {
 		panic(err)
 		return nil, err
@@ -823,39 +1908,66 @@ func (coll *testMongoCollection) UpdateOne(ctx context.Context, filter interface
 	if err != nil {
 		return nil, err
 	}
-	reDoc, err := coll.updateById(docId, updateDoc)
+	reDoc, err := coll.updateById(docId, updateDoc, unset)
 	return &mongo.UpdateResult{UpsertedID: reDoc.getID()}, err
 }
 
-// UpdateByID a document using an ID as the filter
-func (coll *testMongoCollection) UpdateByID(ctx context.Context, id interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
-	fmt.Println("\n--->UPDATE BY ID: ", id, update, opts)
-	if id == nil {
-		return nil, mongo.ErrNilValue
+// UpdateMany documents in the test collection
+func (coll *testMongoCollection) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	var upCount int64
+	coll.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	update, err := cleanUpdateBSON(update)
+	fmt.Println("\n--->UPDATE MANY: ", filter, update, opts)
+	matchDocs, err := coll.findMatching(filter)
+	if err != nil {
+		return nil, err
+	}
+	update, unset, err := cleanUpdateBSON(update)
 	if err != nil {
-		panic(err)
 		return nil, err
 	}
 	updateDoc, err := coll.unmarshallBSON(update)
 	if err != nil {
 		return nil, err
 	}
-	return coll.UpdateOne(ctx, bson.D{{"_id", id}}, updateDoc, opts...)
+	for _, doc := range matchDocs {
+		docId, err := standardizeID(doc)
+		if err != nil {
+			return nil, err
+		}
+		_, err = coll.updateById(docId, updateDoc, unset)
+		if err != nil {
+			return nil, err
+		}
+		upCount++
+	}
+	return &mongo.UpdateResult{MatchedCount: upCount, ModifiedCount: upCount}, nil
+}
+
+// UpdateByID a document using an ID as the filter
+func (coll *testMongoCollection) UpdateByID(ctx context.Context, id interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	fmt.Println("\n--->UPDATE BY ID: ", id, update, opts)
+	if id == nil {
+		return nil, mongo.ErrNilValue
+	}
+	return coll.UpdateOne(ctx, bson.D{{"_id", id}}, update, opts...)
 }
 
 // Find returns a collection of documents
 func (coll *testMongoCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (cur *mongo.Cursor, err error) {
 	var rawResults []byte
 	coll.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	fmt.Println("\n--->FIND: ", filter, opts)
-	filterDoc, err := coll.unmarshallBSON(filter)
+	reDocs, err := coll.findMatching(filter)
 	if err != nil {
 		return nil, err
 	}
-	reDocs, err := coll.find(filterDoc)
-	cd := initTestCursorData(reDocs)
+	cd := initTestCursorData(applyFindOptions(reDocs, opts...))
 	bsonData, err := cd.toDoc()
 	if err != nil {
 		panic(err)
@@ -869,10 +1981,14 @@ func (coll *testMongoCollection) Find(ctx context.Context, filter interface{}, o
 func (coll *testMongoCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
 	var rawResult []byte
 	coll.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
 	fmt.Println("\n--->FIND ONE: ", filter, opts)
+	filter, deletedClause := splitDeletedFilter(filter)
 	filterDoc, err := coll.unmarshallBSON(filter)
 	if err == nil {
-		reDocs, err := coll.find(filterDoc)
+		reDocs, err := coll.find(filterDoc, deletedClause)
 		if err == nil && len(reDocs) > 0 {
 			rawBson, err := reDocs[0].toDoc()
 			if err == nil {
@@ -884,8 +2000,7 @@ func (coll *testMongoCollection) FindOne(ctx context.Context, filter interface{}
 		}
 	}
 	if len(rawResult) == 0 {
-		err = errors.New("document not found")
-		return mongo.NewSingleResultFromDocument(nil, err, nil)
+		return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
 	}
 	doc, _ := bsonx.ReadDoc(rawResult)
 	return mongo.NewSingleResultFromDocument(doc, err, nil)
@@ -895,12 +2010,11 @@ func (coll *testMongoCollection) FindOne(ctx context.Context, filter interface{}
 func (coll *testMongoCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
 	var c int64
 	coll.ctx = ctx
-	fmt.Println("\n--->COUNT DOCUMENTS: ", filter, opts)
-	filterDoc, err := coll.unmarshallBSON(filter)
-	if err != nil {
-		return c, err
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
-	reDocs, err := coll.find(filterDoc)
+	fmt.Println("\n--->COUNT DOCUMENTS: ", filter, opts)
+	reDocs, err := coll.findMatching(filter)
 	if err != nil {
 		panic(err)
 	}
@@ -949,6 +2063,102 @@ func newTestMongoDatabase(databaseName string) (*testMongoDatabase, error) {
 		return &testMongoDatabase{}, err
 	}
 	testsColls = append(testsColls, testTasksCollection)
+	testFilesCollection, err := newTestMongoCollection("files")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT FILE ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testFilesCollection)
+	testRefreshTokensCollection, err := newTestMongoCollection("refresh_tokens")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT REFRESH TOKEN ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testRefreshTokensCollection)
+	testAPIKeysCollection, err := newTestMongoCollection("api_keys")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT API KEY ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testAPIKeysCollection)
+	testUserTokensCollection, err := newTestMongoCollection("user_tokens")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT USER TOKEN ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testUserTokensCollection)
+	testLoginAttemptsCollection, err := newTestMongoCollection("login_attempts")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT LOGIN ATTEMPT ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testLoginAttemptsCollection)
+	testRolesCollection, err := newTestMongoCollection("roles")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT ROLE ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testRolesCollection)
+	testMembershipsCollection, err := newTestMongoCollection("memberships")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT MEMBERSHIP ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testMembershipsCollection)
+	testInvitationsCollection, err := newTestMongoCollection("invitations")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT INVITATION ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testInvitationsCollection)
+	testIdentitiesCollection, err := newTestMongoCollection("identities")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT IDENTITY ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testIdentitiesCollection)
+	testTaskHistoryCollection, err := newTestMongoCollection("task_history")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT TASK HISTORY ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testTaskHistoryCollection)
+	testCommentsCollection, err := newTestMongoCollection("comments")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT COMMENT ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testCommentsCollection)
+	testTaskSeriesCollection, err := newTestMongoCollection("task_series")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT TASK SERIES ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testTaskSeriesCollection)
+	testFeedTokensCollection, err := newTestMongoCollection("feed_tokens")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT FEED TOKEN ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testFeedTokensCollection)
+	testAuditEventsCollection, err := newTestMongoCollection("audit_events")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT AUDIT EVENT ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testAuditEventsCollection)
+	testWebhooksCollection, err := newTestMongoCollection("webhooks")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT WEBHOOK ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testWebhooksCollection)
+	testWebhookDeliveriesCollection, err := newTestMongoCollection("webhook_deliveries")
+	if err != nil {
+		fmt.Println("\nCOLLECTION INIT WEBHOOK DELIVERY ERROR: ", err.Error())
+		return &testMongoDatabase{}, err
+	}
+	testsColls = append(testsColls, testWebhookDeliveriesCollection)
 	return &testMongoDatabase{
 		name:            databaseName,
 		testCollections: testsColls,
@@ -1035,6 +2245,7 @@ func (c *testMongoClient) Ping(ctx context.Context, rp *readpref.ReadPref) error
 
 // testDBClient manages a database connection
 type testDBClient struct {
+	eventBus
 	connectionURI string
 	client        *testMongoClient
 }
@@ -1132,3 +2343,143 @@ func (db *testDBClient) NewFileHandler() *DBHandler[*fileModel] {
 		collection: col,
 	}
 }
+
+// NewRefreshTokenHandler returns a new DBHandler refresh tokens interface
+func (db *testDBClient) NewRefreshTokenHandler() *DBHandler[*refreshTokenModel] {
+	col := db.GetCollection("refresh_tokens")
+	return &DBHandler[*refreshTokenModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewAPIKeyHandler returns a new DBHandler api keys interface
+func (db *testDBClient) NewAPIKeyHandler() *DBHandler[*apiKeyModel] {
+	col := db.GetCollection("api_keys")
+	return &DBHandler[*apiKeyModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewUserTokenHandler returns a new DBHandler user tokens interface
+func (db *testDBClient) NewUserTokenHandler() *DBHandler[*userTokenModel] {
+	col := db.GetCollection("user_tokens")
+	return &DBHandler[*userTokenModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewLoginAttemptHandler returns a new DBHandler login attempts interface
+func (db *testDBClient) NewLoginAttemptHandler() *DBHandler[*loginAttemptModel] {
+	col := db.GetCollection("login_attempts")
+	return &DBHandler[*loginAttemptModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewRoleHandler returns a new DBHandler roles interface
+func (db *testDBClient) NewRoleHandler() *DBHandler[*roleModel] {
+	col := db.GetCollection("roles")
+	return &DBHandler[*roleModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewMembershipHandler returns a new DBHandler memberships interface
+func (db *testDBClient) NewMembershipHandler() *DBHandler[*membershipModel] {
+	col := db.GetCollection("memberships")
+	return &DBHandler[*membershipModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewInvitationHandler returns a new DBHandler invitations interface
+func (db *testDBClient) NewInvitationHandler() *DBHandler[*invitationModel] {
+	col := db.GetCollection("invitations")
+	return &DBHandler[*invitationModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewIdentityHandler returns a new DBHandler identities interface
+func (db *testDBClient) NewIdentityHandler() *DBHandler[*identityModel] {
+	col := db.GetCollection("identities")
+	return &DBHandler[*identityModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewTaskHistoryHandler returns a new DBHandler task history interface
+func (db *testDBClient) NewTaskHistoryHandler() *DBHandler[*taskHistoryModel] {
+	col := db.GetCollection("task_history")
+	return &DBHandler[*taskHistoryModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewCommentHandler returns a new DBHandler comments interface
+func (db *testDBClient) NewCommentHandler() *DBHandler[*commentModel] {
+	col := db.GetCollection("comments")
+	return &DBHandler[*commentModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewTaskSeriesHandler returns a new DBHandler task series interface
+func (db *testDBClient) NewTaskSeriesHandler() *DBHandler[*taskSeriesModel] {
+	col := db.GetCollection("task_series")
+	return &DBHandler[*taskSeriesModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewFeedTokenHandler returns a new DBHandler feed tokens interface
+func (db *testDBClient) NewFeedTokenHandler() *DBHandler[*feedTokenModel] {
+	col := db.GetCollection("feed_tokens")
+	return &DBHandler[*feedTokenModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewAuditEventHandler returns a new DBHandler audit events interface
+func (db *testDBClient) NewAuditEventHandler() *DBHandler[*auditEventModel] {
+	col := db.GetCollection("audit_events")
+	return &DBHandler[*auditEventModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewWebhookHandler returns a new DBHandler webhooks interface
+func (db *testDBClient) NewWebhookHandler() *DBHandler[*webhookModel] {
+	col := db.GetCollection("webhooks")
+	return &DBHandler[*webhookModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// NewWebhookDeliveryHandler returns a new DBHandler webhook deliveries interface
+func (db *testDBClient) NewWebhookDeliveryHandler() *DBHandler[*webhookDeliveryModel] {
+	col := db.GetCollection("webhook_deliveries")
+	return &DBHandler[*webhookDeliveryModel]{
+		db:         db,
+		collection: col,
+	}
+}
+
+// WithTransaction returns errTransactionsUnsupported, the test database behaves as a standalone server
+func (db *testDBClient) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return errTransactionsUnsupported
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"sync"
 )
 
@@ -24,7 +25,7 @@ type dbRoutine[T dbModel] struct {
 }
 
 // execute a DB Routine by inputting a RoutineType, filter, and data
-func (p *dbRoutine[T]) execute(rt routineType, tCh chan T, eCh chan error, f T, d T) {
+func (p *dbRoutine[T]) execute(ctx context.Context, rt routineType, tCh chan T, eCh chan error, f T, d T) {
 	p.rType = rt
 	p.filter = f
 	p.data = d
@@ -32,13 +33,13 @@ func (p *dbRoutine[T]) execute(rt routineType, tCh chan T, eCh chan error, f T,
 	var err error
 	switch p.rType {
 	case FindOne:
-		resp, err = p.handler.FindOne(p.filter)
+		resp, err = p.handler.FindOne(ctx, p.filter)
 	case UpdateOne:
-		resp, err = p.handler.UpdateOne(p.filter, p.data)
+		resp, err = p.handler.UpdateOne(ctx, p.filter, p.data)
 	case InsertOne:
-		resp, err = p.handler.InsertOne(p.data)
+		resp, err = p.handler.InsertOne(ctx, p.data)
 	case DeleteOne:
-		resp, err = p.handler.DeleteOne(p.filter)
+		resp, err = p.handler.DeleteOne(ctx, p.filter)
 	}
 	eCh <- err
 	tCh <- resp
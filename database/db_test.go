@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+	"time"
+)
+
+func Test_OperationTimeout(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name string        // The name of the test
+		op   dbOperation   // The kind of operation
+		env  string        // The configured timeout of the operation
+		want time.Duration // The wanted timeout
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"configured", readOperation, "5s", 5 * time.Second},
+		{"default", writeOperation, "", 10 * time.Second},
+		{"invalid", bulkOperation, "soon", 30 * time.Second},
+		{"negative", readOperation, "-1s", 30 * time.Second},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(operationTimeouts[tt.op].env, tt.env)
+			if got := operationTimeout(tt.op); got != tt.want {
+				t.Errorf("operationTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_DBHandlerContext(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string          // The name of the test
+		ctx     context.Context // The context of the request
+		wantErr error           // The wanted error
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"active", context.Background(), nil},
+		{"cancelled", cancelled, context.Canceled},
+		{"deadline passed", expired, context.DeadlineExceeded},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestGroups()
+			group := &models.Group{Id: "000000000000000000000002"}
+			if _, err := testService.GroupFind(tt.ctx, group); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GroupService.GroupFind() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, err := testService.GroupDelete(tt.ctx, group); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GroupService.GroupDelete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			// The group is only deleted when the context was still active
+			_, err := testService.GroupFind(context.Background(), group)
+			if deleted := err != nil; deleted != (tt.wantErr == nil) {
+				t.Errorf("GroupService.GroupDelete() deleted = %v, want %v", deleted, tt.wantErr == nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"log"
+	"sync"
+)
+
+// EventSubscriber receives the Events emitted by the services of a DBClient
+type EventSubscriber func(e *models.Event)
+
+// eventBus publishes the Events emitted by the services of a DBClient to its subscribers
+type eventBus struct {
+	mu          sync.RWMutex
+	subscribers []EventSubscriber
+}
+
+// Subscribe adds a subscriber that receives every Event emitted after it subscribed
+func (b *eventBus) Subscribe(s EventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, s)
+}
+
+// Emit publishes an Event to every subscriber, in the order they subscribed
+func (b *eventBus) Emit(e *models.Event) {
+	b.mu.RLock()
+	subscribers := append([]EventSubscriber(nil), b.subscribers...)
+	b.mu.RUnlock()
+	for _, s := range subscribers {
+		s(e)
+	}
+}
+
+// emit publishes an Event of a record of a group on the event bus of a DBClient, previous is the record before an
+// update. An event that cannot be encoded is logged and dropped so it never fails the change it reports
+func emit(db DBClient, eventType string, groupId string, data interface{}, previous interface{}) {
+	e, err := models.NewEvent(eventType, groupId, data, previous)
+	if err != nil {
+		log.Println("event error:", err)
+		return
+	}
+	db.Emit(e)
+}
+
+// emitUser publishes an Event of a User, without its password
+func emitUser(db DBClient, eventType string, u *models.User, previous *models.User) {
+	data := *u
+	data.Password = ""
+	if previous == nil {
+		emit(db, eventType, u.GroupId, &data, nil)
+		return
+	}
+	prev := *previous
+	prev.Password = ""
+	emit(db, eventType, u.GroupId, &data, &prev)
+}
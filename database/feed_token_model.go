@@ -0,0 +1,188 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type feedTokenModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	Name         string             `bson:"name,omitempty"`
+	TokenHash    string             `bson:"token_hash,omitempty"`
+	UserId       primitive.ObjectID `bson:"user_id,omitempty"`
+	FeedType     string             `bson:"feed_type,omitempty"`
+	FeedId       primitive.ObjectID `bson:"feed_id,omitempty"`
+	LastUsedAt   time.Time          `bson:"last_used_at,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newFeedTokenModel initializes a new pointer to a feedTokenModel struct from a pointer to a JSON FeedToken struct
+func newFeedTokenModel(f *models.FeedToken) (fm *feedTokenModel, err error) {
+	fm = &feedTokenModel{
+		Name:         f.Name,
+		TokenHash:    f.TokenHash,
+		FeedType:     f.FeedType,
+		LastUsedAt:   f.LastUsedAt,
+		LastModified: f.LastModified,
+		CreatedAt:    f.CreatedAt,
+		DeletedAt:    f.DeletedAt,
+	}
+	if f.Id != "" && f.Id != "000000000000000000000000" {
+		fm.Id, err = primitive.ObjectIDFromHex(f.Id)
+	}
+	if f.UserId != "" && f.UserId != "000000000000000000000000" {
+		fm.UserId, err = primitive.ObjectIDFromHex(f.UserId)
+	}
+	if f.FeedId != "" && f.FeedId != "000000000000000000000000" {
+		fm.FeedId, err = primitive.ObjectIDFromHex(f.FeedId)
+	}
+	return
+}
+
+// update the feedTokenModel using an overwrite bson doc
+func (f *feedTokenModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	fm := feedTokenModel{}
+	err = bson.Unmarshal(data, &fm)
+	if len(fm.Name) > 0 {
+		f.Name = fm.Name
+	}
+	if len(fm.TokenHash) > 0 {
+		f.TokenHash = fm.TokenHash
+	}
+	if !fm.LastUsedAt.IsZero() {
+		f.LastUsedAt = fm.LastUsedAt
+	}
+	if !fm.LastModified.IsZero() {
+		f.LastModified = fm.LastModified
+	}
+	if !fm.DeletedAt.IsZero() {
+		f.DeletedAt = fm.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the feedTokenModel
+func (f *feedTokenModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, f)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the feedTokenModel
+func (f *feedTokenModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	fm := feedTokenModel{}
+	err = bson.Unmarshal(data, &fm)
+	if fm.Id.Hex() != "" && fm.Id.Hex() != "000000000000000000000000" {
+		return f.Id == fm.Id
+	}
+	if fm.TokenHash != "" {
+		return f.TokenHash == fm.TokenHash
+	}
+	if fm.UserId.Hex() != "" && fm.UserId.Hex() != "000000000000000000000000" {
+		return f.UserId == fm.UserId
+	}
+	if fm.FeedId.Hex() != "" && fm.FeedId.Hex() != "000000000000000000000000" {
+		return f.FeedId == fm.FeedId
+	}
+	return false
+}
+
+// getID returns the unique identifier of the feedTokenModel
+func (f *feedTokenModel) getID() (id interface{}) {
+	return f.Id
+}
+
+// getDeletedAt returns the time the feedTokenModel was revoked at
+func (f *feedTokenModel) getDeletedAt() time.Time {
+	return f.DeletedAt
+}
+
+// addTimeStamps updates a feedTokenModel struct with a timestamp
+func (f *feedTokenModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	f.LastModified = currentTime
+	if newRecord {
+		f.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a feedTokenModel has a value assigned for Id, if no value a new one is generated and assigned
+func (f *feedTokenModel) addObjectID() {
+	if f.Id.Hex() == "" || f.Id.Hex() == "000000000000000000000000" {
+		f.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a feedTokenModel struct postProcess to do things such as validating required fields
+func (f *feedTokenModel) postProcess() (err error) {
+	if f.TokenHash == "" || f.FeedId.IsZero() {
+		err = errors.New("feed token record does not have a TokenHash and FeedId")
+	}
+	return
+}
+
+// toDoc converts the bson feedTokenModel into a bson.D
+func (f *feedTokenModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(f)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the feedTokenModel data
+func (f *feedTokenModel) bsonFilter() (doc bson.D, err error) {
+	if f.Id.Hex() != "" && f.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", f.Id}}
+	} else if f.TokenHash != "" {
+		doc = bson.D{{"token_hash", f.TokenHash}}
+	} else if f.UserId.Hex() != "" && f.UserId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"user_id", f.UserId}}
+	} else if f.FeedId.Hex() != "" && f.FeedId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"feed_id", f.FeedId}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the feedTokenModel data
+func (f *feedTokenModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := f.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a FeedToken JSON struct from a pointer to a BSON feedTokenModel
+func (f *feedTokenModel) toRoot() *models.FeedToken {
+	return &models.FeedToken{
+		Id:           f.Id.Hex(),
+		Name:         f.Name,
+		TokenHash:    f.TokenHash,
+		UserId:       f.UserId.Hex(),
+		FeedType:     f.FeedType,
+		FeedId:       f.FeedId.Hex(),
+		LastUsedAt:   f.LastUsedAt,
+		LastModified: f.LastModified,
+		CreatedAt:    f.CreatedAt,
+		DeletedAt:    f.DeletedAt,
+	}
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
+)
+
+// FeedTokenService is used by the app to manage all feed token related controllers and functionality
+type FeedTokenService struct {
+	collection DBCollection
+	db         DBClient
+	handler    *DBHandler[*feedTokenModel]
+}
+
+// NewFeedTokenService is an exported function used to initialize a new FeedTokenService struct
+func NewFeedTokenService(db DBClient, handler *DBHandler[*feedTokenModel]) *FeedTokenService {
+	collection := db.GetCollection("feed_tokens")
+	return &FeedTokenService{collection, db, handler}
+}
+
+// FeedTokenCreate is used to issue a new feed token, the returned FeedToken is the only one to carry the raw Token
+func (p *FeedTokenService) FeedTokenCreate(ctx context.Context, f *models.FeedToken) (*models.FeedToken, error) {
+	err := f.Validate("create")
+	if err != nil {
+		return nil, err
+	}
+	err = f.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	fm, err := newFeedTokenModel(f)
+	if err != nil {
+		return nil, err
+	}
+	fm, err = p.handler.InsertOne(ctx, fm)
+	if err != nil {
+		return nil, err
+	}
+	created := fm.toRoot()
+	created.Token = f.Token
+	return created, nil
+}
+
+// FeedTokensFind is used to find all of the active feed tokens matching the input FeedToken
+func (p *FeedTokenService) FeedTokensFind(ctx context.Context, f *models.FeedToken) ([]*models.FeedToken, error) {
+	var tokens []*models.FeedToken
+	fm, err := newFeedTokenModel(f)
+	if err != nil {
+		return tokens, err
+	}
+	fms, err := p.handler.FindMany(ctx, fm)
+	if err != nil {
+		return tokens, err
+	}
+	for _, m := range fms {
+		tokens = append(tokens, m.toRoot())
+	}
+	return tokens, nil
+}
+
+// FeedTokenDelete is used to revoke a feed token, the token's user id is checked when one is specified
+func (p *FeedTokenService) FeedTokenDelete(ctx context.Context, f *models.FeedToken) (*models.FeedToken, error) {
+	fm, err := newFeedTokenModel(f)
+	if err != nil {
+		return nil, err
+	}
+	found, err := p.handler.FindOne(ctx, &feedTokenModel{Id: fm.Id})
+	if err != nil {
+		return nil, errors.New("feed token not found")
+	}
+	if f.CheckID("user_id") && found.UserId != fm.UserId {
+		return nil, errors.New("feed token not found")
+	}
+	fm, err = p.handler.DeleteOne(ctx, &feedTokenModel{Id: found.Id})
+	if err != nil {
+		return nil, err
+	}
+	return fm.toRoot(), nil
+}
+
+// FeedTokenAuthenticate looks up an active feed token by its raw token for the feed of a feedType and feedId, and
+// records that it was used
+func (p *FeedTokenService) FeedTokenAuthenticate(ctx context.Context, token string, feedType string, feedId string) (*models.FeedToken, error) {
+	fm, err := p.handler.FindOne(ctx, &feedTokenModel{TokenHash: models.HashToken(token)})
+	if err != nil {
+		return nil, errors.New("invalid feed token")
+	}
+	if fm.FeedType != feedType || fm.FeedId.Hex() != feedId {
+		return nil, errors.New("invalid feed token")
+	}
+	fm.LastUsedAt = time.Now().UTC()
+	fm, err = p.handler.UpdateOne(ctx, &feedTokenModel{Id: fm.Id}, fm)
+	if err != nil {
+		return nil, err
+	}
+	return fm.toRoot(), nil
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+)
+
+func Test_FeedTokenCreate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string            // The name of the test
+		wantErr bool              // whether we want an error.
+		feed    *models.FeedToken // The input of the test
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"user feed",
+			false,
+			&models.FeedToken{Name: "phone", UserId: "000000000000000000000012", FeedType: models.UserFeed, FeedId: "000000000000000000000012"},
+		},
+		{
+			"group feed",
+			false,
+			&models.FeedToken{Name: "team", UserId: "000000000000000000000012", FeedType: models.GroupFeed, FeedId: "000000000000000000000002"},
+		},
+		{
+			"missing name",
+			true,
+			&models.FeedToken{UserId: "000000000000000000000012", FeedType: models.UserFeed, FeedId: "000000000000000000000012"},
+		},
+		{
+			"invalid feed type",
+			true,
+			&models.FeedToken{Name: "phone", UserId: "000000000000000000000012", FeedType: "task", FeedId: "000000000000000000000012"},
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestFeedTokenService()
+			got, err := testService.FeedTokenCreate(context.Background(), tt.feed)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FeedTokenService.FeedTokenCreate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (got.Token == "" || got.TokenHash != models.HashToken(got.Token)) { // Asserting whether we get the correct wanted value
+				t.Errorf("FeedTokenService.FeedTokenCreate() = %v, want a new hashed token", got)
+			}
+		})
+	}
+}
+
+func Test_FeedTokenAuthenticate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string // The name of the test
+		wantErr  bool   // whether we want an error.
+		revoked  bool   // whether the issued token is revoked before it is presented
+		feedType string // The type of the feed the token is presented for
+		feedId   string // The id of the feed the token is presented for
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"success", false, false, models.UserFeed, "000000000000000000000012"},
+		{"other user", true, false, models.UserFeed, "000000000000000000000013"},
+		{"other feed type", true, false, models.GroupFeed, "000000000000000000000012"},
+		{"revoked", true, true, models.UserFeed, "000000000000000000000012"},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestFeedTokenService()
+			issued, err := testService.FeedTokenCreate(context.Background(), &models.FeedToken{Name: "phone", UserId: "000000000000000000000012", FeedType: models.UserFeed, FeedId: "000000000000000000000012"})
+			if err != nil {
+				t.Fatalf("FeedTokenService.FeedTokenCreate() error = %v", err)
+			}
+			if tt.revoked {
+				if _, err = testService.FeedTokenDelete(context.Background(), &models.FeedToken{Id: issued.Id, UserId: issued.UserId}); err != nil {
+					t.Fatalf("FeedTokenService.FeedTokenDelete() error = %v", err)
+				}
+			}
+			got, err := testService.FeedTokenAuthenticate(context.Background(), issued.Token, tt.feedType, tt.feedId)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FeedTokenService.FeedTokenAuthenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (got.Id != issued.Id || got.LastUsedAt.IsZero()) { // Asserting whether we get the correct wanted value
+				t.Errorf("FeedTokenService.FeedTokenAuthenticate() = %v, want %v marked as used", got, issued.Id)
+			}
+		})
+	}
+}
+
+func Test_FeedTokenDelete(t *testing.T) {
+	testService := initTestFeedTokenService()
+	issued, err := testService.FeedTokenCreate(context.Background(), &models.FeedToken{Name: "phone", UserId: "000000000000000000000012", FeedType: models.UserFeed, FeedId: "000000000000000000000012"})
+	if err != nil {
+		t.Fatalf("FeedTokenService.FeedTokenCreate() error = %v", err)
+	}
+	// Another user can not revoke the feed token
+	if _, err = testService.FeedTokenDelete(context.Background(), &models.FeedToken{Id: issued.Id, UserId: "000000000000000000000013"}); err == nil {
+		t.Errorf("FeedTokenService.FeedTokenDelete() revoked the feed token of another user")
+	}
+	if _, err = testService.FeedTokenDelete(context.Background(), &models.FeedToken{Id: issued.Id, UserId: issued.UserId}); err != nil {
+		t.Errorf("FeedTokenService.FeedTokenDelete() error = %v", err)
+	}
+	tokens, err := testService.FeedTokensFind(context.Background(), &models.FeedToken{UserId: issued.UserId})
+	if err != nil || len(tokens) != 0 {
+		t.Errorf("FeedTokenService.FeedTokensFind() = %v, %v, want no feed tokens", tokens, err)
+	}
+}
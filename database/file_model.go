@@ -78,6 +78,9 @@ func (u *fileModel) update(doc interface{}) (err error) {
 	if !um.LastModified.IsZero() {
 		u.LastModified = um.LastModified
 	}
+	if !um.DeletedAt.IsZero() {
+		u.DeletedAt = um.DeletedAt
+	}
 	return
 }
 
@@ -126,6 +129,11 @@ func (u *fileModel) getID() (id interface{}) {
 	return u.Id
 }
 
+// getDeletedAt returns the time the fileModel was soft deleted at
+func (u *fileModel) getDeletedAt() time.Time {
+	return u.DeletedAt
+}
+
 // addTimeStamps updates an userModel struct with a timestamp
 func (u *fileModel) addTimeStamps(newRecord bool) {
 	currentTime := time.Now().UTC()
@@ -147,7 +155,6 @@ func (u *fileModel) postProcess() (err error) {
 	if u.GridFSId.Hex() == "" {
 		err = errors.New("user record does not have an email")
 	}
-	// TODO - When implementing soft delete, DeletedAt can be checked here to ensure deleted users are filtered out
 	return
 }
 
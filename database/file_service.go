@@ -2,10 +2,13 @@ package database
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
 	"sync"
+	"time"
 )
 
 // FileService is used by the app to manage all File related controllers and functionality
@@ -15,10 +18,11 @@ type FileService struct {
 	fileHandler  *DBHandler[*fileModel]
 	userHandler  *DBHandler[*userModel]
 	groupHandler *DBHandler[*groupModel]
+	taskHandler  *DBHandler[*taskModel]
 }
 
 // NewFileService is an exported function used to initialize a new FileService struct
-func NewFileService(db DBClient, fHandler *DBHandler[*fileModel], uHandler *DBHandler[*userModel], gHandler *DBHandler[*groupModel]) *FileService {
+func NewFileService(db DBClient, fHandler *DBHandler[*fileModel], uHandler *DBHandler[*userModel], gHandler *DBHandler[*groupModel], tHandler *DBHandler[*taskModel]) *FileService {
 	collection := db.GetCollection("files")
 	return &FileService{
 		collection,
@@ -26,6 +30,7 @@ func NewFileService(db DBClient, fHandler *DBHandler[*fileModel], uHandler *DBHa
 		fHandler,
 		uHandler,
 		gHandler,
+		tHandler,
 	}
 }
 
@@ -72,9 +77,9 @@ func (p *FileService) deleteFileFromBucket(g *fileModel) error {
 }
 
 // checkFileOwner queries an OwnerId to verify the record is legit
-func (p *FileService) checkFileOwner(g *fileModel) error {
+func (p *FileService) checkFileOwner(ctx context.Context, g *fileModel) error {
 	if g.OwnerType == "group" {
-		gm, err := p.groupHandler.FindOne(&groupModel{Id: g.OwnerId})
+		gm, err := p.groupHandler.FindOne(ctx, &groupModel{Id: g.OwnerId})
 		if err != nil {
 			return err
 		}
@@ -82,7 +87,15 @@ func (p *FileService) checkFileOwner(g *fileModel) error {
 			return nil
 		}
 	} else if g.OwnerType == "user" {
-		gm, err := p.userHandler.FindOne(&userModel{Id: g.OwnerId})
+		gm, err := p.userHandler.FindOne(ctx, &userModel{Id: g.OwnerId})
+		if err != nil {
+			return err
+		}
+		if gm.toRoot().CheckID("id") {
+			return nil
+		}
+	} else if g.OwnerType == "task" {
+		gm, err := p.taskHandler.FindOne(ctx, &taskModel{Id: g.OwnerId})
 		if err != nil {
 			return err
 		}
@@ -94,13 +107,13 @@ func (p *FileService) checkFileOwner(g *fileModel) error {
 }
 
 // FilesFind is used to find many files
-func (p *FileService) FilesFind(g *models.File) ([]*models.File, error) {
+func (p *FileService) FilesFind(ctx context.Context, g *models.File) ([]*models.File, error) {
 	var files []*models.File
 	tm, err := newFileModel(g)
 	if err != nil {
 		return files, err
 	}
-	gms, err := p.fileHandler.FindMany(tm)
+	gms, err := p.fileHandler.FindMany(ctx, tm)
 	if err != nil {
 		return files, err
 	}
@@ -111,12 +124,12 @@ func (p *FileService) FilesFind(g *models.File) ([]*models.File, error) {
 }
 
 // FileFind is used to find a specific file
-func (p *FileService) FileFind(g *models.File) (*models.File, error) {
+func (p *FileService) FileFind(ctx context.Context, g *models.File) (*models.File, error) {
 	gm, err := newFileModel(g)
 	if err != nil {
 		return nil, err
 	}
-	gm, err = p.fileHandler.FindOne(gm)
+	gm, err = p.fileHandler.FindOne(ctx, gm)
 	if err != nil {
 		return nil, err
 	}
@@ -124,7 +137,7 @@ func (p *FileService) FileFind(g *models.File) (*models.File, error) {
 }
 
 // FileCreate creates a new GridFS File
-func (p *FileService) FileCreate(g *models.File, content []byte) (*models.File, error) {
+func (p *FileService) FileCreate(ctx context.Context, g *models.File, content []byte) (*models.File, error) {
 	err := g.Validate("create")
 	if err != nil {
 		return nil, err
@@ -138,7 +151,7 @@ func (p *FileService) FileCreate(g *models.File, content []byte) (*models.File,
 	if err != nil {
 		return nil, err
 	}
-	err = p.checkFileOwner(gm) // verify that the owner of the new file is a valid db record
+	err = p.checkFileOwner(ctx, gm) // verify that the owner of the new file is a valid db record
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +160,7 @@ func (p *FileService) FileCreate(g *models.File, content []byte) (*models.File,
 		return nil, err
 	}
 	gm.GridFSId = gridFSId
-	gm, err = p.fileHandler.InsertOne(gm)
+	gm, err = p.fileHandler.InsertOne(ctx, gm)
 	if err != nil {
 		err = p.deleteFileFromBucket(gm)
 		if err != nil {
@@ -159,7 +172,7 @@ func (p *FileService) FileCreate(g *models.File, content []byte) (*models.File,
 }
 
 // FileUpdate is used to update an existing File
-func (p *FileService) FileUpdate(g *models.File, content []byte) (*models.File, error) {
+func (p *FileService) FileUpdate(ctx context.Context, g *models.File, content []byte) (*models.File, error) {
 	var filter models.File
 	err := g.Validate("update")
 	if err != nil {
@@ -170,7 +183,7 @@ func (p *FileService) FileUpdate(g *models.File, content []byte) (*models.File,
 	if err != nil {
 		return nil, err
 	}
-	cur, err := p.fileHandler.FindOne(f)
+	cur, err := p.fileHandler.FindOne(ctx, f)
 	if err != nil {
 		return nil, errors.New("file not found")
 	}
@@ -180,7 +193,7 @@ func (p *FileService) FileUpdate(g *models.File, content []byte) (*models.File,
 		return nil, err
 	}
 	if gm.BucketName != cur.BucketName { // if new file owner and type in update, then verify the new owner
-		err = p.checkFileOwner(gm)
+		err = p.checkFileOwner(ctx, gm)
 		if err != nil {
 			return nil, err
 		}
@@ -197,39 +210,40 @@ func (p *FileService) FileUpdate(g *models.File, content []byte) (*models.File,
 		gm.GridFSId = gridFSId
 		gm.Size = len(content)
 	}
-	gm, err = p.fileHandler.UpdateOne(f, gm)
+	gm, err = p.fileHandler.UpdateOne(ctx, f, gm)
 	if err != nil {
 		return nil, err
 	}
 	return gm.toRoot(), err
 }
 
-// FileDelete is used to delete a GridFS File
-func (p *FileService) FileDelete(g *models.File) (*models.File, error) {
+// FileDelete is used to soft delete a File, its GridFS content is kept until the File is purged
+func (p *FileService) FileDelete(ctx context.Context, g *models.File) (*models.File, error) {
 	gm, err := newFileModel(g)
 	if err != nil {
 		return nil, err
 	}
-	gm, err = p.fileHandler.DeleteOne(gm)
-	if err != nil {
-		return nil, err
-	}
-	err = p.deleteFileFromBucket(gm)
+	gm, err = p.fileHandler.DeleteOne(ctx, gm)
 	if err != nil {
 		return nil, err
 	}
 	return gm.toRoot(), nil
 }
 
-// FileDeleteMany is used to delete a GridFS File
-func (p *FileService) FileDeleteMany(g []*models.File) error {
+// FileDeleteMany is used to soft delete every File matching each of the input Files, such as all the Files of many owners
+func (p *FileService) FileDeleteMany(ctx context.Context, g []*models.File) error {
 	outErrors := make([]error, len(g))
 	var wg sync.WaitGroup
 	wg.Add(len(g))
 	for c, f := range g {
 		go func(c int, f *models.File) {
-			_, outErrors[c] = p.FileDelete(f)
-			wg.Done()
+			defer wg.Done()
+			gm, err := newFileModel(f)
+			if err != nil {
+				outErrors[c] = err
+				return
+			}
+			_, outErrors[c] = p.fileHandler.DeleteMany(ctx, gm)
 		}(c, f)
 	}
 	wg.Wait()
@@ -241,8 +255,38 @@ func (p *FileService) FileDeleteMany(g []*models.File) error {
 	return nil
 }
 
+// FileRestoreMany is used to restore the Files of many owners that were soft deleted at or after since
+func (p *FileService) FileRestoreMany(ctx context.Context, g []*models.File, since time.Time) error {
+	for _, f := range g {
+		gm, err := newFileModel(&models.File{OwnerId: f.OwnerId})
+		if err != nil {
+			return err
+		}
+		err = p.fileHandler.RestoreMany(ctx, gm, since)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FilesPurge is used to permanently remove Files and their GridFS content that were soft deleted at or before a given time
+func (p *FileService) FilesPurge(ctx context.Context, before time.Time) (int64, error) {
+	gms, err := p.fileHandler.FindManyDeleted(ctx, &fileModel{}, before)
+	if err != nil {
+		return 0, err
+	}
+	for _, gm := range gms {
+		err = p.deleteFileFromBucket(gm)
+		if err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return 0, err
+		}
+	}
+	return p.fileHandler.Purge(ctx, before)
+}
+
 // RetrieveFile returns the content bytes for a GridFS File
-func (p *FileService) RetrieveFile(g *models.File) (*bytes.Buffer, error) {
+func (p *FileService) RetrieveFile(ctx context.Context, g *models.File) (*bytes.Buffer, error) {
 	err := g.Validate("retrieve")
 	if err != nil {
 		return nil, err
@@ -255,7 +299,7 @@ func (p *FileService) RetrieveFile(g *models.File) (*bytes.Buffer, error) {
 		return p.downloadFileFromBucket(gm)
 	}
 	if g.CheckID("id") {
-		gm, err = p.fileHandler.FindOne(gm)
+		gm, err = p.fileHandler.FindOne(ctx, gm)
 		if err != nil {
 			return nil, errors.New("file not found")
 		}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+	"time"
+)
+
+func Test_FileDeleteMany(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string         // The name of the test
+		files   []*models.File // The input of the test
+		want    map[string]int // The number of files we want each task to have left
+		wantErr bool           // whether we want an error.
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"every attachment of a task",
+			models.TasksToFiles([]*models.Task{{Id: "000000000000000000000022"}}),
+			map[string]int{"000000000000000000000022": 0, "000000000000000000000023": 1},
+			false,
+		},
+		{
+			"task without attachments",
+			models.TasksToFiles([]*models.Task{{Id: "000000000000000000000024"}}),
+			map[string]int{"000000000000000000000022": 2, "000000000000000000000023": 1},
+			false,
+		},
+		{
+			"missing filter",
+			[]*models.File{{OwnerType: "task"}},
+			map[string]int{"000000000000000000000022": 2, "000000000000000000000023": 1},
+			true,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestFiles()
+			err := testService.FileDeleteMany(context.Background(), tt.files)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FileService.FileDeleteMany() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for taskId, want := range tt.want {
+				got, err := testService.FilesFind(context.Background(), &models.File{OwnerId: taskId})
+				if err != nil || len(got) != want {
+					t.Errorf("FileService.FilesFind() = %d files, want %d, error = %v", len(got), want, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_FileRestoreMany(t *testing.T) {
+	testService := setupTestFiles()
+	if _, err := testService.FileDelete(context.Background(), &models.File{Id: "000000000000000000000051"}); err != nil {
+		t.Fatalf("FileService.FileDelete() error = %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	since := time.Now().UTC().Truncate(time.Millisecond)
+	files := models.TasksToFiles([]*models.Task{{Id: "000000000000000000000022"}})
+	if err := testService.FileDeleteMany(context.Background(), files); err != nil {
+		t.Fatalf("FileService.FileDeleteMany() error = %v", err)
+	}
+	if err := testService.FileRestoreMany(context.Background(), files, since); err != nil {
+		t.Fatalf("FileService.FileRestoreMany() error = %v", err)
+	}
+	// Attachments deleted before the task itself stay deleted
+	got, err := testService.FilesFind(context.Background(), &models.File{OwnerId: "000000000000000000000022"})
+	if err != nil || len(got) != 1 || got[0].Id != "000000000000000000000052" {
+		t.Errorf("FileService.FilesFind() = %+v, error = %v", got, err)
+	}
+}
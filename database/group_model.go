@@ -13,11 +13,50 @@ type groupModel struct {
 	Id           primitive.ObjectID `bson:"_id,omitempty"`
 	Name         string             `bson:"name,omitempty"`
 	RootAdmin    bool               `bson:"root_admin,omitempty"`
+	Require2FA   bool               `bson:"require_2fa,omitempty"`
+	TaskWorkflow *taskWorkflowModel `bson:"task_workflow,omitempty"`
 	LastModified time.Time          `bson:"last_modified,omitempty"`
 	CreatedAt    time.Time          `bson:"created_at,omitempty"`
 	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
 }
 
+// taskWorkflowModel structures the task workflow BSON subdocument of a groupModel
+type taskWorkflowModel struct {
+	Statuses        []models.TaskStatus         `bson:"statuses"`
+	Transitions     []taskStatusTransitionModel `bson:"transitions"`
+	EnforceBlockers bool                        `bson:"enforce_blockers,omitempty"`
+}
+
+// taskStatusTransitionModel structures a transition BSON subdocument of a taskWorkflowModel
+type taskStatusTransitionModel struct {
+	From models.TaskStatus `bson:"from"`
+	To   models.TaskStatus `bson:"to"`
+}
+
+// newTaskWorkflowModel initializes a new pointer to a taskWorkflowModel struct from a pointer to a JSON TaskWorkflow struct
+func newTaskWorkflowModel(w *models.TaskWorkflow) *taskWorkflowModel {
+	if w == nil {
+		return nil
+	}
+	wm := &taskWorkflowModel{Statuses: w.Statuses, EnforceBlockers: w.EnforceBlockers}
+	for _, t := range w.Transitions {
+		wm.Transitions = append(wm.Transitions, taskStatusTransitionModel{From: t.From, To: t.To})
+	}
+	return wm
+}
+
+// toRoot creates and return a new pointer to a TaskWorkflow JSON struct from a pointer to a BSON taskWorkflowModel
+func (w *taskWorkflowModel) toRoot() *models.TaskWorkflow {
+	if w == nil {
+		return nil
+	}
+	workflow := &models.TaskWorkflow{Statuses: w.Statuses, Transitions: []models.TaskStatusTransition{}, EnforceBlockers: w.EnforceBlockers}
+	for _, t := range w.Transitions {
+		workflow.Transitions = append(workflow.Transitions, models.TaskStatusTransition{From: t.From, To: t.To})
+	}
+	return workflow
+}
+
 // newGroupModel initializes a new pointer to a groupModel struct from a pointer to a JSON Group struct
 func newGroupModel(g *models.Group) (gm *groupModel, err error) {
 	gm = &groupModel{
@@ -44,9 +83,18 @@ func (g *groupModel) update(doc interface{}) (err error) {
 	if len(gm.Name) > 0 {
 		g.Name = gm.Name
 	}
+	if gm.Require2FA {
+		g.Require2FA = gm.Require2FA
+	}
+	if gm.TaskWorkflow != nil {
+		g.TaskWorkflow = gm.TaskWorkflow
+	}
 	if !gm.LastModified.IsZero() {
 		g.LastModified = gm.LastModified
 	}
+	if !gm.DeletedAt.IsZero() {
+		g.DeletedAt = gm.DeletedAt
+	}
 	return
 }
 
@@ -82,6 +130,11 @@ func (g *groupModel) getID() (id interface{}) {
 	return g.Id
 }
 
+// getDeletedAt returns the time the groupModel was soft deleted at
+func (g *groupModel) getDeletedAt() time.Time {
+	return g.DeletedAt
+}
+
 // addTimeStamps updates a groupModel struct with a timestamp
 func (g *groupModel) addTimeStamps(newRecord bool) {
 	currentTime := time.Now().UTC()
@@ -103,7 +156,6 @@ func (g *groupModel) postProcess() (err error) {
 	if g.Name == "" {
 		err = errors.New("group record does not have a name")
 	}
-	// TODO - When implementing soft delete, DeletedAt can be checked here to ensure deleted groups are filtered out
 	return
 }
 
@@ -144,6 +196,8 @@ func (g *groupModel) toRoot() *models.Group {
 		Id:           g.Id.Hex(),
 		Name:         g.Name,
 		RootAdmin:    g.RootAdmin,
+		Require2FA:   g.Require2FA,
+		TaskWorkflow: g.TaskWorkflow.toRoot(),
 		LastModified: g.LastModified,
 		CreatedAt:    g.CreatedAt,
 		DeletedAt:    g.DeletedAt,
@@ -21,7 +21,7 @@ func NewGroupService(db DBClient, handler *DBHandler[*groupModel]) *GroupService
 }
 
 // GroupCreate is used to create a new user group
-func (p *GroupService) GroupCreate(g *models.Group) (*models.Group, error) {
+func (p *GroupService) GroupCreate(ctx context.Context, g *models.Group) (*models.Group, error) {
 	err := g.Validate("create")
 	if err != nil {
 		return nil, err
@@ -30,25 +30,27 @@ func (p *GroupService) GroupCreate(g *models.Group) (*models.Group, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, err = p.handler.FindOne(&groupModel{Name: gm.Name})
+	_, err = p.handler.FindOne(ctx, &groupModel{Name: gm.Name})
 	if err == nil {
 		return nil, errors.New("group name exists")
 	}
-	gm, err = p.handler.InsertOne(gm)
+	gm, err = p.handler.InsertOne(ctx, gm)
 	if err != nil {
 		return nil, err
 	}
-	return gm.toRoot(), err
+	group := gm.toRoot()
+	emit(p.db, models.EventGroupCreated, group.Id, group, nil)
+	return group, err
 }
 
 // GroupsFind is used to find all group docs in a MongoDB Collection
-func (p *GroupService) GroupsFind(g *models.Group) ([]*models.Group, error) {
+func (p *GroupService) GroupsFind(ctx context.Context, g *models.Group) ([]*models.Group, error) {
 	var groups []*models.Group
 	m, err := newGroupModel(g)
 	if err != nil {
 		return groups, err
 	}
-	gms, err := p.handler.FindMany(m)
+	gms, err := p.handler.FindMany(ctx, m)
 	if err != nil {
 		return groups, err
 	}
@@ -58,13 +60,30 @@ func (p *GroupService) GroupsFind(g *models.Group) ([]*models.Group, error) {
 	return groups, nil
 }
 
+// GroupsFindPage is used to find a sorted, filtered and paginated page of group docs along with the total number of matches
+func (p *GroupService) GroupsFindPage(ctx context.Context, g *models.Group, o *models.ListOptions) ([]*models.Group, int64, error) {
+	var groups []*models.Group
+	m, err := newGroupModel(g)
+	if err != nil {
+		return groups, 0, err
+	}
+	gms, total, err := p.handler.FindPage(ctx, m, o)
+	if err != nil {
+		return groups, 0, err
+	}
+	for _, gm := range gms {
+		groups = append(groups, gm.toRoot())
+	}
+	return groups, total, nil
+}
+
 // GroupFind is used to find a specific group doc
-func (p *GroupService) GroupFind(g *models.Group) (*models.Group, error) {
+func (p *GroupService) GroupFind(ctx context.Context, g *models.Group) (*models.Group, error) {
 	gm, err := newGroupModel(g)
 	if err != nil {
 		return nil, err
 	}
-	gm, err = p.handler.FindOne(gm)
+	gm, err = p.handler.FindOne(ctx, gm)
 	if err != nil {
 		return nil, err
 	}
@@ -72,33 +91,63 @@ func (p *GroupService) GroupFind(g *models.Group) (*models.Group, error) {
 }
 
 // GroupDelete is used to delete a group doc
-func (p *GroupService) GroupDelete(g *models.Group) (*models.Group, error) {
+func (p *GroupService) GroupDelete(ctx context.Context, g *models.Group) (*models.Group, error) {
 	gm, err := newGroupModel(g)
 	if err != nil {
 		return nil, err
 	}
-	gm, err = p.handler.DeleteOne(gm)
+	gm, err = p.handler.DeleteOne(ctx, gm)
 	if err != nil {
 		return nil, err
 	}
-	return gm.toRoot(), err
+	group := gm.toRoot()
+	emit(p.db, models.EventGroupDeleted, group.Id, group, nil)
+	return group, err
 }
 
 // GroupDeleteMany is used to delete many Groups
-func (p *GroupService) GroupDeleteMany(g *models.Group) (*models.Group, error) {
+func (p *GroupService) GroupDeleteMany(ctx context.Context, g *models.Group) (*models.Group, error) {
 	gm, err := newGroupModel(g)
 	if err != nil {
 		return nil, err
 	}
-	gm, err = p.handler.DeleteMany(gm)
+	gm, err = p.handler.DeleteMany(ctx, gm)
 	if err != nil {
 		return nil, err
 	}
 	return gm.toRoot(), err
 }
 
+// GroupRestore is used to restore a soft deleted group doc
+func (p *GroupService) GroupRestore(ctx context.Context, g *models.Group) (*models.Group, error) {
+	gm, err := newGroupModel(g)
+	if err != nil {
+		return nil, err
+	}
+	dm, err := p.handler.FindOneDeleted(ctx, gm)
+	if err != nil {
+		return nil, errors.New("deleted group not found")
+	}
+	_, err = p.handler.FindOne(ctx, &groupModel{Name: dm.Name})
+	if err == nil {
+		return nil, errors.New("group name exists")
+	}
+	dm, err = p.handler.RestoreOne(ctx, &groupModel{Id: dm.Id})
+	if err != nil {
+		return nil, err
+	}
+	group := dm.toRoot()
+	emit(p.db, models.EventGroupRestored, group.Id, group, nil)
+	return group, err
+}
+
+// GroupsPurge is used to permanently remove groups that were soft deleted at or before a given time
+func (p *GroupService) GroupsPurge(ctx context.Context, before time.Time) (int64, error) {
+	return p.handler.Purge(ctx, before)
+}
+
 // GroupUpdate is used to update an existing group
-func (p *GroupService) GroupUpdate(g *models.Group) (*models.Group, error) {
+func (p *GroupService) GroupUpdate(ctx context.Context, g *models.Group) (*models.Group, error) {
 	var filter models.Group
 	err := g.Validate("create")
 	if err != nil {
@@ -106,7 +155,7 @@ func (p *GroupService) GroupUpdate(g *models.Group) (*models.Group, error) {
 	}
 	filter.Id = g.Id
 	if g.Name != "" {
-		reDoc, err := p.handler.FindOne(&groupModel{Name: g.Name})
+		reDoc, err := p.handler.FindOne(ctx, &groupModel{Name: g.Name})
 		if err == nil && reDoc.toRoot().Id != filter.Id {
 			return nil, errors.New("group name exists")
 		}
@@ -119,21 +168,87 @@ func (p *GroupService) GroupUpdate(g *models.Group) (*models.Group, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, groupErr := p.handler.FindOne(f)
+	cur, groupErr := p.handler.FindOne(ctx, f)
 	if groupErr != nil {
 		return nil, errors.New("group not found")
 	}
-	gm, err = p.handler.UpdateOne(f, gm)
-	return gm.toRoot(), err
+	gm, err = p.handler.UpdateOne(ctx, f, gm)
+	if err != nil {
+		return nil, err
+	}
+	group := gm.toRoot()
+	emit(p.db, models.EventGroupUpdated, group.Id, group, cur.toRoot())
+	return group, nil
+}
+
+// GroupRequire2FA is used to set whether every member of a group must use two-factor authentication
+func (p *GroupService) GroupRequire2FA(ctx context.Context, g *models.Group) (*models.Group, error) {
+	if !g.CheckID("id") {
+		return nil, errors.New("missing valid query filter")
+	}
+	f, err := newGroupModel(&models.Group{Id: g.Id})
+	if err != nil {
+		return nil, err
+	}
+	gm, err := p.handler.FindOne(ctx, f)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	prev := gm.toRoot()
+	gm.Require2FA = g.Require2FA
+	if !g.Require2FA {
+		err = p.handler.UnsetFields(ctx, f, "require_2fa")
+	} else {
+		gm, err = p.handler.UpdateOne(ctx, f, gm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	group := gm.toRoot()
+	emit(p.db, models.EventGroupUpdated, group.Id, group, prev)
+	return group, nil
+}
+
+// GroupSetTaskWorkflow is used to set the task workflow of a group, a nil workflow restores the default one
+func (p *GroupService) GroupSetTaskWorkflow(ctx context.Context, g *models.Group) (*models.Group, error) {
+	if !g.CheckID("id") {
+		return nil, errors.New("missing valid query filter")
+	}
+	if g.TaskWorkflow != nil {
+		if err := g.TaskWorkflow.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	f, err := newGroupModel(&models.Group{Id: g.Id})
+	if err != nil {
+		return nil, err
+	}
+	gm, err := p.handler.FindOne(ctx, f)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	prev := gm.toRoot()
+	gm.TaskWorkflow = newTaskWorkflowModel(g.TaskWorkflow)
+	if g.TaskWorkflow == nil {
+		err = p.handler.UnsetFields(ctx, f, "task_workflow")
+	} else {
+		gm, err = p.handler.UpdateOne(ctx, f, gm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	group := gm.toRoot()
+	emit(p.db, models.EventGroupUpdated, group.Id, group, prev)
+	return group, nil
 }
 
 // GroupDocInsert is used to insert a group doc directly into mongodb for testing purposes
-func (p *GroupService) GroupDocInsert(g *models.Group) (*models.Group, error) {
+func (p *GroupService) GroupDocInsert(ctx context.Context, g *models.Group) (*models.Group, error) {
 	insertGroup, err := newGroupModel(g)
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withTimeout(ctx, writeOperation)
 	defer cancel()
 	_, err = p.collection.InsertOne(ctx, insertGroup)
 	if err != nil {
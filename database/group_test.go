@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
 	"reflect"
@@ -34,7 +35,7 @@ func Test_GroupCreate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := initTestGroupService()
 			//fmt.Println("\n\nPRE CREATE: ", tt.group)
-			got, err := testService.GroupCreate(tt.group)
+			got, err := testService.GroupCreate(context.Background(), tt.group)
 			//fmt.Println("\nPOST CREATE: ", got)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
@@ -85,7 +86,7 @@ func Test_GroupsFind(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestGroups()
-			got, err := testService.GroupsFind(tt.group)
+			got, err := testService.GroupsFind(context.Background(), tt.group)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GroupService.GroupsFind() error = %v, wantErr %v", err, tt.wantErr)
@@ -118,7 +119,7 @@ func Test_GroupFind(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestGroups()
-			got, err := testService.GroupFind(tt.group)
+			got, err := testService.GroupFind(context.Background(), tt.group)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GroupService.GroupFind() error = %v, wantErr %v", err, tt.wantErr)
@@ -164,7 +165,7 @@ func Test_GroupUpdate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestGroups()
-			got, err := testService.GroupUpdate(tt.group)
+			got, err := testService.GroupUpdate(context.Background(), tt.group)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GroupService.GroupUpdate() error = %v, wantErr %v", err, tt.wantErr)
@@ -214,7 +215,7 @@ func Test_GroupDelete(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestGroups()
-			got, err := testService.GroupDelete(tt.group)
+			got, err := testService.GroupDelete(context.Background(), tt.group)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GroupService.GroupDelete() error = %v, wantErr %v", err, tt.wantErr)
@@ -237,3 +238,68 @@ func Test_GroupDelete(t *testing.T) {
 		})
 	}
 }
+
+func Test_GroupRestore(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string        // The name of the test
+		want    *models.Group // What out instance we want our function to return.
+		wantErr bool          // whether we want an error.
+		group   *models.Group
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			&models.Group{Id: "000000000000000000000002", Name: "test2"},
+			false,
+			&models.Group{Id: "000000000000000000000002"},
+		},
+		{
+			"group not deleted",
+			nil,
+			true,
+			&models.Group{Id: "000000000000000000000003"},
+		},
+		{
+			"name taken",
+			nil,
+			true,
+			&models.Group{Id: "000000000000000000000002"},
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestGroups()
+			_, err := testService.GroupDelete(context.Background(), &models.Group{Id: "000000000000000000000002"})
+			if err != nil {
+				t.Errorf("GroupService.GroupDelete() error = %v", err)
+				return
+			}
+			if tt.name == "name taken" {
+				_, err = testService.GroupCreate(context.Background(), &models.Group{Id: "000000000000000000000004", Name: "test2"})
+				if err != nil {
+					t.Errorf("GroupService.GroupCreate() error = %v", err)
+					return
+				}
+			}
+			got, err := testService.GroupRestore(context.Background(), tt.group)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GroupService.GroupRestore() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var failMsg string
+			switch tt.name {
+			case "success":
+				found, err := testService.GroupFind(context.Background(), &models.Group{Id: tt.want.Id})
+				if got.Id != tt.want.Id || err != nil || found.Name != tt.want.Name { // Asserting whether we get the correct wanted value
+					failMsg = fmt.Sprintf("GroupService.GroupRestore() = %v, want %v", got, tt.want)
+				}
+			}
+			if failMsg != "" {
+				t.Errorf(failMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// identityModel structures an identity BSON document to save in an identities collection
+type identityModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	UserId       primitive.ObjectID `bson:"user_id,omitempty"`
+	Provider     string             `bson:"provider,omitempty"`
+	Subject      string             `bson:"subject,omitempty"`
+	Email        string             `bson:"email,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newIdentityModel initializes a new pointer to an identityModel struct from a pointer to a JSON Identity struct
+func newIdentityModel(i *models.Identity) (im *identityModel, err error) {
+	im = &identityModel{
+		Provider:     i.Provider,
+		Subject:      i.Subject,
+		Email:        i.Email,
+		LastModified: i.LastModified,
+		CreatedAt:    i.CreatedAt,
+		DeletedAt:    i.DeletedAt,
+	}
+	if i.Id != "" && i.Id != "000000000000000000000000" {
+		im.Id, err = primitive.ObjectIDFromHex(i.Id)
+	}
+	if i.UserId != "" && i.UserId != "000000000000000000000000" {
+		im.UserId, err = primitive.ObjectIDFromHex(i.UserId)
+	}
+	return
+}
+
+// update the identityModel using an overwrite bson doc
+func (i *identityModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	im := identityModel{}
+	err = bson.Unmarshal(data, &im)
+	if len(im.Email) > 0 {
+		i.Email = im.Email
+	}
+	if !im.LastModified.IsZero() {
+		i.LastModified = im.LastModified
+	}
+	if !im.DeletedAt.IsZero() {
+		i.DeletedAt = im.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the identityModel
+func (i *identityModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, i)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the identityModel
+func (i *identityModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	im := identityModel{}
+	err = bson.Unmarshal(data, &im)
+	if im.Id.Hex() != "" && im.Id.Hex() != "000000000000000000000000" {
+		return i.Id == im.Id
+	}
+	if im.Provider != "" && im.Subject != "" {
+		return i.Provider == im.Provider && i.Subject == im.Subject
+	}
+	if im.UserId.Hex() != "" && im.UserId.Hex() != "000000000000000000000000" {
+		return i.UserId == im.UserId
+	}
+	return false
+}
+
+// getID returns the unique identifier of the identityModel
+func (i *identityModel) getID() (id interface{}) {
+	return i.Id
+}
+
+// getDeletedAt returns the time the identityModel was unlinked at
+func (i *identityModel) getDeletedAt() time.Time {
+	return i.DeletedAt
+}
+
+// addTimeStamps updates an identityModel struct with a timestamp
+func (i *identityModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	i.LastModified = currentTime
+	if newRecord {
+		i.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if an identityModel has a value assigned for Id, if no value a new one is generated and assigned
+func (i *identityModel) addObjectID() {
+	if i.Id.Hex() == "" || i.Id.Hex() == "000000000000000000000000" {
+		i.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates an identityModel struct postProcess to do things such as validating required fields
+func (i *identityModel) postProcess() (err error) {
+	if i.Provider == "" || i.Subject == "" {
+		err = errors.New("identity record does not have a Provider and Subject")
+	}
+	return
+}
+
+// toDoc converts the bson identityModel into a bson.D
+func (i *identityModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(i)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the identityModel data
+func (i *identityModel) bsonFilter() (doc bson.D, err error) {
+	if i.Id.Hex() != "" && i.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", i.Id}}
+	} else if i.Provider != "" && i.Subject != "" {
+		doc = bson.D{{"provider", i.Provider}, {"subject", i.Subject}}
+	} else if i.UserId.Hex() != "" && i.UserId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"user_id", i.UserId}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the identityModel data
+func (i *identityModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := i.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to an Identity JSON struct from a pointer to a BSON identityModel
+func (i *identityModel) toRoot() *models.Identity {
+	return &models.Identity{
+		Id:           i.Id.Hex(),
+		UserId:       i.UserId.Hex(),
+		Provider:     i.Provider,
+		Subject:      i.Subject,
+		Email:        i.Email,
+		LastModified: i.LastModified,
+		CreatedAt:    i.CreatedAt,
+		DeletedAt:    i.DeletedAt,
+	}
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// IdentityService is used by the app to manage all identity related controllers and functionality
+type IdentityService struct {
+	collection DBCollection
+	db         DBClient
+	handler    *DBHandler[*identityModel]
+}
+
+// NewIdentityService is an exported function used to initialize a new IdentityService struct
+func NewIdentityService(db DBClient, handler *DBHandler[*identityModel]) *IdentityService {
+	collection := db.GetCollection("identities")
+	return &IdentityService{collection, db, handler}
+}
+
+// IdentityCreate is used to link an identity provider account to a user, an account can only be linked to one user
+func (p *IdentityService) IdentityCreate(ctx context.Context, i *models.Identity) (*models.Identity, error) {
+	err := i.Validate("create")
+	if err != nil {
+		return nil, err
+	}
+	im, err := newIdentityModel(i)
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.handler.FindOne(ctx, &identityModel{Provider: im.Provider, Subject: im.Subject})
+	if err == nil {
+		return nil, errors.New("identity is already linked to a user")
+	}
+	im, err = p.handler.InsertOne(ctx, im)
+	if err != nil {
+		return nil, err
+	}
+	return im.toRoot(), nil
+}
+
+// IdentitiesFind is used to find the identities linked to a user
+func (p *IdentityService) IdentitiesFind(ctx context.Context, i *models.Identity) ([]*models.Identity, error) {
+	var identities []*models.Identity
+	if !i.CheckID("user_id") {
+		return identities, errors.New("missing identity user id")
+	}
+	im, err := newIdentityModel(&models.Identity{UserId: i.UserId})
+	if err != nil {
+		return identities, err
+	}
+	ims, err := p.handler.FindMany(ctx, im)
+	if err != nil {
+		return identities, err
+	}
+	for _, m := range ims {
+		identities = append(identities, m.toRoot())
+	}
+	return identities, nil
+}
+
+// IdentityFind is used to find the identity of an identity provider account
+func (p *IdentityService) IdentityFind(ctx context.Context, i *models.Identity) (*models.Identity, error) {
+	if i.Provider == "" || i.Subject == "" {
+		return nil, errors.New("missing identity provider or subject")
+	}
+	im, err := p.handler.FindOne(ctx, &identityModel{Provider: i.Provider, Subject: i.Subject})
+	if err != nil {
+		return nil, errors.New("identity not found")
+	}
+	return im.toRoot(), nil
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+)
+
+func Test_IdentityCreate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string           // The name of the test
+		linked   bool             // whether the identity is already linked to a user
+		identity *models.Identity // The identity we want to link
+		wantErr  bool             // whether we want an error
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			false,
+			&models.Identity{UserId: "000000000000000000000012", Provider: "corp", Subject: "subject-1"},
+			false,
+		},
+		{
+			"already linked",
+			true,
+			&models.Identity{UserId: "000000000000000000000013", Provider: "corp", Subject: "subject-1"},
+			true,
+		},
+		{
+			"missing subject",
+			false,
+			&models.Identity{UserId: "000000000000000000000012", Provider: "corp"},
+			true,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestIdentityService()
+			if tt.linked {
+				_, err := testService.IdentityCreate(context.Background(), &models.Identity{UserId: "000000000000000000000012", Provider: "corp", Subject: "subject-1"})
+				if err != nil {
+					t.Fatalf("IdentityService.IdentityCreate() setup error = %v", err)
+				}
+			}
+			_, err := testService.IdentityCreate(context.Background(), tt.identity)
+			if (err != nil) != tt.wantErr { // Asserting whether we get the correct wanted value
+				t.Fatalf("IdentityService.IdentityCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := testService.IdentityFind(context.Background(), &models.Identity{Provider: "corp", Subject: "subject-1"})
+			if err != nil {
+				t.Fatalf("IdentityService.IdentityFind() error = %v", err)
+			}
+			if got.UserId != tt.identity.UserId {
+				t.Errorf("IdentityService.IdentityFind() user id = %v, want %v", got.UserId, tt.identity.UserId)
+			}
+		})
+	}
+}
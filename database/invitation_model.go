@@ -0,0 +1,194 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// invitationModel structures an invitation BSON document to save in an invitations collection
+type invitationModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	TokenHash    string             `bson:"token_hash,omitempty"`
+	Email        string             `bson:"email,omitempty"`
+	GroupId      primitive.ObjectID `bson:"group_id,omitempty"`
+	Role         string             `bson:"role,omitempty"`
+	InvitedBy    primitive.ObjectID `bson:"invited_by,omitempty"`
+	ExpiresAt    time.Time          `bson:"expires_at,omitempty"`
+	AcceptedAt   time.Time          `bson:"accepted_at,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newInvitationModel initializes a new pointer to an invitationModel struct from a pointer to a JSON Invitation struct
+func newInvitationModel(i *models.Invitation) (im *invitationModel, err error) {
+	im = &invitationModel{
+		TokenHash:    i.TokenHash,
+		Email:        i.Email,
+		Role:         i.Role,
+		ExpiresAt:    i.ExpiresAt,
+		AcceptedAt:   i.AcceptedAt,
+		LastModified: i.LastModified,
+		CreatedAt:    i.CreatedAt,
+		DeletedAt:    i.DeletedAt,
+	}
+	if i.Id != "" && i.Id != "000000000000000000000000" {
+		im.Id, err = primitive.ObjectIDFromHex(i.Id)
+	}
+	if i.GroupId != "" && i.GroupId != "000000000000000000000000" {
+		im.GroupId, err = primitive.ObjectIDFromHex(i.GroupId)
+	}
+	if i.InvitedBy != "" && i.InvitedBy != "000000000000000000000000" {
+		im.InvitedBy, err = primitive.ObjectIDFromHex(i.InvitedBy)
+	}
+	return
+}
+
+// update the invitationModel using an overwrite bson doc
+func (i *invitationModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	im := invitationModel{}
+	err = bson.Unmarshal(data, &im)
+	if len(im.TokenHash) > 0 {
+		i.TokenHash = im.TokenHash
+	}
+	if len(im.Role) > 0 {
+		i.Role = im.Role
+	}
+	if !im.ExpiresAt.IsZero() {
+		i.ExpiresAt = im.ExpiresAt
+	}
+	if !im.AcceptedAt.IsZero() {
+		i.AcceptedAt = im.AcceptedAt
+	}
+	if !im.LastModified.IsZero() {
+		i.LastModified = im.LastModified
+	}
+	if !im.DeletedAt.IsZero() {
+		i.DeletedAt = im.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the invitationModel
+func (i *invitationModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, i)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the invitationModel
+func (i *invitationModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	im := invitationModel{}
+	err = bson.Unmarshal(data, &im)
+	if im.Id.Hex() != "" && im.Id.Hex() != "000000000000000000000000" {
+		return i.Id == im.Id
+	}
+	if im.TokenHash != "" {
+		return i.TokenHash == im.TokenHash
+	}
+	if im.GroupId.Hex() != "" && im.GroupId.Hex() != "000000000000000000000000" {
+		return i.GroupId == im.GroupId && (im.Email == "" || i.Email == im.Email)
+	}
+	return false
+}
+
+// getID returns the unique identifier of the invitationModel
+func (i *invitationModel) getID() (id interface{}) {
+	return i.Id
+}
+
+// getDeletedAt returns the time the invitationModel was accepted or revoked at
+func (i *invitationModel) getDeletedAt() time.Time {
+	return i.DeletedAt
+}
+
+// addTimeStamps updates an invitationModel struct with a timestamp
+func (i *invitationModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	i.LastModified = currentTime
+	if newRecord {
+		i.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if an invitationModel has a value assigned for Id, if no value a new one is generated and assigned
+func (i *invitationModel) addObjectID() {
+	if i.Id.Hex() == "" || i.Id.Hex() == "000000000000000000000000" {
+		i.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates an invitationModel struct postProcess to do things such as validating required fields
+func (i *invitationModel) postProcess() (err error) {
+	if i.TokenHash == "" {
+		err = errors.New("invitation record does not have a TokenHash")
+	}
+	return
+}
+
+// toDoc converts the bson invitationModel into a bson.D
+func (i *invitationModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(i)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the invitationModel data
+// A group id filter is narrowed down to a single email address when one is set
+func (i *invitationModel) bsonFilter() (doc bson.D, err error) {
+	if i.Id.Hex() != "" && i.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", i.Id}}
+	} else if i.TokenHash != "" {
+		doc = bson.D{{"token_hash", i.TokenHash}}
+	} else if i.GroupId.Hex() != "" && i.GroupId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"group_id", i.GroupId}}
+		if i.Email != "" {
+			doc = append(doc, bson.E{Key: "email", Value: i.Email})
+		}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the invitationModel data
+func (i *invitationModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := i.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to an Invitation JSON struct from a pointer to a BSON invitationModel
+func (i *invitationModel) toRoot() *models.Invitation {
+	return &models.Invitation{
+		Id:           i.Id.Hex(),
+		TokenHash:    i.TokenHash,
+		Email:        i.Email,
+		GroupId:      i.GroupId.Hex(),
+		Role:         i.Role,
+		InvitedBy:    i.InvitedBy.Hex(),
+		ExpiresAt:    i.ExpiresAt,
+		AcceptedAt:   i.AcceptedAt,
+		LastModified: i.LastModified,
+		CreatedAt:    i.CreatedAt,
+		DeletedAt:    i.DeletedAt,
+	}
+}
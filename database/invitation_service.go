@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"strings"
+	"time"
+)
+
+// InvitationService is used by the app to manage all invitation related controllers and functionality
+type InvitationService struct {
+	collection DBCollection
+	db         DBClient
+	handler    *DBHandler[*invitationModel]
+}
+
+// NewInvitationService is an exported function used to initialize a new InvitationService struct
+func NewInvitationService(db DBClient, handler *DBHandler[*invitationModel]) *InvitationService {
+	collection := db.GetCollection("invitations")
+	return &InvitationService{collection, db, handler}
+}
+
+// findScoped finds a pending invitation by id, checking that it belongs to the input Invitation's group when one is specified
+func (p *InvitationService) findScoped(ctx context.Context, i *models.Invitation) (*invitationModel, error) {
+	im, err := newInvitationModel(&models.Invitation{Id: i.Id})
+	if err != nil {
+		return nil, err
+	}
+	found, err := p.handler.FindOne(ctx, im)
+	if err != nil || (i.CheckID("group_id") && found.GroupId.Hex() != i.GroupId) {
+		return nil, errors.New("invitation not found")
+	}
+	return found, nil
+}
+
+// InvitationCreate is used to issue a new invitation, any pending invitation of the same email address to the group is revoked
+// The returned Invitation is the only one to carry the raw Token
+func (p *InvitationService) InvitationCreate(ctx context.Context, i *models.Invitation) (*models.Invitation, error) {
+	i.Email = strings.TrimSpace(i.Email)
+	err := i.Validate("create")
+	if err != nil {
+		return nil, err
+	}
+	err = i.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	im, err := newInvitationModel(i)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = p.handler.DeleteMany(ctx, &invitationModel{GroupId: im.GroupId, Email: im.Email})
+	im, err = p.handler.InsertOne(ctx, im)
+	if err != nil {
+		return nil, err
+	}
+	created := im.toRoot()
+	created.Token = i.Token
+	return created, nil
+}
+
+// InvitationsFind is used to find the pending invitations of a group
+func (p *InvitationService) InvitationsFind(ctx context.Context, i *models.Invitation) ([]*models.Invitation, error) {
+	var invitations []*models.Invitation
+	if !i.CheckID("group_id") {
+		return invitations, errors.New("missing invitation group id")
+	}
+	im, err := newInvitationModel(&models.Invitation{GroupId: i.GroupId})
+	if err != nil {
+		return invitations, err
+	}
+	ims, err := p.handler.FindMany(ctx, im)
+	if err != nil {
+		return invitations, err
+	}
+	for _, m := range ims {
+		invitations = append(invitations, m.toRoot())
+	}
+	return invitations, nil
+}
+
+// InvitationFind is used to find a pending invitation by its id or by its raw token
+func (p *InvitationService) InvitationFind(ctx context.Context, i *models.Invitation) (*models.Invitation, error) {
+	if i.Token != "" {
+		im, err := p.handler.FindOne(ctx, &invitationModel{TokenHash: models.HashToken(i.Token)})
+		if err != nil {
+			return nil, errors.New("invalid invitation")
+		}
+		return im.toRoot(), nil
+	}
+	im, err := p.findScoped(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	return im.toRoot(), nil
+}
+
+// InvitationRenew is used to replace the token of a pending invitation and extend its expiration, so it can be sent again
+// The returned Invitation is the only one to carry the new raw Token
+func (p *InvitationService) InvitationRenew(ctx context.Context, i *models.Invitation, expiresAt time.Time) (*models.Invitation, error) {
+	cur, err := p.findScoped(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	renewed := cur.toRoot()
+	err = renewed.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	cur.TokenHash = renewed.TokenHash
+	cur.ExpiresAt = expiresAt
+	cur, err = p.handler.UpdateOne(ctx, &invitationModel{Id: cur.Id}, cur)
+	if err != nil {
+		return nil, err
+	}
+	updated := cur.toRoot()
+	updated.Token = renewed.Token
+	return updated, nil
+}
+
+// InvitationRevoke is used to revoke a pending invitation
+func (p *InvitationService) InvitationRevoke(ctx context.Context, i *models.Invitation) (*models.Invitation, error) {
+	cur, err := p.findScoped(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	im, err := p.handler.DeleteOne(ctx, &invitationModel{Id: cur.Id})
+	if err != nil {
+		return nil, err
+	}
+	return im.toRoot(), nil
+}
+
+// InvitationRedeem is used to exchange a raw invitation token for its Invitation record, each invitation can only be accepted once
+func (p *InvitationService) InvitationRedeem(ctx context.Context, token string) (*models.Invitation, error) {
+	im, err := p.handler.FindOne(ctx, &invitationModel{TokenHash: models.HashToken(token)})
+	if err != nil {
+		return nil, errors.New("invalid invitation")
+	}
+	err = im.toRoot().Validate("accept")
+	if err != nil {
+		return nil, err
+	}
+	// Accepting is a compare and set on accepted_at, so concurrent accepts of the same invitation only redeem it once
+	accepted, err := p.handler.UpdateIf(ctx, bson.D{{"_id", im.Id}, {"accepted_at", nil}}, &invitationModel{AcceptedAt: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, errors.New("invitation has already been accepted")
+	}
+	im, err = p.handler.DeleteOne(ctx, &invitationModel{Id: im.Id})
+	if err != nil {
+		return nil, err
+	}
+	return im.toRoot(), nil
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+	"time"
+)
+
+func Test_InvitationCreate(t *testing.T) {
+	testService := initTestInvitationService()
+	invitation := &models.Invitation{
+		Email:     "invitee@test.com",
+		GroupId:   "000000000000000000000003",
+		Role:      models.MemberRole,
+		ExpiresAt: time.Now().UTC().Add(time.Hour),
+	}
+	first, err := testService.InvitationCreate(context.Background(), invitation)
+	if err != nil {
+		t.Fatalf("InvitationService.InvitationCreate() error = %v", err)
+	}
+	if first.Token == "" || first.TokenHash == first.Token {
+		t.Fatalf("InvitationService.InvitationCreate() token = %v, want a raw token", first.Token)
+	}
+	// A new invitation of the same email address to the group replaces the pending one
+	second, err := testService.InvitationCreate(context.Background(), invitation)
+	if err != nil {
+		t.Fatalf("InvitationService.InvitationCreate() error = %v", err)
+	}
+	got, err := testService.InvitationsFind(context.Background(), &models.Invitation{GroupId: "000000000000000000000003"})
+	if err != nil {
+		t.Fatalf("InvitationService.InvitationsFind() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Id != second.Id {
+		t.Errorf("InvitationService.InvitationsFind() = %v, want only %v", got, second.Id)
+	}
+	if _, err = testService.InvitationRedeem(context.Background(), first.Token); err == nil {
+		t.Errorf("InvitationService.InvitationRedeem() accepted a replaced invitation")
+	}
+}
+
+func Test_InvitationRedeem(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name      string        // The name of the test
+		expiresIn time.Duration // how long until the invitation expires
+		redeemed  bool          // whether the invitation was already accepted
+		renewed   bool          // whether the invitation was sent again with a new token
+		wantErr   bool          // whether we want an error
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"success", time.Hour, false, false, false},
+		{"already accepted", time.Hour, true, false, true},
+		{"expired", time.Millisecond, false, false, true},
+		{"renewed", time.Millisecond, false, true, false},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestInvitationService()
+			invitation, err := testService.InvitationCreate(context.Background(), &models.Invitation{
+				Email:     "invitee@test.com",
+				GroupId:   "000000000000000000000003",
+				Role:      models.MemberRole,
+				ExpiresAt: time.Now().UTC().Add(tt.expiresIn),
+			})
+			if err != nil {
+				t.Fatalf("InvitationService.InvitationCreate() error = %v", err)
+			}
+			time.Sleep(time.Millisecond * 5)
+			if tt.renewed {
+				invitation, err = testService.InvitationRenew(context.Background(), &models.Invitation{Id: invitation.Id, GroupId: invitation.GroupId}, time.Now().UTC().Add(time.Hour))
+				if err != nil {
+					t.Fatalf("InvitationService.InvitationRenew() error = %v", err)
+				}
+			}
+			if tt.redeemed {
+				if _, err = testService.InvitationRedeem(context.Background(), invitation.Token); err != nil {
+					t.Fatalf("InvitationService.InvitationRedeem() setup error = %v", err)
+				}
+			}
+			got, err := testService.InvitationRedeem(context.Background(), invitation.Token)
+			if (err != nil) != tt.wantErr { // Asserting whether we get the correct wanted value
+				t.Fatalf("InvitationService.InvitationRedeem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.AcceptedAt.IsZero() {
+				t.Errorf("InvitationService.InvitationRedeem() accepted_at was not set")
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type loginAttemptModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	Key          string             `bson:"key,omitempty"`
+	Failures     int                `bson:"failures,omitempty"`
+	LastFailure  time.Time          `bson:"last_failure,omitempty"`
+	LockedUntil  time.Time          `bson:"locked_until,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+}
+
+// newLoginAttemptModel initializes a new pointer to a loginAttemptModel struct from a pointer to a JSON LoginAttempt struct
+func newLoginAttemptModel(a *models.LoginAttempt) (am *loginAttemptModel, err error) {
+	am = &loginAttemptModel{
+		Key:          a.Key,
+		Failures:     a.Failures,
+		LastFailure:  a.LastFailure,
+		LockedUntil:  a.LockedUntil,
+		LastModified: a.LastModified,
+		CreatedAt:    a.CreatedAt,
+	}
+	if a.Id != "" && a.Id != "000000000000000000000000" {
+		am.Id, err = primitive.ObjectIDFromHex(a.Id)
+	}
+	return
+}
+
+// update the loginAttemptModel using an overwrite bson doc
+func (a *loginAttemptModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	am := loginAttemptModel{}
+	err = bson.Unmarshal(data, &am)
+	if len(am.Key) > 0 {
+		a.Key = am.Key
+	}
+	if am.Failures > 0 {
+		a.Failures = am.Failures
+	}
+	if !am.LastFailure.IsZero() {
+		a.LastFailure = am.LastFailure
+	}
+	if !am.LockedUntil.IsZero() {
+		a.LockedUntil = am.LockedUntil
+	}
+	if !am.LastModified.IsZero() {
+		a.LastModified = am.LastModified
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the loginAttemptModel
+func (a *loginAttemptModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, a)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the loginAttemptModel
+func (a *loginAttemptModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	am := loginAttemptModel{}
+	err = bson.Unmarshal(data, &am)
+	if am.Id.Hex() != "" && am.Id.Hex() != "000000000000000000000000" {
+		return a.Id == am.Id
+	}
+	if am.Key != "" {
+		return a.Key == am.Key
+	}
+	return false
+}
+
+// getID returns the unique identifier of the loginAttemptModel
+func (a *loginAttemptModel) getID() (id interface{}) {
+	return a.Id
+}
+
+// getDeletedAt returns the zero time since login attempts are reset rather than soft deleted
+func (a *loginAttemptModel) getDeletedAt() time.Time {
+	return time.Time{}
+}
+
+// addTimeStamps updates a loginAttemptModel struct with a timestamp
+func (a *loginAttemptModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	a.LastModified = currentTime
+	if newRecord {
+		a.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a loginAttemptModel has a value assigned for Id, if no value a new one is generated and assigned
+func (a *loginAttemptModel) addObjectID() {
+	if a.Id.Hex() == "" || a.Id.Hex() == "000000000000000000000000" {
+		a.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a loginAttemptModel struct postProcess to do things such as validating required fields
+func (a *loginAttemptModel) postProcess() (err error) {
+	if a.Key == "" {
+		err = errors.New("login attempt record does not have a Key")
+	}
+	return
+}
+
+// toDoc converts the bson loginAttemptModel into a bson.D
+func (a *loginAttemptModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(a)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the loginAttemptModel data
+func (a *loginAttemptModel) bsonFilter() (doc bson.D, err error) {
+	if a.Id.Hex() != "" && a.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", a.Id}}
+	} else if a.Key != "" {
+		doc = bson.D{{"key", a.Key}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the loginAttemptModel data
+func (a *loginAttemptModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := a.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a LoginAttempt JSON struct from a pointer to a BSON loginAttemptModel
+func (a *loginAttemptModel) toRoot() *models.LoginAttempt {
+	return &models.LoginAttempt{
+		Id:           a.Id.Hex(),
+		Key:          a.Key,
+		Failures:     a.Failures,
+		LastFailure:  a.LastFailure,
+		LockedUntil:  a.LockedUntil,
+		LastModified: a.LastModified,
+		CreatedAt:    a.CreatedAt,
+	}
+}
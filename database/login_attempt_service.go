@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// LoginAttemptService is used by the app to manage all login attempt related controllers and functionality
+type LoginAttemptService struct {
+	collection DBCollection
+	db         DBClient
+	handler    *DBHandler[*loginAttemptModel]
+}
+
+// NewLoginAttemptService is an exported function used to initialize a new LoginAttemptService struct
+func NewLoginAttemptService(db DBClient, handler *DBHandler[*loginAttemptModel]) *LoginAttemptService {
+	collection := db.GetCollection("login_attempts")
+	return &LoginAttemptService{collection, db, handler}
+}
+
+// LoginAttemptFind is used to find the failed sign ins tracked for a key, an untracked key has no failures
+func (p *LoginAttemptService) LoginAttemptFind(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	if key == "" {
+		return nil, errors.New("missing login attempt key")
+	}
+	am, err := p.handler.FindOne(ctx, &loginAttemptModel{Key: key})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &models.LoginAttempt{Key: key}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return am.toRoot(), nil
+}
+
+// LoginAttemptFail is used to record a failed sign in for a key, locking the key out as defined by the LockoutPolicy
+// The failure is counted with a single atomic increment, so concurrent failed sign ins are never lost
+func (p *LoginAttemptService) LoginAttemptFail(ctx context.Context, key string, policy *models.LockoutPolicy) (*models.LoginAttempt, error) {
+	err := policy.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, errors.New("missing login attempt key")
+	}
+	now := time.Now().UTC()
+	if policy.Window > 0 {
+		_, err = p.handler.UnsetIf(ctx, bson.D{{"key", key}, {"last_failure", bson.D{{"$lte", now.Add(-policy.Window)}}}}, "failures")
+		if err != nil {
+			return nil, err
+		}
+	}
+	am, err := p.handler.IncrementOne(ctx, bson.D{{"key", key}}, "failures", &loginAttemptModel{Key: key, LastFailure: now})
+	if err != nil {
+		return nil, err
+	}
+	lockout := policy.LockoutAfter(am.Failures)
+	if lockout == 0 {
+		return am.toRoot(), nil
+	}
+	// Only the latest failure sets the lockout, a later failure sets a longer one of its own
+	am.LockedUntil = now.Add(lockout)
+	_, err = p.handler.UpdateIf(ctx, bson.D{{"_id", am.Id}, {"failures", am.Failures}}, &loginAttemptModel{LockedUntil: am.LockedUntil})
+	if err != nil {
+		return nil, err
+	}
+	return am.toRoot(), nil
+}
+
+// LoginAttemptReset is used to clear the failed sign ins and any lockout of a key
+func (p *LoginAttemptService) LoginAttemptReset(ctx context.Context, key string) error {
+	if key == "" {
+		return errors.New("missing login attempt key")
+	}
+	am, err := p.handler.FindOne(ctx, &loginAttemptModel{Key: key})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return p.handler.UnsetFields(ctx, &loginAttemptModel{Id: am.Id}, "failures", "last_failure", "locked_until")
+}
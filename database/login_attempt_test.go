@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+	"time"
+)
+
+func Test_LoginAttemptFail(t *testing.T) {
+	policy := &models.LockoutPolicy{MaxAttempts: 3, Lockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour}
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string // The name of the test
+		failures int    // The number of failed sign ins to record
+		reset    bool   // whether the key is reset afterwards
+		locked   bool   // whether we want the key to be locked out
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"below max attempts",
+			2,
+			false,
+			false,
+		},
+		{
+			"locked out",
+			3,
+			false,
+			true,
+		},
+		{
+			"reset",
+			3,
+			true,
+			false,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestLoginAttemptService()
+			for i := 0; i < tt.failures; i++ {
+				_, err := testService.LoginAttemptFail(context.Background(), "account:test2@email.com", policy)
+				if err != nil {
+					t.Fatalf("LoginAttemptService.LoginAttemptFail() error = %v", err)
+				}
+			}
+			if tt.reset {
+				err := testService.LoginAttemptReset(context.Background(), "account:test2@email.com")
+				if err != nil {
+					t.Fatalf("LoginAttemptService.LoginAttemptReset() error = %v", err)
+				}
+			}
+			got, err := testService.LoginAttemptFind(context.Background(), "account:test2@email.com")
+			if err != nil {
+				t.Fatalf("LoginAttemptService.LoginAttemptFind() error = %v", err)
+			}
+			if locked := got.Locked(time.Now().UTC()) > 0; locked != tt.locked { // Asserting whether we get the correct wanted value
+				t.Errorf("LoginAttemptService.LoginAttemptFind() locked = %v, want %v", locked, tt.locked)
+			}
+			if !tt.reset && got.Failures != tt.failures {
+				t.Errorf("LoginAttemptService.LoginAttemptFind() failures = %v, want %v", got.Failures, tt.failures)
+			}
+		})
+	}
+}
+
+func Test_LoginAttemptFindError(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string          // The name of the test
+		ctx     context.Context // The context of the lookup
+		wantErr bool            // whether we want an error
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"untracked key", context.Background(), false},
+		{"database error", cancelled, true},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestLoginAttemptService()
+			got, err := testService.LoginAttemptFind(tt.ctx, "account:test2@email.com")
+			if (err != nil) != tt.wantErr { // Asserting whether we get the correct wanted value
+				t.Fatalf("LoginAttemptService.LoginAttemptFind() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Failures != 0 {
+				t.Errorf("LoginAttemptService.LoginAttemptFind() failures = %v, want 0", got.Failures)
+			}
+		})
+	}
+}
+
+func Test_LoginAttemptFailWindow(t *testing.T) {
+	policy := &models.LockoutPolicy{MaxAttempts: 3, Lockout: time.Minute, MaxLockout: time.Hour, Window: time.Millisecond}
+	testService := initTestLoginAttemptService()
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * time.Millisecond)
+		got, err := testService.LoginAttemptFail(context.Background(), "account:test2@email.com", policy)
+		if err != nil {
+			t.Fatalf("LoginAttemptService.LoginAttemptFail() error = %v", err)
+		}
+		// Failures older than the window are forgotten, so the key is never locked out
+		if got.Failures != 1 || got.Locked(time.Now().UTC()) > 0 {
+			t.Errorf("LoginAttemptService.LoginAttemptFail() failures = %v, want 1 and no lockout", got.Failures)
+		}
+	}
+}
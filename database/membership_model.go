@@ -0,0 +1,171 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// membershipModel structures a membership BSON document to save in a memberships collection
+type membershipModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	UserId       primitive.ObjectID `bson:"user_id,omitempty"`
+	GroupId      primitive.ObjectID `bson:"group_id,omitempty"`
+	Role         string             `bson:"role,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newMembershipModel initializes a new pointer to a membershipModel struct from a pointer to a JSON Membership struct
+func newMembershipModel(m *models.Membership) (mm *membershipModel, err error) {
+	mm = &membershipModel{
+		Role:         m.Role,
+		LastModified: m.LastModified,
+		CreatedAt:    m.CreatedAt,
+		DeletedAt:    m.DeletedAt,
+	}
+	if m.Id != "" && m.Id != "000000000000000000000000" {
+		mm.Id, err = primitive.ObjectIDFromHex(m.Id)
+	}
+	if m.UserId != "" && m.UserId != "000000000000000000000000" {
+		mm.UserId, err = primitive.ObjectIDFromHex(m.UserId)
+	}
+	if m.GroupId != "" && m.GroupId != "000000000000000000000000" {
+		mm.GroupId, err = primitive.ObjectIDFromHex(m.GroupId)
+	}
+	return
+}
+
+// update the membershipModel using an overwrite bson doc
+func (m *membershipModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	mm := membershipModel{}
+	err = bson.Unmarshal(data, &mm)
+	if len(mm.Role) > 0 {
+		m.Role = mm.Role
+	}
+	if !mm.LastModified.IsZero() {
+		m.LastModified = mm.LastModified
+	}
+	if !mm.DeletedAt.IsZero() {
+		m.DeletedAt = mm.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the membershipModel
+func (m *membershipModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, m)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the membershipModel
+func (m *membershipModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	mm := membershipModel{}
+	err = bson.Unmarshal(data, &mm)
+	if mm.Id.Hex() != "" && mm.Id.Hex() != "000000000000000000000000" {
+		return m.Id == mm.Id
+	}
+	userSet := mm.UserId.Hex() != "" && mm.UserId.Hex() != "000000000000000000000000"
+	groupSet := mm.GroupId.Hex() != "" && mm.GroupId.Hex() != "000000000000000000000000"
+	if !userSet && !groupSet {
+		return false
+	}
+	return (!userSet || m.UserId == mm.UserId) && (!groupSet || m.GroupId == mm.GroupId)
+}
+
+// getID returns the unique identifier of the membershipModel
+func (m *membershipModel) getID() (id interface{}) {
+	return m.Id
+}
+
+// getDeletedAt returns the time the membershipModel was soft deleted at
+func (m *membershipModel) getDeletedAt() time.Time {
+	return m.DeletedAt
+}
+
+// addTimeStamps updates a membershipModel struct with a timestamp
+func (m *membershipModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	m.LastModified = currentTime
+	if newRecord {
+		m.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a membershipModel has a value assigned for Id, if no value a new one is generated and assigned
+func (m *membershipModel) addObjectID() {
+	if m.Id.Hex() == "" || m.Id.Hex() == "000000000000000000000000" {
+		m.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a membershipModel struct postProcess to do things such as validating required fields
+func (m *membershipModel) postProcess() (err error) {
+	if m.Role == "" {
+		err = errors.New("membership record does not have a role")
+	}
+	return
+}
+
+// toDoc converts the bson membershipModel into a bson.D
+func (m *membershipModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(m)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the membershipModel data
+// The user id and group id are combined so that a single Membership can be found by both
+func (m *membershipModel) bsonFilter() (doc bson.D, err error) {
+	if m.Id.Hex() != "" && m.Id.Hex() != "000000000000000000000000" {
+		return bson.D{{"_id", m.Id}}, nil
+	}
+	if m.UserId.Hex() != "" && m.UserId.Hex() != "000000000000000000000000" {
+		doc = append(doc, bson.E{Key: "user_id", Value: m.UserId})
+	}
+	if m.GroupId.Hex() != "" && m.GroupId.Hex() != "000000000000000000000000" {
+		doc = append(doc, bson.E{Key: "group_id", Value: m.GroupId})
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the membershipModel data
+func (m *membershipModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := m.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a Membership JSON struct from a pointer to a BSON membershipModel
+func (m *membershipModel) toRoot() *models.Membership {
+	return &models.Membership{
+		Id:           m.Id.Hex(),
+		UserId:       m.UserId.Hex(),
+		GroupId:      m.GroupId.Hex(),
+		Role:         m.Role,
+		LastModified: m.LastModified,
+		CreatedAt:    m.CreatedAt,
+		DeletedAt:    m.DeletedAt,
+	}
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// MembershipService is used by the app to manage all membership related controllers and functionality
+type MembershipService struct {
+	collection DBCollection
+	db         DBClient
+	handler    *DBHandler[*membershipModel]
+}
+
+// NewMembershipService is an exported function used to initialize a new MembershipService struct
+func NewMembershipService(db DBClient, handler *DBHandler[*membershipModel]) *MembershipService {
+	collection := db.GetCollection("memberships")
+	return &MembershipService{collection, db, handler}
+}
+
+// MembershipCreate is used to add a user to a group, a user can only be a member of a group once
+func (p *MembershipService) MembershipCreate(ctx context.Context, m *models.Membership) (*models.Membership, error) {
+	err := m.Validate("create")
+	if err != nil {
+		return nil, err
+	}
+	if m.Role == "" {
+		m.Role = models.MemberRole
+	}
+	mm, err := newMembershipModel(m)
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.handler.FindOne(ctx, &membershipModel{UserId: mm.UserId, GroupId: mm.GroupId})
+	if err == nil {
+		return nil, errors.New("user is already a member of the group")
+	}
+	mm, err = p.handler.InsertOne(ctx, mm)
+	if err != nil {
+		return nil, err
+	}
+	return mm.toRoot(), nil
+}
+
+// MembershipsFind is used to find the active memberships of a user or of a group
+func (p *MembershipService) MembershipsFind(ctx context.Context, m *models.Membership) ([]*models.Membership, error) {
+	var memberships []*models.Membership
+	if !m.CheckID("user_id") && !m.CheckID("group_id") {
+		return memberships, errors.New("missing valid query filter")
+	}
+	mm, err := newMembershipModel(&models.Membership{UserId: m.UserId, GroupId: m.GroupId})
+	if err != nil {
+		return memberships, err
+	}
+	mms, err := p.handler.FindMany(ctx, mm)
+	if err != nil {
+		return memberships, err
+	}
+	for _, r := range mms {
+		memberships = append(memberships, r.toRoot())
+	}
+	return memberships, nil
+}
+
+// MembershipFind is used to find the active membership of a user in a group
+func (p *MembershipService) MembershipFind(ctx context.Context, m *models.Membership) (*models.Membership, error) {
+	if !m.CheckID("user_id") || !m.CheckID("group_id") {
+		return nil, errors.New("missing membership user id or group id")
+	}
+	mm, err := newMembershipModel(&models.Membership{UserId: m.UserId, GroupId: m.GroupId})
+	if err != nil {
+		return nil, err
+	}
+	mm, err = p.handler.FindOne(ctx, mm)
+	if err != nil {
+		return nil, errors.New("membership not found")
+	}
+	return mm.toRoot(), nil
+}
+
+// MembershipUpdate is used to change the role of a user in a group
+func (p *MembershipService) MembershipUpdate(ctx context.Context, m *models.Membership) (*models.Membership, error) {
+	err := m.Validate("update")
+	if err != nil {
+		return nil, err
+	}
+	mm, err := newMembershipModel(&models.Membership{Id: m.Id})
+	if err != nil {
+		return nil, err
+	}
+	cur, err := p.handler.FindOne(ctx, mm)
+	if err != nil {
+		return nil, errors.New("membership not found")
+	}
+	cur.Role = m.Role
+	cur, err = p.handler.UpdateOne(ctx, &membershipModel{Id: cur.Id}, cur)
+	if err != nil {
+		return nil, err
+	}
+	return cur.toRoot(), nil
+}
+
+// MembershipDelete is used to remove a user from a group it is a member of
+func (p *MembershipService) MembershipDelete(ctx context.Context, m *models.Membership) (*models.Membership, error) {
+	if !m.CheckID("user_id") || !m.CheckID("group_id") {
+		return nil, errors.New("missing membership user id or group id")
+	}
+	mm, err := newMembershipModel(&models.Membership{UserId: m.UserId, GroupId: m.GroupId})
+	if err != nil {
+		return nil, err
+	}
+	mm, err = p.handler.DeleteOne(ctx, mm)
+	if err != nil {
+		return nil, errors.New("membership not found")
+	}
+	return mm.toRoot(), nil
+}
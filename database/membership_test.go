@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+)
+
+func Test_MembershipCreate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name       string             // The name of the test
+		existed    bool               // whether the user is already a member of the group
+		membership *models.Membership // The membership we want to create
+		wantRole   string             // The role we want the membership to have
+		wantErr    bool               // whether we want an error
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"default role",
+			false,
+			&models.Membership{UserId: "000000000000000000000012", GroupId: "000000000000000000000003"},
+			models.MemberRole,
+			false,
+		},
+		{
+			"custom role",
+			false,
+			&models.Membership{UserId: "000000000000000000000012", GroupId: "000000000000000000000003", Role: "project lead"},
+			"project lead",
+			false,
+		},
+		{
+			"already a member",
+			true,
+			&models.Membership{UserId: "000000000000000000000012", GroupId: "000000000000000000000003"},
+			"",
+			true,
+		},
+		{
+			"missing group",
+			false,
+			&models.Membership{UserId: "000000000000000000000012"},
+			"",
+			true,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestMembershipService()
+			if tt.existed {
+				_, err := testService.MembershipCreate(context.Background(), &models.Membership{UserId: "000000000000000000000012", GroupId: "000000000000000000000003"})
+				if err != nil {
+					t.Fatalf("MembershipService.MembershipCreate() setup error = %v", err)
+				}
+			}
+			_, err := testService.MembershipCreate(context.Background(), tt.membership)
+			if (err != nil) != tt.wantErr { // Asserting whether we get the correct wanted value
+				t.Fatalf("MembershipService.MembershipCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := testService.MembershipFind(context.Background(), tt.membership)
+			if err != nil {
+				t.Fatalf("MembershipService.MembershipFind() error = %v", err)
+			}
+			if got.Role != tt.wantRole {
+				t.Errorf("MembershipService.MembershipFind() role = %v, want %v", got.Role, tt.wantRole)
+			}
+		})
+	}
+}
+
+func Test_MembershipDelete(t *testing.T) {
+	testService := initTestMembershipService()
+	for _, groupId := range []string{"000000000000000000000002", "000000000000000000000003"} {
+		_, err := testService.MembershipCreate(context.Background(), &models.Membership{UserId: "000000000000000000000012", GroupId: groupId})
+		if err != nil {
+			t.Fatalf("MembershipService.MembershipCreate() error = %v", err)
+		}
+	}
+	_, err := testService.MembershipDelete(context.Background(), &models.Membership{UserId: "000000000000000000000012", GroupId: "000000000000000000000002"})
+	if err != nil {
+		t.Fatalf("MembershipService.MembershipDelete() error = %v", err)
+	}
+	got, err := testService.MembershipsFind(context.Background(), &models.Membership{UserId: "000000000000000000000012"})
+	if err != nil {
+		t.Fatalf("MembershipService.MembershipsFind() error = %v", err)
+	}
+	if len(got) != 1 || got[0].GroupId != "000000000000000000000003" {
+		t.Errorf("MembershipService.MembershipsFind() = %v, want only the membership of group 000000000000000000000003", got)
+	}
+}
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type refreshTokenModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	TokenHash    string             `bson:"token_hash,omitempty"`
+	FamilyId     primitive.ObjectID `bson:"family_id,omitempty"`
+	UserId       primitive.ObjectID `bson:"user_id,omitempty"`
+	GroupId      primitive.ObjectID `bson:"group_id,omitempty"`
+	ExpiresAt    time.Time          `bson:"expires_at,omitempty"`
+	RotatedAt    time.Time          `bson:"rotated_at,omitempty"`
+	RevokedAt    time.Time          `bson:"revoked_at,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+}
+
+// newRefreshTokenModel initializes a new pointer to a refreshTokenModel struct from a pointer to a JSON RefreshToken struct
+func newRefreshTokenModel(rt *models.RefreshToken) (rm *refreshTokenModel, err error) {
+	rm = &refreshTokenModel{
+		TokenHash:    rt.TokenHash,
+		ExpiresAt:    rt.ExpiresAt,
+		RotatedAt:    rt.RotatedAt,
+		RevokedAt:    rt.RevokedAt,
+		LastModified: rt.LastModified,
+		CreatedAt:    rt.CreatedAt,
+	}
+	if rt.Id != "" && rt.Id != "000000000000000000000000" {
+		rm.Id, err = primitive.ObjectIDFromHex(rt.Id)
+	}
+	if rt.FamilyId != "" && rt.FamilyId != "000000000000000000000000" {
+		rm.FamilyId, err = primitive.ObjectIDFromHex(rt.FamilyId)
+	}
+	if rt.UserId != "" && rt.UserId != "000000000000000000000000" {
+		rm.UserId, err = primitive.ObjectIDFromHex(rt.UserId)
+	}
+	if rt.GroupId != "" && rt.GroupId != "000000000000000000000000" {
+		rm.GroupId, err = primitive.ObjectIDFromHex(rt.GroupId)
+	}
+	return
+}
+
+// update the refreshTokenModel using an overwrite bson doc
+func (r *refreshTokenModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	rm := refreshTokenModel{}
+	err = bson.Unmarshal(data, &rm)
+	if len(rm.TokenHash) > 0 {
+		r.TokenHash = rm.TokenHash
+	}
+	if !rm.ExpiresAt.IsZero() {
+		r.ExpiresAt = rm.ExpiresAt
+	}
+	if !rm.RotatedAt.IsZero() {
+		r.RotatedAt = rm.RotatedAt
+	}
+	if !rm.RevokedAt.IsZero() {
+		r.RevokedAt = rm.RevokedAt
+	}
+	if !rm.LastModified.IsZero() {
+		r.LastModified = rm.LastModified
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the refreshTokenModel
+func (r *refreshTokenModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, r)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the refreshTokenModel
+func (r *refreshTokenModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	rm := refreshTokenModel{}
+	err = bson.Unmarshal(data, &rm)
+	if rm.Id.Hex() != "" && rm.Id.Hex() != "000000000000000000000000" {
+		return r.Id == rm.Id
+	}
+	if rm.TokenHash != "" {
+		return r.TokenHash == rm.TokenHash
+	}
+	if rm.FamilyId.Hex() != "" && rm.FamilyId.Hex() != "000000000000000000000000" {
+		return r.FamilyId == rm.FamilyId
+	}
+	if rm.UserId.Hex() != "" && rm.UserId.Hex() != "000000000000000000000000" {
+		return r.UserId == rm.UserId
+	}
+	return false
+}
+
+// getID returns the unique identifier of the refreshTokenModel
+func (r *refreshTokenModel) getID() (id interface{}) {
+	return r.Id
+}
+
+// getDeletedAt returns the zero time since refresh tokens are revoked rather than soft deleted
+func (r *refreshTokenModel) getDeletedAt() time.Time {
+	return time.Time{}
+}
+
+// addTimeStamps updates a refreshTokenModel struct with a timestamp
+func (r *refreshTokenModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	r.LastModified = currentTime
+	if newRecord {
+		r.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a refreshTokenModel has a value assigned for Id, if no value a new one is generated and assigned
+func (r *refreshTokenModel) addObjectID() {
+	if r.Id.Hex() == "" || r.Id.Hex() == "000000000000000000000000" {
+		r.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a refreshTokenModel struct postProcess to do things such as validating required fields
+func (r *refreshTokenModel) postProcess() (err error) {
+	if r.TokenHash == "" {
+		err = errors.New("refresh token record does not have a TokenHash")
+	}
+	return
+}
+
+// toDoc converts the bson refreshTokenModel into a bson.D
+func (r *refreshTokenModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(r)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the refreshTokenModel data
+func (r *refreshTokenModel) bsonFilter() (doc bson.D, err error) {
+	if r.Id.Hex() != "" && r.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", r.Id}}
+	} else if r.TokenHash != "" {
+		doc = bson.D{{"token_hash", r.TokenHash}}
+	} else if r.FamilyId.Hex() != "" && r.FamilyId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"family_id", r.FamilyId}}
+	} else if r.UserId.Hex() != "" && r.UserId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"user_id", r.UserId}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the refreshTokenModel data
+func (r *refreshTokenModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := r.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a RefreshToken JSON struct from a pointer to a BSON refreshTokenModel
+func (r *refreshTokenModel) toRoot() *models.RefreshToken {
+	return &models.RefreshToken{
+		Id:           r.Id.Hex(),
+		TokenHash:    r.TokenHash,
+		FamilyId:     r.FamilyId.Hex(),
+		UserId:       r.UserId.Hex(),
+		GroupId:      r.GroupId.Hex(),
+		ExpiresAt:    r.ExpiresAt,
+		RotatedAt:    r.RotatedAt,
+		RevokedAt:    r.RevokedAt,
+		LastModified: r.LastModified,
+		CreatedAt:    r.CreatedAt,
+	}
+}
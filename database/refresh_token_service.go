@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+// RefreshTokenService is used by the app to manage all refresh token related controllers and functionality
+type RefreshTokenService struct {
+	collection DBCollection
+	db         DBClient
+	handler    *DBHandler[*refreshTokenModel]
+}
+
+// NewRefreshTokenService is an exported function used to initialize a new RefreshTokenService struct
+func NewRefreshTokenService(db DBClient, handler *DBHandler[*refreshTokenModel]) *RefreshTokenService {
+	collection := db.GetCollection("refresh_tokens")
+	return &RefreshTokenService{collection, db, handler}
+}
+
+// RefreshTokenCreate is used to issue a new refresh token, starting a new token family if one is not specified
+func (p *RefreshTokenService) RefreshTokenCreate(ctx context.Context, rt *models.RefreshToken) (*models.RefreshToken, error) {
+	if !rt.CheckID("user_id") {
+		return nil, errors.New("missing refresh token user id")
+	}
+	if rt.ExpiresAt.IsZero() {
+		return nil, errors.New("missing refresh token expiration")
+	}
+	err := rt.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	rm, err := newRefreshTokenModel(rt)
+	if err != nil {
+		return nil, err
+	}
+	rm.addObjectID()
+	if !rt.CheckID("family_id") {
+		rm.FamilyId = rm.Id
+	}
+	rm, err = p.handler.InsertOne(ctx, rm)
+	if err != nil {
+		return nil, err
+	}
+	created := rm.toRoot()
+	created.Token = rt.Token
+	return created, nil
+}
+
+// RefreshTokenRotate exchanges a refresh token for a new one in the same token family
+// Presenting a refresh token that was already rotated is treated as reuse and revokes the entire family
+func (p *RefreshTokenService) RefreshTokenRotate(ctx context.Context, token string, expiresAt time.Time) (*models.RefreshToken, error) {
+	rm, err := p.handler.FindOne(ctx, &refreshTokenModel{TokenHash: models.HashToken(token)})
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+	cur := rm.toRoot()
+	if !cur.RotatedAt.IsZero() {
+		err = p.revokeFamily(ctx, rm)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New("refresh token reuse detected")
+	}
+	err = cur.Validate()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := p.markRotated(ctx, rm)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated { // a concurrent refresh presented the same token first
+		err = p.revokeFamily(ctx, rm)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New("refresh token reuse detected")
+	}
+	return p.RefreshTokenCreate(ctx, &models.RefreshToken{UserId: cur.UserId, GroupId: cur.GroupId, FamilyId: cur.FamilyId, ExpiresAt: expiresAt})
+}
+
+// markRotated stamps the rotated_at field of a refresh token that was not rotated yet, it reports whether this call
+// rotated it, so that only one of several concurrent refreshes with the same token succeeds
+func (p *RefreshTokenService) markRotated(ctx context.Context, rm *refreshTokenModel) (bool, error) {
+	return p.handler.UpdateIf(ctx, bson.D{{"_id", rm.Id}, {"rotated_at", nil}}, &refreshTokenModel{RotatedAt: time.Now().UTC()})
+}
+
+// RefreshTokenRevoke is used during sign-out to revoke the token family of a refresh token
+func (p *RefreshTokenService) RefreshTokenRevoke(ctx context.Context, token string) error {
+	rm, err := p.handler.FindOne(ctx, &refreshTokenModel{TokenHash: models.HashToken(token)})
+	if err != nil {
+		return errors.New("invalid refresh token")
+	}
+	return p.revokeFamily(ctx, rm)
+}
+
+// revokeFamily revokes every refresh token sharing a family with the input refreshTokenModel
+func (p *RefreshTokenService) revokeFamily(ctx context.Context, rm *refreshTokenModel) error {
+	return p.handler.UpdateMany(ctx, &refreshTokenModel{FamilyId: rm.FamilyId}, &refreshTokenModel{RevokedAt: time.Now().UTC()})
+}
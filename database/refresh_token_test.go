@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+	"time"
+)
+
+func Test_RefreshTokenCreate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string               // The name of the test
+		wantErr bool                 // whether we want an error.
+		token   *models.RefreshToken // The input of the test
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			false,
+			&models.RefreshToken{UserId: "000000000000000000000012", ExpiresAt: time.Now().UTC().Add(time.Hour)},
+		},
+		{
+			"missing user",
+			true,
+			&models.RefreshToken{ExpiresAt: time.Now().UTC().Add(time.Hour)},
+		},
+		{
+			"missing expiration",
+			true,
+			&models.RefreshToken{UserId: "000000000000000000000012"},
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestRefreshTokenService()
+			got, err := testService.RefreshTokenCreate(context.Background(), tt.token)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RefreshTokenService.RefreshTokenCreate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (got.Token == "" || got.FamilyId != got.Id) { // Asserting whether we get the correct wanted value
+				t.Errorf("RefreshTokenService.RefreshTokenCreate() = %v, want a new token family", got)
+			}
+		})
+	}
+}
+
+func Test_RefreshTokenRotate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string // The name of the test
+		wantErr bool   // whether we want an error.
+		expired bool   // whether the issued token is already expired
+		reuse   bool   // whether the issued token is presented a second time
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			false,
+			false,
+			false,
+		},
+		{
+			"expired",
+			true,
+			true,
+			false,
+		},
+		{
+			"reuse detected",
+			true,
+			false,
+			true,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestRefreshTokenService()
+			expiresAt := time.Now().UTC().Add(time.Hour)
+			if tt.expired {
+				expiresAt = time.Now().UTC().Add(-time.Hour)
+			}
+			issued, err := testService.RefreshTokenCreate(context.Background(), &models.RefreshToken{UserId: "000000000000000000000012", ExpiresAt: expiresAt})
+			if err != nil {
+				t.Errorf("RefreshTokenService.RefreshTokenCreate() error = %v", err)
+				return
+			}
+			got, err := testService.RefreshTokenRotate(context.Background(), issued.Token, time.Now().UTC().Add(time.Hour))
+			if tt.reuse {
+				if err != nil {
+					t.Errorf("RefreshTokenService.RefreshTokenRotate() error = %v", err)
+					return
+				}
+				_, err = testService.RefreshTokenRotate(context.Background(), issued.Token, time.Now().UTC().Add(time.Hour))
+				// the rotated token's family must be revoked once reuse is detected
+				if _, rErr := testService.RefreshTokenRotate(context.Background(), got.Token, time.Now().UTC().Add(time.Hour)); rErr == nil {
+					t.Errorf("RefreshTokenService.RefreshTokenRotate() family was not revoked after reuse")
+				}
+			}
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RefreshTokenService.RefreshTokenRotate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (got.FamilyId != issued.FamilyId || got.Token == issued.Token) { // Asserting whether we get the correct wanted value
+				t.Errorf("RefreshTokenService.RefreshTokenRotate() = %v, want a new token in family %v", got, issued.FamilyId)
+			}
+		})
+	}
+}
+
+func Test_RefreshTokenMarkRotated(t *testing.T) {
+	testService := initTestRefreshTokenService()
+	issued, err := testService.RefreshTokenCreate(context.Background(), &models.RefreshToken{UserId: "000000000000000000000012", ExpiresAt: time.Now().UTC().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("RefreshTokenService.RefreshTokenCreate() error = %v", err)
+	}
+	// Two refreshes that both read the token before either rotated it
+	rm, err := testService.handler.FindOne(context.Background(), &refreshTokenModel{TokenHash: models.HashToken(issued.Token)})
+	if err != nil {
+		t.Fatalf("DBHandler.FindOne() error = %v", err)
+	}
+	if rotated, err := testService.markRotated(context.Background(), rm); !rotated || err != nil {
+		t.Errorf("RefreshTokenService.markRotated() = %v, %v, want the first refresh to rotate the token", rotated, err)
+	}
+	if rotated, err := testService.markRotated(context.Background(), rm); rotated || err != nil {
+		t.Errorf("RefreshTokenService.markRotated() = %v, %v, want the second refresh to lose", rotated, err)
+	}
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// roleModel structures a role BSON document to save in a roles collection
+type roleModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	Name         string             `bson:"name,omitempty"`
+	GroupId      primitive.ObjectID `bson:"group_id,omitempty"`
+	Permissions  []string           `bson:"permissions,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newRoleModel initializes a new pointer to a roleModel struct from a pointer to a JSON Role struct
+func newRoleModel(r *models.Role) (rm *roleModel, err error) {
+	rm = &roleModel{
+		Name:         r.Name,
+		Permissions:  r.Permissions,
+		LastModified: r.LastModified,
+		CreatedAt:    r.CreatedAt,
+		DeletedAt:    r.DeletedAt,
+	}
+	if r.Id != "" && r.Id != "000000000000000000000000" {
+		rm.Id, err = primitive.ObjectIDFromHex(r.Id)
+	}
+	if r.GroupId != "" && r.GroupId != "000000000000000000000000" {
+		rm.GroupId, err = primitive.ObjectIDFromHex(r.GroupId)
+	}
+	return
+}
+
+// update the roleModel using an overwrite bson doc
+func (r *roleModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	rm := roleModel{}
+	err = bson.Unmarshal(data, &rm)
+	if len(rm.Name) > 0 {
+		r.Name = rm.Name
+	}
+	if len(rm.Permissions) > 0 {
+		r.Permissions = rm.Permissions
+	}
+	if !rm.LastModified.IsZero() {
+		r.LastModified = rm.LastModified
+	}
+	if !rm.DeletedAt.IsZero() {
+		r.DeletedAt = rm.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the roleModel
+func (r *roleModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, r)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the roleModel
+func (r *roleModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	rm := roleModel{}
+	err = bson.Unmarshal(data, &rm)
+	if rm.Id.Hex() != "" && rm.Id.Hex() != "000000000000000000000000" {
+		return r.Id == rm.Id
+	}
+	if rm.GroupId.Hex() != "" && rm.GroupId.Hex() != "000000000000000000000000" {
+		return r.GroupId == rm.GroupId && (rm.Name == "" || r.Name == rm.Name)
+	}
+	return false
+}
+
+// getID returns the unique identifier of the roleModel
+func (r *roleModel) getID() (id interface{}) {
+	return r.Id
+}
+
+// getDeletedAt returns the time the roleModel was soft deleted at
+func (r *roleModel) getDeletedAt() time.Time {
+	return r.DeletedAt
+}
+
+// addTimeStamps updates a roleModel struct with a timestamp
+func (r *roleModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	r.LastModified = currentTime
+	if newRecord {
+		r.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a roleModel has a value assigned for Id, if no value a new one is generated and assigned
+func (r *roleModel) addObjectID() {
+	if r.Id.Hex() == "" || r.Id.Hex() == "000000000000000000000000" {
+		r.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a roleModel struct postProcess to do things such as validating required fields
+func (r *roleModel) postProcess() (err error) {
+	if r.Name == "" {
+		err = errors.New("role record does not have a name")
+	}
+	return
+}
+
+// toDoc converts the bson roleModel into a bson.D
+func (r *roleModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(r)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the roleModel data
+// A group id filter is narrowed down to a single role when a name is set
+func (r *roleModel) bsonFilter() (doc bson.D, err error) {
+	if r.Id.Hex() != "" && r.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", r.Id}}
+	} else if r.GroupId.Hex() != "" && r.GroupId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"group_id", r.GroupId}}
+		if r.Name != "" {
+			doc = append(doc, bson.E{Key: "name", Value: r.Name})
+		}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the roleModel data
+func (r *roleModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := r.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a Role JSON struct from a pointer to a BSON roleModel
+func (r *roleModel) toRoot() *models.Role {
+	permissions := r.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return &models.Role{
+		Id:           r.Id.Hex(),
+		Name:         r.Name,
+		GroupId:      r.GroupId.Hex(),
+		Permissions:  permissions,
+		LastModified: r.LastModified,
+		CreatedAt:    r.CreatedAt,
+		DeletedAt:    r.DeletedAt,
+	}
+}
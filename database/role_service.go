@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// RoleService is used by the app to manage all role related controllers and functionality
+type RoleService struct {
+	collection DBCollection
+	db         DBClient
+	handler    *DBHandler[*roleModel]
+}
+
+// NewRoleService is an exported function used to initialize a new RoleService struct
+func NewRoleService(db DBClient, handler *DBHandler[*roleModel]) *RoleService {
+	collection := db.GetCollection("roles")
+	return &RoleService{collection, db, handler}
+}
+
+// findScoped finds an active role by id, checking that it belongs to the input Role's group when one is specified
+func (p *RoleService) findScoped(ctx context.Context, r *models.Role) (*roleModel, error) {
+	rm, err := newRoleModel(r)
+	if err != nil {
+		return nil, err
+	}
+	found, err := p.handler.FindOne(ctx, &roleModel{Id: rm.Id})
+	if err != nil {
+		return nil, errors.New("role not found")
+	}
+	if r.CheckID("group_id") && found.GroupId != rm.GroupId {
+		return nil, errors.New("role not found")
+	}
+	return found, nil
+}
+
+// RoleCreate is used to create a new role, role names are unique within a group
+func (p *RoleService) RoleCreate(ctx context.Context, r *models.Role) (*models.Role, error) {
+	err := r.Validate("create")
+	if err != nil {
+		return nil, err
+	}
+	rm, err := newRoleModel(r)
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.handler.FindOne(ctx, &roleModel{GroupId: rm.GroupId, Name: rm.Name})
+	if err == nil {
+		return nil, errors.New("role name is already in use")
+	}
+	rm, err = p.handler.InsertOne(ctx, rm)
+	if err != nil {
+		return nil, err
+	}
+	return rm.toRoot(), nil
+}
+
+// RolesFind is used to find all of the active roles of a group
+func (p *RoleService) RolesFind(ctx context.Context, r *models.Role) ([]*models.Role, error) {
+	var roles []*models.Role
+	if !r.CheckID("group_id") {
+		return roles, errors.New("missing role group id")
+	}
+	rm, err := newRoleModel(&models.Role{GroupId: r.GroupId})
+	if err != nil {
+		return roles, err
+	}
+	rms, err := p.handler.FindMany(ctx, rm)
+	if err != nil {
+		return roles, err
+	}
+	for _, m := range rms {
+		roles = append(roles, m.toRoot())
+	}
+	return roles, nil
+}
+
+// RoleFind is used to find an active role by its id, or by its group id and name
+func (p *RoleService) RoleFind(ctx context.Context, r *models.Role) (*models.Role, error) {
+	if r.CheckID("id") {
+		rm, err := p.findScoped(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		return rm.toRoot(), nil
+	}
+	if !r.CheckID("group_id") || r.Name == "" {
+		return nil, errors.New("missing valid query filter")
+	}
+	rm, err := newRoleModel(&models.Role{GroupId: r.GroupId, Name: r.Name})
+	if err != nil {
+		return nil, err
+	}
+	rm, err = p.handler.FindOne(ctx, rm)
+	if err != nil {
+		return nil, errors.New("role not found")
+	}
+	return rm.toRoot(), nil
+}
+
+// RoleUpdate is used to rename a role or replace its permissions, a nil Permissions slice keeps the current ones
+func (p *RoleService) RoleUpdate(ctx context.Context, r *models.Role) (*models.Role, error) {
+	err := r.Validate("update")
+	if err != nil {
+		return nil, err
+	}
+	cur, err := p.findScoped(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Name != "" && r.Name != cur.Name {
+		_, err = p.handler.FindOne(ctx, &roleModel{GroupId: cur.GroupId, Name: r.Name})
+		if err == nil {
+			return nil, errors.New("role name is already in use")
+		}
+		cur.Name = r.Name
+	}
+	if r.Permissions != nil {
+		cur.Permissions = r.Permissions
+	}
+	cur, err = p.handler.UpdateOne(ctx, &roleModel{Id: cur.Id}, cur)
+	if err != nil {
+		return nil, err
+	}
+	if r.Permissions != nil && len(r.Permissions) == 0 {
+		err = p.handler.UnsetFields(ctx, &roleModel{Id: cur.Id}, "permissions")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cur.toRoot(), nil
+}
+
+// RoleDelete is used to soft delete a role, users that are still assigned the role are no longer granted its permissions
+func (p *RoleService) RoleDelete(ctx context.Context, r *models.Role) (*models.Role, error) {
+	cur, err := p.findScoped(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	rm, err := p.handler.DeleteOne(ctx, &roleModel{Id: cur.Id})
+	if err != nil {
+		return nil, err
+	}
+	return rm.toRoot(), nil
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+)
+
+func Test_RoleCreate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string       // The name of the test
+		existed bool         // whether a role with the same name already exists in the group
+		role    *models.Role // The role we want to create
+		wantErr bool         // whether we want an error
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			false,
+			&models.Role{Name: "project lead", GroupId: "000000000000000000000002", Permissions: []string{models.PermTasksUpdateAny}},
+			false,
+		},
+		{
+			"name in use",
+			true,
+			&models.Role{Name: "project lead", GroupId: "000000000000000000000002", Permissions: []string{models.PermTasksUpdateAny}},
+			true,
+		},
+		{
+			"name in use by another group",
+			true,
+			&models.Role{Name: "project lead", GroupId: "000000000000000000000003", Permissions: []string{models.PermTasksUpdateAny}},
+			false,
+		},
+		{
+			"reserved name",
+			false,
+			&models.Role{Name: models.AdminRole, GroupId: "000000000000000000000002"},
+			true,
+		},
+		{
+			"unknown permission",
+			false,
+			&models.Role{Name: "project lead", GroupId: "000000000000000000000002", Permissions: []string{"tasks.everything"}},
+			true,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestRoleService()
+			if tt.existed {
+				_, err := testService.RoleCreate(context.Background(), &models.Role{Name: "project lead", GroupId: "000000000000000000000002"})
+				if err != nil {
+					t.Fatalf("RoleService.RoleCreate() setup error = %v", err)
+				}
+			}
+			got, err := testService.RoleCreate(context.Background(), tt.role)
+			if (err != nil) != tt.wantErr { // Asserting whether we get the correct wanted value
+				t.Fatalf("RoleService.RoleCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			found, err := testService.RoleFind(context.Background(), &models.Role{GroupId: tt.role.GroupId, Name: tt.role.Name})
+			if err != nil {
+				t.Fatalf("RoleService.RoleFind() error = %v", err)
+			}
+			if found.Id != got.Id || !found.HasPermission(models.PermTasksUpdateAny) {
+				t.Errorf("RoleService.RoleFind() = %v, want %v", found, got)
+			}
+		})
+	}
+}
+
+func Test_RoleUpdate(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name        string       // The name of the test
+		update      *models.Role // The update we want to make to the role
+		permissions []string     // The permissions we want the role to have afterwards
+		wantErr     bool         // whether we want an error
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"replace permissions",
+			&models.Role{Permissions: []string{models.PermTasksReadAny, models.PermUsersUnlock}},
+			[]string{models.PermTasksReadAny, models.PermUsersUnlock},
+			false,
+		},
+		{
+			"clear permissions",
+			&models.Role{Permissions: []string{}},
+			[]string{},
+			false,
+		},
+		{
+			"rename keeps permissions",
+			&models.Role{Name: "team lead"},
+			[]string{models.PermTasksUpdateAny},
+			false,
+		},
+		{
+			"wrong group",
+			&models.Role{GroupId: "000000000000000000000003", Permissions: []string{}},
+			nil,
+			true,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestRoleService()
+			role, err := testService.RoleCreate(context.Background(), &models.Role{Name: "project lead", GroupId: "000000000000000000000002", Permissions: []string{models.PermTasksUpdateAny}})
+			if err != nil {
+				t.Fatalf("RoleService.RoleCreate() error = %v", err)
+			}
+			tt.update.Id = role.Id
+			_, err = testService.RoleUpdate(context.Background(), tt.update)
+			if (err != nil) != tt.wantErr { // Asserting whether we get the correct wanted value
+				t.Fatalf("RoleService.RoleUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := testService.RoleFind(context.Background(), &models.Role{Id: role.Id})
+			if err != nil {
+				t.Fatalf("RoleService.RoleFind() error = %v", err)
+			}
+			if len(got.Permissions) != len(tt.permissions) {
+				t.Fatalf("RoleService.RoleFind() permissions = %v, want %v", got.Permissions, tt.permissions)
+			}
+			for _, p := range tt.permissions {
+				if !got.HasPermission(p) {
+					t.Errorf("RoleService.RoleFind() permissions = %v, want %v", got.Permissions, tt.permissions)
+				}
+			}
+		})
+	}
+}
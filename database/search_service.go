@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SearchService is used by the app to rank the tasks, users and groups that match a search query
+type SearchService struct {
+	db           DBClient
+	taskHandler  *DBHandler[*taskModel]
+	userHandler  *DBHandler[*userModel]
+	groupHandler *DBHandler[*groupModel]
+}
+
+// NewSearchService is an exported function used to initialize a new SearchService struct
+func NewSearchService(db DBClient, tHandler *DBHandler[*taskModel], uHandler *DBHandler[*userModel], gHandler *DBHandler[*groupModel]) *SearchService {
+	return &SearchService{db, tHandler, uHandler, gHandler}
+}
+
+// searchTaskFilters returns the filters of the tasks in the scope of a User, a User that can only read its own tasks
+// also finds the tasks assigned to it
+func searchTaskFilters(scope *models.User) ([]bson.D, error) {
+	filter := &models.Task{}
+	filter.LoadScope(scope, models.PermTasksReadAny)
+	tm, err := newTaskModel(filter)
+	if err != nil {
+		return nil, err
+	}
+	var f bson.D
+	if !tm.GroupId.IsZero() {
+		f = bson.D{{"group_id", tm.GroupId}}
+	}
+	if tm.UserId.IsZero() || scope.RootAdmin {
+		return []bson.D{f}, nil
+	}
+	owned := append(bson.D{}, f...)
+	assigned := append(bson.D{}, f...)
+	return []bson.D{
+		append(owned, bson.E{Key: "user_id", Value: tm.UserId}),
+		append(assigned, bson.E{Key: "assignee_id", Value: tm.UserId}),
+	}, nil
+}
+
+// Search is used to find the tasks, users and groups in the scope of a User that match a text query, best match first
+// The scope follows the User returned by the find scope of a request, a zero GroupId searches every group
+func (p *SearchService) Search(ctx context.Context, query string, scope *models.User, limit int64) ([]*models.SearchHit, error) {
+	var hits []*models.SearchHit
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return hits, errors.New("missing search query")
+	}
+	if utf8.RuneCountInString(query) > models.MaxSearchQueryLength {
+		return hits, errors.New("search query is too long")
+	}
+	if limit < 1 || limit > models.MaxSearchLimit {
+		return hits, errors.New("invalid search limit")
+	}
+	var groupFilter, userFilter bson.D
+	if scope.GroupId != "" {
+		gId, err := primitive.ObjectIDFromHex(scope.GroupId)
+		if err != nil {
+			return hits, errors.New("invalid group id")
+		}
+		groupFilter = bson.D{{"_id", gId}}
+		userFilter = bson.D{{"group_id", gId}}
+	}
+	taskFilters, err := searchTaskFilters(scope)
+	if err != nil {
+		return hits, err
+	}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, f := range taskFilters {
+		tms, scores, err := p.taskHandler.SearchText(ctx, f, query, limit)
+		if err != nil {
+			return hits, err
+		}
+		for i, tm := range tms {
+			if !seen[tm.Id] {
+				seen[tm.Id] = true
+				hits = append(hits, &models.SearchHit{Type: models.SearchTask, Id: tm.Id.Hex(), Score: scores[i], Task: tm.toRoot()})
+			}
+		}
+	}
+	ums, scores, err := p.userHandler.SearchText(ctx, userFilter, query, limit)
+	if err != nil {
+		return hits, err
+	}
+	for i, um := range ums {
+		hits = append(hits, &models.SearchHit{Type: models.SearchUser, Id: um.Id.Hex(), Score: scores[i], User: um.toRoot()})
+	}
+	gms, scores, err := p.groupHandler.SearchText(ctx, groupFilter, query, limit)
+	if err != nil {
+		return hits, err
+	}
+	for i, gm := range gms {
+		hits = append(hits, &models.SearchHit{Type: models.SearchGroup, Id: gm.Id.Hex(), Score: scores[i], Group: gm.toRoot()})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if int64(len(hits)) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+)
+
+func Test_Search(t *testing.T) {
+	root := &models.User{RootAdmin: true}
+	group := &models.User{GroupId: "000000000000000000000002", Role: "member"}
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string       // The name of the test
+		wantErr bool         // whether we want an error.
+		query   string       // The search query
+		scope   *models.User // The scope of the requester
+		limit   int64        // The maximum number of hits
+		want    []string     // The wanted ids of the hits, best match first
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"task name", false, "task1", group, 20, []string{"000000000000000000000022"}},
+		{"root emails", false, "email", root, 20, []string{"000000000000000000000011", "000000000000000000000012", "000000000000000000000013"}},
+		{"group emails", false, "email", group, 20, []string{"000000000000000000000012", "000000000000000000000013"}},
+		{"ranked", false, "test2 email", root, 20, []string{"000000000000000000000002", "000000000000000000000012", "000000000000000000000011", "000000000000000000000013"}},
+		{"root groups", false, "test3", root, 20, []string{"000000000000000000000003", "000000000000000000000013"}},
+		{"out of group scope", false, "test3", group, 20, []string{"000000000000000000000013"}},
+		{"no match", false, "test1", group, 20, nil},
+		{"limit", false, "email", root, 1, []string{"000000000000000000000011"}},
+		{"own and assigned tasks", false, "task1 task2", &models.User{Id: "000000000000000000000012", GroupId: "000000000000000000000002", Role: "member"}, 20, []string{"000000000000000000000023", "000000000000000000000022"}},
+		{"own tasks", false, "task1 task2", &models.User{Id: "000000000000000000000013", GroupId: "000000000000000000000002", Role: "member"}, 20, []string{"000000000000000000000022"}},
+		{"missing query", true, " ", root, 20, nil},
+		{"invalid limit", true, "email", root, 0, nil},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestSearch()
+			got, err := testService.Search(context.Background(), tt.query, tt.scope, tt.limit)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SearchService.Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var ids []string
+			for _, hit := range got {
+				ids = append(ids, hit.Id)
+			}
+			if len(ids) != len(tt.want) { // Asserting whether we get the correct wanted value
+				t.Errorf("SearchService.Search() = %v, want %v", ids, tt.want)
+				return
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Errorf("SearchService.Search() = %v, want %v", ids, tt.want)
+					return
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// taskHistoryModel structures a task history BSON document to save in a task_history collection
+type taskHistoryModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	TaskId       primitive.ObjectID `bson:"task_id,omitempty"`
+	GroupId      primitive.ObjectID `bson:"group_id,omitempty"`
+	ActorId      primitive.ObjectID `bson:"actor_id,omitempty"`
+	From         models.TaskStatus  `bson:"from,omitempty"`
+	To           models.TaskStatus  `bson:"to,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newTaskHistoryModel initializes a new pointer to a taskHistoryModel struct from a pointer to a JSON TaskHistory struct
+func newTaskHistoryModel(h *models.TaskHistory) (hm *taskHistoryModel, err error) {
+	hm = &taskHistoryModel{
+		From:         h.From,
+		To:           h.To,
+		LastModified: h.LastModified,
+		CreatedAt:    h.CreatedAt,
+		DeletedAt:    h.DeletedAt,
+	}
+	if h.Id != "" && h.Id != "000000000000000000000000" {
+		hm.Id, err = primitive.ObjectIDFromHex(h.Id)
+	}
+	if h.TaskId != "" && h.TaskId != "000000000000000000000000" {
+		hm.TaskId, err = primitive.ObjectIDFromHex(h.TaskId)
+	}
+	if h.GroupId != "" && h.GroupId != "000000000000000000000000" {
+		hm.GroupId, err = primitive.ObjectIDFromHex(h.GroupId)
+	}
+	if h.ActorId != "" && h.ActorId != "000000000000000000000000" {
+		hm.ActorId, err = primitive.ObjectIDFromHex(h.ActorId)
+	}
+	return
+}
+
+// update the taskHistoryModel using an overwrite bson doc
+func (h *taskHistoryModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	hm := taskHistoryModel{}
+	err = bson.Unmarshal(data, &hm)
+	if !hm.LastModified.IsZero() {
+		h.LastModified = hm.LastModified
+	}
+	if !hm.DeletedAt.IsZero() {
+		h.DeletedAt = hm.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the taskHistoryModel
+func (h *taskHistoryModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, h)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the taskHistoryModel
+func (h *taskHistoryModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	hm := taskHistoryModel{}
+	err = bson.Unmarshal(data, &hm)
+	if hm.Id.Hex() != "" && hm.Id.Hex() != "000000000000000000000000" {
+		return h.Id == hm.Id
+	}
+	if hm.TaskId.Hex() != "" && hm.TaskId.Hex() != "000000000000000000000000" {
+		return h.TaskId == hm.TaskId
+	}
+	if hm.GroupId.Hex() != "" && hm.GroupId.Hex() != "000000000000000000000000" {
+		return h.GroupId == hm.GroupId
+	}
+	return false
+}
+
+// getID returns the unique identifier of the taskHistoryModel
+func (h *taskHistoryModel) getID() (id interface{}) {
+	return h.Id
+}
+
+// getDeletedAt returns the time the taskHistoryModel was soft deleted at
+func (h *taskHistoryModel) getDeletedAt() time.Time {
+	return h.DeletedAt
+}
+
+// addTimeStamps updates a taskHistoryModel struct with a timestamp
+func (h *taskHistoryModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	h.LastModified = currentTime
+	if newRecord {
+		h.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a taskHistoryModel has a value assigned for Id, if no value a new one is generated and assigned
+func (h *taskHistoryModel) addObjectID() {
+	if h.Id.Hex() == "" || h.Id.Hex() == "000000000000000000000000" {
+		h.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a taskHistoryModel struct postProcess to do things such as validating required fields
+func (h *taskHistoryModel) postProcess() (err error) {
+	if h.TaskId.IsZero() || h.To == "" {
+		err = errors.New("task history record does not have a TaskId and To status")
+	}
+	return
+}
+
+// toDoc converts the bson taskHistoryModel into a bson.D
+func (h *taskHistoryModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(h)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the taskHistoryModel data
+func (h *taskHistoryModel) bsonFilter() (doc bson.D, err error) {
+	if h.Id.Hex() != "" && h.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", h.Id}}
+	} else if h.TaskId.Hex() != "" && h.TaskId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"task_id", h.TaskId}}
+	} else if h.GroupId.Hex() != "" && h.GroupId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"group_id", h.GroupId}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the taskHistoryModel data
+func (h *taskHistoryModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := h.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a TaskHistory JSON struct from a pointer to a BSON taskHistoryModel
+func (h *taskHistoryModel) toRoot() *models.TaskHistory {
+	return &models.TaskHistory{
+		Id:           h.Id.Hex(),
+		TaskId:       h.TaskId.Hex(),
+		GroupId:      h.GroupId.Hex(),
+		ActorId:      h.ActorId.Hex(),
+		From:         h.From,
+		To:           h.To,
+		LastModified: h.LastModified,
+		CreatedAt:    h.CreatedAt,
+		DeletedAt:    h.DeletedAt,
+	}
+}
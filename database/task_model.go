@@ -10,16 +10,24 @@ import (
 
 // taskModel structures a group BSON document to save in a users collection
 type taskModel struct {
-	Id           primitive.ObjectID `bson:"_id,omitempty"`
-	Name         string             `bson:"name,omitempty"`
-	Status       models.TaskStatus  `bson:"status,omitempty"`
-	Due          time.Time          `bson:"due,omitempty"`
-	Description  string             `bson:"description,omitempty"`
-	UserId       primitive.ObjectID `bson:"user_id,omitempty"`
-	GroupId      primitive.ObjectID `bson:"group_id,omitempty"`
-	LastModified time.Time          `bson:"last_modified,omitempty"`
-	CreatedAt    time.Time          `bson:"created_at,omitempty"`
-	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+	Id           primitive.ObjectID   `bson:"_id,omitempty"`
+	Name         string               `bson:"name,omitempty"`
+	Status       models.TaskStatus    `bson:"status,omitempty"`
+	Priority     models.TaskPriority  `bson:"priority,omitempty"`
+	Due          time.Time            `bson:"due,omitempty"`
+	Description  string               `bson:"description,omitempty"`
+	Labels       []string             `bson:"labels,omitempty"`
+	Estimate     float64              `bson:"estimate,omitempty"`
+	UserId       primitive.ObjectID   `bson:"user_id,omitempty"`
+	AssigneeId   primitive.ObjectID   `bson:"assignee_id,omitempty"`
+	GroupId      primitive.ObjectID   `bson:"group_id,omitempty"`
+	ParentId     primitive.ObjectID   `bson:"parent_id,omitempty"`
+	BlockedBy    []primitive.ObjectID `bson:"blocked_by,omitempty"`
+	SeriesId     primitive.ObjectID   `bson:"series_id,omitempty"`
+	CompletedAt  time.Time            `bson:"completed_at,omitempty"`
+	LastModified time.Time            `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time            `bson:"created_at,omitempty"`
+	DeletedAt    time.Time            `bson:"deleted_at,omitempty"`
 }
 
 // newTaskModel initializes a new pointer to a userModel struct from a pointer to a JSON User struct
@@ -27,8 +35,12 @@ func newTaskModel(u *models.Task) (um *taskModel, err error) {
 	um = &taskModel{
 		Name:         u.Name,
 		Status:       u.Status,
+		Priority:     u.Priority,
 		Due:          u.Due,
 		Description:  u.Description,
+		Labels:       u.Labels,
+		Estimate:     u.Estimate,
+		CompletedAt:  u.CompletedAt,
 		LastModified: u.LastModified,
 		CreatedAt:    u.CreatedAt,
 		DeletedAt:    u.DeletedAt,
@@ -42,6 +54,22 @@ func newTaskModel(u *models.Task) (um *taskModel, err error) {
 	if u.UserId != "" && u.UserId != "000000000000000000000000" {
 		um.UserId, err = primitive.ObjectIDFromHex(u.UserId)
 	}
+	if u.AssigneeId != "" && u.AssigneeId != "000000000000000000000000" {
+		um.AssigneeId, err = primitive.ObjectIDFromHex(u.AssigneeId)
+	}
+	if u.ParentId != "" && u.ParentId != "000000000000000000000000" {
+		um.ParentId, err = primitive.ObjectIDFromHex(u.ParentId)
+	}
+	if u.SeriesId != "" && u.SeriesId != "000000000000000000000000" {
+		um.SeriesId, err = primitive.ObjectIDFromHex(u.SeriesId)
+	}
+	for _, blocker := range u.BlockedBy {
+		blockerId, bErr := primitive.ObjectIDFromHex(blocker)
+		if bErr != nil {
+			return um, bErr
+		}
+		um.BlockedBy = append(um.BlockedBy, blockerId)
+	}
 	return
 }
 
@@ -59,24 +87,71 @@ func (u *taskModel) update(doc interface{}) (err error) {
 	if len(um.Status) > 0 {
 		u.Status = um.Status
 	}
+	if len(um.Priority) > 0 {
+		u.Priority = um.Priority
+	}
 	if !um.Due.IsZero() {
 		u.Due = um.Due
 	}
 	if len(um.Description) > 0 {
 		u.Description = um.Description
 	}
+	if len(um.Labels) > 0 {
+		u.Labels = um.Labels
+	}
+	if um.Estimate != 0 {
+		u.Estimate = um.Estimate
+	}
 	if len(um.UserId.Hex()) > 0 && um.UserId.Hex() != "000000000000000000000000" {
 		u.UserId = um.UserId
 	}
+	if len(um.AssigneeId.Hex()) > 0 && um.AssigneeId.Hex() != "000000000000000000000000" {
+		u.AssigneeId = um.AssigneeId
+	}
+	if len(um.ParentId.Hex()) > 0 && um.ParentId.Hex() != "000000000000000000000000" {
+		u.ParentId = um.ParentId
+	}
+	if len(um.BlockedBy) > 0 {
+		u.BlockedBy = um.BlockedBy
+	}
+	if len(um.SeriesId.Hex()) > 0 && um.SeriesId.Hex() != "000000000000000000000000" {
+		u.SeriesId = um.SeriesId
+	}
+	if !um.CompletedAt.IsZero() {
+		u.CompletedAt = um.CompletedAt
+	}
 	if len(um.GroupId.Hex()) > 0 && um.GroupId.Hex() != "000000000000000000000000" {
 		u.GroupId = um.GroupId
 	}
 	if !um.LastModified.IsZero() {
 		u.LastModified = um.LastModified
 	}
+	if !um.DeletedAt.IsZero() {
+		u.DeletedAt = um.DeletedAt
+	}
 	return
 }
 
+// clearFields zeroes the taskModel fields matching the bson keys in fields
+func (u *taskModel) clearFields(fields ...string) {
+	for _, field := range fields {
+		switch field {
+		case "assignee_id":
+			u.AssigneeId = primitive.NilObjectID
+		case "labels":
+			u.Labels = nil
+		case "estimate":
+			u.Estimate = 0
+		case "parent_id":
+			u.ParentId = primitive.NilObjectID
+		case "blocked_by":
+			u.BlockedBy = nil
+		case "completed_at":
+			u.CompletedAt = time.Time{}
+		}
+	}
+}
+
 // bsonLoad loads a bson doc into the userModel
 func (u *taskModel) bsonLoad(doc bson.D) (err error) {
 	bData, err := bsonMarshall(doc)
@@ -122,6 +197,11 @@ func (u *taskModel) getID() (id interface{}) {
 	return u.Id
 }
 
+// getDeletedAt returns the time the taskModel was soft deleted at
+func (u *taskModel) getDeletedAt() time.Time {
+	return u.DeletedAt
+}
+
 // addTimeStamps updates an userModel struct with a timestamp
 func (u *taskModel) addTimeStamps(newRecord bool) {
 	currentTime := time.Now().UTC()
@@ -144,7 +224,6 @@ func (u *taskModel) postProcess() (err error) {
 	if u.UserId.Hex() == "" {
 		err = errors.New("user record does not have an email")
 	}
-	// TODO - When implementing soft delete, DeletedAt can be checked here to ensure deleted users are filtered out
 	return
 }
 
@@ -182,16 +261,33 @@ func (u *taskModel) bsonUpdate() (doc bson.D, err error) {
 
 // toRoot creates and return a new pointer to a Task JSON struct from a pointer to a BSON taskModel
 func (u *taskModel) toRoot() *models.Task {
-	return &models.Task{
+	t := &models.Task{
 		Id:           u.Id.Hex(),
 		Name:         u.Name,
 		Status:       u.Status,
+		Priority:     u.Priority,
 		Due:          u.Due,
 		Description:  u.Description,
+		Labels:       u.Labels,
+		Estimate:     u.Estimate,
 		UserId:       u.UserId.Hex(),
 		GroupId:      u.GroupId.Hex(),
+		CompletedAt:  u.CompletedAt,
 		LastModified: u.LastModified,
 		CreatedAt:    u.CreatedAt,
 		DeletedAt:    u.DeletedAt,
 	}
+	if !u.AssigneeId.IsZero() {
+		t.AssigneeId = u.AssigneeId.Hex()
+	}
+	if !u.ParentId.IsZero() {
+		t.ParentId = u.ParentId.Hex()
+	}
+	if !u.SeriesId.IsZero() {
+		t.SeriesId = u.SeriesId.Hex()
+	}
+	for _, blocker := range u.BlockedBy {
+		t.BlockedBy = append(t.BlockedBy, blocker.Hex())
+	}
+	return t
 }
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// taskSeriesModel structures a task series BSON document to save in a task_series collection
+type taskSeriesModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	TaskId       primitive.ObjectID `bson:"task_id,omitempty"`
+	GroupId      primitive.ObjectID `bson:"group_id,omitempty"`
+	UserId       primitive.ObjectID `bson:"user_id,omitempty"`
+	RRule        string             `bson:"rrule,omitempty"`
+	Generate     string             `bson:"generate,omitempty"`
+	Start        time.Time          `bson:"start,omitempty"`
+	Occurrence   int                `bson:"occurrence,omitempty"`
+	Due          time.Time          `bson:"due,omitempty"`
+	NextRun      time.Time          `bson:"next_run"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newTaskSeriesModel initializes a new pointer to a taskSeriesModel struct from a pointer to a JSON TaskSeries struct
+func newTaskSeriesModel(s *models.TaskSeries) (sm *taskSeriesModel, err error) {
+	sm = &taskSeriesModel{
+		RRule:        s.RRule,
+		Generate:     s.Generate,
+		Start:        s.Start,
+		Occurrence:   s.Occurrence,
+		Due:          s.Due,
+		LastModified: s.LastModified,
+		CreatedAt:    s.CreatedAt,
+		DeletedAt:    s.DeletedAt,
+	}
+	if s.Id != "" && s.Id != "000000000000000000000000" {
+		sm.Id, err = primitive.ObjectIDFromHex(s.Id)
+	}
+	if s.TaskId != "" && s.TaskId != "000000000000000000000000" {
+		sm.TaskId, err = primitive.ObjectIDFromHex(s.TaskId)
+	}
+	if s.GroupId != "" && s.GroupId != "000000000000000000000000" {
+		sm.GroupId, err = primitive.ObjectIDFromHex(s.GroupId)
+	}
+	if s.UserId != "" && s.UserId != "000000000000000000000000" {
+		sm.UserId, err = primitive.ObjectIDFromHex(s.UserId)
+	}
+	return
+}
+
+// update the taskSeriesModel using an overwrite bson doc
+func (s *taskSeriesModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	sm := taskSeriesModel{}
+	err = bson.Unmarshal(data, &sm)
+	if !sm.TaskId.IsZero() {
+		s.TaskId = sm.TaskId
+	}
+	if sm.RRule != "" {
+		s.RRule = sm.RRule
+	}
+	if sm.Generate != "" {
+		s.Generate = sm.Generate
+	}
+	if !sm.Start.IsZero() {
+		s.Start = sm.Start
+	}
+	if sm.Occurrence != 0 {
+		s.Occurrence = sm.Occurrence
+	}
+	if !sm.Due.IsZero() {
+		s.Due = sm.Due
+	}
+	if !sm.NextRun.IsZero() {
+		s.NextRun = sm.NextRun
+	}
+	if !sm.LastModified.IsZero() {
+		s.LastModified = sm.LastModified
+	}
+	if !sm.DeletedAt.IsZero() {
+		s.DeletedAt = sm.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the taskSeriesModel
+func (s *taskSeriesModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, s)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the taskSeriesModel
+func (s *taskSeriesModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	sm := taskSeriesModel{}
+	err = bson.Unmarshal(data, &sm)
+	if sm.Id.Hex() != "" && sm.Id.Hex() != "000000000000000000000000" {
+		return s.Id == sm.Id
+	}
+	if sm.TaskId.Hex() != "" && sm.TaskId.Hex() != "000000000000000000000000" {
+		return s.TaskId == sm.TaskId
+	}
+	if sm.GroupId.Hex() != "" && sm.GroupId.Hex() != "000000000000000000000000" {
+		return s.GroupId == sm.GroupId
+	}
+	return false
+}
+
+// getID returns the unique identifier of the taskSeriesModel
+func (s *taskSeriesModel) getID() (id interface{}) {
+	return s.Id
+}
+
+// getDeletedAt returns the time the taskSeriesModel was soft deleted at
+func (s *taskSeriesModel) getDeletedAt() time.Time {
+	return s.DeletedAt
+}
+
+// addTimeStamps updates a taskSeriesModel struct with a timestamp
+func (s *taskSeriesModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	s.LastModified = currentTime
+	if newRecord {
+		s.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a taskSeriesModel has a value assigned for Id, if no value a new one is generated and assigned
+func (s *taskSeriesModel) addObjectID() {
+	if s.Id.Hex() == "" || s.Id.Hex() == "000000000000000000000000" {
+		s.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a taskSeriesModel struct postProcess to do things such as validating required fields
+func (s *taskSeriesModel) postProcess() (err error) {
+	if s.TaskId.IsZero() || s.RRule == "" {
+		err = errors.New("task series record does not have a TaskId and RRule")
+	}
+	return
+}
+
+// toDoc converts the bson taskSeriesModel into a bson.D
+func (s *taskSeriesModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(s)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the taskSeriesModel data
+func (s *taskSeriesModel) bsonFilter() (doc bson.D, err error) {
+	if s.Id.Hex() != "" && s.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", s.Id}}
+	} else if s.TaskId.Hex() != "" && s.TaskId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"task_id", s.TaskId}}
+	} else if s.GroupId.Hex() != "" && s.GroupId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"group_id", s.GroupId}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the taskSeriesModel data
+func (s *taskSeriesModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := s.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a TaskSeries JSON struct from a pointer to a BSON taskSeriesModel
+func (s *taskSeriesModel) toRoot() *models.TaskSeries {
+	return &models.TaskSeries{
+		Id:           s.Id.Hex(),
+		TaskId:       s.TaskId.Hex(),
+		GroupId:      s.GroupId.Hex(),
+		UserId:       s.UserId.Hex(),
+		RRule:        s.RRule,
+		Generate:     s.Generate,
+		Start:        s.Start,
+		Occurrence:   s.Occurrence,
+		Due:          s.Due,
+		LastModified: s.LastModified,
+		CreatedAt:    s.CreatedAt,
+		DeletedAt:    s.DeletedAt,
+	}
+}
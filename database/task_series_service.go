@@ -0,0 +1,298 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+// TaskSeriesService is used by the app to manage all TaskSeries related controllers and functionality
+type TaskSeriesService struct {
+	collection   DBCollection
+	db           DBClient
+	handler      *DBHandler[*taskSeriesModel]
+	taskHandler  *DBHandler[*taskModel]
+	groupHandler *DBHandler[*groupModel]
+}
+
+// NewTaskSeriesService is an exported function used to initialize a new TaskSeriesService struct
+func NewTaskSeriesService(db DBClient, handler *DBHandler[*taskSeriesModel], tHandler *DBHandler[*taskModel], gHandler *DBHandler[*groupModel]) *TaskSeriesService {
+	collection := db.GetCollection("task_series")
+	return &TaskSeriesService{collection, db, handler, tHandler, gHandler}
+}
+
+// nextOccurrence returns the due, position and next run of the occurrence that follows the current instance of a
+// taskSeriesModel, missed occurrences are skipped so the next instance is never already overdue
+func nextOccurrence(sm *taskSeriesModel, now time.Time) (*taskSeriesModel, error) {
+	rule, err := models.ParseRRule(sm.RRule)
+	if err != nil {
+		return nil, err
+	}
+	after := sm.Due
+	if now.After(after) {
+		after = now
+	}
+	next, position, ok := rule.Next(sm.Start, after)
+	if !ok {
+		return nil, models.ErrTaskSeriesEnded
+	}
+	update := &taskSeriesModel{Occurrence: position, Due: next}
+	if sm.Generate == models.GenerateOnSchedule {
+		update.NextRun = next
+	}
+	return update, nil
+}
+
+// claim moves a taskSeriesModel on to its next occurrence, it reports false when another writer, such as a scheduler
+// of another API replica, moved the series on first
+func (p *TaskSeriesService) claim(ctx context.Context, sm *taskSeriesModel, update *taskSeriesModel) (bool, error) {
+	return p.handler.UpdateIf(ctx, bson.D{{"_id", sm.Id}, {"occurrence", sm.Occurrence}}, update)
+}
+
+// release moves a taskSeriesModel back to the occurrence it was claimed from, when its claimed instance could not be
+// created, unless another writer moved the series on since
+func (p *TaskSeriesService) release(ctx context.Context, sm *taskSeriesModel, update *taskSeriesModel) error {
+	prev := &taskSeriesModel{TaskId: sm.TaskId, Occurrence: sm.Occurrence, Due: sm.Due, NextRun: sm.NextRun}
+	_, err := p.handler.UpdateIf(ctx, bson.D{{"_id", sm.Id}, {"occurrence", update.Occurrence}, {"task_id", update.TaskId}}, prev)
+	return err
+}
+
+// currentTask returns the current instance of a taskSeriesModel, even when it was deleted
+func (p *TaskSeriesService) currentTask(ctx context.Context, sm *taskSeriesModel) (*taskModel, error) {
+	tm, err := p.taskHandler.FindOne(ctx, &taskModel{Id: sm.TaskId})
+	if err == nil {
+		return tm, nil
+	}
+	tm, err = p.taskHandler.FindOneDeleted(ctx, &taskModel{Id: sm.TaskId})
+	if err != nil {
+		return nil, errors.New("task series instance not found")
+	}
+	return tm, nil
+}
+
+// advance creates the next instance of a taskSeriesModel, the series ends when it has no further occurrences
+func (p *TaskSeriesService) advance(ctx context.Context, sm *taskSeriesModel, now time.Time) (*models.Task, error) {
+	update, err := nextOccurrence(sm, now)
+	if errors.Is(err, models.ErrTaskSeriesEnded) {
+		_, err = p.handler.DeleteOne(ctx, &taskSeriesModel{Id: sm.Id})
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+	cur, err := p.currentTask(ctx, sm)
+	if err != nil {
+		return nil, err
+	}
+	gm, err := p.groupHandler.FindOne(ctx, &groupModel{Id: cur.GroupId})
+	if err != nil {
+		return nil, errors.New("invalid group id")
+	}
+	update.TaskId = primitive.NewObjectID()
+	claimed, err := p.claim(ctx, sm, update)
+	if err != nil || !claimed {
+		return nil, err
+	}
+	tm := &taskModel{
+		Id:          update.TaskId,
+		Name:        cur.Name,
+		Status:      gm.toRoot().Workflow().InitialStatus(),
+		Priority:    cur.Priority,
+		Due:         update.Due,
+		Description: cur.Description,
+		Labels:      cur.Labels,
+		Estimate:    cur.Estimate,
+		UserId:      cur.UserId,
+		AssigneeId:  cur.AssigneeId,
+		GroupId:     cur.GroupId,
+		SeriesId:    sm.Id,
+	}
+	tm, err = p.taskHandler.InsertOne(ctx, tm)
+	if err != nil {
+		if releaseErr := p.release(ctx, sm, update); releaseErr != nil {
+			log.Println("task series error:", releaseErr)
+		}
+		return nil, err
+	}
+	task := tm.toRoot()
+	emit(p.db, models.EventTaskCreated, task.GroupId, task, nil)
+	return task, nil
+}
+
+// withNextDue returns the TaskSeries of a taskSeriesModel along with the due of its next occurrence
+func withNextDue(sm *taskSeriesModel) *models.TaskSeries {
+	series := sm.toRoot()
+	if rule, err := models.ParseRRule(sm.RRule); err == nil {
+		if next, _, ok := rule.Next(sm.Start, sm.Due); ok {
+			series.NextDue = next
+		}
+	}
+	return series
+}
+
+// TaskSeriesSet is used to make a Task the current instance of a TaskSeries with a RRULE, a Task that already is the
+// current instance of a series changes the rule of its series, which restarts at the Task
+func (p *TaskSeriesService) TaskSeriesSet(ctx context.Context, g *models.TaskSeries) (*models.TaskSeries, error) {
+	err := g.Validate("set")
+	if err != nil {
+		return nil, err
+	}
+	rule, err := g.Rule()
+	if err != nil {
+		return nil, err
+	}
+	if g.Generate == "" {
+		g.Generate = models.GenerateOnCompletion
+	}
+	taskId, err := primitive.ObjectIDFromHex(g.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	tm, err := p.taskHandler.FindOne(ctx, &taskModel{Id: taskId})
+	if err != nil {
+		return nil, errors.New("task not found")
+	}
+	if tm.Due.IsZero() {
+		return nil, errors.New("recurring tasks need a due date")
+	}
+	sm := &taskSeriesModel{
+		TaskId:     tm.Id,
+		GroupId:    tm.GroupId,
+		UserId:     tm.UserId,
+		RRule:      rule.String(),
+		Generate:   g.Generate,
+		Start:      tm.Due,
+		Occurrence: 1,
+		Due:        tm.Due,
+	}
+	if sm.Generate == models.GenerateOnSchedule {
+		sm.NextRun = tm.Due
+	}
+	if !tm.SeriesId.IsZero() {
+		cur, err := p.handler.FindOne(ctx, &taskSeriesModel{Id: tm.SeriesId})
+		if err == nil {
+			if cur.TaskId != tm.Id {
+				return nil, errors.New("only the current instance of a task series can change its recurrence")
+			}
+			sm.Id = cur.Id
+			sm.CreatedAt = cur.CreatedAt
+			claimed, err := p.claim(ctx, cur, sm)
+			if err != nil {
+				return nil, err
+			} else if !claimed {
+				return nil, models.ErrTaskSeriesChanged
+			}
+			sm.LastModified = time.Now().UTC()
+			return withNextDue(sm), nil
+		}
+	}
+	sm, err = p.handler.InsertOne(ctx, sm)
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.taskHandler.UpdateOne(ctx, &taskModel{Id: tm.Id}, &taskModel{SeriesId: sm.Id})
+	if err != nil {
+		return nil, err
+	}
+	return withNextDue(sm), nil
+}
+
+// TaskSeriesFind is used to find a specific TaskSeries doc along with the due of its next occurrence
+func (p *TaskSeriesService) TaskSeriesFind(ctx context.Context, g *models.TaskSeries) (*models.TaskSeries, error) {
+	sm, err := newTaskSeriesModel(g)
+	if err != nil {
+		return nil, err
+	}
+	sm, err = p.handler.FindOne(ctx, sm)
+	if err != nil {
+		return nil, errors.New("task series not found")
+	}
+	return withNextDue(sm), nil
+}
+
+// TaskSeriesStop is used to stop a TaskSeries, its instances are kept
+func (p *TaskSeriesService) TaskSeriesStop(ctx context.Context, g *models.TaskSeries) (*models.TaskSeries, error) {
+	sm, err := newTaskSeriesModel(g)
+	if err != nil {
+		return nil, err
+	}
+	sm, err = p.handler.DeleteOne(ctx, sm)
+	if err != nil {
+		return nil, err
+	}
+	return sm.toRoot(), nil
+}
+
+// TaskSeriesSkip is used to skip the occurrence of the current instance of a TaskSeries, the instance moves on to
+// the next occurrence
+func (p *TaskSeriesService) TaskSeriesSkip(ctx context.Context, g *models.TaskSeries, now time.Time) (*models.TaskSeries, error) {
+	sm, err := newTaskSeriesModel(g)
+	if err != nil {
+		return nil, err
+	}
+	sm, err = p.handler.FindOne(ctx, sm)
+	if err != nil {
+		return nil, errors.New("task series not found")
+	}
+	update, err := nextOccurrence(sm, now)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := p.claim(ctx, sm, update)
+	if err != nil {
+		return nil, err
+	} else if !claimed {
+		return nil, models.ErrTaskSeriesChanged
+	}
+	_, err = p.taskHandler.UpdateOne(ctx, &taskModel{Id: sm.TaskId}, &taskModel{Due: update.Due})
+	if err != nil {
+		return nil, err
+	}
+	sm.Occurrence, sm.Due, sm.NextRun = update.Occurrence, update.Due, update.NextRun
+	return withNextDue(sm), nil
+}
+
+// TaskSeriesComplete is used to create the next instance of the TaskSeries of a Task that was completed, when the
+// Task is the current instance of a series that generates its instances on completion
+func (p *TaskSeriesService) TaskSeriesComplete(ctx context.Context, g *models.Task, now time.Time) (*models.Task, error) {
+	if !g.CheckID("series_id") || g.Status != models.COMPLETED {
+		return nil, nil
+	}
+	sm, err := newTaskSeriesModel(&models.TaskSeries{Id: g.SeriesId})
+	if err != nil {
+		return nil, err
+	}
+	sm, err = p.handler.FindOne(ctx, sm)
+	if err != nil || sm.TaskId.Hex() != g.Id || sm.Generate != models.GenerateOnCompletion {
+		return nil, nil
+	}
+	return p.advance(ctx, sm, now)
+}
+
+// TaskSeriesRunDue is used to create the next instance of every TaskSeries generated on schedule whose current
+// instance is due, it returns the new instances
+func (p *TaskSeriesService) TaskSeriesRunDue(ctx context.Context, now time.Time) ([]*models.Task, error) {
+	var tasks []*models.Task
+	sms, err := p.handler.findMany(ctx, activeFilter(bson.D{
+		{"generate", models.GenerateOnSchedule},
+		{"next_run", bson.D{{"$lte", now}}},
+	}))
+	if err != nil {
+		return tasks, err
+	}
+	var runErr error
+	for _, sm := range sms {
+		task, err := p.advance(ctx, sm, now)
+		if err != nil {
+			runErr = err
+			continue
+		}
+		if task != nil {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, runErr
+}
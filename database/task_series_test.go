@@ -0,0 +1,291 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+// testSeriesStart is the due of the first instance of every series set by the tests, a Monday
+var testSeriesStart = time.Date(2026, time.January, 5, 9, 0, 0, 0, time.UTC)
+
+// setTestTaskSeries makes Task1 the current instance of a series with the rule and generation mode
+func setTestTaskSeries(t *testing.T, rule string, generate string) (*TaskSeriesService, *models.TaskSeries) {
+	testService := setupTestTaskSeries(testSeriesStart)
+	series, err := testService.TaskSeriesSet(context.Background(), &models.TaskSeries{TaskId: "000000000000000000000022", RRule: rule, Generate: generate})
+	if err != nil {
+		t.Fatalf("TaskSeriesService.TaskSeriesSet() error = %v", err)
+	}
+	return testService, series
+}
+
+// countTestSeriesTasks returns how many tasks are instances of a series
+func countTestSeriesTasks(t *testing.T, testService *TaskSeriesService, seriesId string) int {
+	tms, err := testService.taskHandler.FindMany(context.Background(), &taskModel{})
+	if err != nil {
+		t.Fatalf("DBHandler.FindMany() error = %v", err)
+	}
+	count := 0
+	for _, tm := range tms {
+		if tm.SeriesId.Hex() == seriesId {
+			count++
+		}
+	}
+	return count
+}
+
+func Test_TaskSeriesSet(t *testing.T) {
+	testService := setupTestTaskSeries(testSeriesStart)
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name        string             // The name of the test
+		series      *models.TaskSeries // The input of the test
+		wantErr     bool               // whether we want an error.
+		wantNextDue time.Time          // The due of the next occurrence we want
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"daily", &models.TaskSeries{TaskId: "000000000000000000000022", RRule: "FREQ=DAILY"}, false, testSeriesStart.AddDate(0, 0, 1)},
+		{"change rule", &models.TaskSeries{TaskId: "000000000000000000000022", RRule: "FREQ=WEEKLY;BYDAY=MO,WE"}, false, testSeriesStart.AddDate(0, 0, 2)},
+		{"invalid rule", &models.TaskSeries{TaskId: "000000000000000000000022", RRule: "FREQ=HOURLY"}, true, time.Time{}},
+		{"invalid generate", &models.TaskSeries{TaskId: "000000000000000000000022", RRule: "FREQ=DAILY", Generate: "never"}, true, time.Time{}},
+		{"missing task", &models.TaskSeries{TaskId: "000000000000000000000029", RRule: "FREQ=DAILY"}, true, time.Time{}},
+	}
+	// Iterating over the previous test slice, each test sets the recurrence of the same task
+	var seriesId string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testService.TaskSeriesSet(context.Background(), tt.series)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TaskSeriesService.TaskSeriesSet() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if seriesId == "" {
+				seriesId = got.Id
+			}
+			if got.Id != seriesId || got.Occurrence != 1 || !got.Due.Equal(testSeriesStart) || !got.NextDue.Equal(tt.wantNextDue) {
+				t.Errorf("TaskSeriesService.TaskSeriesSet() = %+v, want series %v next due %v", got, seriesId, tt.wantNextDue)
+			}
+			if count := countTestSeriesTasks(t, testService, seriesId); count != 1 {
+				t.Errorf("series has %v instances, want 1", count)
+			}
+		})
+	}
+}
+
+func Test_TaskSeriesComplete(t *testing.T) {
+	testService, series := setTestTaskSeries(t, "FREQ=WEEKLY;BYDAY=MO,WE", models.GenerateOnCompletion)
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string            // The name of the test
+		status  models.TaskStatus // The status the current instance moves to
+		now     time.Time         // The time the current instance is completed at
+		want    time.Time         // The due of the next instance we want, zero when no instance is created
+		wantOcc int               // The occurrence of the next instance we want
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"not completed", models.INPROGRESS, testSeriesStart, time.Time{}, 1},
+		{"completed on time", models.COMPLETED, testSeriesStart.Add(time.Hour), testSeriesStart.AddDate(0, 0, 2), 2},
+		{"completed late skips missed occurrences", models.COMPLETED, testSeriesStart.AddDate(0, 0, 10), testSeriesStart.AddDate(0, 0, 14), 5},
+	}
+	// Iterating over the previous test slice, each test completes the current instance of the series
+	taskId := series.TaskId
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testService.TaskSeriesComplete(context.Background(), &models.Task{Id: taskId, SeriesId: series.Id, Status: tt.status}, tt.now)
+			if err != nil {
+				t.Errorf("TaskSeriesService.TaskSeriesComplete() error = %v", err)
+				return
+			}
+			if (got != nil) != !tt.want.IsZero() {
+				t.Errorf("TaskSeriesService.TaskSeriesComplete() = %v, want due %v", got, tt.want)
+				return
+			}
+			if got != nil {
+				if !got.Due.Equal(tt.want) || got.SeriesId != series.Id || got.Status != models.NOTSTARTED || got.Name != "Task1" {
+					t.Errorf("TaskSeriesService.TaskSeriesComplete() = %+v, want due %v", got, tt.want)
+				}
+				taskId = got.Id
+			}
+			cur, err := testService.TaskSeriesFind(context.Background(), &models.TaskSeries{Id: series.Id})
+			if err != nil || cur.TaskId != taskId || cur.Occurrence != tt.wantOcc {
+				t.Errorf("TaskSeriesService.TaskSeriesFind() = %+v, %v, want task %v occurrence %v", cur, err, taskId, tt.wantOcc)
+			}
+		})
+	}
+	// Completing an instance that is no longer the current instance creates nothing
+	got, err := testService.TaskSeriesComplete(context.Background(), &models.Task{Id: series.TaskId, SeriesId: series.Id, Status: models.COMPLETED}, testSeriesStart)
+	if got != nil || err != nil {
+		t.Errorf("TaskSeriesService.TaskSeriesComplete() = %v, %v, want nil", got, err)
+	}
+}
+
+func Test_TaskSeriesRunDue(t *testing.T) {
+	testService, series := setTestTaskSeries(t, "FREQ=DAILY;COUNT=3", models.GenerateOnSchedule)
+	// replica is the TaskSeriesService of another API replica running against the same database
+	replica := NewTaskSeriesService(testService.db, testService.handler, testService.taskHandler, testService.groupHandler)
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string             // The name of the test
+		service  *TaskSeriesService // The service that runs the scheduler
+		now      time.Time          // The time the injected clock tells
+		want     time.Time          // The due of the instance we want created, zero when none is created
+		wantDone bool               // whether we want the series to have ended
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"not due", testService, testSeriesStart.Add(-time.Minute), time.Time{}, false},
+		{"due", testService, testSeriesStart, testSeriesStart.AddDate(0, 0, 1), false},
+		{"already run", testService, testSeriesStart.Add(time.Minute), time.Time{}, false},
+		{"already run by replica", replica, testSeriesStart.Add(time.Minute), time.Time{}, false},
+		{"replica due", replica, testSeriesStart.AddDate(0, 0, 1), testSeriesStart.AddDate(0, 0, 2), false},
+		{"last occurrence", testService, testSeriesStart.AddDate(0, 0, 2), time.Time{}, true},
+	}
+	// Iterating over the previous test slice, each test runs a scheduler with the clock at now
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.service.TaskSeriesRunDue(context.Background(), tt.now)
+			if err != nil {
+				t.Errorf("TaskSeriesService.TaskSeriesRunDue() error = %v", err)
+				return
+			}
+			if (len(got) == 1) != !tt.want.IsZero() || len(got) > 1 {
+				t.Errorf("TaskSeriesService.TaskSeriesRunDue() = %v, want due %v", got, tt.want)
+				return
+			}
+			if len(got) == 1 && (!got[0].Due.Equal(tt.want) || got[0].SeriesId != series.Id) {
+				t.Errorf("TaskSeriesService.TaskSeriesRunDue() = %+v, want due %v", got[0], tt.want)
+			}
+			if _, err = testService.TaskSeriesFind(context.Background(), &models.TaskSeries{Id: series.Id}); (err != nil) != tt.wantDone {
+				t.Errorf("TaskSeriesService.TaskSeriesFind() error = %v, wantDone %v", err, tt.wantDone)
+			}
+		})
+	}
+	if count := countTestSeriesTasks(t, testService, series.Id); count != 3 {
+		t.Errorf("series has %v instances, want 3", count)
+	}
+}
+
+func Test_TaskSeriesClaim(t *testing.T) {
+	testService, series := setTestTaskSeries(t, "FREQ=DAILY", models.GenerateOnSchedule)
+	seriesId, _ := primitive.ObjectIDFromHex(series.Id)
+	stale, err := testService.handler.FindOne(context.Background(), &taskSeriesModel{Id: seriesId})
+	if err != nil {
+		t.Fatalf("DBHandler.FindOne() error = %v", err)
+	}
+	if _, err = testService.TaskSeriesRunDue(context.Background(), testSeriesStart); err != nil {
+		t.Fatalf("TaskSeriesService.TaskSeriesRunDue() error = %v", err)
+	}
+	// A scheduler that read the series before it moved on loses the claim and creates nothing
+	got, err := testService.advance(context.Background(), stale, testSeriesStart)
+	if got != nil || err != nil {
+		t.Errorf("TaskSeriesService.advance() = %v, %v, want nil", got, err)
+	}
+	if count := countTestSeriesTasks(t, testService, series.Id); count != 2 {
+		t.Errorf("series has %v instances, want 2", count)
+	}
+}
+
+func Test_TaskSeriesInsertFailure(t *testing.T) {
+	testService, series := setTestTaskSeries(t, "FREQ=DAILY", models.GenerateOnCompletion)
+	tHandler := testService.taskHandler
+	testService.taskHandler = &DBHandler[*taskModel]{db: testService.db, collection: failingTestCollection{tHandler.collection}}
+	completed := &models.Task{Id: series.TaskId, SeriesId: series.Id, Status: models.COMPLETED}
+	// An instance that fails to be created releases the claimed occurrence
+	if got, err := testService.TaskSeriesComplete(context.Background(), completed, testSeriesStart); got != nil || err == nil {
+		t.Errorf("TaskSeriesService.TaskSeriesComplete() = %v, %v, want an error", got, err)
+	}
+	cur, err := testService.TaskSeriesFind(context.Background(), &models.TaskSeries{Id: series.Id})
+	if err != nil || cur.TaskId != series.TaskId || cur.Occurrence != 1 || !cur.Due.Equal(testSeriesStart) {
+		t.Errorf("TaskSeriesService.TaskSeriesFind() = %+v, %v, want task %v occurrence 1", cur, err, series.TaskId)
+	}
+	// Completing the instance again, once the database recovers, creates the next instance
+	testService.taskHandler = tHandler
+	got, err := testService.TaskSeriesComplete(context.Background(), completed, testSeriesStart)
+	if err != nil || got == nil || !got.Due.Equal(testSeriesStart.AddDate(0, 0, 1)) {
+		t.Errorf("TaskSeriesService.TaskSeriesComplete() = %v, %v, want due %v", got, err, testSeriesStart.AddDate(0, 0, 1))
+	}
+	if count := countTestSeriesTasks(t, testService, series.Id); count != 2 {
+		t.Errorf("series has %v instances, want 2", count)
+	}
+}
+
+func Test_TaskSeriesSkip(t *testing.T) {
+	testService, series := setTestTaskSeries(t, "FREQ=DAILY;COUNT=2", models.GenerateOnCompletion)
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name      string    // The name of the test
+		now       time.Time // The time the occurrence is skipped at
+		want      time.Time // The due of the current instance we want
+		wantEnded bool      // whether we want the series to have no further occurrences
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"skip", testSeriesStart, testSeriesStart.AddDate(0, 0, 1), false},
+		{"skip last occurrence", testSeriesStart, time.Time{}, true},
+	}
+	// Iterating over the previous test slice, each test skips the occurrence of the same task
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testService.TaskSeriesSkip(context.Background(), &models.TaskSeries{Id: series.Id}, tt.now)
+			if errors.Is(err, models.ErrTaskSeriesEnded) != tt.wantEnded || (err != nil && !tt.wantEnded) {
+				t.Errorf("TaskSeriesService.TaskSeriesSkip() error = %v, wantEnded %v", err, tt.wantEnded)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if !got.Due.Equal(tt.want) || got.TaskId != series.TaskId {
+				t.Errorf("TaskSeriesService.TaskSeriesSkip() = %+v, want due %v", got, tt.want)
+			}
+			taskId, _ := primitive.ObjectIDFromHex(series.TaskId)
+			tm, err := testService.taskHandler.FindOne(context.Background(), &taskModel{Id: taskId})
+			if err != nil || !tm.Due.Equal(tt.want) {
+				t.Errorf("DBHandler.FindOne() = %v, %v, want due %v", tm, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_TaskSeriesStop(t *testing.T) {
+	testService, series := setTestTaskSeries(t, "FREQ=DAILY", models.GenerateOnCompletion)
+	if _, err := testService.TaskSeriesStop(context.Background(), &models.TaskSeries{Id: series.Id}); err != nil {
+		t.Fatalf("TaskSeriesService.TaskSeriesStop() error = %v", err)
+	}
+	if _, err := testService.TaskSeriesFind(context.Background(), &models.TaskSeries{Id: series.Id}); err == nil {
+		t.Errorf("TaskSeriesService.TaskSeriesFind() found a stopped series")
+	}
+	// Completing the instance of a stopped series creates nothing and keeps the instance
+	got, err := testService.TaskSeriesComplete(context.Background(), &models.Task{Id: series.TaskId, SeriesId: series.Id, Status: models.COMPLETED}, testSeriesStart)
+	if got != nil || err != nil {
+		t.Errorf("TaskSeriesService.TaskSeriesComplete() = %v, %v, want nil", got, err)
+	}
+	if count := countTestSeriesTasks(t, testService, series.Id); count != 1 {
+		t.Errorf("series has %v instances, want 1", count)
+	}
+}
+
+func Test_TaskSeriesTaskUpdate(t *testing.T) {
+	ts := setupTestTasks()
+	series, err := ts.seriesService.TaskSeriesSet(context.Background(), &models.TaskSeries{TaskId: "000000000000000000000022", RRule: "FREQ=DAILY"})
+	if err != nil {
+		t.Fatalf("TaskSeriesService.TaskSeriesSet() error = %v", err)
+	}
+	// Completing the current instance through any task update creates the next instance
+	for _, status := range []models.TaskStatus{models.INPROGRESS, models.COMPLETED} {
+		if _, err = ts.TaskUpdate(context.Background(), &models.Task{Id: series.TaskId, Status: status}, "000000000000000000000012"); err != nil {
+			t.Fatalf("TaskService.TaskUpdate() error = %v", err)
+		}
+	}
+	cur, err := ts.seriesService.TaskSeriesFind(context.Background(), &models.TaskSeries{Id: series.Id})
+	if err != nil || cur.TaskId == series.TaskId || cur.Occurrence != 2 {
+		t.Errorf("TaskSeriesService.TaskSeriesFind() = %+v, %v, want the next instance", cur, err)
+	}
+	if count := countTestSeriesTasks(t, ts.seriesService, series.Id); count != 2 {
+		t.Errorf("series has %v instances, want 2", count)
+	}
+}
@@ -4,37 +4,54 @@ import (
 	"context"
 	"errors"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"sort"
 	"time"
 )
 
 // TaskService is used by the app to manage all Task related controllers and functionality
 type TaskService struct {
-	collection   DBCollection
-	db           DBClient
-	taskHandler  *DBHandler[*taskModel]
-	userHandler  *DBHandler[*userModel]
-	groupHandler *DBHandler[*groupModel]
+	collection        DBCollection
+	db                DBClient
+	taskHandler       *DBHandler[*taskModel]
+	userHandler       *DBHandler[*userModel]
+	groupHandler      *DBHandler[*groupModel]
+	historyHandler    *DBHandler[*taskHistoryModel]
+	membershipHandler *DBHandler[*membershipModel]
+	seriesService     *TaskSeriesService
 }
 
 // NewTaskService is an exported function used to initialize a new TaskService struct
-func NewTaskService(db DBClient, tHandler *DBHandler[*taskModel], uHandler *DBHandler[*userModel], gHandler *DBHandler[*groupModel]) *TaskService {
+func NewTaskService(db DBClient, tHandler *DBHandler[*taskModel], uHandler *DBHandler[*userModel], gHandler *DBHandler[*groupModel], hHandler *DBHandler[*taskHistoryModel], mHandler *DBHandler[*membershipModel], sHandler *DBHandler[*taskSeriesModel]) *TaskService {
 	collection := db.GetCollection("tasks")
-	return &TaskService{collection, db, tHandler, uHandler, gHandler}
+	return &TaskService{collection, db, tHandler, uHandler, gHandler, hHandler, mHandler, NewTaskSeriesService(db, sHandler, tHandler, gHandler)}
 }
 
-// checkLinkedRecords ensures the userId and groupId in the models.Task is correct
-func (p *TaskService) checkLinkedRecords(g *groupModel, u *userModel) error {
+// taskWorkflow returns the task workflow of a group
+func (p *TaskService) taskWorkflow(ctx context.Context, groupId primitive.ObjectID) (*models.TaskWorkflow, error) {
+	gm, err := p.groupHandler.FindOne(ctx, &groupModel{Id: groupId})
+	if err != nil {
+		return nil, errors.New("invalid group id")
+	}
+	return gm.toRoot().Workflow(), nil
+}
+
+// checkLinkedRecords ensures the userId and groupId in the models.Task is correct, the user must belong to the group
+// either as one of its own users or through an active membership
+func (p *TaskService) checkLinkedRecords(ctx context.Context, g *groupModel, u *userModel) error {
 	gOutCh := make(chan *groupModel)
 	gErrCh := make(chan error)
 	uOutCh := make(chan *userModel)
 	uErrCh := make(chan error)
 	go func() {
-		reG, err := p.groupHandler.FindOne(g)
+		reG, err := p.groupHandler.FindOne(ctx, g)
 		gOutCh <- reG
 		gErrCh <- err
 	}()
 	go func() {
-		reU, err := p.userHandler.FindOne(u)
+		reU, err := p.userHandler.FindOne(ctx, u)
 		uOutCh <- reU
 		uErrCh <- err
 	}()
@@ -54,14 +71,130 @@ func (p *TaskService) checkLinkedRecords(g *groupModel, u *userModel) error {
 			}
 		}
 	}
-	if g.Id != u.GroupId {
+	if g.Id == u.GroupId {
+		return nil
+	}
+	if _, err := p.membershipHandler.FindOne(ctx, &membershipModel{UserId: u.Id, GroupId: g.Id}); err != nil {
 		return errors.New("task user is not in task group")
 	}
 	return nil
 }
 
+// checkAssignee ensures the assignee of a taskModel, when set, is a user or member of the task group
+func (p *TaskService) checkAssignee(ctx context.Context, gm *taskModel) error {
+	if gm.AssigneeId.IsZero() {
+		return nil
+	}
+	if err := p.checkLinkedRecords(ctx, &groupModel{Id: gm.GroupId}, &userModel{Id: gm.AssigneeId}); err != nil {
+		return errors.New("task assignee is not in task group")
+	}
+	return nil
+}
+
+// groupTasks returns the tasks of a group keyed by their id
+func (p *TaskService) groupTasks(ctx context.Context, groupId primitive.ObjectID) (map[primitive.ObjectID]*taskModel, error) {
+	tms, err := p.taskHandler.FindMany(ctx, &taskModel{GroupId: groupId})
+	if err != nil {
+		return nil, err
+	}
+	tasks := make(map[primitive.ObjectID]*taskModel, len(tms))
+	for _, tm := range tms {
+		tasks[tm.Id] = tm
+	}
+	return tasks, nil
+}
+
+// blockedBy determines whether the task with the id from is transitively blocked by the task with the id target
+func blockedBy(tasks map[primitive.ObjectID]*taskModel, from primitive.ObjectID, target primitive.ObjectID) bool {
+	visited := make(map[primitive.ObjectID]bool)
+	stack := []primitive.ObjectID{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == target {
+			return true
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		if tm, ok := tasks[id]; ok {
+			stack = append(stack, tm.BlockedBy...)
+		}
+	}
+	return false
+}
+
+// checkRelations ensures the parent and blockers of a taskModel are tasks of its group that do not lead back to it
+func (p *TaskService) checkRelations(ctx context.Context, gm *taskModel) error {
+	if gm.ParentId.IsZero() && len(gm.BlockedBy) == 0 {
+		return nil
+	}
+	tasks, err := p.groupTasks(ctx, gm.GroupId)
+	if err != nil {
+		return err
+	}
+	if !gm.ParentId.IsZero() {
+		if _, ok := tasks[gm.ParentId]; !ok {
+			return errors.New("task parent is not in task group")
+		}
+		visited := make(map[primitive.ObjectID]bool)
+		for id := gm.ParentId; !id.IsZero() && !visited[id]; {
+			if id == gm.Id {
+				return &models.CycleError{Relation: "parent", TaskId: gm.ParentId.Hex()}
+			}
+			visited[id] = true
+			parent, ok := tasks[id]
+			if !ok {
+				break
+			}
+			id = parent.ParentId
+		}
+	}
+	for _, blocker := range gm.BlockedBy {
+		if _, ok := tasks[blocker]; !ok {
+			return errors.New("task blocker is not in task group: " + blocker.Hex())
+		}
+		if blockedBy(tasks, blocker, gm.Id) {
+			return &models.CycleError{Relation: "blocker", TaskId: blocker.Hex()}
+		}
+	}
+	return nil
+}
+
+// checkBlockers ensures none of the blockers of a taskModel are still open
+func (p *TaskService) checkBlockers(ctx context.Context, gm *taskModel) error {
+	var open []string
+	for _, blocker := range gm.BlockedBy {
+		bm, err := p.taskHandler.FindOne(ctx, &taskModel{Id: blocker})
+		if err != nil {
+			continue
+		}
+		if bm.toRoot().Open() {
+			open = append(open, blocker.Hex())
+		}
+	}
+	if len(open) > 0 {
+		return &models.BlockedError{BlockerIds: open}
+	}
+	return nil
+}
+
+// sameObjectIDs determines whether two lists of ObjectIDs hold the same ids in the same order
+func sameObjectIDs(a []primitive.ObjectID, b []primitive.ObjectID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // TaskCreate is used to create a new user Task
-func (p *TaskService) TaskCreate(g *models.Task) (*models.Task, error) {
+func (p *TaskService) TaskCreate(ctx context.Context, g *models.Task) (*models.Task, error) {
 	err := g.Validate("create")
 	if err != nil {
 		return nil, err
@@ -70,26 +203,45 @@ func (p *TaskService) TaskCreate(g *models.Task) (*models.Task, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = p.checkLinkedRecords(&groupModel{Id: gm.GroupId}, &userModel{Id: gm.UserId})
+	err = p.checkLinkedRecords(ctx, &groupModel{Id: gm.GroupId}, &userModel{Id: gm.UserId})
 	if err != nil {
 		return nil, err
 	}
-	gm.Status = models.NOTSTARTED
-	gm, err = p.taskHandler.InsertOne(gm)
+	err = p.checkAssignee(ctx, gm)
 	if err != nil {
 		return nil, err
 	}
-	return gm.toRoot(), err
+	err = p.checkRelations(ctx, gm)
+	if err != nil {
+		return nil, err
+	}
+	gm.SeriesId = primitive.NilObjectID // tasks join a series when their recurrence is set
+	workflow, err := p.taskWorkflow(ctx, gm.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	gm.Status = workflow.InitialStatus()
+	gm.CompletedAt = time.Time{}
+	if gm.Priority == "" {
+		gm.Priority = models.MEDIUM
+	}
+	gm, err = p.taskHandler.InsertOne(ctx, gm)
+	if err != nil {
+		return nil, err
+	}
+	task := gm.toRoot()
+	emit(p.db, models.EventTaskCreated, task.GroupId, task, nil)
+	return task, nil
 }
 
 // TasksFind is used to find all Task docs in a MongoDB Collection
-func (p *TaskService) TasksFind(g *models.Task) ([]*models.Task, error) {
+func (p *TaskService) TasksFind(ctx context.Context, g *models.Task) ([]*models.Task, error) {
 	var tasks []*models.Task
 	tm, err := newTaskModel(g)
 	if err != nil {
 		return tasks, err
 	}
-	gms, err := p.taskHandler.FindMany(tm)
+	gms, err := p.taskHandler.FindMany(ctx, tm)
 	if err != nil {
 		return tasks, err
 	}
@@ -99,13 +251,84 @@ func (p *TaskService) TasksFind(g *models.Task) ([]*models.Task, error) {
 	return tasks, nil
 }
 
+// TasksFindCalendar is used to find the Task docs with a due date of a calendar feed, the tasks of a group when the
+// GroupId is set, otherwise the tasks a user owns or is assigned
+func (p *TaskService) TasksFindCalendar(ctx context.Context, g *models.Task) ([]*models.Task, error) {
+	var tasks []*models.Task
+	tm, err := newTaskModel(g)
+	if err != nil {
+		return tasks, err
+	}
+	var filters []bson.D
+	if !tm.GroupId.IsZero() {
+		filters = append(filters, bson.D{{"group_id", tm.GroupId}})
+	} else if !tm.UserId.IsZero() {
+		filters = append(filters, bson.D{{"user_id", tm.UserId}}, bson.D{{"assignee_id", tm.UserId}})
+	} else {
+		return tasks, errors.New("missing calendar user or group id")
+	}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, f := range filters {
+		gms, err := p.taskHandler.findMany(ctx, activeFilter(f))
+		if err != nil {
+			return tasks, err
+		}
+		for _, gm := range gms {
+			if !seen[gm.Id] && !gm.Due.IsZero() {
+				seen[gm.Id] = true
+				tasks = append(tasks, gm.toRoot())
+			}
+		}
+	}
+	return tasks, nil
+}
+
+// TasksFindPage is used to find a sorted, filtered and paginated page of Task docs along with the total number of matches
+func (p *TaskService) TasksFindPage(ctx context.Context, g *models.Task, o *models.ListOptions) ([]*models.Task, int64, error) {
+	var tasks []*models.Task
+	tm, err := newTaskModel(g)
+	if err != nil {
+		return tasks, 0, err
+	}
+	gms, total, err := p.taskHandler.FindPage(ctx, tm, o)
+	if err != nil {
+		return tasks, 0, err
+	}
+	for _, gm := range gms {
+		tasks = append(tasks, gm.toRoot())
+	}
+	return tasks, total, nil
+}
+
+// TasksFindVisiblePage is used to find a sorted, filtered and paginated page of the Task docs of a group that a user
+// either owns or is assigned to, along with the total number of matches, the user is the UserId of the models.Task
+func (p *TaskService) TasksFindVisiblePage(ctx context.Context, g *models.Task, o *models.ListOptions) ([]*models.Task, int64, error) {
+	var tasks []*models.Task
+	tm, err := newTaskModel(g)
+	if err != nil {
+		return tasks, 0, err
+	}
+	if tm.GroupId.IsZero() || tm.UserId.IsZero() {
+		return tasks, 0, errors.New("missing task user id or group id")
+	}
+	f := bson.D{{"group_id", tm.GroupId}, {"$or", bson.A{bson.D{{"user_id", tm.UserId}}, bson.D{{"assignee_id", tm.UserId}}}}}
+	gms, total, err := p.taskHandler.findPage(ctx, f, o)
+	if err != nil {
+		return tasks, 0, err
+	}
+	for _, gm := range gms {
+		tasks = append(tasks, gm.toRoot())
+	}
+	return tasks, total, nil
+}
+
 // TaskFind is used to find a specific Task doc
-func (p *TaskService) TaskFind(g *models.Task) (*models.Task, error) {
+func (p *TaskService) TaskFind(ctx context.Context, g *models.Task) (*models.Task, error) {
 	gm, err := newTaskModel(g)
 	if err != nil {
 		return nil, err
 	}
-	gm, err = p.taskHandler.FindOne(gm)
+	gm, err = p.taskHandler.FindOne(ctx, gm)
 	if err != nil {
 		return nil, err
 	}
@@ -113,70 +336,322 @@ func (p *TaskService) TaskFind(g *models.Task) (*models.Task, error) {
 }
 
 // TaskDelete is used to delete a Task doc
-func (p *TaskService) TaskDelete(g *models.Task) (*models.Task, error) {
+func (p *TaskService) TaskDelete(ctx context.Context, g *models.Task) (*models.Task, error) {
 	gm, err := newTaskModel(g)
 	if err != nil {
 		return nil, err
 	}
-	gm, err = p.taskHandler.DeleteOne(gm)
+	gm, err = p.taskHandler.DeleteOne(ctx, gm)
 	if err != nil {
 		return nil, err
 	}
-	return gm.toRoot(), err
+	task := gm.toRoot()
+	emit(p.db, models.EventTaskDeleted, task.GroupId, task, nil)
+	return task, nil
 }
 
 // TaskDeleteMany is used to delete many Tasks
-func (p *TaskService) TaskDeleteMany(g *models.Task) (*models.Task, error) {
+func (p *TaskService) TaskDeleteMany(ctx context.Context, g *models.Task) (*models.Task, error) {
 	gm, err := newTaskModel(g)
 	if err != nil {
 		return nil, err
 	}
-	gm, err = p.taskHandler.DeleteMany(gm)
+	gm, err = p.taskHandler.DeleteMany(ctx, gm)
 	if err != nil {
 		return nil, err
 	}
 	return gm.toRoot(), err
 }
 
-// TaskUpdate is used to update an existing Task
-func (p *TaskService) TaskUpdate(g *models.Task) (*models.Task, error) {
+// TaskRestore is used to restore a soft deleted Task doc
+func (p *TaskService) TaskRestore(ctx context.Context, g *models.Task) (*models.Task, error) {
+	gm, err := newTaskModel(g)
+	if err != nil {
+		return nil, err
+	}
+	dm, err := p.taskHandler.FindOneDeleted(ctx, &taskModel{Id: gm.Id})
+	if err != nil {
+		return nil, errors.New("deleted task not found")
+	}
+	if (g.CheckID("group_id") && dm.GroupId != gm.GroupId) || (g.CheckID("user_id") && dm.UserId != gm.UserId) {
+		return nil, errors.New("deleted task not found")
+	}
+	err = p.checkLinkedRecords(ctx, &groupModel{Id: dm.GroupId}, &userModel{Id: dm.UserId})
+	if err != nil {
+		return nil, err
+	}
+	dm, err = p.taskHandler.RestoreOne(ctx, &taskModel{Id: dm.Id})
+	if err != nil {
+		return nil, err
+	}
+	task := dm.toRoot()
+	emit(p.db, models.EventTaskRestored, task.GroupId, task, nil)
+	return task, nil
+}
+
+// TaskRestoreMany is used to restore many Tasks that were soft deleted at or after since
+func (p *TaskService) TaskRestoreMany(ctx context.Context, g *models.Task, since time.Time) error {
+	gm, err := newTaskModel(g)
+	if err != nil {
+		return err
+	}
+	return p.taskHandler.RestoreMany(ctx, gm, since)
+}
+
+// TasksPurge is used to permanently remove Tasks that were soft deleted at or before a given time
+func (p *TaskService) TasksPurge(ctx context.Context, before time.Time) (int64, error) {
+	return p.taskHandler.Purge(ctx, before)
+}
+
+// TaskUpdate is used to update an existing Task by an actor, the optional fields in clear are removed from it
+// Status changes must be allowed by the task workflow of the group and are recorded in the Task's history,
+// completed_at is set when the Task moves to COMPLETED and removed when it moves away from it
+func (p *TaskService) TaskUpdate(ctx context.Context, g *models.Task, actorId string, clear ...string) (*models.Task, error) {
 	var filter models.Task
 	err := g.Validate("update")
 	if err != nil {
 		return nil, err
 	}
+	err = models.CheckTaskClearFields(clear)
+	if err != nil {
+		return nil, err
+	}
 	filter.Id = g.Id
 	f, err := newTaskModel(&filter)
 	if err != nil {
 		return nil, err
 	}
-	cur, TaskErr := p.taskHandler.FindOne(f)
+	cur, TaskErr := p.taskHandler.FindOne(ctx, f)
 	if TaskErr != nil {
 		return nil, errors.New("task not found")
 	}
+	if g.Labels != nil && len(g.Labels) == 0 {
+		clear = append(clear, "labels")
+	}
+	if g.BlockedBy != nil && len(g.BlockedBy) == 0 {
+		clear = append(clear, "blocked_by")
+	}
 	g.BuildUpdate(cur.toRoot())
 	gm, err := newTaskModel(g)
 	if err != nil {
 		return nil, err
 	}
-	err = p.checkLinkedRecords(&groupModel{Id: gm.GroupId}, &userModel{Id: gm.UserId})
+	gm.clearFields(clear...)
+	err = p.checkLinkedRecords(ctx, &groupModel{Id: gm.GroupId}, &userModel{Id: gm.UserId})
 	if err != nil {
 		return nil, err
 	}
-	gm, err = p.taskHandler.UpdateOne(f, gm)
+	if gm.AssigneeId != cur.AssigneeId || gm.GroupId != cur.GroupId {
+		err = p.checkAssignee(ctx, gm)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if gm.ParentId != cur.ParentId || !sameObjectIDs(gm.BlockedBy, cur.BlockedBy) || gm.GroupId != cur.GroupId {
+		err = p.checkRelations(ctx, gm)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var history *taskHistoryModel
+	if gm.Status != cur.Status {
+		workflow, err := p.taskWorkflow(ctx, gm.GroupId)
+		if err != nil {
+			return nil, err
+		}
+		if err = workflow.CheckTransition(cur.Status, gm.Status); err != nil {
+			return nil, err
+		}
+		if gm.Status == models.COMPLETED && workflow.EnforceBlockers {
+			if err = p.checkBlockers(ctx, gm); err != nil {
+				return nil, err
+			}
+		}
+		entry := &models.TaskHistory{TaskId: g.Id, GroupId: gm.GroupId.Hex(), ActorId: actorId, From: cur.Status, To: gm.Status}
+		if err = entry.Validate("create"); err != nil {
+			return nil, err
+		}
+		history, err = newTaskHistoryModel(entry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if gm.Status == models.COMPLETED && cur.Status != models.COMPLETED {
+		gm.CompletedAt = time.Now().UTC()
+	} else if gm.Status != models.COMPLETED && !cur.CompletedAt.IsZero() {
+		clear = append(clear, "completed_at")
+	}
+	gm, err = p.taskHandler.UpdateOne(ctx, f, gm)
 	if err != nil {
 		return nil, err
 	}
-	return gm.toRoot(), err
+	if len(clear) > 0 {
+		err = p.taskHandler.UnsetFields(ctx, &taskModel{Id: gm.Id}, clear...)
+		if err != nil {
+			return nil, err
+		}
+		gm.clearFields(clear...)
+	}
+	if history != nil {
+		_, err = p.historyHandler.InsertOne(ctx, history)
+		if err != nil {
+			return nil, err
+		}
+	}
+	task, prev := gm.toRoot(), cur.toRoot()
+	emit(p.db, models.EventTaskUpdated, task.GroupId, task, prev)
+	if history != nil {
+		emit(p.db, models.EventTaskStatusChanged, task.GroupId, task, prev)
+	}
+	if gm.Status == models.COMPLETED && cur.Status != models.COMPLETED {
+		// the task is already updated, so a series that fails to move on does not fail the update
+		if _, err = p.seriesService.TaskSeriesComplete(ctx, task, time.Now().UTC()); err != nil {
+			log.Println("task series error:", err)
+		}
+	}
+	return task, nil
+}
+
+// TaskHistoryFind is used to find the status transitions of a Task, oldest first
+func (p *TaskService) TaskHistoryFind(ctx context.Context, g *models.Task) ([]*models.TaskHistory, error) {
+	var history []*models.TaskHistory
+	if !g.CheckID("id") {
+		return history, errors.New("missing valid query filter")
+	}
+	hm, err := newTaskHistoryModel(&models.TaskHistory{TaskId: g.Id})
+	if err != nil {
+		return history, err
+	}
+	hms, err := p.historyHandler.FindMany(ctx, hm)
+	if err != nil {
+		return history, err
+	}
+	sort.SliceStable(hms, func(i, j int) bool {
+		return hms[i].CreatedAt.Before(hms[j].CreatedAt)
+	})
+	for _, h := range hms {
+		history = append(history, h.toRoot())
+	}
+	return history, nil
+}
+
+// TaskSubtree is used to find the tree of subtasks below a Task, oldest subtasks first
+func (p *TaskService) TaskSubtree(ctx context.Context, g *models.Task) ([]*models.TaskTree, error) {
+	gm, err := newTaskModel(&models.Task{Id: g.Id})
+	if err != nil {
+		return nil, err
+	}
+	gm, err = p.taskHandler.FindOne(ctx, gm)
+	if err != nil {
+		return nil, errors.New("task not found")
+	}
+	tasks, err := p.groupTasks(ctx, gm.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[primitive.ObjectID][]*taskModel)
+	for _, tm := range tasks {
+		if !tm.ParentId.IsZero() {
+			children[tm.ParentId] = append(children[tm.ParentId], tm)
+		}
+	}
+	visited := map[primitive.ObjectID]bool{gm.Id: true}
+	var build func(id primitive.ObjectID) []*models.TaskTree
+	build = func(id primitive.ObjectID) []*models.TaskTree {
+		var trees []*models.TaskTree
+		subtasks := children[id]
+		sort.SliceStable(subtasks, func(i, j int) bool {
+			return subtasks[i].CreatedAt.Before(subtasks[j].CreatedAt)
+		})
+		for _, tm := range subtasks {
+			if visited[tm.Id] {
+				continue
+			}
+			visited[tm.Id] = true
+			trees = append(trees, &models.TaskTree{Task: tm.toRoot(), Subtasks: build(tm.Id)})
+		}
+		return trees
+	}
+	return build(gm.Id), nil
+}
+
+// TaskDependencies is used to find the dependency graph of a Task, the tasks it is transitively blocked by
+// or that are transitively blocked by it, along with the "blocked by" links between them
+func (p *TaskService) TaskDependencies(ctx context.Context, g *models.Task) (*models.TaskGraph, error) {
+	gm, err := newTaskModel(&models.Task{Id: g.Id})
+	if err != nil {
+		return nil, err
+	}
+	gm, err = p.taskHandler.FindOne(ctx, gm)
+	if err != nil {
+		return nil, errors.New("task not found")
+	}
+	tasks, err := p.groupTasks(ctx, gm.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	dependents := make(map[primitive.ObjectID][]primitive.ObjectID)
+	for _, tm := range tasks {
+		for _, blocker := range tm.BlockedBy {
+			dependents[blocker] = append(dependents[blocker], tm.Id)
+		}
+	}
+	included := map[primitive.ObjectID]bool{gm.Id: true}
+	walk := func(next func(id primitive.ObjectID) []primitive.ObjectID) {
+		visited := map[primitive.ObjectID]bool{}
+		queue := []primitive.ObjectID{gm.Id}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			for _, n := range next(id) {
+				if _, ok := tasks[n]; ok {
+					included[n] = true
+					queue = append(queue, n)
+				}
+			}
+		}
+	}
+	walk(func(id primitive.ObjectID) []primitive.ObjectID {
+		if tm, ok := tasks[id]; ok {
+			return tm.BlockedBy
+		}
+		return nil
+	})
+	walk(func(id primitive.ObjectID) []primitive.ObjectID {
+		return dependents[id]
+	})
+	var nodes []*taskModel
+	for id := range included {
+		if tm, ok := tasks[id]; ok {
+			nodes = append(nodes, tm)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].CreatedAt.Before(nodes[j].CreatedAt)
+	})
+	graph := &models.TaskGraph{Tasks: []*models.Task{}, Edges: []*models.TaskDependency{}}
+	for _, tm := range nodes {
+		graph.Tasks = append(graph.Tasks, tm.toRoot())
+		for _, blocker := range tm.BlockedBy {
+			if included[blocker] {
+				graph.Edges = append(graph.Edges, &models.TaskDependency{TaskId: tm.Id.Hex(), BlockedBy: blocker.Hex()})
+			}
+		}
+	}
+	return graph, nil
 }
 
 // TaskDocInsert is used to insert a Task doc directly into mongodb for testing purposes
-func (p *TaskService) TaskDocInsert(g *models.Task) (*models.Task, error) {
+func (p *TaskService) TaskDocInsert(ctx context.Context, g *models.Task) (*models.Task, error) {
 	insertTask, err := newTaskModel(g)
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withTimeout(ctx, writeOperation)
 	defer cancel()
 	_, err = p.collection.InsertOne(ctx, insertTask)
 	if err != nil {
@@ -1,8 +1,11 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"testing"
 	"time"
 )
@@ -28,6 +31,19 @@ func Test_TaskCreate(t *testing.T) {
 				GroupId: "000000000000000000000002",
 			},
 		},
+		{
+			"assignee outside group",
+			&models.Task{Id: "000000000000000000000022"},
+			true,
+			&models.Task{
+				Id:         "000000000000000000000022",
+				Name:       "Task1",
+				Due:        time.Now().UTC(),
+				UserId:     "000000000000000000000012",
+				AssigneeId: "000000000000000000000011",
+				GroupId:    "000000000000000000000002",
+			},
+		},
 		{
 			"missing name",
 			&models.Task{Id: "000000000000000000000022"},
@@ -45,7 +61,7 @@ func Test_TaskCreate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := initTestTaskService()
 			fmt.Println("\n\nPRE CREATE: ", tt.task)
-			got, err := testService.TaskCreate(tt.task)
+			got, err := testService.TaskCreate(context.Background(), tt.task)
 			fmt.Println("\nPOST CREATE: ", got)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
@@ -61,7 +77,7 @@ func Test_TaskCreate(t *testing.T) {
 			var failMsg string
 			switch tt.name {
 			case "success":
-				if got.Id != tt.want.Id || got.CreatedAt.IsZero() || got.Status != tt.want.Status { // Asserting whether we get the correct wanted value
+				if got.Id != tt.want.Id || got.CreatedAt.IsZero() || got.Status != tt.want.Status || got.Priority != models.MEDIUM { // Asserting whether we get the correct wanted value
 					failMsg = fmt.Sprintf("TaskService.TaskCreate() = %v, want %v", got, tt.want)
 				}
 			}
@@ -104,7 +120,7 @@ func Test_TasksFind(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestTasks()
-			got, err := testService.TasksFind(tt.task)
+			got, err := testService.TasksFind(context.Background(), tt.task)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
 				t.Errorf("TaskService.TasksFind() error = %v, wantErr %v", err, tt.wantErr)
@@ -117,6 +133,220 @@ func Test_TasksFind(t *testing.T) {
 	}
 }
 
+func Test_TasksFindPage(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name      string // The name of the test
+		want      int    // What out instance we want our function to return.
+		wantTotal int64  // The total number of matches we want returned
+		wantFirst string // The id of the first task we want returned
+		wantErr   bool   // whether we want an error.
+		task      *models.Task
+		opts      *models.ListOptions
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"first page",
+			1,
+			2,
+			"000000000000000000000022",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 1},
+		},
+		{
+			"after cursor",
+			1,
+			2,
+			"000000000000000000000023",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 1, After: "000000000000000000000022"},
+		},
+		{
+			"offset",
+			1,
+			2,
+			"000000000000000000000023",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 1, Offset: 1},
+		},
+		{
+			"sort descending",
+			2,
+			2,
+			"000000000000000000000023",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 10, Sort: []string{"-name"}},
+		},
+		{
+			"equality filter",
+			1,
+			1,
+			"000000000000000000000022",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 10, Filters: map[string]string{"user_id": "000000000000000000000013"}},
+		},
+		{
+			"filter outside scope",
+			0,
+			0,
+			"",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 10, Filters: map[string]string{"group_id": "000000000000000000000003"}},
+		},
+		{
+			"assignee filter",
+			1,
+			1,
+			"000000000000000000000022",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 10, Filters: map[string]string{"assignee_id": "000000000000000000000012"}},
+		},
+		{
+			"priority filter",
+			1,
+			1,
+			"000000000000000000000023",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 10, Filters: map[string]string{"priority": string(models.MEDIUM)}},
+		},
+		{
+			"label filter",
+			1,
+			1,
+			"000000000000000000000022",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 10, Filters: map[string]string{"labels": "api"}},
+		},
+		{
+			"estimate range",
+			1,
+			1,
+			"000000000000000000000023",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 10, Ranges: map[string]string{"estimate_gte": "4", "estimate_lte": "10"}},
+		},
+		{
+			"completed range",
+			0,
+			0,
+			"",
+			false,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 10, Ranges: map[string]string{"completed_at_gte": "2020-01-01T00:00:00Z"}},
+		},
+		{
+			"invalid filter id",
+			0,
+			0,
+			"",
+			true,
+			&models.Task{GroupId: "000000000000000000000002"},
+			&models.ListOptions{Limit: 10, Filters: map[string]string{"user_id": "invalid"}},
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestTasks()
+			got, total, err := testService.TasksFindPage(context.Background(), tt.task, tt.opts)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TaskService.TasksFindPage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.want || total != tt.wantTotal { // Asserting whether we get the correct wanted value
+				t.Errorf("TaskService.TasksFindPage() = %v (total %v), want %v (total %v)", len(got), total, tt.want, tt.wantTotal)
+				return
+			}
+			if len(got) > 0 && got[0].Id != tt.wantFirst {
+				t.Errorf("TaskService.TasksFindPage() first = %v, want %v", got[0].Id, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func Test_TasksFindVisiblePage(t *testing.T) {
+	testService := setupTestTasks()
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string              // The name of the test
+		wantErr bool                // whether we want an error.
+		task    *models.Task        // The input of the test
+		opts    *models.ListOptions // The list options of the test
+		want    []string            // The ids of the tasks we want
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"owned and assigned", false, &models.Task{UserId: "000000000000000000000012", GroupId: "000000000000000000000002"}, &models.ListOptions{Limit: 10, Sort: []string{"name"}}, []string{"000000000000000000000022", "000000000000000000000023"}},
+		{"assigned filter", false, &models.Task{UserId: "000000000000000000000012", GroupId: "000000000000000000000002"}, &models.ListOptions{Limit: 10, Filters: map[string]string{"assignee_id": "000000000000000000000012"}}, []string{"000000000000000000000022"}},
+		{"owned", false, &models.Task{UserId: "000000000000000000000013", GroupId: "000000000000000000000002"}, &models.ListOptions{Limit: 10}, []string{"000000000000000000000022"}},
+		{"other group", false, &models.Task{UserId: "000000000000000000000012", GroupId: "000000000000000000000003"}, &models.ListOptions{Limit: 10}, nil},
+		{"missing scope", true, &models.Task{GroupId: "000000000000000000000002"}, &models.ListOptions{Limit: 10}, nil},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := testService.TasksFindVisiblePage(context.Background(), tt.task, tt.opts)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TaskService.TasksFindVisiblePage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var ids []string
+			for _, task := range got {
+				ids = append(ids, task.Id)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) || total != int64(len(tt.want)) {
+				t.Errorf("TaskService.TasksFindVisiblePage() = %v (total %v), want %v", ids, total, tt.want)
+			}
+		})
+	}
+}
+
+func Test_TasksFindCalendar(t *testing.T) {
+	testService := setupTestTasks()
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string       // The name of the test
+		wantErr bool         // whether we want an error.
+		task    *models.Task // The input of the test
+		want    []string     // The ids of the tasks we want
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"owned and assigned", false, &models.Task{UserId: "000000000000000000000012"}, []string{"000000000000000000000023", "000000000000000000000022"}},
+		{"owned", false, &models.Task{UserId: "000000000000000000000013"}, []string{"000000000000000000000022"}},
+		{"group", false, &models.Task{GroupId: "000000000000000000000002"}, []string{"000000000000000000000022", "000000000000000000000023"}},
+		{"missing scope", true, &models.Task{}, nil},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testService.TasksFindCalendar(context.Background(), tt.task)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TaskService.TasksFindCalendar() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var ids []string
+			for _, task := range got {
+				ids = append(ids, task.Id)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("TaskService.TasksFindCalendar() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
 func Test_TaskFind(t *testing.T) {
 	// Defining our test slice. Each unit test should have the following properties:
 	tests := []struct {
@@ -137,7 +367,7 @@ func Test_TaskFind(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestTasks()
-			got, err := testService.TaskFind(tt.task)
+			got, err := testService.TaskFind(context.Background(), tt.task)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
 				t.Errorf("TaskService.TaskFind() error = %v, wantErr %v", err, tt.wantErr)
@@ -168,11 +398,17 @@ func Test_TaskUpdate(t *testing.T) {
 	}{
 		// Here we're declaring each unit test input and output data as defined before
 		{
-			"complete task",
-			&models.Task{Id: "000000000000000000000022", Name: "Task1", Status: models.COMPLETED},
-			false,
+			"skip to completed",
+			&models.Task{Id: "000000000000000000000022", Name: "Task1"},
+			true,
 			&models.Task{Id: "000000000000000000000022", Status: models.COMPLETED},
 		},
+		{
+			"unknown status",
+			&models.Task{Id: "000000000000000000000022", Name: "Task1"},
+			true,
+			&models.Task{Id: "000000000000000000000022", Status: "ARCHIVED"},
+		},
 		{
 			"in progress task",
 			&models.Task{Id: "000000000000000000000022", Name: "Task1", Status: models.INPROGRESS},
@@ -185,12 +421,24 @@ func Test_TaskUpdate(t *testing.T) {
 			true,
 			&models.Task{Id: "000000000000000000000022", UserId: "000000000000000000000002", Status: models.COMPLETED},
 		},
+		{
+			"assignee outside group",
+			&models.Task{Id: "000000000000000000000023", Name: "Task2"},
+			true,
+			&models.Task{Id: "000000000000000000000023", AssigneeId: "000000000000000000000011"},
+		},
+		{
+			"reassign task",
+			&models.Task{Id: "000000000000000000000023", Name: "Task2", AssigneeId: "000000000000000000000013", Priority: models.URGENT},
+			false,
+			&models.Task{Id: "000000000000000000000023", AssigneeId: "000000000000000000000013", Priority: models.URGENT},
+		},
 	}
 	// Iterating over the previous test slice
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestTasks()
-			got, err := testService.TaskUpdate(tt.task)
+			got, err := testService.TaskUpdate(context.Background(), tt.task, "000000000000000000000012")
 			// Checking the error
 			if (err != nil) != tt.wantErr {
 				t.Errorf("TaskService.TaskUpdate() error = %v, wantErr %v", err, tt.wantErr)
@@ -198,14 +446,14 @@ func Test_TaskUpdate(t *testing.T) {
 			}
 			var failMsg string
 			switch tt.name {
-			case "complete task":
-				if got.Status != models.COMPLETED || got.Name != tt.want.Name { // Asserting whether we get the correct wanted value
-					failMsg = fmt.Sprintf("TaskService.TaskUpdate() = %v, want %v", got.Name, tt.want.Name)
-				}
 			case "in progress task":
 				if got.Status != models.INPROGRESS || got.Name != tt.want.Name { // Asserting whether we get the correct wanted value
 					failMsg = fmt.Sprintf("TaskService.TaskUpdate() = %v, want %v", got.Name, tt.want.Name)
 				}
+			case "reassign task":
+				if got.AssigneeId != tt.want.AssigneeId || got.Priority != tt.want.Priority || got.Name != tt.want.Name { // Asserting whether we get the correct wanted value
+					failMsg = fmt.Sprintf("TaskService.TaskUpdate() = %v, want %v", got, tt.want)
+				}
 			}
 
 			if failMsg != "" {
@@ -215,6 +463,230 @@ func Test_TaskUpdate(t *testing.T) {
 	}
 }
 
+func Test_TaskUpdateClear(t *testing.T) {
+	testService := setupTestTasks()
+	got, err := testService.TaskUpdate(context.Background(), &models.Task{Id: "000000000000000000000022", Labels: []string{}}, "000000000000000000000012", "assignee_id", "estimate")
+	if err != nil {
+		t.Fatalf("TaskService.TaskUpdate() error = %v", err)
+	}
+	found, err := testService.TaskFind(context.Background(), &models.Task{Id: "000000000000000000000022"})
+	if err != nil {
+		t.Fatalf("TaskService.TaskFind() error = %v", err)
+	}
+	for _, task := range []*models.Task{got, found} {
+		if task.AssigneeId != "" || task.Estimate != 0 || len(task.Labels) != 0 || task.Name != "Task1" {
+			t.Errorf("TaskService.TaskUpdate() did not clear the task fields: %+v", task)
+		}
+	}
+	if _, err = testService.TaskUpdate(context.Background(), &models.Task{Id: "000000000000000000000022"}, "000000000000000000000012", "name"); err == nil {
+		t.Errorf("TaskService.TaskUpdate() cleared a required field")
+	}
+}
+
+func Test_TaskWorkflow(t *testing.T) {
+	testService := setupTestTasks()
+	workflow := &models.TaskWorkflow{
+		Statuses: []models.TaskStatus{models.NOTSTARTED, "IN_REVIEW", models.COMPLETED},
+		Transitions: []models.TaskStatusTransition{
+			{From: models.NOTSTARTED, To: "IN_REVIEW"},
+			{From: "IN_REVIEW", To: models.COMPLETED},
+			{From: models.COMPLETED, To: "IN_REVIEW"},
+		},
+	}
+	groupId, _ := primitive.ObjectIDFromHex("000000000000000000000002")
+	gm, err := testService.groupHandler.FindOne(context.Background(), &groupModel{Id: groupId})
+	if err != nil {
+		t.Fatalf("DBHandler.FindOne() error = %v", err)
+	}
+	gm.TaskWorkflow = newTaskWorkflowModel(workflow)
+	if _, err = testService.groupHandler.UpdateOne(context.Background(), &groupModel{Id: gm.Id}, gm); err != nil {
+		t.Fatalf("DBHandler.UpdateOne() error = %v", err)
+	}
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string            // The name of the test
+		status  models.TaskStatus // The status the task moves to
+		wantErr bool              // whether we want an error.
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"default transition", models.INPROGRESS, true},
+		{"custom status", "IN_REVIEW", false},
+		{"complete", models.COMPLETED, false},
+		{"reopen", "IN_REVIEW", false},
+	}
+	// Iterating over the previous test slice, each test moves the same task
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testService.TaskUpdate(context.Background(), &models.Task{Id: "000000000000000000000022", Status: tt.status}, "000000000000000000000012")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TaskService.TaskUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Status != tt.status || got.CompletedAt.IsZero() != (tt.status != models.COMPLETED) {
+				t.Errorf("TaskService.TaskUpdate() = %+v", got)
+			}
+		})
+	}
+	history, err := testService.TaskHistoryFind(context.Background(), &models.Task{Id: "000000000000000000000022"})
+	if err != nil {
+		t.Fatalf("TaskService.TaskHistoryFind() error = %v", err)
+	}
+	if len(history) != 3 || history[0].From != models.NOTSTARTED || history[2].To != "IN_REVIEW" || history[0].ActorId != "000000000000000000000012" {
+		t.Errorf("TaskService.TaskHistoryFind() = %+v", history)
+	}
+}
+
+// setupTestTaskRelations adds a subtask of Task1 that is blocked by Task2 to the test tasks
+func setupTestTaskRelations(t *testing.T) *TaskService {
+	testService := setupTestTasks()
+	_, err := testService.TaskCreate(context.Background(), &models.Task{
+		Id:        "000000000000000000000024",
+		Name:      "Task3",
+		Due:       time.Now().UTC(),
+		UserId:    "000000000000000000000012",
+		GroupId:   "000000000000000000000002",
+		ParentId:  "000000000000000000000022",
+		BlockedBy: []string{"000000000000000000000023"},
+	})
+	if err != nil {
+		t.Fatalf("TaskService.TaskCreate() error = %v", err)
+	}
+	return testService
+}
+
+func Test_TaskRelations(t *testing.T) {
+	testService := setupTestTaskRelations(t)
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name      string       // The name of the test
+		task      *models.Task // The input of the test
+		wantErr   bool         // whether we want an error.
+		wantCycle bool         // whether we want a CycleError
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"parent cycle", &models.Task{Id: "000000000000000000000022", ParentId: "000000000000000000000024"}, true, true},
+		{"blocker cycle", &models.Task{Id: "000000000000000000000023", BlockedBy: []string{"000000000000000000000024"}}, true, true},
+		{"blocker chain", &models.Task{Id: "000000000000000000000022", BlockedBy: []string{"000000000000000000000024"}}, false, false},
+		{"transitive blocker cycle", &models.Task{Id: "000000000000000000000023", BlockedBy: []string{"000000000000000000000022"}}, true, true},
+		{"blocker outside group", &models.Task{Id: "000000000000000000000023", BlockedBy: []string{"000000000000000000000099"}}, true, false},
+		{"parent outside group", &models.Task{Id: "000000000000000000000023", ParentId: "000000000000000000000099"}, true, false},
+	}
+	// Iterating over the previous test slice, each test updates the same tasks
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testService.TaskUpdate(context.Background(), tt.task, "000000000000000000000012")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TaskService.TaskUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var cycleErr *models.CycleError
+			if errors.As(err, &cycleErr) != tt.wantCycle {
+				t.Errorf("TaskService.TaskUpdate() error = %v, wantCycle %v", err, tt.wantCycle)
+			}
+		})
+	}
+	got, err := testService.TaskUpdate(context.Background(), &models.Task{Id: "000000000000000000000022", BlockedBy: []string{}}, "000000000000000000000012")
+	if err != nil || len(got.BlockedBy) != 0 {
+		t.Errorf("TaskService.TaskUpdate() = %+v, error = %v", got, err)
+	}
+}
+
+func Test_TaskBlockedCompletion(t *testing.T) {
+	testService := setupTestTaskRelations(t)
+	groupId, _ := primitive.ObjectIDFromHex("000000000000000000000002")
+	gm, err := testService.groupHandler.FindOne(context.Background(), &groupModel{Id: groupId})
+	if err != nil {
+		t.Fatalf("DBHandler.FindOne() error = %v", err)
+	}
+	workflow := models.DefaultTaskWorkflow()
+	workflow.EnforceBlockers = true
+	gm.TaskWorkflow = newTaskWorkflowModel(workflow)
+	if _, err = testService.groupHandler.UpdateOne(context.Background(), &groupModel{Id: gm.Id}, gm); err != nil {
+		t.Fatalf("DBHandler.UpdateOne() error = %v", err)
+	}
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name        string       // The name of the test
+		task        *models.Task // The input of the test
+		wantBlocked bool         // whether we want a BlockedError
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"start blocked task", &models.Task{Id: "000000000000000000000024", Status: models.INPROGRESS}, false},
+		{"complete blocked task", &models.Task{Id: "000000000000000000000024", Status: models.COMPLETED}, true},
+		{"start blocker", &models.Task{Id: "000000000000000000000023", Status: models.INPROGRESS}, false},
+		{"complete blocker", &models.Task{Id: "000000000000000000000023", Status: models.COMPLETED}, false},
+		{"complete unblocked task", &models.Task{Id: "000000000000000000000024", Status: models.COMPLETED}, false},
+	}
+	// Iterating over the previous test slice, each test moves the same tasks
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testService.TaskUpdate(context.Background(), tt.task, "000000000000000000000012")
+			var blockedErr *models.BlockedError
+			if errors.As(err, &blockedErr) != tt.wantBlocked || (err != nil && blockedErr == nil) {
+				t.Errorf("TaskService.TaskUpdate() error = %v, wantBlocked %v", err, tt.wantBlocked)
+			}
+		})
+	}
+}
+
+func Test_TaskSubtree(t *testing.T) {
+	testService := setupTestTaskRelations(t)
+	_, err := testService.TaskCreate(context.Background(), &models.Task{
+		Id:       "000000000000000000000025",
+		Name:     "Task4",
+		Due:      time.Now().UTC(),
+		UserId:   "000000000000000000000012",
+		GroupId:  "000000000000000000000002",
+		ParentId: "000000000000000000000024",
+	})
+	if err != nil {
+		t.Fatalf("TaskService.TaskCreate() error = %v", err)
+	}
+	got, err := testService.TaskSubtree(context.Background(), &models.Task{Id: "000000000000000000000022"})
+	if err != nil {
+		t.Fatalf("TaskService.TaskSubtree() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Id != "000000000000000000000024" || len(got[0].Subtasks) != 1 || got[0].Subtasks[0].Id != "000000000000000000000025" {
+		t.Errorf("TaskService.TaskSubtree() = %+v", got)
+	}
+	got, err = testService.TaskSubtree(context.Background(), &models.Task{Id: "000000000000000000000023"})
+	if err != nil || len(got) != 0 {
+		t.Errorf("TaskService.TaskSubtree() = %+v, error = %v", got, err)
+	}
+}
+
+func Test_TaskDependencies(t *testing.T) {
+	testService := setupTestTaskRelations(t)
+	if _, err := testService.TaskUpdate(context.Background(), &models.Task{Id: "000000000000000000000022", BlockedBy: []string{"000000000000000000000024"}}, "000000000000000000000012"); err != nil {
+		t.Fatalf("TaskService.TaskUpdate() error = %v", err)
+	}
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name  string // The name of the test
+		id    string // The id of the task
+		tasks int    // The number of tasks we want in the graph
+		edges int    // The number of edges we want in the graph
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"middle of the chain", "000000000000000000000024", 3, 2},
+		{"end of the chain", "000000000000000000000022", 3, 2},
+		{"start of the chain", "000000000000000000000023", 3, 2},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testService.TaskDependencies(context.Background(), &models.Task{Id: tt.id})
+			if err != nil {
+				t.Fatalf("TaskService.TaskDependencies() error = %v", err)
+			}
+			if len(got.Tasks) != tt.tasks || len(got.Edges) != tt.edges {
+				t.Errorf("TaskService.TaskDependencies() = %d tasks and %d edges, want %d and %d", len(got.Tasks), len(got.Edges), tt.tasks, tt.edges)
+			}
+		})
+	}
+}
+
 func Test_TaskDelete(t *testing.T) {
 	// Defining our test slice. Each unit test should have the following properties:
 	tests := []struct {
@@ -241,7 +713,7 @@ func Test_TaskDelete(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestTasks()
-			got, err := testService.TaskDelete(tt.task)
+			got, err := testService.TaskDelete(context.Background(), tt.task)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
 				t.Errorf("TaskService.TaskDelete() error = %v, wantErr %v", err, tt.wantErr)
@@ -264,3 +736,66 @@ func Test_TaskDelete(t *testing.T) {
 		})
 	}
 }
+
+func Test_TaskRestore(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string       // The name of the test
+		want    *models.Task // What out instance we want our function to return.
+		wantErr bool         // whether we want an error.
+		task    *models.Task
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			&models.Task{Id: "000000000000000000000022", Name: "Task1"},
+			false,
+			&models.Task{Id: "000000000000000000000022"},
+		},
+		{
+			"task not deleted",
+			nil,
+			true,
+			&models.Task{Id: "000000000000000000000023"},
+		},
+		{
+			"out of group scope",
+			nil,
+			true,
+			&models.Task{Id: "000000000000000000000022", GroupId: "000000000000000000000003"},
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestTasks()
+			_, err := testService.TaskDelete(context.Background(), &models.Task{Id: "000000000000000000000022"})
+			if err != nil {
+				t.Errorf("TaskService.TaskDelete() error = %v", err)
+				return
+			}
+			_, err = testService.TaskFind(context.Background(), &models.Task{Id: "000000000000000000000022"})
+			if err == nil {
+				t.Errorf("TaskService.TaskFind() found a soft deleted task")
+				return
+			}
+			got, err := testService.TaskRestore(context.Background(), tt.task)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TaskService.TaskRestore() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var failMsg string
+			switch tt.name {
+			case "success":
+				found, err := testService.TaskFind(context.Background(), &models.Task{Id: tt.want.Id})
+				if got.Id != tt.want.Id || err != nil || found.Name != tt.want.Name { // Asserting whether we get the correct wanted value
+					failMsg = fmt.Sprintf("TaskService.TaskRestore() = %v, want %v", got, tt.want)
+				}
+			}
+			if failMsg != "" {
+				t.Errorf(failMsg)
+			}
+		})
+	}
+}
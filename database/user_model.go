@@ -20,12 +20,19 @@ type userModel struct {
 	RootAdmin    bool               `bson:"root_admin,omitempty"`
 	GroupId      primitive.ObjectID `bson:"group_id,omitempty"`
 	ImageId      primitive.ObjectID `bson:"image_id,omitempty"`
+	TwoFactor    bool               `bson:"two_factor,omitempty"`
+	TOTPSecret   string             `bson:"totp_secret,omitempty"`
+	TOTPStep     int64              `bson:"totp_step,omitempty"`
+	Recovery     []string           `bson:"recovery_codes,omitempty"`
+	VerifiedAt   time.Time          `bson:"email_verified_at,omitempty"`
+	LockedUntil  time.Time          `bson:"locked_until,omitempty"`
 	LastModified time.Time          `bson:"last_modified,omitempty"`
 	CreatedAt    time.Time          `bson:"created_at,omitempty"`
 	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
 }
 
 // newUserModel initializes a new pointer to a userModel struct from a pointer to a JSON User struct
+// The 2FA, email verification and lockout fields are left out so they can only be changed by their dedicated UserService methods
 func newUserModel(u *models.User) (um *userModel, err error) {
 	um = &userModel{
 		Username:     u.Username,
@@ -83,9 +90,30 @@ func (u *userModel) update(doc interface{}) (err error) {
 	if len(um.Role) > 0 {
 		u.Role = um.Role
 	}
+	if um.TwoFactor {
+		u.TwoFactor = um.TwoFactor
+	}
+	if len(um.TOTPSecret) > 0 {
+		u.TOTPSecret = um.TOTPSecret
+	}
+	if um.TOTPStep > 0 {
+		u.TOTPStep = um.TOTPStep
+	}
+	if len(um.Recovery) > 0 {
+		u.Recovery = um.Recovery
+	}
+	if !um.VerifiedAt.IsZero() {
+		u.VerifiedAt = um.VerifiedAt
+	}
+	if !um.LockedUntil.IsZero() {
+		u.LockedUntil = um.LockedUntil
+	}
 	if !um.LastModified.IsZero() {
 		u.LastModified = um.LastModified
 	}
+	if !um.DeletedAt.IsZero() {
+		u.DeletedAt = um.DeletedAt
+	}
 	return
 }
 
@@ -134,6 +162,11 @@ func (u *userModel) getID() (id interface{}) {
 	return u.Id
 }
 
+// getDeletedAt returns the time the userModel was soft deleted at
+func (u *userModel) getDeletedAt() time.Time {
+	return u.DeletedAt
+}
+
 // addTimeStamps updates an userModel struct with a timestamp
 func (u *userModel) addTimeStamps(newRecord bool) {
 	currentTime := time.Now().UTC()
@@ -156,7 +189,6 @@ func (u *userModel) postProcess() (err error) {
 	if u.Email == "" {
 		err = errors.New("user record does not have an email")
 	}
-	// TODO - When implementing soft delete, DeletedAt can be checked here to ensure deleted users are filtered out
 	return
 }
 
@@ -205,6 +237,12 @@ func (u *userModel) toRoot() *models.User {
 		RootAdmin:    u.RootAdmin,
 		GroupId:      u.GroupId.Hex(),
 		ImageId:      u.ImageId.Hex(),
+		TwoFactor:    u.TwoFactor,
+		TOTPSecret:   u.TOTPSecret,
+		TOTPStep:     u.TOTPStep,
+		Recovery:     u.Recovery,
+		VerifiedAt:   u.VerifiedAt,
+		LockedUntil:  u.LockedUntil,
 		LastModified: u.LastModified,
 		CreatedAt:    u.CreatedAt,
 		DeletedAt:    u.DeletedAt,
@@ -26,7 +26,7 @@ func NewUserService(db DBClient, uHandler *DBHandler[*userModel], gHandler *DBHa
 }
 
 // checkLinkedRecords ensures the email is unique and groupId valid for a User
-func (p *UserService) checkLinkedRecords(g *groupModel, u *userModel, curUser *userModel) error {
+func (p *UserService) checkLinkedRecords(ctx context.Context, g *groupModel, u *userModel, curUser *userModel) error {
 	var wg sync.WaitGroup
 	uCh := make(chan *userModel)
 	uErr := make(chan error)
@@ -35,8 +35,8 @@ func (p *UserService) checkLinkedRecords(g *groupModel, u *userModel, curUser *u
 	uRoutine := p.userHandler.newRoutine()
 	gRoutine := p.groupHandler.newRoutine()
 	wg.Add(2)
-	go uRoutine.execute(FindOne, uCh, uErr, u, nil)
-	go gRoutine.execute(FindOne, gCh, gErr, g, nil)
+	go uRoutine.execute(ctx, FindOne, uCh, uErr, u, nil)
+	go gRoutine.execute(ctx, FindOne, gCh, gErr, g, nil)
 	go uRoutine.resolve(uCh, uErr, &wg)
 	go gRoutine.resolve(gCh, gErr, &wg)
 	wg.Wait()
@@ -55,12 +55,12 @@ func (p *UserService) checkLinkedRecords(g *groupModel, u *userModel, curUser *u
 }
 
 // AuthenticateUser is used to authenticate users that are signing in
-func (p *UserService) AuthenticateUser(u *models.User) (*models.User, error) {
+func (p *UserService) AuthenticateUser(ctx context.Context, u *models.User) (*models.User, error) {
 	um, err := newUserModel(u)
 	if err != nil {
 		return nil, err
 	}
-	checkUser, err := p.userHandler.FindOne(um)
+	checkUser, err := p.userHandler.FindOne(ctx, um)
 	if err != nil {
 		return nil, errors.New("invalid email")
 	}
@@ -73,7 +73,7 @@ func (p *UserService) AuthenticateUser(u *models.User) (*models.User, error) {
 }
 
 // UserCreate is used to create a new user
-func (p *UserService) UserCreate(u *models.User) (*models.User, error) {
+func (p *UserService) UserCreate(ctx context.Context, u *models.User) (*models.User, error) {
 	if u.Id == "" {
 		u.Id = utilities.GenerateObjectID()
 	}
@@ -81,13 +81,13 @@ func (p *UserService) UserCreate(u *models.User) (*models.User, error) {
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withTimeout(ctx, writeOperation)
 	defer cancel()
 	docCount, err := p.collection.CountDocuments(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
-	err = p.checkLinkedRecords(&groupModel{Id: um.GroupId}, &userModel{Email: um.Email}, nil)
+	err = p.checkLinkedRecords(ctx, &groupModel{Id: um.GroupId}, &userModel{Email: um.Email}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -99,54 +99,116 @@ func (p *UserService) UserCreate(u *models.User) (*models.User, error) {
 	if docCount == 0 {
 		u.Role = "admin"
 		u.RootAdmin = true
-	} else if u.Role != "admin" {
-		u.Role = "member"
+	} else if u.Role == "" {
+		u.Role = models.MemberRole
 	}
 	um, err = newUserModel(u)
 	if err != nil {
 		return nil, err
 	}
-	um, err = p.userHandler.InsertOne(um)
+	um, err = p.userHandler.InsertOne(ctx, um)
 	if err != nil {
 		return nil, err
 	}
-	return um.toRoot(), err
+	user := um.toRoot()
+	emitUser(p.db, models.EventUserCreated, user, nil)
+	return user, nil
 }
 
 // UserDelete is used to delete an User
-func (p *UserService) UserDelete(u *models.User) (*models.User, error) {
+func (p *UserService) UserDelete(ctx context.Context, u *models.User) (*models.User, error) {
 	um, err := newUserModel(u)
 	if err != nil {
 		return nil, err
 	}
-	um, err = p.userHandler.DeleteOne(um)
+	um, err = p.userHandler.DeleteOne(ctx, um)
 	if err != nil {
 		return nil, err
 	}
-	return um.toRoot(), err
+	user := um.toRoot()
+	emitUser(p.db, models.EventUserDeleted, user, nil)
+	return user, nil
+}
+
+// UserPurge is used to permanently remove an User, such as one whose creation is rolled back
+func (p *UserService) UserPurge(ctx context.Context, u *models.User) (*models.User, error) {
+	if !u.CheckID("id") {
+		return nil, errors.New("missing valid query filter")
+	}
+	um, err := newUserModel(&models.User{Id: u.Id})
+	if err != nil {
+		return nil, err
+	}
+	um, err = p.userHandler.PurgeOne(ctx, um)
+	if err != nil {
+		return nil, err
+	}
+	user := um.toRoot()
+	emitUser(p.db, models.EventUserDeleted, user, nil)
+	return user, nil
 }
 
 // UserDeleteMany is used to delete many Users
-func (p *UserService) UserDeleteMany(u *models.User) (*models.User, error) {
+func (p *UserService) UserDeleteMany(ctx context.Context, u *models.User) (*models.User, error) {
 	um, err := newUserModel(u)
 	if err != nil {
 		return nil, err
 	}
-	um, err = p.userHandler.DeleteMany(um)
+	um, err = p.userHandler.DeleteMany(ctx, um)
 	if err != nil {
 		return nil, err
 	}
 	return um.toRoot(), err
 }
 
+// UserRestore is used to restore a soft deleted User
+func (p *UserService) UserRestore(ctx context.Context, u *models.User) (*models.User, error) {
+	um, err := newUserModel(u)
+	if err != nil {
+		return nil, err
+	}
+	dm, err := p.userHandler.FindOneDeleted(ctx, &userModel{Id: um.Id, Email: um.Email})
+	if err != nil {
+		return nil, errors.New("deleted user not found")
+	}
+	if u.CheckID("group_id") && dm.GroupId != um.GroupId {
+		return nil, errors.New("deleted user not found")
+	}
+	err = p.checkLinkedRecords(ctx, &groupModel{Id: dm.GroupId}, &userModel{Email: dm.Email}, nil)
+	if err != nil {
+		return nil, err
+	}
+	dm, err = p.userHandler.RestoreOne(ctx, &userModel{Id: dm.Id})
+	if err != nil {
+		return nil, err
+	}
+	user := dm.toRoot()
+	emitUser(p.db, models.EventUserRestored, user, nil)
+	return user, nil
+}
+
+// UserRestoreMany is used to restore many Users that were soft deleted at or after since
+func (p *UserService) UserRestoreMany(ctx context.Context, u *models.User, since time.Time) error {
+	um, err := newUserModel(u)
+	if err != nil {
+		return err
+	}
+	return p.userHandler.RestoreMany(ctx, um, since)
+}
+
+// UsersPurge is used to permanently remove Users that were soft deleted at or before a given time
+func (p *UserService) UsersPurge(ctx context.Context, before time.Time) (int64, error) {
+	return p.userHandler.Purge(ctx, before)
+}
+
 // UsersFind is used to find all user docs
-func (p *UserService) UsersFind(u *models.User) ([]*models.User, error) {
+func (p *UserService) UsersFind(ctx context.Context, u *models.User) ([]*models.User, error) {
 	var users []*models.User
 	um, err := newUserModel(u)
 	if err != nil {
 		return users, err
 	}
-	ums, err := p.userHandler.FindMany(um)
+	ums, err := p.userHandler.FindMany(ctx, um)
 	if err != nil {
 		return users, err
 	}
@@ -156,13 +218,30 @@ func (p *UserService) UsersFind(u *models.User) ([]*models.User, error) {
 	return users, nil
 }
 
+// UsersFindPage is used to find a sorted, filtered and paginated page of user docs along with the total number of matches
+func (p *UserService) UsersFindPage(ctx context.Context, u *models.User, o *models.ListOptions) ([]*models.User, int64, error) {
+	var users []*models.User
+	um, err := newUserModel(u)
+	if err != nil {
+		return users, 0, err
+	}
+	ums, total, err := p.userHandler.FindPage(ctx, um, o)
+	if err != nil {
+		return users, 0, err
+	}
+	for _, m := range ums {
+		users = append(users, m.toRoot())
+	}
+	return users, total, nil
+}
+
 // UserFind is used to find a specific user doc
-func (p *UserService) UserFind(u *models.User) (*models.User, error) {
+func (p *UserService) UserFind(ctx context.Context, u *models.User) (*models.User, error) {
 	um, err := newUserModel(u)
 	if err != nil {
 		return nil, err
 	}
-	um, err = p.userHandler.FindOne(um)
+	um, err = p.userHandler.FindOne(ctx, um)
 	if err != nil {
 		return nil, err
 	}
@@ -170,12 +249,12 @@ func (p *UserService) UserFind(u *models.User) (*models.User, error) {
 }
 
 // UserUpdate is used to update an existing user doc
-func (p *UserService) UserUpdate(u *models.User) (*models.User, error) {
+func (p *UserService) UserUpdate(ctx context.Context, u *models.User) (*models.User, error) {
 	filter, err := u.BuildFilter()
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withTimeout(ctx, writeOperation)
 	defer cancel()
 	docCount, err := p.collection.CountDocuments(ctx, bson.M{})
 	if err != nil {
@@ -188,7 +267,7 @@ func (p *UserService) UserUpdate(u *models.User) (*models.User, error) {
 	if err != nil {
 		return nil, err
 	}
-	curUser, err := p.userHandler.FindOne(f)
+	curUser, err := p.userHandler.FindOne(ctx, f)
 	if err != nil {
 		return u, err
 	}
@@ -197,7 +276,7 @@ func (p *UserService) UserUpdate(u *models.User) (*models.User, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = p.checkLinkedRecords(&groupModel{Id: um.GroupId}, &userModel{Email: um.Email}, curUser)
+	err = p.checkLinkedRecords(ctx, &groupModel{Id: um.GroupId}, &userModel{Email: um.Email}, curUser)
 	if err != nil {
 		return nil, err
 	}
@@ -208,52 +287,174 @@ func (p *UserService) UserUpdate(u *models.User) (*models.User, error) {
 		}
 		um.Password = u.Password
 	}
-	um, err = p.userHandler.UpdateOne(f, um)
+	emailChanged := um.Email != curUser.Email
+	um, err = p.userHandler.UpdateOne(ctx, f, um)
 	if err != nil {
 		return nil, err
 	}
-	return um.toRoot(), err
+	if emailChanged && !curUser.VerifiedAt.IsZero() { // a new email address needs to be verified again
+		err = p.userHandler.UnsetFields(ctx, &userModel{Id: curUser.Id}, "email_verified_at")
+		if err != nil {
+			return nil, err
+		}
+	}
+	user := um.toRoot()
+	emitUser(p.db, models.EventUserUpdated, user, curUser.toRoot())
+	return user, nil
 }
 
 // UpdatePassword is used to update the currently logged-in user's password
-func (p *UserService) UpdatePassword(u *models.User, currentPassword string, newPassword string) (*models.User, error) {
+func (p *UserService) UpdatePassword(ctx context.Context, u *models.User, currentPassword string, newPassword string) (*models.User, error) {
 	um, err := newUserModel(u)
 	if err != nil {
 		return nil, err
 	}
-	user, err := p.userHandler.FindOne(um)
+	user, err := p.userHandler.FindOne(ctx, um)
 	if err != nil {
 		return nil, err
 	}
 	rootUser := user.toRoot()
 	err = rootUser.Authenticate(currentPassword)
 	if err == nil { // 3. Update doc with new password
-		currentTime := time.Now().UTC()
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+		return p.setPassword(ctx, user, newPassword)
+	}
+	return nil, errors.New("invalid password")
+}
+
+// UserResetPassword is used to set a new password for a User that has proven ownership of its email with a reset token
+func (p *UserService) UserResetPassword(ctx context.Context, u *models.User, newPassword string) (*models.User, error) {
+	if !u.CheckID("id") {
+		return nil, errors.New("missing valid query filter")
+	}
+	if newPassword == "" {
+		return nil, errors.New("missing new password")
+	}
+	um, err := newUserModel(&models.User{Id: u.Id})
+	if err != nil {
+		return nil, err
+	}
+	user, err := p.userHandler.FindOne(ctx, um)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return p.setPassword(ctx, user, newPassword)
+}
+
+// setPassword hashes and stores a new password for a found userModel
+func (p *UserService) setPassword(ctx context.Context, user *userModel, newPassword string) (*models.User, error) {
+	currentTime := time.Now().UTC()
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.D{{"_id", user.Id}}
+	update := bson.D{{"$set",
+		bson.D{
+			{"password", string(hashedPassword)},
+			{"last_modified", currentTime},
+		},
+	}}
+	ctx, cancel := withTimeout(ctx, writeOperation)
+	defer cancel()
+	_, err = p.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return user.toRoot(), nil
+}
+
+// UserVerifyEmail is used to record that a User has verified its current email address
+func (p *UserService) UserVerifyEmail(ctx context.Context, u *models.User) (*models.User, error) {
+	if !u.CheckID("id") {
+		return nil, errors.New("missing valid query filter")
+	}
+	um, err := newUserModel(&models.User{Id: u.Id})
+	if err != nil {
+		return nil, err
+	}
+	curUser, err := p.userHandler.FindOne(ctx, um)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	curUser.VerifiedAt = time.Now().UTC()
+	curUser, err = p.userHandler.UpdateOne(ctx, um, curUser)
+	if err != nil {
+		return nil, err
+	}
+	return curUser.toRoot(), nil
+}
+
+// UserTwoFactorUpdate is used to store the 2FA settings of a User, settings that are cleared are removed from the user doc
+func (p *UserService) UserTwoFactorUpdate(ctx context.Context, u *models.User) (*models.User, error) {
+	if !u.CheckID("id") {
+		return nil, errors.New("missing valid query filter")
+	}
+	um, err := newUserModel(&models.User{Id: u.Id})
+	if err != nil {
+		return nil, err
+	}
+	curUser, err := p.userHandler.FindOne(ctx, um)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	var unset []string
+	curUser.TwoFactor, curUser.TOTPSecret, curUser.TOTPStep, curUser.Recovery = u.TwoFactor, u.TOTPSecret, u.TOTPStep, u.Recovery
+	if !u.TwoFactor {
+		unset = append(unset, "two_factor")
+	}
+	if u.TOTPSecret == "" {
+		unset = append(unset, "totp_secret")
+	}
+	if u.TOTPStep == 0 {
+		unset = append(unset, "totp_step")
+	}
+	if len(u.Recovery) == 0 {
+		unset = append(unset, "recovery_codes")
+	}
+	curUser, err = p.userHandler.UpdateOne(ctx, um, curUser)
+	if err != nil {
+		return nil, err
+	}
+	if len(unset) > 0 {
+		err = p.userHandler.UnsetFields(ctx, um, unset...)
 		if err != nil {
 			return nil, err
 		}
-		filter := bson.D{{"_id", user.Id}}
-		update := bson.D{{"$set",
-			bson.D{
-				{"password", string(hashedPassword)},
-				{"last_modified", currentTime},
-			},
-		}}
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		_, err = p.collection.UpdateOne(ctx, filter, update)
+	}
+	return curUser.toRoot(), nil
+}
+
+// UserLockUpdate is used to store until when a User is locked out of signing in, a zero time unlocks the User
+func (p *UserService) UserLockUpdate(ctx context.Context, u *models.User) (*models.User, error) {
+	if !u.CheckID("id") {
+		return nil, errors.New("missing valid query filter")
+	}
+	um, err := newUserModel(&models.User{Id: u.Id})
+	if err != nil {
+		return nil, err
+	}
+	curUser, err := p.userHandler.FindOne(ctx, um)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	curUser.LockedUntil = u.LockedUntil
+	if u.LockedUntil.IsZero() {
+		err = p.userHandler.UnsetFields(ctx, um, "locked_until")
 		if err != nil {
 			return nil, err
 		}
-		user.Password = ""
-		return user.toRoot(), nil
+		return curUser.toRoot(), nil
 	}
-	return nil, errors.New("invalid password")
+	curUser, err = p.userHandler.UpdateOne(ctx, um, curUser)
+	if err != nil {
+		return nil, err
+	}
+	return curUser.toRoot(), nil
 }
 
 // UserDocInsert is used to insert user doc directly into mongodb for testing purposes
-func (p *UserService) UserDocInsert(u *models.User) (*models.User, error) {
+func (p *UserService) UserDocInsert(ctx context.Context, u *models.User) (*models.User, error) {
 	password := []byte(u.Password)
 	hashedPassword, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
 	if err != nil {
@@ -264,7 +465,7 @@ func (p *UserService) UserDocInsert(u *models.User) (*models.User, error) {
 	if err != nil {
 		return u, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withTimeout(ctx, writeOperation)
 	defer cancel()
 	_, err = p.collection.InsertOne(ctx, insertUser)
 	if err != nil {
@@ -1,9 +1,11 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
 	"testing"
+	"time"
 )
 
 func Test_UserCreate(t *testing.T) {
@@ -103,7 +105,7 @@ func Test_UserCreate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := initTestUserService()
 			//fmt.Println("\n\nPRE CREATE: ", tt.user)
-			got, err := testService.UserCreate(tt.user)
+			got, err := testService.UserCreate(context.Background(), tt.user)
 			//fmt.Println("\nPOST CREATE: ", got)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
@@ -163,7 +165,7 @@ func Test_UsersFind(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestUsers()
 			// fmt.Println("\n\nPRE FIND: ", tt.user)
-			got, err := testService.UsersFind(tt.user)
+			got, err := testService.UsersFind(context.Background(), tt.user)
 			//fmt.Println("\n\nPOST FIND: ", got)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
@@ -198,7 +200,7 @@ func Test_UserFind(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestUsers()
 			fmt.Println("\nPRE FIND: ", tt.user)
-			got, err := testService.UserFind(tt.user)
+			got, err := testService.UserFind(context.Background(), tt.user)
 			fmt.Println("\nPOST FIND: ", got)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
@@ -257,7 +259,7 @@ func Test_UserUpdate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestUsers()
-			got, err := testService.UserUpdate(tt.user)
+			got, err := testService.UserUpdate(context.Background(), tt.user)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
 				t.Errorf("UserService.UserUpdate() error = %v, wantErr %v", err, tt.wantErr)
@@ -303,7 +305,7 @@ func Test_UserDelete(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestUsers()
-			got, err := testService.UserDelete(tt.user)
+			got, err := testService.UserDelete(context.Background(), tt.user)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
 				t.Errorf("UserService.UserDelete() error = %v, wantErr %v", err, tt.wantErr)
@@ -361,7 +363,7 @@ func Test_AuthenticateUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestUsers()
 			//fmt.Println("\nPRE AUTH: ", tt.user)
-			got, err := testService.AuthenticateUser(tt.user)
+			got, err := testService.AuthenticateUser(context.Background(), tt.user)
 			//fmt.Println("\nPOST AUTH: ", got)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
@@ -425,7 +427,7 @@ func Test_UpdatePassword(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			testService := setupTestUsers()
 			//fmt.Println("\nPRE PW UPDATE: ", tt.user)
-			got, err := testService.UpdatePassword(tt.user, tt.CPW, tt.NPW)
+			got, err := testService.UpdatePassword(context.Background(), tt.user, tt.CPW, tt.NPW)
 			//fmt.Println("\nPOST PW UPDATE: ", got)
 			// Checking the error
 			if (err != nil) != tt.wantErr {
@@ -445,3 +447,150 @@ func Test_UpdatePassword(t *testing.T) {
 		})
 	}
 }
+
+func Test_UserRestore(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string       // The name of the test
+		want    *models.User // What out instance we want our function to return.
+		wantErr bool         // whether we want an error.
+		deleted *models.User // The user that is soft deleted before the test
+		user    *models.User
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			&models.User{Id: "000000000000000000000012", Email: "test2@email.com"},
+			false,
+			&models.User{Id: "000000000000000000000012"},
+			&models.User{Id: "000000000000000000000012"},
+		},
+		{
+			"user not deleted",
+			nil,
+			true,
+			&models.User{Id: "000000000000000000000012"},
+			&models.User{Id: "000000000000000000000013"},
+		},
+		{
+			"out of group scope",
+			nil,
+			true,
+			&models.User{Id: "000000000000000000000012"},
+			&models.User{Id: "000000000000000000000012", GroupId: "000000000000000000000003"},
+		},
+		{
+			"email taken",
+			nil,
+			true,
+			&models.User{Id: "000000000000000000000012"},
+			&models.User{Id: "000000000000000000000012"},
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestUsers()
+			_, err := testService.UserDelete(context.Background(), tt.deleted)
+			if err != nil {
+				t.Errorf("UserService.UserDelete() error = %v", err)
+				return
+			}
+			if tt.name == "email taken" {
+				_, err = testService.UserCreate(context.Background(), &models.User{
+					Id:       "000000000000000000000014",
+					Username: "test4",
+					Email:    "test2@email.com",
+					Password: "abc123",
+					GroupId:  "000000000000000000000002",
+				})
+				if err != nil {
+					t.Errorf("UserService.UserCreate() error = %v", err)
+					return
+				}
+			}
+			got, err := testService.UserRestore(context.Background(), tt.user)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserService.UserRestore() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var failMsg string
+			switch tt.name {
+			case "success":
+				if got.Id != tt.want.Id || got.DeletedAt.IsZero() { // Asserting whether we get the correct wanted value
+					failMsg = fmt.Sprintf("UserService.UserRestore() = %v, want %v", got, tt.want)
+				}
+				found, err := testService.UserFind(context.Background(), &models.User{Id: tt.want.Id})
+				if err != nil || found.Email != tt.want.Email {
+					failMsg = fmt.Sprintf("UserService.UserFind() after restore error = %v", err)
+				}
+			}
+			if failMsg != "" {
+				t.Errorf(failMsg)
+			}
+		})
+	}
+}
+
+func Test_UsersPurge(t *testing.T) {
+	testService := setupTestUsers()
+	_, err := testService.UserDelete(context.Background(), &models.User{Id: "000000000000000000000012"})
+	if err != nil {
+		t.Errorf("UserService.UserDelete() error = %v", err)
+		return
+	}
+	got, err := testService.UsersPurge(context.Background(), time.Now().UTC().Add(-time.Hour))
+	if err != nil || got != 0 {
+		t.Errorf("UserService.UsersPurge() = %v, want %v, error = %v", got, 0, err)
+		return
+	}
+	got, err = testService.UsersPurge(context.Background(), time.Now().UTC().Add(time.Second))
+	if err != nil || got != 1 {
+		t.Errorf("UserService.UsersPurge() = %v, want %v, error = %v", got, 1, err)
+		return
+	}
+	_, err = testService.UserRestore(context.Background(), &models.User{Id: "000000000000000000000012"})
+	if err == nil {
+		t.Errorf("UserService.UserRestore() expected an error after purge")
+	}
+}
+
+func Test_UserPurge(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string       // The name of the test
+		wantErr bool         // whether we want an error.
+		user    *models.User // The input of the test
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"success", false, &models.User{Id: "000000000000000000000012"}},
+		{"user not found", true, &models.User{Id: "000000000000000000000014"}},
+		{"missing id", true, &models.User{Email: "test@example.com"}},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := setupTestUsers()
+			got, err := testService.UserPurge(context.Background(), tt.user)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserService.UserPurge() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Id != tt.user.Id {
+				t.Errorf("UserService.UserPurge() = %v, want %v", got.Id, tt.user.Id)
+			}
+			// A purged User is gone for good, it can neither be found nor restored
+			if _, err = testService.UserFind(context.Background(), tt.user); err == nil {
+				t.Errorf("UserService.UserFind() expected an error after purge")
+			}
+			if _, err = testService.UserRestore(context.Background(), tt.user); err == nil {
+				t.Errorf("UserService.UserRestore() expected an error after purge")
+			}
+		})
+	}
+}
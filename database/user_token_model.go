@@ -0,0 +1,179 @@
+package database
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type userTokenModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	TokenHash    string             `bson:"token_hash,omitempty"`
+	UserId       primitive.ObjectID `bson:"user_id,omitempty"`
+	Purpose      string             `bson:"purpose,omitempty"`
+	ExpiresAt    time.Time          `bson:"expires_at,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newUserTokenModel initializes a new pointer to a userTokenModel struct from a pointer to a JSON UserToken struct
+func newUserTokenModel(t *models.UserToken) (tm *userTokenModel, err error) {
+	tm = &userTokenModel{
+		TokenHash:    t.TokenHash,
+		Purpose:      t.Purpose,
+		ExpiresAt:    t.ExpiresAt,
+		LastModified: t.LastModified,
+		CreatedAt:    t.CreatedAt,
+		DeletedAt:    t.DeletedAt,
+	}
+	if t.Id != "" && t.Id != "000000000000000000000000" {
+		tm.Id, err = primitive.ObjectIDFromHex(t.Id)
+	}
+	if t.UserId != "" && t.UserId != "000000000000000000000000" {
+		tm.UserId, err = primitive.ObjectIDFromHex(t.UserId)
+	}
+	return
+}
+
+// update the userTokenModel using an overwrite bson doc
+func (t *userTokenModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	tm := userTokenModel{}
+	err = bson.Unmarshal(data, &tm)
+	if len(tm.TokenHash) > 0 {
+		t.TokenHash = tm.TokenHash
+	}
+	if len(tm.Purpose) > 0 {
+		t.Purpose = tm.Purpose
+	}
+	if !tm.ExpiresAt.IsZero() {
+		t.ExpiresAt = tm.ExpiresAt
+	}
+	if !tm.LastModified.IsZero() {
+		t.LastModified = tm.LastModified
+	}
+	if !tm.DeletedAt.IsZero() {
+		t.DeletedAt = tm.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the userTokenModel
+func (t *userTokenModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, t)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the userTokenModel
+func (t *userTokenModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	tm := userTokenModel{}
+	err = bson.Unmarshal(data, &tm)
+	if tm.Id.Hex() != "" && tm.Id.Hex() != "000000000000000000000000" {
+		return t.Id == tm.Id
+	}
+	if tm.TokenHash != "" {
+		return t.TokenHash == tm.TokenHash
+	}
+	if tm.UserId.Hex() != "" && tm.UserId.Hex() != "000000000000000000000000" {
+		return t.UserId == tm.UserId && (tm.Purpose == "" || t.Purpose == tm.Purpose)
+	}
+	return false
+}
+
+// getID returns the unique identifier of the userTokenModel
+func (t *userTokenModel) getID() (id interface{}) {
+	return t.Id
+}
+
+// getDeletedAt returns the time the userTokenModel was used or replaced at
+func (t *userTokenModel) getDeletedAt() time.Time {
+	return t.DeletedAt
+}
+
+// addTimeStamps updates a userTokenModel struct with a timestamp
+func (t *userTokenModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	t.LastModified = currentTime
+	if newRecord {
+		t.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a userTokenModel has a value assigned for Id, if no value a new one is generated and assigned
+func (t *userTokenModel) addObjectID() {
+	if t.Id.Hex() == "" || t.Id.Hex() == "000000000000000000000000" {
+		t.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a userTokenModel struct postProcess to do things such as validating required fields
+func (t *userTokenModel) postProcess() (err error) {
+	if t.TokenHash == "" {
+		err = errors.New("user token record does not have a TokenHash")
+	}
+	return
+}
+
+// toDoc converts the bson userTokenModel into a bson.D
+func (t *userTokenModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(t)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the userTokenModel data
+// A user id filter is narrowed down to a single purpose when one is set
+func (t *userTokenModel) bsonFilter() (doc bson.D, err error) {
+	if t.Id.Hex() != "" && t.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", t.Id}}
+	} else if t.TokenHash != "" {
+		doc = bson.D{{"token_hash", t.TokenHash}}
+	} else if t.UserId.Hex() != "" && t.UserId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"user_id", t.UserId}}
+		if t.Purpose != "" {
+			doc = append(doc, bson.E{Key: "purpose", Value: t.Purpose})
+		}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the userTokenModel data
+func (t *userTokenModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := t.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a UserToken JSON struct from a pointer to a BSON userTokenModel
+func (t *userTokenModel) toRoot() *models.UserToken {
+	return &models.UserToken{
+		Id:           t.Id.Hex(),
+		TokenHash:    t.TokenHash,
+		UserId:       t.UserId.Hex(),
+		Purpose:      t.Purpose,
+		ExpiresAt:    t.ExpiresAt,
+		LastModified: t.LastModified,
+		CreatedAt:    t.CreatedAt,
+		DeletedAt:    t.DeletedAt,
+	}
+}
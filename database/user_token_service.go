@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// UserTokenService is used by the app to manage all user token related controllers and functionality
+type UserTokenService struct {
+	collection DBCollection
+	db         DBClient
+	handler    *DBHandler[*userTokenModel]
+}
+
+// NewUserTokenService is an exported function used to initialize a new UserTokenService struct
+func NewUserTokenService(db DBClient, handler *DBHandler[*userTokenModel]) *UserTokenService {
+	collection := db.GetCollection("user_tokens")
+	return &UserTokenService{collection, db, handler}
+}
+
+// UserTokenCreate is used to issue a new user token, any outstanding tokens of the user for the same purpose are revoked
+// The returned UserToken is the only one to carry the raw Token
+func (p *UserTokenService) UserTokenCreate(ctx context.Context, t *models.UserToken) (*models.UserToken, error) {
+	err := t.Validate(t.Purpose)
+	if err != nil {
+		return nil, err
+	}
+	err = t.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	tm, err := newUserTokenModel(t)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = p.handler.DeleteMany(ctx, &userTokenModel{UserId: tm.UserId, Purpose: tm.Purpose})
+	tm, err = p.handler.InsertOne(ctx, tm)
+	if err != nil {
+		return nil, err
+	}
+	created := tm.toRoot()
+	created.Token = t.Token
+	return created, nil
+}
+
+// UserTokenRedeem is used to exchange a raw user token for its UserToken record, each token can only be redeemed once
+func (p *UserTokenService) UserTokenRedeem(ctx context.Context, token string, purpose string) (*models.UserToken, error) {
+	tm, err := p.handler.FindOne(ctx, &userTokenModel{TokenHash: models.HashToken(token)})
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+	found := tm.toRoot()
+	err = found.Validate(purpose)
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.handler.DeleteMany(ctx, &userTokenModel{UserId: tm.UserId, Purpose: tm.Purpose})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
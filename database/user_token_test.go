@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+	"time"
+)
+
+func Test_UserTokenRedeem(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string        // The name of the test
+		wantErr bool          // whether we want an error.
+		purpose string        // The purpose the token is redeemed for
+		ttl     time.Duration // How long the issued token is valid for
+		reuse   bool          // whether the token is redeemed a second time
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"success",
+			false,
+			models.PasswordResetToken,
+			time.Hour,
+			false,
+		},
+		{
+			"wrong purpose",
+			true,
+			models.EmailVerificationToken,
+			time.Hour,
+			false,
+		},
+		{
+			"reused",
+			true,
+			models.PasswordResetToken,
+			time.Hour,
+			true,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := initTestUserTokenService()
+			issued, err := testService.UserTokenCreate(context.Background(), &models.UserToken{UserId: "000000000000000000000012", Purpose: models.PasswordResetToken, ExpiresAt: time.Now().UTC().Add(tt.ttl)})
+			if err != nil {
+				t.Fatalf("UserTokenService.UserTokenCreate() error = %v", err)
+			}
+			if tt.reuse {
+				_, err = testService.UserTokenRedeem(context.Background(), issued.Token, tt.purpose)
+				if err != nil {
+					t.Fatalf("UserTokenService.UserTokenRedeem() error = %v", err)
+				}
+			}
+			got, err := testService.UserTokenRedeem(context.Background(), issued.Token, tt.purpose)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UserTokenService.UserTokenRedeem() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.UserId != issued.UserId { // Asserting whether we get the correct wanted value
+				t.Errorf("UserTokenService.UserTokenRedeem() = %v, want %v", got, issued)
+			}
+		})
+	}
+}
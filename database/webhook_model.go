@@ -0,0 +1,374 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type webhookModel struct {
+	Id           primitive.ObjectID `bson:"_id,omitempty"`
+	URL          string             `bson:"url,omitempty"`
+	Secret       string             `bson:"secret,omitempty"`
+	Events       []string           `bson:"events,omitempty"`
+	UserId       primitive.ObjectID `bson:"user_id,omitempty"`
+	GroupId      primitive.ObjectID `bson:"group_id,omitempty"`
+	LastModified time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at,omitempty"`
+	DeletedAt    time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newWebhookModel initializes a new pointer to a webhookModel struct from a pointer to a JSON Webhook struct
+func newWebhookModel(w *models.Webhook) (wm *webhookModel, err error) {
+	wm = &webhookModel{
+		URL:          w.URL,
+		Secret:       w.Secret,
+		Events:       w.Events,
+		LastModified: w.LastModified,
+		CreatedAt:    w.CreatedAt,
+		DeletedAt:    w.DeletedAt,
+	}
+	if w.Id != "" && w.Id != "000000000000000000000000" {
+		wm.Id, err = primitive.ObjectIDFromHex(w.Id)
+	}
+	if w.UserId != "" && w.UserId != "000000000000000000000000" {
+		wm.UserId, err = primitive.ObjectIDFromHex(w.UserId)
+	}
+	if w.GroupId != "" && w.GroupId != "000000000000000000000000" {
+		wm.GroupId, err = primitive.ObjectIDFromHex(w.GroupId)
+	}
+	return
+}
+
+// update the webhookModel using an overwrite bson doc
+func (w *webhookModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	wm := webhookModel{}
+	err = bson.Unmarshal(data, &wm)
+	if len(wm.URL) > 0 {
+		w.URL = wm.URL
+	}
+	if len(wm.Secret) > 0 {
+		w.Secret = wm.Secret
+	}
+	if len(wm.Events) > 0 {
+		w.Events = wm.Events
+	}
+	if !wm.LastModified.IsZero() {
+		w.LastModified = wm.LastModified
+	}
+	if !wm.DeletedAt.IsZero() {
+		w.DeletedAt = wm.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the webhookModel
+func (w *webhookModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, w)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the webhookModel
+func (w *webhookModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	wm := webhookModel{}
+	err = bson.Unmarshal(data, &wm)
+	if wm.Id.Hex() != "" && wm.Id.Hex() != "000000000000000000000000" {
+		return w.Id == wm.Id
+	}
+	if wm.GroupId.Hex() != "" && wm.GroupId.Hex() != "000000000000000000000000" {
+		return w.GroupId == wm.GroupId
+	}
+	return false
+}
+
+// getID returns the unique identifier of the webhookModel
+func (w *webhookModel) getID() (id interface{}) {
+	return w.Id
+}
+
+// getDeletedAt returns the time the webhookModel was deleted at
+func (w *webhookModel) getDeletedAt() time.Time {
+	return w.DeletedAt
+}
+
+// addTimeStamps updates a webhookModel struct with a timestamp
+func (w *webhookModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	w.LastModified = currentTime
+	if newRecord {
+		w.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a webhookModel has a value assigned for Id, if no value a new one is generated and assigned
+func (w *webhookModel) addObjectID() {
+	if w.Id.Hex() == "" || w.Id.Hex() == "000000000000000000000000" {
+		w.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a webhookModel struct postProcess to do things such as validating required fields
+func (w *webhookModel) postProcess() (err error) {
+	if w.URL == "" || w.Secret == "" || w.GroupId.IsZero() {
+		err = errors.New("webhook record does not have a URL, Secret and GroupId")
+	}
+	return
+}
+
+// toDoc converts the bson webhookModel into a bson.D
+func (w *webhookModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(w)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the webhookModel data
+func (w *webhookModel) bsonFilter() (doc bson.D, err error) {
+	if w.Id.Hex() != "" && w.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", w.Id}}
+	} else if w.GroupId.Hex() != "" && w.GroupId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"group_id", w.GroupId}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the webhookModel data
+func (w *webhookModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := w.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a Webhook JSON struct from a pointer to a BSON webhookModel
+func (w *webhookModel) toRoot() *models.Webhook {
+	return &models.Webhook{
+		Id:           w.Id.Hex(),
+		URL:          w.URL,
+		Secret:       w.Secret,
+		Events:       w.Events,
+		UserId:       w.UserId.Hex(),
+		GroupId:      w.GroupId.Hex(),
+		LastModified: w.LastModified,
+		CreatedAt:    w.CreatedAt,
+		DeletedAt:    w.DeletedAt,
+	}
+}
+
+type webhookDeliveryModel struct {
+	Id             primitive.ObjectID `bson:"_id,omitempty"`
+	WebhookId      primitive.ObjectID `bson:"webhook_id,omitempty"`
+	GroupId        primitive.ObjectID `bson:"group_id,omitempty"`
+	Event          string             `bson:"event,omitempty"`
+	EventId        primitive.ObjectID `bson:"event_id,omitempty"`
+	Payload        string             `bson:"payload,omitempty"`
+	Status         string             `bson:"status,omitempty"`
+	Attempts       int                `bson:"attempts,omitempty"`
+	NextAttemptAt  time.Time          `bson:"next_attempt_at,omitempty"`
+	LastAttemptAt  time.Time          `bson:"last_attempt_at,omitempty"`
+	ResponseStatus int                `bson:"response_status,omitempty"`
+	Error          string             `bson:"error,omitempty"`
+	LastModified   time.Time          `bson:"last_modified,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at,omitempty"`
+	DeletedAt      time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// newWebhookDeliveryModel initializes a new pointer to a webhookDeliveryModel struct from a pointer to a JSON
+// WebhookDelivery struct
+func newWebhookDeliveryModel(d *models.WebhookDelivery) (dm *webhookDeliveryModel, err error) {
+	dm = &webhookDeliveryModel{
+		Event:          d.Event,
+		Payload:        string(d.Payload),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastAttemptAt:  d.LastAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.Error,
+		LastModified:   d.LastModified,
+		CreatedAt:      d.CreatedAt,
+		DeletedAt:      d.DeletedAt,
+	}
+	if d.Id != "" && d.Id != "000000000000000000000000" {
+		dm.Id, err = primitive.ObjectIDFromHex(d.Id)
+	}
+	if d.WebhookId != "" && d.WebhookId != "000000000000000000000000" {
+		dm.WebhookId, err = primitive.ObjectIDFromHex(d.WebhookId)
+	}
+	if d.GroupId != "" && d.GroupId != "000000000000000000000000" {
+		dm.GroupId, err = primitive.ObjectIDFromHex(d.GroupId)
+	}
+	if d.EventId != "" && d.EventId != "000000000000000000000000" {
+		dm.EventId, err = primitive.ObjectIDFromHex(d.EventId)
+	}
+	return
+}
+
+// update the webhookDeliveryModel using an overwrite bson doc
+func (d *webhookDeliveryModel) update(doc interface{}) (err error) {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return
+	}
+	dm := webhookDeliveryModel{}
+	err = bson.Unmarshal(data, &dm)
+	if len(dm.Status) > 0 {
+		d.Status = dm.Status
+	}
+	if dm.Attempts > 0 {
+		d.Attempts = dm.Attempts
+	}
+	if !dm.NextAttemptAt.IsZero() {
+		d.NextAttemptAt = dm.NextAttemptAt
+	}
+	if !dm.LastAttemptAt.IsZero() {
+		d.LastAttemptAt = dm.LastAttemptAt
+	}
+	if dm.ResponseStatus > 0 {
+		d.ResponseStatus = dm.ResponseStatus
+	}
+	if len(dm.Error) > 0 {
+		d.Error = dm.Error
+	}
+	if !dm.LastModified.IsZero() {
+		d.LastModified = dm.LastModified
+	}
+	if !dm.DeletedAt.IsZero() {
+		d.DeletedAt = dm.DeletedAt
+	}
+	return
+}
+
+// bsonLoad loads a bson doc into the webhookDeliveryModel
+func (d *webhookDeliveryModel) bsonLoad(doc bson.D) (err error) {
+	bData, err := bsonMarshall(doc)
+	if err != nil {
+		return err
+	}
+	err = bson.Unmarshal(bData, d)
+	return err
+}
+
+// match compares an input bson doc and returns whether there's a match with the webhookDeliveryModel
+func (d *webhookDeliveryModel) match(doc interface{}) bool {
+	data, err := bsonMarshall(doc)
+	if err != nil {
+		return false
+	}
+	dm := webhookDeliveryModel{}
+	err = bson.Unmarshal(data, &dm)
+	if dm.Id.Hex() != "" && dm.Id.Hex() != "000000000000000000000000" {
+		return d.Id == dm.Id
+	}
+	if dm.WebhookId.Hex() != "" && dm.WebhookId.Hex() != "000000000000000000000000" {
+		return d.WebhookId == dm.WebhookId
+	}
+	return false
+}
+
+// getID returns the unique identifier of the webhookDeliveryModel
+func (d *webhookDeliveryModel) getID() (id interface{}) {
+	return d.Id
+}
+
+// getDeletedAt returns the time the webhookDeliveryModel was deleted at
+func (d *webhookDeliveryModel) getDeletedAt() time.Time {
+	return d.DeletedAt
+}
+
+// addTimeStamps updates a webhookDeliveryModel struct with a timestamp
+func (d *webhookDeliveryModel) addTimeStamps(newRecord bool) {
+	currentTime := time.Now().UTC()
+	d.LastModified = currentTime
+	if newRecord {
+		d.CreatedAt = currentTime
+	}
+}
+
+// addObjectID checks if a webhookDeliveryModel has a value assigned for Id, if no value a new one is generated and
+// assigned
+func (d *webhookDeliveryModel) addObjectID() {
+	if d.Id.Hex() == "" || d.Id.Hex() == "000000000000000000000000" {
+		d.Id = primitive.NewObjectID()
+	}
+}
+
+// postProcess updates a webhookDeliveryModel struct postProcess to do things such as validating required fields
+func (d *webhookDeliveryModel) postProcess() (err error) {
+	if d.WebhookId.IsZero() || d.Event == "" || d.Payload == "" {
+		err = errors.New("webhook delivery record does not have a WebhookId, Event and Payload")
+	}
+	return
+}
+
+// toDoc converts the bson webhookDeliveryModel into a bson.D
+func (d *webhookDeliveryModel) toDoc() (doc bson.D, err error) {
+	data, err := bson.Marshal(d)
+	if err != nil {
+		return
+	}
+	err = bson.Unmarshal(data, &doc)
+	return
+}
+
+// bsonFilter generates a bson filter for MongoDB queries from the webhookDeliveryModel data
+func (d *webhookDeliveryModel) bsonFilter() (doc bson.D, err error) {
+	if d.Id.Hex() != "" && d.Id.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"_id", d.Id}}
+	} else if d.WebhookId.Hex() != "" && d.WebhookId.Hex() != "000000000000000000000000" {
+		doc = bson.D{{"webhook_id", d.WebhookId}}
+	}
+	return
+}
+
+// bsonUpdate generates a bson update for MongoDB queries from the webhookDeliveryModel data
+func (d *webhookDeliveryModel) bsonUpdate() (doc bson.D, err error) {
+	inner, err := d.toDoc()
+	if err != nil {
+		return
+	}
+	doc = bson.D{{"$set", inner}}
+	return
+}
+
+// toRoot creates and return a new pointer to a WebhookDelivery JSON struct from a pointer to a BSON
+// webhookDeliveryModel
+func (d *webhookDeliveryModel) toRoot() *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		Id:             d.Id.Hex(),
+		WebhookId:      d.WebhookId.Hex(),
+		GroupId:        d.GroupId.Hex(),
+		Event:          d.Event,
+		EventId:        d.EventId.Hex(),
+		Payload:        json.RawMessage(d.Payload),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastAttemptAt:  d.LastAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.Error,
+		LastModified:   d.LastModified,
+		CreatedAt:      d.CreatedAt,
+		DeletedAt:      d.DeletedAt,
+	}
+}
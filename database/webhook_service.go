@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// webhookDeliveryLease is how long a claimed delivery is held by its dispatcher, a delivery whose dispatcher stopped
+// before recording its attempt is retried once the lease ran out
+const webhookDeliveryLease = 2 * models.WebhookTimeout
+
+// WebhookService is used by the app to manage all Webhook related controllers and functionality
+type WebhookService struct {
+	collection      DBCollection
+	db              DBClient
+	handler         *DBHandler[*webhookModel]
+	deliveryHandler *DBHandler[*webhookDeliveryModel]
+}
+
+// NewWebhookService is an exported function used to initialize a new WebhookService struct
+func NewWebhookService(db DBClient, handler *DBHandler[*webhookModel], dHandler *DBHandler[*webhookDeliveryModel]) *WebhookService {
+	collection := db.GetCollection("webhooks")
+	return &WebhookService{collection, db, handler, dHandler}
+}
+
+// withoutSecret returns the Webhook of a webhookModel without its secret
+func withoutSecret(wm *webhookModel) *models.Webhook {
+	w := wm.toRoot()
+	w.Secret = ""
+	return w
+}
+
+// findWebhook returns the webhookModel of a Webhook, the webhook's group id is checked when one is specified
+func (p *WebhookService) findWebhook(ctx context.Context, w *models.Webhook) (*webhookModel, error) {
+	wm, err := newWebhookModel(w)
+	if err != nil {
+		return nil, err
+	}
+	found, err := p.handler.FindOne(ctx, &webhookModel{Id: wm.Id})
+	if err != nil {
+		return nil, errors.New("webhook not found")
+	}
+	if w.CheckID("group_id") && found.GroupId != wm.GroupId {
+		return nil, errors.New("webhook not found")
+	}
+	return found, nil
+}
+
+// WebhookCreate is used to register a new Webhook, a secret is generated when none is specified. The returned Webhook
+// is the only one to carry the secret
+func (p *WebhookService) WebhookCreate(ctx context.Context, w *models.Webhook) (*models.Webhook, error) {
+	err := w.Validate("create")
+	if err != nil {
+		return nil, err
+	}
+	if w.Secret == "" {
+		if err = w.GenerateSecret(); err != nil {
+			return nil, err
+		}
+	}
+	wm, err := newWebhookModel(w)
+	if err != nil {
+		return nil, err
+	}
+	wm.Id = primitive.NilObjectID
+	wm, err = p.handler.InsertOne(ctx, wm)
+	if err != nil {
+		return nil, err
+	}
+	return wm.toRoot(), nil
+}
+
+// WebhooksFind is used to find the Webhooks of a group
+func (p *WebhookService) WebhooksFind(ctx context.Context, w *models.Webhook) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	wm, err := newWebhookModel(w)
+	if err != nil {
+		return webhooks, err
+	}
+	wms, err := p.handler.FindMany(ctx, wm)
+	if err != nil {
+		return webhooks, err
+	}
+	for _, m := range wms {
+		webhooks = append(webhooks, withoutSecret(m))
+	}
+	return webhooks, nil
+}
+
+// WebhookFind is used to find a Webhook, the webhook's group id is checked when one is specified
+func (p *WebhookService) WebhookFind(ctx context.Context, w *models.Webhook) (*models.Webhook, error) {
+	wm, err := p.findWebhook(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	return withoutSecret(wm), nil
+}
+
+// WebhookUpdate is used to change the URL or the events of a Webhook, the webhook's group id is checked when one is
+// specified
+func (p *WebhookService) WebhookUpdate(ctx context.Context, w *models.Webhook) (*models.Webhook, error) {
+	err := w.Validate("update")
+	if err != nil {
+		return nil, err
+	}
+	cur, err := p.findWebhook(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	if w.URL != "" {
+		cur.URL = w.URL
+	}
+	if w.Events != nil {
+		cur.Events = w.Events
+	}
+	wm, err := p.handler.UpdateOne(ctx, &webhookModel{Id: cur.Id}, cur)
+	if err != nil {
+		return nil, err
+	}
+	return withoutSecret(wm), nil
+}
+
+// WebhookDelete is used to delete a Webhook, its pending deliveries are no longer attempted
+func (p *WebhookService) WebhookDelete(ctx context.Context, w *models.Webhook) (*models.Webhook, error) {
+	cur, err := p.findWebhook(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	wm, err := p.handler.DeleteOne(ctx, &webhookModel{Id: cur.Id})
+	if err != nil {
+		return nil, err
+	}
+	return withoutSecret(wm), nil
+}
+
+// WebhookEnqueue is used to queue a delivery of an Event to every Webhook of its group that subscribes to it
+func (p *WebhookService) WebhookEnqueue(ctx context.Context, e *models.Event) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	groupId, err := primitive.ObjectIDFromHex(e.GroupId)
+	if err != nil {
+		return deliveries, nil // events of records without a group have no webhooks
+	}
+	wms, err := p.handler.findMany(ctx, activeFilter(bson.D{{"group_id", groupId}, {"events", e.Type}}))
+	if err != nil {
+		return deliveries, err
+	}
+	for _, wm := range wms {
+		d, err := models.NewWebhookDelivery(wm.toRoot(), e)
+		if err != nil {
+			return deliveries, err
+		}
+		dm, err := newWebhookDeliveryModel(d)
+		if err != nil {
+			return deliveries, err
+		}
+		dm, err = p.deliveryHandler.InsertOne(ctx, dm)
+		if err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, dm.toRoot())
+	}
+	return deliveries, nil
+}
+
+// WebhookDeliveriesFindPage is used to find a sorted, filtered and paginated page of the deliveries of a Webhook along
+// with the total number of matches
+func (p *WebhookService) WebhookDeliveriesFindPage(ctx context.Context, d *models.WebhookDelivery, o *models.ListOptions) ([]*models.WebhookDelivery, int64, error) {
+	var deliveries []*models.WebhookDelivery
+	dm, err := newWebhookDeliveryModel(d)
+	if err != nil {
+		return deliveries, 0, err
+	}
+	if dm.WebhookId.IsZero() {
+		return deliveries, 0, errors.New("missing webhook id")
+	}
+	dms, total, err := p.deliveryHandler.FindPage(ctx, &webhookDeliveryModel{WebhookId: dm.WebhookId}, o)
+	if err != nil {
+		return deliveries, 0, err
+	}
+	for _, m := range dms {
+		deliveries = append(deliveries, m.toRoot())
+	}
+	return deliveries, total, nil
+}
+
+// WebhookDeliveriesDue is used to find the pending deliveries whose next attempt is due at now
+func (p *WebhookService) WebhookDeliveriesDue(ctx context.Context, now time.Time) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	dms, err := p.deliveryHandler.findMany(ctx, activeFilter(bson.D{
+		{"status", models.DeliveryPending},
+		{"next_attempt_at", bson.D{{"$lte", now}}},
+	}))
+	if err != nil {
+		return deliveries, err
+	}
+	for _, m := range dms {
+		deliveries = append(deliveries, m.toRoot())
+	}
+	return deliveries, nil
+}
+
+// WebhookDeliveryClaim is used to claim the next attempt of a due delivery at now, it returns the Webhook, with its
+// secret, to deliver to when the attempt was claimed. Every attempt is claimed by exactly one dispatcher, and a
+// delivery whose Webhook was deleted is dead
+func (p *WebhookService) WebhookDeliveryClaim(ctx context.Context, d *models.WebhookDelivery, now time.Time) (*models.Webhook, bool, error) {
+	dm, err := newWebhookDeliveryModel(d)
+	if err != nil {
+		return nil, false, err
+	}
+	claim := &webhookDeliveryModel{Attempts: dm.Attempts + 1, NextAttemptAt: now.Add(webhookDeliveryLease)}
+	claimed, err := p.deliveryHandler.UpdateIf(ctx, bson.D{
+		{"_id", dm.Id},
+		{"status", models.DeliveryPending},
+		{"next_attempt_at", primitive.NewDateTimeFromTime(dm.NextAttemptAt)},
+	}, claim)
+	if err != nil || !claimed {
+		return nil, false, err
+	}
+	d.Attempts, d.NextAttemptAt = claim.Attempts, claim.NextAttemptAt
+	wm, err := p.handler.FindOne(ctx, &webhookModel{Id: dm.WebhookId})
+	if err != nil {
+		d.RecordAttempt(0, errors.New("webhook was deleted"), now)
+		d.Status, d.NextAttemptAt = models.DeliveryDead, time.Time{}
+		_, err = p.WebhookDeliveryRecord(ctx, d)
+		return nil, false, err
+	}
+	return wm.toRoot(), true, nil
+}
+
+// WebhookDeliveryRecord is used to store the result of the latest attempt of a delivery
+func (p *WebhookService) WebhookDeliveryRecord(ctx context.Context, d *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	dm, err := newWebhookDeliveryModel(d)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := p.deliveryHandler.FindOne(ctx, &webhookDeliveryModel{Id: dm.Id})
+	if err != nil {
+		return nil, errors.New("webhook delivery not found")
+	}
+	cur.Status, cur.Attempts, cur.LastAttemptAt = dm.Status, dm.Attempts, dm.LastAttemptAt
+	cur.NextAttemptAt, cur.ResponseStatus, cur.Error = dm.NextAttemptAt, dm.ResponseStatus, dm.Error
+	cur, err = p.deliveryHandler.UpdateOne(ctx, &webhookDeliveryModel{Id: cur.Id}, cur)
+	if err != nil {
+		return nil, err
+	}
+	var clear []string
+	if cur.NextAttemptAt.IsZero() {
+		clear = append(clear, "next_attempt_at")
+	}
+	if cur.ResponseStatus == 0 {
+		clear = append(clear, "response_status")
+	}
+	if cur.Error == "" {
+		clear = append(clear, "error")
+	}
+	if len(clear) > 0 {
+		if err = p.deliveryHandler.UnsetFields(ctx, &webhookDeliveryModel{Id: cur.Id}, clear...); err != nil {
+			return nil, err
+		}
+	}
+	return cur.toRoot(), nil
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"testing"
+	"time"
+)
+
+func Test_WebhookEnqueue(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string          // The name of the test
+		webhook *models.Webhook // The webhook registered before the task is created
+		deleted bool            // whether the webhook is deleted before the task is created
+		want    int             // The wanted number of deliveries
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"subscribed event",
+			&models.Webhook{URL: "https://hooks.example.com/tasks", Events: []string{models.EventTaskCreated}, UserId: "000000000000000000000012", GroupId: "000000000000000000000002"},
+			false,
+			1,
+		},
+		{
+			"unsubscribed event",
+			&models.Webhook{URL: "https://hooks.example.com/users", Events: []string{models.EventUserDeleted}, UserId: "000000000000000000000012", GroupId: "000000000000000000000002"},
+			false,
+			0,
+		},
+		{
+			"other group",
+			&models.Webhook{URL: "https://hooks.example.com/tasks", Events: []string{models.EventTaskCreated}, UserId: "000000000000000000000013", GroupId: "000000000000000000000003"},
+			false,
+			0,
+		},
+		{
+			"deleted webhook",
+			&models.Webhook{URL: "https://hooks.example.com/tasks", Events: []string{models.EventTaskCreated}, UserId: "000000000000000000000012", GroupId: "000000000000000000000002"},
+			true,
+			0,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, ts := setupTestWebhooks()
+			webhook, err := ws.WebhookCreate(context.Background(), tt.webhook)
+			if err != nil {
+				t.Errorf("WebhookService.WebhookCreate() error = %v", err)
+				return
+			}
+			if webhook.Secret == "" {
+				t.Errorf("WebhookService.WebhookCreate() did not return a secret")
+			}
+			if tt.deleted {
+				if _, err = ws.WebhookDelete(context.Background(), &models.Webhook{Id: webhook.Id}); err != nil {
+					t.Errorf("WebhookService.WebhookDelete() error = %v", err)
+					return
+				}
+			}
+			task, err := ts.TaskCreate(context.Background(), &models.Task{Name: "Task3", Due: time.Now().UTC().Add(24 * time.Hour), UserId: "000000000000000000000012", GroupId: "000000000000000000000002"})
+			if err != nil {
+				t.Errorf("TaskService.TaskCreate() error = %v", err)
+				return
+			}
+			opts, _ := models.NewListOptions(nil, models.WebhookDeliverySortFields, models.WebhookDeliveryFilterFields)
+			deliveries, total, err := ws.WebhookDeliveriesFindPage(context.Background(), &models.WebhookDelivery{WebhookId: webhook.Id}, opts)
+			if err != nil {
+				t.Errorf("WebhookService.WebhookDeliveriesFindPage() error = %v", err)
+				return
+			}
+			if int(total) != tt.want || len(deliveries) != tt.want { // Asserting whether we get the correct wanted value
+				t.Errorf("WebhookService.WebhookDeliveriesFindPage() = %v deliveries, want %v", total, tt.want)
+				return
+			}
+			if tt.want == 0 {
+				return
+			}
+			var event models.Event
+			if err = json.Unmarshal(deliveries[0].Payload, &event); err != nil {
+				t.Errorf("WebhookDelivery payload error = %v", err)
+				return
+			}
+			var data models.Task
+			_ = json.Unmarshal(event.Data, &data)
+			if deliveries[0].Status != models.DeliveryPending || event.Type != models.EventTaskCreated || event.GroupId != task.GroupId || data.Id != task.Id {
+				t.Errorf("WebhookService.WebhookEnqueue() = %+v, event %+v", deliveries[0], event)
+			}
+		})
+	}
+}
+
+func Test_WebhookDeliveryClaim(t *testing.T) {
+	ws, ts := setupTestWebhooks()
+	webhook, err := ws.WebhookCreate(context.Background(), &models.Webhook{URL: "https://hooks.example.com/status", Secret: "whsec", Events: []string{models.EventTaskStatusChanged}, UserId: "000000000000000000000012", GroupId: "000000000000000000000002"})
+	if err != nil {
+		t.Fatalf("WebhookService.WebhookCreate() error = %v", err)
+	}
+	// Only status changes are queued for the webhook
+	if _, err = ts.TaskUpdate(context.Background(), &models.Task{Id: "000000000000000000000022", Name: "Task1 renamed"}, "000000000000000000000012"); err != nil {
+		t.Fatalf("TaskService.TaskUpdate() error = %v", err)
+	}
+	if _, err = ts.TaskUpdate(context.Background(), &models.Task{Id: "000000000000000000000022", Status: models.INPROGRESS}, "000000000000000000000012"); err != nil {
+		t.Fatalf("TaskService.TaskUpdate() error = %v", err)
+	}
+	now := time.Now().UTC()
+	due, err := ws.WebhookDeliveriesDue(context.Background(), now)
+	if err != nil || len(due) != 1 || due[0].Event != models.EventTaskStatusChanged {
+		t.Fatalf("WebhookService.WebhookDeliveriesDue() = %+v, %v", due, err)
+	}
+	delivery := due[0]
+	// An attempt is claimed once, along with the secret of its webhook
+	stale := *delivery
+	claimedWebhook, claimed, err := ws.WebhookDeliveryClaim(context.Background(), delivery, now)
+	if err != nil || !claimed || claimedWebhook.Id != webhook.Id || claimedWebhook.Secret != "whsec" || delivery.Attempts != 1 {
+		t.Fatalf("WebhookService.WebhookDeliveryClaim() = %+v, %v, %v", claimedWebhook, claimed, err)
+	}
+	if _, claimed, err = ws.WebhookDeliveryClaim(context.Background(), &stale, now); err != nil || claimed {
+		t.Errorf("WebhookService.WebhookDeliveryClaim() claimed twice = %v, %v", claimed, err)
+	}
+	// A failed attempt is retried after a backoff, until the delivery is dead
+	for attempt := 1; ; attempt++ {
+		delivery.RecordAttempt(500, nil, now)
+		recorded, err := ws.WebhookDeliveryRecord(context.Background(), delivery)
+		if err != nil {
+			t.Fatalf("WebhookService.WebhookDeliveryRecord() error = %v", err)
+		}
+		if attempt == models.WebhookMaxAttempts {
+			if recorded.Status != models.DeliveryDead || !recorded.NextAttemptAt.IsZero() || recorded.Attempts != attempt {
+				t.Errorf("WebhookService.WebhookDeliveryRecord() = %+v, want a dead delivery", recorded)
+			}
+			break
+		}
+		if recorded.Status != models.DeliveryPending || recorded.ResponseStatus != 500 || !recorded.NextAttemptAt.Equal(now.Add(models.WebhookRetryBackoff(attempt))) {
+			t.Fatalf("WebhookService.WebhookDeliveryRecord() attempt %v = %+v", attempt, recorded)
+		}
+		if due, _ = ws.WebhookDeliveriesDue(context.Background(), now); len(due) != 0 {
+			t.Fatalf("WebhookService.WebhookDeliveriesDue() before backoff = %+v", due)
+		}
+		now = recorded.NextAttemptAt
+		if _, claimed, err = ws.WebhookDeliveryClaim(context.Background(), recorded, now); err != nil || !claimed {
+			t.Fatalf("WebhookService.WebhookDeliveryClaim() attempt %v = %v, %v", attempt+1, claimed, err)
+		}
+		delivery = recorded
+	}
+	if due, _ = ws.WebhookDeliveriesDue(context.Background(), now.Add(models.WebhookMaxBackoff)); len(due) != 0 {
+		t.Errorf("WebhookService.WebhookDeliveriesDue() dead delivery = %+v", due)
+	}
+}
@@ -0,0 +1,50 @@
+package mail
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Email is a sent email as it is recorded by a FileMailer
+type Email struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// FileMailer records emails as JSON lines in a file instead of delivering them, for development and testing
+// When no file path is set the emails are written to the log
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileMailer initializes a new FileMailer
+func NewFileMailer(path string) *FileMailer {
+	return &FileMailer{path: path}
+}
+
+// Send appends an email to the FileMailer's file
+func (m *FileMailer) Send(to string, subject string, body string) error {
+	data, err := json.Marshal(Email{To: to, Subject: subject, Body: body, SentAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	if m.path == "" {
+		log.Println("mail:", string(data))
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,20 @@
+package mail
+
+import (
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"os"
+	"strconv"
+)
+
+// NewMailer initializes the services.Mailer selected by the MAILER environmental variable
+// "smtp" delivers emails using the SMTP_* settings, anything else writes them to MAIL_FILE or the log
+func NewMailer() services.Mailer {
+	if os.Getenv("MAILER") == "smtp" {
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			port = 587
+		}
+		return NewSMTPMailer(os.Getenv("SMTP_HOST"), port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+	}
+	return NewFileMailer(os.Getenv("MAIL_FILE"))
+}
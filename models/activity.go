@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// The types of Activity in the activity feed of a group
+const (
+	ActivityTaskCreated       = "task.created"
+	ActivityTaskStatusChanged = "task.status_changed"
+	ActivityCommentCreated    = "comment.created"
+)
+
+// Activity is a root struct that is used to return an entry of the activity feed of a group.
+// Activities are not stored, they are merged from the tasks, task history and comments of the group
+type Activity struct {
+	Type      string     `json:"type"`
+	TaskId    string     `json:"task_id,omitempty"`
+	GroupId   string     `json:"group_id,omitempty"`
+	ActorId   string     `json:"actor_id,omitempty"`
+	TaskName  string     `json:"task_name,omitempty"`
+	From      TaskStatus `json:"from,omitempty"`
+	To        TaskStatus `json:"to,omitempty"`
+	CommentId string     `json:"comment_id,omitempty"`
+	Body      string     `json:"body,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"strings"
+	"time"
+)
+
+// APIKeyScopes are the scopes that can be granted to an APIKey
+var APIKeyScopes = []string{"tasks:read", "tasks:write", "users:read", "users:write", "groups:read", "groups:write"}
+
+// APIKey is a root struct that is used to store the json encoded data for/from a mongodb api key doc.
+// Only the hash of an api key is stored, the Key itself is only set when a new api key is created
+type APIKey struct {
+	Id           string    `json:"id,omitempty"`
+	Name         string    `json:"name,omitempty"`
+	Key          string    `json:"key,omitempty"`
+	KeyHash      string    `json:"-"`
+	UserId       string    `json:"user_id,omitempty"`
+	GroupId      string    `json:"group_id,omitempty"`
+	Scopes       []string  `json:"scopes,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+	LastUsedAt   time.Time `json:"last_used_at,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+}
+
+// GenerateKey assigns a new random opaque key to the APIKey along with its hash
+func (k *APIKey) GenerateKey() (err error) {
+	k.Key, err = generateSecret()
+	if err != nil {
+		return
+	}
+	k.KeyHash = HashToken(k.Key)
+	return
+}
+
+// CheckID determines whether a specified ID is set or not
+func (k *APIKey) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(k.Id) {
+			return false
+		}
+	case "user_id":
+		if !utilities.CheckObjectID(k.UserId) {
+			return false
+		}
+	case "group_id":
+		if !utilities.CheckObjectID(k.GroupId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks whether an APIKey has the fields required for a given valCase
+func (k *APIKey) Validate(valCase string) (err error) {
+	var missingFields []string
+	switch valCase {
+	case "create":
+		if k.Name == "" {
+			missingFields = append(missingFields, "name")
+		}
+		if len(k.Scopes) == 0 {
+			missingFields = append(missingFields, "scopes")
+		}
+		if !k.CheckID("user_id") {
+			missingFields = append(missingFields, "user_id")
+		}
+		if !k.CheckID("group_id") {
+			missingFields = append(missingFields, "group_id")
+		}
+		if len(missingFields) > 0 {
+			return errors.New("missing the following api key fields: " + strings.Join(missingFields, ", "))
+		}
+		for _, scope := range k.Scopes {
+			if !containsField(APIKeyScopes, scope) {
+				return errors.New("invalid api key scope: " + scope)
+			}
+		}
+		if !k.ExpiresAt.IsZero() && k.ExpiresAt.Before(time.Now().UTC()) {
+			return errors.New("api key expiration must be in the future")
+		}
+	}
+	return
+}
+
+// Expired determines whether the APIKey has passed its optional expiration
+func (k *APIKey) Expired() bool {
+	return !k.ExpiresAt.IsZero() && time.Now().UTC().After(k.ExpiresAt)
+}
+
+// HasScope determines whether the APIKey was granted a scope, a write scope also grants read access to its resource
+func (k *APIKey) HasScope(scope string) bool {
+	if containsField(k.Scopes, scope) {
+		return true
+	}
+	if strings.HasSuffix(scope, ":read") {
+		return containsField(k.Scopes, strings.TrimSuffix(scope, ":read")+":write")
+	}
+	return false
+}
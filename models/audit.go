@@ -0,0 +1,155 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// AuditRedacted replaces the values of the redacted fields of an AuditChange
+const AuditRedacted = "[REDACTED]"
+
+// auditRedactedFields are the json fields of the models whose values are never written to the audit log
+var auditRedactedFields = []string{"password", "key", "token", "auth_token", "refresh_token", "secret"}
+
+// AuditEventSortFields are the audit event fields a list of audit events can be sorted by
+var AuditEventSortFields = []string{"action", "resource_type", "status", "created_at"}
+
+// AuditEventFilterFields are the audit event fields a list of audit events can be filtered by
+var AuditEventFilterFields = []string{"actor_id", "group_id", "action", "resource_type", "resource_id", "request_id"}
+
+// AuditEventRangeFields are the audit event fields a list of audit events can be filtered by with the _gte and _lte
+// range suffixes
+var AuditEventRangeFields = []string{"created_at"}
+
+// AuditEvent is a root struct that is used to store the json encoded data for/from a mongodb audit event doc.
+// An AuditEvent is recorded for every mutating API call and is never updated or deleted. The Action is the method and
+// route of the call, e.g. "PATCH /tasks/{taskId}", and Changes holds the fields of the resource it changed. The GroupId
+// is the group of the resource the call changed, or the group of the actor's session when that is unknown
+type AuditEvent struct {
+	Id           string                  `json:"id,omitempty"`
+	ActorId      string                  `json:"actor_id,omitempty"`
+	GroupId      string                  `json:"group_id,omitempty"`
+	Action       string                  `json:"action,omitempty"`
+	ResourceType string                  `json:"resource_type,omitempty"`
+	ResourceId   string                  `json:"resource_id,omitempty"`
+	Changes      map[string]*AuditChange `json:"changes,omitempty"`
+	Status       int                     `json:"status,omitempty"`
+	RequestId    string                  `json:"request_id,omitempty"`
+	IP           string                  `json:"ip,omitempty"`
+	CreatedAt    time.Time               `json:"created_at,omitempty"`
+}
+
+// AuditChange holds the json encoded value of a field before and after a change, a null value is an unset field
+type AuditChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// Validate checks whether an AuditEvent has the fields required for a given valCase
+func (e *AuditEvent) Validate(valCase string) (err error) {
+	switch valCase {
+	case "create":
+		if e.Action == "" || e.ResourceType == "" {
+			return errors.New("missing the following audit event fields: action, resource_type")
+		}
+	default:
+		return errors.New("unrecognized validation case")
+	}
+	return
+}
+
+// RecordChange sets the resource of the AuditEvent along with the fields that differ between the before and after
+// states of it, a nil before is a created resource and a nil after a deleted one. The GroupId of the AuditEvent
+// becomes the group the resource belongs to, so the event is listed to the admins of that group
+func (e *AuditEvent) RecordChange(resourceType string, before interface{}, after interface{}) error {
+	changes, err := AuditDiff(before, after)
+	if err != nil {
+		return err
+	}
+	e.ResourceType = resourceType
+	e.Changes = changes
+	if id := auditStateField("id", after, before); id != "" {
+		e.ResourceId = id
+	}
+	groupField := "group_id"
+	if resourceType == "groups" {
+		groupField = "id"
+	}
+	if groupId := auditStateField(groupField, after, before); groupId != "" {
+		e.GroupId = groupId
+	}
+	return nil
+}
+
+// auditStateField returns the first non-empty value a string json field has in the states of a models struct
+func auditStateField(field string, states ...interface{}) string {
+	for _, state := range states {
+		fields, _ := auditFields(state)
+		var value string
+		if json.Unmarshal(fields[field], &value) == nil && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// auditFields json encodes a models struct into its fields, zero times are left out like the other empty fields
+func auditFields(v interface{}) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields, err
+	}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return fields, err
+	}
+	zeroTime, _ := json.Marshal(time.Time{})
+	for k, v := range fields {
+		if bytes.Equal(v, zeroTime) {
+			delete(fields, k)
+		}
+	}
+	return fields, nil
+}
+
+// AuditDiff returns the json fields that differ between the before and after states of a models struct, the values of
+// redacted fields such as passwords are replaced so that only the fact they changed is kept
+func AuditDiff(before interface{}, after interface{}) (map[string]*AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for k := range beforeFields {
+		keys = append(keys, k)
+	}
+	for k := range afterFields {
+		if _, ok := beforeFields[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	changes := make(map[string]*AuditChange)
+	redacted, _ := json.Marshal(AuditRedacted)
+	for _, k := range keys {
+		b, a := beforeFields[k], afterFields[k]
+		if k == "last_modified" || bytes.Equal(b, a) {
+			continue
+		}
+		if containsField(auditRedactedFields, k) {
+			if b != nil {
+				b = redacted
+			}
+			if a != nil {
+				a = redacted
+			}
+		}
+		changes[k] = &AuditChange{Before: b, After: a}
+	}
+	return changes, nil
+}
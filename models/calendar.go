@@ -0,0 +1,319 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxCalendarImportSize    = 5 << 20 // maximum number of bytes of an imported iCalendar file
+	MaxCalendarImportEntries = 500     // maximum number of entries of an imported iCalendar file
+	calendarLineLength       = 75      // content lines are folded after this many octets
+	calendarDateTime         = "20060102T150405Z"
+	calendarFloating         = "20060102T150405"
+	calendarDate             = "20060102"
+)
+
+// CalendarEntry is a VTODO or VEVENT of an iCalendar file that can be imported as a Task
+type CalendarEntry struct {
+	UID         string
+	Component   string
+	Summary     string
+	Description string
+	Due         time.Time
+	Priority    int
+	Categories  []string
+}
+
+// Task returns the Task an imported CalendarEntry creates, a todo is due at its DUE and an event at its start
+func (e *CalendarEntry) Task() *Task {
+	t := &Task{
+		Name:        strings.TrimSpace(e.Summary),
+		Description: e.Description,
+		Due:         e.Due,
+		Labels:      e.Categories,
+	}
+	switch {
+	case e.Priority == 1:
+		t.Priority = URGENT
+	case e.Priority >= 2 && e.Priority <= 4:
+		t.Priority = HIGH
+	case e.Priority == 5:
+		t.Priority = MEDIUM
+	case e.Priority >= 6 && e.Priority <= 9:
+		t.Priority = LOW
+	}
+	return t
+}
+
+// calendarProperty is a content line of an iCalendar file
+type calendarProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// escapeCalendarText escapes a TEXT value of an iCalendar content line
+func escapeCalendarText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// unescapeCalendarText reverses escapeCalendarText
+func unescapeCalendarText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// splitCalendarList splits an escaped TEXT list on its unescaped commas
+func splitCalendarList(s string) []string {
+	var items []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, unescapeCalendarText(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, unescapeCalendarText(s[start:]))
+}
+
+// writeCalendarLine writes a content line, folding it so no line is longer than calendarLineLength octets
+func writeCalendarLine(buf *bytes.Buffer, line string) {
+	limit := calendarLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = calendarLineLength - 1
+	}
+	buf.WriteString(line + "\r\n")
+}
+
+// calendarStatus returns the VTODO STATUS of a Task, statuses of custom workflows are in process until completed
+func calendarStatus(t *Task) string {
+	switch {
+	case t.Status == COMPLETED || !t.CompletedAt.IsZero():
+		return "COMPLETED"
+	case t.Status == NOTSTARTED || t.Status == "":
+		return "NEEDS-ACTION"
+	}
+	return "IN-PROCESS"
+}
+
+// calendarPriority returns the VTODO PRIORITY of a Task
+func calendarPriority(p TaskPriority) int {
+	switch p {
+	case URGENT:
+		return 1
+	case HIGH:
+		return 3
+	case MEDIUM:
+		return 5
+	case LOW:
+		return 9
+	}
+	return 0
+}
+
+// EncodeCalendar encodes the Tasks that have a due date as the VTODOs of an iCalendar file
+func EncodeCalendar(name string, tasks []*Task, now time.Time) []byte {
+	sorted := make([]*Task, 0, len(tasks))
+	for _, t := range tasks {
+		if !t.Due.IsZero() {
+			sorted = append(sorted, t)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Due.Before(sorted[j].Due)
+	})
+	buf := &bytes.Buffer{}
+	writeCalendarLine(buf, "BEGIN:VCALENDAR")
+	writeCalendarLine(buf, "VERSION:2.0")
+	writeCalendarLine(buf, "PRODID:-//go-rest-api-boilerplate//Tasks//EN")
+	writeCalendarLine(buf, "CALSCALE:GREGORIAN")
+	writeCalendarLine(buf, "METHOD:PUBLISH")
+	writeCalendarLine(buf, "X-WR-CALNAME:"+escapeCalendarText(name))
+	for _, t := range sorted {
+		writeCalendarLine(buf, "BEGIN:VTODO")
+		writeCalendarLine(buf, "UID:"+t.Id)
+		writeCalendarLine(buf, "DTSTAMP:"+now.UTC().Format(calendarDateTime))
+		if !t.CreatedAt.IsZero() {
+			writeCalendarLine(buf, "CREATED:"+t.CreatedAt.UTC().Format(calendarDateTime))
+		}
+		if !t.LastModified.IsZero() {
+			writeCalendarLine(buf, "LAST-MODIFIED:"+t.LastModified.UTC().Format(calendarDateTime))
+		}
+		writeCalendarLine(buf, "SUMMARY:"+escapeCalendarText(t.Name))
+		if t.Description != "" {
+			writeCalendarLine(buf, "DESCRIPTION:"+escapeCalendarText(t.Description))
+		}
+		writeCalendarLine(buf, "DUE:"+t.Due.UTC().Format(calendarDateTime))
+		writeCalendarLine(buf, "STATUS:"+calendarStatus(t))
+		if !t.CompletedAt.IsZero() {
+			writeCalendarLine(buf, "COMPLETED:"+t.CompletedAt.UTC().Format(calendarDateTime))
+		}
+		if p := calendarPriority(t.Priority); p > 0 {
+			writeCalendarLine(buf, "PRIORITY:"+strconv.Itoa(p))
+		}
+		if len(t.Labels) > 0 {
+			labels := make([]string, len(t.Labels))
+			for i, label := range t.Labels {
+				labels[i] = escapeCalendarText(label)
+			}
+			writeCalendarLine(buf, "CATEGORIES:"+strings.Join(labels, ","))
+		}
+		if t.ParentId != "" {
+			writeCalendarLine(buf, "RELATED-TO:"+t.ParentId)
+		}
+		writeCalendarLine(buf, "END:VTODO")
+	}
+	writeCalendarLine(buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// parseCalendarProperty parses an unfolded content line into its name, parameters and value
+func parseCalendarProperty(line string) (*calendarProperty, error) {
+	quoted := false
+	for i, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ':' && !quoted:
+			p := &calendarProperty{params: make(map[string]string), value: line[i+1:]}
+			parts := strings.Split(line[:i], ";")
+			p.name = strings.ToUpper(parts[0])
+			for _, param := range parts[1:] {
+				if k, v, ok := strings.Cut(param, "="); ok {
+					p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+				}
+			}
+			if p.name == "" {
+				return nil, errors.New("invalid iCalendar content line: " + line)
+			}
+			return p, nil
+		}
+	}
+	return nil, errors.New("invalid iCalendar content line: " + line)
+}
+
+// parseCalendarTime parses a DATE or DATE-TIME value, floating times and unknown time zones are read as UTC
+func parseCalendarTime(p *calendarProperty) (time.Time, error) {
+	value := strings.TrimSpace(p.value)
+	if p.params["VALUE"] == "DATE" || len(value) == len(calendarDate) {
+		return time.Parse(calendarDate, value)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(calendarDateTime, value)
+	}
+	loc := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation(calendarFloating, value, loc)
+	return t.UTC(), err
+}
+
+// ParseCalendar parses the VTODOs and VEVENTs of an iCalendar file, the components nested in them, such as alarms,
+// are ignored
+func ParseCalendar(data []byte) ([]*CalendarEntry, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.NewReplacer("\n ", "", "\n\t", "").Replace(text)
+	var entries []*CalendarEntry
+	var stack []string
+	var entry *CalendarEntry
+	var start, end time.Time
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		p, err := parseCalendarProperty(line)
+		if err != nil {
+			return nil, err
+		}
+		switch p.name {
+		case "BEGIN":
+			component := strings.ToUpper(strings.TrimSpace(p.value))
+			if len(stack) == 0 && component != "VCALENDAR" {
+				return nil, errors.New("invalid iCalendar file, it does not begin with a VCALENDAR")
+			}
+			if len(stack) == 1 && (component == "VTODO" || component == "VEVENT") {
+				entry = &CalendarEntry{Component: component}
+				start, end = time.Time{}, time.Time{}
+			}
+			stack = append(stack, component)
+			continue
+		case "END":
+			component := strings.ToUpper(strings.TrimSpace(p.value))
+			if len(stack) == 0 || stack[len(stack)-1] != component {
+				return nil, errors.New("invalid iCalendar file, unexpected END:" + component)
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 1 && entry != nil {
+				if entry.Due.IsZero() {
+					entry.Due = start
+				}
+				if entry.Due.IsZero() {
+					entry.Due = end
+				}
+				entries = append(entries, entry)
+				if len(entries) > MaxCalendarImportEntries {
+					return nil, errors.New("an iCalendar file can have at most " + strconv.Itoa(MaxCalendarImportEntries) + " entries")
+				}
+				entry = nil
+			}
+			continue
+		}
+		if entry == nil || len(stack) != 2 {
+			continue
+		}
+		switch p.name {
+		case "UID":
+			entry.UID = p.value
+		case "SUMMARY":
+			entry.Summary = unescapeCalendarText(p.value)
+		case "DESCRIPTION":
+			entry.Description = unescapeCalendarText(p.value)
+		case "PRIORITY":
+			entry.Priority, _ = strconv.Atoi(strings.TrimSpace(p.value))
+		case "CATEGORIES":
+			for _, category := range splitCalendarList(p.value) {
+				if category = strings.TrimSpace(category); category != "" {
+					entry.Categories = append(entry.Categories, category)
+				}
+			}
+		case "DUE", "DTSTART", "DTEND":
+			t, err := parseCalendarTime(p)
+			if err != nil {
+				return nil, errors.New("invalid iCalendar " + p.name + ": " + p.value)
+			}
+			switch {
+			case p.name == "DUE" && entry.Component == "VTODO":
+				entry.Due = t
+			case p.name == "DTSTART":
+				start = t
+			case p.name == "DTEND":
+				end = t
+			}
+		}
+	}
+	if len(stack) != 0 {
+		return nil, errors.New("invalid iCalendar file, END:" + stack[len(stack)-1] + " is missing")
+	}
+	if entries == nil && !strings.Contains(strings.ToUpper(text), "BEGIN:VCALENDAR") {
+		return nil, errors.New("invalid iCalendar file, it does not begin with a VCALENDAR")
+	}
+	return entries, nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// The strategies a cascade delete is applied with
+const (
+	CascadeTransaction = "transaction"
+	CascadeSaga        = "saga"
+)
+
+// DeleteReport is a root struct that reports what a cascade delete of a Group or a User removed. Removed counts the
+// records soft deleted from each collection, or revoked for refresh tokens, which all share the DeletedAt time, and
+// Strategy is whether they were removed in a transaction or by a saga that undoes its completed steps when a step fails
+type DeleteReport struct {
+	Group     *Group           `json:"group,omitempty"`
+	User      *User            `json:"user,omitempty"`
+	Removed   map[string]int64 `json:"removed"`
+	Strategy  string           `json:"strategy"`
+	DeletedAt time.Time        `json:"deleted_at"`
+}
+
+// NewDeleteReport initializes a new DeleteReport of records deleted at deletedAt
+func NewDeleteReport(deletedAt time.Time) *DeleteReport {
+	return &DeleteReport{Removed: make(map[string]int64), DeletedAt: deletedAt}
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCommentLength is the maximum number of characters of a Comment body
+const MaxCommentLength = 10000
+
+var (
+	mentionPattern  = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9_][A-Za-z0-9_.-]*)`)
+	markdownCodeBlk = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+)
+
+// Comment is a root struct that is used to store the json encoded data for/from a mongodb comment doc.
+// A Comment is a markdown message about a Task, Mentions holds the ids of the users of the group it @mentions
+type Comment struct {
+	Id           string    `json:"id,omitempty"`
+	TaskId       string    `json:"task_id,omitempty"`
+	GroupId      string    `json:"group_id,omitempty"`
+	UserId       string    `json:"user_id,omitempty"`
+	Body         string    `json:"body,omitempty"`
+	Mentions     []string  `json:"mentions,omitempty"`
+	EditedAt     time.Time `json:"edited_at,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+}
+
+// CheckID determines whether a specified ID is set or not
+func (g *Comment) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(g.Id) {
+			return false
+		}
+	case "task_id":
+		if !utilities.CheckObjectID(g.TaskId) {
+			return false
+		}
+	case "group_id":
+		if !utilities.CheckObjectID(g.GroupId) {
+			return false
+		}
+	case "user_id":
+		if !utilities.CheckObjectID(g.UserId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate a Comment for different scenarios such as creating or updating a Comment
+func (g *Comment) Validate(valCase string) (err error) {
+	var missingFields []string
+	switch valCase {
+	case "create":
+		if !g.CheckID("task_id") {
+			missingFields = append(missingFields, "task_id")
+		}
+		if !g.CheckID("group_id") {
+			missingFields = append(missingFields, "group_id")
+		}
+		if !g.CheckID("user_id") {
+			missingFields = append(missingFields, "user_id")
+		}
+	case "update":
+		if !g.CheckID("id") {
+			missingFields = append(missingFields, "id")
+		}
+	default:
+		return errors.New("unrecognized validation case")
+	}
+	if strings.TrimSpace(g.Body) == "" {
+		missingFields = append(missingFields, "body")
+	}
+	if len(missingFields) > 0 {
+		return errors.New("missing the following comment fields: " + strings.Join(missingFields, ", "))
+	}
+	if len([]rune(g.Body)) > MaxCommentLength {
+		return errors.New("comment body can not be longer than " + strconv.Itoa(MaxCommentLength) + " characters")
+	}
+	return nil
+}
+
+// MentionedUsernames returns the unique usernames @mentioned in the markdown body of the Comment,
+// mentions inside of code spans and code blocks are ignored
+func (g *Comment) MentionedUsernames() []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	body := markdownCodeBlk.ReplaceAllString(g.Body, " ")
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := strings.TrimRight(match[1], ".-")
+		if username != "" && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
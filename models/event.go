@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"time"
+)
+
+// The types of Event emitted when the records of a group change
+const (
+	EventTaskCreated       = ActivityTaskCreated
+	EventTaskUpdated       = "task.updated"
+	EventTaskStatusChanged = ActivityTaskStatusChanged
+	EventTaskDeleted       = "task.deleted"
+	EventTaskRestored      = "task.restored"
+	EventCommentCreated    = ActivityCommentCreated
+	EventUserCreated       = "user.created"
+	EventUserUpdated       = "user.updated"
+	EventUserDeleted       = "user.deleted"
+	EventUserRestored      = "user.restored"
+	EventGroupCreated      = "group.created"
+	EventGroupUpdated      = "group.updated"
+	EventGroupDeleted      = "group.deleted"
+	EventGroupRestored     = "group.restored"
+)
+
+// EventTypes lists every type of Event that can be subscribed to
+var EventTypes = []string{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskStatusChanged,
+	EventTaskDeleted,
+	EventTaskRestored,
+	EventCommentCreated,
+	EventUserCreated,
+	EventUserUpdated,
+	EventUserDeleted,
+	EventUserRestored,
+	EventGroupCreated,
+	EventGroupUpdated,
+	EventGroupDeleted,
+	EventGroupRestored,
+}
+
+// CheckEventType determines whether an event type can be subscribed to
+func CheckEventType(eventType string) bool {
+	return containsField(EventTypes, eventType)
+}
+
+// Event is a root struct that is used to publish a change of a record of a group. Data is the record after the change
+// and Previous, for updates, the record before it
+type Event struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	GroupId   string          `json:"group_id"`
+	Data      json.RawMessage `json:"data"`
+	Previous  json.RawMessage `json:"previous,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// NewEvent initializes a new Event of a record of a group, previous is omitted when it is nil
+func NewEvent(eventType string, groupId string, data interface{}, previous interface{}) (*Event, error) {
+	e := &Event{
+		Id:        utilities.GenerateObjectID(),
+		Type:      eventType,
+		GroupId:   groupId,
+		CreatedAt: time.Now().UTC(),
+	}
+	var err error
+	e.Data, err = json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		e.Previous, err = json.Marshal(previous)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"strings"
+	"time"
+)
+
+const (
+	UserFeed  = "user"
+	GroupFeed = "group"
+)
+
+// FeedToken is a root struct that is used to store the json encoded data for/from a mongodb feed token doc.
+// A feed token authenticates the read only iCalendar feed of the tasks of a User or Group, so calendar apps can
+// subscribe to it without signing in. Only the hash of a feed token is stored, the Token and URL are only set when a
+// new feed token is created
+type FeedToken struct {
+	Id           string    `json:"id,omitempty"`
+	Name         string    `json:"name,omitempty"`
+	Token        string    `json:"token,omitempty"`
+	TokenHash    string    `json:"-"`
+	URL          string    `json:"url,omitempty"`
+	UserId       string    `json:"user_id,omitempty"`
+	FeedType     string    `json:"feed_type,omitempty"`
+	FeedId       string    `json:"feed_id,omitempty"`
+	LastUsedAt   time.Time `json:"last_used_at,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+}
+
+// GenerateToken assigns a new random opaque token to the FeedToken along with its hash
+func (f *FeedToken) GenerateToken() (err error) {
+	f.Token, err = generateSecret()
+	if err != nil {
+		return
+	}
+	f.TokenHash = HashToken(f.Token)
+	return
+}
+
+// CheckID determines whether a specified ID is set or not
+func (f *FeedToken) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(f.Id) {
+			return false
+		}
+	case "user_id":
+		if !utilities.CheckObjectID(f.UserId) {
+			return false
+		}
+	case "feed_id":
+		if !utilities.CheckObjectID(f.FeedId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks whether a FeedToken has the fields required for a given valCase
+func (f *FeedToken) Validate(valCase string) (err error) {
+	var missingFields []string
+	switch valCase {
+	case "create":
+		if f.Name == "" {
+			missingFields = append(missingFields, "name")
+		}
+		if !f.CheckID("user_id") {
+			missingFields = append(missingFields, "user_id")
+		}
+		if !f.CheckID("feed_id") {
+			missingFields = append(missingFields, "feed_id")
+		}
+		if len(missingFields) > 0 {
+			return errors.New("missing the following feed token fields: " + strings.Join(missingFields, ", "))
+		}
+		if f.FeedType != UserFeed && f.FeedType != GroupFeed {
+			return errors.New("feed_type must be " + UserFeed + " or " + GroupFeed)
+		}
+	default:
+		return errors.New("unrecognized validation case")
+	}
+	return
+}
+
+// FeedPath returns the path of the iCalendar feed the FeedToken authenticates
+func (f *FeedToken) FeedPath() string {
+	if f.FeedType == GroupFeed {
+		return "/groups/" + f.FeedId + "/tasks.ics"
+	}
+	return "/users/" + f.FeedId + "/tasks.ics"
+}
@@ -7,6 +7,9 @@ import (
 	"time"
 )
 
+// MaxAttachmentSize is the maximum number of bytes of a file attached to a Task
+const MaxAttachmentSize = 10 << 20
+
 // File is a root struct that is used to store the json encoded data for/from a mongodb file doc.
 type File struct {
 	Id           string    `json:"id,omitempty"`
@@ -9,14 +9,22 @@ import (
 
 // Group is a root struct that is used to store the json encoded data for/from a mongodb group doc.
 type Group struct {
-	Id           string    `json:"id,omitempty"`
-	Name         string    `json:"name,omitempty"`
-	RootAdmin    bool      `json:"root_admin,omitempty"`
-	LastModified time.Time `json:"last_modified,omitempty"`
-	CreatedAt    time.Time `json:"created_at,omitempty"`
-	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+	Id           string        `json:"id,omitempty"`
+	Name         string        `json:"name,omitempty"`
+	RootAdmin    bool          `json:"root_admin,omitempty"`
+	Require2FA   bool          `json:"require_2fa,omitempty"`
+	TaskWorkflow *TaskWorkflow `json:"task_workflow,omitempty"`
+	LastModified time.Time     `json:"last_modified,omitempty"`
+	CreatedAt    time.Time     `json:"created_at,omitempty"`
+	DeletedAt    time.Time     `json:"deleted_at,omitempty"`
 }
 
+// GroupSortFields are the group fields a list of groups can be sorted by
+var GroupSortFields = []string{"name", "last_modified", "created_at"}
+
+// GroupFilterFields are the group fields a list of groups can be filtered by
+var GroupFilterFields = []string{"name"}
+
 // CheckID determines whether a specified ID is set or not
 func (g *Group) CheckID(chkId string) bool {
 	switch chkId {
@@ -48,3 +56,11 @@ func (g *Group) Validate(valCase string) (err error) {
 	}
 	return
 }
+
+// Workflow returns the TaskWorkflow of the Group, or the default one when it has not configured its own
+func (g *Group) Workflow() *TaskWorkflow {
+	if g.TaskWorkflow == nil {
+		return DefaultTaskWorkflow()
+	}
+	return g.TaskWorkflow
+}
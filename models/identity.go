@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"strings"
+	"time"
+)
+
+// Identity is a root struct that is used to store the json encoded data for/from a mongodb identity doc.
+// An Identity links the account of an external OpenID Connect identity provider to a User
+type Identity struct {
+	Id           string    `json:"id,omitempty"`
+	UserId       string    `json:"user_id,omitempty"`
+	Provider     string    `json:"provider,omitempty"`
+	Subject      string    `json:"subject,omitempty"`
+	Email        string    `json:"email,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+}
+
+// CheckID determines whether a specified ID is set or not
+func (g *Identity) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(g.Id) {
+			return false
+		}
+	case "user_id":
+		if !utilities.CheckObjectID(g.UserId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate an Identity for different scenarios such as creating an Identity
+func (g *Identity) Validate(valCase string) (err error) {
+	var missingFields []string
+	switch valCase {
+	case "create":
+		if !g.CheckID("user_id") {
+			missingFields = append(missingFields, "user_id")
+		}
+		if g.Provider == "" {
+			missingFields = append(missingFields, "provider")
+		}
+		if g.Subject == "" {
+			missingFields = append(missingFields, "subject")
+		}
+	default:
+		return errors.New("unrecognized validation case")
+	}
+	if len(missingFields) > 0 {
+		return errors.New("missing the following identity fields: " + strings.Join(missingFields, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"strings"
+	"time"
+)
+
+// Invitation is a root struct that is used to store the json encoded data for/from a mongodb invitation doc.
+// An Invitation is a single use token that is emailed to invite someone to join a group with a role.
+// Only the hash of an invitation token is stored, the Token itself is only set when a new token is generated
+type Invitation struct {
+	Id           string    `json:"id,omitempty"`
+	Token        string    `json:"token,omitempty"`
+	TokenHash    string    `json:"-"`
+	Email        string    `json:"email,omitempty"`
+	GroupId      string    `json:"group_id,omitempty"`
+	Role         string    `json:"role,omitempty"`
+	InvitedBy    string    `json:"invited_by,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+	AcceptedAt   time.Time `json:"accepted_at,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+}
+
+// GenerateToken assigns a new random opaque token to the Invitation along with its hash
+func (g *Invitation) GenerateToken() (err error) {
+	g.Token, err = generateSecret()
+	if err != nil {
+		return
+	}
+	g.TokenHash = HashToken(g.Token)
+	return
+}
+
+// CheckID determines whether a specified ID is set or not
+func (g *Invitation) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(g.Id) {
+			return false
+		}
+	case "group_id":
+		if !utilities.CheckObjectID(g.GroupId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate an Invitation for different scenarios such as creating or accepting an Invitation
+func (g *Invitation) Validate(valCase string) (err error) {
+	var missingFields []string
+	switch valCase {
+	case "create":
+		if g.Email == "" {
+			missingFields = append(missingFields, "email")
+		}
+		if !g.CheckID("group_id") {
+			missingFields = append(missingFields, "group_id")
+		}
+		if g.Role == "" {
+			missingFields = append(missingFields, "role")
+		}
+		if g.ExpiresAt.IsZero() {
+			missingFields = append(missingFields, "expires_at")
+		}
+	case "accept":
+		if !g.AcceptedAt.IsZero() || !g.DeletedAt.IsZero() {
+			return errors.New("invitation is no longer valid")
+		}
+	default:
+		return errors.New("unrecognized validation case")
+	}
+	if len(missingFields) > 0 {
+		return errors.New("missing the following invitation fields: " + strings.Join(missingFields, ", "))
+	}
+	if time.Now().UTC().After(g.ExpiresAt) {
+		return errors.New("invitation has expired")
+	}
+	return nil
+}
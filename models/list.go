@@ -0,0 +1,145 @@
+package models
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultListLimit int64 = 50
+	MaxListLimit     int64 = 500
+)
+
+// ListOptions is a root struct that is used to store the pagination, sorting and filtering settings of a list request
+type ListOptions struct {
+	Limit   int64
+	Offset  int64
+	After   string
+	Sort    []string
+	Filters map[string]string
+	Ranges  map[string]string
+}
+
+// NewListOptions loads ListOptions from a set of query params, only accepting the given sortable and filterable fields
+func NewListOptions(q url.Values, sortable []string, filterable []string) (*ListOptions, error) {
+	var err error
+	o := &ListOptions{Limit: DefaultListLimit, Filters: make(map[string]string), Ranges: make(map[string]string)}
+	if v := q.Get("limit"); v != "" {
+		o.Limit, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || o.Limit < 1 || o.Limit > MaxListLimit {
+			return nil, errors.New("limit must be a number between 1 and " + strconv.FormatInt(MaxListLimit, 10))
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		o.Offset, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || o.Offset < 0 {
+			return nil, errors.New("offset must be a positive number")
+		}
+	}
+	if v := q.Get("after"); v != "" {
+		if b, err := hex.DecodeString(v); err != nil || len(b) != 12 {
+			return nil, errors.New("invalid after cursor")
+		}
+		o.After = v
+	}
+	if v := q.Get("sort"); v != "" {
+		for _, field := range strings.Split(v, ",") {
+			if !containsField(sortable, strings.TrimPrefix(field, "-")) {
+				return nil, errors.New("invalid sort field: " + field)
+			}
+			o.Sort = append(o.Sort, field)
+		}
+	}
+	for _, field := range filterable {
+		if v := q.Get(field); v != "" {
+			o.Filters[field] = v
+		}
+	}
+	return o, o.Validate()
+}
+
+// LoadRanges loads the _gte and _lte range filters of the given rangeable fields from a set of query params
+// A range value is either an RFC 3339 timestamp or a number
+func (o *ListOptions) LoadRanges(q url.Values, rangeable []string) error {
+	for _, field := range rangeable {
+		for _, suffix := range []string{"_gte", "_lte"} {
+			v := q.Get(field + suffix)
+			if v == "" {
+				continue
+			}
+			if _, err := ParseRangeValue(v); err != nil {
+				return errors.New("invalid " + field + suffix + " filter")
+			}
+			o.Ranges[field+suffix] = v
+		}
+	}
+	return nil
+}
+
+// ParseRangeValue converts a range filter value into a time.Time when it is an RFC 3339 timestamp, otherwise a float64
+func ParseRangeValue(v string) (interface{}, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+// Validate ensures the ListOptions do not mix offset and cursor based pagination
+func (o *ListOptions) Validate() error {
+	if o.After != "" && o.Offset > 0 {
+		return errors.New("after and offset can not be used together")
+	}
+	if o.After != "" && len(o.Sort) > 0 {
+		return errors.New("after can not be used with a custom sort")
+	}
+	return nil
+}
+
+// Cursor determines whether the ListOptions pages through results using after cursors rather than offsets
+func (o *ListOptions) Cursor() bool {
+	return o.After != "" || (o.Offset == 0 && len(o.Sort) == 0)
+}
+
+// Next returns the query params for the page following a page of count results, or nil if it was the last page
+func (o *ListOptions) Next(total int64, count int, lastId string) url.Values {
+	if int64(count) >= total {
+		return nil
+	}
+	q := url.Values{}
+	q.Set("limit", strconv.FormatInt(o.Limit, 10))
+	for field, v := range o.Filters {
+		q.Set(field, v)
+	}
+	for field, v := range o.Ranges {
+		q.Set(field, v)
+	}
+	if o.Cursor() {
+		if int64(count) < o.Limit || lastId == "" {
+			return nil
+		}
+		q.Set("after", lastId)
+		return q
+	}
+	if o.Offset+int64(count) >= total {
+		return nil
+	}
+	q.Set("offset", strconv.FormatInt(o.Offset+int64(count), 10))
+	if len(o.Sort) > 0 {
+		q.Set("sort", strings.Join(o.Sort, ","))
+	}
+	return q
+}
+
+// containsField checks whether a field name is in a list of allowed fields
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// LoginAttempt is a root struct that is used to store the json encoded data for/from a mongodb login attempt doc.
+// Failed sign ins are tracked per Key, which identifies either an account or a client ip address
+type LoginAttempt struct {
+	Id           string    `json:"id,omitempty"`
+	Key          string    `json:"key,omitempty"`
+	Failures     int       `json:"failures,omitempty"`
+	LastFailure  time.Time `json:"last_failure,omitempty"`
+	LockedUntil  time.Time `json:"locked_until,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+}
+
+// LockoutPolicy defines after how many failed sign ins a LoginAttempt key is locked out and for how long
+// The first lockout lasts Lockout, each following failure doubles it up to MaxLockout. Failures are forgotten
+// once a key goes Window without failing
+type LockoutPolicy struct {
+	MaxAttempts int
+	Lockout     time.Duration
+	MaxLockout  time.Duration
+	Window      time.Duration
+}
+
+// Validate checks whether a LockoutPolicy can be used to lock out sign ins
+func (p *LockoutPolicy) Validate() error {
+	if p.MaxAttempts <= 0 {
+		return errors.New("lockout policy max attempts must be positive")
+	}
+	if p.Lockout <= 0 || p.MaxLockout < p.Lockout {
+		return errors.New("lockout policy durations are invalid")
+	}
+	return nil
+}
+
+// LockoutAfter returns how long a LoginAttempt key is locked out for once it reaches a number of failed sign ins
+func (p *LockoutPolicy) LockoutAfter(failures int) time.Duration {
+	if failures < p.MaxAttempts {
+		return 0
+	}
+	lockout := p.Lockout
+	for i := p.MaxAttempts; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > p.MaxLockout {
+		lockout = p.MaxLockout
+	}
+	return lockout
+}
+
+// Locked returns how much longer the LoginAttempt key is locked out for at a point in time
+func (a *LoginAttempt) Locked(now time.Time) time.Duration {
+	if a.LockedUntil.After(now) {
+		return a.LockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// Fail records a failed sign in at a point in time, locking out the key with exponential backoff once the policy's
+// max attempts are reached
+func (a *LoginAttempt) Fail(p *LockoutPolicy, now time.Time) {
+	if p.Window > 0 && now.Sub(a.LastFailure) > p.Window {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailure = now
+	if lockout := p.LockoutAfter(a.Failures); lockout > 0 {
+		a.LockedUntil = now.Add(lockout)
+	}
+}
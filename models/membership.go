@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"strings"
+	"time"
+)
+
+// Membership is a root struct that is used to store the json encoded data for/from a mongodb membership doc.
+// A Membership grants a User a role in a group other than the group the User belongs to
+type Membership struct {
+	Id           string    `json:"id,omitempty"`
+	UserId       string    `json:"user_id,omitempty"`
+	GroupId      string    `json:"group_id,omitempty"`
+	Role         string    `json:"role,omitempty"`
+	Primary      bool      `json:"primary,omitempty"` // set when listing the group a User belongs to along with its memberships
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+}
+
+// PrimaryMembership returns the Membership of a User in the group it belongs to
+func PrimaryMembership(u *User) *Membership {
+	return &Membership{
+		UserId:  u.Id,
+		GroupId: u.GroupId,
+		Role:    u.Role,
+		Primary: true,
+	}
+}
+
+// CheckID determines whether a specified ID is set or not
+func (g *Membership) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(g.Id) {
+			return false
+		}
+	case "user_id":
+		if !utilities.CheckObjectID(g.UserId) {
+			return false
+		}
+	case "group_id":
+		if !utilities.CheckObjectID(g.GroupId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate a Membership for different scenarios such as creating or updating a Membership
+func (g *Membership) Validate(valCase string) (err error) {
+	var missingFields []string
+	switch valCase {
+	case "create":
+		if !g.CheckID("user_id") {
+			missingFields = append(missingFields, "user_id")
+		}
+		if !g.CheckID("group_id") {
+			missingFields = append(missingFields, "group_id")
+		}
+	case "update":
+		if !g.CheckID("id") {
+			missingFields = append(missingFields, "id")
+		}
+		if g.Role == "" {
+			missingFields = append(missingFields, "role")
+		}
+	default:
+		return errors.New("unrecognized validation case")
+	}
+	if len(missingFields) > 0 {
+		return errors.New("missing the following membership fields: " + strings.Join(missingFields, ", "))
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package models
 
 import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -188,6 +192,91 @@ func Test_ValidateTask(t *testing.T) {
 			},
 			"update",
 		},
+		{
+			"update details success",
+			false,
+			&Task{
+				Id:         "000000000000000000000001",
+				Priority:   HIGH,
+				Labels:     []string{"backend"},
+				Estimate:   2.5,
+				AssigneeId: "000000000000000000000002",
+			},
+			"update",
+		},
+		{
+			"invalid priority",
+			true,
+			&Task{
+				Id:       "000000000000000000000001",
+				Priority: "SOMEDAY",
+			},
+			"update",
+		},
+		{
+			"negative estimate",
+			true,
+			&Task{
+				Id:       "000000000000000000000001",
+				Estimate: -1,
+			},
+			"update",
+		},
+		{
+			"empty label",
+			true,
+			&Task{
+				Id:     "000000000000000000000001",
+				Labels: []string{" "},
+			},
+			"update",
+		},
+		{
+			"valid relations",
+			false,
+			&Task{
+				Id:        "000000000000000000000001",
+				ParentId:  "000000000000000000000002",
+				BlockedBy: []string{"000000000000000000000003", "000000000000000000000004"},
+			},
+			"update",
+		},
+		{
+			"own parent",
+			true,
+			&Task{
+				Id:       "000000000000000000000001",
+				ParentId: "000000000000000000000001",
+			},
+			"update",
+		},
+		{
+			"blocks itself",
+			true,
+			&Task{
+				Id:        "000000000000000000000001",
+				BlockedBy: []string{"000000000000000000000001"},
+			},
+			"update",
+		},
+		{
+			"duplicate blocker",
+			true,
+			&Task{
+				Id:        "000000000000000000000001",
+				BlockedBy: []string{"000000000000000000000003", "000000000000000000000003"},
+			},
+			"update",
+		},
+		{
+			"invalid blocker",
+			true,
+			&Task{
+				Id:        "000000000000000000000001",
+				BlockedBy: []string{"3"},
+			},
+			"update",
+		},
 	}
 	// Iterating over the previous test slice
 	for _, tt := range tests {
@@ -201,3 +290,662 @@ func Test_ValidateTask(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewListOptions(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string // The name of the test
+		wantErr  bool   // whether we want an error.
+		query    string // The input of the test
+		wantNext string // The next page query we want for a full page of results out of 100
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"defaults",
+			false,
+			"",
+			"",
+		},
+		{
+			"offset",
+			false,
+			"limit=10&offset=20&sort=-due,name&status=COMPLETED",
+			"limit=10&offset=30&sort=-due%2Cname&status=COMPLETED",
+		},
+		{
+			"cursor",
+			false,
+			"limit=10&after=000000000000000000000022",
+			"after=000000000000000000000030&limit=10",
+		},
+		{
+			"limit too large",
+			true,
+			"limit=100000",
+			"",
+		},
+		{
+			"invalid sort field",
+			true,
+			"sort=password",
+			"",
+		},
+		{
+			"invalid cursor",
+			true,
+			"after=abc",
+			"",
+		},
+		{
+			"cursor with offset",
+			true,
+			"after=000000000000000000000022&offset=10",
+			"",
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			got, err := NewListOptions(q, TaskSortFields, TaskFilterFields)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewListOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil || tt.wantNext == "" {
+				return
+			}
+			next := got.Next(100, int(got.Limit), "000000000000000000000030")
+			if next.Encode() != tt.wantNext {
+				t.Errorf("ListOptions.Next() = %v, want %v", next.Encode(), tt.wantNext)
+			}
+		})
+	}
+}
+
+func Test_LoginAttemptFail(t *testing.T) {
+	policy := &LockoutPolicy{MaxAttempts: 3, Lockout: time.Minute, MaxLockout: time.Minute * 3, Window: time.Hour}
+	now := time.Now().UTC()
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string        // The name of the test
+		failures int           // The number of failed sign ins recorded
+		last     time.Time     // When the previous failure was recorded
+		want     time.Duration // The lockout we want after recording one more failure
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"below max attempts",
+			1,
+			now,
+			0,
+		},
+		{
+			"first lockout",
+			2,
+			now,
+			time.Minute,
+		},
+		{
+			"doubled lockout",
+			3,
+			now,
+			time.Minute * 2,
+		},
+		{
+			"capped lockout",
+			10,
+			now,
+			time.Minute * 3,
+		},
+		{
+			"forgotten failures",
+			10,
+			now.Add(-time.Hour * 2),
+			0,
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempt := &LoginAttempt{Key: "account:test@example.com", Failures: tt.failures, LastFailure: tt.last}
+			attempt.Fail(policy, now)
+			if got := attempt.Locked(now); got != tt.want { // Asserting whether we get the correct wanted value
+				t.Errorf("LoginAttempt.Fail() lockout = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidateRole(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string // The name of the test
+		wantErr bool   // whether we want an error.
+		role    *Role  // The input of the test
+		valCase string // What out instance we want our function to return.
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"create success",
+			false,
+			&Role{
+				Name:        "project lead",
+				GroupId:     "000000000000000000000001",
+				Permissions: []string{PermTasksUpdateAny},
+			},
+			"create",
+		},
+		{
+			"create error",
+			true,
+			&Role{
+				Name: "project lead",
+			},
+			"create",
+		},
+		{
+			"reserved name error",
+			true,
+			&Role{
+				Name:    MemberRole,
+				GroupId: "000000000000000000000001",
+			},
+			"create",
+		},
+		{
+			"unknown permission error",
+			true,
+			&Role{
+				Id:          "000000000000000000000001",
+				Permissions: []string{"groups.delete"},
+			},
+			"update",
+		},
+		{
+			"update success",
+			false,
+			&Role{
+				Id: "000000000000000000000001",
+			},
+			"update",
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.role.Validate(tt.valCase)
+			// Checking the error
+			if (got != nil) != tt.wantErr {
+				t.Errorf("Role.Validate() error = %v, wantErr %v", got, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_ValidateInvitation(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name       string      // The name of the test
+		wantErr    bool        // whether we want an error.
+		invitation *Invitation // The input of the test
+		valCase    string      // What out instance we want our function to return.
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"create success",
+			false,
+			&Invitation{
+				Email:     "invitee@test.com",
+				GroupId:   "000000000000000000000001",
+				Role:      MemberRole,
+				ExpiresAt: time.Now().UTC().Add(time.Hour),
+			},
+			"create",
+		},
+		{
+			"create error",
+			true,
+			&Invitation{
+				Email:     "invitee@test.com",
+				Role:      MemberRole,
+				ExpiresAt: time.Now().UTC().Add(time.Hour),
+			},
+			"create",
+		},
+		{
+			"expired error",
+			true,
+			&Invitation{
+				ExpiresAt: time.Now().UTC().Add(-time.Hour),
+			},
+			"accept",
+		},
+		{
+			"accepted error",
+			true,
+			&Invitation{
+				ExpiresAt:  time.Now().UTC().Add(time.Hour),
+				AcceptedAt: time.Now().UTC(),
+			},
+			"accept",
+		},
+		{
+			"accept success",
+			false,
+			&Invitation{
+				ExpiresAt: time.Now().UTC().Add(time.Hour),
+			},
+			"accept",
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.invitation.Validate(tt.valCase)
+			// Checking the error
+			if (got != nil) != tt.wantErr {
+				t.Errorf("Invitation.Validate() error = %v, wantErr %v", got, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_TaskWorkflow(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string        // The name of the test
+		wantErr  bool          // whether we want an error.
+		workflow *TaskWorkflow // The input of the test
+		from     TaskStatus    // The status a task moves from, the transition is not checked when empty
+		to       TaskStatus    // The status a task moves to
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"default transition", false, DefaultTaskWorkflow(), NOTSTARTED, INPROGRESS},
+		{"skipped status", true, DefaultTaskWorkflow(), NOTSTARTED, COMPLETED},
+		{"backwards transition", true, DefaultTaskWorkflow(), COMPLETED, INPROGRESS},
+		{"unknown status", true, DefaultTaskWorkflow(), NOTSTARTED, "ARCHIVED"},
+		{"removed status", false, DefaultTaskWorkflow(), "IN_REVIEW", COMPLETED},
+		{"no statuses", true, &TaskWorkflow{}, "", ""},
+		{"invalid status", true, &TaskWorkflow{Statuses: []TaskStatus{"in review"}}, "", ""},
+		{"duplicate status", true, &TaskWorkflow{Statuses: []TaskStatus{NOTSTARTED, NOTSTARTED}}, "", ""},
+		{
+			"unknown transition",
+			true,
+			&TaskWorkflow{
+				Statuses:    []TaskStatus{NOTSTARTED, COMPLETED},
+				Transitions: []TaskStatusTransition{{From: NOTSTARTED, To: INPROGRESS}},
+			},
+			"",
+			"",
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.workflow.Validate()
+			if got == nil && tt.to != "" {
+				got = tt.workflow.CheckTransition(tt.from, tt.to)
+			}
+			// Checking the error
+			if (got != nil) != tt.wantErr {
+				t.Errorf("TaskWorkflow error = %v, wantErr %v", got, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_CommentMentions(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string   // The name of the test
+		want    []string // What usernames we want to be mentioned
+		wantErr bool     // whether we want a validation error.
+		body    string   // The input of the test
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"single mention", []string{"jill"}, false, "@jill can you review this?"},
+		{"trailing punctuation", []string{"jill", "bill.smith"}, false, "Thanks @jill. Also @bill.smith."},
+		{"duplicate mentions", []string{"jill"}, false, "@jill @jill"},
+		{"code is ignored", []string{"bill"}, false, "Run `@jill` and\n```\n@jack\n```\nthen ping @bill"},
+		{"emails are ignored", nil, false, "Mail jill@example.com"},
+		{"empty body", nil, true, " \n "},
+		{"body too long", nil, true, strings.Repeat("a", MaxCommentLength+1)},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := &Comment{Id: "000000000000000000000031", Body: tt.body}
+			err := comment.Validate("update")
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Comment.Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			got := comment.MentionedUsernames()
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Comment.MentionedUsernames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_RRuleNext(t *testing.T) {
+	start := time.Date(2026, time.January, 5, 9, 0, 0, 0, time.UTC) // a Monday
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string    // The name of the test
+		wantErr  bool      // whether we want a parse error.
+		rule     string    // The input of the test
+		after    time.Time // The time the next occurrence must follow
+		want     time.Time // The next occurrence we want
+		position int       // The position of the next occurrence we want, 0 when the rule has ended
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"daily", false, "FREQ=DAILY", start, start.AddDate(0, 0, 1), 2},
+		{"daily interval", false, "RRULE:FREQ=DAILY;INTERVAL=3", start.AddDate(0, 0, 4), start.AddDate(0, 0, 6), 3},
+		{"weekly by day", false, "FREQ=WEEKLY;BYDAY=MO,WE", start, start.AddDate(0, 0, 2), 2},
+		{"weekly by day next week", false, "freq=weekly;byday=mo,we", start.AddDate(0, 0, 2), start.AddDate(0, 0, 7), 3},
+		{"monthly last day", false, "FREQ=MONTHLY;BYMONTHDAY=-1", start, time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC), 1},
+		{"monthly first monday", false, "FREQ=MONTHLY;BYDAY=1MO", start, time.Date(2026, time.February, 2, 9, 0, 0, 0, time.UTC), 2},
+		{"yearly", false, "FREQ=YEARLY", start, start.AddDate(1, 0, 0), 2},
+		{"count", false, "FREQ=DAILY;COUNT=3", start.AddDate(0, 0, 1), start.AddDate(0, 0, 2), 3},
+		{"count ended", false, "FREQ=DAILY;COUNT=3", start.AddDate(0, 0, 2), time.Time{}, 0},
+		{"until", false, "FREQ=DAILY;UNTIL=20260107", start.AddDate(0, 0, 1), start.AddDate(0, 0, 2), 3},
+		{"until ended", false, "FREQ=DAILY;UNTIL=20260107T000000Z", start.AddDate(0, 0, 1), time.Time{}, 0},
+		{"missing freq", true, "INTERVAL=2", start, time.Time{}, 0},
+		{"unknown freq", true, "FREQ=HOURLY", start, time.Time{}, 0},
+		{"count and until", true, "FREQ=DAILY;COUNT=2;UNTIL=20260107", start, time.Time{}, 0},
+		{"weekly ordinal", true, "FREQ=WEEKLY;BYDAY=1MO", start, time.Time{}, 0},
+		{"invalid day", true, "FREQ=WEEKLY;BYDAY=XX", start, time.Time{}, 0},
+		{"invalid month day", true, "FREQ=MONTHLY;BYMONTHDAY=32", start, time.Time{}, 0},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule)
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRRule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if _, err = ParseRRule(rule.String()); err != nil {
+				t.Errorf("ParseRRule(%q) error = %v", rule.String(), err)
+			}
+			got, position, ok := rule.Next(start, tt.after)
+			if ok != (tt.position > 0) || !got.Equal(tt.want) || position != tt.position {
+				t.Errorf("RRule.Next() = %v, %v, want %v, %v", got, position, tt.want, tt.position)
+			}
+		})
+	}
+}
+
+func Test_EncodeCalendar(t *testing.T) {
+	due := time.Date(2026, time.March, 2, 17, 30, 0, 0, time.UTC)
+	tasks := []*Task{
+		{Id: "000000000000000000000022", Name: "Review; merge, ship", Priority: URGENT, Due: due.Add(time.Hour), Labels: []string{"api", "a,b"}},
+		{Id: "000000000000000000000023", Name: "Plan", Priority: LOW, Due: due, Description: strings.Repeat("détails ", 20) + "\nnext line"},
+		{Id: "000000000000000000000024", Name: "No due date"},
+	}
+	data := EncodeCalendar("Team Tasks", tasks, due)
+	for _, line := range strings.Split(string(data), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("EncodeCalendar() line of %v octets: %q", len(line), line)
+		}
+	}
+	entries, err := ParseCalendar(data)
+	if err != nil {
+		t.Fatalf("ParseCalendar() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ParseCalendar() = %v entries, want 2", len(entries))
+	}
+	// Tasks are encoded in order of their due date
+	plan, review := entries[0].Task(), entries[1].Task()
+	if entries[0].UID != "000000000000000000000023" || plan.Description != tasks[1].Description || plan.Priority != LOW || !plan.Due.Equal(due) {
+		t.Errorf("ParseCalendar() = %+v, want %+v", plan, tasks[1])
+	}
+	if review.Name != tasks[0].Name || review.Priority != URGENT || fmt.Sprint(review.Labels) != fmt.Sprint(tasks[0].Labels) || !review.Due.Equal(tasks[0].Due) {
+		t.Errorf("ParseCalendar() = %+v, want %+v", review, tasks[0])
+	}
+}
+
+func Test_ParseCalendar(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name     string    // The name of the test
+		wantErr  bool      // whether we want an error.
+		contents string    // The input of the test
+		want     []string  // The summaries of the entries we want
+		wantDue  time.Time // The due of the first entry we want
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"todo",
+			false,
+			"BEGIN:VCALENDAR\nBEGIN:VTODO\nUID:1\nSUMMARY:Pay rent\nDUE:20260301T090000Z\nBEGIN:VALARM\nSUMMARY:Reminder\nEND:VALARM\nEND:VTODO\nEND:VCALENDAR\n",
+			[]string{"Pay rent"},
+			time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			"event in a time zone",
+			false,
+			"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Stand\r\n up\r\nDTSTART;TZID=America/New_York:20260301T090000\r\nDTEND;TZID=America/New_York:20260301T093000\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			[]string{"Standup"},
+			time.Date(2026, time.March, 1, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			"all day event",
+			false,
+			"BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Holiday\nDTSTART;VALUE=DATE:20260704\nEND:VEVENT\nBEGIN:VTODO\nSUMMARY:Undated\nEND:VTODO\nEND:VCALENDAR\n",
+			[]string{"Holiday", "Undated"},
+			time.Date(2026, time.July, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{"empty calendar", false, "BEGIN:VCALENDAR\nVERSION:2.0\nEND:VCALENDAR\n", nil, time.Time{}},
+		{"not a calendar", true, "BEGIN:VCARD\nFN:Jill\nEND:VCARD\n", nil, time.Time{}},
+		{"missing end", true, "BEGIN:VCALENDAR\nBEGIN:VTODO\nSUMMARY:Pay rent\nEND:VCALENDAR\n", nil, time.Time{}},
+		{"invalid due", true, "BEGIN:VCALENDAR\nBEGIN:VTODO\nDUE:tomorrow\nEND:VTODO\nEND:VCALENDAR\n", nil, time.Time{}},
+		{"empty file", true, "", nil, time.Time{}},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ParseCalendar([]byte(tt.contents))
+			// Checking the error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCalendar() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.Summary)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ParseCalendar() = %v, want %v", got, tt.want)
+			}
+			if len(entries) > 0 && !entries[0].Due.Equal(tt.wantDue) {
+				t.Errorf("ParseCalendar() due = %v, want %v", entries[0].Due, tt.wantDue)
+			}
+		})
+	}
+}
+
+func Test_AuditDiff(t *testing.T) {
+	created := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name   string      // The name of the test
+		before interface{} // The state of the resource before the change
+		after  interface{} // The state of the resource after the change
+		want   string      // The wanted changes, as field:before>after sorted by field
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{
+			"updated task",
+			&Task{Id: "000000000000000000000021", Name: "Draft", Status: NOTSTARTED, CreatedAt: created, LastModified: created},
+			&Task{Id: "000000000000000000000021", Name: "Final", Status: NOTSTARTED, CreatedAt: created, LastModified: created.Add(time.Hour)},
+			`name:"Draft">"Final"`,
+		},
+		{
+			"created group",
+			(*Group)(nil),
+			&Group{Id: "000000000000000000000002", Name: "test2"},
+			`id:null>"000000000000000000000002" name:null>"test2"`,
+		},
+		{
+			"redacted password",
+			&User{Id: "000000000000000000000012", Password: "$2a$10$old", Role: MemberRole},
+			&User{Id: "000000000000000000000012", Password: "$2a$10$new", Role: AdminRole},
+			`password:"[REDACTED]">"[REDACTED]" role:"member">"admin"`,
+		},
+		{
+			"unchanged user",
+			&User{Id: "000000000000000000000012", Password: "$2a$10$old"},
+			&User{Id: "000000000000000000000012", Password: "$2a$10$old"},
+			"",
+		},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := AuditDiff(tt.before, tt.after)
+			if err != nil {
+				t.Errorf("AuditDiff() error = %v", err)
+				return
+			}
+			var got []string
+			for field, change := range changes {
+				before, after := string(change.Before), string(change.After)
+				if before == "" {
+					before = "null"
+				}
+				if after == "" {
+					after = "null"
+				}
+				got = append(got, field+":"+before+">"+after)
+			}
+			sort.Strings(got)
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("AuditDiff() = %v, want %v", strings.Join(got, " "), tt.want)
+			}
+		})
+	}
+}
+
+func Test_AuditEventRecordChange(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name         string      // The name of the test
+		resourceType string      // The type of the changed resource
+		before       interface{} // The state of the resource before the change
+		after        interface{} // The state of the resource after the change
+		wantId       string      // The wanted resource id of the event
+		wantGroupId  string      // The wanted group of the event
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"updated user", "users", &User{Id: "000000000000000000000012", GroupId: "000000000000000000000002"}, &User{Id: "000000000000000000000012", GroupId: "000000000000000000000002", Role: AdminRole}, "000000000000000000000012", "000000000000000000000002"},
+		{"deleted role", "roles", &Role{Id: "000000000000000000000041", GroupId: "000000000000000000000003"}, (*Role)(nil), "000000000000000000000041", "000000000000000000000003"},
+		{"updated group", "groups", &Group{Id: "000000000000000000000003"}, &Group{Id: "000000000000000000000003", Name: "test3"}, "000000000000000000000003", "000000000000000000000003"},
+		{"resource without a group", "users", (*User)(nil), &User{Id: "000000000000000000000012"}, "000000000000000000000012", "000000000000000000000001"},
+	}
+	// Iterating over the previous test slice, each event was made by an actor of group 000000000000000000000001
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &AuditEvent{ActorId: "000000000000000000000011", GroupId: "000000000000000000000001"}
+			if err := e.RecordChange(tt.resourceType, tt.before, tt.after); err != nil {
+				t.Errorf("AuditEvent.RecordChange() error = %v", err)
+				return
+			}
+			if e.ResourceId != tt.wantId || e.GroupId != tt.wantGroupId {
+				t.Errorf("AuditEvent.RecordChange() = %+v, want resource %v group %v", e, tt.wantId, tt.wantGroupId)
+			}
+		})
+	}
+}
+
+func Test_WebhookValidateURL(t *testing.T) {
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name    string // The name of the test
+		url     string // The url of the webhook
+		wantErr bool   // Whether we want an error
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"public host name", "https://hooks.example.com/events", false},
+		{"public address", "http://93.184.216.34:8080/events", false},
+		{"not http", "ftp://hooks.example.com/events", true},
+		{"loopback", "http://127.0.0.1:8080/events", true},
+		{"loopback ipv6", "http://[::1]/events", true},
+		{"localhost", "http://LOCALHOST./events", true},
+		{"link-local metadata", "http://169.254.169.254/latest/meta-data", true},
+		{"private", "https://10.0.0.5/events", true},
+		{"ipv4 mapped private", "http://[::ffff:192.168.1.1]/events", true},
+		{"unspecified", "http://0.0.0.0/events", true},
+		{"carrier-grade nat", "http://100.64.0.1/events", true},
+		{"carrier-grade nat end", "http://100.127.255.254/events", true},
+		{"after carrier-grade nat", "http://100.128.0.1/events", false},
+		{"ipv4 mapped carrier-grade nat", "http://[::ffff:100.100.100.200]/events", true},
+		{"nat64", "http://[64:ff9b::a00:5]/events", true},
+		{"nat64 public", "http://[64:ff9b::5db8:d822]/events", true},
+		{"local-use nat64", "http://[64:ff9b:1::a00:5]/events", true},
+		{"public ipv6", "http://[2606:2800:220:1:248:1893:25c8:1946]/events", false},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Webhook{Id: "000000000000000000000071", URL: tt.url}
+			if err := w.Validate("update"); (err != nil) != tt.wantErr {
+				t.Errorf("Webhook.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_WebhookDeliveryRecordAttempt(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	// Defining our test slice. Each unit test should have the following properties:
+	tests := []struct {
+		name       string        // The name of the test
+		attempts   int           // The attempts of the delivery, including the recorded one
+		status     int           // The response status of the endpoint
+		err        error         // The error of the request to the endpoint
+		wantStatus string        // The wanted status of the delivery
+		wantWait   time.Duration // The wanted wait before the next attempt
+	}{
+		// Here we're declaring each unit test input and output data as defined before
+		{"delivered", 1, 204, nil, DeliverySucceeded, 0},
+		{"first failure", 1, 500, nil, DeliveryPending, 30 * time.Second},
+		{"third failure", 3, 0, fmt.Errorf("connection refused"), DeliveryPending, 2 * time.Minute},
+		{"redirect", 2, 302, nil, DeliveryPending, time.Minute},
+		{"dead letter", WebhookMaxAttempts, 503, nil, DeliveryDead, 0},
+	}
+	// Iterating over the previous test slice
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &WebhookDelivery{Status: DeliveryPending, Attempts: tt.attempts, Error: "previous error"}
+			d.RecordAttempt(tt.status, tt.err, now)
+			if d.Status != tt.wantStatus || d.LastAttemptAt != now || d.ResponseStatus != tt.status { // Asserting whether we get the correct wanted value
+				t.Errorf("WebhookDelivery.RecordAttempt() = %+v, want status %v", d, tt.wantStatus)
+			}
+			if (d.Error == "") != (tt.wantStatus == DeliverySucceeded) {
+				t.Errorf("WebhookDelivery.RecordAttempt() error = %q", d.Error)
+			}
+			if tt.wantWait == 0 && !d.NextAttemptAt.IsZero() || tt.wantWait > 0 && !d.NextAttemptAt.Equal(now.Add(tt.wantWait)) {
+				t.Errorf("WebhookDelivery.RecordAttempt() next attempt = %v, want %v", d.NextAttemptAt, now.Add(tt.wantWait))
+			}
+		})
+	}
+	if got := WebhookRetryBackoff(20); got != WebhookMaxBackoff {
+		t.Errorf("WebhookRetryBackoff() = %v, want %v", got, WebhookMaxBackoff)
+	}
+	if got := SignWebhookPayload("whsec", 1772355600, []byte(`{"type":"task.created"}`)); got != "6de97f681520ba587bf8c031fad6edc31f4a9e6cde95340f0c039b29339c20c6" {
+		t.Errorf("SignWebhookPayload() = %v", got)
+	}
+}
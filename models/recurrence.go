@@ -0,0 +1,340 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RRule frequencies
+const (
+	DAILY   = "DAILY"
+	WEEKLY  = "WEEKLY"
+	MONTHLY = "MONTHLY"
+	YEARLY  = "YEARLY"
+)
+
+// maxRRulePeriods bounds how many periods of a rule are searched for an occurrence, so rules matching no dates end
+const maxRRulePeriods = 10000
+
+// rruleWeekdays maps the iCalendar weekday codes to time.Weekday values
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RRuleDay is a BYDAY weekday, the optional ordinal selects its nth (or nth last when negative) day of a month
+type RRuleDay struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// String returns the iCalendar form of the RRuleDay
+func (d RRuleDay) String() string {
+	code := strings.ToUpper(d.Weekday.String()[:2])
+	if d.Ordinal != 0 {
+		return strconv.Itoa(d.Ordinal) + code
+	}
+	return code
+}
+
+// RRule is the subset of an iCalendar (RFC 5545) recurrence rule tasks can repeat with
+type RRule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []RRuleDay
+	ByMonthDay []int
+	ByMonth    []int
+	WeekStart  time.Weekday
+}
+
+// parseRRuleInts parses a comma separated list of integers between min and max, zero excluded
+func parseRRuleInts(key string, value string, min int, max int) ([]int, error) {
+	var ints []int
+	for _, v := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(v)
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, errors.New("invalid rrule " + key + ": " + v)
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
+
+// ParseRRule parses an iCalendar RRULE value, with or without its "RRULE:" prefix
+func ParseRRule(rule string) (*RRule, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	if rule == "" {
+		return nil, errors.New("missing rrule")
+	}
+	r := &RRule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(rule, ";") {
+		key, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return nil, errors.New("invalid rrule part: " + part)
+		}
+		if seen[key] {
+			return nil, errors.New("duplicate rrule part: " + key)
+		}
+		seen[key] = true
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case DAILY, WEEKLY, MONTHLY, YEARLY:
+				r.Freq = value
+			default:
+				return nil, errors.New("unsupported rrule frequency: " + value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 {
+				return nil, errors.New("invalid rrule INTERVAL: " + value)
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return nil, errors.New("invalid rrule COUNT: " + value)
+			}
+		case "UNTIL":
+			if r.Until, err = time.Parse("20060102T150405Z", value); err != nil {
+				r.Until, err = time.Parse("20060102", value)
+				if err != nil {
+					return nil, errors.New("invalid rrule UNTIL: " + value)
+				}
+				r.Until = r.Until.Add(24*time.Hour - time.Second) // a date UNTIL includes the whole day
+			}
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				if len(v) < 2 {
+					return nil, errors.New("invalid rrule BYDAY: " + v)
+				}
+				weekday, ok := rruleWeekdays[v[len(v)-2:]]
+				if !ok {
+					return nil, errors.New("invalid rrule BYDAY: " + v)
+				}
+				day := RRuleDay{Weekday: weekday}
+				if len(v) > 2 {
+					day.Ordinal, err = strconv.Atoi(v[:len(v)-2])
+					if err != nil || day.Ordinal == 0 || day.Ordinal < -5 || day.Ordinal > 5 {
+						return nil, errors.New("invalid rrule BYDAY: " + v)
+					}
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			if r.ByMonthDay, err = parseRRuleInts(key, value, -31, 31); err != nil {
+				return nil, err
+			}
+		case "BYMONTH":
+			if r.ByMonth, err = parseRRuleInts(key, value, 1, 12); err != nil {
+				return nil, err
+			}
+		case "WKST":
+			weekStart, ok := rruleWeekdays[value]
+			if !ok {
+				return nil, errors.New("invalid rrule WKST: " + value)
+			}
+			r.WeekStart = weekStart
+		default:
+			return nil, errors.New("unsupported rrule part: " + key)
+		}
+	}
+	if r.Freq == "" {
+		return nil, errors.New("rrule needs a FREQ")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, errors.New("rrule can not have both COUNT and UNTIL")
+	}
+	for _, day := range r.ByDay {
+		if day.Ordinal != 0 && r.Freq != MONTHLY && r.Freq != YEARLY {
+			return nil, errors.New("rrule BYDAY ordinals are only allowed for MONTHLY and YEARLY rules")
+		}
+	}
+	if r.Freq == WEEKLY && len(r.ByMonthDay) > 0 {
+		return nil, errors.New("rrule BYMONTHDAY is not allowed for WEEKLY rules")
+	}
+	return r, nil
+}
+
+// String returns the iCalendar RRULE value of the RRule
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, day := range r.ByDay {
+			days = append(days, day.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+RRuleDay{Weekday: r.WeekStart}.String())
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// joinInts joins a list of integers with commas
+func joinInts(ints []int) string {
+	var s []string
+	for _, n := range ints {
+		s = append(s, strconv.Itoa(n))
+	}
+	return strings.Join(s, ",")
+}
+
+// containsInt checks whether a list of integers contains n
+func containsInt(ints []int, n int) bool {
+	for _, i := range ints {
+		if i == n {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDay checks whether a date is one of the BYMONTHDAY and plain BYDAY days of the RRule
+func (r *RRule) matchesDay(t time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsInt(r.ByMonth, int(t.Month())) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 {
+		last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		if !containsInt(r.ByMonthDay, t.Day()) && !containsInt(r.ByMonthDay, t.Day()-last-1) {
+			return false
+		}
+	}
+	if len(r.ByDay) > 0 {
+		for _, day := range r.ByDay {
+			if day.Weekday == t.Weekday() {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// monthDays returns the days of a month the RRule occurs on, at the time of day of start
+func (r *RRule) monthDays(start time.Time, year int, month time.Month) []time.Time {
+	first := time.Date(year, month, 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	var days []time.Time
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if start.Day() <= last {
+			days = append(days, first.AddDate(0, 0, start.Day()-1))
+		}
+		return days
+	}
+	for d := 1; d <= last; d++ {
+		t := first.AddDate(0, 0, d-1)
+		if len(r.ByMonthDay) > 0 && !containsInt(r.ByMonthDay, d) && !containsInt(r.ByMonthDay, d-last-1) {
+			continue
+		}
+		if len(r.ByDay) > 0 && !r.matchesOrdinalDay(t, d, last) {
+			continue
+		}
+		days = append(days, t)
+	}
+	return days
+}
+
+// matchesOrdinalDay checks whether the dth of a month with last days is one of the BYDAY days of the RRule
+func (r *RRule) matchesOrdinalDay(t time.Time, d int, last int) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday != t.Weekday() {
+			continue
+		}
+		if day.Ordinal == 0 || (day.Ordinal > 0 && (d-1)/7+1 == day.Ordinal) || (day.Ordinal < 0 && (last-d)/7+1 == -day.Ordinal) {
+			return true
+		}
+	}
+	return false
+}
+
+// period returns the sorted dates of the nth period of the RRule for a series starting at start
+func (r *RRule) period(start time.Time, n int) []time.Time {
+	var dates []time.Time
+	switch r.Freq {
+	case DAILY:
+		if t := start.AddDate(0, 0, n*r.Interval); r.matchesDay(t) {
+			dates = append(dates, t)
+		}
+	case WEEKLY:
+		weekStart := start.AddDate(0, 0, -((int(start.Weekday())-int(r.WeekStart)+7)%7)+n*r.Interval*7)
+		if len(r.ByDay) == 0 {
+			dates = append(dates, weekStart.AddDate(0, 0, (int(start.Weekday())-int(r.WeekStart)+7)%7))
+		}
+		for _, day := range r.ByDay {
+			dates = append(dates, weekStart.AddDate(0, 0, (int(day.Weekday)-int(r.WeekStart)+7)%7))
+		}
+		var matched []time.Time
+		for _, t := range dates {
+			if len(r.ByMonth) == 0 || containsInt(r.ByMonth, int(t.Month())) {
+				matched = append(matched, t)
+			}
+		}
+		dates = matched
+	case MONTHLY:
+		month := time.Date(start.Year(), start.Month()+time.Month(n*r.Interval), 1, 0, 0, 0, 0, start.Location())
+		if len(r.ByMonth) == 0 || containsInt(r.ByMonth, int(month.Month())) {
+			dates = r.monthDays(start, month.Year(), month.Month())
+		}
+	case YEARLY:
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []int{int(start.Month())}
+		}
+		for _, m := range months {
+			dates = append(dates, r.monthDays(start, start.Year()+n*r.Interval, time.Month(m))...)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+	return dates
+}
+
+// Next returns the first occurrence of the RRule after a given time for a series starting at start, along with its
+// position in the series, ok is false when the series has no further occurrences
+func (r *RRule) Next(start time.Time, after time.Time) (next time.Time, position int, ok bool) {
+	for n := 0; n < maxRRulePeriods; n++ {
+		for _, t := range r.period(start, n) {
+			if t.Before(start) {
+				continue
+			}
+			position++
+			if (r.Count > 0 && position > r.Count) || (!r.Until.IsZero() && t.After(r.Until)) {
+				return time.Time{}, 0, false
+			}
+			if t.After(after) {
+				return t, position, true
+			}
+		}
+	}
+	return time.Time{}, 0, false
+}
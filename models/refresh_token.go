@@ -0,0 +1,89 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"time"
+)
+
+// RefreshToken is a root struct that is used to store the json encoded data for/from a mongodb refresh token doc.
+// Only the hash of a refresh token is stored, the Token itself is only set when a new refresh token is generated
+type RefreshToken struct {
+	Id           string    `json:"id,omitempty"`
+	Token        string    `json:"refresh_token,omitempty"`
+	TokenHash    string    `json:"-"`
+	FamilyId     string    `json:"family_id,omitempty"`
+	UserId       string    `json:"user_id,omitempty"`
+	GroupId      string    `json:"group_id,omitempty"` // the group the refreshed sessions are issued for
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+	RotatedAt    time.Time `json:"rotated_at,omitempty"`
+	RevokedAt    time.Time `json:"revoked_at,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+}
+
+// HashToken returns the hash of an opaque token or key that is stored in place of the secret itself
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateSecret returns a new random opaque secret
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GenerateToken assigns a new random opaque token to the RefreshToken along with its hash
+func (t *RefreshToken) GenerateToken() (err error) {
+	t.Token, err = generateSecret()
+	if err != nil {
+		return
+	}
+	t.TokenHash = HashToken(t.Token)
+	return
+}
+
+// CheckID determines whether a specified ID is set or not
+func (t *RefreshToken) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(t.Id) {
+			return false
+		}
+	case "family_id":
+		if !utilities.CheckObjectID(t.FamilyId) {
+			return false
+		}
+	case "user_id":
+		if !utilities.CheckObjectID(t.UserId) {
+			return false
+		}
+	case "group_id":
+		if !utilities.CheckObjectID(t.GroupId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks whether a RefreshToken can still be exchanged for a new session
+func (t *RefreshToken) Validate() error {
+	if !t.RevokedAt.IsZero() {
+		return errors.New("refresh token has been revoked")
+	}
+	if !t.RotatedAt.IsZero() {
+		return errors.New("refresh token has already been used")
+	}
+	if time.Now().UTC().After(t.ExpiresAt) {
+		return errors.New("refresh token has expired")
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"strings"
+	"time"
+)
+
+// The built-in roles every group has, admins are granted every permission while members are granted none
+// Members can still manage themselves along with their own tasks
+const (
+	AdminRole  = "admin"
+	MemberRole = "member"
+)
+
+// The permissions that can be granted to a Role, the .any permissions extend to records of other users in the group
+const (
+	PermTasksReadAny   = "tasks.read.any"
+	PermTasksCreateAny = "tasks.create.any"
+	PermTasksUpdateAny = "tasks.update.any"
+	PermTasksDeleteAny = "tasks.delete.any"
+	PermUsersCreate    = "users.create"
+	PermUsersUpdateAny = "users.update.any"
+	PermUsersDelete    = "users.delete"
+	PermUsersUnlock    = "users.unlock"
+	PermUsersInvite    = "users.invite"
+	PermGroupsUpdate   = "groups.update"
+	PermRolesManage    = "roles.manage"
+	PermCommentsManage = "comments.manage"
+	PermWebhooksManage = "webhooks.manage"
+)
+
+// Permissions lists every permission that can be granted to a Role
+var Permissions = []string{
+	PermTasksReadAny,
+	PermTasksCreateAny,
+	PermTasksUpdateAny,
+	PermTasksDeleteAny,
+	PermUsersCreate,
+	PermUsersUpdateAny,
+	PermUsersDelete,
+	PermUsersUnlock,
+	PermUsersInvite,
+	PermGroupsUpdate,
+	PermRolesManage,
+	PermCommentsManage,
+	PermWebhooksManage,
+}
+
+// Role is a root struct that is used to store the json encoded data for/from a mongodb role doc.
+// A Role is a named set of permissions defined by a group, which users of the group are assigned by name
+type Role struct {
+	Id           string    `json:"id,omitempty"`
+	Name         string    `json:"name,omitempty"`
+	GroupId      string    `json:"group_id,omitempty"`
+	Permissions  []string  `json:"permissions"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+}
+
+// BuiltInRoles returns the built-in admin and member roles of a group
+func BuiltInRoles(groupId string) []*Role {
+	return []*Role{
+		{Name: AdminRole, GroupId: groupId, Permissions: Permissions},
+		{Name: MemberRole, GroupId: groupId, Permissions: []string{}},
+	}
+}
+
+// IsBuiltInRole determines whether a role name is reserved for a built-in role
+func IsBuiltInRole(name string) bool {
+	return name == AdminRole || name == MemberRole
+}
+
+// CheckID determines whether a specified ID is set or not
+func (g *Role) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(g.Id) {
+			return false
+		}
+	case "group_id":
+		if !utilities.CheckObjectID(g.GroupId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate a Role for different scenarios such as creating or updating a Role
+func (g *Role) Validate(valCase string) (err error) {
+	var missingFields []string
+	switch valCase {
+	case "create":
+		if g.Name == "" {
+			missingFields = append(missingFields, "name")
+		}
+		if !g.CheckID("group_id") {
+			missingFields = append(missingFields, "group_id")
+		}
+	case "update":
+		if !g.CheckID("id") {
+			missingFields = append(missingFields, "id")
+		}
+	default:
+		return errors.New("unrecognized validation case")
+	}
+	if len(missingFields) > 0 {
+		return errors.New("missing the following role fields: " + strings.Join(missingFields, ", "))
+	}
+	if IsBuiltInRole(g.Name) {
+		return errors.New("role name is reserved: " + g.Name)
+	}
+	for _, p := range g.Permissions {
+		if !containsField(Permissions, p) {
+			return errors.New("invalid permission: " + p)
+		}
+	}
+	return nil
+}
+
+// HasPermission determines whether the Role grants a permission
+func (g *Role) HasPermission(permission string) bool {
+	return containsField(g.Permissions, permission)
+}
@@ -0,0 +1,25 @@
+package models
+
+// The types of SearchHit returned by a search
+const (
+	SearchTask  = "task"
+	SearchUser  = "user"
+	SearchGroup = "group"
+)
+
+const (
+	DefaultSearchLimit   int64 = 20  // number of hits returned when a search does not set a limit
+	MaxSearchLimit       int64 = 100 // maximum number of hits returned by a search
+	MaxSearchQueryLength       = 256 // maximum number of characters of a search query
+)
+
+// SearchHit is a root struct that is used to return a ranked match of a search across tasks, users and groups.
+// Hits are not stored, they are merged from the text searches of each collection and ranked by their Score
+type SearchHit struct {
+	Type  string  `json:"type"`
+	Id    string  `json:"id"`
+	Score float64 `json:"score"`
+	Task  *Task   `json:"task,omitempty"`
+	User  *User   `json:"user,omitempty"`
+	Group *Group  `json:"group,omitempty"`
+}
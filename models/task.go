@@ -1,8 +1,10 @@
 package models
 
 import (
+	"encoding/hex"
 	"errors"
 	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -15,25 +17,73 @@ const (
 	COMPLETED  TaskStatus = "COMPLETED"
 )
 
+type TaskPriority string
+
+const (
+	LOW    TaskPriority = "LOW"
+	MEDIUM TaskPriority = "MEDIUM"
+	HIGH   TaskPriority = "HIGH"
+	URGENT TaskPriority = "URGENT"
+)
+
+// Valid determines whether a TaskPriority is one of the defined priorities
+func (p TaskPriority) Valid() bool {
+	switch p {
+	case LOW, MEDIUM, HIGH, URGENT:
+		return true
+	}
+	return false
+}
+
 // Task is a root struct that is used to store the json encoded data for/from a mongodb group doc.
 type Task struct {
-	Id           string     `json:"id,omitempty"`
-	Name         string     `json:"name,omitempty"`
-	Status       TaskStatus `json:"status,omitempty"`
-	Due          time.Time  `json:"due,omitempty"`
-	Description  string     `json:"description,omitempty"`
-	UserId       string     `json:"user_id,omitempty"`
-	GroupId      string     `json:"group_id,omitempty"`
-	LastModified time.Time  `json:"last_modified,omitempty"`
-	CreatedAt    time.Time  `json:"created_at,omitempty"`
-	DeletedAt    time.Time  `json:"deleted_at,omitempty"`
+	Id           string       `json:"id,omitempty"`
+	Name         string       `json:"name,omitempty"`
+	Status       TaskStatus   `json:"status,omitempty"`
+	Priority     TaskPriority `json:"priority,omitempty"`
+	Due          time.Time    `json:"due,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	Labels       []string     `json:"labels,omitempty"`
+	Estimate     float64      `json:"estimate,omitempty"`
+	UserId       string       `json:"user_id,omitempty"`
+	AssigneeId   string       `json:"assignee_id,omitempty"`
+	GroupId      string       `json:"group_id,omitempty"`
+	ParentId     string       `json:"parent_id,omitempty"`
+	BlockedBy    []string     `json:"blocked_by,omitempty"`
+	SeriesId     string       `json:"series_id,omitempty"`
+	CompletedAt  time.Time    `json:"completed_at,omitempty"`
+	LastModified time.Time    `json:"last_modified,omitempty"`
+	CreatedAt    time.Time    `json:"created_at,omitempty"`
+	DeletedAt    time.Time    `json:"deleted_at,omitempty"`
+}
+
+// TaskSortFields are the task fields a list of tasks can be sorted by
+var TaskSortFields = []string{"name", "status", "due", "estimate", "completed_at", "last_modified", "created_at"}
+
+// TaskFilterFields are the task fields a list of tasks can be filtered by, labels matches tasks having the label
+var TaskFilterFields = []string{"name", "status", "priority", "labels", "user_id", "assignee_id", "group_id", "parent_id", "series_id"}
+
+// TaskRangeFields are the task fields a list of tasks can be filtered by with the _gte and _lte range suffixes
+var TaskRangeFields = []string{"due", "estimate", "completed_at"}
+
+// TaskClearableFields are the optional task fields a modification request can clear by setting them to null
+var TaskClearableFields = []string{"assignee_id", "labels", "estimate", "parent_id", "blocked_by"}
+
+// CheckTaskClearFields ensures every field in a list of fields to clear is one of the TaskClearableFields
+func CheckTaskClearFields(fields []string) error {
+	for _, field := range fields {
+		if !containsField(TaskClearableFields, field) {
+			return errors.New("task field can not be cleared: " + field)
+		}
+	}
+	return nil
 }
 
-// LoadScope scopes the Task struct
-func (g *Task) LoadScope(scopeUser *User) {
+// LoadScope scopes the Task struct, users without the anyPermission are scoped to their own tasks
+func (g *Task) LoadScope(scopeUser *User, anyPermission string) {
 	if !scopeUser.RootAdmin {
 		g.GroupId = scopeUser.GroupId
-		if scopeUser.Role != "admin" {
+		if !scopeUser.HasPermission(anyPermission) {
 			g.UserId = scopeUser.Id
 		}
 	}
@@ -46,6 +96,23 @@ func (g *Task) LoadScope(scopeUser *User) {
 	return
 }
 
+// InScope determines whether a found Task is within the scope of a User, tasks of other users in the group
+// are only in scope when the User has the anyPermission
+func (g *Task) InScope(scopeUser *User, anyPermission string) bool {
+	if scopeUser.RootAdmin {
+		return true
+	}
+	if g.GroupId != scopeUser.GroupId {
+		return false
+	}
+	return g.UserId == scopeUser.Id || scopeUser.HasPermission(anyPermission)
+}
+
+// AssignedTo determines whether a found Task is assigned to a User of its group
+func (g *Task) AssignedTo(scopeUser *User) bool {
+	return g.AssigneeId != "" && g.AssigneeId == scopeUser.Id && g.GroupId == scopeUser.GroupId
+}
+
 // CheckID determines whether a specified ID is set or not
 func (g *Task) CheckID(chkId string) bool {
 	switch chkId {
@@ -61,6 +128,18 @@ func (g *Task) CheckID(chkId string) bool {
 		if !utilities.CheckObjectID(g.UserId) {
 			return false
 		}
+	case "assignee_id":
+		if !utilities.CheckObjectID(g.AssigneeId) {
+			return false
+		}
+	case "parent_id":
+		if !utilities.CheckObjectID(g.ParentId) {
+			return false
+		}
+	case "series_id":
+		if !utilities.CheckObjectID(g.SeriesId) {
+			return false
+		}
 	}
 	return true
 }
@@ -92,6 +171,36 @@ func (g *Task) Validate(valCase string) (err error) {
 	if len(missingFields) > 0 {
 		return errors.New("missing the following group fields: " + strings.Join(missingFields, ", "))
 	}
+	if g.Priority != "" && !g.Priority.Valid() {
+		return errors.New("invalid task priority: " + string(g.Priority))
+	}
+	if g.Estimate < 0 {
+		return errors.New("task estimate can not be negative")
+	}
+	for _, label := range g.Labels {
+		if strings.TrimSpace(label) == "" {
+			return errors.New("task labels can not be empty")
+		}
+	}
+	if g.ParentId != "" && g.ParentId == g.Id {
+		return errors.New("task can not be its own parent")
+	}
+	if len(g.BlockedBy) > MaxTaskBlockers {
+		return errors.New("task can be blocked by at most " + strconv.Itoa(MaxTaskBlockers) + " tasks")
+	}
+	seen := make(map[string]bool)
+	for _, blocker := range g.BlockedBy {
+		if _, err := hex.DecodeString(blocker); err != nil || len(blocker) != 24 || !utilities.CheckObjectID(blocker) {
+			return errors.New("invalid task blocker: " + blocker)
+		}
+		if blocker == g.Id {
+			return errors.New("task can not block itself")
+		}
+		if seen[blocker] {
+			return errors.New("duplicate task blocker: " + blocker)
+		}
+		seen[blocker] = true
+	}
 	return
 }
 
@@ -103,16 +212,47 @@ func (g *Task) BuildUpdate(cur *Task) {
 	if len(g.Status) == 0 {
 		g.Status = cur.Status
 	}
+	if len(g.Priority) == 0 {
+		g.Priority = cur.Priority
+	}
 	if g.Due.IsZero() {
 		g.Due = cur.Due
 	}
 	if len(g.Description) == 0 {
 		g.Description = cur.Description
 	}
+	if g.Labels == nil {
+		g.Labels = cur.Labels
+	}
+	if g.Estimate == 0 {
+		g.Estimate = cur.Estimate
+	}
 	if len(g.UserId) == 0 {
 		g.UserId = cur.UserId
 	}
+	if len(g.AssigneeId) == 0 {
+		g.AssigneeId = cur.AssigneeId
+	}
 	if len(g.GroupId) == 0 {
 		g.GroupId = cur.GroupId
 	}
+	if len(g.ParentId) == 0 {
+		g.ParentId = cur.ParentId
+	}
+	if g.BlockedBy == nil {
+		g.BlockedBy = cur.BlockedBy
+	}
+	g.SeriesId = cur.SeriesId
+	g.CompletedAt = cur.CompletedAt
+}
+
+// TasksToFiles converts an input slice of task to a slice of file matching the attachments of each task
+func TasksToFiles(tasks []*Task) []*File {
+	var files []*File
+	for _, t := range tasks {
+		if t.CheckID("id") {
+			files = append(files, &File{OwnerId: t.Id, OwnerType: "task"})
+		}
+	}
+	return files
 }
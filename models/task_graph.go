@@ -0,0 +1,50 @@
+package models
+
+import "strings"
+
+// MaxTaskBlockers is the maximum number of tasks a Task can be blocked by
+const MaxTaskBlockers = 50
+
+// TaskTree is a Task along with the tree of its subtasks
+type TaskTree struct {
+	*Task
+	Subtasks []*TaskTree `json:"subtasks,omitempty"`
+}
+
+// TaskDependency is a "blocked by" link between two tasks of a group
+type TaskDependency struct {
+	TaskId    string `json:"task_id"`
+	BlockedBy string `json:"blocked_by"`
+}
+
+// TaskGraph is the dependency graph of a Task, the tasks that transitively block it or are blocked by it
+type TaskGraph struct {
+	Tasks []*Task           `json:"tasks"`
+	Edges []*TaskDependency `json:"edges"`
+}
+
+// Open determines whether a Task still blocks the tasks it blocks
+func (g *Task) Open() bool {
+	return g.Status != COMPLETED
+}
+
+// CycleError is returned when a parent or blocker link would make a Task depend on itself
+type CycleError struct {
+	Relation string
+	TaskId   string
+}
+
+// Error returns the message of the CycleError
+func (e *CycleError) Error() string {
+	return "task " + e.Relation + " " + e.TaskId + " would create a cycle"
+}
+
+// BlockedError is returned when a Task can not be completed because some of its blockers are still open
+type BlockedError struct {
+	BlockerIds []string
+}
+
+// Error returns the message of the BlockedError
+func (e *BlockedError) Error() string {
+	return "task is blocked by open tasks: " + strings.Join(e.BlockerIds, ", ")
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"strings"
+	"time"
+)
+
+// TaskHistory is a root struct that is used to store the json encoded data for/from a mongodb task history doc.
+// A TaskHistory records a single status transition of a Task and the User that made it
+type TaskHistory struct {
+	Id           string     `json:"id,omitempty"`
+	TaskId       string     `json:"task_id,omitempty"`
+	GroupId      string     `json:"group_id,omitempty"`
+	ActorId      string     `json:"actor_id,omitempty"`
+	From         TaskStatus `json:"from,omitempty"`
+	To           TaskStatus `json:"to,omitempty"`
+	LastModified time.Time  `json:"last_modified,omitempty"`
+	CreatedAt    time.Time  `json:"created_at,omitempty"`
+	DeletedAt    time.Time  `json:"deleted_at,omitempty"`
+}
+
+// CheckID determines whether a specified ID is set or not
+func (g *TaskHistory) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(g.Id) {
+			return false
+		}
+	case "task_id":
+		if !utilities.CheckObjectID(g.TaskId) {
+			return false
+		}
+	case "group_id":
+		if !utilities.CheckObjectID(g.GroupId) {
+			return false
+		}
+	case "actor_id":
+		if !utilities.CheckObjectID(g.ActorId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate a TaskHistory for different scenarios such as creating a TaskHistory
+func (g *TaskHistory) Validate(valCase string) (err error) {
+	var missingFields []string
+	switch valCase {
+	case "create":
+		if !g.CheckID("task_id") {
+			missingFields = append(missingFields, "task_id")
+		}
+		if !g.CheckID("group_id") {
+			missingFields = append(missingFields, "group_id")
+		}
+		if !g.CheckID("actor_id") {
+			missingFields = append(missingFields, "actor_id")
+		}
+		if g.To == "" {
+			missingFields = append(missingFields, "to")
+		}
+	default:
+		return errors.New("unrecognized validation case")
+	}
+	if len(missingFields) > 0 {
+		return errors.New("missing the following task history fields: " + strings.Join(missingFields, ", "))
+	}
+	return nil
+}
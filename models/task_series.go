@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"time"
+)
+
+// TaskSeries generation modes
+const (
+	GenerateOnCompletion = "completion"
+	GenerateOnSchedule   = "schedule"
+)
+
+// ErrTaskSeriesEnded is returned when a TaskSeries has no further occurrences
+var ErrTaskSeriesEnded = errors.New("task series has no further occurrences")
+
+// ErrTaskSeriesChanged is returned when a TaskSeries moved on to another occurrence while it was being changed
+var ErrTaskSeriesChanged = errors.New("task series changed, try again")
+
+// TaskSeries is a root struct that is used to store the json encoded data for/from a mongodb task series doc.
+// A TaskSeries repeats a Task with an iCalendar RRULE, each occurrence of the rule is a new instance of the Task
+// that is created when the current instance is completed or, on schedule, when the current instance is due
+type TaskSeries struct {
+	Id           string    `json:"id,omitempty"`
+	TaskId       string    `json:"task_id,omitempty"`
+	GroupId      string    `json:"group_id,omitempty"`
+	UserId       string    `json:"user_id,omitempty"`
+	RRule        string    `json:"rrule,omitempty"`
+	Generate     string    `json:"generate,omitempty"`
+	Start        time.Time `json:"start,omitempty"`
+	Occurrence   int       `json:"occurrence,omitempty"`
+	Due          time.Time `json:"due,omitempty"`
+	NextDue      time.Time `json:"next_due,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+}
+
+// CheckID determines whether a specified ID is set or not
+func (g *TaskSeries) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(g.Id) {
+			return false
+		}
+	case "task_id":
+		if !utilities.CheckObjectID(g.TaskId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate a TaskSeries for different scenarios such as setting the recurrence of a Task
+func (g *TaskSeries) Validate(valCase string) (err error) {
+	switch valCase {
+	case "set":
+		if !g.CheckID("task_id") {
+			return errors.New("missing task_id")
+		}
+		if _, err = ParseRRule(g.RRule); err != nil {
+			return err
+		}
+		if g.Generate != "" && g.Generate != GenerateOnCompletion && g.Generate != GenerateOnSchedule {
+			return errors.New("task series generate must be " + GenerateOnCompletion + " or " + GenerateOnSchedule)
+		}
+	default:
+		return errors.New("unrecognized validation case")
+	}
+	return
+}
+
+// Rule returns the parsed RRULE of the TaskSeries
+func (g *TaskSeries) Rule() (*RRule, error) {
+	return ParseRRule(g.RRule)
+}
@@ -20,11 +20,24 @@ type User struct {
 	RootAdmin    bool      `json:"root_admin,omitempty"`
 	GroupId      string    `json:"group_id,omitempty"`
 	ImageId      string    `json:"image_id,omitempty"`
+	TwoFactor    bool      `json:"two_factor,omitempty"`
+	TOTPSecret   string    `json:"-"`
+	TOTPStep     int64     `json:"-"`
+	Recovery     []string  `json:"-"`
+	VerifiedAt   time.Time `json:"email_verified_at,omitempty"`
+	LockedUntil  time.Time `json:"locked_until,omitempty"`
+	Permissions  []string  `json:"-"`
 	LastModified time.Time `json:"last_modified,omitempty"`
 	CreatedAt    time.Time `json:"created_at,omitempty"`
 	DeletedAt    time.Time `json:"deleted_at,omitempty"`
 }
 
+// UserSortFields are the user fields a list of users can be sorted by
+var UserSortFields = []string{"username", "firstname", "lastname", "email", "role", "last_modified", "created_at"}
+
+// UserFilterFields are the user fields a list of users can be filtered by
+var UserFilterFields = []string{"username", "firstname", "lastname", "email", "role", "group_id"}
+
 // LoadScope scopes the User struct
 func (g *User) LoadScope(scopeUser *User, valCase string) {
 	switch valCase {
@@ -33,14 +46,17 @@ func (g *User) LoadScope(scopeUser *User, valCase string) {
 			g.RootAdmin = false
 			g.GroupId = scopeUser.GroupId
 		}
+		if !scopeUser.HasPermission(PermRolesManage) {
+			g.Role = MemberRole
+		}
 	case "update":
 		g.Id = scopeUser.Id
 		if !scopeUser.RootAdmin {
 			g.RootAdmin = false
 			g.GroupId = scopeUser.GroupId
-			if scopeUser.Role != "admin" {
-				g.Role = "member"
-			}
+		}
+		if !scopeUser.HasPermission(PermRolesManage) {
+			g.Role = "" // keeps the current role
 		}
 	case "find":
 		if !scopeUser.RootAdmin {
@@ -50,6 +66,11 @@ func (g *User) LoadScope(scopeUser *User, valCase string) {
 	return
 }
 
+// HasPermission determines whether the User is granted a permission, root admins are granted every permission
+func (g *User) HasPermission(permission string) bool {
+	return g.RootAdmin || containsField(g.Permissions, permission)
+}
+
 // CheckID determines whether a specified ID is set or not
 func (g *User) CheckID(chkId string) bool {
 	switch chkId {
@@ -79,6 +100,12 @@ func (g *User) Authenticate(checkPassword string) error {
 	return errors.New("no password set to hash in user model")
 }
 
+// SetRandomPassword assigns a random password to a User that signs in without one, such as with an identity provider
+func (g *User) SetRandomPassword() (err error) {
+	g.Password, err = generateSecret()
+	return
+}
+
 // HashPassword hashes a user password and associates it with the user struct
 func (g *User) HashPassword() error {
 	if len(g.Password) != 0 {
@@ -171,6 +198,18 @@ func (g *User) BuildUpdate(curUser *User) {
 	}
 }
 
+// UseRecoveryCode removes a matching one-time recovery code from the User, the codes are stored as hashes
+func (g *User) UseRecoveryCode(code string) bool {
+	hash := HashToken(strings.ToLower(strings.TrimSpace(code)))
+	for i, h := range g.Recovery {
+		if h == hash {
+			g.Recovery = append(g.Recovery[:i:i], g.Recovery[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 // UsersToFiles converts an input slice of user to a slice of file
 func UsersToFiles(users []*User) []*File {
 	var files []*File
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"time"
+)
+
+const (
+	PasswordResetToken     = "password_reset"
+	EmailVerificationToken = "email_verification"
+)
+
+// UserToken is a root struct that is used to store the json encoded data for/from a mongodb user token doc.
+// User tokens are single use tokens that are emailed to a User, such as to reset a password or verify an email address.
+// Only the hash of a user token is stored, the Token itself is only set when a new user token is generated
+type UserToken struct {
+	Id           string    `json:"id,omitempty"`
+	Token        string    `json:"token,omitempty"`
+	TokenHash    string    `json:"-"`
+	UserId       string    `json:"user_id,omitempty"`
+	Purpose      string    `json:"purpose,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+}
+
+// GenerateToken assigns a new random opaque token to the UserToken along with its hash
+func (t *UserToken) GenerateToken() (err error) {
+	t.Token, err = generateSecret()
+	if err != nil {
+		return
+	}
+	t.TokenHash = HashToken(t.Token)
+	return
+}
+
+// CheckID determines whether a specified ID is set or not
+func (t *UserToken) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(t.Id) {
+			return false
+		}
+	case "user_id":
+		if !utilities.CheckObjectID(t.UserId) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks whether a UserToken can be issued or redeemed for a purpose
+func (t *UserToken) Validate(purpose string) error {
+	if t.Purpose != purpose || (purpose != PasswordResetToken && purpose != EmailVerificationToken) {
+		return errors.New("invalid token purpose")
+	}
+	if !t.CheckID("user_id") {
+		return errors.New("missing token user id")
+	}
+	if time.Now().UTC().After(t.ExpiresAt) {
+		return errors.New("token has expired")
+	}
+	return nil
+}
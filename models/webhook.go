@@ -0,0 +1,275 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The states of a WebhookDelivery, a delivery that keeps failing is dead once it has used up its attempts
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+const (
+	WebhookMaxAttempts = 8                // attempts of a delivery before it is dead
+	WebhookBackoff     = 30 * time.Second // wait after the first failed attempt, doubled after every further one
+	WebhookMaxBackoff  = 6 * time.Hour    // longest wait between two attempts
+	WebhookTimeout     = 10 * time.Second // time a webhook endpoint has to respond
+)
+
+// WebhookDeliverySortFields are the webhook delivery fields a list of deliveries can be sorted by
+var WebhookDeliverySortFields = []string{"event", "status", "attempts", "next_attempt_at", "created_at"}
+
+// WebhookDeliveryFilterFields are the webhook delivery fields a list of deliveries can be filtered by
+var WebhookDeliveryFilterFields = []string{"event", "event_id", "status"}
+
+// WebhookDeliveryRangeFields are the webhook delivery fields a list of deliveries can be filtered by with the _gte
+// and _lte range suffixes
+var WebhookDeliveryRangeFields = []string{"created_at"}
+
+// Webhook is a root struct that is used to store the json encoded data for/from a mongodb webhook doc.
+// The Events of a group a Webhook subscribes to are POSTed to its URL, signed with its Secret. The Secret is only
+// returned when the Webhook is created
+type Webhook struct {
+	Id           string    `json:"id,omitempty"`
+	URL          string    `json:"url,omitempty"`
+	Secret       string    `json:"secret,omitempty"`
+	Events       []string  `json:"events,omitempty"`
+	UserId       string    `json:"user_id,omitempty"`
+	GroupId      string    `json:"group_id,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at,omitempty"`
+}
+
+// GenerateSecret assigns a new random secret to the Webhook
+func (g *Webhook) GenerateSecret() (err error) {
+	g.Secret, err = generateSecret()
+	return
+}
+
+// CheckID determines whether a specified ID is set or not
+func (g *Webhook) CheckID(chkId string) bool {
+	switch chkId {
+	case "id":
+		if !utilities.CheckObjectID(g.Id) {
+			return false
+		}
+	case "user_id":
+		if !utilities.CheckObjectID(g.UserId) {
+			return false
+		}
+	case "group_id":
+		if !utilities.CheckObjectID(g.GroupId) {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrWebhookAddress is returned for webhook urls that point to an address of the API's own network
+var ErrWebhookAddress = errors.New("webhook urls can not point to loopback, link-local, private or NAT addresses")
+
+// webhookBlockedNetworks are the ranges that reach a private network without being private addresses, the shared
+// address space of carrier-grade NAT that cloud networks use and the NAT64 prefixes that embed any IPv4 address
+var webhookBlockedNetworks = parseNetworks("100.64.0.0/10", "64:ff9b::/96", "64:ff9b:1::/48")
+
+// parseNetworks parses a list of CIDR ranges, panicking on an invalid one
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// CheckWebhookIP ensures a webhook is only delivered to a public address, so that webhooks can not reach the loopback,
+// link-local, such as cloud metadata endpoints, or private addresses of the API's own network
+// IPv4 addresses mapped into IPv6 are checked as the IPv4 address they map to
+func CheckWebhookIP(ip net.IP) error {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() {
+		return ErrWebhookAddress
+	}
+	for _, network := range webhookBlockedNetworks {
+		if network.Contains(ip) {
+			return ErrWebhookAddress
+		}
+	}
+	return nil
+}
+
+// checkURL ensures the URL of a Webhook is an absolute http or https URL whose host is not a local address, host names
+// are checked against the addresses they resolve to when a delivery is sent
+func (g *Webhook) checkURL() error {
+	u, err := url.Parse(g.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookAddress
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return CheckWebhookIP(ip)
+	}
+	return nil
+}
+
+// checkEvents ensures a Webhook subscribes to at least one event, and only to event types that exist
+func (g *Webhook) checkEvents() error {
+	if len(g.Events) == 0 {
+		return errors.New("a webhook must subscribe to at least one event")
+	}
+	for _, e := range g.Events {
+		if !CheckEventType(e) {
+			return errors.New("invalid event type: " + e + ", events must be one of: " + strings.Join(EventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// Validate checks whether a Webhook has the fields required for a given valCase
+func (g *Webhook) Validate(valCase string) (err error) {
+	var missingFields []string
+	switch valCase {
+	case "create":
+		if g.URL == "" {
+			missingFields = append(missingFields, "url")
+		}
+		if !g.CheckID("user_id") {
+			missingFields = append(missingFields, "user_id")
+		}
+		if !g.CheckID("group_id") {
+			missingFields = append(missingFields, "group_id")
+		}
+		if len(missingFields) > 0 {
+			return errors.New("missing the following webhook fields: " + strings.Join(missingFields, ", "))
+		}
+		if err = g.checkURL(); err != nil {
+			return
+		}
+		return g.checkEvents()
+	case "update":
+		if !g.CheckID("id") {
+			return errors.New("missing the following webhook fields: id")
+		}
+		if g.URL != "" {
+			if err = g.checkURL(); err != nil {
+				return
+			}
+		}
+		if g.Events != nil {
+			return g.checkEvents()
+		}
+	default:
+		return errors.New("unrecognized validation case")
+	}
+	return
+}
+
+// Subscribes determines whether the Webhook subscribes to an event type
+func (g *Webhook) Subscribes(eventType string) bool {
+	return containsField(g.Events, eventType)
+}
+
+// SignWebhookPayload returns the signature of a webhook payload sent at a unix timestamp, the hex encoded
+// HMAC-SHA256 of the timestamp and payload joined by a dot
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDelivery is a root struct that is used to store the json encoded data for/from a mongodb webhook delivery
+// doc. A WebhookDelivery is the delivery of an Event to a Webhook, it is attempted until the endpoint responds with a
+// 2xx status or it is dead
+type WebhookDelivery struct {
+	Id             string          `json:"id,omitempty"`
+	WebhookId      string          `json:"webhook_id,omitempty"`
+	GroupId        string          `json:"group_id,omitempty"`
+	Event          string          `json:"event,omitempty"`
+	EventId        string          `json:"event_id,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	Status         string          `json:"status,omitempty"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  time.Time       `json:"last_attempt_at,omitempty"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	LastModified   time.Time       `json:"last_modified,omitempty"`
+	CreatedAt      time.Time       `json:"created_at,omitempty"`
+	DeletedAt      time.Time       `json:"deleted_at,omitempty"`
+}
+
+// NewWebhookDelivery initializes a new pending WebhookDelivery of an Event to a Webhook
+func NewWebhookDelivery(w *Webhook, e *Event) (*WebhookDelivery, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookDelivery{
+		WebhookId:     w.Id,
+		GroupId:       w.GroupId,
+		Event:         e.Type,
+		EventId:       e.Id,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: e.CreatedAt,
+	}, nil
+}
+
+// WebhookRetryBackoff returns how long to wait after an attempt of a delivery before the next one
+func WebhookRetryBackoff(attempt int) time.Duration {
+	backoff := WebhookBackoff
+	for i := 1; i < attempt && backoff < WebhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > WebhookMaxBackoff {
+		backoff = WebhookMaxBackoff
+	}
+	return backoff
+}
+
+// RecordAttempt records the result of the latest attempt of the WebhookDelivery at now, a failed delivery is retried
+// with an exponential backoff until it has used up its attempts
+func (d *WebhookDelivery) RecordAttempt(responseStatus int, err error, now time.Time) {
+	d.LastAttemptAt = now
+	d.ResponseStatus = responseStatus
+	d.Error = ""
+	if err == nil && (responseStatus < 200 || responseStatus > 299) {
+		err = errors.New("endpoint responded with status " + strconv.Itoa(responseStatus))
+	}
+	if err == nil {
+		d.Status = DeliverySucceeded
+		d.NextAttemptAt = time.Time{}
+		return
+	}
+	d.Error = err.Error()
+	if d.Attempts >= WebhookMaxAttempts {
+		d.Status = DeliveryDead
+		d.NextAttemptAt = time.Time{}
+		return
+	}
+	d.Status = DeliveryPending
+	d.NextAttemptAt = now.Add(WebhookRetryBackoff(d.Attempts))
+}
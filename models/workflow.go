@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+)
+
+// MaxWorkflowStatuses is the maximum number of statuses a TaskWorkflow can define
+const MaxWorkflowStatuses = 20
+
+var workflowStatusPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,31}$`)
+
+// TaskStatusTransition is a move between two task statuses that a TaskWorkflow allows
+type TaskStatusTransition struct {
+	From TaskStatus `json:"from"`
+	To   TaskStatus `json:"to"`
+}
+
+// TaskWorkflow is the set of statuses the tasks of a group can have and the transitions allowed between them.
+// The first status is the status of new tasks, EnforceBlockers stops tasks moving to COMPLETED while they have open blockers
+type TaskWorkflow struct {
+	Statuses        []TaskStatus           `json:"statuses"`
+	Transitions     []TaskStatusTransition `json:"transitions"`
+	EnforceBlockers bool                   `json:"enforce_blockers,omitempty"`
+}
+
+// DefaultTaskWorkflow returns the TaskWorkflow of groups that have not configured their own
+func DefaultTaskWorkflow() *TaskWorkflow {
+	return &TaskWorkflow{
+		Statuses: []TaskStatus{NOTSTARTED, INPROGRESS, COMPLETED},
+		Transitions: []TaskStatusTransition{
+			{From: NOTSTARTED, To: INPROGRESS},
+			{From: INPROGRESS, To: COMPLETED},
+		},
+	}
+}
+
+// Validate ensures the TaskWorkflow has unique, well formed statuses and only transitions between them
+func (w *TaskWorkflow) Validate() error {
+	if len(w.Statuses) == 0 {
+		return errors.New("a task workflow needs at least one status")
+	}
+	if len(w.Statuses) > MaxWorkflowStatuses {
+		return errors.New("a task workflow can have at most 20 statuses")
+	}
+	seen := make(map[TaskStatus]bool)
+	for _, status := range w.Statuses {
+		if !workflowStatusPattern.MatchString(string(status)) {
+			return errors.New("invalid task status: " + string(status))
+		}
+		if seen[status] {
+			return errors.New("duplicate task status: " + string(status))
+		}
+		seen[status] = true
+	}
+	for _, t := range w.Transitions {
+		if !seen[t.From] || !seen[t.To] {
+			return errors.New("task transition between unknown statuses: " + string(t.From) + " to " + string(t.To))
+		}
+		if t.From == t.To {
+			return errors.New("task transition to the same status: " + string(t.From))
+		}
+	}
+	return nil
+}
+
+// InitialStatus returns the status of new tasks
+func (w *TaskWorkflow) InitialStatus() TaskStatus {
+	return w.Statuses[0]
+}
+
+// HasStatus determines whether a status is one of the TaskWorkflow statuses
+func (w *TaskWorkflow) HasStatus(status TaskStatus) bool {
+	for _, s := range w.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition ensures a task can move from one status to another, tasks left in a status that the
+// TaskWorkflow no longer has can move to any of its statuses
+func (w *TaskWorkflow) CheckTransition(from TaskStatus, to TaskStatus) error {
+	if !w.HasStatus(to) {
+		return &TransitionError{From: from, To: to}
+	}
+	if !w.HasStatus(from) {
+		return nil
+	}
+	for _, t := range w.Transitions {
+		if t.From == from && t.To == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to}
+}
+
+// TransitionError is returned when a TaskWorkflow does not allow a task to move between two statuses
+type TransitionError struct {
+	From TaskStatus
+	To   TaskStatus
+}
+
+// Error returns the message of the TransitionError
+func (e *TransitionError) Error() string {
+	return "task can not move from " + string(e.From) + " to " + string(e.To)
+}
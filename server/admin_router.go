@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"net/http"
+	"os"
+	"time"
+)
+
+type adminRouter struct {
+	aService *services.TokenService
+	gService services.GroupService
+	uService services.UserService
+	tService services.TaskService
+	fService services.FileService
+}
+
+// NewAdminRouter is a function that initializes a new adminRouter struct
+func NewAdminRouter(router *mux.Router, a *services.TokenService, g services.GroupService, u services.UserService, t services.TaskService, f services.FileService) *mux.Router {
+	aRouter := adminRouter{a, g, u, t, f}
+	router.HandleFunc("/purge", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/purge", a.RootAdminTokenVerifyMiddleWare(aRouter.Purge)).Methods("POST")
+	return router
+}
+
+// purgeRetention returns how long soft deleted records are kept before they can be purged
+func purgeRetention() (time.Duration, error) {
+	retention := os.Getenv("PURGE_RETENTION")
+	if retention == "" {
+		return time.Hour * 720, nil // Default 30 day retention for soft deleted records
+	}
+	return time.ParseDuration(retention)
+}
+
+// Purge permanently removes every record that was soft deleted longer ago than the configured retention
+func (ar *adminRouter) Purge(w http.ResponseWriter, r *http.Request) {
+	retention, err := purgeRetention()
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	var dto purgeDTO
+	dto.Before = time.Now().UTC().Add(-retention)
+	dto.Files, err = ar.fService.FilesPurge(r.Context(), dto.Before)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	dto.Tasks, err = ar.tService.TasksPurge(r.Context(), dto.Before)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	dto.Users, err = ar.uService.UsersPurge(r.Context(), dto.Before)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	dto.Groups, err = ar.gService.GroupsPurge(r.Context(), dto.Before)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(dto); err != nil {
+		return
+	}
+	return
+}
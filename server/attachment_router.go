@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+)
+
+type attachmentRouter struct {
+	aService *services.TokenService
+	tService services.TaskService
+	fService services.FileService
+}
+
+// NewAttachmentRouter is a function that initializes a new attachmentRouter struct
+func NewAttachmentRouter(router *mux.Router, a *services.TokenService, t services.TaskService, f services.FileService) *mux.Router {
+	aRouter := attachmentRouter{a, t, f}
+	router.HandleFunc("/tasks/{taskId}/attachments", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/tasks/{taskId}/attachments", a.MemberTokenVerifyMiddleWare(aRouter.AttachmentsShow)).Methods("GET")
+	router.HandleFunc("/tasks/{taskId}/attachments", a.MemberTokenVerifyMiddleWare(aRouter.UploadAttachment)).Methods("POST")
+	router.HandleFunc("/tasks/{taskId}/attachments/{attachmentId}", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/tasks/{taskId}/attachments/{attachmentId}", a.MemberTokenVerifyMiddleWare(aRouter.GetAttachment)).Methods("GET")
+	router.HandleFunc("/tasks/{taskId}/attachments/{attachmentId}", a.MemberTokenVerifyMiddleWare(aRouter.DeleteAttachment)).Methods("DELETE")
+	return router
+}
+
+// loadTask returns the Task of an attachment request when the requester can access it with the anyPermission
+func (ar *attachmentRouter) loadTask(w http.ResponseWriter, r *http.Request, anyPermission string) (*models.Task, bool) {
+	vars := mux.Vars(r)
+	taskId := vars["taskId"]
+	if !utilities.CheckObjectID(taskId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing taskId"})
+		return nil, false
+	}
+	td, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return nil, false
+	}
+	requester := td.ToUser()
+	task, err := ar.tService.TaskFind(r.Context(), &models.Task{Id: taskId})
+	if err != nil || !(task.InScope(requester, anyPermission) || task.AssignedTo(requester)) {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "task not found"})
+		return nil, false
+	}
+	return task, true
+}
+
+// loadAttachment returns the attachment File of a request when it belongs to the Task of the request
+func (ar *attachmentRouter) loadAttachment(w http.ResponseWriter, r *http.Request, anyPermission string) (*models.File, bool) {
+	task, ok := ar.loadTask(w, r, anyPermission)
+	if !ok {
+		return nil, false
+	}
+	vars := mux.Vars(r)
+	attachmentId := vars["attachmentId"]
+	if !utilities.CheckObjectID(attachmentId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing attachmentId"})
+		return nil, false
+	}
+	file, err := ar.fService.FileFind(r.Context(), &models.File{Id: attachmentId})
+	if err != nil || file.OwnerType != "task" || file.OwnerId != task.Id {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "attachment not found"})
+		return nil, false
+	}
+	return file, true
+}
+
+// AttachmentsShow returns the attachments of a task
+func (ar *attachmentRouter) AttachmentsShow(w http.ResponseWriter, r *http.Request) {
+	task, ok := ar.loadTask(w, r, models.PermTasksReadAny)
+	if !ok {
+		return
+	}
+	files, err := ar.fService.FilesFind(r.Context(), &models.File{OwnerId: task.Id})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&attachmentsDTO{Attachments: files}); err != nil {
+		return
+	}
+}
+
+// UploadAttachment attaches the file of a multipart form request to a task
+func (ar *attachmentRouter) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	task, ok := ar.loadTask(w, r, models.PermTasksUpdateAny)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, models.MaxAttachmentSize+1048576)
+	file, handler, err := r.FormFile("file")
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	defer file.Close()
+	buf := bytes.NewBuffer(nil)
+	if _, err = io.Copy(buf, io.LimitReader(file, models.MaxAttachmentSize+1)); err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if buf.Len() > models.MaxAttachmentSize {
+		utilities.RespondWithError(w, http.StatusRequestEntityTooLarge, utilities.JWTError{Message: "attachments can not be larger than " + strconv.Itoa(models.MaxAttachmentSize) + " bytes"})
+		return
+	}
+	fileType := handler.Header.Get("Content-Type")
+	if fileType == "" {
+		fileType = http.DetectContentType(buf.Bytes())
+	}
+	f := &models.File{
+		OwnerType:  "task",
+		OwnerId:    task.Id,
+		BucketType: "task-attachments",
+		Name:       filepath.Base(handler.Filename),
+		FileType:   fileType,
+	}
+	f, err = ar.fService.FileCreate(r.Context(), f, buf.Bytes())
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(f); err != nil {
+		return
+	}
+}
+
+// GetAttachment returns the file contents of a task attachment
+func (ar *attachmentRouter) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	file, ok := ar.loadAttachment(w, r, models.PermTasksReadAny)
+	if !ok {
+		return
+	}
+	contents, err := ar.fService.RetrieveFile(r.Context(), &models.File{GridFSId: file.GridFSId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	cd := mime.FormatMediaType("attachment", map[string]string{"filename": file.Name})
+	w.Header().Set("Content-Disposition", cd)
+	w.Header().Set("Content-Type", file.FileType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	contentReader := bytes.NewReader(contents.Bytes())
+	http.ServeContent(w, r, file.Name, file.LastModified, contentReader)
+}
+
+// DeleteAttachment deletes a task attachment, its GridFS content is kept until the File is purged
+func (ar *attachmentRouter) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	file, ok := ar.loadAttachment(w, r, models.PermTasksUpdateAny)
+	if !ok {
+		return
+	}
+	file, err := ar.fService.FileDelete(r.Context(), &models.File{Id: file.Id})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(file); err != nil {
+		return
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type auditRouter struct {
+	aService  *services.TokenService
+	auService services.AuditService
+}
+
+// NewAuditRouter is a function that initializes a new auditRouter struct, every mutating call to the router is
+// recorded in the audit log
+func NewAuditRouter(router *mux.Router, a *services.TokenService, au services.AuditService) *mux.Router {
+	aRouter := auditRouter{a, au}
+	router.Use(aRouter.AuditMiddleWare)
+	router.HandleFunc("/audit", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/audit", a.AdminTokenVerifyMiddleWare(aRouter.GetAuditEvents)).Methods("GET")
+	return router
+}
+
+// auditResponseWriter captures the status code a handler responded with
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code before writing it
+func (w *auditResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// newAuditEvent returns the AuditEvent of a request, its action is the method and route template of the request and
+// its resource is the first segment of the route along with the last record id in the route, routes of a group set
+// the group of the event
+func newAuditEvent(r *http.Request) *models.AuditEvent {
+	e := &models.AuditEvent{
+		Action:    r.Method + " " + r.URL.Path,
+		RequestId: utilities.GenerateObjectID(),
+		IP:        clientIP(r),
+		CreatedAt: time.Now().UTC(),
+	}
+	template := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if t, err := route.GetPathTemplate(); err == nil {
+			template = t
+			e.Action = r.Method + " " + t
+		}
+	}
+	vars := mux.Vars(r)
+	for i, segment := range strings.Split(strings.Trim(template, "/"), "/") {
+		if i == 0 {
+			e.ResourceType = segment
+		}
+		name := strings.Trim(segment, "{}")
+		if name != segment && strings.HasSuffix(name, "Id") && utilities.CheckObjectID(vars[name]) {
+			e.ResourceId = vars[name]
+		}
+	}
+	if utilities.CheckObjectID(vars["groupId"]) {
+		e.GroupId = vars["groupId"]
+	}
+	return e
+}
+
+// AuditMiddleWare records an AuditEvent for every POST, PUT, PATCH and DELETE request once it has been handled
+// Failed calls are recorded as well, with the status code they were answered with
+func (ar *auditRouter) AuditMiddleWare(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		e := newAuditEvent(r)
+		w.Header().Set("X-Request-Id", e.RequestId)
+		aw := &auditResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(aw, auth.WithAuditEvent(r, e))
+		e.Status = aw.status
+		// the call was already handled, so it is recorded even when the client has gone away
+		if _, err := ar.auService.AuditEventCreate(context.Background(), e); err != nil {
+			log.Println("audit event error:", err)
+		}
+	})
+}
+
+// auditChange records the before and after states of the resource a request changed on the request's AuditEvent
+func auditChange(r *http.Request, resourceType string, before interface{}, after interface{}) {
+	e := auth.LoadAuditEvent(r)
+	if e == nil {
+		return
+	}
+	if err := e.RecordChange(resourceType, before, after); err != nil {
+		log.Println("audit event error:", err)
+	}
+}
+
+// GetAuditEvents returns a page of the audit log, group admins only see the events of the records of their own group
+func (ar *auditRouter) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	tokenData, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	opts, err := models.NewListOptions(r.URL.Query(), models.AuditEventSortFields, models.AuditEventFilterFields)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = opts.LoadRanges(r.URL.Query(), models.AuditEventRangeFields); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	var filter models.AuditEvent
+	if !tokenData.RootAdmin {
+		filter.GroupId = tokenData.GroupId
+	}
+	events, total, err := ar.auService.AuditEventsFindPage(r.Context(), &filter, opts)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	var lastId string
+	if len(events) > 0 {
+		lastId = events[len(events)-1].Id
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&auditEventsDTO{AuditEvents: events, pageDTO: newPageDTO(r, opts, total, len(events), lastId)}); err != nil {
+		return
+	}
+}
@@ -0,0 +1,264 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type calendarRouter struct {
+	aService *services.TokenService
+	tService services.TaskService
+	uService services.UserService
+	gService services.GroupService
+	mService services.MembershipService
+	fService services.FeedTokenService
+}
+
+// NewCalendarRouter is a function that initializes a new calendarRouter struct
+func NewCalendarRouter(router *mux.Router, a *services.TokenService, t services.TaskService, u services.UserService, g services.GroupService, m services.MembershipService, f services.FeedTokenService) *mux.Router {
+	cRouter := calendarRouter{a, t, u, g, m, f}
+	router.HandleFunc("/auth/feeds", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/feeds", a.MemberTokenVerifyMiddleWare(cRouter.GetFeedTokens)).Methods("GET")
+	router.HandleFunc("/auth/feeds", a.MemberTokenVerifyMiddleWare(cRouter.CreateFeedToken)).Methods("POST")
+	router.HandleFunc("/auth/feeds/{feedId}", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/feeds/{feedId}", a.MemberTokenVerifyMiddleWare(cRouter.DeleteFeedToken)).Methods("DELETE")
+	router.HandleFunc("/users/{userId}/tasks.ics", cRouter.GetUserCalendar).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/tasks.ics", cRouter.GetGroupCalendar).Methods("GET")
+	router.HandleFunc("/tasks/import", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/tasks/import", a.MemberTokenVerifyMiddleWare(cRouter.ImportTasks)).Methods("POST")
+	return router
+}
+
+// GetFeedTokens returns the active feed tokens of the requesting user
+func (cr *calendarRouter) GetFeedTokens(w http.ResponseWriter, r *http.Request) {
+	tokenData, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	tokens, err := cr.fService.FeedTokensFind(r.Context(), &models.FeedToken{UserId: tokenData.UserId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(feedTokensDTO{FeedTokens: tokens}); err != nil {
+		return
+	}
+}
+
+// CreateFeedToken issues a feed token for the calendar feed of the requesting user, or of a group they are a member of
+func (cr *calendarRouter) CreateFeedToken(w http.ResponseWriter, r *http.Request) {
+	var dto feedTokenRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	tokenData, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	feed := dto.toFeedToken()
+	feed.UserId = tokenData.UserId
+	switch feed.FeedType {
+	case models.UserFeed:
+		if feed.FeedId == "" {
+			feed.FeedId = tokenData.UserId
+		}
+		if feed.FeedId != tokenData.UserId {
+			utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: "unauthorized"})
+			return
+		}
+	case models.GroupFeed:
+		if feed.FeedId == "" {
+			feed.FeedId = tokenData.GroupId
+		}
+		if _, err = auth.VerifyGroupMemberScope(r, feed.FeedId); err != nil {
+			utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+			return
+		}
+	}
+	created, err := cr.fService.FeedTokenCreate(r.Context(), feed)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	created.URL = created.FeedPath() + "?token=" + created.Token
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(created); err != nil {
+		return
+	}
+}
+
+// DeleteFeedToken revokes a feed token of the requesting user
+func (cr *calendarRouter) DeleteFeedToken(w http.ResponseWriter, r *http.Request) {
+	feedId := mux.Vars(r)["feedId"]
+	if !utilities.CheckObjectID(feedId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing feedId"})
+		return
+	}
+	tokenData, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	feed, err := cr.fService.FeedTokenDelete(r.Context(), &models.FeedToken{Id: feedId, UserId: tokenData.UserId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(feed); err != nil {
+		return
+	}
+}
+
+// authenticateFeed returns the user that issued the feed token of a calendar feed request, when the user still has
+// access to the feed
+func (cr *calendarRouter) authenticateFeed(w http.ResponseWriter, r *http.Request, feedType string, feedId string) (*models.User, bool) {
+	if !utilities.CheckObjectID(feedId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing " + feedType + "Id"})
+		return nil, false
+	}
+	feed, err := cr.fService.FeedTokenAuthenticate(r.Context(), r.URL.Query().Get("token"), feedType, feedId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return nil, false
+	}
+	user, err := cr.uService.UserFind(r.Context(), &models.User{Id: feed.UserId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: "invalid feed token"})
+		return nil, false
+	}
+	if feedType == models.GroupFeed && user.GroupId != feedId {
+		if _, err = cr.mService.MembershipFind(r.Context(), &models.Membership{UserId: user.Id, GroupId: feedId}); err != nil {
+			group, err := cr.gService.GroupFind(r.Context(), &models.Group{Id: user.GroupId})
+			if err != nil || !group.RootAdmin {
+				utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: "invalid feed token"})
+				return nil, false
+			}
+		}
+	}
+	return user, true
+}
+
+// writeCalendar responds with the iCalendar feed of a set of tasks
+func (cr *calendarRouter) writeCalendar(w http.ResponseWriter, r *http.Request, name string, tasks []*models.Task) {
+	contents := models.EncodeCalendar(name, tasks, time.Now().UTC())
+	w.Header().Set("Content-Type", "text/calendar; charset=UTF-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": "tasks.ics"}))
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "tasks.ics", time.Time{}, bytes.NewReader(contents))
+}
+
+// GetUserCalendar returns the iCalendar feed of the tasks a user owns or is assigned, authenticated by a feed token
+func (cr *calendarRouter) GetUserCalendar(w http.ResponseWriter, r *http.Request) {
+	userId := mux.Vars(r)["userId"]
+	user, ok := cr.authenticateFeed(w, r, models.UserFeed, userId)
+	if !ok {
+		return
+	}
+	tasks, err := cr.tService.TasksFindCalendar(r.Context(), &models.Task{UserId: userId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	cr.writeCalendar(w, r, strings.TrimSpace(user.FirstName+" "+user.LastName)+" Tasks", tasks)
+}
+
+// GetGroupCalendar returns the iCalendar feed of the tasks of a group, authenticated by a feed token
+func (cr *calendarRouter) GetGroupCalendar(w http.ResponseWriter, r *http.Request) {
+	groupId := mux.Vars(r)["groupId"]
+	if _, ok := cr.authenticateFeed(w, r, models.GroupFeed, groupId); !ok {
+		return
+	}
+	group, err := cr.gService.GroupFind(r.Context(), &models.Group{Id: groupId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	tasks, err := cr.tService.TasksFindCalendar(r.Context(), &models.Task{GroupId: groupId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	cr.writeCalendar(w, r, group.Name+" Tasks", tasks)
+}
+
+// ImportTasks creates tasks in the group of the session from the VTODOs and VEVENTs of an iCalendar file, uploaded as
+// the file of a multipart form or as a text/calendar body
+func (cr *calendarRouter) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	td, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, models.MaxCalendarImportSize+1048576)
+	var src io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+			return
+		}
+		defer file.Close()
+		src = file
+	}
+	buf := bytes.NewBuffer(nil)
+	if _, err = io.Copy(buf, io.LimitReader(src, models.MaxCalendarImportSize+1)); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if buf.Len() > models.MaxCalendarImportSize {
+		utilities.RespondWithError(w, http.StatusRequestEntityTooLarge, utilities.JWTError{Message: "calendar files can not be larger than " + strconv.Itoa(models.MaxCalendarImportSize) + " bytes"})
+		return
+	}
+	entries, err := models.ParseCalendar(buf.Bytes())
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	dto := taskImportDTO{Tasks: []*models.Task{}, Skipped: []*skippedEntryDTO{}}
+	for _, entry := range entries {
+		task := entry.Task()
+		task.Id = utilities.GenerateObjectID()
+		task.UserId = td.UserId
+		task.GroupId = td.GroupId
+		created, err := cr.tService.TaskCreate(r.Context(), task)
+		if err != nil {
+			dto.Skipped = append(dto.Skipped, &skippedEntryDTO{UID: entry.UID, Summary: entry.Summary, Error: err.Error()})
+			continue
+		}
+		dto.Tasks = append(dto.Tasks, created)
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(dto); err != nil {
+		return
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+)
+
+type commentRouter struct {
+	aService *services.TokenService
+	tService services.TaskService
+	cService services.CommentService
+}
+
+// NewCommentRouter is a function that initializes a new commentRouter struct
+func NewCommentRouter(router *mux.Router, a *services.TokenService, t services.TaskService, c services.CommentService) *mux.Router {
+	cRouter := commentRouter{a, t, c}
+	router.HandleFunc("/tasks/{taskId}/comments", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/tasks/{taskId}/comments", a.MemberTokenVerifyMiddleWare(cRouter.CommentsShow)).Methods("GET")
+	router.HandleFunc("/tasks/{taskId}/comments", a.MemberTokenVerifyMiddleWare(cRouter.CreateComment)).Methods("POST")
+	router.HandleFunc("/tasks/{taskId}/comments/{commentId}", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/tasks/{taskId}/comments/{commentId}", a.MemberTokenVerifyMiddleWare(cRouter.ModifyComment)).Methods("PATCH")
+	router.HandleFunc("/tasks/{taskId}/comments/{commentId}", a.MemberTokenVerifyMiddleWare(cRouter.DeleteComment)).Methods("DELETE")
+	return router
+}
+
+// loadTask returns the Task of a comment request when the requester can read it, along with the requester
+func (cr *commentRouter) loadTask(w http.ResponseWriter, r *http.Request) (*models.Task, *models.User, bool) {
+	vars := mux.Vars(r)
+	taskId := vars["taskId"]
+	if !utilities.CheckObjectID(taskId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing taskId"})
+		return nil, nil, false
+	}
+	td, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return nil, nil, false
+	}
+	requester := td.ToUser()
+	task, err := cr.tService.TaskFind(r.Context(), &models.Task{Id: taskId})
+	if err != nil || !(task.InScope(requester, models.PermTasksReadAny) || task.AssignedTo(requester)) {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "task not found"})
+		return nil, nil, false
+	}
+	return task, requester, true
+}
+
+// loadOwnComment returns the Comment of a comment request when the requester is its author or can manage comments
+func (cr *commentRouter) loadOwnComment(w http.ResponseWriter, r *http.Request) (*models.Comment, bool) {
+	task, requester, ok := cr.loadTask(w, r)
+	if !ok {
+		return nil, false
+	}
+	vars := mux.Vars(r)
+	commentId := vars["commentId"]
+	if !utilities.CheckObjectID(commentId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing commentId"})
+		return nil, false
+	}
+	comment, err := cr.cService.CommentFind(r.Context(), &models.Comment{Id: commentId, TaskId: task.Id})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "comment not found"})
+		return nil, false
+	}
+	if comment.UserId != requester.Id && !requester.HasPermission(models.PermCommentsManage) {
+		utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: "missing the " + models.PermCommentsManage + " permission"})
+		return nil, false
+	}
+	return comment, true
+}
+
+// CommentsShow returns the comments of a task, oldest first
+func (cr *commentRouter) CommentsShow(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := cr.loadTask(w, r)
+	if !ok {
+		return
+	}
+	comments, err := cr.cService.CommentsFind(r.Context(), &models.Comment{TaskId: task.Id})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&commentsDTO{Comments: comments}); err != nil {
+		return
+	}
+}
+
+// CreateComment adds a comment to a task from a REST Request post body
+func (cr *commentRouter) CreateComment(w http.ResponseWriter, r *http.Request) {
+	var comment models.Comment
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &comment); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	task, requester, ok := cr.loadTask(w, r)
+	if !ok {
+		return
+	}
+	comment.Id = ""
+	comment.TaskId = task.Id
+	comment.GroupId = task.GroupId
+	comment.UserId = requester.Id
+	c, err := cr.cService.CommentCreate(r.Context(), &comment)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(c); err != nil {
+		return
+	}
+}
+
+// ModifyComment edits the body of a comment, only its author or a user that can manage comments can edit it
+func (cr *commentRouter) ModifyComment(w http.ResponseWriter, r *http.Request) {
+	var comment models.Comment
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &comment); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	cur, ok := cr.loadOwnComment(w, r)
+	if !ok {
+		return
+	}
+	c, err := cr.cService.CommentUpdate(r.Context(), &models.Comment{Id: cur.Id, TaskId: cur.TaskId, Body: comment.Body})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(c); err != nil {
+		return
+	}
+}
+
+// DeleteComment deletes a comment, only its author or a user that can manage comments can delete it
+func (cr *commentRouter) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	cur, ok := cr.loadOwnComment(w, r)
+	if !ok {
+		return
+	}
+	c, err := cr.cService.CommentDelete(r.Context(), cur)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(c); err != nil {
+		return
+	}
+}
@@ -3,8 +3,29 @@ package server
 import (
 	"errors"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"net/http"
+	"time"
 )
 
+/*
+================ Page DTOs ==================
+*/
+
+// pageDTO is embedded in list DTOs to return the total number of matches and a link to the next page
+type pageDTO struct {
+	Total int64  `json:"total"`
+	Next  string `json:"next,omitempty"`
+}
+
+// newPageDTO initializes a pageDTO for a page of count results returned from a list request
+func newPageDTO(r *http.Request, o *models.ListOptions, total int64, count int, lastId string) pageDTO {
+	page := pageDTO{Total: total}
+	if q := o.Next(total, count, lastId); q != nil {
+		page.Next = r.URL.Path + "?" + q.Encode()
+	}
+	return page
+}
+
 /*
 ================ User DTOs ==================
 */
@@ -15,6 +36,22 @@ type updatePassword struct {
 	CurrentPassword string `json:"current_password"`
 }
 
+// forgotPassword is used when requesting a password reset email
+type forgotPassword struct {
+	Email string `json:"email"`
+}
+
+// resetPassword is used when setting a new password with a password reset token
+type resetPassword struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// verifyEmail is used when verifying an email address with an email verification token
+type verifyEmail struct {
+	Token string `json:"token"`
+}
+
 // userSignIn is used when updating a user password
 type userSignIn struct {
 	Email    string `json:"email"`
@@ -35,9 +72,100 @@ func (u *userSignIn) toUser() (*models.User, error) {
 	}, nil
 }
 
+// refreshSession is used when exchanging a refresh token for a new session
+type refreshSession struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// switchGroup is used when switching a session to another group the user belongs to
+type switchGroup struct {
+	GroupId string `json:"group_id"`
+}
+
+// membershipsDTO is used when returning a slice of Membership
+type membershipsDTO struct {
+	Memberships []*models.Membership `json:"memberships"`
+}
+
+// twoFactorChallenge is returned from a sign in that must be completed with a second factor
+type twoFactorChallenge struct {
+	ChallengeToken string `json:"challenge_token"`
+	TwoFactor      bool   `json:"two_factor"`
+}
+
+// twoFactorCode is used when completing a 2FA challenge or enabling and disabling 2FA
+type twoFactorCode struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// twoFactorEnrollment is used when returning a new TOTP secret and its recovery codes
+type twoFactorEnrollment struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// apiKeyRequest is used when creating a new api key
+type apiKeyRequest struct {
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// toAPIKey converts apiKeyRequest DTO to an api key
+func (k *apiKeyRequest) toAPIKey() *models.APIKey {
+	return &models.APIKey{
+		Name:      k.Name,
+		Scopes:    k.Scopes,
+		ExpiresAt: k.ExpiresAt,
+	}
+}
+
+// apiKeysDTO is used when returning a slice of APIKey
+type apiKeysDTO struct {
+	APIKeys []*models.APIKey `json:"api_keys"`
+}
+
+// feedTokenRequest is used when creating a new feed token
+type feedTokenRequest struct {
+	Name     string `json:"name"`
+	FeedType string `json:"feed_type"`
+	FeedId   string `json:"feed_id"`
+}
+
+// toFeedToken converts feedTokenRequest DTO to a feed token
+func (f *feedTokenRequest) toFeedToken() *models.FeedToken {
+	return &models.FeedToken{
+		Name:     f.Name,
+		FeedType: f.FeedType,
+		FeedId:   f.FeedId,
+	}
+}
+
+// feedTokensDTO is used when returning a slice of FeedToken
+type feedTokensDTO struct {
+	FeedTokens []*models.FeedToken `json:"feed_tokens"`
+}
+
+// skippedEntryDTO is used when returning a calendar entry that could not be imported
+type skippedEntryDTO struct {
+	UID     string `json:"uid,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Error   string `json:"error"`
+}
+
+// taskImportDTO is used when returning the result of a calendar import
+type taskImportDTO struct {
+	Tasks   []*models.Task     `json:"tasks"`
+	Skipped []*skippedEntryDTO `json:"skipped"`
+}
+
 // usersDTO is used when returning a slice of User
 type usersDTO struct {
 	Users []*models.User `json:"users"`
+	pageDTO
 }
 
 // clean ensures the users in the usersDTO have no passwords set
@@ -51,6 +179,7 @@ func (u *usersDTO) clean() {
 type userTasksDTO struct {
 	User  *models.User   `json:"user"`
 	Tasks []*models.Task `json:"tasks"`
+	pageDTO
 }
 
 // clean ensures the users in the userTasksDTO have password set
@@ -65,12 +194,14 @@ func (u *userTasksDTO) clean() {
 // groupsDTO is used when returning a slice of Group
 type groupsDTO struct {
 	Groups []*models.Group `json:"groups"`
+	pageDTO
 }
 
 // groupUsersDTO is used when returning a group with its associated users
 type groupUsersDTO struct {
 	Group *models.Group  `json:"group"`
 	Users []*models.User `json:"users"`
+	pageDTO
 }
 
 // clean ensures the users in the groupUsersDTO have no passwords set
@@ -84,6 +215,12 @@ func (u *groupUsersDTO) clean() {
 type groupTasksDTO struct {
 	Group *models.Group  `json:"group"`
 	Tasks []*models.Task `json:"tasks"`
+	pageDTO
+}
+
+// groupRequire2FA is used when setting whether the members of a group must use 2FA
+type groupRequire2FA struct {
+	Required bool `json:"required"`
 }
 
 /*
@@ -93,4 +230,145 @@ type groupTasksDTO struct {
 // tasksDTO is used when returning a slice of Task
 type tasksDTO struct {
 	Tasks []*models.Task `json:"tasks"`
+	pageDTO
+}
+
+// taskHistoryDTO is used when returning the status transitions of a Task
+type taskHistoryDTO struct {
+	History []*models.TaskHistory `json:"history"`
+}
+
+// taskDetailDTO is used when returning a Task along with its expanded subtasks and dependencies
+type taskDetailDTO struct {
+	*models.Task
+	Subtasks     []*models.TaskTree `json:"subtasks,omitempty"`
+	Dependencies *models.TaskGraph  `json:"dependencies,omitempty"`
+}
+
+// attachmentsDTO is used when returning the attachment Files of a Task
+type attachmentsDTO struct {
+	Attachments []*models.File `json:"attachments"`
+}
+
+// commentsDTO is used when returning the comments of a Task
+type commentsDTO struct {
+	Comments []*models.Comment `json:"comments"`
+}
+
+// activityDTO is used when returning a page of the activity feed of a group, Next links to the older activities
+type activityDTO struct {
+	Activity []*models.Activity `json:"activity"`
+	Next     string             `json:"next,omitempty"`
+}
+
+// searchDTO is used when returning the ranked hits of a search, best match first
+type searchDTO struct {
+	Query string              `json:"query"`
+	Hits  []*models.SearchHit `json:"hits"`
+}
+
+// clean ensures the users in the searchDTO hits have no passwords set
+func (s *searchDTO) clean() {
+	for _, hit := range s.Hits {
+		if hit.User != nil {
+			hit.User.Password = ""
+		}
+	}
+}
+
+// auditEventsDTO is used when returning a page of the audit log
+type auditEventsDTO struct {
+	AuditEvents []*models.AuditEvent `json:"audit_events"`
+	pageDTO
+}
+
+/*
+================ Role DTOs ==================
+*/
+
+// rolesDTO is used when returning the roles of a group along with every permission that can be granted
+type rolesDTO struct {
+	Roles       []*models.Role `json:"roles"`
+	Permissions []string       `json:"permissions"`
+}
+
+// assignRole is used when assigning a role to a user
+type assignRole struct {
+	Role string `json:"role"`
+}
+
+/*
+================ Invitation DTOs ==================
+*/
+
+// invitationsDTO is used when returning the pending invitations of a group
+type invitationsDTO struct {
+	Invitations []*models.Invitation `json:"invitations"`
+}
+
+// acceptInvitation is used when accepting an invitation, the user fields are only used when creating a new user
+type acceptInvitation struct {
+	Token     string `json:"token"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	FirstName string `json:"firstname"`
+	LastName  string `json:"lastname"`
+}
+
+// toUser converts acceptInvitation DTO to a user
+func (a *acceptInvitation) toUser() *models.User {
+	return &models.User{
+		Username:  a.Username,
+		Password:  a.Password,
+		FirstName: a.FirstName,
+		LastName:  a.LastName,
+	}
+}
+
+/*
+================ Admin DTOs ==================
+*/
+
+// purgeDTO is used when returning the number of soft deleted records that were purged
+type purgeDTO struct {
+	Before time.Time `json:"before"`
+	Groups int64     `json:"groups"`
+	Users  int64     `json:"users"`
+	Tasks  int64     `json:"tasks"`
+	Files  int64     `json:"files"`
+}
+
+// deleteReportDTO is embedded in delete DTOs to return the number of records a cascade delete removed from each
+// collection and whether they were removed in a transaction or by a saga
+type deleteReportDTO struct {
+	Removed  map[string]int64 `json:"removed"`
+	Strategy string           `json:"strategy"`
+}
+
+// newDeleteReportDTO initializes a deleteReportDTO from the DeleteReport of a cascade delete
+func newDeleteReportDTO(report *models.DeleteReport) deleteReportDTO {
+	return deleteReportDTO{Removed: report.Removed, Strategy: report.Strategy}
+}
+
+// groupDeleteDTO is used when returning a deleted Group along with what was deleted with it
+type groupDeleteDTO struct {
+	*models.Group
+	deleteReportDTO
+}
+
+// userDeleteDTO is used when returning a deleted User along with what was deleted with it
+type userDeleteDTO struct {
+	*models.User
+	deleteReportDTO
+}
+
+// webhooksDTO is used when returning a slice of Webhook
+type webhooksDTO struct {
+	Webhooks []*models.Webhook `json:"webhooks"`
+}
+
+// webhookDeliveriesDTO is used when returning a page of the deliveries of a Webhook
+type webhookDeliveriesDTO struct {
+	Deliveries []*models.WebhookDelivery `json:"deliveries"`
+	pageDTO
 }
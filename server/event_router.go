@@ -0,0 +1,198 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// eventStreamHeartbeat is how often an idle event stream is kept alive, so that proxies do not close it
+const eventStreamHeartbeat = 30 * time.Second
+
+// eventWriteTimeout is how long a WebSocket event stream may take to write a message
+const eventWriteTimeout = 10 * time.Second
+
+type eventRouter struct {
+	aService *services.TokenService
+	broker   services.EventBroker
+	upgrader websocket.Upgrader
+}
+
+// NewEventRouter is a function that initializes a new eventRouter struct
+func NewEventRouter(router *mux.Router, a *services.TokenService, broker services.EventBroker) *mux.Router {
+	eRouter := eventRouter{a, broker, websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		// the handshake is authenticated by the Auth-Token or API-Key header before it is upgraded, browsers are only
+		// allowed to open a stream from the same origins as the CORS headers allow, clients that send no Origin are not
+		// browsers
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || utilities.CheckOrigin(origin)
+		},
+	}}
+	router.HandleFunc("/events/stream", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/events/stream", a.MemberTokenVerifyMiddleWare(eRouter.StreamEvents)).Methods("GET")
+	router.HandleFunc("/events/ws", a.MemberTokenVerifyMiddleWare(eRouter.EventsWebSocket)).Methods("GET")
+	return router
+}
+
+// eventGroupScope returns the group whose events a stream request receives, which is the group of the session.
+// Root admins receive the events of every group unless they select one with group_id
+func eventGroupScope(r *http.Request) (string, error) {
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		return "", err
+	}
+	if groupId := r.URL.Query().Get("group_id"); groupId != "" {
+		return auth.VerifyGroupRequestScope(r, groupId)
+	}
+	return decodedToken.GetGroupsScope().Id, nil
+}
+
+// eventTypes returns the comma separated event types a stream request selects with types, none selects every type
+func eventTypes(r *http.Request) ([]string, error) {
+	query := r.URL.Query().Get("types")
+	if query == "" {
+		return nil, nil
+	}
+	types := strings.Split(query, ",")
+	for _, t := range types {
+		if !models.CheckEventType(t) {
+			return nil, errors.New("invalid event type: " + t)
+		}
+	}
+	return types, nil
+}
+
+// subscribe subscribes a stream request to the events of its group scope and responds with an error when the request
+// is invalid, the returned function unsubscribes it
+func (er *eventRouter) subscribe(w http.ResponseWriter, r *http.Request) (<-chan *models.Event, func(), bool) {
+	groupId, err := eventGroupScope(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return nil, nil, false
+	}
+	types, err := eventTypes(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return nil, nil, false
+	}
+	events, unsubscribe := er.broker.Subscribe(func(e *models.Event) bool {
+		if groupId != "" && e.GroupId != groupId {
+			return false
+		}
+		if len(types) == 0 {
+			return true
+		}
+		for _, t := range types {
+			if e.Type == t {
+				return true
+			}
+		}
+		return false
+	})
+	return events, unsubscribe, true
+}
+
+// StreamEvents is the handler function that streams the change events of the requester's group as Server-Sent Events
+// The stream ends when the client falls too far behind, clients reconnect and refetch what they track
+func (er *eventRouter) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: "streaming is not supported"})
+		return
+	}
+	events, unsubscribe, ok := er.subscribe(w, r)
+	if !ok {
+		return
+	}
+	defer unsubscribe()
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.Id, e.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// EventsWebSocket is the handler function that streams the change events of the requester's group over a WebSocket,
+// each event is sent as a JSON text message and messages from the client are ignored
+func (er *eventRouter) EventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	events, unsubscribe, ok := er.subscribe(w, r)
+	if !ok {
+		return
+	}
+	defer unsubscribe()
+	conn, err := er.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader already responded with the error
+	}
+	defer conn.Close()
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * eventStreamHeartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * eventStreamHeartbeat))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWriteTimeout)); err != nil {
+				return
+			}
+		case e, ok := <-events:
+			if !ok {
+				msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "event stream fell behind")
+				_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(eventWriteTimeout))
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err = conn.WriteJSON(e); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/JECSand/go-rest-api-boilerplate/auth"
@@ -10,29 +11,49 @@ import (
 	"github.com/gorilla/mux"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 type groupRouter struct {
-	aService *services.TokenService
-	gService services.GroupService
-	uService services.UserService
-	tService services.TaskService
-	fService services.FileService
+	aService  *services.TokenService
+	gService  services.GroupService
+	uService  services.UserService
+	tService  services.TaskService
+	fService  services.FileService
+	mService  services.MembershipService
+	acService services.ActivityService
+	cService  services.CascadeService
 }
 
 // NewGroupRouter is a function that initializes a new groupRouter struct
-func NewGroupRouter(router *mux.Router, a *services.TokenService, g services.GroupService, u services.UserService, t services.TaskService, f services.FileService) *mux.Router {
-	gRouter := groupRouter{a, g, u, t, f}
+func NewGroupRouter(router *mux.Router, a *services.TokenService, g services.GroupService, u services.UserService, t services.TaskService, f services.FileService, m services.MembershipService, ac services.ActivityService, c services.CascadeService) *mux.Router {
+	gRouter := groupRouter{a, g, u, t, f, m, ac, c}
 	router.HandleFunc("/groups", utilities.HandleOptionsRequest).Methods("OPTIONS")
 	router.HandleFunc("/groups", a.AdminTokenVerifyMiddleWare(gRouter.GetGroups)).Methods("GET")
 	router.HandleFunc("/groups", a.RootAdminTokenVerifyMiddleWare(gRouter.CreateGroup)).Methods("POST")
 	router.HandleFunc("/groups/{groupId}", utilities.HandleOptionsRequest).Methods("OPTIONS")
 	router.HandleFunc("/groups/{groupId}", a.AdminTokenVerifyMiddleWare(gRouter.GetGroup)).Methods("GET")
 	router.HandleFunc("/groups/{groupId}", a.RootAdminTokenVerifyMiddleWare(gRouter.DeleteGroup)).Methods("DELETE")
-	router.HandleFunc("/groups/{groupId}", a.AdminTokenVerifyMiddleWare(gRouter.ModifyGroup)).Methods("PATCH")
+	router.HandleFunc("/groups/{groupId}", a.RequirePermission(models.PermGroupsUpdate, gRouter.ModifyGroup)).Methods("PATCH")
+	router.HandleFunc("/groups/{groupId}/restore", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/groups/{groupId}/restore", a.RootAdminTokenVerifyMiddleWare(gRouter.RestoreGroup)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/2fa", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/groups/{groupId}/2fa", a.RequirePermission(models.PermGroupsUpdate, gRouter.RequireTwoFactor)).Methods("PUT")
+	router.HandleFunc("/groups/{groupId}/workflow", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/groups/{groupId}/workflow", a.MemberTokenVerifyMiddleWare(gRouter.GetTaskWorkflow)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/workflow", a.RequirePermission(models.PermGroupsUpdate, gRouter.SetTaskWorkflow)).Methods("PUT")
+	router.HandleFunc("/groups/{groupId}/activity", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/groups/{groupId}/activity", a.MemberTokenVerifyMiddleWare(gRouter.GetGroupActivity)).Methods("GET")
 	router.HandleFunc("/groups/{groupId}/users", utilities.HandleOptionsRequest).Methods("OPTIONS")
 	router.HandleFunc("/groups/{groupId}/users", a.MemberTokenVerifyMiddleWare(gRouter.GetGroupUsers)).Methods("GET")
 	router.HandleFunc("/groups/{groupId}/tasks", a.MemberTokenVerifyMiddleWare(gRouter.GetGroupTasks)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/members", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/groups/{groupId}/members", a.MemberTokenVerifyMiddleWare(gRouter.GetGroupMembers)).Methods("GET")
+	router.HandleFunc("/groups/{groupId}/members", a.RootAdminTokenVerifyMiddleWare(gRouter.AddGroupMember)).Methods("POST")
+	router.HandleFunc("/groups/{groupId}/members/{userId}", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/groups/{groupId}/members/{userId}", a.RequirePermission(models.PermUsersDelete, gRouter.RemoveGroupMember)).Methods("DELETE")
 	return router
 }
 
@@ -45,16 +66,26 @@ func (gr *groupRouter) GetGroupTasks(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId"})
 		return
 	}
-	groupId, err = auth.VerifyGroupRequestScope(r, groupId)
+	groupId, err = auth.VerifyGroupMemberScope(r, groupId)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	dto, err := gr.getGroupTasks(groupId)
+	opts, err := models.NewListOptions(r.URL.Query(), models.TaskSortFields, models.TaskFilterFields)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	dto, err := gr.getGroupTasks(r.Context(), groupId, opts)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
 	}
+	var lastId string
+	if len(dto.Tasks) > 0 {
+		lastId = dto.Tasks[len(dto.Tasks)-1].Id
+	}
+	dto.pageDTO = newPageDTO(r, opts, dto.Total, len(dto.Tasks), lastId)
 	w = utilities.SetResponseHeaders(w, "", "")
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(dto); err != nil {
@@ -63,6 +94,102 @@ func (gr *groupRouter) GetGroupTasks(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// GetGroupMembers returns the users of other groups that are members of a group
+func (gr *groupRouter) GetGroupMembers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var err error
+	groupId := vars["groupId"]
+	if !utilities.CheckObjectID(groupId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId"})
+		return
+	}
+	groupId, err = auth.VerifyGroupMemberScope(r, groupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	memberships, err := gr.mService.MembershipsFind(r.Context(), &models.Membership{GroupId: groupId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&membershipsDTO{Memberships: memberships}); err != nil {
+		return
+	}
+}
+
+// AddGroupMember adds a user of another group to a group, only root admins can add users directly
+// Group admins invite users of other groups instead, so the users have to accept joining the group
+func (gr *groupRouter) AddGroupMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	groupId := vars["groupId"]
+	if !utilities.CheckObjectID(groupId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId"})
+		return
+	}
+	var membership models.Membership
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &membership); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	groupId, err = auth.VerifyGroupRequestScope(r, groupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	membership.Id = ""
+	membership.GroupId = groupId
+	m, err := gr.aService.AddMember(r.Context(), &membership)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "memberships", nil, m)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(m); err != nil {
+		return
+	}
+}
+
+// RemoveGroupMember removes a user of another group from a group
+func (gr *groupRouter) RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	groupId := vars["groupId"]
+	userId := vars["userId"]
+	if !utilities.CheckObjectID(groupId) || !utilities.CheckObjectID(userId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId or userId"})
+		return
+	}
+	groupId, err := auth.VerifyGroupRequestScope(r, groupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	m, err := gr.aService.RemoveMember(r.Context(), &models.Membership{UserId: userId, GroupId: groupId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "memberships", m, nil)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(m); err != nil {
+		return
+	}
+}
+
 // GetGroupUsers returns a groupUsersDTO
 func (gr *groupRouter) GetGroupUsers(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -72,16 +199,26 @@ func (gr *groupRouter) GetGroupUsers(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId"})
 		return
 	}
-	groupId, err = auth.VerifyGroupRequestScope(r, groupId)
+	groupId, err = auth.VerifyGroupMemberScope(r, groupId)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	dto, err := gr.getGroupUsers(groupId)
+	opts, err := models.NewListOptions(r.URL.Query(), models.UserSortFields, models.UserFilterFields)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	dto, err := gr.getGroupUsers(r.Context(), groupId, opts)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
 	}
+	var lastId string
+	if len(dto.Users) > 0 {
+		lastId = dto.Users[len(dto.Users)-1].Id
+	}
+	dto.pageDTO = newPageDTO(r, opts, dto.Total, len(dto.Users), lastId)
 	dto.clean()
 	w = utilities.SetResponseHeaders(w, "", "")
 	w.WriteHeader(http.StatusOK)
@@ -99,13 +236,22 @@ func (gr *groupRouter) GetGroups(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	groups, err := gr.gService.GroupsFind(tokenData.GetGroupsScope())
+	opts, err := models.NewListOptions(r.URL.Query(), models.GroupSortFields, models.GroupFilterFields)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	groups, total, err := gr.gService.GroupsFindPage(r.Context(), tokenData.GetGroupsScope(), opts)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
 		return
 	}
+	var lastId string
+	if len(groups) > 0 {
+		lastId = groups[len(groups)-1].Id
+	}
 	w.WriteHeader(http.StatusOK)
-	if err = json.NewEncoder(w).Encode(&groupsDTO{Groups: groups}); err != nil {
+	if err = json.NewEncoder(w).Encode(&groupsDTO{Groups: groups, pageDTO: newPageDTO(r, opts, total, len(groups), lastId)}); err != nil {
 		return
 	}
 }
@@ -128,11 +274,12 @@ func (gr *groupRouter) CreateGroup(w http.ResponseWriter, r *http.Request) {
 	}
 	group.Id = utilities.GenerateObjectID()
 	group.RootAdmin = false
-	g, err := gr.gService.GroupCreate(&group)
+	g, err := gr.gService.GroupCreate(r.Context(), &group)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
 		return
 	} else {
+		auditChange(r, "groups", nil, g)
 		w = utilities.SetResponseHeaders(w, "", "")
 		w.WriteHeader(http.StatusCreated)
 		if err = json.NewEncoder(w).Encode(g); err != nil {
@@ -169,11 +316,13 @@ func (gr *groupRouter) ModifyGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	group.Id = groupId
-	g, err := gr.gService.GroupUpdate(&group)
+	cur, _ := gr.gService.GroupFind(r.Context(), &models.Group{Id: groupId})
+	g, err := gr.gService.GroupUpdate(r.Context(), &group)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
 		return
 	} else {
+		auditChange(r, "groups", cur, g)
 		w = utilities.SetResponseHeaders(w, "", "")
 		w.WriteHeader(http.StatusAccepted)
 		if err = json.NewEncoder(w).Encode(g); err != nil {
@@ -182,6 +331,173 @@ func (gr *groupRouter) ModifyGroup(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// RequireTwoFactor is the handler function that sets whether every member of a group must use 2FA
+func (gr *groupRouter) RequireTwoFactor(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	groupId := vars["groupId"]
+	if !utilities.CheckObjectID(groupId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId"})
+		return
+	}
+	var dto groupRequire2FA
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	groupId, err = auth.VerifyGroupRequestScope(r, groupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	cur, _ := gr.gService.GroupFind(r.Context(), &models.Group{Id: groupId})
+	g, err := gr.gService.GroupRequire2FA(r.Context(), &models.Group{Id: groupId, Require2FA: dto.Required})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "groups", cur, g)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(g); err != nil {
+		return
+	}
+}
+
+// GetTaskWorkflow returns the task statuses of a group and the transitions allowed between them to its members
+func (gr *groupRouter) GetTaskWorkflow(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var err error
+	groupId := vars["groupId"]
+	if !utilities.CheckObjectID(groupId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId"})
+		return
+	}
+	groupId, err = auth.VerifyGroupMemberScope(r, groupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	group, err := gr.gService.GroupFind(r.Context(), &models.Group{Id: groupId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(group.Workflow()); err != nil {
+		return
+	}
+}
+
+// GetGroupActivity is the handler function that returns the activity feed of a group to its members, newest first
+// The before query param pages through older activities
+func (gr *groupRouter) GetGroupActivity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var err error
+	groupId := vars["groupId"]
+	if !utilities.CheckObjectID(groupId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId"})
+		return
+	}
+	groupId, err = auth.VerifyGroupMemberScope(r, groupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	q := r.URL.Query()
+	limit := models.DefaultListLimit
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || limit < 1 || limit > models.MaxListLimit {
+			utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "limit must be a number between 1 and " + strconv.FormatInt(models.MaxListLimit, 10)})
+			return
+		}
+	}
+	var before time.Time
+	if v := q.Get("before"); v != "" {
+		before, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "invalid before filter"})
+			return
+		}
+	}
+	activity, err := gr.acService.ActivityFind(r.Context(), groupId, before, limit)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	dto := activityDTO{Activity: activity}
+	if int64(len(activity)) == limit {
+		next := url.Values{}
+		next.Set("limit", strconv.FormatInt(limit, 10))
+		next.Set("before", activity[len(activity)-1].CreatedAt.UTC().Format(time.RFC3339Nano))
+		dto.Next = r.URL.Path + "?" + next.Encode()
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&dto); err != nil {
+		return
+	}
+}
+
+// SetTaskWorkflow is the handler function that configures the task workflow of a group
+// A workflow without statuses restores the default statuses and transitions
+func (gr *groupRouter) SetTaskWorkflow(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	groupId := vars["groupId"]
+	if !utilities.CheckObjectID(groupId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId"})
+		return
+	}
+	var workflow models.TaskWorkflow
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &workflow); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	groupId, err = auth.VerifyGroupRequestScope(r, groupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	group := &models.Group{Id: groupId}
+	if len(workflow.Statuses) > 0 {
+		group.TaskWorkflow = &workflow
+	} else if workflow.EnforceBlockers {
+		group.TaskWorkflow = models.DefaultTaskWorkflow()
+		group.TaskWorkflow.EnforceBlockers = true
+	}
+	cur, _ := gr.gService.GroupFind(r.Context(), &models.Group{Id: groupId})
+	g, err := gr.gService.GroupSetTaskWorkflow(r.Context(), group)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "groups", cur, g)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(g.Workflow()); err != nil {
+		return
+	}
+}
+
 // GetGroup shows a specific group
 func (gr *groupRouter) GetGroup(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -196,7 +512,7 @@ func (gr *groupRouter) GetGroup(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	group, err := gr.gService.GroupFind(&models.Group{Id: groupId})
+	group, err := gr.gService.GroupFind(r.Context(), &models.Group{Id: groupId})
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
@@ -209,7 +525,7 @@ func (gr *groupRouter) GetGroup(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// DeleteGroup deletes a group
+// DeleteGroup deletes a group along with its users, tasks, user images and task attachments
 func (gr *groupRouter) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	groupId := vars["groupId"]
@@ -217,21 +533,46 @@ func (gr *groupRouter) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId"})
 		return
 	}
-	groupUsers, err := gr.getGroupUsers(groupId)
+	cur, err := gr.gService.GroupFind(r.Context(), &models.Group{Id: groupId})
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	err = gr.deleteGroupAssets(groupUsers.Group, groupUsers.Users)
+	report, err := gr.cService.GroupDeleteCascade(r.Context(), cur)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	group, err := gr.gService.GroupDelete(&models.Group{Id: groupId})
+	auditChange(r, "groups", cur, report.Group)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&groupDeleteDTO{Group: report.Group, deleteReportDTO: newDeleteReportDTO(report)}); err != nil {
+		return
+	}
+	return
+}
+
+// RestoreGroup restores a soft deleted group along with the users, tasks and images deleted with it
+func (gr *groupRouter) RestoreGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	groupId := vars["groupId"]
+	if !utilities.CheckObjectID(groupId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing groupId"})
+		return
+	}
+	group, err := gr.gService.GroupRestore(r.Context(), &models.Group{Id: groupId})
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
 	}
+	err = gr.restoreGroupAssets(r.Context(), group)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	deleted := *group
+	group.DeletedAt = time.Time{}
+	auditChange(r, "groups", &deleted, group)
 	w = utilities.SetResponseHeaders(w, "", "")
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(group); err != nil {
@@ -240,59 +581,51 @@ func (gr *groupRouter) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// deleteGroupAssets asynchronously gets a group and its users from the database
-func (gr *groupRouter) deleteGroupAssets(group *models.Group, users []*models.User) error {
+// restoreGroupAssets restores the users, tasks, user images and task attachments of a group that were deleted along with it
+func (gr *groupRouter) restoreGroupAssets(ctx context.Context, group *models.Group) error {
 	if !group.CheckID("id") {
-		return errors.New("filter id cannot be empty for mass delete")
+		return errors.New("filter id cannot be empty for mass restore")
 	}
-	fErrCh := make(chan error) // Images Files Bulk Delete
-	uErrCh := make(chan error) // Delete Group Users
-	tErrCh := make(chan error) // Delete Group Tasks
-	go func() {
-		err := gr.fService.FileDeleteMany(models.UsersToFiles(users))
-		fErrCh <- err
-	}()
-	go func() {
-		_, err := gr.uService.UserDeleteMany(&models.User{GroupId: group.Id})
-		uErrCh <- err
-	}()
-	go func() {
-		_, err := gr.tService.TaskDeleteMany(&models.Task{GroupId: group.Id})
-		tErrCh <- err
-	}()
-	for i := 0; i < 3; i++ {
-		select {
-		case fErr := <-fErrCh:
-			if fErr != nil {
-				return fErr
-			}
-		case uErr := <-uErrCh:
-			if uErr != nil {
-				return uErr
-			}
-		case tErr := <-tErrCh:
-			if tErr != nil {
-				return tErr
-			}
-		}
+	err := gr.uService.UserRestoreMany(ctx, &models.User{GroupId: group.Id}, group.DeletedAt)
+	if err != nil {
+		return err
 	}
-	return nil
+	err = gr.tService.TaskRestoreMany(ctx, &models.Task{GroupId: group.Id}, group.DeletedAt)
+	if err != nil {
+		return err
+	}
+	users, err := gr.uService.UsersFind(ctx, &models.User{GroupId: group.Id})
+	if err != nil {
+		return err
+	}
+	tasks, err := gr.tService.TasksFind(ctx, &models.Task{GroupId: group.Id})
+	if err != nil {
+		return err
+	}
+	return gr.fService.FileRestoreMany(ctx, append(models.UsersToFiles(users), models.TasksToFiles(tasks)...), group.DeletedAt)
 }
 
-// getGroupUsers asynchronously gets a group and its users from the database
-func (gr *groupRouter) getGroupUsers(groupId string) (*groupUsersDTO, error) {
+// getGroupUsers asynchronously gets a group and a page of its users from the database, or every user when opts is nil
+func (gr *groupRouter) getGroupUsers(ctx context.Context, groupId string, opts *models.ListOptions) (*groupUsersDTO, error) {
 	var dto groupUsersDTO
 	gOutCh := make(chan *models.Group)
 	gErrCh := make(chan error)
 	uOutCh := make(chan []*models.User)
 	uErrCh := make(chan error)
+	var total int64
 	go func() {
-		reG, err := gr.gService.GroupFind(&models.Group{Id: groupId})
+		reG, err := gr.gService.GroupFind(ctx, &models.Group{Id: groupId})
 		gOutCh <- reG
 		gErrCh <- err
 	}()
 	go func() {
-		reU, err := gr.uService.UsersFind(&models.User{GroupId: groupId})
+		var reU []*models.User
+		var err error
+		if opts == nil {
+			reU, err = gr.uService.UsersFind(ctx, &models.User{GroupId: groupId})
+		} else {
+			reU, total, err = gr.uService.UsersFindPage(ctx, &models.User{GroupId: groupId}, opts)
+		}
 		uOutCh <- reU
 		uErrCh <- err
 	}()
@@ -312,23 +645,26 @@ func (gr *groupRouter) getGroupUsers(groupId string) (*groupUsersDTO, error) {
 			}
 		}
 	}
+	dto.Total = total
 	return &dto, nil
 }
 
-// getGroupTasks asynchronously gets a group and its users from the database
-func (gr *groupRouter) getGroupTasks(groupId string) (*groupTasksDTO, error) {
+// getGroupTasks asynchronously gets a group and a page of its tasks from the database
+func (gr *groupRouter) getGroupTasks(ctx context.Context, groupId string, opts *models.ListOptions) (*groupTasksDTO, error) {
 	var dto groupTasksDTO
 	gOutCh := make(chan *models.Group)
 	gErrCh := make(chan error)
 	uOutCh := make(chan []*models.Task)
 	uErrCh := make(chan error)
+	var total int64
 	go func() {
-		reG, err := gr.gService.GroupFind(&models.Group{Id: groupId})
+		reG, err := gr.gService.GroupFind(ctx, &models.Group{Id: groupId})
 		gOutCh <- reG
 		gErrCh <- err
 	}()
 	go func() {
-		reU, err := gr.tService.TasksFind(&models.Task{GroupId: groupId})
+		reU, t, err := gr.tService.TasksFindPage(ctx, &models.Task{GroupId: groupId}, opts)
+		total = t
 		uOutCh <- reU
 		uErrCh <- err
 	}()
@@ -348,5 +684,6 @@ func (gr *groupRouter) getGroupTasks(groupId string) (*groupTasksDTO, error) {
 			}
 		}
 	}
+	dto.Total = total
 	return &dto, nil
 }
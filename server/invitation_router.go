@@ -0,0 +1,212 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+)
+
+type invitationRouter struct {
+	aService *services.TokenService
+	iService services.InvitationService
+}
+
+// NewInvitationRouter is a function that initializes a new invitationRouter struct
+func NewInvitationRouter(router *mux.Router, a *services.TokenService, i services.InvitationService) *mux.Router {
+	iRouter := invitationRouter{a, i}
+	router.HandleFunc("/invitations", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/invitations", a.RequirePermission(models.PermUsersInvite, iRouter.GetInvitations)).Methods("GET")
+	router.HandleFunc("/invitations", a.RequirePermission(models.PermUsersInvite, iRouter.CreateInvitation)).Methods("POST")
+	router.HandleFunc("/invitations/{inviteId}", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/invitations/{inviteId}", a.RequirePermission(models.PermUsersInvite, iRouter.RevokeInvitation)).Methods("DELETE")
+	router.HandleFunc("/invitations/{inviteId}/resend", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/invitations/{inviteId}/resend", a.RequirePermission(models.PermUsersInvite, iRouter.ResendInvitation)).Methods("POST")
+	router.HandleFunc("/auth/invitations/accept", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/invitations/accept", iRouter.AcceptInvitation).Methods("POST")
+	return router
+}
+
+// GetInvitations is the handler function that returns the pending invitations of a group
+func (ir *invitationRouter) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	groupId, err := roleGroupScope(r, r.URL.Query().Get("group_id"))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	invitations, err := ir.iService.InvitationsFind(r.Context(), &models.Invitation{GroupId: groupId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&invitationsDTO{Invitations: invitations}); err != nil {
+		return
+	}
+}
+
+// CreateInvitation is the handler function that invites an email address to join a group with a role
+func (ir *invitationRouter) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	var invitation models.Invitation
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &invitation); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	invitation.GroupId, err = roleGroupScope(r, invitation.GroupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if invitation.Role != "" && invitation.Role != models.MemberRole && !decodedToken.HasPermission(models.PermRolesManage) {
+		utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: "missing the " + models.PermRolesManage + " permission"})
+		return
+	}
+	if err = ir.aService.CanGrantRole(r.Context(), decodedToken.ToUser(), invitation.Role); err != nil {
+		utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	created, err := ir.aService.CreateInvitation(r.Context(), decodedToken.ToUser(), &invitation)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "invitations", nil, created)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(created); err != nil {
+		return
+	}
+}
+
+// ResendInvitation is the handler function that emails a pending invitation again with a new token and expiration
+func (ir *invitationRouter) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	inviteId := vars["inviteId"]
+	if !utilities.CheckObjectID(inviteId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing inviteId"})
+		return
+	}
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	scope := &models.Invitation{Id: inviteId, GroupId: decodedToken.GetGroupsScope().Id}
+	cur, err := ir.iService.InvitationFind(r.Context(), scope)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	invitation, err := ir.aService.ResendInvitation(r.Context(), scope)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "invitations", cur, invitation)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(invitation); err != nil {
+		return
+	}
+}
+
+// RevokeInvitation is the handler function that revokes a pending invitation
+func (ir *invitationRouter) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	inviteId := vars["inviteId"]
+	if !utilities.CheckObjectID(inviteId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing inviteId"})
+		return
+	}
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	invitation, err := ir.aService.RevokeInvitation(r.Context(), &models.Invitation{Id: inviteId, GroupId: decodedToken.GetGroupsScope().Id})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "invitations", invitation, nil)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(invitation); err != nil {
+		return
+	}
+}
+
+// AcceptInvitation is the handler function that redeems an invitation token
+// A new user is signed in with a new session, an existing user is added to the group and can switch to it after signing in
+func (ir *invitationRouter) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var dto acceptInvitation
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	u, created, err := ir.aService.AcceptInvitation(r.Context(), dto.Token, dto.toUser(), clientIP(r))
+	if errors.Is(err, services.ErrLoginLocked) {
+		utilities.RespondWithError(w, http.StatusTooManyRequests, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	u.Password = ""
+	if !created {
+		auditChange(r, "memberships", nil, &models.Membership{UserId: u.Id, GroupId: u.GroupId, Role: u.Role})
+		w = utilities.SetResponseHeaders(w, "", "")
+		w.WriteHeader(http.StatusOK)
+		if err = json.NewEncoder(w).Encode(u); err != nil {
+			return
+		}
+		return
+	}
+	auditChange(r, "users", nil, u)
+	sessionToken, err := ir.aService.GenerateToken(u)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	refreshToken, err := ir.aService.GenerateRefreshToken(r.Context(), u)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, sessionToken, "")
+	w.Header().Add("Refresh-Token", refreshToken)
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(u); err != nil {
+		return
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"time"
+)
+
+type recurrenceRouter struct {
+	aService *services.TokenService
+	tService services.TaskService
+	sService services.TaskSeriesService
+}
+
+// NewRecurrenceRouter is a function that initializes a new recurrenceRouter struct
+func NewRecurrenceRouter(router *mux.Router, a *services.TokenService, t services.TaskService, s services.TaskSeriesService) *mux.Router {
+	rRouter := recurrenceRouter{a, t, s}
+	router.HandleFunc("/tasks/{taskId}/recurrence", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/tasks/{taskId}/recurrence", a.MemberTokenVerifyMiddleWare(rRouter.RecurrenceShow)).Methods("GET")
+	router.HandleFunc("/tasks/{taskId}/recurrence", a.MemberTokenVerifyMiddleWare(rRouter.SetRecurrence)).Methods("PUT")
+	router.HandleFunc("/tasks/{taskId}/recurrence", a.MemberTokenVerifyMiddleWare(rRouter.StopRecurrence)).Methods("DELETE")
+	router.HandleFunc("/tasks/{taskId}/recurrence/skip", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/tasks/{taskId}/recurrence/skip", a.MemberTokenVerifyMiddleWare(rRouter.SkipOccurrence)).Methods("POST")
+	return router
+}
+
+// loadTask returns the Task of a recurrence request when the requester can access it with the anyPermission
+func (rr *recurrenceRouter) loadTask(w http.ResponseWriter, r *http.Request, anyPermission string) (*models.Task, bool) {
+	vars := mux.Vars(r)
+	taskId := vars["taskId"]
+	if !utilities.CheckObjectID(taskId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing taskId"})
+		return nil, false
+	}
+	td, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return nil, false
+	}
+	requester := td.ToUser()
+	task, err := rr.tService.TaskFind(r.Context(), &models.Task{Id: taskId})
+	if err != nil || !(task.InScope(requester, anyPermission) || task.AssignedTo(requester)) {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "task not found"})
+		return nil, false
+	}
+	return task, true
+}
+
+// loadSeries returns the TaskSeries of the Task of a recurrence request
+func (rr *recurrenceRouter) loadSeries(w http.ResponseWriter, r *http.Request, anyPermission string) (*models.Task, *models.TaskSeries, bool) {
+	task, ok := rr.loadTask(w, r, anyPermission)
+	if !ok {
+		return nil, nil, false
+	}
+	if !task.CheckID("series_id") {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "task is not recurring"})
+		return nil, nil, false
+	}
+	series, err := rr.sService.TaskSeriesFind(r.Context(), &models.TaskSeries{Id: task.SeriesId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return nil, nil, false
+	}
+	return task, series, true
+}
+
+// RecurrenceShow returns the series a task belongs to
+func (rr *recurrenceRouter) RecurrenceShow(w http.ResponseWriter, r *http.Request) {
+	_, series, ok := rr.loadSeries(w, r, models.PermTasksReadAny)
+	if !ok {
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(series); err != nil {
+		return
+	}
+}
+
+// SetRecurrence makes a task repeat with a RRULE, or changes the RRULE of the series the task is the current instance of
+func (rr *recurrenceRouter) SetRecurrence(w http.ResponseWriter, r *http.Request) {
+	var series models.TaskSeries
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &series); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	task, ok := rr.loadTask(w, r, models.PermTasksUpdateAny)
+	if !ok {
+		return
+	}
+	var cur *models.TaskSeries
+	if task.CheckID("series_id") {
+		cur, _ = rr.sService.TaskSeriesFind(r.Context(), &models.TaskSeries{Id: task.SeriesId})
+	}
+	s, err := rr.sService.TaskSeriesSet(r.Context(), &models.TaskSeries{TaskId: task.Id, RRule: series.RRule, Generate: series.Generate})
+	if errors.Is(err, models.ErrTaskSeriesChanged) {
+		utilities.RespondWithError(w, http.StatusConflict, utilities.JWTError{Message: err.Error()})
+		return
+	} else if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if cur != nil && cur.Id == s.Id {
+		auditChange(r, "task_series", cur, s)
+	} else {
+		auditChange(r, "task_series", nil, s)
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(s); err != nil {
+		return
+	}
+}
+
+// StopRecurrence stops the series a task belongs to, the instances already created are kept
+func (rr *recurrenceRouter) StopRecurrence(w http.ResponseWriter, r *http.Request) {
+	_, series, ok := rr.loadSeries(w, r, models.PermTasksUpdateAny)
+	if !ok {
+		return
+	}
+	s, err := rr.sService.TaskSeriesStop(r.Context(), &models.TaskSeries{Id: series.Id})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "task_series", series, nil)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(s); err != nil {
+		return
+	}
+}
+
+// SkipOccurrence skips the occurrence of a task that is the current instance of its series, the task moves on to the
+// next occurrence
+func (rr *recurrenceRouter) SkipOccurrence(w http.ResponseWriter, r *http.Request) {
+	task, series, ok := rr.loadSeries(w, r, models.PermTasksUpdateAny)
+	if !ok {
+		return
+	}
+	if series.TaskId != task.Id {
+		utilities.RespondWithError(w, http.StatusConflict, utilities.JWTError{Message: "only the current instance of a task series can be skipped"})
+		return
+	}
+	s, err := rr.sService.TaskSeriesSkip(r.Context(), &models.TaskSeries{Id: series.Id}, time.Now().UTC())
+	if errors.Is(err, models.ErrTaskSeriesEnded) || errors.Is(err, models.ErrTaskSeriesChanged) {
+		utilities.RespondWithError(w, http.StatusConflict, utilities.JWTError{Message: err.Error()})
+		return
+	} else if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "task_series", series, s)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(s); err != nil {
+		return
+	}
+}
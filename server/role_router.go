@@ -0,0 +1,231 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+)
+
+type roleRouter struct {
+	aService  *services.TokenService
+	roService services.RoleService
+}
+
+// NewRoleRouter is a function that initializes a new roleRouter struct
+func NewRoleRouter(router *mux.Router, a *services.TokenService, ro services.RoleService) *mux.Router {
+	rRouter := roleRouter{a, ro}
+	router.HandleFunc("/roles", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/roles", a.RequirePermission(models.PermRolesManage, rRouter.GetRoles)).Methods("GET")
+	router.HandleFunc("/roles", a.RequirePermission(models.PermRolesManage, rRouter.CreateRole)).Methods("POST")
+	router.HandleFunc("/roles/{roleId}", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/roles/{roleId}", a.RequirePermission(models.PermRolesManage, rRouter.ModifyRole)).Methods("PATCH")
+	router.HandleFunc("/roles/{roleId}", a.RequirePermission(models.PermRolesManage, rRouter.DeleteRole)).Methods("DELETE")
+	router.HandleFunc("/users/{userId}/role", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/users/{userId}/role", a.RequirePermission(models.PermRolesManage, rRouter.AssignRole)).Methods("PUT")
+	return router
+}
+
+// roleGroupScope returns the group whose roles a requester manages, root admins can select a group with groupId
+func roleGroupScope(r *http.Request, groupId string) (string, error) {
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		return "", err
+	}
+	if groupId == "" {
+		groupId = decodedToken.GroupId
+	}
+	return auth.VerifyGroupRequestScope(r, groupId)
+}
+
+// readRole decodes a Role from a http request body
+func readRole(r *http.Request) (*models.Role, error) {
+	var role models.Role
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		return nil, err
+	}
+	if err = r.Body.Close(); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(body, &role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetRoles is the handler function that returns the built-in and custom roles of a group
+func (rr *roleRouter) GetRoles(w http.ResponseWriter, r *http.Request) {
+	groupId, err := roleGroupScope(r, r.URL.Query().Get("group_id"))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	roles, err := rr.roService.RolesFind(r.Context(), &models.Role{GroupId: groupId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&rolesDTO{Roles: append(models.BuiltInRoles(groupId), roles...), Permissions: models.Permissions}); err != nil {
+		return
+	}
+}
+
+// CreateRole is the handler function that creates a new custom role for a group
+func (rr *roleRouter) CreateRole(w http.ResponseWriter, r *http.Request) {
+	role, err := readRole(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	role.GroupId, err = roleGroupScope(r, role.GroupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = services.CanGrantPermissions(decodedToken.ToUser(), role.Permissions); err != nil {
+		utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	role.Id = ""
+	role, err = rr.roService.RoleCreate(r.Context(), role)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "roles", nil, role)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(role); err != nil {
+		return
+	}
+}
+
+// ModifyRole is the handler function that renames a custom role or replaces its permissions
+func (rr *roleRouter) ModifyRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	roleId := vars["roleId"]
+	if !utilities.CheckObjectID(roleId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing roleId"})
+		return
+	}
+	role, err := readRole(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = services.CanGrantPermissions(decodedToken.ToUser(), role.Permissions); err != nil {
+		utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	role.Id = roleId
+	role.GroupId = decodedToken.GetGroupsScope().Id
+	cur, err := rr.roService.RoleFind(r.Context(), &models.Role{Id: role.Id, GroupId: role.GroupId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	role, err = rr.roService.RoleUpdate(r.Context(), role)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "roles", cur, role)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(role); err != nil {
+		return
+	}
+}
+
+// DeleteRole is the handler function that deletes a custom role, users assigned the role lose its permissions
+func (rr *roleRouter) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	roleId := vars["roleId"]
+	if !utilities.CheckObjectID(roleId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing roleId"})
+		return
+	}
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	role, err := rr.roService.RoleDelete(r.Context(), &models.Role{Id: roleId, GroupId: decodedToken.GetGroupsScope().Id})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "roles", role, nil)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(role); err != nil {
+		return
+	}
+}
+
+// AssignRole is the handler function that assigns a built-in or custom role to a user of the group
+func (rr *roleRouter) AssignRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userId := vars["userId"]
+	if !utilities.CheckObjectID(userId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing userId"})
+		return
+	}
+	var dto assignRole
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil || dto.Role == "" {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing role"})
+		return
+	}
+	userScope, err := auth.VerifyUserPermissionScope(r, userId, models.PermRolesManage)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = rr.aService.CanGrantRole(r.Context(), decodedToken.ToUser(), dto.Role); err != nil {
+		utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	cur, user, err := rr.aService.AssignRole(r.Context(), userScope, dto.Role)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "users", cur, user)
+	user.Password = ""
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(user); err != nil {
+		return
+	}
+}
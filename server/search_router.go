@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type searchRouter struct {
+	aService *services.TokenService
+	sService services.SearchService
+}
+
+// NewSearchRouter is a function that initializes a new searchRouter struct
+func NewSearchRouter(router *mux.Router, a *services.TokenService, s services.SearchService) *mux.Router {
+	sRouter := searchRouter{a, s}
+	router.HandleFunc("/search", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/search", a.MemberTokenVerifyMiddleWare(sRouter.Search)).Methods("GET")
+	return router
+}
+
+// Search returns the ranked tasks, users and groups within the requester's scope that match the q query parameter
+func (sr *searchRouter) Search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing search query"})
+		return
+	}
+	if utf8.RuneCountInString(query) > models.MaxSearchQueryLength {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "search query can be at most " + strconv.Itoa(models.MaxSearchQueryLength) + " characters"})
+		return
+	}
+	var err error
+	limit := models.DefaultSearchLimit
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || limit < 1 || limit > models.MaxSearchLimit {
+			utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "limit must be a number between 1 and " + strconv.FormatInt(models.MaxSearchLimit, 10)})
+			return
+		}
+	}
+	userScope, err := auth.VerifyRequestScope(r, "find")
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	hits, err := sr.sService.Search(r.Context(), query, userScope, limit)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	dto := searchDTO{Query: query, Hits: hits}
+	if dto.Hits == nil {
+		dto.Hits = []*models.SearchHit{}
+	}
+	dto.clean()
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(dto); err != nil {
+		return
+	}
+}
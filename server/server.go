@@ -2,6 +2,7 @@ package server
 
 import (
 	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"log"
@@ -12,30 +13,77 @@ import (
 
 // Server is a struct that stores the API Apps high level attributes such as the router, config, and services
 type Server struct {
-	Router       *mux.Router
-	TokenService *services.TokenService
-	UserService  services.UserService
-	GroupService services.GroupService
-	TaskService  services.TaskService
-	FileService  services.FileService
+	Router            *mux.Router
+	TokenService      *services.TokenService
+	UserService       services.UserService
+	GroupService      services.GroupService
+	TaskService       services.TaskService
+	FileService       services.FileService
+	RoleService       services.RoleService
+	MembershipService services.MembershipService
+	InvitationService services.InvitationService
+	CommentService    services.CommentService
+	ActivityService   services.ActivityService
+	TaskSeriesService services.TaskSeriesService
+	FeedTokenService  services.FeedTokenService
+	SearchService     services.SearchService
+	AuditService      services.AuditService
+	WebhookService    services.WebhookService
+	EventBroker       services.EventBroker
+	CascadeService    services.CascadeService
 }
 
 // NewServer is a function used to initialize a new Server struct
-func NewServer(u services.UserService, g services.GroupService, tt services.TaskService, f services.FileService, t *services.TokenService) *Server {
+func NewServer(u services.UserService, g services.GroupService, tt services.TaskService, f services.FileService, ro services.RoleService, m services.MembershipService, i services.InvitationService, c services.CommentService, ac services.ActivityService, ts services.TaskSeriesService, ft services.FeedTokenService, se services.SearchService, au services.AuditService, wh services.WebhookService, eb services.EventBroker, cs services.CascadeService, t *services.TokenService) *Server {
 	router := mux.NewRouter().StrictSlash(true)
-	router = NewGroupRouter(router, t, g, u, tt, f)
-	router = NewUserRouter(router, t, u, g, tt, f)
-	router = NewTaskRouter(router, t, tt)
+	router.Use(corsMiddleWare)
+	router = NewAuditRouter(router, t, au)
+	router = NewGroupRouter(router, t, g, u, tt, f, m, ac, cs)
+	router = NewUserRouter(router, t, u, g, tt, f, cs)
+	router = NewTaskRouter(router, t, tt, f, ts)
+	router = NewRecurrenceRouter(router, t, tt, ts)
+	router = NewAttachmentRouter(router, t, tt, f)
+	router = NewCommentRouter(router, t, tt, c)
+	router = NewAdminRouter(router, t, g, u, tt, f)
+	router = NewRoleRouter(router, t, ro)
+	router = NewInvitationRouter(router, t, i)
+	router = NewCalendarRouter(router, t, tt, u, g, m, ft)
+	router = NewSearchRouter(router, t, se)
+	router = NewWebhookRouter(router, t, wh)
+	router = NewEventRouter(router, t, eb)
 	return &Server{
-		Router:       router,
-		TokenService: t,
-		UserService:  u,
-		GroupService: g,
-		TaskService:  tt,
-		FileService:  f,
+		Router:            router,
+		TokenService:      t,
+		UserService:       u,
+		GroupService:      g,
+		TaskService:       tt,
+		FileService:       f,
+		RoleService:       ro,
+		MembershipService: m,
+		InvitationService: i,
+		CommentService:    c,
+		ActivityService:   ac,
+		TaskSeriesService: ts,
+		FeedTokenService:  ft,
+		SearchService:     se,
+		AuditService:      au,
+		WebhookService:    wh,
+		EventBroker:       eb,
+		CascadeService:    cs,
 	}
 }
 
+// corsMiddleWare lets the browsers of the utilities.AllowedOrigins read the responses of the API
+func corsMiddleWare(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); utilities.CheckOrigin(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // Start starts the initialized Server
 func (s *Server) Start() {
 	log.Println("Listening on port " + os.Getenv("PORT"))
@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/JECSand/go-rest-api-boilerplate/auth"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
 	"github.com/JECSand/go-rest-api-boilerplate/services"
@@ -9,16 +10,20 @@ import (
 	"github.com/gorilla/mux"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type taskRouter struct {
 	aService *services.TokenService
 	tService services.TaskService
+	fService services.FileService
+	sService services.TaskSeriesService
 }
 
 // NewTaskRouter is a function that initializes a new groupRouter struct
-func NewTaskRouter(router *mux.Router, a *services.TokenService, t services.TaskService) *mux.Router {
-	gRouter := taskRouter{a, t}
+func NewTaskRouter(router *mux.Router, a *services.TokenService, t services.TaskService, f services.FileService, s services.TaskSeriesService) *mux.Router {
+	gRouter := taskRouter{a, t, f, s}
 	router.HandleFunc("/tasks", utilities.HandleOptionsRequest).Methods("OPTIONS")
 	router.HandleFunc("/tasks", a.MemberTokenVerifyMiddleWare(gRouter.TasksShow)).Methods("GET")
 	router.HandleFunc("/tasks", a.MemberTokenVerifyMiddleWare(gRouter.CreateTask)).Methods("POST")
@@ -26,10 +31,15 @@ func NewTaskRouter(router *mux.Router, a *services.TokenService, t services.Task
 	router.HandleFunc("/tasks/{taskId}", a.MemberTokenVerifyMiddleWare(gRouter.TaskShow)).Methods("GET")
 	router.HandleFunc("/tasks/{taskId}", a.MemberTokenVerifyMiddleWare(gRouter.DeleteTask)).Methods("DELETE")
 	router.HandleFunc("/tasks/{taskId}", a.MemberTokenVerifyMiddleWare(gRouter.ModifyTask)).Methods("PATCH")
+	router.HandleFunc("/tasks/{taskId}/restore", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/tasks/{taskId}/restore", a.MemberTokenVerifyMiddleWare(gRouter.RestoreTask)).Methods("POST")
+	router.HandleFunc("/tasks/{taskId}/history", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/tasks/{taskId}/history", a.MemberTokenVerifyMiddleWare(gRouter.TaskHistory)).Methods("GET")
 	return router
 }
 
-// TasksShow returns all tasks to client
+// TasksShow returns all tasks to client, users that can not read the tasks of other users are shown their own tasks
+// along with the tasks assigned to them
 func (gr *taskRouter) TasksShow(w http.ResponseWriter, r *http.Request) {
 	var filter models.Task
 	userScope, err := auth.VerifyRequestScope(r, "find")
@@ -37,15 +47,39 @@ func (gr *taskRouter) TasksShow(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	filter.LoadScope(userScope)
-	tasks, err := gr.tService.TasksFind(&filter)
+	td, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	requester := td.ToUser()
+	filter.LoadScope(userScope, models.PermTasksReadAny)
+	findPage := gr.tService.TasksFindPage
+	if !requester.RootAdmin && !requester.HasPermission(models.PermTasksReadAny) {
+		filter.UserId = requester.Id
+		findPage = gr.tService.TasksFindVisiblePage
+	}
+	opts, err := models.NewListOptions(r.URL.Query(), models.TaskSortFields, models.TaskFilterFields)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = opts.LoadRanges(r.URL.Query(), models.TaskRangeFields); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	tasks, total, err := findPage(r.Context(), &filter, opts)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
 		return
 	}
+	var lastId string
+	if len(tasks) > 0 {
+		lastId = tasks[len(tasks)-1].Id
+	}
 	w = utilities.SetResponseHeaders(w, "", "")
 	w.WriteHeader(http.StatusOK)
-	if err = json.NewEncoder(w).Encode(&tasksDTO{Tasks: tasks}); err != nil {
+	if err = json.NewEncoder(w).Encode(&tasksDTO{Tasks: tasks, pageDTO: newPageDTO(r, opts, total, len(tasks), lastId)}); err != nil {
 		return
 	}
 }
@@ -71,7 +105,7 @@ func (gr *taskRouter) CreateTask(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	task.LoadScope(userScope)
+	task.LoadScope(userScope, models.PermTasksCreateAny)
 	task.Id = utilities.GenerateObjectID()
 	if !task.CheckID("user_id") || !task.CheckID("group_id") {
 		td, err := auth.LoadTokenFromRequest(r)
@@ -86,11 +120,16 @@ func (gr *taskRouter) CreateTask(w http.ResponseWriter, r *http.Request) {
 			task.GroupId = td.GroupId
 		}
 	}
-	g, err := gr.tService.TaskCreate(&task)
-	if err != nil {
+	g, err := gr.tService.TaskCreate(r.Context(), &task)
+	var cycleErr *models.CycleError
+	if errors.As(err, &cycleErr) {
+		utilities.RespondWithError(w, http.StatusConflict, utilities.JWTError{Message: err.Error()})
+		return
+	} else if err != nil {
 		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
 		return
 	} else {
+		auditChange(r, "tasks", nil, g)
 		w = utilities.SetResponseHeaders(w, "", "")
 		w.WriteHeader(http.StatusCreated)
 		if err = json.NewEncoder(w).Encode(g); err != nil {
@@ -121,12 +160,50 @@ func (gr *taskRouter) ModifyTask(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	task.Id = taskId
-	g, err := gr.tService.TaskUpdate(&task)
+	clear, err := nullFields(body, models.TaskClearableFields)
 	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	td, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	requester := td.ToUser()
+	cur, err := gr.tService.TaskFind(r.Context(), &models.Task{Id: taskId})
+	if err != nil || !(cur.InScope(requester, models.PermTasksUpdateAny) || cur.AssignedTo(requester)) {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "task not found"})
+		return
+	}
+	if task.CheckID("user_id") && task.UserId != cur.UserId && !requester.HasPermission(models.PermTasksUpdateAny) {
+		utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: "missing the " + models.PermTasksUpdateAny + " permission"})
+		return
+	}
+	reassigned := task.AssigneeId != "" && task.AssigneeId != cur.AssigneeId
+	for _, field := range clear {
+		reassigned = reassigned || field == "assignee_id"
+	}
+	if reassigned && !cur.InScope(requester, models.PermTasksUpdateAny) {
+		utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: "only the task owner can reassign a task"})
+		return
+	}
+	if !requester.RootAdmin {
+		task.GroupId = "" // tasks can only be moved between groups by root admins
+	}
+	task.Id = taskId
+	g, err := gr.tService.TaskUpdate(r.Context(), &task, requester.Id, clear...)
+	var transitionErr *models.TransitionError
+	var cycleErr *models.CycleError
+	var blockedErr *models.BlockedError
+	if errors.As(err, &transitionErr) || errors.As(err, &cycleErr) || errors.As(err, &blockedErr) {
+		utilities.RespondWithError(w, http.StatusConflict, utilities.JWTError{Message: err.Error()})
+		return
+	} else if err != nil {
 		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
 		return
 	} else {
+		auditChange(r, "tasks", cur, g)
 		w = utilities.SetResponseHeaders(w, "", "")
 		w.WriteHeader(http.StatusAccepted)
 		if err = json.NewEncoder(w).Encode(g); err != nil {
@@ -149,13 +226,151 @@ func (gr *taskRouter) TaskShow(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	filter.LoadScope(userScope)
+	td, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	requester := td.ToUser()
+	filter.LoadScope(userScope, models.PermTasksReadAny)
+	filter.Id = taskId
+	task, err := gr.tService.TaskFind(r.Context(), &filter)
+	if err != nil || !(task.InScope(requester, models.PermTasksReadAny) || task.AssignedTo(requester)) {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "task not found"})
+		return
+	}
+	visible := func(t *models.Task) bool {
+		return t.InScope(requester, models.PermTasksReadAny) || t.AssignedTo(requester)
+	}
+	detail := &taskDetailDTO{Task: task}
+	for _, expand := range strings.Split(r.URL.Query().Get("expand"), ",") {
+		switch strings.TrimSpace(expand) {
+		case "":
+		case "subtasks":
+			subtasks, err := gr.tService.TaskSubtree(r.Context(), task)
+			if err != nil {
+				utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+				return
+			}
+			detail.Subtasks = visibleSubtasks(subtasks, visible)
+		case "dependencies":
+			graph, err := gr.tService.TaskDependencies(r.Context(), task)
+			if err != nil {
+				utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+				return
+			}
+			detail.Dependencies = visibleDependencies(graph, visible)
+		default:
+			utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "invalid expand: " + expand})
+			return
+		}
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(detail); err != nil {
+		return
+	}
+	return
+}
+
+// visibleSubtasks returns the trees of subtasks without the subtasks, and their own subtasks, that are not visible
+func visibleSubtasks(trees []*models.TaskTree, visible func(t *models.Task) bool) []*models.TaskTree {
+	var subtasks []*models.TaskTree
+	for _, tree := range trees {
+		if visible(tree.Task) {
+			subtasks = append(subtasks, &models.TaskTree{Task: tree.Task, Subtasks: visibleSubtasks(tree.Subtasks, visible)})
+		}
+	}
+	return subtasks
+}
+
+// visibleDependencies returns a dependency graph without the tasks that are not visible and their links
+func visibleDependencies(graph *models.TaskGraph, visible func(t *models.Task) bool) *models.TaskGraph {
+	ids := make(map[string]bool)
+	deps := &models.TaskGraph{Tasks: []*models.Task{}, Edges: []*models.TaskDependency{}}
+	for _, t := range graph.Tasks {
+		if visible(t) {
+			ids[t.Id] = true
+			deps.Tasks = append(deps.Tasks, t)
+		}
+	}
+	for _, e := range graph.Edges {
+		if ids[e.TaskId] && ids[e.BlockedBy] {
+			deps.Edges = append(deps.Edges, e)
+		}
+	}
+	return deps
+}
+
+// TaskHistory returns the status transitions of a specific task
+func (gr *taskRouter) TaskHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	taskId := vars["taskId"]
+	if !utilities.CheckObjectID(taskId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing taskId"})
+		return
+	}
+	td, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	requester := td.ToUser()
+	task, err := gr.tService.TaskFind(r.Context(), &models.Task{Id: taskId})
+	if err != nil || !(task.InScope(requester, models.PermTasksReadAny) || task.AssignedTo(requester)) {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "task not found"})
+		return
+	}
+	history, err := gr.tService.TaskHistoryFind(r.Context(), task)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&taskHistoryDTO{History: history}); err != nil {
+		return
+	}
+}
+
+// DeleteTask deletes a task
+func (gr *taskRouter) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	taskId := vars["taskId"]
+	if !utilities.CheckObjectID(taskId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing taskId"})
+		return
+	}
+	var filter models.Task
+	userScope, err := auth.VerifyRequestScope(r, "update")
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	td, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	requester := td.ToUser()
+	filter.LoadScope(userScope, models.PermTasksDeleteAny)
 	filter.Id = taskId
-	task, err := gr.tService.TaskFind(&filter)
+	cur, err := gr.tService.TaskFind(r.Context(), &filter)
+	if err != nil || !cur.InScope(requester, models.PermTasksDeleteAny) {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "task not found"})
+		return
+	}
+	task, err := gr.tService.TaskDelete(r.Context(), &filter)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
 	}
+	err = gr.fService.FileDeleteMany(r.Context(), models.TasksToFiles([]*models.Task{task}))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "tasks", cur, task)
 	w = utilities.SetResponseHeaders(w, "", "")
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(task); err != nil {
@@ -164,8 +379,8 @@ func (gr *taskRouter) TaskShow(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// DeleteTask deletes a task
-func (gr *taskRouter) DeleteTask(w http.ResponseWriter, r *http.Request) {
+// RestoreTask restores a soft deleted task
+func (gr *taskRouter) RestoreTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	taskId := vars["taskId"]
 	if !utilities.CheckObjectID(taskId) {
@@ -178,13 +393,21 @@ func (gr *taskRouter) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	filter.LoadScope(userScope)
+	filter.LoadScope(userScope, models.PermTasksDeleteAny)
 	filter.Id = taskId
-	task, err := gr.tService.TaskDelete(&filter)
+	task, err := gr.tService.TaskRestore(r.Context(), &filter)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
 	}
+	err = gr.fService.FileRestoreMany(r.Context(), models.TasksToFiles([]*models.Task{task}), task.DeletedAt)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	deleted := *task
+	task.DeletedAt = time.Time{}
+	auditChange(r, "tasks", &deleted, task)
 	w = utilities.SetResponseHeaders(w, "", "")
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(task); err != nil {
@@ -192,3 +415,18 @@ func (gr *taskRouter) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	}
 	return
 }
+
+// nullFields returns the fields of a JSON request body that are explicitly set to null, limited to the given fields
+func nullFields(body []byte, fields []string) ([]string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	var nulls []string
+	for _, field := range fields {
+		if v, ok := raw[field]; ok && string(v) == "null" {
+			nulls = append(nulls, field)
+		}
+	}
+	return nulls, nil
+}
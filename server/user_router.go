@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/JECSand/go-rest-api-boilerplate/auth"
@@ -10,40 +11,80 @@ import (
 	"github.com/JECSand/go-rest-api-boilerplate/utilities"
 	"github.com/gorilla/mux"
 	"io"
+	"log"
+	"math"
 	"mime"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 )
 
+// oidcStateCookie is the cookie that carries the state of an identity provider sign in to its callback
+const oidcStateCookie = "oidc_state"
+
 type userRouter struct {
 	aService *services.TokenService
 	uService services.UserService
 	gService services.GroupService
 	tService services.TaskService
 	fService services.FileService
+	cService services.CascadeService
 }
 
 // NewUserRouter is a function that initializes a new userRouter struct
-func NewUserRouter(router *mux.Router, a *services.TokenService, u services.UserService, g services.GroupService, t services.TaskService, f services.FileService) *mux.Router {
-	uRouter := userRouter{a, u, g, t, f}
+func NewUserRouter(router *mux.Router, a *services.TokenService, u services.UserService, g services.GroupService, t services.TaskService, f services.FileService, c services.CascadeService) *mux.Router {
+	uRouter := userRouter{a, u, g, t, f, c}
 	router.HandleFunc("/auth", utilities.HandleOptionsRequest).Methods("OPTIONS")
 	router.HandleFunc("/auth", uRouter.SignIn).Methods("POST")
 	router.HandleFunc("/auth", a.MemberTokenVerifyMiddleWare(uRouter.RefreshSession)).Methods("GET")
 	router.HandleFunc("/auth", a.MemberTokenVerifyMiddleWare(uRouter.SignOut)).Methods("DELETE")
+	router.HandleFunc("/auth/refresh", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/refresh", uRouter.RefreshToken).Methods("POST")
+	router.HandleFunc("/.well-known/jwks.json", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/.well-known/jwks.json", uRouter.GetJWKS).Methods("GET")
+	router.HandleFunc("/auth/oidc/{provider}/start", uRouter.StartOIDCSignIn).Methods("GET")
+	router.HandleFunc("/auth/oidc/{provider}/callback", uRouter.OIDCCallback).Methods("GET")
+	router.HandleFunc("/auth/2fa", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/2fa", uRouter.CompleteTwoFactor).Methods("POST")
+	router.HandleFunc("/auth/2fa", a.MemberTokenVerifyMiddleWare(uRouter.DisableTwoFactor)).Methods("DELETE")
+	router.HandleFunc("/auth/2fa/enroll", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/2fa/enroll", a.MemberTokenVerifyMiddleWare(uRouter.EnrollTwoFactor)).Methods("POST")
+	router.HandleFunc("/auth/2fa/enable", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/2fa/enable", a.MemberTokenVerifyMiddleWare(uRouter.EnableTwoFactor)).Methods("POST")
+	router.HandleFunc("/auth/groups", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/groups", a.MemberTokenVerifyMiddleWare(uRouter.GetMemberships)).Methods("GET")
+	router.HandleFunc("/auth/switch-group", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/switch-group", a.MemberTokenVerifyMiddleWare(uRouter.SwitchGroup)).Methods("POST")
 	router.HandleFunc("/auth/register", utilities.HandleOptionsRequest).Methods("OPTIONS")
 	router.HandleFunc("/auth/register", uRouter.RegisterUser).Methods("POST")
-	router.HandleFunc("/auth/api-key", utilities.HandleOptionsRequest).Methods("OPTIONS")
-	router.HandleFunc("/auth/api-key", a.MemberTokenVerifyMiddleWare(uRouter.GenerateAPIKey)).Methods("GET")
+	router.HandleFunc("/auth/api-keys", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/api-keys", a.MemberTokenVerifyMiddleWare(uRouter.GetAPIKeys)).Methods("GET")
+	router.HandleFunc("/auth/api-keys", a.MemberTokenVerifyMiddleWare(uRouter.CreateAPIKey)).Methods("POST")
+	router.HandleFunc("/auth/api-keys/{keyId}", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/api-keys/{keyId}", a.MemberTokenVerifyMiddleWare(uRouter.DeleteAPIKey)).Methods("DELETE")
 	router.HandleFunc("/auth/password", utilities.HandleOptionsRequest).Methods("OPTIONS")
 	router.HandleFunc("/auth/password", a.MemberTokenVerifyMiddleWare(uRouter.UpdatePassword)).Methods("POST")
+	router.HandleFunc("/auth/password/forgot", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/password/forgot", uRouter.ForgotPassword).Methods("POST")
+	router.HandleFunc("/auth/password/reset", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/password/reset", uRouter.ResetPassword).Methods("POST")
+	router.HandleFunc("/auth/verify", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/verify", uRouter.VerifyEmail).Methods("POST")
+	router.HandleFunc("/auth/verify/resend", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/auth/verify/resend", a.MemberTokenVerifyMiddleWare(uRouter.ResendVerification)).Methods("POST")
 	router.HandleFunc("/users", utilities.HandleOptionsRequest).Methods("OPTIONS")
 	router.HandleFunc("/users", a.MemberTokenVerifyMiddleWare(uRouter.GetUsers)).Methods("GET")
 	router.HandleFunc("/users/{userId}", utilities.HandleOptionsRequest).Methods("OPTIONS")
 	router.HandleFunc("/users/{userId}", a.MemberTokenVerifyMiddleWare(uRouter.GetUser)).Methods("GET")
-	router.HandleFunc("/users", a.AdminTokenVerifyMiddleWare(uRouter.CreateUser)).Methods("POST")
-	router.HandleFunc("/users/{userId}", a.AdminTokenVerifyMiddleWare(uRouter.DeleteUser)).Methods("DELETE")
+	router.HandleFunc("/users", a.RequirePermission(models.PermUsersCreate, uRouter.CreateUser)).Methods("POST")
+	router.HandleFunc("/users/{userId}", a.RequirePermission(models.PermUsersDelete, uRouter.DeleteUser)).Methods("DELETE")
 	router.HandleFunc("/users/{userId}", a.MemberTokenVerifyMiddleWare(uRouter.ModifyUser)).Methods("PATCH")
+	router.HandleFunc("/users/{userId}/restore", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/users/{userId}/restore", a.RequirePermission(models.PermUsersDelete, uRouter.RestoreUser)).Methods("POST")
+	router.HandleFunc("/users/{userId}/unlock", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/users/{userId}/unlock", a.RequirePermission(models.PermUsersUnlock, uRouter.UnlockUser)).Methods("POST")
 	router.HandleFunc("/users/{userId}/image", utilities.HandleOptionsRequest).Methods("OPTIONS")
 	router.HandleFunc("/users/{userId}/image", a.MemberTokenVerifyMiddleWare(uRouter.UploadImage)).Methods("POST")
 	router.HandleFunc("/users/{userId}/image", a.MemberTokenVerifyMiddleWare(uRouter.GetImage)).Methods("GET")
@@ -60,7 +101,7 @@ func (ur *userRouter) GetUserTasks(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing userId"})
 		return
 	}
-	user, err := ur.uService.UserFind(&models.User{Id: userId})
+	user, err := ur.uService.UserFind(r.Context(), &models.User{Id: userId})
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
@@ -74,14 +115,24 @@ func (ur *userRouter) GetUserTasks(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: "unauthorized"})
 		return
 	}
+	opts, err := models.NewListOptions(r.URL.Query(), models.TaskSortFields, models.TaskFilterFields)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
 	var dto userTasksDTO
 	dto.User = user
-	tasks, err := ur.tService.TasksFind(&models.Task{UserId: userId})
+	tasks, total, err := ur.tService.TasksFindPage(r.Context(), &models.Task{UserId: userId}, opts)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
 	}
+	var lastId string
+	if len(tasks) > 0 {
+		lastId = tasks[len(tasks)-1].Id
+	}
 	dto.Tasks = tasks
+	dto.pageDTO = newPageDTO(r, opts, total, len(tasks), lastId)
 	dto.clean()
 	w = utilities.SetResponseHeaders(w, "", "")
 	w.WriteHeader(http.StatusOK)
@@ -114,7 +165,7 @@ func (ur *userRouter) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	inUser := decodedToken.ToUser()
-	u, err := ur.uService.UpdatePassword(inUser, pw.CurrentPassword, pw.NewPassword)
+	u, err := ur.uService.UpdatePassword(r.Context(), inUser, pw.CurrentPassword, pw.NewPassword)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
@@ -129,6 +180,119 @@ func (ur *userRouter) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ForgotPassword is the handler function that emails a password reset link
+// The response is the same whether or not a user has the email so that it can not be used to discover accounts
+func (ur *userRouter) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var dto forgotPassword
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if dto.Email == "" {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing email"})
+		return
+	}
+	if err = ur.aService.ForgotPassword(r.Context(), dto.Email); err != nil {
+		log.Println("password reset email error:", err)
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusAccepted)
+	return
+}
+
+// ResetPassword is the handler function that sets a new password using a password reset token
+func (ur *userRouter) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var dto resetPassword
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if dto.Token == "" || dto.NewPassword == "" {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing token or new password"})
+		return
+	}
+	u, err := ur.aService.ResetPassword(r.Context(), dto.Token, dto.NewPassword)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusAccepted)
+	u.Password = ""
+	if err = json.NewEncoder(w).Encode(u); err != nil {
+		return
+	}
+	return
+}
+
+// VerifyEmail is the handler function that verifies a user's email address using an email verification token
+func (ur *userRouter) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var dto verifyEmail
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if dto.Token == "" {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing token"})
+		return
+	}
+	u, err := ur.aService.VerifyEmail(r.Context(), dto.Token)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	u.Password = ""
+	if err = json.NewEncoder(w).Encode(u); err != nil {
+		return
+	}
+	return
+}
+
+// ResendVerification is the handler function that emails a new email verification link to the requesting user
+func (ur *userRouter) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	tokenData, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = ur.aService.SendEmailVerification(r.Context(), tokenData.ToUser()); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusAccepted)
+	return
+}
+
 // ModifyUser is the handler function that updates a user
 func (ur *userRouter) ModifyUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -158,11 +322,37 @@ func (ur *userRouter) ModifyUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	user.LoadScope(userScope, "update")
-	u, err := ur.uService.UserUpdate(&user)
+	cur, findErr := ur.uService.UserFind(r.Context(), &models.User{Id: userId})
+	if user.Role != "" {
+		if findErr != nil {
+			utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "user not found"})
+			return
+		}
+		if user.GroupId == "" {
+			user.GroupId = cur.GroupId
+		}
+		if user.Role != cur.Role {
+			decodedToken, err := auth.LoadTokenFromRequest(r)
+			if err != nil {
+				utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+				return
+			}
+			if err = ur.aService.CanGrantRole(r.Context(), decodedToken.ToUser(), user.Role); err != nil {
+				utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: err.Error()})
+				return
+			}
+		}
+		if err = ur.aService.ValidateRole(r.Context(), user.GroupId, user.Role); err != nil {
+			utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+			return
+		}
+	}
+	u, err := ur.uService.UserUpdate(r.Context(), &user)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
 		return
 	} else {
+		auditChange(r, "users", cur, u)
 		w = utilities.SetResponseHeaders(w, "", "")
 		w.WriteHeader(http.StatusAccepted)
 		if err = json.NewEncoder(w).Encode(u); err != nil {
@@ -193,24 +383,231 @@ func (ur *userRouter) SignIn(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	u, err := ur.uService.AuthenticateUser(user)
+	ip := clientIP(r)
+	wait, err := ur.aService.LoginLockout(r.Context(), user.Email, ip)
 	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: "sign in is temporarily unavailable"})
+		return
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		utilities.RespondWithError(w, http.StatusTooManyRequests, utilities.JWTError{Message: "too many failed sign in attempts, try again later"})
+		return
+	}
+	u, err := ur.uService.AuthenticateUser(r.Context(), user)
+	if err != nil {
+		if lErr := ur.aService.RecordLoginFailure(r.Context(), user.Email, ip); lErr != nil {
+			log.Println("login attempt error:", lErr)
+		}
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
-	} else {
-		sessionToken, err := ur.aService.GenerateToken(u, "session")
+	}
+	ur.completeSignIn(r.Context(), w, u)
+}
+
+// completeSignIn starts a session for an authenticated user, or responds with a 2FA challenge when the user has 2FA enabled
+// The failed sign ins of a user with 2FA enabled are only cleared once it completes the challenge
+func (ur *userRouter) completeSignIn(ctx context.Context, w http.ResponseWriter, u *models.User) {
+	if u.TwoFactor {
+		challengeToken, err := ur.aService.GenerateChallengeToken(u)
 		if err != nil {
 			utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 			return
 		}
-		w = utilities.SetResponseHeaders(w, sessionToken, "")
-		w.WriteHeader(http.StatusOK)
-		u.Password = ""
-		if err = json.NewEncoder(w).Encode(u); err != nil {
+		w = utilities.SetResponseHeaders(w, "", "")
+		w.WriteHeader(http.StatusAccepted)
+		if err = json.NewEncoder(w).Encode(twoFactorChallenge{ChallengeToken: challengeToken, TwoFactor: true}); err != nil {
 			return
 		}
 		return
 	}
+	if err := ur.aService.RecordLoginSuccess(ctx, u); err != nil {
+		log.Println("login attempt error:", err)
+	}
+	ur.startSession(ctx, w, u)
+}
+
+// StartOIDCSignIn is the handler function that redirects to an identity provider to sign in
+// The state of the sign in is kept in a short-lived cookie that the callback must be made with
+func (ur *userRouter) StartOIDCSignIn(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	authURL, stateToken, err := ur.aService.StartOIDCSignIn(provider)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    stateToken,
+		Path:     "/auth/oidc/" + provider,
+		HttpOnly: true,
+		Secure:   os.Getenv("HTTPS") == "ON",
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback is the handler function that identity providers redirect back to after signing in
+func (ur *userRouter) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     "/auth/oidc/" + provider,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   os.Getenv("HTTPS") == "ON",
+		SameSite: http.SameSiteLaxMode,
+	})
+	q := r.URL.Query()
+	if idpErr := q.Get("error"); idpErr != "" {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: "identity provider error: " + idpErr})
+		return
+	}
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing oidc state"})
+		return
+	}
+	u, err := ur.aService.CompleteOIDCSignIn(r.Context(), provider, q.Get("state"), cookie.Value, q.Get("code"))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	ur.completeSignIn(r.Context(), w, u)
+}
+
+// clientIP returns the ip address a request was received from
+// Forwarding headers such as X-Forwarded-For are ignored since clients can set them to anything
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// startSession responds with a new session token and refresh token for an authenticated user
+func (ur *userRouter) startSession(ctx context.Context, w http.ResponseWriter, u *models.User) {
+	sessionToken, err := ur.aService.GenerateToken(u)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	refreshToken, err := ur.aService.GenerateRefreshToken(ctx, u)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, sessionToken, "")
+	w.Header().Add("Refresh-Token", refreshToken)
+	w.WriteHeader(http.StatusOK)
+	u.Password = ""
+	if err = json.NewEncoder(w).Encode(u); err != nil {
+		return
+	}
+	return
+}
+
+// CompleteTwoFactor is the handler function that exchanges a 2FA challenge token and code for a new session
+func (ur *userRouter) CompleteTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var dto twoFactorCode
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if dto.ChallengeToken == "" {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing challenge token"})
+		return
+	}
+	u, err := ur.aService.CompleteTwoFactor(r.Context(), dto.ChallengeToken, dto.Code, dto.RecoveryCode, clientIP(r))
+	if errors.Is(err, services.ErrLoginLocked) {
+		utilities.RespondWithError(w, http.StatusTooManyRequests, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	ur.startSession(r.Context(), w, u)
+}
+
+// EnrollTwoFactor is the handler function that generates a new TOTP secret and recovery codes for the requesting user
+func (ur *userRouter) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	tokenData, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	secret, uri, codes, err := ur.aService.EnrollTwoFactor(r.Context(), tokenData.ToUser())
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(twoFactorEnrollment{Secret: secret, URI: uri, RecoveryCodes: codes}); err != nil {
+		return
+	}
+	return
+}
+
+// EnableTwoFactor is the handler function that confirms the TOTP enrollment of the requesting user
+func (ur *userRouter) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ur.updateTwoFactor(w, r, true)
+}
+
+// DisableTwoFactor is the handler function that turns off 2FA for the requesting user
+func (ur *userRouter) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ur.updateTwoFactor(w, r, false)
+}
+
+// updateTwoFactor enables or disables 2FA for the requesting user after checking the code in the request body
+func (ur *userRouter) updateTwoFactor(w http.ResponseWriter, r *http.Request, enable bool) {
+	var dto twoFactorCode
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	tokenData, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	var u *models.User
+	if enable {
+		u, err = ur.aService.EnableTwoFactor(r.Context(), tokenData.ToUser(), dto.Code)
+	} else {
+		u, err = ur.aService.DisableTwoFactor(r.Context(), tokenData.ToUser(), dto.Code, dto.RecoveryCode)
+	}
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	u.Password = ""
+	if err = json.NewEncoder(w).Encode(u); err != nil {
+		return
+	}
+	return
 }
 
 // RefreshSession is the handler function that refreshes a users JWT token
@@ -221,12 +618,17 @@ func (ur *userRouter) RefreshSession(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	user, err := ur.uService.UserFind(tokenData.ToUser())
+	user, err := ur.uService.UserFind(r.Context(), &models.User{Id: tokenData.UserId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	user, err = ur.aService.SessionUser(r.Context(), user, tokenData.GroupId)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	newToken, err := ur.aService.GenerateToken(user, "session")
+	newToken, err := ur.aService.GenerateToken(user)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
 		return
@@ -236,37 +638,204 @@ func (ur *userRouter) RefreshSession(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// GenerateAPIKey is the handler function that generates 6 month API Key for a given user
-func (ur *userRouter) GenerateAPIKey(w http.ResponseWriter, r *http.Request) {
-	authToken := r.Header.Get("Auth-Token")
-	tokenData, err := auth.DecodeJWT(authToken)
+// SwitchGroup is the handler function that starts a new session for another group the user belongs to
+func (ur *userRouter) SwitchGroup(w http.ResponseWriter, r *http.Request) {
+	var dto switchGroup
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil || !utilities.CheckObjectID(dto.GroupId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing group_id"})
+		return
+	}
+	tokenData, err := auth.LoadTokenFromRequest(r)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	user, err := ur.uService.UserFind(tokenData.ToUser())
+	u, err := ur.aService.SwitchGroup(r.Context(), tokenData.ToUser(), dto.GroupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	ur.startSession(r.Context(), w, u)
+}
+
+// GetMemberships is the handler function that returns every group the user belongs to
+func (ur *userRouter) GetMemberships(w http.ResponseWriter, r *http.Request) {
+	tokenData, err := auth.LoadTokenFromRequest(r)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	apiKey, err := ur.aService.GenerateToken(user, "api")
+	memberships, err := ur.aService.UserMemberships(r.Context(), tokenData.ToUser())
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&membershipsDTO{Memberships: memberships}); err != nil {
+		return
+	}
+}
+
+// RefreshToken is the handler function that exchanges a refresh token for a new session and rotated refresh token
+func (ur *userRouter) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var dto refreshSession
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	w = utilities.SetResponseHeaders(w, "", apiKey)
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if dto.RefreshToken == "" {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing refresh token"})
+		return
+	}
+	u, refreshToken, err := ur.aService.RotateRefreshToken(r.Context(), dto.RefreshToken)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	sessionToken, err := ur.aService.GenerateToken(u)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, sessionToken, "")
+	w.Header().Add("Refresh-Token", refreshToken)
+	w.WriteHeader(http.StatusOK)
+	u.Password = ""
+	if err = json.NewEncoder(w).Encode(u); err != nil {
+		return
+	}
+	return
+}
+
+// GetJWKS is the handler function that returns the public keys that session tokens can be verified with
+func (ur *userRouter) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	jwks, err := auth.JWKS()
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(jwks); err != nil {
+		return
+	}
+	return
+}
+
+// GetAPIKeys is the handler function that returns the active api keys of the requesting user
+func (ur *userRouter) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	tokenData, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	keys, err := ur.aService.FindAPIKeys(r.Context(), tokenData.ToUser())
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(apiKeysDTO{APIKeys: keys}); err != nil {
+		return
+	}
+	return
+}
+
+// CreateAPIKey is the handler function that issues a new named and scoped api key for the requesting user
+func (ur *userRouter) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var dto apiKeyRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = r.Body.Close(); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, &dto); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	tokenData, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	key, err := ur.aService.CreateAPIKey(r.Context(), tokenData.ToUser(), dto.toAPIKey())
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(key); err != nil {
+		return
+	}
+	return
+}
+
+// DeleteAPIKey is the handler function that revokes an api key of the requesting user
+func (ur *userRouter) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyId := mux.Vars(r)["keyId"]
+	if !utilities.CheckObjectID(keyId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing keyId"})
+		return
+	}
+	tokenData, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	key, err := ur.aService.RevokeAPIKey(r.Context(), tokenData.ToUser(), keyId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
 	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(key); err != nil {
+		return
+	}
 	return
 }
 
 // SignOut is the handler function that ends a users session
 func (ur *userRouter) SignOut(w http.ResponseWriter, r *http.Request) {
 	authToken := r.Header.Get("Auth-Token")
-	err := ur.aService.BlacklistAuthToken(authToken)
+	err := ur.aService.BlacklistAuthToken(r.Context(), authToken)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
+	if refreshToken := r.Header.Get("Refresh-Token"); refreshToken != "" {
+		err = ur.aService.RevokeRefreshToken(r.Context(), refreshToken)
+		if err != nil {
+			utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+			return
+		}
+	}
 	w = utilities.SetResponseHeaders(w, "", "")
 	w.WriteHeader(http.StatusOK)
 	return
@@ -298,24 +867,34 @@ func (ur *userRouter) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		group.Name = groupName
 		group.Id = utilities.GenerateObjectID()
 		group.RootAdmin = false
-		g, err := ur.gService.GroupCreate(&group)
+		g, err := ur.gService.GroupCreate(r.Context(), &group)
 		if err != nil {
 			utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
 			return
 		}
 		user.Role = "admin"
 		user.GroupId = g.Id
-		u, err := ur.uService.UserCreate(&user)
+		u, err := ur.uService.UserCreate(r.Context(), &user)
 		if err != nil {
 			utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
 			return
 		} else {
-			newToken, err := ur.aService.GenerateToken(u, "session")
+			auditChange(r, "users", nil, u)
+			if err = ur.aService.SendEmailVerification(r.Context(), u); err != nil { // the user can request a new link later on
+				log.Println("email verification error:", err)
+			}
+			newToken, err := ur.aService.GenerateToken(u)
 			if err != nil {
 				utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 				return
 			}
+			refreshToken, err := ur.aService.GenerateRefreshToken(r.Context(), u)
+			if err != nil {
+				utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+				return
+			}
 			w = utilities.SetResponseHeaders(w, newToken, "")
+			w.Header().Add("Refresh-Token", refreshToken)
 			w.WriteHeader(http.StatusCreated)
 			u.Password = ""
 			if err = json.NewEncoder(w).Encode(u); err != nil {
@@ -342,7 +921,7 @@ func (ur *userRouter) CreateUser(w http.ResponseWriter, r *http.Request) {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	decodedToken, err := auth.DecodeJWT(r.Header.Get("Auth-Token"))
+	decodedToken, err := auth.LoadTokenFromRequest(r)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
@@ -352,11 +931,22 @@ func (ur *userRouter) CreateUser(w http.ResponseWriter, r *http.Request) {
 	if user.GroupId == "" {
 		user.GroupId = decodedToken.GroupId
 	}
-	u, err := ur.uService.UserCreate(&user)
+	if user.Role != "" {
+		if err = ur.aService.CanGrantRole(r.Context(), decodedToken.ToUser(), user.Role); err != nil {
+			utilities.RespondWithError(w, http.StatusForbidden, utilities.JWTError{Message: err.Error()})
+			return
+		}
+		if err = ur.aService.ValidateRole(r.Context(), user.GroupId, user.Role); err != nil {
+			utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+			return
+		}
+	}
+	u, err := ur.uService.UserCreate(r.Context(), &user)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
 		return
 	} else {
+		auditChange(r, "users", nil, u)
 		w = utilities.SetResponseHeaders(w, "", "")
 		w.WriteHeader(http.StatusCreated)
 		u.Password = ""
@@ -369,7 +959,7 @@ func (ur *userRouter) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 // GetUsers is the handler that returns a slice of user
 func (ur *userRouter) GetUsers(w http.ResponseWriter, r *http.Request) {
-	decodedToken, err := auth.DecodeJWT(r.Header.Get("Auth-Token"))
+	decodedToken, err := auth.LoadTokenFromRequest(r)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
@@ -377,12 +967,21 @@ func (ur *userRouter) GetUsers(w http.ResponseWriter, r *http.Request) {
 	var filter models.User
 	userScope := decodedToken.GetUsersScope("find")
 	filter.LoadScope(userScope, "find")
-	users, err := ur.uService.UsersFind(&filter)
+	opts, err := models.NewListOptions(r.URL.Query(), models.UserSortFields, models.UserFilterFields)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	users, total, err := ur.uService.UsersFindPage(r.Context(), &filter, opts)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	dto := usersDTO{Users: users}
+	var lastId string
+	if len(users) > 0 {
+		lastId = users[len(users)-1].Id
+	}
+	dto := usersDTO{Users: users, pageDTO: newPageDTO(r, opts, total, len(users), lastId)}
 	dto.clean()
 	w = utilities.SetResponseHeaders(w, "", "")
 	w.WriteHeader(http.StatusOK)
@@ -407,7 +1006,7 @@ func (ur *userRouter) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	filter.LoadScope(userScope, "find")
-	user, err := ur.uService.UserFind(&filter)
+	user, err := ur.uService.UserFind(r.Context(), &filter)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
@@ -421,7 +1020,7 @@ func (ur *userRouter) GetUser(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// DeleteUser is the handler function that deletes a user
+// DeleteUser is the handler function that deletes a user along with its tasks, image and task attachments
 func (ur *userRouter) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userId := vars["userId"]
@@ -430,36 +1029,101 @@ func (ur *userRouter) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	filter := models.User{Id: userId}
-	userScope, err := auth.VerifyUserRequestScope(r, userId, "update")
+	userScope, err := auth.VerifyUserPermissionScope(r, userId, models.PermUsersDelete)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
 		return
 	}
 	filter.LoadScope(userScope, "find")
-	user, err := ur.uService.UserFind(&filter)
+	cur, err := ur.uService.UserFind(r.Context(), &filter)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	err = ur.deleteUserAssets(user)
+	report, err := ur.cService.UserDeleteCascade(r.Context(), &filter)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	user, err = ur.uService.UserDelete(&filter)
+	user := report.User
+	auditChange(r, "users", cur, user)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	user.Password = ""
+	if err = json.NewEncoder(w).Encode(&userDeleteDTO{User: user, deleteReportDTO: newDeleteReportDTO(report)}); err != nil {
+		return
+	}
+}
+
+// RestoreUser is the handler function that restores a soft deleted user along with the tasks and image deleted with it
+func (ur *userRouter) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userId := vars["userId"]
+	if !utilities.CheckObjectID(userId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing userId"})
+		return
+	}
+	filter := models.User{Id: userId}
+	userScope, err := auth.VerifyUserPermissionScope(r, userId, models.PermUsersDelete)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	filter.LoadScope(userScope, "find")
+	user, err := ur.uService.UserRestore(r.Context(), &filter)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
 	}
-	if user.Id != "" {
-		w = utilities.SetResponseHeaders(w, "", "")
-		w.WriteHeader(http.StatusOK)
-		user.Password = ""
-		if err = json.NewEncoder(w).Encode(user); err != nil {
-			return
-		}
+	err = ur.restoreUserAssets(r.Context(), user)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	deleted := *user
+	user.DeletedAt = time.Time{}
+	auditChange(r, "users", &deleted, user)
+	user.Password = ""
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(user); err != nil {
+		return
+	}
+	return
+}
+
+// UnlockUser is the handler function that lifts the sign in lockout of a user
+func (ur *userRouter) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userId := vars["userId"]
+	if !utilities.CheckObjectID(userId) {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing userId"})
+		return
+	}
+	filter := models.User{Id: userId}
+	userScope, err := auth.VerifyUserPermissionScope(r, userId, models.PermUsersUnlock)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	filter.LoadScope(userScope, "find")
+	user, err := ur.uService.UserFind(r.Context(), &filter)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "user not found"})
 		return
 	}
+	user, err = ur.aService.UnlockUser(r.Context(), user)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	user.Password = ""
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(user); err != nil {
+		return
+	}
+	return
 }
 
 // UploadImage allows for a user image to be associated with the User record
@@ -483,7 +1147,7 @@ func (ur *userRouter) UploadImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	filter.LoadScope(userScope, "find")
-	user, err := ur.uService.UserFind(&filter)
+	user, err := ur.uService.UserFind(r.Context(), &filter)
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "user not found"})
 		return
@@ -500,18 +1164,18 @@ func (ur *userRouter) UploadImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if newImage {
-		f, err = ur.fService.FileCreate(f, buf.Bytes())
+		f, err = ur.fService.FileCreate(r.Context(), f, buf.Bytes())
 		if err != nil {
 			utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
 			return
 		}
-		user, err = ur.uService.UserUpdate(&models.User{Id: user.Id, ImageId: user.ImageId})
+		user, err = ur.uService.UserUpdate(r.Context(), &models.User{Id: user.Id, ImageId: user.ImageId})
 		if err != nil {
 			utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
 			return
 		}
 	} else {
-		f, err = ur.fService.FileUpdate(f, buf.Bytes())
+		f, err = ur.fService.FileUpdate(r.Context(), f, buf.Bytes())
 		if err != nil {
 			utilities.RespondWithError(w, http.StatusInternalServerError, utilities.JWTError{Message: err.Error()})
 			return
@@ -541,17 +1205,17 @@ func (ur *userRouter) GetImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	filter.LoadScope(userScope, "find")
-	user, err := ur.uService.UserFind(&filter)
+	user, err := ur.uService.UserFind(r.Context(), &filter)
 	if err != nil || !user.CheckID("image_id") {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: "user image not found"})
 		return
 	}
-	file, err := ur.fService.FileFind(&models.File{Id: user.ImageId})
+	file, err := ur.fService.FileFind(r.Context(), &models.File{Id: user.ImageId})
 	if err != nil || file.OwnerType != "user" || file.OwnerId != user.Id {
 		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: "unauthorized"})
 		return
 	}
-	contents, err := ur.fService.RetrieveFile(&models.File{GridFSId: file.GridFSId})
+	contents, err := ur.fService.RetrieveFile(r.Context(), &models.File{GridFSId: file.GridFSId})
 	if err != nil {
 		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
 		return
@@ -564,36 +1228,18 @@ func (ur *userRouter) GetImage(w http.ResponseWriter, r *http.Request) {
 	http.ServeContent(w, r, file.Name, modTime, contentReader)
 }
 
-// deleteUserAssets asynchronously gets a group and its users from the database
-func (ur *userRouter) deleteUserAssets(user *models.User) error {
+// restoreUserAssets restores the tasks, image and task attachments of a user that were deleted along with it
+func (ur *userRouter) restoreUserAssets(ctx context.Context, user *models.User) error {
 	if !user.CheckID("id") {
-		return errors.New("filter id cannot be empty for mass delete")
-	}
-	gErrCh := make(chan error)
-	uErrCh := make(chan error)
-	go func() {
-		if user.CheckID("image_id") {
-			_, err := ur.fService.FileDelete(&models.File{OwnerId: user.Id, OwnerType: "user"})
-			gErrCh <- err
-		} else {
-			gErrCh <- nil
-		}
-	}()
-	go func() {
-		_, err := ur.tService.TaskDeleteMany(&models.Task{UserId: user.Id})
-		uErrCh <- err
-	}()
-	for i := 0; i < 2; i++ {
-		select {
-		case gErr := <-gErrCh:
-			if gErr != nil {
-				return gErr
-			}
-		case uErr := <-uErrCh:
-			if uErr != nil {
-				return uErr
-			}
-		}
+		return errors.New("filter id cannot be empty for mass restore")
+	}
+	err := ur.tService.TaskRestoreMany(ctx, &models.Task{UserId: user.Id}, user.DeletedAt)
+	if err != nil {
+		return err
+	}
+	tasks, err := ur.tService.TasksFind(ctx, &models.Task{UserId: user.Id})
+	if err != nil {
+		return err
 	}
-	return nil
+	return ur.fService.FileRestoreMany(ctx, append(models.UsersToFiles([]*models.User{user}), models.TasksToFiles(tasks)...), user.DeletedAt)
 }
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/JECSand/go-rest-api-boilerplate/auth"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"github.com/JECSand/go-rest-api-boilerplate/services"
+	"github.com/JECSand/go-rest-api-boilerplate/utilities"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+)
+
+type webhookRouter struct {
+	aService  *services.TokenService
+	whService services.WebhookService
+}
+
+// NewWebhookRouter is a function that initializes a new webhookRouter struct
+func NewWebhookRouter(router *mux.Router, a *services.TokenService, wh services.WebhookService) *mux.Router {
+	wRouter := webhookRouter{a, wh}
+	router.HandleFunc("/webhooks", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/webhooks", a.RequirePermission(models.PermWebhooksManage, wRouter.GetWebhooks)).Methods("GET")
+	router.HandleFunc("/webhooks", a.RequirePermission(models.PermWebhooksManage, wRouter.CreateWebhook)).Methods("POST")
+	router.HandleFunc("/webhooks/{webhookId}", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/webhooks/{webhookId}", a.RequirePermission(models.PermWebhooksManage, wRouter.GetWebhook)).Methods("GET")
+	router.HandleFunc("/webhooks/{webhookId}", a.RequirePermission(models.PermWebhooksManage, wRouter.ModifyWebhook)).Methods("PATCH")
+	router.HandleFunc("/webhooks/{webhookId}", a.RequirePermission(models.PermWebhooksManage, wRouter.DeleteWebhook)).Methods("DELETE")
+	router.HandleFunc("/webhooks/{webhookId}/deliveries", utilities.HandleOptionsRequest).Methods("OPTIONS")
+	router.HandleFunc("/webhooks/{webhookId}/deliveries", a.RequirePermission(models.PermWebhooksManage, wRouter.GetWebhookDeliveries)).Methods("GET")
+	return router
+}
+
+// readWebhook decodes a Webhook from a http request body
+func readWebhook(r *http.Request) (*models.Webhook, error) {
+	var webhook models.Webhook
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		return nil, err
+	}
+	if err = r.Body.Close(); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(body, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// scopedWebhook returns the Webhook of the webhookId of a request, scoped to the requester's group unless they are a
+// root admin
+func scopedWebhook(r *http.Request) (*models.Webhook, error) {
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Webhook{Id: mux.Vars(r)["webhookId"], GroupId: decodedToken.GetGroupsScope().Id}, nil
+}
+
+// GetWebhooks is the handler function that returns the webhooks of a group, without their secrets
+func (wr *webhookRouter) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	groupId, err := roleGroupScope(r, r.URL.Query().Get("group_id"))
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	webhooks, err := wr.whService.WebhooksFind(r.Context(), &models.Webhook{GroupId: groupId})
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusServiceUnavailable, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&webhooksDTO{Webhooks: webhooks}); err != nil {
+		return
+	}
+}
+
+// CreateWebhook is the handler function that registers a new webhook for a group, the response is the only one to
+// include the webhook's secret
+func (wr *webhookRouter) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := readWebhook(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	webhook.GroupId, err = roleGroupScope(r, webhook.GroupId)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	decodedToken, err := auth.LoadTokenFromRequest(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	webhook.Id = ""
+	webhook.UserId = decodedToken.UserId
+	webhook, err = wr.whService.WebhookCreate(r.Context(), webhook)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "webhooks", nil, webhook)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(webhook); err != nil {
+		return
+	}
+}
+
+// GetWebhook is the handler function that returns a webhook, without its secret
+func (wr *webhookRouter) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	filter, err := scopedWebhook(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if !filter.CheckID("id") {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing webhookId"})
+		return
+	}
+	webhook, err := wr.whService.WebhookFind(r.Context(), filter)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(webhook); err != nil {
+		return
+	}
+}
+
+// ModifyWebhook is the handler function that changes the url or the events of a webhook
+func (wr *webhookRouter) ModifyWebhook(w http.ResponseWriter, r *http.Request) {
+	filter, err := scopedWebhook(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if !filter.CheckID("id") {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing webhookId"})
+		return
+	}
+	webhook, err := readWebhook(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	cur, err := wr.whService.WebhookFind(r.Context(), filter)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	webhook.Id, webhook.GroupId = filter.Id, filter.GroupId
+	webhook, err = wr.whService.WebhookUpdate(r.Context(), webhook)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "webhooks", cur, webhook)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusAccepted)
+	if err = json.NewEncoder(w).Encode(webhook); err != nil {
+		return
+	}
+}
+
+// DeleteWebhook is the handler function that deletes a webhook, its pending deliveries are no longer attempted
+func (wr *webhookRouter) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	filter, err := scopedWebhook(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if !filter.CheckID("id") {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing webhookId"})
+		return
+	}
+	webhook, err := wr.whService.WebhookDelete(r.Context(), filter)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	auditChange(r, "webhooks", webhook, nil)
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(webhook); err != nil {
+		return
+	}
+}
+
+// GetWebhookDeliveries is the handler function that returns a page of the delivery log of a webhook
+func (wr *webhookRouter) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	filter, err := scopedWebhook(r)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusUnauthorized, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if !filter.CheckID("id") {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: "missing webhookId"})
+		return
+	}
+	opts, err := models.NewListOptions(r.URL.Query(), models.WebhookDeliverySortFields, models.WebhookDeliveryFilterFields)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	if err = opts.LoadRanges(r.URL.Query(), models.WebhookDeliveryRangeFields); err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	webhook, err := wr.whService.WebhookFind(r.Context(), filter)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusNotFound, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	deliveries, total, err := wr.whService.WebhookDeliveriesFindPage(r.Context(), &models.WebhookDelivery{WebhookId: webhook.Id}, opts)
+	if err != nil {
+		utilities.RespondWithError(w, http.StatusBadRequest, utilities.JWTError{Message: err.Error()})
+		return
+	}
+	var lastId string
+	if len(deliveries) > 0 {
+		lastId = deliveries[len(deliveries)-1].Id
+	}
+	w = utilities.SetResponseHeaders(w, "", "")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(&webhookDeliveriesDTO{Deliveries: deliveries, pageDTO: newPageDTO(r, opts, total, len(deliveries), lastId)}); err != nil {
+		return
+	}
+}
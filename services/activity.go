@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
+)
+
+// ActivityService is an interface used to build the activity feed of a group
+type ActivityService interface {
+	ActivityFind(ctx context.Context, groupId string, before time.Time, limit int64) ([]*models.Activity, error)
+}
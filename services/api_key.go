@@ -0,0 +1,14 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// APIKeyService is an interface used to manage the relevant api key doc controllers
+type APIKeyService interface {
+	APIKeyCreate(ctx context.Context, k *models.APIKey) (*models.APIKey, error)
+	APIKeysFind(ctx context.Context, k *models.APIKey) ([]*models.APIKey, error)
+	APIKeyDelete(ctx context.Context, k *models.APIKey) (*models.APIKey, error)
+	APIKeyAuthenticate(ctx context.Context, key string) (*models.APIKey, error)
+}
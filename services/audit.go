@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// AuditService is an interface used to record and query the audit log
+type AuditService interface {
+	AuditEventCreate(ctx context.Context, e *models.AuditEvent) (*models.AuditEvent, error)
+	AuditEventsFindPage(ctx context.Context, e *models.AuditEvent, o *models.ListOptions) ([]*models.AuditEvent, int64, error)
+}
@@ -1,7 +1,9 @@
 package services
 
+import "context"
+
 // BlacklistService is an interface used to manage the relevant group doc controllers
 type BlacklistService interface {
-	BlacklistAuthToken(authToken string) error
-	CheckTokenBlacklist(authToken string) bool
+	BlacklistAuthToken(ctx context.Context, authToken string) error
+	CheckTokenBlacklist(ctx context.Context, authToken string) bool
 }
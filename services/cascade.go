@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// CascadeService is an interface used to delete groups and users along with the records that belong to them
+type CascadeService interface {
+	GroupDeleteCascade(ctx context.Context, g *models.Group) (*models.DeleteReport, error)
+	UserDeleteCascade(ctx context.Context, u *models.User) (*models.DeleteReport, error)
+}
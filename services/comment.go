@@ -0,0 +1,15 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// CommentService is an interface used to manage the relevant comment doc controllers
+type CommentService interface {
+	CommentCreate(ctx context.Context, c *models.Comment) (*models.Comment, error)
+	CommentsFind(ctx context.Context, c *models.Comment) ([]*models.Comment, error)
+	CommentFind(ctx context.Context, c *models.Comment) (*models.Comment, error)
+	CommentUpdate(ctx context.Context, c *models.Comment) (*models.Comment, error)
+	CommentDelete(ctx context.Context, c *models.Comment) (*models.Comment, error)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"sync"
+)
+
+// defaultEventBuffer is how many Events a subscriber of a MemoryEventBroker can fall behind before it is unsubscribed
+const defaultEventBuffer = 64
+
+// EventFilter selects the Events a subscriber of an EventBroker receives
+type EventFilter func(e *models.Event) bool
+
+// EventBroker fans the Events emitted by the services out to the event streams of the API. The MemoryEventBroker fans
+// out the Events of its own API replica, a broker backed by MongoDB change streams can fan out those of every replica
+type EventBroker interface {
+	Publish(e *models.Event)
+	Subscribe(filter EventFilter) (<-chan *models.Event, func())
+}
+
+// eventSubscription is a subscriber of a MemoryEventBroker
+type eventSubscription struct {
+	filter EventFilter
+	events chan *models.Event
+}
+
+// MemoryEventBroker is an in-process EventBroker
+type MemoryEventBroker struct {
+	mu            sync.Mutex
+	subscriptions map[*eventSubscription]struct{}
+	buffer        int
+}
+
+// NewMemoryEventBroker is an exported function used to initialize a new MemoryEventBroker struct, buffer is how many
+// Events a subscriber can fall behind
+func NewMemoryEventBroker(buffer int) *MemoryEventBroker {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+	return &MemoryEventBroker{subscriptions: make(map[*eventSubscription]struct{}), buffer: buffer}
+}
+
+// Publish sends an Event to every subscriber whose filter selects it without waiting on them. A subscriber that fell
+// a full buffer behind is unsubscribed and its channel closed, so its stream ends rather than silently missing Events
+func (b *MemoryEventBroker) Publish(e *models.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subscriptions {
+		if s.filter != nil && !s.filter(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			delete(b.subscriptions, s)
+			close(s.events)
+		}
+	}
+}
+
+// Subscribe returns a channel of the Events selected by a filter, a nil filter selects every Event, along with the
+// function that unsubscribes it
+func (b *MemoryEventBroker) Subscribe(filter EventFilter) (<-chan *models.Event, func()) {
+	s := &eventSubscription{filter: filter, events: make(chan *models.Event, b.buffer)}
+	b.mu.Lock()
+	b.subscriptions[s] = struct{}{}
+	b.mu.Unlock()
+	return s.events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscriptions[s]; ok {
+			delete(b.subscriptions, s)
+			close(s.events)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// FeedTokenService is an interface used to manage the relevant feed token doc controllers
+type FeedTokenService interface {
+	FeedTokenCreate(ctx context.Context, f *models.FeedToken) (*models.FeedToken, error)
+	FeedTokensFind(ctx context.Context, f *models.FeedToken) ([]*models.FeedToken, error)
+	FeedTokenDelete(ctx context.Context, f *models.FeedToken) (*models.FeedToken, error)
+	FeedTokenAuthenticate(ctx context.Context, token string, feedType string, feedId string) (*models.FeedToken, error)
+}
@@ -2,16 +2,20 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
 )
 
 // FileService is an interface used to manage the relevant file doc controllers
 type FileService interface {
-	FileCreate(g *models.File, content []byte) (*models.File, error)
-	FileFind(g *models.File) (*models.File, error)
-	FilesFind(g *models.File) ([]*models.File, error)
-	FileDelete(g *models.File) (*models.File, error)
-	FileDeleteMany(g []*models.File) error
-	FileUpdate(g *models.File, content []byte) (*models.File, error)
-	RetrieveFile(g *models.File) (*bytes.Buffer, error)
+	FileCreate(ctx context.Context, g *models.File, content []byte) (*models.File, error)
+	FileFind(ctx context.Context, g *models.File) (*models.File, error)
+	FilesFind(ctx context.Context, g *models.File) ([]*models.File, error)
+	FileDelete(ctx context.Context, g *models.File) (*models.File, error)
+	FileDeleteMany(ctx context.Context, g []*models.File) error
+	FileRestoreMany(ctx context.Context, g []*models.File, since time.Time) error
+	FilesPurge(ctx context.Context, before time.Time) (int64, error)
+	FileUpdate(ctx context.Context, g *models.File, content []byte) (*models.File, error)
+	RetrieveFile(ctx context.Context, g *models.File) (*bytes.Buffer, error)
 }
@@ -1,14 +1,23 @@
 package services
 
-import "github.com/JECSand/go-rest-api-boilerplate/models"
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
+)
 
 // GroupService is an interface used to manage the relevant group doc controllers
 type GroupService interface {
-	GroupCreate(g *models.Group) (*models.Group, error)
-	GroupFind(g *models.Group) (*models.Group, error)
-	GroupsFind(g *models.Group) ([]*models.Group, error)
-	GroupDelete(g *models.Group) (*models.Group, error)
-	GroupDeleteMany(g *models.Group) (*models.Group, error)
-	GroupUpdate(g *models.Group) (*models.Group, error)
-	GroupDocInsert(g *models.Group) (*models.Group, error)
+	GroupCreate(ctx context.Context, g *models.Group) (*models.Group, error)
+	GroupFind(ctx context.Context, g *models.Group) (*models.Group, error)
+	GroupsFind(ctx context.Context, g *models.Group) ([]*models.Group, error)
+	GroupsFindPage(ctx context.Context, g *models.Group, o *models.ListOptions) ([]*models.Group, int64, error)
+	GroupDelete(ctx context.Context, g *models.Group) (*models.Group, error)
+	GroupDeleteMany(ctx context.Context, g *models.Group) (*models.Group, error)
+	GroupRestore(ctx context.Context, g *models.Group) (*models.Group, error)
+	GroupsPurge(ctx context.Context, before time.Time) (int64, error)
+	GroupUpdate(ctx context.Context, g *models.Group) (*models.Group, error)
+	GroupRequire2FA(ctx context.Context, g *models.Group) (*models.Group, error)
+	GroupSetTaskWorkflow(ctx context.Context, g *models.Group) (*models.Group, error)
+	GroupDocInsert(ctx context.Context, g *models.Group) (*models.Group, error)
 }
@@ -0,0 +1,13 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// IdentityService is an interface used to manage the relevant identity doc controllers
+type IdentityService interface {
+	IdentityCreate(ctx context.Context, i *models.Identity) (*models.Identity, error)
+	IdentitiesFind(ctx context.Context, i *models.Identity) ([]*models.Identity, error)
+	IdentityFind(ctx context.Context, i *models.Identity) (*models.Identity, error)
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
+)
+
+// InvitationService is an interface used to manage the relevant invitation doc controllers
+type InvitationService interface {
+	InvitationCreate(ctx context.Context, i *models.Invitation) (*models.Invitation, error)
+	InvitationsFind(ctx context.Context, i *models.Invitation) ([]*models.Invitation, error)
+	InvitationFind(ctx context.Context, i *models.Invitation) (*models.Invitation, error)
+	InvitationRenew(ctx context.Context, i *models.Invitation, expiresAt time.Time) (*models.Invitation, error)
+	InvitationRevoke(ctx context.Context, i *models.Invitation) (*models.Invitation, error)
+	InvitationRedeem(ctx context.Context, token string) (*models.Invitation, error)
+}
@@ -0,0 +1,13 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// LoginAttemptService is an interface used to manage the relevant login attempt doc controllers
+type LoginAttemptService interface {
+	LoginAttemptFind(ctx context.Context, key string) (*models.LoginAttempt, error)
+	LoginAttemptFail(ctx context.Context, key string, policy *models.LockoutPolicy) (*models.LoginAttempt, error)
+	LoginAttemptReset(ctx context.Context, key string) error
+}
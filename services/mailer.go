@@ -0,0 +1,6 @@
+package services
+
+// Mailer is an interface used to deliver emails such as password reset and email verification links
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
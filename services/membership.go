@@ -0,0 +1,15 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// MembershipService is an interface used to manage the relevant membership doc controllers
+type MembershipService interface {
+	MembershipCreate(ctx context.Context, m *models.Membership) (*models.Membership, error)
+	MembershipsFind(ctx context.Context, m *models.Membership) ([]*models.Membership, error)
+	MembershipFind(ctx context.Context, m *models.Membership) (*models.Membership, error)
+	MembershipUpdate(ctx context.Context, m *models.Membership) (*models.Membership, error)
+	MembershipDelete(ctx context.Context, m *models.Membership) (*models.Membership, error)
+}
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
+)
+
+// RefreshTokenService is an interface used to manage the relevant refresh token doc controllers
+type RefreshTokenService interface {
+	RefreshTokenCreate(ctx context.Context, rt *models.RefreshToken) (*models.RefreshToken, error)
+	RefreshTokenRotate(ctx context.Context, token string, expiresAt time.Time) (*models.RefreshToken, error)
+	RefreshTokenRevoke(ctx context.Context, token string) error
+}
@@ -0,0 +1,15 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// RoleService is an interface used to manage the relevant role doc controllers
+type RoleService interface {
+	RoleCreate(ctx context.Context, r *models.Role) (*models.Role, error)
+	RolesFind(ctx context.Context, r *models.Role) ([]*models.Role, error)
+	RoleFind(ctx context.Context, r *models.Role) (*models.Role, error)
+	RoleUpdate(ctx context.Context, r *models.Role) (*models.Role, error)
+	RoleDelete(ctx context.Context, r *models.Role) (*models.Role, error)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// Clock tells the current time, the TaskScheduler reads the time from a Clock so tests can control it
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock of the system time
+type systemClock struct{}
+
+// Now returns the current system time in UTC
+func (systemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// SystemClock is the Clock the application runs with
+var SystemClock Clock = systemClock{}
+
+// defaultSchedulerInterval is how often the TaskScheduler runs when SCHEDULER_INTERVAL is not configured
+const defaultSchedulerInterval = time.Minute
+
+// SchedulerInterval returns how often the TaskScheduler looks for due task series
+func SchedulerInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("SCHEDULER_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return defaultSchedulerInterval
+	}
+	return interval
+}
+
+// TaskScheduler creates the next instances of the task series that are generated on schedule. Every API replica
+// can run one, each occurrence is claimed by exactly one scheduler
+type TaskScheduler struct {
+	sService TaskSeriesService
+	clock    Clock
+	interval time.Duration
+}
+
+// NewTaskScheduler is an exported function used to initialize a new TaskScheduler struct
+func NewTaskScheduler(sService TaskSeriesService, clock Clock, interval time.Duration) *TaskScheduler {
+	return &TaskScheduler{sService, clock, interval}
+}
+
+// Run creates the next instance of every task series whose current instance is due, it returns how many it created
+func (s *TaskScheduler) Run(ctx context.Context) (int, error) {
+	tasks, err := s.sService.TaskSeriesRunDue(ctx, s.clock.Now())
+	return len(tasks), err
+}
+
+// Start runs the TaskScheduler every interval until ctx is done, which also cancels a run in progress
+func (s *TaskScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Run(ctx); err != nil {
+				log.Println("task scheduler error:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// SearchService is an interface used to search the tasks, users and groups within the scope of a User
+type SearchService interface {
+	Search(ctx context.Context, query string, scope *models.User, limit int64) ([]*models.SearchHit, error)
+}
@@ -1,14 +1,27 @@
 package services
 
-import "github.com/JECSand/go-rest-api-boilerplate/models"
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
+)
 
 // TaskService is an interface used to manage the relevant task doc controllers
 type TaskService interface {
-	TaskCreate(g *models.Task) (*models.Task, error)
-	TaskFind(g *models.Task) (*models.Task, error)
-	TasksFind(g *models.Task) ([]*models.Task, error)
-	TaskDelete(g *models.Task) (*models.Task, error)
-	TaskDeleteMany(g *models.Task) (*models.Task, error)
-	TaskUpdate(g *models.Task) (*models.Task, error)
-	TaskDocInsert(g *models.Task) (*models.Task, error)
+	TaskCreate(ctx context.Context, g *models.Task) (*models.Task, error)
+	TaskFind(ctx context.Context, g *models.Task) (*models.Task, error)
+	TasksFind(ctx context.Context, g *models.Task) ([]*models.Task, error)
+	TasksFindPage(ctx context.Context, g *models.Task, o *models.ListOptions) ([]*models.Task, int64, error)
+	TasksFindVisiblePage(ctx context.Context, g *models.Task, o *models.ListOptions) ([]*models.Task, int64, error)
+	TasksFindCalendar(ctx context.Context, g *models.Task) ([]*models.Task, error)
+	TaskDelete(ctx context.Context, g *models.Task) (*models.Task, error)
+	TaskDeleteMany(ctx context.Context, g *models.Task) (*models.Task, error)
+	TaskRestore(ctx context.Context, g *models.Task) (*models.Task, error)
+	TaskRestoreMany(ctx context.Context, g *models.Task, since time.Time) error
+	TasksPurge(ctx context.Context, before time.Time) (int64, error)
+	TaskUpdate(ctx context.Context, g *models.Task, actorId string, clear ...string) (*models.Task, error)
+	TaskHistoryFind(ctx context.Context, g *models.Task) ([]*models.TaskHistory, error)
+	TaskSubtree(ctx context.Context, g *models.Task) ([]*models.TaskTree, error)
+	TaskDependencies(ctx context.Context, g *models.Task) (*models.TaskGraph, error)
+	TaskDocInsert(ctx context.Context, g *models.Task) (*models.Task, error)
 }
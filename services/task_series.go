@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
+)
+
+// TaskSeriesService is an interface used to manage the relevant task series doc controllers
+type TaskSeriesService interface {
+	TaskSeriesSet(ctx context.Context, g *models.TaskSeries) (*models.TaskSeries, error)
+	TaskSeriesFind(ctx context.Context, g *models.TaskSeries) (*models.TaskSeries, error)
+	TaskSeriesStop(ctx context.Context, g *models.TaskSeries) (*models.TaskSeries, error)
+	TaskSeriesSkip(ctx context.Context, g *models.TaskSeries, now time.Time) (*models.TaskSeries, error)
+	TaskSeriesComplete(ctx context.Context, g *models.Task, now time.Time) (*models.Task, error)
+	TaskSeriesRunDue(ctx context.Context, now time.Time) ([]*models.Task, error)
+}
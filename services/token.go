@@ -1,84 +1,288 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"github.com/JECSand/go-rest-api-boilerplate/auth"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
 	"github.com/JECSand/go-rest-api-boilerplate/utilities"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // TokenService is used by the app to manage db auth functionality
 type TokenService struct {
-	uService UserService
-	gService GroupService
-	bService BlacklistService
+	uService  UserService
+	gService  GroupService
+	bService  BlacklistService
+	rService  RefreshTokenService
+	kService  APIKeyService
+	tService  UserTokenService
+	lService  LoginAttemptService
+	roService RoleService
+	mService  MembershipService
+	iService  InvitationService
+	idService IdentityService
+	mailer    Mailer
 }
 
 // NewTokenService is an exported function used to initialize a new authService struct
-func NewTokenService(uService UserService, gService GroupService, bService BlacklistService) *TokenService {
-	return &TokenService{uService, gService, bService}
+func NewTokenService(uService UserService, gService GroupService, bService BlacklistService, rService RefreshTokenService, kService APIKeyService, tService UserTokenService, lService LoginAttemptService, roService RoleService, mService MembershipService, iService InvitationService, idService IdentityService, mailer Mailer) *TokenService {
+	return &TokenService{uService, gService, bService, rService, kService, tService, lService, roService, mService, iService, idService, mailer}
 }
 
-// verifyTokenUser verifies Token's User
-func (a *TokenService) verifyTokenUser(decodedToken *auth.TokenData) (bool, string) {
+// ErrLoginLocked is returned when a sign in is refused because the account or client ip address is locked out
+var ErrLoginLocked = errors.New("too many failed sign in attempts, try again later")
+
+const (
+	challengeTokenTTL    = time.Minute * 5  // how long a User has to complete 2FA after signing in
+	maxChallengeFailures = 5                // wrong 2FA codes after which a challenge token is invalidated
+	oidcStateTTL         = time.Minute * 10 // how long a User has to sign in with an identity provider
+	recoveryCodeCount    = 10
+	passwordResetTTL     = time.Hour * 1
+	emailVerificationTTL = time.Hour * 48
+	invitationTTL        = time.Hour * 24 * 7  // default lifetime of an invitation
+	maxInvitationTTL     = time.Hour * 24 * 30 // longest an invitation can be valid for
+	maxLoginLockout      = time.Hour * 24      // longest an account or ip address is locked out for
+	loginAttemptWindow   = time.Hour * 24      // how long failed sign ins are remembered for
+	ipAttemptFactor      = 4                   // an ip address is allowed this many times the failed sign ins of an account
+)
+
+// loginLockoutPolicy returns the LockoutPolicy for failed sign ins to an account
+func loginLockoutPolicy() *models.LockoutPolicy {
+	maxAttempts, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS"))
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	lockout, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT"))
+	if err != nil || lockout <= 0 || lockout > maxLoginLockout {
+		lockout = time.Minute
+	}
+	return &models.LockoutPolicy{
+		MaxAttempts: maxAttempts,
+		Lockout:     lockout,
+		MaxLockout:  maxLoginLockout,
+		Window:      loginAttemptWindow,
+	}
+}
+
+// accountLoginKey returns the LoginAttempt key of an account, accounts are tracked by email whether they exist or not
+func accountLoginKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// challengeLoginKey returns the LoginAttempt key of a 2FA challenge token, only the hash of the token is stored
+func challengeLoginKey(challengeToken string) string {
+	return "challenge:" + models.HashToken(challengeToken)
+}
+
+// ipLoginKey returns the LoginAttempt key of a client ip address
+func ipLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// appURL returns the base url of the front end that emailed links point to
+func appURL() string {
+	return strings.TrimRight(os.Getenv("APP_URL"), "/")
+}
+
+// totpIssuer returns the issuer name that is shown next to TOTP codes in authenticator apps
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "go-rest-api"
+}
+
+// refreshTokenTTL returns how long a refresh token can go unused before it expires
+func refreshTokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_TTL"))
+	if err != nil || ttl <= 0 {
+		return time.Hour * 720 // Default 30 day expiration for refresh tokens
+	}
+	return ttl
+}
+
+// verifyTokenUser verifies Token's User, returning the User as a member of the Token's Group along with the Group
+func (a *TokenService) verifyTokenUser(ctx context.Context, decodedToken *auth.TokenData) (*models.User, *models.Group, error) {
 	tUser := decodedToken.ToUser()
-	checkUser, err := a.uService.UserFind(tUser)
+	checkUser, err := a.uService.UserFind(ctx, &models.User{Id: tUser.Id})
+	if err != nil {
+		return nil, nil, err
+	}
+	checkGroup, err := a.gService.GroupFind(ctx, &models.Group{Id: tUser.GroupId})
+	if err != nil {
+		return nil, nil, err
+	}
+	// validate that the User belongs to the Token's Group, either as its own group or through a membership
+	sessionUser, err := a.SessionUser(ctx, checkUser, checkGroup.Id)
+	if err != nil {
+		return nil, nil, errors.New("Incorrect group id")
+	}
+	return sessionUser, checkGroup, nil
+}
+
+// SessionUser returns a copy of a User acting as a member of a group, with the role it was given in the group
+// The User's own group is used when groupId is empty
+func (a *TokenService) SessionUser(ctx context.Context, u *models.User, groupId string) (*models.User, error) {
+	if groupId == "" || groupId == u.GroupId {
+		return u, nil
+	}
+	m, err := a.mService.MembershipFind(ctx, &models.Membership{UserId: u.Id, GroupId: groupId})
+	if err != nil {
+		return nil, errors.New("user is not a member of the group")
+	}
+	session := *u
+	session.GroupId = m.GroupId
+	session.Role = m.Role
+	return &session, nil
+}
+
+// userGroups returns the ids of every group a User belongs to, either as its own group or through a membership
+func (a *TokenService) userGroups(ctx context.Context, u *models.User) []string {
+	groups := []string{u.GroupId}
+	memberships, err := a.mService.MembershipsFind(ctx, &models.Membership{UserId: u.Id})
+	if err != nil {
+		return groups
+	}
+	for _, m := range memberships {
+		groups = append(groups, m.GroupId)
+	}
+	return groups
+}
+
+// requires2FA determines whether a request must be blocked until the User enables 2FA as required by its Group
+// The /auth routes remain available so the User can enroll
+func requires2FA(u *models.User, g *models.Group, r *http.Request) bool {
+	if !g.Require2FA || u.TwoFactor {
+		return false
+	}
+	return r.URL.Path != "/auth" && !strings.HasPrefix(r.URL.Path, "/auth/")
+}
+
+// requestScope returns the api key scope needed for a http request, e.g. tasks:read for GET /tasks/{taskId}
+// Requests outside the tasks, users and groups resources return an empty scope that no api key is granted
+func requestScope(r *http.Request) string {
+	resource := strings.SplitN(strings.Trim(r.URL.Path, "/"), "/", 2)[0]
+	if resource != "tasks" && resource != "users" && resource != "groups" {
+		return ""
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return resource + ":read"
+	}
+	return resource + ":write"
+}
+
+// verifyAPIKey authenticates an API-Key header and returns TokenData for the User the api key belongs to, acting in
+// the group the api key was issued for
+func (a *TokenService) verifyAPIKey(key string, r *http.Request) (*auth.TokenData, error) {
+	apiKey, err := a.kService.APIKeyAuthenticate(r.Context(), key)
 	if err != nil {
-		return false, err.Error()
+		return nil, err
 	}
-	checkGroup, err := a.gService.GroupFind(&models.Group{Id: tUser.GroupId})
+	scope := requestScope(r)
+	if !apiKey.HasScope(scope) {
+		if scope == "" {
+			return nil, errors.New("api keys can not access this resource")
+		}
+		return nil, errors.New("api key is missing the " + scope + " scope")
+	}
+	user, err := a.uService.UserFind(r.Context(), &models.User{Id: apiKey.UserId})
 	if err != nil {
-		return false, err.Error()
+		return nil, err
+	}
+	user, err = a.SessionUser(r.Context(), user, apiKey.GroupId)
+	if err != nil {
+		return nil, errors.New("api key was issued for a group its user no longer belongs to")
+	}
+	return auth.InitUserToken(user)
+}
+
+// permissions resolves the permissions a User is granted by its role, root admins and group admins are granted every
+// permission while members, along with users whose custom role no longer exists, are granted none
+func (a *TokenService) permissions(ctx context.Context, u *models.User) []string {
+	if u.RootAdmin || u.Role == models.AdminRole {
+		return models.Permissions
+	}
+	if u.Role == "" || u.Role == models.MemberRole {
+		return nil
 	}
-	// validate the Group id of the User and the associated User's Group
-	if checkUser.GroupId != checkGroup.Id {
-		return false, "Incorrect group id"
+	role, err := a.roService.RoleFind(ctx, &models.Role{GroupId: u.GroupId, Name: u.Role})
+	if err != nil {
+		return nil
 	}
-	return true, "No Error"
+	return role.Permissions
 }
 
 // tokenVerifyMiddleWare inputs the route handler function along with User roleType to verify User token and permissions
-func (a *TokenService) tokenVerifyMiddleWare(roleType string, next http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+// Requests without an Auth-Token can instead authenticate with a scoped API-Key
+// When a permission is set, the User's role must also grant it
+func (a *TokenService) tokenVerifyMiddleWare(roleType string, permission string, next http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
 	var errorObject utilities.JWTError
+	var decodedToken *auth.TokenData
+	var err error
 	authToken := r.Header.Get("Auth-Token")
-	if a.bService.CheckTokenBlacklist(authToken) {
-		errorObject.Message = "Invalid Token"
-		utilities.RespondWithError(w, http.StatusUnauthorized, errorObject)
-		return
+	if apiKey := r.Header.Get("API-Key"); authToken == "" && apiKey != "" {
+		decodedToken, err = a.verifyAPIKey(apiKey, r)
+		if err != nil {
+			errorObject.Message = err.Error()
+			utilities.RespondWithError(w, http.StatusUnauthorized, errorObject)
+			return
+		}
+	} else {
+		if a.bService.CheckTokenBlacklist(r.Context(), authToken) {
+			errorObject.Message = "Invalid Token"
+			utilities.RespondWithError(w, http.StatusUnauthorized, errorObject)
+			return
+		}
+		decodedToken, err = auth.DecodeJWT(authToken)
+		if err != nil {
+			errorObject.Message = err.Error()
+			utilities.RespondWithError(w, http.StatusUnauthorized, errorObject)
+			return
+		}
 	}
-	decodedToken, err := auth.DecodeJWT(r.Header.Get("Auth-Token"))
+	checkUser, checkGroup, err := a.verifyTokenUser(r.Context(), decodedToken)
 	if err != nil {
 		errorObject.Message = err.Error()
 		utilities.RespondWithError(w, http.StatusUnauthorized, errorObject)
 		return
 	}
-	verified, verifyMsg := a.verifyTokenUser(decodedToken)
-	if verified {
-		if roleType == "Root" && decodedToken.RootAdmin {
-			next.ServeHTTP(w, r)
-		} else if roleType == "Admin" && decodedToken.Role == "admin" {
-			next.ServeHTTP(w, r)
-		} else if roleType == "Member" {
-			next.ServeHTTP(w, r)
-		} else {
-			errorObject.Message = "Invalid Token"
-			utilities.RespondWithError(w, http.StatusUnauthorized, errorObject)
-			return
-		}
+	if requires2FA(checkUser, checkGroup, r) {
+		errorObject.Message = "two-factor authentication is required by your group"
+		utilities.RespondWithError(w, http.StatusForbidden, errorObject)
+		return
+	}
+	// the User's current role is used so that role changes take effect without a new token
+	decodedToken.Role = checkUser.Role
+	decodedToken.Permissions = a.permissions(r.Context(), checkUser)
+	decodedToken.Groups = a.userGroups(r.Context(), checkUser)
+	r = auth.WithTokenData(r, decodedToken)
+	if permission != "" && !decodedToken.HasPermission(permission) {
+		errorObject.Message = "missing the " + permission + " permission"
+		utilities.RespondWithError(w, http.StatusForbidden, errorObject)
+		return
+	}
+	if roleType == "Root" && decodedToken.RootAdmin {
+		next.ServeHTTP(w, r)
+	} else if roleType == "Admin" && decodedToken.Role == models.AdminRole {
+		next.ServeHTTP(w, r)
+	} else if roleType == "Member" {
+		next.ServeHTTP(w, r)
 	} else {
-		errorObject.Message = verifyMsg
+		errorObject.Message = "Invalid Token"
 		utilities.RespondWithError(w, http.StatusUnauthorized, errorObject)
 		return
 	}
 }
 
-// GenerateToken outputs an auth token string for an inputted User
-func (a *TokenService) GenerateToken(u *models.User, tType string) (string, error) {
-	expDT := time.Now().Add(time.Hour * 1).Unix() // Default 1 hour expiration for session token
-	if tType == "api" {
-		expDT = time.Now().Add(time.Hour * 4380).Unix() // 6 month expiration for api key
-	}
+// GenerateToken outputs a session auth token string for an inputted User, which expires after an hour
+func (a *TokenService) GenerateToken(u *models.User) (string, error) {
+	expDT := time.Now().Add(time.Hour * 1).Unix()
 	tData, err := auth.InitUserToken(u)
 	if err != nil {
 		return "", err
@@ -86,10 +290,740 @@ func (a *TokenService) GenerateToken(u *models.User, tType string) (string, erro
 	return tData.CreateToken(expDT)
 }
 
+// GenerateRefreshToken outputs a new refresh token string, starting a new token family for an inputted User
+func (a *TokenService) GenerateRefreshToken(ctx context.Context, u *models.User) (string, error) {
+	rt, err := a.rService.RefreshTokenCreate(ctx, &models.RefreshToken{
+		UserId:    u.Id,
+		GroupId:   u.GroupId,
+		ExpiresAt: time.Now().UTC().Add(refreshTokenTTL()),
+	})
+	if err != nil {
+		return "", err
+	}
+	return rt.Token, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one and returns the User it was issued to,
+// acting as a member of the group the refresh token was issued for
+func (a *TokenService) RotateRefreshToken(ctx context.Context, refreshToken string) (*models.User, string, error) {
+	rt, err := a.rService.RefreshTokenRotate(ctx, refreshToken, time.Now().UTC().Add(refreshTokenTTL()))
+	if err != nil {
+		return nil, "", err
+	}
+	u, err := a.uService.UserFind(ctx, &models.User{Id: rt.UserId})
+	if err != nil {
+		return nil, "", err
+	}
+	if rt.CheckID("group_id") {
+		u, err = a.SessionUser(ctx, u, rt.GroupId)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return u, rt.Token, nil
+}
+
+// SwitchGroup returns an inputted User acting as a member of another group it belongs to
+func (a *TokenService) SwitchGroup(ctx context.Context, u *models.User, groupId string) (*models.User, error) {
+	user, err := a.uService.UserFind(ctx, &models.User{Id: u.Id})
+	if err != nil {
+		return nil, err
+	}
+	_, err = a.gService.GroupFind(ctx, &models.Group{Id: groupId})
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	return a.SessionUser(ctx, user, groupId)
+}
+
+// UserMemberships returns every group an inputted User belongs to, starting with its own group
+func (a *TokenService) UserMemberships(ctx context.Context, u *models.User) ([]*models.Membership, error) {
+	user, err := a.uService.UserFind(ctx, &models.User{Id: u.Id})
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := a.mService.MembershipsFind(ctx, &models.Membership{UserId: user.Id})
+	if err != nil {
+		return nil, err
+	}
+	return append([]*models.Membership{models.PrimaryMembership(user)}, memberships...), nil
+}
+
+// AddMember adds a User from another group to a group with a built-in or custom role of the group
+func (a *TokenService) AddMember(ctx context.Context, m *models.Membership) (*models.Membership, error) {
+	err := m.Validate("create")
+	if err != nil {
+		return nil, err
+	}
+	user, err := a.uService.UserFind(ctx, &models.User{Id: m.UserId})
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.GroupId == m.GroupId {
+		return nil, errors.New("user is already a member of the group")
+	}
+	_, err = a.gService.GroupFind(ctx, &models.Group{Id: m.GroupId})
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	if m.Role == "" {
+		m.Role = models.MemberRole
+	}
+	err = a.ValidateRole(ctx, m.GroupId, m.Role)
+	if err != nil {
+		return nil, err
+	}
+	return a.mService.MembershipCreate(ctx, m)
+}
+
+// RemoveMember removes a User from a group it is a member of, a User can not be removed from its own group
+func (a *TokenService) RemoveMember(ctx context.Context, m *models.Membership) (*models.Membership, error) {
+	return a.mService.MembershipDelete(ctx, m)
+}
+
+// RevokeRefreshToken is used to revoke a refresh token along with the rest of its token family
+func (a *TokenService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	return a.rService.RefreshTokenRevoke(ctx, refreshToken)
+}
+
+// CreateAPIKey issues a new named and scoped api key for an inputted User
+func (a *TokenService) CreateAPIKey(ctx context.Context, u *models.User, k *models.APIKey) (*models.APIKey, error) {
+	k.UserId = u.Id
+	k.GroupId = u.GroupId
+	return a.kService.APIKeyCreate(ctx, k)
+}
+
+// FindAPIKeys returns the active api keys of an inputted User
+func (a *TokenService) FindAPIKeys(ctx context.Context, u *models.User) ([]*models.APIKey, error) {
+	return a.kService.APIKeysFind(ctx, &models.APIKey{UserId: u.Id})
+}
+
+// RevokeAPIKey revokes one of the api keys of an inputted User
+func (a *TokenService) RevokeAPIKey(ctx context.Context, u *models.User, keyId string) (*models.APIKey, error) {
+	return a.kService.APIKeyDelete(ctx, &models.APIKey{Id: keyId, UserId: u.Id})
+}
+
+// GenerateChallengeToken outputs a short-lived token that an inputted User exchanges for a session by completing 2FA
+func (a *TokenService) GenerateChallengeToken(u *models.User) (string, error) {
+	return auth.CreateChallengeToken(u.Id, time.Now().Add(challengeTokenTTL).Unix())
+}
+
+// EnrollTwoFactor generates a new TOTP secret and recovery codes for an inputted User
+// The enrollment only takes effect once it is confirmed with a TOTP code through EnableTwoFactor
+func (a *TokenService) EnrollTwoFactor(ctx context.Context, u *models.User) (secret string, uri string, codes []string, err error) {
+	user, err := a.uService.UserFind(ctx, &models.User{Id: u.Id})
+	if err != nil {
+		return
+	}
+	if user.TwoFactor {
+		err = errors.New("two-factor authentication is already enabled")
+		return
+	}
+	secret, err = auth.GenerateTOTPSecret()
+	if err != nil {
+		return
+	}
+	codes, err = auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return
+	}
+	user.TOTPSecret = secret
+	user.TOTPStep = 0
+	user.Recovery = nil
+	for _, code := range codes {
+		user.Recovery = append(user.Recovery, models.HashToken(code))
+	}
+	_, err = a.uService.UserTwoFactorUpdate(ctx, user)
+	if err != nil {
+		return
+	}
+	uri = auth.TOTPProvisioningURI(totpIssuer(), user.Email, secret)
+	return
+}
+
+// EnableTwoFactor confirms the pending TOTP enrollment of an inputted User with a TOTP code
+func (a *TokenService) EnableTwoFactor(ctx context.Context, u *models.User, code string) (*models.User, error) {
+	user, err := a.uService.UserFind(ctx, &models.User{Id: u.Id})
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactor {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("two-factor authentication has not been enrolled")
+	}
+	user.TwoFactor = true
+	return user, a.verifySecondFactor(ctx, user, code, "")
+}
+
+// DisableTwoFactor removes the TOTP secret and recovery codes of an inputted User after checking a TOTP or recovery code
+func (a *TokenService) DisableTwoFactor(ctx context.Context, u *models.User, code string, recoveryCode string) (*models.User, error) {
+	user, err := a.uService.UserFind(ctx, &models.User{Id: u.Id})
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactor {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+	group, err := a.gService.GroupFind(ctx, &models.Group{Id: user.GroupId})
+	if err != nil {
+		return nil, err
+	}
+	if group.Require2FA {
+		return nil, errors.New("two-factor authentication is required by your group")
+	}
+	err = a.verifySecondFactor(ctx, user, code, recoveryCode)
+	if err != nil {
+		return nil, err
+	}
+	user.TwoFactor, user.TOTPSecret, user.TOTPStep, user.Recovery = false, "", 0, nil
+	return a.uService.UserTwoFactorUpdate(ctx, user)
+}
+
+// CompleteTwoFactor exchanges a challenge token along with a TOTP or recovery code for the User it was issued to
+// Wrong codes count as failed sign ins to the account, and the challenge token is invalidated after
+// maxChallengeFailures of them. The failed sign ins of the account are only cleared once a code is verified
+func (a *TokenService) CompleteTwoFactor(ctx context.Context, challengeToken string, code string, recoveryCode string, ip string) (*models.User, error) {
+	if a.bService.CheckTokenBlacklist(ctx, challengeToken) {
+		return nil, errors.New("challenge token is no longer valid, sign in again")
+	}
+	userId, err := auth.DecodeChallengeToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	user, err := a.uService.UserFind(ctx, &models.User{Id: userId})
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactor {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+	wait, err := a.LoginLockout(ctx, user.Email, ip)
+	if err != nil {
+		return nil, err
+	}
+	if wait > 0 {
+		return nil, ErrLoginLocked
+	}
+	if err = a.verifySecondFactor(ctx, user, code, recoveryCode); err != nil {
+		if fErr := a.recordChallengeFailure(ctx, challengeToken, user.Email, ip); fErr != nil {
+			return nil, fErr
+		}
+		return nil, err
+	}
+	return user, a.RecordLoginSuccess(ctx, user)
+}
+
+// recordChallengeFailure records a wrong 2FA code as a failed sign in to an account, and blacklists the challenge
+// token once it reaches maxChallengeFailures
+func (a *TokenService) recordChallengeFailure(ctx context.Context, challengeToken string, email string, ip string) error {
+	if err := a.RecordLoginFailure(ctx, email, ip); err != nil {
+		return err
+	}
+	attempt, err := a.lService.LoginAttemptFail(ctx, challengeLoginKey(challengeToken), &models.LockoutPolicy{
+		MaxAttempts: maxChallengeFailures,
+		Lockout:     challengeTokenTTL,
+		MaxLockout:  challengeTokenTTL,
+	})
+	if err != nil {
+		return err
+	}
+	if attempt.Failures < maxChallengeFailures {
+		return nil
+	}
+	return a.bService.BlacklistAuthToken(ctx, challengeToken)
+}
+
+// verifySecondFactor checks a TOTP code, or a recovery code when one is given, and records that it was used
+func (a *TokenService) verifySecondFactor(ctx context.Context, user *models.User, code string, recoveryCode string) error {
+	if recoveryCode != "" {
+		if !user.UseRecoveryCode(recoveryCode) {
+			return errors.New("invalid recovery code")
+		}
+	} else {
+		step, ok := auth.VerifyTOTP(user.TOTPSecret, code, time.Now(), user.TOTPStep)
+		if !ok {
+			return errors.New("invalid two-factor code")
+		}
+		user.TOTPStep = step
+	}
+	_, err := a.uService.UserTwoFactorUpdate(ctx, user)
+	return err
+}
+
+// issueUserToken creates a new single use token for an inputted User and emails it as a link to the User
+func (a *TokenService) issueUserToken(ctx context.Context, u *models.User, purpose string, ttl time.Duration, subject string, path string, text string) error {
+	ut, err := a.tService.UserTokenCreate(ctx, &models.UserToken{
+		UserId:    u.Id,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	})
+	if err != nil {
+		return err
+	}
+	link := appURL() + path + "?token=" + url.QueryEscape(ut.Token)
+	return a.mailer.Send(u.Email, subject, text+"\n\n"+link+"\n\nThis link expires in "+ttl.String()+".\n")
+}
+
+// ForgotPassword emails a password reset link to the User with an inputted email address
+// No error is returned when there is no such User so that callers can not use it to discover accounts
+func (a *TokenService) ForgotPassword(ctx context.Context, email string) error {
+	if email == "" {
+		return errors.New("missing email")
+	}
+	user, err := a.uService.UserFind(ctx, &models.User{Email: email})
+	if err != nil {
+		return nil
+	}
+	return a.issueUserToken(ctx, user, models.PasswordResetToken, passwordResetTTL, "Reset your password", "/reset-password",
+		"A password reset was requested for your account. Use the link below to choose a new password, or ignore this email if it was not you.")
+}
+
+// ResetPassword redeems a password reset token and sets the new password of the User it was issued to
+func (a *TokenService) ResetPassword(ctx context.Context, token string, newPassword string) (*models.User, error) {
+	if newPassword == "" {
+		return nil, errors.New("missing new password")
+	}
+	ut, err := a.tService.UserTokenRedeem(ctx, token, models.PasswordResetToken)
+	if err != nil {
+		return nil, err
+	}
+	return a.uService.UserResetPassword(ctx, &models.User{Id: ut.UserId}, newPassword)
+}
+
+// SendEmailVerification emails an email verification link to an inputted User
+func (a *TokenService) SendEmailVerification(ctx context.Context, u *models.User) error {
+	user, err := a.uService.UserFind(ctx, &models.User{Id: u.Id})
+	if err != nil {
+		return err
+	}
+	if !user.VerifiedAt.IsZero() {
+		return errors.New("email is already verified")
+	}
+	return a.issueUserToken(ctx, user, models.EmailVerificationToken, emailVerificationTTL, "Verify your email", "/verify-email",
+		"Use the link below to verify your email address.")
+}
+
+// VerifyEmail redeems an email verification token and marks the email of the User it was issued to as verified
+func (a *TokenService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	ut, err := a.tService.UserTokenRedeem(ctx, token, models.EmailVerificationToken)
+	if err != nil {
+		return nil, err
+	}
+	return a.uService.UserVerifyEmail(ctx, &models.User{Id: ut.UserId})
+}
+
+// StartOIDCSignIn returns the url of an identity provider that a User signs in at, along with the state token that
+// the callback of the sign in must be made with
+func (a *TokenService) StartOIDCSignIn(provider string) (authURL string, stateToken string, err error) {
+	p, err := auth.GetOIDCProvider(provider)
+	if err != nil {
+		return "", "", err
+	}
+	state, verifier, nonce, err := auth.NewOIDCState()
+	if err != nil {
+		return "", "", err
+	}
+	authURL, err = p.AuthCodeURL(state, verifier, nonce)
+	if err != nil {
+		return "", "", err
+	}
+	stateToken, err = auth.CreateOIDCStateToken(p.Name, state, verifier, nonce, time.Now().Add(oidcStateTTL).Unix())
+	return authURL, stateToken, err
+}
+
+// CompleteOIDCSignIn exchanges the authorization code of an identity provider callback for the User it signed in
+// The identity is linked to the User with its verified email address the first time it is used, and when no User has the
+// email address a new User is provisioned into the group the provider maps the identity to
+func (a *TokenService) CompleteOIDCSignIn(ctx context.Context, provider string, state string, stateToken string, code string) (*models.User, error) {
+	p, err := auth.GetOIDCProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	verifier, nonce, err := auth.DecodeOIDCStateToken(stateToken, p.Name, state)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := p.Exchange(code, verifier, nonce)
+	if err != nil {
+		return nil, err
+	}
+	linked, err := a.idService.IdentityFind(ctx, &models.Identity{Provider: p.Name, Subject: identity.Subject})
+	if err == nil {
+		return a.uService.UserFind(ctx, &models.User{Id: linked.UserId})
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errors.New("the identity provider did not return a verified email address")
+	}
+	user, err := a.uService.UserFind(ctx, &models.User{Email: identity.Email})
+	if err != nil {
+		user, err = a.provisionOIDCUser(ctx, p, identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = a.idService.IdentityCreate(ctx, &models.Identity{
+		UserId:   user.Id,
+		Provider: p.Name,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// provisionOIDCUser creates a new User with the verified email address of an identity provider account
+// The User is given a random password, a local password can be set later on with a password reset
+func (a *TokenService) provisionOIDCUser(ctx context.Context, p *auth.OIDCProvider, identity *auth.OIDCIdentity) (*models.User, error) {
+	groupId := p.MapGroup(identity)
+	if groupId == "" {
+		return nil, errors.New("no account exists for " + identity.Email)
+	}
+	if _, err := a.gService.GroupFind(ctx, &models.Group{Id: groupId}); err != nil {
+		return nil, errors.New("group not found")
+	}
+	role := p.Role
+	if role == "" {
+		role = models.MemberRole
+	}
+	err := a.ValidateRole(ctx, groupId, role)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{
+		Username:  identity.Username,
+		FirstName: identity.FirstName,
+		LastName:  identity.LastName,
+		Email:     identity.Email,
+		GroupId:   groupId,
+		Role:      role,
+	}
+	if user.Username == "" {
+		user.Username = identity.Email
+	}
+	err = user.SetRandomPassword()
+	if err != nil {
+		return nil, err
+	}
+	user, err = a.uService.UserCreate(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return a.uService.UserVerifyEmail(ctx, user)
+}
+
+// sendInvitation emails an invitation link to the email address an inputted Invitation was issued to
+func (a *TokenService) sendInvitation(ctx context.Context, i *models.Invitation) error {
+	g, err := a.gService.GroupFind(ctx, &models.Group{Id: i.GroupId})
+	if err != nil {
+		return errors.New("group not found")
+	}
+	link := appURL() + "/accept-invite?token=" + url.QueryEscape(i.Token)
+	return a.mailer.Send(i.Email, "You have been invited to join "+g.Name,
+		"You have been invited to join "+g.Name+" as "+i.Role+". Use the link below to accept the invitation.\n\n"+link+
+			"\n\nThis invitation expires on "+i.ExpiresAt.Format(time.RFC1123)+".\n")
+}
+
+// CreateInvitation issues a new invitation to join a group on behalf of an inputted inviter and emails it
+// The invitation expires after a week unless an earlier or later expiration of up to 30 days is set
+func (a *TokenService) CreateInvitation(ctx context.Context, inviter *models.User, i *models.Invitation) (*models.Invitation, error) {
+	now := time.Now().UTC()
+	if i.ExpiresAt.IsZero() {
+		i.ExpiresAt = now.Add(invitationTTL)
+	} else if i.ExpiresAt.After(now.Add(maxInvitationTTL)) {
+		return nil, errors.New("invitation can not be valid for more than " + maxInvitationTTL.String())
+	}
+	if i.Role == "" {
+		i.Role = models.MemberRole
+	}
+	err := a.ValidateRole(ctx, i.GroupId, i.Role)
+	if err != nil {
+		return nil, err
+	}
+	i.Id = ""
+	i.InvitedBy = inviter.Id
+	i.AcceptedAt = time.Time{}
+	invitation, err := a.iService.InvitationCreate(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	err = a.sendInvitation(ctx, invitation)
+	if err != nil {
+		return nil, err
+	}
+	invitation.Token = ""
+	return invitation, nil
+}
+
+// ResendInvitation replaces the token of a pending invitation, resets its expiration and emails it again
+func (a *TokenService) ResendInvitation(ctx context.Context, i *models.Invitation) (*models.Invitation, error) {
+	invitation, err := a.iService.InvitationRenew(ctx, i, time.Now().UTC().Add(invitationTTL))
+	if err != nil {
+		return nil, err
+	}
+	err = a.sendInvitation(ctx, invitation)
+	if err != nil {
+		return nil, err
+	}
+	invitation.Token = ""
+	return invitation, nil
+}
+
+// RevokeInvitation revokes a pending invitation so it can no longer be accepted
+func (a *TokenService) RevokeInvitation(ctx context.Context, i *models.Invitation) (*models.Invitation, error) {
+	return a.iService.InvitationRevoke(ctx, i)
+}
+
+// AcceptInvitation redeems an invitation token, a new User is created from the inputted User when no account has the
+// email address the invitation was issued to, otherwise the existing User must sign in with its password and is added
+// to the group. The returned bool is true when a new User was created
+// The invitation is only redeemed once the User or membership is created, which is undone when the redeem fails.
+// Signing in with the password of an existing User counts towards the same lockout as the sign in route
+func (a *TokenService) AcceptInvitation(ctx context.Context, token string, u *models.User, ip string) (*models.User, bool, error) {
+	if token == "" {
+		return nil, false, errors.New("missing invitation token")
+	}
+	i, err := a.iService.InvitationFind(ctx, &models.Invitation{Token: token})
+	if err != nil {
+		return nil, false, err
+	}
+	err = i.Validate("accept")
+	if err != nil {
+		return nil, false, err
+	}
+	err = a.ValidateRole(ctx, i.GroupId, i.Role)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err = a.uService.UserFind(ctx, &models.User{Email: i.Email}); err != nil {
+		user := &models.User{
+			Username:  u.Username,
+			Password:  u.Password,
+			FirstName: u.FirstName,
+			LastName:  u.LastName,
+			Email:     i.Email,
+			GroupId:   i.GroupId,
+			Role:      i.Role,
+		}
+		err = user.Validate("create")
+		if err != nil {
+			return nil, false, err
+		}
+		user, err = a.uService.UserCreate(ctx, user)
+		if err != nil {
+			return nil, false, err
+		}
+		if _, err = a.iService.InvitationRedeem(ctx, token); err != nil {
+			// the User is purged rather than soft deleted so that it can neither be restored nor block a new sign up
+			if _, dErr := a.uService.UserPurge(ctx, &models.User{Id: user.Id}); dErr != nil {
+				return nil, false, dErr
+			}
+			return nil, false, err
+		}
+		user, err = a.uService.UserVerifyEmail(ctx, user) // the invitation link proves the email address belongs to the User
+		return user, true, err
+	}
+	if u.Password == "" {
+		return nil, false, errors.New("missing user password")
+	}
+	wait, err := a.LoginLockout(ctx, i.Email, ip)
+	if err != nil {
+		return nil, false, err
+	}
+	if wait > 0 {
+		return nil, false, ErrLoginLocked
+	}
+	user, err := a.uService.AuthenticateUser(ctx, &models.User{Email: i.Email, Password: u.Password})
+	if err != nil {
+		if lErr := a.RecordLoginFailure(ctx, i.Email, ip); lErr != nil {
+			return nil, false, lErr
+		}
+		return nil, false, err
+	}
+	if err = a.RecordLoginSuccess(ctx, user); err != nil {
+		return nil, false, err
+	}
+	if user.GroupId == i.GroupId {
+		return nil, false, errors.New("user is already a member of the group")
+	}
+	if _, err = a.mService.MembershipFind(ctx, &models.Membership{UserId: user.Id, GroupId: i.GroupId}); err == nil {
+		return nil, false, errors.New("user is already a member of the group")
+	}
+	m, err := a.mService.MembershipCreate(ctx, &models.Membership{UserId: user.Id, GroupId: i.GroupId, Role: i.Role})
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err = a.iService.InvitationRedeem(ctx, token); err != nil {
+		if _, dErr := a.mService.MembershipDelete(ctx, m); dErr != nil {
+			return nil, false, dErr
+		}
+		return nil, false, err
+	}
+	user, err = a.SessionUser(ctx, user, i.GroupId)
+	return user, false, err
+}
+
+// LoginLockout returns how much longer sign ins to an account or from a client ip address are locked out for
+// An error is returned when the failed sign ins can not be looked up, so sign ins fail closed
+func (a *TokenService) LoginLockout(ctx context.Context, email string, ip string) (time.Duration, error) {
+	keys := []string{accountLoginKey(email)}
+	if ip != "" {
+		keys = append(keys, ipLoginKey(ip))
+	}
+	var wait time.Duration
+	now := time.Now().UTC()
+	for _, key := range keys {
+		attempt, err := a.lService.LoginAttemptFind(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if attempt.Locked(now) > wait {
+			wait = attempt.Locked(now)
+		}
+	}
+	return wait, nil
+}
+
+// RecordLoginFailure records a failed sign in to an account from a client ip address
+// When the account gets locked out, the lockout is also stored on the User
+func (a *TokenService) RecordLoginFailure(ctx context.Context, email string, ip string) error {
+	policy := loginLockoutPolicy()
+	attempt, err := a.lService.LoginAttemptFail(ctx, accountLoginKey(email), policy)
+	if err != nil {
+		return err
+	}
+	if attempt.Locked(time.Now().UTC()) > 0 {
+		if user, err := a.uService.UserFind(ctx, &models.User{Email: email}); err == nil {
+			user.LockedUntil = attempt.LockedUntil
+			if _, err = a.uService.UserLockUpdate(ctx, user); err != nil {
+				return err
+			}
+		}
+	}
+	if ip == "" {
+		return nil
+	}
+	ipPolicy := *policy
+	ipPolicy.MaxAttempts *= ipAttemptFactor
+	_, err = a.lService.LoginAttemptFail(ctx, ipLoginKey(ip), &ipPolicy)
+	return err
+}
+
+// RecordLoginSuccess clears the failed sign ins to the account of an inputted User after it signs in
+// The failed sign ins of the client ip address are kept, so signing into one account does not lift an ip lockout
+func (a *TokenService) RecordLoginSuccess(ctx context.Context, u *models.User) error {
+	err := a.lService.LoginAttemptReset(ctx, accountLoginKey(u.Email))
+	if err != nil || u.LockedUntil.IsZero() {
+		return err
+	}
+	u.LockedUntil = time.Time{}
+	_, err = a.uService.UserLockUpdate(ctx, u)
+	return err
+}
+
+// UnlockUser lifts the lockout of an inputted User and clears its failed sign ins
+func (a *TokenService) UnlockUser(ctx context.Context, u *models.User) (*models.User, error) {
+	user, err := a.uService.UserFind(ctx, &models.User{Id: u.Id})
+	if err != nil {
+		return nil, err
+	}
+	err = a.lService.LoginAttemptReset(ctx, accountLoginKey(user.Email))
+	if err != nil {
+		return nil, err
+	}
+	user.LockedUntil = time.Time{}
+	return a.uService.UserLockUpdate(ctx, user)
+}
+
+// ValidateRole checks that a role name can be assigned to the users of a group, it must be built-in or a role of the group
+func (a *TokenService) ValidateRole(ctx context.Context, groupId string, name string) error {
+	if models.IsBuiltInRole(name) {
+		return nil
+	}
+	_, err := a.roService.RoleFind(ctx, &models.Role{GroupId: groupId, Name: name})
+	if err != nil {
+		return errors.New("role not found: " + name)
+	}
+	return nil
+}
+
+// CanGrantRole checks that a User can assign a role to the users of its group, only root admins can grant the admin
+// role and other users can only grant custom roles whose permissions they are granted themselves
+// Role names that are not found are left for ValidateRole to reject
+func (a *TokenService) CanGrantRole(ctx context.Context, granter *models.User, name string) error {
+	if granter.RootAdmin || name == "" || name == models.MemberRole {
+		return nil
+	}
+	if name == models.AdminRole {
+		return errors.New("only root admins can grant the admin role")
+	}
+	role, err := a.roService.RoleFind(ctx, &models.Role{GroupId: granter.GroupId, Name: name})
+	if err != nil {
+		return nil
+	}
+	return CanGrantPermissions(granter, role.Permissions)
+}
+
+// CanGrantPermissions checks that a User is granted every permission it grants to a role
+func CanGrantPermissions(granter *models.User, permissions []string) error {
+	for _, permission := range permissions {
+		if !granter.HasPermission(permission) {
+			return errors.New("can not grant the " + permission + " permission without having it")
+		}
+	}
+	return nil
+}
+
+// AssignRole assigns a built-in or custom role of a group to an inputted User, the User's role in its own group is
+// assigned unless the inputted User's GroupId is a group the User is only a member of
+// The User is returned as it was before and after the role was assigned
+func (a *TokenService) AssignRole(ctx context.Context, u *models.User, name string) (*models.User, *models.User, error) {
+	user, err := a.uService.UserFind(ctx, &models.User{Id: u.Id})
+	if err != nil {
+		return nil, nil, errors.New("user not found")
+	}
+	if u.GroupId != "" && user.GroupId != u.GroupId {
+		m, err := a.mService.MembershipFind(ctx, &models.Membership{UserId: user.Id, GroupId: u.GroupId})
+		if err != nil {
+			return nil, nil, errors.New("user not found")
+		}
+		err = a.ValidateRole(ctx, m.GroupId, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		before, err := a.SessionUser(ctx, user, m.GroupId)
+		if err != nil {
+			return nil, nil, err
+		}
+		_, err = a.mService.MembershipUpdate(ctx, &models.Membership{Id: m.Id, Role: name})
+		if err != nil {
+			return nil, nil, err
+		}
+		after := *before
+		after.Role = name
+		return before, &after, nil
+	}
+	if user.RootAdmin {
+		return nil, nil, errors.New("the role of a root admin can not be changed")
+	}
+	err = a.ValidateRole(ctx, user.GroupId, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err := a.uService.UserUpdate(ctx, &models.User{Id: user.Id, Role: name})
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, after, nil
+}
+
 // RootAdminTokenVerifyMiddleWare is used to verify that the requester is a valid admin
 func (a *TokenService) RootAdminTokenVerifyMiddleWare(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		a.tokenVerifyMiddleWare("Root", next, w, r)
+		a.tokenVerifyMiddleWare("Root", "", next, w, r)
 		return
 	}
 }
@@ -97,7 +1031,7 @@ func (a *TokenService) RootAdminTokenVerifyMiddleWare(next http.HandlerFunc) htt
 // AdminTokenVerifyMiddleWare is used to verify that the requester is a valid admin
 func (a *TokenService) AdminTokenVerifyMiddleWare(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		a.tokenVerifyMiddleWare("Admin", next, w, r)
+		a.tokenVerifyMiddleWare("Admin", "", next, w, r)
 		return
 	}
 }
@@ -105,12 +1039,20 @@ func (a *TokenService) AdminTokenVerifyMiddleWare(next http.HandlerFunc) http.Ha
 // MemberTokenVerifyMiddleWare is used to verify that a requester is authenticated
 func (a *TokenService) MemberTokenVerifyMiddleWare(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		a.tokenVerifyMiddleWare("Member", next, w, r)
+		a.tokenVerifyMiddleWare("Member", "", next, w, r)
+		return
+	}
+}
+
+// RequirePermission is used to verify that a requester is authenticated and that its role grants a permission
+func (a *TokenService) RequirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.tokenVerifyMiddleWare("Member", permission, next, w, r)
 		return
 	}
 }
 
 // BlacklistAuthToken is used to blacklist an unexpired token
-func (a *TokenService) BlacklistAuthToken(authToken string) error {
-	return a.bService.BlacklistAuthToken(authToken)
+func (a *TokenService) BlacklistAuthToken(ctx context.Context, authToken string) error {
+	return a.bService.BlacklistAuthToken(ctx, authToken)
 }
@@ -1,18 +1,29 @@
 package services
 
 import (
+	"context"
 	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
 )
 
 // UserService is an interface used to manage the relevant user doc controllers
 type UserService interface {
-	AuthenticateUser(u *models.User) (*models.User, error)
-	UpdatePassword(u *models.User, CurrentPassword string, newPassword string) (*models.User, error)
-	UserCreate(u *models.User) (*models.User, error)
-	UserDelete(u *models.User) (*models.User, error)
-	UserDeleteMany(u *models.User) (*models.User, error)
-	UsersFind(u *models.User) ([]*models.User, error)
-	UserFind(u *models.User) (*models.User, error)
-	UserUpdate(u *models.User) (*models.User, error)
-	UserDocInsert(u *models.User) (*models.User, error)
+	AuthenticateUser(ctx context.Context, u *models.User) (*models.User, error)
+	UpdatePassword(ctx context.Context, u *models.User, CurrentPassword string, newPassword string) (*models.User, error)
+	UserCreate(ctx context.Context, u *models.User) (*models.User, error)
+	UserDelete(ctx context.Context, u *models.User) (*models.User, error)
+	UserDeleteMany(ctx context.Context, u *models.User) (*models.User, error)
+	UserPurge(ctx context.Context, u *models.User) (*models.User, error)
+	UserRestore(ctx context.Context, u *models.User) (*models.User, error)
+	UserRestoreMany(ctx context.Context, u *models.User, since time.Time) error
+	UsersPurge(ctx context.Context, before time.Time) (int64, error)
+	UsersFind(ctx context.Context, u *models.User) ([]*models.User, error)
+	UsersFindPage(ctx context.Context, u *models.User, o *models.ListOptions) ([]*models.User, int64, error)
+	UserFind(ctx context.Context, u *models.User) (*models.User, error)
+	UserUpdate(ctx context.Context, u *models.User) (*models.User, error)
+	UserTwoFactorUpdate(ctx context.Context, u *models.User) (*models.User, error)
+	UserResetPassword(ctx context.Context, u *models.User, newPassword string) (*models.User, error)
+	UserVerifyEmail(ctx context.Context, u *models.User) (*models.User, error)
+	UserLockUpdate(ctx context.Context, u *models.User) (*models.User, error)
+	UserDocInsert(ctx context.Context, u *models.User) (*models.User, error)
 }
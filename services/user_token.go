@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+)
+
+// UserTokenService is an interface used to manage the relevant user token doc controllers
+type UserTokenService interface {
+	UserTokenCreate(ctx context.Context, t *models.UserToken) (*models.UserToken, error)
+	UserTokenRedeem(ctx context.Context, token string, purpose string) (*models.UserToken, error)
+}
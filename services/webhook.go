@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"time"
+)
+
+// WebhookService is an interface used to manage the relevant webhook and webhook delivery doc controllers
+type WebhookService interface {
+	WebhookCreate(ctx context.Context, w *models.Webhook) (*models.Webhook, error)
+	WebhooksFind(ctx context.Context, w *models.Webhook) ([]*models.Webhook, error)
+	WebhookFind(ctx context.Context, w *models.Webhook) (*models.Webhook, error)
+	WebhookUpdate(ctx context.Context, w *models.Webhook) (*models.Webhook, error)
+	WebhookDelete(ctx context.Context, w *models.Webhook) (*models.Webhook, error)
+	WebhookEnqueue(ctx context.Context, e *models.Event) ([]*models.WebhookDelivery, error)
+	WebhookDeliveriesFindPage(ctx context.Context, d *models.WebhookDelivery, o *models.ListOptions) ([]*models.WebhookDelivery, int64, error)
+	WebhookDeliveriesDue(ctx context.Context, now time.Time) ([]*models.WebhookDelivery, error)
+	WebhookDeliveryClaim(ctx context.Context, d *models.WebhookDelivery, now time.Time) (*models.Webhook, bool, error)
+	WebhookDeliveryRecord(ctx context.Context, d *models.WebhookDelivery) (*models.WebhookDelivery, error)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"github.com/JECSand/go-rest-api-boilerplate/models"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// defaultWebhookInterval is how often the WebhookDispatcher runs when WEBHOOK_INTERVAL is not configured
+const defaultWebhookInterval = 10 * time.Second
+
+// WebhookInterval returns how often the WebhookDispatcher looks for due webhook deliveries
+func WebhookInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("WEBHOOK_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return defaultWebhookInterval
+	}
+	return interval
+}
+
+// NewWebhookClient returns the http.Client webhook deliveries are sent with. It only connects to public addresses,
+// which is checked against the address every connection is made to so that host names resolving to a local address are
+// refused as well, and it does not follow redirects, which could otherwise point a delivery at a local address
+func NewWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: models.WebhookTimeout,
+		Control: func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return models.ErrWebhookAddress
+			}
+			return models.CheckWebhookIP(ip)
+		},
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: models.WebhookTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Timeout: models.WebhookTimeout,
+	}
+}
+
+// WebhookDispatcher POSTs the due webhook deliveries to their endpoints. Every API replica can run one, each attempt
+// of a delivery is claimed by exactly one dispatcher
+type WebhookDispatcher struct {
+	whService WebhookService
+	client    *http.Client
+	clock     Clock
+	interval  time.Duration
+}
+
+// NewWebhookDispatcher is an exported function used to initialize a new WebhookDispatcher struct
+func NewWebhookDispatcher(whService WebhookService, client *http.Client, clock Clock, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{whService, client, clock, interval}
+}
+
+// post sends the payload of a delivery to a Webhook, signed with the webhook's secret, and returns the response status
+func (d *WebhookDispatcher) post(ctx context.Context, w *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-rest-api-boilerplate-webhooks")
+	req.Header.Set("X-Webhook-Id", delivery.Id)
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", "sha256="+models.SignWebhookPayload(w.Secret, timestamp, delivery.Payload))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1048576))
+	return resp.StatusCode, nil
+}
+
+// Run attempts every due webhook delivery, it returns how many it attempted
+func (d *WebhookDispatcher) Run(ctx context.Context) (int, error) {
+	now := d.clock.Now()
+	deliveries, err := d.whService.WebhookDeliveriesDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	var runErr error
+	attempted := 0
+	for _, delivery := range deliveries {
+		w, claimed, err := d.whService.WebhookDeliveryClaim(ctx, delivery, now)
+		if err != nil {
+			runErr = err
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		status, postErr := d.post(ctx, w, delivery, now)
+		delivery.RecordAttempt(status, postErr, d.clock.Now())
+		if _, err = d.whService.WebhookDeliveryRecord(ctx, delivery); err != nil {
+			runErr = err
+		}
+		attempted++
+	}
+	return attempted, runErr
+}
+
+// Start runs the WebhookDispatcher every interval until ctx is done, which also cancels a run in progress
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.Run(ctx); err != nil {
+				log.Println("webhook dispatcher error:", err)
+			}
+		}
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 )
 
 // JsonErr structures a standard error to return
@@ -31,12 +34,41 @@ func CheckObjectID(hexID string) bool {
 	return true
 }
 
+// AllowedOrigins returns the browser origins allowed to call the API, configured as a comma separated CORS_ORIGINS
+// list where "*" allows every origin. The origin of APP_URL is allowed when none are configured
+func AllowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("CORS_ORIGINS"), ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		if u, err := url.Parse(os.Getenv("APP_URL")); err == nil && u.Scheme != "" && u.Host != "" {
+			origins = append(origins, u.Scheme+"://"+u.Host)
+		}
+	}
+	return origins
+}
+
+// CheckOrigin determines whether a browser origin is one of the AllowedOrigins
+func CheckOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	for _, allowed := range AllowedOrigins() {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
 // HandleOptionsRequest handles incoming OPTIONS request
 func HandleOptionsRequest(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Auth-Token, API-Key")
-	w.Header().Add("Access-Control-Expose-Headers", "Content-Type, Auth-Token, API-Key")
-	w.Header().Add("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Auth-Token, API-Key, Refresh-Token")
+	w.Header().Add("Access-Control-Expose-Headers", "Content-Type, Auth-Token, API-Key, Refresh-Token")
 	w.Header().Add("Access-Control-Allow-Methods", "GET,DELETE,POST,PATCH")
 	w.WriteHeader(http.StatusOK)
 }
@@ -44,9 +76,8 @@ func HandleOptionsRequest(w http.ResponseWriter, r *http.Request) {
 // SetResponseHeaders sets the response headers being sent back to the client
 func SetResponseHeaders(w http.ResponseWriter, authToken string, apiKey string) http.ResponseWriter {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Auth-Token, API-Key")
-	w.Header().Add("Access-Control-Expose-Headers", "Content-Type, Auth-Token, API-Key")
-	w.Header().Add("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Auth-Token, API-Key, Refresh-Token")
+	w.Header().Add("Access-Control-Expose-Headers", "Content-Type, Auth-Token, API-Key, Refresh-Token")
 	w.Header().Add("Access-Control-Allow-Methods", "GET,DELETE,POST,PATCH")
 	if authToken != "" {
 		w.Header().Add("Auth-Token", authToken)
@@ -62,7 +93,6 @@ func RespondWithError(w http.ResponseWriter, status int, error JWTError) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Auth-Token")
 	w.Header().Add("Access-Control-Expose-Headers", "Content-Type, Auth-Token")
-	w.Header().Add("Access-Control-Allow-Origin", "*")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(error); err != nil {
 		panic(err)